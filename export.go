@@ -0,0 +1,46 @@
+package main
+
+import (
+	"abt-analytics-dashboard/internal/processor"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// exportSubcommand is accepted in place of the -export flag, as in
+// `main export --data sales.csv --out dashboard.json`
+const exportSubcommand = "export"
+
+// runExport processes the dataset at dataPath with p and writes the
+// resulting dashboard data, processing report included, to outPath as
+// JSON. The output is written to a temporary file that replaces outPath
+// only once complete, so a failed export never leaves a partial file.
+func runExport(p *processor.Processor, dataPath, outPath string, pretty bool) error {
+	if err := p.ProcessDataset(dataPath); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(outPath), filepath.Base(outPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	encoder := json.NewEncoder(tmp)
+	if pretty {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(p.GetDashboardData()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), outPath); err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"abt-analytics-dashboard/internal/processor"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const exportFixture = `transaction_id,transaction_date,country,region,product_name,quantity,total_price
+TXN001,2024-01-15,Germany,Europe,Laptop,1,1200
+TXN002,2024-01-20,France,Europe,Mouse,2,40
+TXN003,2024-02-03,Japan,Asia,Camera,1,500
+`
+
+func TestRunExport(t *testing.T) {
+	dir := t.TempDir()
+	dataPath := filepath.Join(dir, "sales.csv")
+	if err := os.WriteFile(dataPath, []byte(exportFixture), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+	outPath := filepath.Join(dir, "dashboard.json")
+
+	if err := runExport(processor.New(), dataPath, outPath, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	content, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("Expected the export file, got %v", err)
+	}
+	var data models.DashboardData
+	if err := json.Unmarshal(content, &data); err != nil {
+		t.Fatalf("Failed to decode export: %v", err)
+	}
+	if data.Report.Rows != 3 {
+		t.Errorf("Expected a processing report of 3 rows, got %d", data.Report.Rows)
+	}
+	if len(data.TopRegions) != 2 || data.TopRegions[0].Region != "Europe" || data.TopRegions[0].TotalRevenue != 1240 {
+		t.Errorf("Expected Europe to lead with 1240, got %+v", data.TopRegions)
+	}
+	if len(data.MonthlySales) != 2 {
+		t.Errorf("Expected 2 months of sales, got %d", len(data.MonthlySales))
+	}
+	if bytes.Contains(content, []byte("\n  ")) {
+		t.Error("Expected compact JSON without --pretty")
+	}
+
+	if err := runExport(processor.New(), dataPath, outPath, true); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	pretty, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("Expected the export file, got %v", err)
+	}
+	if !bytes.Contains(pretty, []byte("\n  \"")) {
+		t.Error("Expected indented JSON with --pretty")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to list output directory: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected only the dataset and the export, got %d entries", len(entries))
+	}
+}
+
+func TestRunExportFailureKeepsExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "dashboard.json")
+	if err := os.WriteFile(outPath, []byte("previous"), 0644); err != nil {
+		t.Fatalf("Failed to write previous export: %v", err)
+	}
+
+	if err := runExport(processor.New(), filepath.Join(dir, "missing.csv"), outPath, false); err == nil {
+		t.Fatal("Expected an error for a missing dataset")
+	}
+	if content, _ := os.ReadFile(outPath); string(content) != "previous" {
+		t.Errorf("Expected the previous export to be kept, got %q", content)
+	}
+
+	dataPath := filepath.Join(dir, "sales.csv")
+	if err := os.WriteFile(dataPath, []byte(exportFixture), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+	if err := runExport(processor.New(), dataPath, filepath.Join(dir, "missing", "out.json"), false); err == nil {
+		t.Error("Expected an error for an unwritable output path")
+	}
+}
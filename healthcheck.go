@@ -0,0 +1,80 @@
+package main
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// healthCheckTimeout bounds the whole health probe, connection included
+const healthCheckTimeout = 2 * time.Second
+
+// healthCheckSubcommand is accepted in place of the -healthcheck flag, as
+// in `main healthcheck`
+const healthCheckSubcommand = "healthcheck"
+
+// healthCheckTarget returns the health endpoint URL of the server that cfg
+// describes and a client that reaches it. A server listening on every
+// interface is probed over loopback, and a Unix socket server through its
+// socket.
+func healthCheckTarget(cfg *config.Config) (string, *http.Client, error) {
+	client := &http.Client{Timeout: healthCheckTimeout}
+
+	network, address := cfg.ListenAddress()
+	if network == "unix" {
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", address)
+			},
+		}
+		return "http://unix/api/health", client, nil
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || port == "" {
+		return "", nil, fmt.Errorf("no port configured (set PORT or -port)")
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/api/health", client, nil
+}
+
+// checkHealth requests url and returns the response status, failing unless
+// it is 200 OK
+func checkHealth(client *http.Client, url string) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.Status, fmt.Errorf("unhealthy: %s", resp.Status)
+	}
+	return resp.Status, nil
+}
+
+// runHealthCheck probes the server configured by cfg, prints the outcome to
+// w and returns the process exit code: 0 when healthy and 1 otherwise
+func runHealthCheck(cfg *config.Config, w io.Writer) int {
+	url, client, err := healthCheckTarget(cfg)
+	if err != nil {
+		fmt.Fprintf(w, "health check failed: %v\n", err)
+		return 1
+	}
+
+	status, err := checkHealth(client, url)
+	if err != nil {
+		fmt.Fprintf(w, "health check of %s failed: %v\n", url, err)
+		return 1
+	}
+	fmt.Fprintf(w, "healthy: %s %s\n", url, status)
+	return 0
+}
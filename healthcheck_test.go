@@ -0,0 +1,110 @@
+package main
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// healthServer serves /api/health with status
+func healthServer(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/health" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// portConfig returns a config listening on the port of server
+func portConfig(t *testing.T, server *httptest.Server) *config.Config {
+	t.Helper()
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to parse server address: %v", err)
+	}
+	return &config.Config{Port: ":" + port}
+}
+
+func TestRunHealthCheckHealthy(t *testing.T) {
+	server := healthServer(t, http.StatusOK)
+
+	var out bytes.Buffer
+	if code := runHealthCheck(portConfig(t, server), &out); code != 0 {
+		t.Errorf("Expected exit code 0, got %d: %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "healthy") || !strings.Contains(out.String(), "200 OK") {
+		t.Errorf("Expected the status to be printed, got %q", out.String())
+	}
+}
+
+func TestRunHealthCheckFailing(t *testing.T) {
+	unhealthy := healthServer(t, http.StatusServiceUnavailable)
+	var out bytes.Buffer
+	if code := runHealthCheck(portConfig(t, unhealthy), &out); code != 1 {
+		t.Errorf("Expected exit code 1 for a 503, got %d", code)
+	}
+	if !strings.Contains(out.String(), "503") {
+		t.Errorf("Expected the 503 status to be printed, got %q", out.String())
+	}
+
+	stopped := healthServer(t, http.StatusOK)
+	cfg := portConfig(t, stopped)
+	stopped.Close()
+	out.Reset()
+	if code := runHealthCheck(cfg, &out); code != 1 {
+		t.Errorf("Expected exit code 1 when nothing listens, got %d", code)
+	}
+
+	out.Reset()
+	if code := runHealthCheck(&config.Config{Port: ":"}, &out); code != 1 || !strings.Contains(out.String(), "no port") {
+		t.Errorf("Expected exit code 1 without a port, got %d: %q", code, out.String())
+	}
+}
+
+func TestHealthCheckTarget(t *testing.T) {
+	tests := []struct {
+		port string
+		want string
+	}{
+		{":8080", "http://127.0.0.1:8080/api/health"},
+		{"0.0.0.0:8080", "http://127.0.0.1:8080/api/health"},
+		{"[::]:8080", "http://127.0.0.1:8080/api/health"},
+		{"10.0.0.5:9000", "http://10.0.0.5:9000/api/health"},
+	}
+	for _, tt := range tests {
+		url, _, err := healthCheckTarget(&config.Config{Port: tt.port})
+		if err != nil || url != tt.want {
+			t.Errorf("Port %q: expected %s, got %s (%v)", tt.port, tt.want, url, err)
+		}
+	}
+}
+
+func TestRunHealthCheckUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	var out bytes.Buffer
+	if code := runHealthCheck(&config.Config{Port: ":8080", ListenSocket: path}, &out); code != 0 {
+		t.Errorf("Expected exit code 0 over the socket, got %d: %s", code, out.String())
+	}
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/models"
+	"abt-analytics-dashboard/internal/processor"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// adminRoute is an endpoint under /api/admin. Aliases are full paths that
+// predate the admin subrouter and serve the same handler through the same
+// middleware. Routes that read a body without enforcing their own size
+// limit set limitBody to apply MAX_REQUEST_BODY_BYTES.
+type adminRoute struct {
+	method    string
+	path      string
+	handler   http.HandlerFunc
+	limitBody bool
+	aliases   []string
+}
+
+// adminRoutes lists every admin endpoint. Register admin endpoints here
+// rather than on the router, so they cannot miss the admin middleware.
+func (s *Server) adminRoutes() []adminRoute {
+	return []adminRoute{
+		// Uploads enforce MAX_UPLOAD_BYTES themselves
+		{method: "POST", path: "/upload", handler: s.uploadDataset, aliases: []string{"/api/upload"}},
+		{method: "POST", path: "/sample-data", handler: s.loadSampleData, limitBody: true},
+		{method: "GET", path: "/config", handler: s.getConfig},
+		{method: "POST", path: "/config/reload", handler: s.reloadConfig, limitBody: true},
+		{method: "POST", path: "/stage", handler: s.stageDataset, limitBody: true},
+		{method: "GET", path: "/stage/preview", handler: s.previewStagedDataset},
+		{method: "POST", path: "/promote", handler: s.promoteStagedDataset, limitBody: true},
+		{method: "POST", path: "/discard", handler: s.discardStagedDataset, limitBody: true},
+		{method: "GET", path: "/processing-log", handler: s.streamProcessingLog},
+		{method: "GET", path: "/sample-transactions", handler: s.getSampleTransactions},
+		{method: "GET", path: "/exclusions", handler: s.getExclusions},
+	}
+}
+
+// adminChain wraps an admin route in the admin middleware: audit logging,
+// then the IP allowlist, the rate limit and authentication, so that every
+// rejected request is logged and unauthenticated clients are throttled
+func (s *Server) adminChain(route adminRoute) http.Handler {
+	var handler http.Handler = route.handler
+	if route.limitBody {
+		handler = s.bodyLimitMiddleware(handler)
+	}
+	handler = s.adminAuthMiddleware(handler)
+	handler = s.adminRateLimitMiddleware(handler)
+	handler = s.adminAllowlistMiddleware(handler)
+	return s.adminAuditMiddleware(handler)
+}
+
+// adminAuditMiddleware logs every admin request with its client, outcome
+// and duration, whether or not it was allowed
+func (s *Server) adminAuditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		log.Printf("Admin audit: %s %s from %s -> %d (%v)",
+			r.Method, r.URL.Path, s.clientIP(r), recorder.status, time.Since(start))
+	})
+}
+
+// adminAllowlistMiddleware rejects admin requests from clients outside
+// ADMIN_ALLOWED_IPS. No configured entries allows every client.
+func (s *Server) adminAllowlistMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowlist := s.runtimeConfig().AdminAllowedIPs
+		if len(allowlist) > 0 && !ipAllowed(s.clientIP(r), allowlist) {
+			s.writeErrorResponse(w, http.StatusForbidden, "Admin access is not allowed from this address")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// adminRateLimitMiddleware limits each client to ADMIN_RATE_LIMIT admin
+// requests per minute. Zero disables the limit.
+func (s *Server) adminRateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if perMinute := s.runtimeConfig().AdminRateLimit; perMinute > 0 {
+			if ok, retryAfter := s.adminLimiter.allow(s.clientIP(r), perMinute); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				s.writeErrorResponse(w, http.StatusTooManyRequests,
+					fmt.Sprintf("Admin rate limit of %d requests per minute exceeded (ADMIN_RATE_LIMIT)", perMinute))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ipAllowed reports whether ip matches an address or CIDR range in allowlist
+func ipAllowed(ip string, allowlist []string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, entry := range allowlist {
+		if allowed := net.ParseIP(entry); allowed != nil {
+			if allowed.Equal(addr) {
+				return true
+			}
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush forwards to the underlying writer, so streamed responses keep
+// flushing through the recorder
+func (r *statusRecorder) Flush() {
+	http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// adminAuthMiddleware requires "Authorization: Bearer <ADMIN_API_KEY>" on
+// admin routes. When no key is configured every admin request is rejected.
+func (s *Server) adminAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := s.runtimeConfig().AdminAPIKey
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if key == "" || !ok || subtle.ConstantTimeCompare([]byte(token), []byte(key)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			s.writeErrorResponse(w, http.StatusUnauthorized, "A valid admin API key is required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// loadSampleData switches the served data to sample data. It is idempotent:
+// when sample data is already being served nothing is reloaded.
+func (s *Server) loadSampleData(w http.ResponseWriter, r *http.Request) {
+	changed := s.processor.GetDashboardData().DataSource != processor.SourceSample
+	if changed {
+		log.Printf("Admin request from %s: switching to sample data", s.clientIP(r))
+		if err := s.processor.LoadSampleData(); errors.Is(err, processor.ErrProcessingInProgress) {
+			s.writeErrorResponse(w, http.StatusConflict, "Another dataset is being processed; retry once it has finished")
+			return
+		}
+	}
+
+	data := s.processor.GetDashboardData()
+	response := SampleDataResponse{
+		Changed:     changed,
+		DataSource:  data.DataSource,
+		LastUpdated: data.LastUpdated,
+		RecordCount: data.RecordCount,
+	}
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// getSampleTransactions returns the raw transactions sampled from the
+// current dataset, for one country with ?country= or overall
+func (s *Server) getSampleTransactions(w http.ResponseWriter, r *http.Request) {
+	country := r.URL.Query().Get("country")
+
+	sample, ok := s.processor.GetSampleTransactions(country)
+	if !ok {
+		if s.processor.GetDashboardData().SampleTransactions == nil {
+			s.writeErrorResponse(w, http.StatusNotFound, "No transactions were sampled; set RETAIN_SAMPLE_TRANSACTIONS and reload the dataset")
+			return
+		}
+		s.writeErrorResponse(w, http.StatusNotFound, fmt.Sprintf("No sampled transactions for country '%s'", country))
+		return
+	}
+
+	meta := dataMeta(s.processor.GetDashboardData(), "Raw transactions sampled at random from the current dataset")
+	meta.Country = country
+	s.writeResponse(w, r, http.StatusOK, newListResponse(sample, meta))
+}
+
+// getExclusions returns the configured exclusions and the rows each left
+// out of the served data
+func (s *Server) getExclusions(w http.ResponseWriter, r *http.Request) {
+	configured := s.processor.GetExclusions()
+	applied := s.processor.GetDashboardData().Report.Excluded
+	if applied == nil {
+		applied = []models.ExcludedTotal{}
+	}
+	response := ExclusionsResponse{
+		Configured: configured,
+		Applied:    applied,
+		Pending:    !exclusionsApplied(configured, applied),
+	}
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// exclusionsApplied reports whether applied holds exactly the configured
+// exclusions, in order
+func exclusionsApplied(configured models.Exclusions, applied []models.ExcludedTotal) bool {
+	if len(applied) != len(configured.Products)+len(configured.Countries) {
+		return false
+	}
+	for i, key := range append(append([]string{}, configured.Products...), configured.Countries...) {
+		if applied[i].Key != key {
+			return false
+		}
+	}
+	return true
+}
+
+// getConfig returns the effective configuration with secrets redacted
+func (s *Server) getConfig(w http.ResponseWriter, r *http.Request) {
+	response := ConfigResponse{
+		Settings:   s.runtimeConfig().Redacted(),
+		Reloadable: config.ReloadableSettings(),
+	}
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// reloadConfig re-reads the configuration, as SIGUSR1 does
+func (s *Server) reloadConfig(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Admin request from %s: reloading configuration", s.clientIP(r))
+	result, err := s.ReloadConfig()
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response := ConfigReloadResponse{
+		Changed: result.Changed,
+		Ignored: result.Ignored,
+	}
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
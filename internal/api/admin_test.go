@@ -0,0 +1,584 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/models"
+	"abt-analytics-dashboard/internal/processor"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const adminTestKey = "test-admin-key"
+
+func newAdminTestServer(t *testing.T) (*processor.Processor, http.Handler) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "data.csv")
+	content := "transaction_id,transaction_date,country,region,product_name,quantity,total_price\nTXN001,2024-01-15,USA,North America,Laptop,1,100\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+
+	proc := processor.New()
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+	return proc, NewServer(proc, &config.Config{Port: ":8080", AdminAPIKey: adminTestKey}).setupRoutes()
+}
+
+func postSampleData(router http.Handler, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/admin/sample-data", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func healthDataSource(t *testing.T, router http.Handler) string {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/health", nil))
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse health JSON: %v", err)
+	}
+	source, _ := response["data_source"].(string)
+	return source
+}
+
+func TestAdminSampleDataSwitchesSource(t *testing.T) {
+	proc, router := newAdminTestServer(t)
+
+	if source := healthDataSource(t, router); source != processor.SourceDataset {
+		t.Fatalf("Expected initial data source '%s', got '%s'", processor.SourceDataset, source)
+	}
+
+	rr := postSampleData(router, "Bearer "+adminTestKey)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response["changed"] != true || response["data_source"] != processor.SourceSample {
+		t.Errorf("Expected a switch to sample data, got %v", response)
+	}
+
+	if source := healthDataSource(t, router); source != processor.SourceSample {
+		t.Errorf("Expected health data source '%s', got '%s'", processor.SourceSample, source)
+	}
+	if len(proc.GetTopProducts()) == 0 {
+		t.Error("Expected sample products to be served")
+	}
+
+	history := proc.GetProcessingHistory()
+	if len(history) != 2 || history[0].Source != processor.SourceSample || history[1].Source != processor.SourceDataset {
+		t.Errorf("Expected sample run recorded after the dataset run, got %+v", history)
+	}
+
+	// A second call is a no-op
+	rr = postSampleData(router, "Bearer "+adminTestKey)
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if rr.Code != http.StatusOK || response["changed"] != false {
+		t.Errorf("Expected repeated call to be unchanged, got %d %v", rr.Code, response)
+	}
+	if len(proc.GetProcessingHistory()) != 2 {
+		t.Errorf("Expected no new history entry, got %d", len(proc.GetProcessingHistory()))
+	}
+}
+
+func TestAdminSampleDataWhileProcessing(t *testing.T) {
+	proc, router := newAdminTestServer(t)
+
+	pending, writer := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- proc.ProcessReader(pending) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for !proc.GetProcessingStatus().Running {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for processing to start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if rr := postSampleData(router, "Bearer "+adminTestKey); rr.Code != http.StatusConflict {
+		t.Errorf("Expected status %d while processing, got %d: %s", http.StatusConflict, rr.Code, rr.Body.String())
+	}
+
+	io.WriteString(writer, "transaction_id,country,product_name,quantity,total_price\nTXN001,Germany,Laptop,1,1200\n")
+	writer.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Expected the running load to succeed, got %v", err)
+	}
+	if source := healthDataSource(t, router); source != processor.SourceReader {
+		t.Errorf("Expected the running load to be served, got data source '%s'", source)
+	}
+	if rr := postSampleData(router, "Bearer "+adminTestKey); rr.Code != http.StatusOK {
+		t.Errorf("Expected sample data to load afterwards, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestAdminSampleDataRequiresKey(t *testing.T) {
+	_, router := newAdminTestServer(t)
+
+	for _, authorization := range []string{"", "Bearer wrong-key", adminTestKey} {
+		rr := postSampleData(router, authorization)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: expected status %d, got %d", authorization, http.StatusUnauthorized, rr.Code)
+		}
+	}
+
+	if source := healthDataSource(t, router); source != processor.SourceDataset {
+		t.Errorf("Expected data source to stay '%s', got '%s'", processor.SourceDataset, source)
+	}
+
+	// Without a configured key the admin routes are closed
+	proc := processor.New()
+	closed := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+	if rr := postSampleData(closed, "Bearer "); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without a configured key, got %d", http.StatusUnauthorized, rr.Code)
+	}
+}
+
+func adminRequest(router http.Handler, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+adminTestKey)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAdminConfigReloadSwapsRuntimeSettings(t *testing.T) {
+	cfg := &config.Config{
+		Port:               ":8080",
+		AdminAPIKey:        adminTestKey,
+		LogLevel:           config.LogLevelInfo,
+		CORSAllowedOrigins: []string{"https://old.example"},
+	}
+	router := NewServer(processor.New(), cfg).setupRoutes()
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	corsOrigin := func() string {
+		req := httptest.NewRequest("GET", "/api/health", nil)
+		req.Header.Set("Origin", "https://new.example")
+		req.Header.Set("User-Agent", "reload-test")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Header().Get("Access-Control-Allow-Origin")
+	}
+	if origin := corsOrigin(); origin != "" {
+		t.Fatalf("Expected https://new.example to be rejected before the reload, got %q", origin)
+	}
+	if strings.Contains(logs.String(), "user-agent=") {
+		t.Fatal("Expected info-level request logs before the reload")
+	}
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://new.example")
+	t.Setenv("LOG_LEVEL", config.LogLevelDebug)
+	t.Setenv("PORT", "9090")
+
+	rr := adminRequest(router, "POST", "/api/admin/config/reload")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response ConfigReloadResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if strings.Join(response.Changed, ",") != "LOG_LEVEL,CORS_ALLOWED_ORIGINS" {
+		t.Errorf("Expected LOG_LEVEL and CORS_ALLOWED_ORIGINS to change, got %v", response.Changed)
+	}
+	if len(response.Ignored) != 1 || response.Ignored[0] != "PORT" {
+		t.Errorf("Expected PORT to be ignored, got %v", response.Ignored)
+	}
+	if !strings.Contains(logs.String(), "PORT changed but only takes effect after a restart") {
+		t.Errorf("Expected a warning about PORT, got logs: %s", logs.String())
+	}
+
+	logs.Reset()
+	if origin := corsOrigin(); origin != "https://new.example" {
+		t.Errorf("Expected the CORS middleware to allow https://new.example after the reload, got %q", origin)
+	}
+	if !strings.Contains(logs.String(), `user-agent="reload-test"`) {
+		t.Errorf("Expected debug request logs after the reload, got: %s", logs.String())
+	}
+}
+
+func TestAdminConfigReloadRejectsInvalidSettings(t *testing.T) {
+	cfg := &config.Config{Port: ":8080", AdminAPIKey: adminTestKey, MaxRequestBodyBytes: 4096}
+	server := NewServer(processor.New(), cfg)
+	router := server.setupRoutes()
+
+	t.Setenv("MAX_REQUEST_BODY_BYTES", "8192")
+	t.Setenv("LOG_LEVEL", "verbose")
+
+	rr := adminRequest(router, "POST", "/api/admin/config/reload")
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "verbose") {
+		t.Fatalf("Expected status 400 naming the invalid log level, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if limit := server.maxRequestBodyBytes(); limit != 4096 {
+		t.Errorf("Expected the body limit to stay 4096 after a rejected reload, got %d", limit)
+	}
+}
+
+func TestAdminConfigRedactsSecrets(t *testing.T) {
+	_, router := newAdminTestServer(t)
+
+	if rr := postSampleData(router, ""); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected admin routes to require the key, got %d", rr.Code)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/admin/config", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without the key, got %d", rr.Code)
+	}
+
+	rr = adminRequest(router, "GET", "/api/admin/config")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), adminTestKey) {
+		t.Errorf("Expected the admin key to be redacted, got %s", rr.Body.String())
+	}
+
+	var response ConfigResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Settings["ADMIN_API_KEY"] != "[REDACTED]" || response.Settings["PORT"] != ":8080" {
+		t.Errorf("Expected a redacted key and port :8080, got %v", response.Settings)
+	}
+	if len(response.Reloadable) == 0 {
+		t.Error("Expected the reloadable settings to be listed")
+	}
+}
+
+// adminRoutePaths returns every path an admin route is served at
+func adminRoutePaths(s *Server) map[string]string {
+	paths := make(map[string]string)
+	for _, route := range s.adminRoutes() {
+		paths["/api/admin"+route.path] = route.method
+		for _, alias := range route.aliases {
+			paths[alias] = route.method
+		}
+	}
+	return paths
+}
+
+func TestAdminRoutesRequireAuthentication(t *testing.T) {
+	server := NewServer(processor.New(), &config.Config{Port: ":8080", AdminAPIKey: adminTestKey})
+	router := server.setupRoutes()
+
+	paths := adminRoutePaths(server)
+	if len(paths) < 5 {
+		t.Fatalf("Expected the admin routes and the /api/upload alias, got %v", paths)
+	}
+	for path, method := range paths {
+		for _, authorization := range []string{"", "Bearer wrong-key", adminTestKey} {
+			req := httptest.NewRequest(method, path, strings.NewReader("transaction_id\nTXN001\n"))
+			if authorization != "" {
+				req.Header.Set("Authorization", authorization)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != http.StatusUnauthorized {
+				t.Errorf("%s %s with %q: expected status 401, got %d", method, path, authorization, rr.Code)
+			}
+		}
+	}
+}
+
+func TestAdminAllowlist(t *testing.T) {
+	cfg := &config.Config{
+		Port:            ":8080",
+		AdminAPIKey:     adminTestKey,
+		AdminAllowedIPs: []string{"10.0.0.0/8", "192.0.2.7"},
+	}
+	proc := processor.New()
+	server := NewServer(proc, cfg)
+	router := server.setupRoutes()
+
+	// Load a sampled dataset and stage another, so that every GET admin
+	// route has something to serve
+	proc.SetSampleTransactions(1)
+	if err := proc.ProcessDataset(writeStageFile(t)); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+	if _, err := proc.StageDataset(writeStageFile(t)); err != nil {
+		t.Fatalf("Failed to stage dataset: %v", err)
+	}
+
+	testCases := map[string]int{
+		"10.1.2.3:5000":  http.StatusOK,
+		"192.0.2.7:5000": http.StatusOK,
+		"192.0.2.8:5000": http.StatusForbidden,
+	}
+	for remoteAddr, expected := range testCases {
+		for path, method := range adminRoutePaths(server) {
+			if method != "GET" {
+				continue
+			}
+			req := httptest.NewRequest(method, path, nil)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set("Authorization", "Bearer "+adminTestKey)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != expected {
+				t.Errorf("%s from %s: expected status %d, got %d", path, remoteAddr, expected, rr.Code)
+			}
+		}
+	}
+
+	// Requests outside the allowlist are rejected before authentication
+	req := httptest.NewRequest("POST", "/api/upload", nil)
+	req.RemoteAddr = "203.0.113.1:5000"
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected the upload alias to apply the allowlist, got %d", rr.Code)
+	}
+}
+
+func TestAdminRateLimit(t *testing.T) {
+	cfg := &config.Config{Port: ":8080", AdminAPIKey: adminTestKey, AdminRateLimit: 3}
+	router := NewServer(processor.New(), cfg).setupRoutes()
+
+	for i := 1; i <= 4; i++ {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/admin/config", nil))
+		if i <= 3 && rr.Code != http.StatusUnauthorized {
+			t.Errorf("Request %d: expected status 401, got %d", i, rr.Code)
+		}
+		if i == 4 {
+			if rr.Code != http.StatusTooManyRequests {
+				t.Fatalf("Expected status 429 once the limit is spent, got %d", rr.Code)
+			}
+			if rr.Header().Get("Retry-After") == "" {
+				t.Error("Expected a Retry-After header")
+			}
+		}
+	}
+
+	// Other clients have their own budget, and public routes are not limited
+	req := httptest.NewRequest("GET", "/api/admin/config", nil)
+	req.RemoteAddr = "198.51.100.9:5000"
+	req.Header.Set("Authorization", "Bearer "+adminTestKey)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected another client to be allowed, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/health", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected public routes to be unaffected, got %d", rr.Code)
+	}
+}
+
+func TestAdminAuditLog(t *testing.T) {
+	_, router := newAdminTestServer(t)
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	postSampleData(router, "")
+	postSampleData(router, "Bearer "+adminTestKey)
+
+	for _, expected := range []string{
+		"Admin audit: POST /api/admin/sample-data from 192.0.2.1 -> 401",
+		"Admin audit: POST /api/admin/sample-data from 192.0.2.1 -> 200",
+	} {
+		if !strings.Contains(logs.String(), expected) {
+			t.Errorf("Expected %q in the logs, got: %s", expected, logs.String())
+		}
+	}
+}
+
+func TestIPAllowed(t *testing.T) {
+	allowlist := []string{"10.0.0.0/8", "2001:db8::/32", "192.0.2.7"}
+	testCases := map[string]bool{
+		"10.255.0.1":  true,
+		"2001:db8::1": true,
+		"192.0.2.7":   true,
+		"192.0.2.70":  false,
+		"11.0.0.1":    false,
+		"not-an-ip":   false,
+	}
+	for ip, expected := range testCases {
+		if got := ipAllowed(ip, allowlist); got != expected {
+			t.Errorf("ipAllowed(%q): expected %v, got %v", ip, expected, got)
+		}
+	}
+}
+
+func TestAdminSampleTransactions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	content := "transaction_id,transaction_date,user_id,country,region,product_name,quantity,price,total_price\n" +
+		"TXN001,2024-01-15,U1,USA,North America,Laptop,1,100,100\n" +
+		"TXN002,2024-01-16,U2,USA,North America,Mouse,2,10,20\n" +
+		"TXN003,2024-01-17,U3,Japan,Asia,Camera,1,50,50\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+
+	proc := processor.New()
+	proc.SetSampleTransactions(5)
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+	router := NewServer(proc, &config.Config{Port: ":8080", AdminAPIKey: adminTestKey}).setupRoutes()
+
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Authorization", "Bearer "+adminTestKey)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/api/admin/sample-transactions?country=USA")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Count int                  `json:"count"`
+		Data  []models.Transaction `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Expected sampled rows to parse as transactions, got %v", err)
+	}
+	if response.Count != 2 || len(response.Data) != 2 {
+		t.Fatalf("Expected the 2 USA rows, got %+v", response)
+	}
+	for _, transaction := range response.Data {
+		if transaction.Country != "USA" || transaction.UserID == "" || transaction.TransactionDate.IsZero() || transaction.TotalPrice == 0 {
+			t.Errorf("Expected a complete USA row, got %+v", transaction)
+		}
+	}
+
+	if rr := get("/api/admin/sample-transactions"); rr.Code != http.StatusOK || decodeResponse(t, rr)["count"] != float64(3) {
+		t.Errorf("Expected all 3 rows in the overall sample, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := get("/api/admin/sample-transactions?country=France"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unsampled country, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	_, router = newAdminTestServer(t)
+	req := httptest.NewRequest("GET", "/api/admin/sample-transactions", nil)
+	req.Header.Set("Authorization", "Bearer "+adminTestKey)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound || !strings.Contains(rr.Body.String(), "RETAIN_SAMPLE_TRANSACTIONS") {
+		t.Errorf("Expected a 404 naming RETAIN_SAMPLE_TRANSACTIONS when sampling is off, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestAdminExclusionsReloadAndHideEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	content := `transaction_id,transaction_date,country,region,product_name,quantity,total_price
+TXN001,2024-01-15,USA,North America,Laptop,1,1000
+TXN002,2024-01-16,USA,North America,Mouse,2,40
+TXN003,2024-02-01,Germany,Europe,Keyboard,1,80
+TXN004,2024-02-03,France,Europe,Mouse,1,20
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+	proc := processor.New()
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+	router := NewServer(proc, &config.Config{Port: ":8080", AdminAPIKey: adminTestKey}).setupRoutes()
+
+	exclusions := func() ExclusionsResponse {
+		t.Helper()
+		rr := adminRequest(router, "GET", "/api/admin/exclusions")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var response ExclusionsResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response JSON: %v", err)
+		}
+		return response
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/admin/exclusions", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without the key, got %d", rr.Code)
+	}
+	if response := exclusions(); len(response.Configured.Products) != 0 || len(response.Applied) != 0 || response.Pending {
+		t.Errorf("Expected no exclusions, got %+v", response)
+	}
+
+	t.Setenv("EXCLUDE_PRODUCTS", "Laptop")
+	t.Setenv("EXCLUDE_COUNTRIES", "DE")
+	if rr := adminRequest(router, "POST", "/api/admin/config/reload"); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the reload to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if response := exclusions(); !response.Pending || len(response.Applied) != 0 {
+		t.Errorf("Expected the reloaded exclusions to be pending, got %+v", response)
+	}
+
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to reprocess dataset: %v", err)
+	}
+	response := exclusions()
+	want := []models.ExcludedTotal{
+		{Kind: models.ExcludedProduct, Key: "Laptop", Revenue: 1000, TransactionCount: 1},
+		{Kind: models.ExcludedCountry, Key: "DE", Revenue: 80, TransactionCount: 1},
+	}
+	if response.Pending || len(response.Applied) != 2 || response.Applied[0] != want[0] || response.Applied[1] != want[1] {
+		t.Errorf("Expected applied exclusions %+v, got %+v", want, response)
+	}
+
+	for _, endpoint := range []string{
+		"/api/revenue-by-country", "/api/top-products", "/api/top-regions", "/api/countries",
+		"/api/dimensions", "/api/products/search?q=lap", "/api/regions/North%20America/products",
+	} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", endpoint, nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d: %s", endpoint, rr.Code, rr.Body.String())
+			continue
+		}
+		if body := rr.Body.String(); strings.Contains(body, "Laptop") || strings.Contains(body, "Germany") {
+			t.Errorf("%s: expected the excluded entries to be gone, got %s", endpoint, body)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries/Germany", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected an excluded country to be not found, got %d", rr.Code)
+	}
+	revenue := 0.0
+	for _, summary := range proc.GetDashboardData().CountrySummaries {
+		revenue += summary.TotalRevenue
+	}
+	if revenue != 60 {
+		t.Errorf("Expected the country totals to drop to 60, got %v", revenue)
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// Version identifies the build. It is reported at / and keys the caching of
+// responses that only change between builds. Release builds set it with
+// -ldflags "-X abt-analytics-dashboard/internal/api.Version=1.2.3".
+var Version = "1.0.0"
+
+// cacheBuildConstant is the Cache-Control of responses that only change
+// with a new build. After a day clients revalidate with the ETag, which
+// stays valid until the next release.
+const cacheBuildConstant = "public, max-age=86400"
+
+// buildETag returns a strong ETag for the response to r, derived from the
+// build Version, the request URI and the content encoding the response is
+// compressed with, since each encoding is a different representation
+func (s *Server) buildETag(r *http.Request) string {
+	encoding := s.responseEncoding(r)
+	sum := sha256.Sum256([]byte(Version + "\x00" + r.URL.RequestURI() + "\x00" + encoding))
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists etag. The
+// comparison is weak, as RFC 9110 requires for If-None-Match, so a W/
+// prefix added by a proxy still matches.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// buildCached serves next with caching headers for build-constant content:
+// a strong ETag derived from Version and a long max-age. Conditional
+// requests with a matching ETag get 304 Not Modified. Error responses are
+// sent without the caching headers.
+func (s *Server) buildCached(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		etag := s.buildETag(r)
+		if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, etag) {
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", cacheBuildConstant)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next(&cacheHeaderWriter{ResponseWriter: w, etag: etag}, r)
+	}
+}
+
+// cacheHeaderWriter adds the build caching headers to successful responses
+type cacheHeaderWriter struct {
+	http.ResponseWriter
+	etag        string
+	wroteHeader bool
+}
+
+func (w *cacheHeaderWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if statusCode == http.StatusOK {
+			w.Header().Set("ETag", w.etag)
+			w.Header().Set("Cache-Control", cacheBuildConstant)
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *cacheHeaderWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
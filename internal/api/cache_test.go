@@ -0,0 +1,132 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/processor"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// schemaRequest requests path with the given headers
+func schemaRequest(router http.Handler, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestSchemaETagStable(t *testing.T) {
+	router := newQueryTestRouter()
+
+	for _, path := range []string{"/api/schema", "/api/schema/health"} {
+		first := schemaRequest(router, path, nil)
+		second := schemaRequest(router, path, nil)
+		if first.Code != http.StatusOK || second.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d and %d", path, http.StatusOK, first.Code, second.Code)
+		}
+
+		etag := first.Header().Get("ETag")
+		if etag == "" || etag[0] != '"' {
+			t.Fatalf("%s: expected a strong ETag, got %q", path, etag)
+		}
+		if second.Header().Get("ETag") != etag {
+			t.Errorf("%s: expected the same ETag on every request, got %q and %q", path, etag, second.Header().Get("ETag"))
+		}
+		if cacheControl := first.Header().Get("Cache-Control"); cacheControl != cacheBuildConstant {
+			t.Errorf("%s: expected Cache-Control %q, got %q", path, cacheBuildConstant, cacheControl)
+		}
+	}
+
+	index := schemaRequest(router, "/api/schema", nil).Header().Get("ETag")
+	health := schemaRequest(router, "/api/schema/health", nil).Header().Get("ETag")
+	if index == health {
+		t.Error("Expected different ETags for different schemas")
+	}
+	gzipped := schemaRequest(router, "/api/schema/health", map[string]string{"Accept-Encoding": "gzip"}).Header().Get("ETag")
+	if gzipped == health {
+		t.Error("Expected a different ETag for the gzip-encoded representation")
+	}
+}
+
+func TestSchemaETagBrotliDisabled(t *testing.T) {
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, &config.Config{Port: ":8080", DisableBrotli: true}).setupRoutes()
+
+	// The response is gzip-encoded, so its ETag is the gzip one, not br's
+	rr := schemaRequest(router, "/api/schema/health", map[string]string{"Accept-Encoding": "br, gzip"})
+	if encoding := rr.Header().Get("Content-Encoding"); encoding != "gzip" {
+		t.Fatalf("Expected gzip with brotli disabled, got %q", encoding)
+	}
+	gzipped := schemaRequest(router, "/api/schema/health", map[string]string{"Accept-Encoding": "gzip"})
+	if rr.Header().Get("ETag") != gzipped.Header().Get("ETag") {
+		t.Errorf("Expected the gzip ETag, got %q and %q", rr.Header().Get("ETag"), gzipped.Header().Get("ETag"))
+	}
+	brotli := schemaRequest(newQueryTestRouter(), "/api/schema/health", map[string]string{"Accept-Encoding": "br"})
+	if rr.Header().Get("ETag") == brotli.Header().Get("ETag") {
+		t.Error("Expected the ETag of a gzip response to differ from a br one")
+	}
+}
+
+func TestSchemaNotModified(t *testing.T) {
+	router := newQueryTestRouter()
+	etag := schemaRequest(router, "/api/schema/health", nil).Header().Get("ETag")
+
+	for _, match := range []string{etag, "W/" + etag, `"other", ` + etag} {
+		rr := schemaRequest(router, "/api/schema/health", map[string]string{"If-None-Match": match})
+		if rr.Code != http.StatusNotModified {
+			t.Errorf("If-None-Match %s: expected status %d, got %d", match, http.StatusNotModified, rr.Code)
+			continue
+		}
+		if rr.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: expected an empty body, got %q", match, rr.Body.String())
+		}
+		if rr.Header().Get("ETag") != etag {
+			t.Errorf("If-None-Match %s: expected the ETag on the 304, got %q", match, rr.Header().Get("ETag"))
+		}
+	}
+
+	rr := schemaRequest(router, "/api/schema/health", map[string]string{"If-None-Match": `"stale"`})
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status %d for a stale ETag, got %d", http.StatusOK, rr.Code)
+	}
+}
+
+func TestSchemaETagChangesWithVersion(t *testing.T) {
+	router := newQueryTestRouter()
+	etag := schemaRequest(router, "/api/schema/health", nil).Header().Get("ETag")
+
+	previous := Version
+	Version = "2.0.0-test"
+	defer func() { Version = previous }()
+
+	rr := schemaRequest(router, "/api/schema/health", map[string]string{"If-None-Match": etag})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d after a version change, got %d", http.StatusOK, rr.Code)
+	}
+	if rr.Header().Get("ETag") == etag {
+		t.Errorf("Expected a new ETag for version %s, got %q again", Version, etag)
+	}
+
+	root := decodeResponse(t, schemaRequest(router, "/", nil))
+	if root["version"] != Version {
+		t.Errorf("Expected the root endpoint to report version %s, got %v", Version, root["version"])
+	}
+}
+
+func TestSchemaErrorsNotCached(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := schemaRequest(router, "/api/schema/unknown", nil)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+	if rr.Header().Get("ETag") != "" || rr.Header().Get("Cache-Control") != "" {
+		t.Errorf("Expected no caching headers on an error, got ETag %q and Cache-Control %q",
+			rr.Header().Get("ETag"), rr.Header().Get("Cache-Control"))
+	}
+}
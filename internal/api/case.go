@@ -0,0 +1,152 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// maxCachedKeys bounds the key mapping cache. Map keys are partly data
+// (currencies, overflow kinds), so the set of keys seen is not fixed.
+const maxCachedKeys = 4096
+
+// camelKeys caches the camelCase form of the object keys seen so far
+var camelKeys = struct {
+	sync.RWMutex
+	keys map[string]string
+}{keys: make(map[string]string)}
+
+// negotiateCase returns the key casing requested with ?case=, or the
+// JSON_CASE default when the parameter is absent
+func (s *Server) negotiateCase(r *http.Request) (string, error) {
+	value := strings.ToLower(r.URL.Query().Get("case"))
+	switch value {
+	case "":
+		if s.runtimeConfig().JSONCase == config.JSONCaseCamel {
+			return config.JSONCaseCamel, nil
+		}
+		return config.JSONCaseSnake, nil
+	case config.JSONCaseSnake, config.JSONCaseCamel:
+		return value, nil
+	default:
+		return "", fmt.Errorf("must be one of: %s, %s", config.JSONCaseSnake, config.JSONCaseCamel)
+	}
+}
+
+// camelCase converts a snake_case key such as "revenue_last_7d" to
+// "revenueLast7d". Keys that are not lower-case snake_case identifiers,
+// like country or product names used as map keys, are returned unchanged.
+func camelCase(key string) string {
+	camelKeys.RLock()
+	converted, ok := camelKeys.keys[key]
+	camelKeys.RUnlock()
+	if ok {
+		return converted
+	}
+
+	converted = toCamelCase(key)
+	camelKeys.Lock()
+	if len(camelKeys.keys) < maxCachedKeys {
+		camelKeys.keys[key] = converted
+	}
+	camelKeys.Unlock()
+	return converted
+}
+
+func toCamelCase(key string) string {
+	if key == "" || key[0] < 'a' || key[0] > 'z' || !strings.Contains(key, "_") {
+		return key
+	}
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_') {
+			return key
+		}
+	}
+
+	var b strings.Builder
+	for i, part := range strings.Split(key, "_") {
+		if i > 0 && part != "" {
+			part = strings.ToUpper(part[:1]) + part[1:]
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// rekeyJSON returns the JSON document src with every object key replaced by
+// rename(key), at any depth. Values, key order and number formatting are
+// kept as they are.
+func rekeyJSON(src []byte, rename func(string) string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(src))
+	decoder.UseNumber()
+
+	// containers tracks the open objects and arrays with how many tokens
+	// they held so far; in objects, even positions are keys
+	type container struct {
+		object bool
+		tokens int
+	}
+	var containers []container
+	var out bytes.Buffer
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		isKey := false
+		if delim, ok := token.(json.Delim); !ok || delim == '{' || delim == '[' {
+			if n := len(containers); n > 0 {
+				top := &containers[n-1]
+				switch {
+				case top.object && top.tokens%2 == 1:
+					out.WriteByte(':')
+				case top.tokens > 0:
+					out.WriteByte(',')
+				}
+				isKey = top.object && top.tokens%2 == 0
+				top.tokens++
+			}
+		}
+
+		switch value := token.(type) {
+		case json.Delim:
+			out.WriteByte(byte(value))
+			if value == '{' || value == '[' {
+				containers = append(containers, container{object: value == '{'})
+			} else {
+				containers = containers[:len(containers)-1]
+			}
+		case string:
+			if isKey {
+				value = rename(value)
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(encoded)
+		case json.Number:
+			out.WriteString(value.String())
+		case bool:
+			out.WriteString(fmt.Sprint(value))
+		case nil:
+			out.WriteString("null")
+		}
+	}
+
+	if len(containers) > 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
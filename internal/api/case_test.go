@@ -0,0 +1,158 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/processor"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// camelizeKeys converts the keys of a decoded snake_case body the way the
+// camel response option does, for comparing the two casings
+func camelizeKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[toCamelCase(key)] = camelizeKeys(item)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = camelizeKeys(item)
+		}
+		return converted
+	default:
+		return value
+	}
+}
+
+func TestCamelCaseResponsesMatchSnakeCase(t *testing.T) {
+	router := newQueryTestRouter()
+
+	for _, path := range []string{"/api/dashboard", "/api/summary", "/api/revenue-by-country?limit=3&page=2", "/api/processing-report"} {
+		separator := "?"
+		if strings.Contains(path, "?") {
+			separator = "&"
+		}
+		snakeStatus, snake := getJSON(t, router, path)
+		camelStatus, camel := getJSON(t, router, path+separator+"case=camel")
+		if snakeStatus != http.StatusOK || camelStatus != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d and %d", path, http.StatusOK, snakeStatus, camelStatus)
+		}
+		if !reflect.DeepEqual(camelizeKeys(snake), camel) {
+			t.Errorf("%s: expected the camelCase body to match the snake_case body", path)
+		}
+	}
+}
+
+func TestCamelCaseNestedKeys(t *testing.T) {
+	router := newQueryTestRouter()
+
+	_, body := getJSON(t, router, "/api/revenue-by-country?limit=2&case=camel")
+	response := body.(map[string]interface{})
+	meta, ok := response["meta"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a meta object, got %v", response["meta"])
+	}
+	for _, key := range []string{"totalPages", "pageSize", "hasNext", "updatedAt"} {
+		if _, ok := meta[key]; !ok {
+			t.Errorf("Expected meta key %q, got %v", key, meta)
+		}
+	}
+	if _, ok := meta["total_pages"]; ok {
+		t.Error("Expected no snake_case keys left in meta")
+	}
+
+	_, body = getJSON(t, router, "/api/dashboard?case=camel")
+	data := body.(map[string]interface{})["data"].(map[string]interface{})
+	products, ok := data["topProducts"].([]interface{})
+	if !ok || len(products) == 0 {
+		t.Fatalf("Expected topProducts in the dashboard, got %v", data["topProducts"])
+	}
+	if _, ok := products[0].(map[string]interface{})["productName"]; !ok {
+		t.Errorf("Expected productName in nested product rows, got %v", products[0])
+	}
+}
+
+func TestCamelCaseKeepsDataKeys(t *testing.T) {
+	// currencies and region names used as map keys are data, not identifiers
+	src := `{"sales_by_currency":{"USD":10,"EUR":5},"unknown_regions":{"Atlantis":1,"north america":2}}`
+	got, err := rekeyJSON([]byte(src), camelCase)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := `{"salesByCurrency":{"USD":10,"EUR":5},"unknownRegions":{"Atlantis":1,"north america":2}}` + "\n"
+	if string(got) != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestJSONCaseDefault(t *testing.T) {
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, &config.Config{Port: ":8080", JSONCase: config.JSONCaseCamel}).setupRoutes()
+
+	_, body := getJSON(t, router, "/api/summary")
+	data := body.(map[string]interface{})["data"].(map[string]interface{})
+	if _, ok := data["revenueLast7d"]; !ok {
+		t.Errorf("Expected camelCase keys with JSON_CASE=camel, got %v", data)
+	}
+
+	_, body = getJSON(t, router, "/api/summary?case=snake")
+	data = body.(map[string]interface{})["data"].(map[string]interface{})
+	if _, ok := data["revenue_last_7d"]; !ok {
+		t.Errorf("Expected ?case=snake to override the default, got %v", data)
+	}
+}
+
+func TestInvalidCase(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/summary?case=kebab", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "case") {
+		t.Errorf("Expected the error to name the case parameter, got %s", rr.Body.String())
+	}
+}
+
+func TestToCamelCase(t *testing.T) {
+	tests := map[string]string{
+		"total_revenue":   "totalRevenue",
+		"revenue_last_7d": "revenueLast7d",
+		"rows":            "rows",
+		"Germany":         "Germany",
+		"United_Kingdom":  "United_Kingdom",
+		"north america":   "north america",
+		"usd":             "usd",
+		"":                "",
+	}
+	for input, want := range tests {
+		if got := camelCase(input); got != want {
+			t.Errorf("Expected camelCase(%q) to be %q, got %q", input, want, got)
+		}
+	}
+}
+
+func TestRekeyJSON(t *testing.T) {
+	src := `{"z_key":1.50,"a_list":[{"inner_key":true},null,"text_value"],"empty_obj":{},"big_num":12345678901234567890}`
+	got, err := rekeyJSON([]byte(src), camelCase)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := `{"zKey":1.50,"aList":[{"innerKey":true},null,"text_value"],"emptyObj":{},"bigNum":12345678901234567890}` + "\n"
+	if string(got) != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if _, err := rekeyJSON([]byte(`{"broken":`), camelCase); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+}
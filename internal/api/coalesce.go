@@ -0,0 +1,242 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"bytes"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// coalesceTTL is how long a coalesced response is reused after it was
+// computed, as long as the data and configuration it was computed from are
+// still being served
+const coalesceTTL = 5 * time.Second
+
+// maxCoalescedResponses bounds the responses kept for reuse; beyond it,
+// identical requests still share computations in flight but their results
+// are not kept
+const maxCoalescedResponses = 256
+
+// maxCoalescedBodyBytes bounds the body recorded for a coalesced response.
+// A response growing past it is streamed to its own client instead, and
+// the requests waiting for it compute their own.
+const maxCoalescedBodyBytes = 1 << 20
+
+// coalescer shares the response of an expensive GET handler among identical
+// requests: those arriving while it is computed wait for it, and those
+// arriving shortly after reuse it until the data is replaced
+type coalescer struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+	now   func() time.Time
+}
+
+func newCoalescer() *coalescer {
+	return &coalescer{calls: make(map[string]*coalescedCall), now: time.Now}
+}
+
+// coalescedCall is one computation of a response. done is closed once the
+// response is recorded; abandoned is then set if the request computing it
+// ended before it finished, in which case waiters compute their own, since
+// the response may be an error or missing.
+type coalescedCall struct {
+	done      chan struct{}
+	updated   time.Time
+	config    *config.Config
+	expires   time.Time
+	abandoned bool
+
+	status int
+	header http.Header
+	body   []byte
+}
+
+// reusable reports whether a finished call can answer a request for data
+// last updated at updated and served with cfg
+func (c *coalescedCall) reusable(updated time.Time, cfg *config.Config, now time.Time) bool {
+	select {
+	case <-c.done:
+		return !c.abandoned && c.status == http.StatusOK && c.updated.Equal(updated) && c.config == cfg && now.Before(c.expires)
+	default:
+		// Still in flight; join it if it computes the same data
+		return c.updated.Equal(updated) && c.config == cfg
+	}
+}
+
+// coalesced shares next's responses among identical GET requests, keyed by
+// their path, query, Accept and Accept-Language headers. Only idempotent,
+// data-serving handlers may be wrapped: responses must depend on nothing
+// else of the request, and admin or mutating routes must never be.
+// Streaming formats (?format=ndjson) are never coalesced, and responses
+// over maxCoalescedBodyBytes stop being recorded, so that both still reach
+// the client as they are written.
+func (s *Server) coalesced(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || strings.EqualFold(r.URL.Query().Get("format"), formatNDJSON) {
+			next(w, r)
+			return
+		}
+
+		key := r.URL.RequestURI() + "\x00" + r.Header.Get("Accept") + "\x00" + r.Header.Get("Accept-Language")
+		updated := s.processor.GetDashboardData().LastUpdated
+		cfg := s.runtimeConfig()
+		c := s.coalescer
+
+		c.mu.Lock()
+		call, ok := c.calls[key]
+		if ok && call.reusable(updated, cfg, c.now()) {
+			c.mu.Unlock()
+			select {
+			case <-call.done:
+			case <-r.Context().Done():
+				return
+			}
+			if call.abandoned {
+				next(w, r)
+				return
+			}
+			call.writeTo(w)
+			return
+		}
+
+		call = &coalescedCall{done: make(chan struct{}), updated: updated, config: cfg}
+		c.calls[key] = call
+		c.mu.Unlock()
+
+		recorder := &responseRecorder{header: make(http.Header), w: w, limit: maxCoalescedBodyBytes}
+		finished := false
+		recorder.onStream = func() {
+			// Too large to share; let the waiters compute their own
+			finished = true
+			c.finish(key, call, true)
+		}
+		defer func() {
+			if !finished {
+				// next panicked; let the waiters compute their own
+				c.finish(key, call, true)
+			}
+		}()
+		next(recorder, r)
+		if recorder.streaming {
+			return
+		}
+		finished = true
+
+		call.status, call.header, call.body = recorder.result()
+		c.finish(key, call, r.Context().Err() != nil)
+		if recorder.status != 0 {
+			call.writeTo(w)
+		}
+	}
+}
+
+// finish releases the requests waiting for call. Abandoned and failed
+// calls are forgotten at once, as are all calls once maxCoalescedResponses
+// are kept.
+func (c *coalescer) finish(key string, call *coalescedCall, abandoned bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	call.abandoned = abandoned
+	call.expires = now.Add(coalesceTTL)
+	c.prune(now)
+	if c.calls[key] == call && (abandoned || call.status != http.StatusOK || len(c.calls) > maxCoalescedResponses) {
+		delete(c.calls, key)
+	}
+	close(call.done)
+}
+
+// prune drops the finished calls expired by now. Callers must hold c.mu.
+func (c *coalescer) prune(now time.Time) {
+	for key, call := range c.calls {
+		select {
+		case <-call.done:
+			if !now.Before(call.expires) {
+				delete(c.calls, key)
+			}
+		default:
+		}
+	}
+}
+
+// writeTo writes the recorded response
+func (c *coalescedCall) writeTo(w http.ResponseWriter) {
+	for name, values := range c.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(c.status)
+	w.Write(c.body)
+}
+
+// responseRecorder records a response so that it can be written to several
+// clients. Once the body would grow past limit, the recorded response is
+// written to w and the rest streamed to it; onStream is called when that
+// happens.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+
+	w         http.ResponseWriter
+	limit     int
+	onStream  func()
+	streaming bool
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.status == 0 {
+		r.status = statusCode
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if !r.streaming && r.limit > 0 && r.body.Len()+len(b) > r.limit {
+		r.stream()
+	}
+	if r.streaming {
+		return r.w.Write(b)
+	}
+	return r.body.Write(b)
+}
+
+// stream writes the response recorded so far to w, which receives all
+// further writes directly
+func (r *responseRecorder) stream() {
+	r.streaming = true
+	for name, values := range r.header {
+		r.w.Header()[name] = values
+	}
+	r.w.WriteHeader(r.status)
+	r.w.Write(r.body.Bytes())
+	r.body = bytes.Buffer{}
+	if r.onStream != nil {
+		r.onStream()
+	}
+}
+
+// Flush forwards to w once the response is streamed; a response still
+// being recorded is only written when complete
+func (r *responseRecorder) Flush() {
+	if r.streaming {
+		http.NewResponseController(r.w).Flush()
+	}
+}
+
+// result returns the recorded status, headers and body
+func (r *responseRecorder) result() (int, http.Header, []byte) {
+	status := r.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return status, r.header, r.body.Bytes()
+}
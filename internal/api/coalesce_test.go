@@ -0,0 +1,259 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/processor"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingHandler is a slow handler that counts how often it runs and
+// blocks until release is closed
+type countingHandler struct {
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+	status  int
+}
+
+func newCountingHandler() *countingHandler {
+	return &countingHandler{started: make(chan struct{}, 100), release: make(chan struct{}), status: http.StatusOK}
+}
+
+func (h *countingHandler) serve(w http.ResponseWriter, r *http.Request) {
+	n := h.calls.Add(1)
+	h.started <- struct{}{}
+	<-h.release
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(h.status)
+	fmt.Fprintf(w, `{"computation":%d}`, n)
+}
+
+func newCoalescingServer() *Server {
+	proc := processor.New()
+	proc.LoadSampleData()
+	return newServer(proc, &config.Config{Port: ":8080"})
+}
+
+func TestCoalescedRequestsShareOneComputation(t *testing.T) {
+	s := newCoalescingServer()
+	h := newCountingHandler()
+	handler := s.coalesced(h.serve)
+
+	const requests = 50
+	recorders := make([]*httptest.ResponseRecorder, requests)
+	var wg sync.WaitGroup
+	serve := func(i int) {
+		defer wg.Done()
+		recorders[i] = httptest.NewRecorder()
+		handler(recorders[i], httptest.NewRequest("GET", "/api/dashboard?format=json", nil))
+	}
+
+	// The first request computes; the others arrive while it is blocked
+	wg.Add(requests)
+	go serve(0)
+	<-h.started
+	for i := 1; i < requests; i++ {
+		go serve(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(h.release)
+	wg.Wait()
+
+	if calls := h.calls.Load(); calls != 1 {
+		t.Errorf("Expected one computation for %d identical requests, got %d", requests, calls)
+	}
+	for i, rr := range recorders {
+		if rr.Code != http.StatusOK || rr.Body.String() != `{"computation":1}` || rr.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("Request %d: expected the shared response, got %d %q", i, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestCoalescedResponseReusedUntilDataChanges(t *testing.T) {
+	s := newCoalescingServer()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.coalescer.now = func() time.Time { return now }
+	h := newCountingHandler()
+	close(h.release)
+	handler := s.coalesced(h.serve)
+
+	get := func(target string) string {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest("GET", target, nil))
+		return rr.Body.String()
+	}
+
+	get("/api/products/search?q=lap")
+	if body := get("/api/products/search?q=lap"); body != `{"computation":1}` || h.calls.Load() != 1 {
+		t.Errorf("Expected the response reused within the TTL, got %s after %d computations", body, h.calls.Load())
+	}
+	if get("/api/products/search?q=cam"); h.calls.Load() != 2 {
+		t.Errorf("Expected a different query to be computed, got %d computations", h.calls.Load())
+	}
+
+	now = now.Add(coalesceTTL)
+	if get("/api/products/search?q=lap"); h.calls.Load() != 3 {
+		t.Errorf("Expected the response recomputed after the TTL, got %d computations", h.calls.Load())
+	}
+
+	// New data invalidates the response at once
+	s.processor.LoadSampleData()
+	if get("/api/products/search?q=lap"); h.calls.Load() != 4 {
+		t.Errorf("Expected the response recomputed for new data, got %d computations", h.calls.Load())
+	}
+
+	// The Accept header picks a different representation
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/products/search?q=lap", nil)
+	req.Header.Set("Accept", "application/yaml")
+	handler(rr, req)
+	if h.calls.Load() != 5 {
+		t.Errorf("Expected a different Accept header to be computed, got %d computations", h.calls.Load())
+	}
+}
+
+func TestCoalescingSkipsMutationsAndErrors(t *testing.T) {
+	s := newCoalescingServer()
+	h := newCountingHandler()
+	close(h.release)
+	handler := s.coalesced(h.serve)
+
+	for i := 0; i < 2; i++ {
+		handler(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/admin/promote", nil))
+	}
+	if calls := h.calls.Load(); calls != 2 {
+		t.Errorf("Expected every POST to run the handler, got %d computations", calls)
+	}
+
+	h.status = http.StatusBadRequest
+	for i := 0; i < 2; i++ {
+		handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/products/search", nil))
+	}
+	if calls := h.calls.Load(); calls != 4 {
+		t.Errorf("Expected error responses not to be reused, got %d computations", calls)
+	}
+}
+
+func TestCoalescedWaitersRecomputeWhenLeaderLeaves(t *testing.T) {
+	s := newCoalescingServer()
+	h := newCountingHandler()
+	handler := s.coalesced(h.serve)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := httptest.NewRecorder()
+	waiter := httptest.NewRecorder()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		handler(leader, httptest.NewRequest("GET", "/api/revenue-by-country", nil).WithContext(ctx))
+	}()
+	<-h.started
+	go func() {
+		defer wg.Done()
+		handler(waiter, httptest.NewRequest("GET", "/api/revenue-by-country", nil))
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	close(h.release)
+	wg.Wait()
+
+	if calls := h.calls.Load(); calls != 2 {
+		t.Errorf("Expected the waiter to compute its own response, got %d computations", calls)
+	}
+	if waiter.Code != http.StatusOK || waiter.Body.String() != `{"computation":2}` {
+		t.Errorf("Expected the waiter's own response, got %d %q", waiter.Code, waiter.Body.String())
+	}
+}
+
+func TestCoalescedRoutesServeIdenticalResponses(t *testing.T) {
+	router := newQueryTestRouter()
+
+	var bodies [2]string
+	for i := range bodies {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/revenue-by-country?countries=USA", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		bodies[i] = rr.Body.String()
+	}
+	if bodies[0] != bodies[1] || bodies[0] == "" {
+		t.Errorf("Expected the repeated request to get the same response, got %q and %q", bodies[0], bodies[1])
+	}
+}
+
+// flushRecorder records the body length at each flush
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushedAt []int
+}
+
+func (r *flushRecorder) Flush() {
+	r.flushedAt = append(r.flushedAt, r.Body.Len())
+	r.ResponseRecorder.Flush()
+}
+
+func TestCoalescingKeepsNDJSONIncremental(t *testing.T) {
+	s := newCoalescingServer()
+	items := makeCountryRevenues(2*streamFlushInterval + 17)
+	var calls atomic.Int32
+	handler := s.coalesced(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeNDJSONList(w, http.StatusOK, items, ndjsonSummary{Type: "summary", Count: len(items)}, nil)
+	})
+
+	for i := 0; i < 2; i++ {
+		rr := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		handler(rr, httptest.NewRequest("GET", "/api/revenue-by-country?format=ndjson", nil))
+
+		if len(rr.flushedAt) < 2 || rr.flushedAt[0] == 0 || rr.flushedAt[0] >= rr.Body.Len() {
+			t.Fatalf("Expected the rows to be flushed as they are written, got flushes at %v of %d bytes", rr.flushedAt, rr.Body.Len())
+		}
+		if rows, _ := readNDJSON(t, rr.Body); len(rows) != len(items) {
+			t.Errorf("Expected %d rows, got %d", len(items), len(rows))
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected NDJSON requests not to be coalesced, got %d computations", calls.Load())
+	}
+}
+
+func TestCoalescingStreamsLargeResponses(t *testing.T) {
+	s := newCoalescingServer()
+	chunk := strings.Repeat("x", maxCoalescedBodyBytes/2)
+	var calls atomic.Int32
+	handler := s.coalesced(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < 4; i++ {
+			io.WriteString(w, chunk)
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	for i := 0; i < 2; i++ {
+		rr := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		handler(rr, httptest.NewRequest("GET", "/api/revenue-by-country", nil))
+
+		if rr.Body.Len() != 4*len(chunk) || rr.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("Expected the whole response, got %d bytes and headers %v", rr.Body.Len(), rr.Header())
+		}
+		if len(rr.flushedAt) == 0 || rr.flushedAt[0] >= rr.Body.Len() {
+			t.Errorf("Expected the response to be flushed once over the cap, got flushes at %v", rr.flushedAt)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected responses over the cap not to be reused, got %d computations", calls.Load())
+	}
+}
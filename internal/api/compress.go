@@ -0,0 +1,186 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// compressionEncodings lists the content codings the server can produce,
+// most preferred first. Identity is always acceptable unless the client
+// refuses it.
+var compressionEncodings = []string{"br", "gzip"}
+
+// gzipEncodings is compressionEncodings without br, used when
+// DISABLE_BROTLI is set
+var gzipEncodings = []string{"gzip"}
+
+// compressor is the part of the gzip and brotli writers the middleware uses
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// gzipWriters and brotliWriters reuse compressors between responses
+var (
+	gzipWriters = sync.Pool{
+		New: func() interface{} { return gzip.NewWriter(nil) },
+	}
+	brotliWriters = sync.Pool{
+		New: func() interface{} { return brotli.NewWriterLevel(nil, brotli.DefaultCompression) },
+	}
+)
+
+// compressorPools maps each content coding to the pool of its writers
+var compressorPools = map[string]*sync.Pool{
+	"gzip": &gzipWriters,
+	"br":   &brotliWriters,
+}
+
+// negotiateEncoding picks the most preferred of the server's encodings that
+// the Accept-Encoding header allows, or "identity" when none is acceptable.
+// Codings listed with q=0 are refused; "*" covers codings not listed.
+func negotiateEncoding(header string, encodings []string) string {
+	if header == "" {
+		return "identity"
+	}
+
+	accepted := make(map[string]bool)
+	wildcard, hasWildcard := false, false
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+
+		allowed := true
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				allowed = err == nil && q > 0
+			}
+		}
+
+		if coding == "*" {
+			wildcard, hasWildcard = allowed, true
+			continue
+		}
+		accepted[coding] = allowed
+	}
+
+	for _, encoding := range encodings {
+		allowed, listed := accepted[encoding]
+		if listed && allowed || !listed && hasWildcard && wildcard {
+			return encoding
+		}
+	}
+	return "identity"
+}
+
+// responseEncoding returns the content coding compressionMiddleware
+// compresses the response to r with, or "identity" when it is sent as is,
+// as it is for HEAD requests
+func (s *Server) responseEncoding(r *http.Request) string {
+	if r.Method == http.MethodHead {
+		return "identity"
+	}
+	encodings := compressionEncodings
+	if s.runtimeConfig().DisableBrotli {
+		encodings = gzipEncodings
+	}
+	return negotiateEncoding(r.Header.Get("Accept-Encoding"), encodings)
+}
+
+// compressionMiddleware compresses responses with the best encoding the
+// client accepts: br, then gzip, unless DISABLE_BROTLI is set. Responses
+// that already carry a Content-Encoding, partial content and bodiless
+// statuses are passed through unchanged.
+func (s *Server) compressionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := s.responseEncoding(r)
+		if encoding == "identity" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter compresses the response body once the handler has written
+// headers that allow it
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	cw          compressor
+	wroteHeader bool
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	header := w.Header()
+	if header.Get("Content-Encoding") == "" && header.Get("Content-Range") == "" &&
+		status != http.StatusNoContent && status != http.StatusNotModified && status >= http.StatusOK {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		w.cw = compressorPools[w.encoding].Get().(compressor)
+		w.cw.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.cw == nil {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.cw.Write(p)
+}
+
+// Flush writes any buffered compressed data, so streamed responses reach
+// the client as they are produced
+func (w *compressWriter) Flush() {
+	if w.cw != nil {
+		w.cw.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close finishes the compressed stream and returns the compressor to its
+// pool
+func (w *compressWriter) Close() {
+	if w.cw == nil {
+		return
+	}
+	w.cw.Close()
+	compressorPools[w.encoding].Put(w.cw)
+	w.cw = nil
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
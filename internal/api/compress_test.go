@@ -0,0 +1,153 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/processor"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := []struct {
+		header   string
+		expected string
+	}{
+		{"", "identity"},
+		{"gzip", "gzip"},
+		{"br, gzip, deflate", "gzip"},
+		{"GZIP;q=0.5", "gzip"},
+		{"gzip;q=0", "identity"},
+		{"deflate", "identity"},
+		{"*", "gzip"},
+		{"*;q=0", "identity"},
+		{"gzip;q=0, *", "identity"},
+	}
+
+	for _, tc := range cases {
+		if got := negotiateEncoding(tc.header, []string{"gzip"}); got != tc.expected {
+			t.Errorf("Expected %q for Accept-Encoding %q, got %q", tc.expected, tc.header, got)
+		}
+	}
+
+	if got := negotiateEncoding("gzip, br", []string{"br", "gzip"}); got != "br" {
+		t.Errorf("Expected the server's preference order to win, got %q", got)
+	}
+}
+
+// compressedGet requests path from router with Accept-Encoding set to
+// accept and returns the response and its decoded body
+func compressedGet(t *testing.T, router http.Handler, path, accept string) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set("Accept-Encoding", accept)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var reader io.Reader
+	switch encoding := rr.Header().Get("Content-Encoding"); encoding {
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(rr.Body.Bytes()))
+	case "gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(rr.Body.Bytes()))
+		if err != nil {
+			t.Fatalf("Failed to read gzip response: %v", err)
+		}
+		reader = gz
+	default:
+		reader = bytes.NewReader(rr.Body.Bytes())
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to decode %q response: %v", rr.Header().Get("Content-Encoding"), err)
+	}
+	return rr, string(decoded)
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries", nil))
+	plain := rr.Body.String()
+	if rr.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Expected an uncompressed response without Accept-Encoding, got %q", rr.Header().Get("Content-Encoding"))
+	}
+	if rr.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Expected Vary: Accept-Encoding, got %q", rr.Header().Get("Vary"))
+	}
+
+	cases := []struct {
+		accept   string
+		expected string
+	}{
+		{"gzip, deflate, br", "br"},
+		{"br;q=0.2, gzip;q=0.8", "br"},
+		{"gzip", "gzip"},
+		{"br;q=0, gzip", "gzip"},
+	}
+	for _, tc := range cases {
+		rr, decoded := compressedGet(t, router, "/api/countries", tc.accept)
+		if rr.Code != http.StatusOK || rr.Header().Get("Content-Encoding") != tc.expected {
+			t.Fatalf("Accept-Encoding %q: expected a %s response, got %d with encoding %q",
+				tc.accept, tc.expected, rr.Code, rr.Header().Get("Content-Encoding"))
+		}
+		if rr.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding %q: expected Vary: Accept-Encoding, got %q", tc.accept, rr.Header().Get("Vary"))
+		}
+		if decoded != plain {
+			t.Errorf("Accept-Encoding %q: expected the decoded body to match the plain response", tc.accept)
+		}
+	}
+}
+
+func TestCompressionBrotliDisabled(t *testing.T) {
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, &config.Config{Port: ":8080", DisableBrotli: true}).setupRoutes()
+
+	rr, decoded := compressedGet(t, router, "/api/countries", "br, gzip")
+	if encoding := rr.Header().Get("Content-Encoding"); encoding != "gzip" {
+		t.Errorf("Expected gzip with brotli disabled, got %q", encoding)
+	}
+	if !strings.HasPrefix(decoded, "{") {
+		t.Errorf("Expected a JSON body, got %q", decoded)
+	}
+
+	if rr, _ := compressedGet(t, router, "/api/countries", "br"); rr.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Expected identity for a br-only client with brotli disabled, got %q", rr.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestCompressionSkipsEncodedAndEmptyResponses(t *testing.T) {
+	server := NewServer(processor.New(), &config.Config{Port: ":8080"})
+	handler := server.compressionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/empty" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte("already compressed"))
+	}))
+
+	for _, path := range []string{"/empty", "/encoded"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if path == "/encoded" && rr.Body.String() != "already compressed" {
+			t.Errorf("Expected an encoded body to pass through, got %q", rr.Body.String())
+		}
+		if path == "/empty" && (rr.Body.Len() != 0 || rr.Header().Get("Content-Encoding") != "") {
+			t.Errorf("Expected an empty uncompressed 204, got %q with encoding %q", rr.Body.String(), rr.Header().Get("Content-Encoding"))
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// defaultFilename names a download whose filename sanitizes to nothing
+const defaultFilename = "export"
+
+// sanitizeFilename makes name safe to offer as a download filename: control
+// characters, CR and LF included, and path separators are removed, and
+// surrounding spaces and dots trimmed. Filenames built from request values,
+// such as a country filter, must go through it.
+func sanitizeFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '/' || r == '\\' || r == unicode.ReplacementChar {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, " .")
+	if name == "" {
+		return defaultFilename
+	}
+	return name
+}
+
+// contentDisposition returns the Content-Disposition value of an attachment
+// named filename, sanitized first. Following RFC 6266, filename holds a
+// quoted ASCII fallback, with quotes and non-ASCII characters replaced,
+// and filename* the exact name percent-encoded as UTF-8 when the two
+// differ.
+func contentDisposition(filename string) string {
+	filename = sanitizeFilename(filename)
+
+	fallback := strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || r == '"' {
+			return '_'
+		}
+		return r
+	}, filename)
+	if fallback == filename {
+		return fmt.Sprintf(`attachment; filename="%s"`, fallback)
+	}
+	return fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, fallback, encodeExtValue(filename))
+}
+
+// encodeExtValue percent-encodes every byte of value outside the RFC 5987
+// attr-char set
+func encodeExtValue(value string) string {
+	var encoded strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if isAttrChar(c) {
+			encoded.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
+
+// isAttrChar reports whether c may appear unencoded in an RFC 5987
+// ext-value
+func isAttrChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"mime"
+	"strings"
+	"testing"
+)
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		expected string
+		decoded  string
+	}{
+		{"plain", "revenue-Germany.csv", `attachment; filename="revenue-Germany.csv"`, "revenue-Germany.csv"},
+		{"CRLF", "revenue-X\r\nSet-Cookie: session=1.csv", `attachment; filename="revenue-XSet-Cookie: session=1.csv"`, "revenue-XSet-Cookie: session=1.csv"},
+		{"other controls", "a\x00b\tc\x7fd\u0085.csv", `attachment; filename="abcd.csv"`, "abcd.csv"},
+		{"quotes", `x"; filename="evil.exe.csv`,
+			`attachment; filename="x_; filename=_evil.exe.csv"; filename*=UTF-8''x%22%3B%20filename%3D%22evil.exe.csv`,
+			`x"; filename="evil.exe.csv`},
+		{"non-ASCII", "revenue-Côte d'Ivoire.csv",
+			`attachment; filename="revenue-C_te d'Ivoire.csv"; filename*=UTF-8''revenue-C%C3%B4te%20d%27Ivoire.csv`,
+			"revenue-Côte d'Ivoire.csv"},
+		{"non-Latin", "日本.xlsx", `attachment; filename="__.xlsx"; filename*=UTF-8''%E6%97%A5%E6%9C%AC.xlsx`, "日本.xlsx"},
+		{"path separators", "../../etc/passwd", `attachment; filename="etcpasswd"`, "etcpasswd"},
+		{"empty", " \r\n ", `attachment; filename="export"`, "export"},
+	}
+
+	for _, test := range tests {
+		got := contentDisposition(test.filename)
+		if got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, got)
+		}
+		if strings.ContainsAny(got, "\r\n") {
+			t.Errorf("%s: expected no line breaks, got %q", test.name, got)
+		}
+
+		_, params, err := mime.ParseMediaType(got)
+		if err != nil {
+			t.Errorf("%s: expected a parseable value, got %v", test.name, err)
+		} else if params["filename"] != test.decoded {
+			t.Errorf("%s: expected the filename to decode to %q, got %q", test.name, test.decoded, params["filename"])
+		}
+	}
+}
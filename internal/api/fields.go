@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// fieldSelector is implemented by response bodies whose elements can be
+// trimmed to the fields named in ?fields=
+type fieldSelector interface {
+	selectFields(names []string) (interface{}, error)
+}
+
+// selectFields returns a copy of the list whose elements only encode the
+// named fields, in struct declaration order. Names must be json field names
+// of the element type.
+func (l ListResponse[T]) selectFields(names []string) (interface{}, error) {
+	valid := jsonFieldNames(reflect.TypeOf((*T)(nil)).Elem())
+	if len(valid) == 0 {
+		return nil, fmt.Errorf("field selection is not supported by this endpoint")
+	}
+
+	requested := make(map[string]bool, len(names))
+	for _, name := range names {
+		if !contains(valid, name) {
+			return nil, fmt.Errorf("unknown field '%s'; valid fields: %s", name, strings.Join(valid, ", "))
+		}
+		requested[name] = true
+	}
+
+	fields := make([]string, 0, len(requested))
+	for _, name := range valid {
+		if requested[name] {
+			fields = append(fields, name)
+		}
+	}
+
+	items := make([]json.Marshaler, len(l.Data))
+	for i := range l.Data {
+		items[i] = projection[T]{item: &l.Data[i], fields: fields}
+	}
+	return newListResponse(items, l.Meta), nil
+}
+
+// projection encodes only the listed json fields of the wrapped item
+type projection[T any] struct {
+	item   *T
+	fields []string
+}
+
+func (p projection[T]) MarshalJSON() ([]byte, error) {
+	raw, err := json.Marshal(p.item)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	written := 0
+	for _, field := range p.fields {
+		value, ok := values[field]
+		if !ok {
+			continue
+		}
+		if written > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(field)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+		written++
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// jsonFieldNames lists the json names of a struct type's encoded fields in
+// declaration order, or nil when t is not a struct
+func jsonFieldNames(t reflect.Type) []string {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// parseFields reads the ?fields= parameter, returning nil when it is absent
+func parseFields(r *http.Request) []string {
+	return splitList(r.URL.Query().Get("fields"))
+}
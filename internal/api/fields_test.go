@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestFieldSelection(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-products?fields=purchase_count,product_name", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response struct {
+		Count int                          `json:"count"`
+		Data  []map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(response.Data) == 0 {
+		t.Fatal("Expected top products")
+	}
+
+	for _, item := range response.Data {
+		if len(item) != 2 {
+			t.Errorf("Expected exactly 2 keys, got %v", item)
+		}
+		for _, key := range []string{"product_name", "purchase_count"} {
+			if _, ok := item[key]; !ok {
+				t.Errorf("Expected key %s in %v", key, item)
+			}
+		}
+		for _, key := range []string{"rank", "current_stock", "stock_status"} {
+			if _, ok := item[key]; ok {
+				t.Errorf("Expected key %s to be absent, got %v", key, item)
+			}
+		}
+	}
+
+	// Keys follow the struct order, not the order requested
+	if body := rr.Body.String(); strings.Index(body, `"product_name"`) > strings.Index(body, `"purchase_count"`) {
+		t.Errorf("Expected product_name before purchase_count, got %s", body)
+	}
+}
+
+func TestFieldSelectionYAML(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-regions?fields=region&format=yaml", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response struct {
+		Data []map[string]interface{} `yaml:"data"`
+	}
+	if err := yaml.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	for _, item := range response.Data {
+		if _, ok := item["region"]; !ok || len(item) != 1 {
+			t.Errorf("Expected only region, got %v", item)
+		}
+	}
+}
+
+func TestFieldSelectionUnknownField(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-products?fields=product_name,price", nil))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", rr.Code)
+	}
+
+	problem := decodeProblem(t, rr)
+	if len(problem.InvalidParams) != 1 || problem.InvalidParams[0].Name != "fields" {
+		t.Fatalf("Expected one error for fields, got %v", problem.InvalidParams)
+	}
+
+	message := problem.InvalidParams[0].Reason
+	for _, want := range []string{"'price'", "product_name", "purchase_count", "current_stock"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("Expected message to mention %s, got %q", want, message)
+		}
+	}
+}
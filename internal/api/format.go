@@ -0,0 +1,278 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Response formats supported by writeResponse. JSON is the default.
+const (
+	formatJSON   = "json"
+	formatYAML   = "yaml"
+	formatNDJSON = "ndjson"
+)
+
+// ndjsonContentType is the media type of newline-delimited JSON responses
+const ndjsonContentType = "application/x-ndjson"
+
+// responseFormat describes how to encode a response envelope in a
+// non-JSON format
+type responseFormat struct {
+	contentType string
+	mediaTypes  []string
+	marshal     func(v interface{}) ([]byte, error)
+}
+
+// responseFormats lists the alternative formats that can be requested with
+// ?format=<name> or an Accept header matching one of the media types
+var responseFormats = map[string]responseFormat{
+	formatYAML: {
+		contentType: "application/yaml",
+		mediaTypes:  []string{"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"},
+		marshal:     yaml.Marshal,
+	},
+}
+
+// jsonStreamer is implemented by response bodies that write their own JSON
+// incrementally instead of being encoded in one go. Object keys are renamed
+// when rename is set.
+type jsonStreamer interface {
+	streamJSON(w http.ResponseWriter, statusCode int, rename func(string) string)
+}
+
+// ndjsonStreamer is implemented by list bodies that can be written as
+// newline-delimited JSON, one element per line
+type ndjsonStreamer interface {
+	streamNDJSON(w http.ResponseWriter, statusCode int, rename func(string) string)
+}
+
+// negotiateFormat picks the response format from the ?format= parameter or,
+// failing that, the Accept header. Accept values that name no supported
+// format fall back to JSON; an unknown ?format= value is an error. NDJSON
+// is only chosen with ?format=ndjson.
+func negotiateFormat(r *http.Request) (string, error) {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		if _, ok := responseFormats[format]; ok || format == formatJSON || format == formatNDJSON {
+			return format, nil
+		}
+		return "", fmt.Errorf("unsupported format '%s'", format)
+	}
+
+	best, bestQ := formatJSON, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+
+		if format := formatForMediaType(mediaType); format != "" {
+			best, bestQ = format, q
+		}
+	}
+
+	return best, nil
+}
+
+// formatForMediaType maps an Accept media type to a response format, or
+// returns "" when the media type is not supported
+func formatForMediaType(mediaType string) string {
+	switch mediaType {
+	case "application/json", "application/*", "*/*":
+		return formatJSON
+	}
+	for name, format := range responseFormats {
+		for _, candidate := range format.mediaTypes {
+			if mediaType == candidate {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// writeResponse writes body in the format negotiated for the request. JSON
+// bodies that implement jsonStreamer are streamed; other formats encode the
+// same envelope, converted through JSON so that field names match. Bodies
+// that implement fieldSelector are trimmed to the fields named in ?fields=.
+// With ?case=camel or JSON_CASE=camel, object keys are re-keyed to
+// camelCase as they are encoded; streamed bodies are re-keyed element by
+// element. User IDs are pseudonymized first when PSEUDONYMIZE_USERS is
+// enabled.
+func (s *Server) writeResponse(w http.ResponseWriter, r *http.Request, statusCode int, body interface{}) {
+	w.Header().Add("Vary", "Accept")
+	body = s.pseudonymizeUsers(body)
+
+	name, err := negotiateFormat(r)
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusNotAcceptable, err.Error())
+		return
+	}
+
+	jsonCase, err := s.negotiateCase(r)
+	if err != nil {
+		s.writeValidationErrorResponse(w, []fieldError{{Field: "case", Message: err.Error()}})
+		return
+	}
+	var rename func(string) string
+	if jsonCase == config.JSONCaseCamel {
+		rename = camelCase
+	}
+
+	if fields := parseFields(r); len(fields) > 0 {
+		if selector, ok := body.(fieldSelector); ok {
+			if body, err = selector.selectFields(fields); err != nil {
+				s.writeValidationErrorResponse(w, []fieldError{{Field: "fields", Message: err.Error()}})
+				return
+			}
+		}
+	}
+
+	if name == formatNDJSON {
+		streamer, ok := body.(ndjsonStreamer)
+		if !ok {
+			s.writeErrorResponse(w, http.StatusNotAcceptable, "format 'ndjson' is only supported by list endpoints")
+			return
+		}
+		streamer.streamNDJSON(w, statusCode, rename)
+		return
+	}
+
+	if name == formatJSON {
+		if streamer, ok := body.(jsonStreamer); ok {
+			streamer.streamJSON(w, statusCode, rename)
+			return
+		}
+		if rename != nil {
+			s.writeRekeyedJSON(w, statusCode, body, rename)
+			return
+		}
+		s.writeJSONResponse(w, statusCode, body)
+		return
+	}
+
+	format := responseFormats[name]
+	encoded, err := encodeVia(body, format.marshal, rename)
+	if err != nil {
+		log.Printf("Error encoding %s response: %v", name, err)
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	w.Header().Set("Content-Type", format.contentType)
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(encoded); err != nil {
+		log.Printf("Error writing %s response: %v", name, err)
+	}
+}
+
+// writeRekeyedJSON encodes a body that is not streamed as JSON with its
+// object keys renamed
+func (s *Server) writeRekeyedJSON(w http.ResponseWriter, statusCode int, body interface{}, rename func(string) string) {
+	raw, err := json.Marshal(body)
+	if err == nil {
+		raw, err = rekeyJSON(raw, rename)
+	}
+	if err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(raw); err != nil {
+		log.Printf("Error writing JSON response: %v", err)
+	}
+}
+
+// encodeVia round-trips v through JSON before marshaling it, so the output
+// uses the json struct tags and matches the JSON representation. A non-nil
+// rename re-keys the objects on the way. Objects are decoded into a
+// document tree rather than maps, so that their keys keep the order of the
+// JSON encoding instead of being sorted.
+func encodeVia(v interface{}, marshal func(interface{}) ([]byte, error), rename func(string) string) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if rename != nil {
+		if raw, err = rekeyJSON(raw, rename); err != nil {
+			return nil, err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	document, err := decodeNode(decoder)
+	if err != nil {
+		return nil, err
+	}
+	return marshal(document)
+}
+
+// decodeNode reads the next JSON value from decoder as a YAML node,
+// keeping the order of object keys and the text of numbers
+func decodeNode(decoder *json.Decoder) (*yaml.Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if value == '{' {
+			node.Kind, node.Tag = yaml.MappingNode, "!!map"
+		}
+		for decoder.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+			child, err := decodeNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		// Consume the closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(value.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(value)}, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// normalize round-trips a decoded document through JSON so values decoded
+// from YAML and JSON compare equal
+func normalize(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to re-encode document: %v", err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatalf("Failed to re-decode document: %v", err)
+	}
+	return out
+}
+
+func TestYAMLResponsesMatchJSON(t *testing.T) {
+	router := newQueryTestRouter()
+
+	endpoints := []string{
+		"/api/revenue-by-country?page_size=5",
+		"/api/top-products",
+		"/api/sales-by-month",
+		"/api/top-regions",
+		"/api/summary",
+		"/api/dashboard",
+	}
+
+	for _, endpoint := range endpoints {
+		jsonRR := httptest.NewRecorder()
+		router.ServeHTTP(jsonRR, httptest.NewRequest("GET", endpoint, nil))
+
+		var jsonDoc interface{}
+		if err := json.Unmarshal(jsonRR.Body.Bytes(), &jsonDoc); err != nil {
+			t.Fatalf("%s: failed to parse JSON: %v", endpoint, err)
+		}
+
+		req := httptest.NewRequest("GET", endpoint, nil)
+		req.Header.Set("Accept", "application/yaml")
+		yamlRR := httptest.NewRecorder()
+		router.ServeHTTP(yamlRR, req)
+
+		if yamlRR.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", endpoint, yamlRR.Code)
+		}
+		if contentType := yamlRR.Header().Get("Content-Type"); contentType != "application/yaml" {
+			t.Errorf("%s: expected Content-Type application/yaml, got %s", endpoint, contentType)
+		}
+
+		var yamlDoc interface{}
+		if err := yaml.Unmarshal(yamlRR.Body.Bytes(), &yamlDoc); err != nil {
+			t.Fatalf("%s: failed to parse YAML: %v", endpoint, err)
+		}
+
+		if !reflect.DeepEqual(normalize(t, yamlDoc), normalize(t, jsonDoc)) {
+			t.Errorf("%s: expected YAML content to match JSON content", endpoint)
+		}
+	}
+}
+
+func TestFormatQueryParameter(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-regions?format=yaml", nil))
+
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/yaml" {
+		t.Errorf("Expected Content-Type application/yaml, got %s", contentType)
+	}
+	if !strings.HasPrefix(rr.Body.String(), "count:") {
+		t.Errorf("Expected a YAML body, got %q", rr.Body.String()[:20])
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-regions?format=xml", nil))
+	if rr.Code != http.StatusNotAcceptable {
+		t.Errorf("Expected status %d for unsupported format, got %d", http.StatusNotAcceptable, rr.Code)
+	}
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	testCases := []struct {
+		accept   string
+		expected string
+	}{
+		{"", formatJSON},
+		{"application/json", formatJSON},
+		{"*/*", formatJSON},
+		{"text/html", formatJSON},
+		{"application/yaml", formatYAML},
+		{"text/yaml", formatYAML},
+		{"application/json, application/yaml", formatJSON},
+		{"application/json;q=0.5, application/x-yaml", formatYAML},
+		{"application/yaml;q=0", formatJSON},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest("GET", "/api/top-products", nil)
+		req.Header.Set("Accept", tc.accept)
+
+		format, err := negotiateFormat(req)
+		if err != nil {
+			t.Errorf("Accept %q: unexpected error %v", tc.accept, err)
+		}
+		if format != tc.expected {
+			t.Errorf("Accept %q: expected %s, got %s", tc.accept, tc.expected, format)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/models"
+	"abt-analytics-dashboard/internal/processor"
+	"bytes"
+	"flag"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenEnvelope is a list envelope with fixed contents, including a map
+// and most meta fields, so that its encoding only changes with the code
+func goldenEnvelope() ListResponse[models.RegionRevenue] {
+	updated := time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC)
+	timestamp := updated.Add(time.Minute)
+	minAvgOrder := 12.5
+	filtered := 3
+
+	regions := []models.RegionRevenue{
+		{Region: "Bavaria", TotalRevenue: 1234.5, ItemsSold: 42, TransactionCount: 17, AverageBasketSize: 2.47, AverageOrderValue: 72.62,
+			RevenueByCurrency: map[string]float64{"USD": 234.5, "EUR": 1000, "GBP": 0}},
+		{Region: "Ontario", TotalRevenue: 980, ItemsSold: 12, TransactionCount: 9, AverageBasketSize: 1.33, AverageOrderValue: 108.89},
+	}
+	return newListResponse(regions, Meta{
+		Description:       "Golden regions",
+		UpdatedAt:         &updated,
+		Timestamp:         &timestamp,
+		ReportingCurrency: "USD",
+		Pagination:        &Pagination{Total: 2, TotalItems: 2, TotalPages: 1, Page: 1, PageSize: 10},
+		SortBy:            "total_revenue",
+		Order:             "desc",
+		RankBy:            "revenue",
+		MinAvgOrder:       &minAvgOrder,
+		AvgOrderFiltered:  &filtered,
+		From:              "2025-01",
+		To:                "2025-03",
+	})
+}
+
+// TestEnvelopeGolden writes the same envelope repeatedly in each format and
+// compares the bytes with testdata/envelope.*.golden; run with -update to
+// rewrite them after an intended change
+func TestEnvelopeGolden(t *testing.T) {
+	proc := processor.New()
+	server := NewServer(proc, &config.Config{Port: ":8080"})
+
+	tests := []struct {
+		name   string
+		target string
+		body   func() interface{}
+	}{
+		{"envelope.json.golden", "/", func() interface{} { return goldenEnvelope() }},
+		{"envelope.camel.json.golden", "/?case=camel", func() interface{} { return goldenEnvelope() }},
+		{"envelope.yaml.golden", "/?format=yaml", func() interface{} { return goldenEnvelope() }},
+		{"envelope.fields.json.golden", "/?fields=region,total_revenue", func() interface{} { return goldenEnvelope() }},
+		{"response.json.golden", "/", func() interface{} {
+			envelope := goldenEnvelope()
+			return Response[models.RegionRevenue]{Data: envelope.Data[0], Meta: envelope.Meta}
+		}},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join("testdata", test.name)
+
+		var first []byte
+		for run := 0; run < 5; run++ {
+			rr := httptest.NewRecorder()
+			server.writeResponse(rr, httptest.NewRequest("GET", test.target, nil), 200, test.body())
+			if run == 0 {
+				first = rr.Body.Bytes()
+			} else if !bytes.Equal(rr.Body.Bytes(), first) {
+				t.Fatalf("%s: run %d encoded different bytes:\n%s\nthan the first run:\n%s", test.name, run, rr.Body.Bytes(), first)
+			}
+		}
+
+		if *updateGolden {
+			if err := os.WriteFile(path, first, 0644); err != nil {
+				t.Fatalf("Failed to update %s: %v", path, err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s (run with -update to create it): %v", path, err)
+		}
+		if !bytes.Equal(first, want) {
+			t.Errorf("%s: encoding changed; got:\n%s\nwant:\n%s", test.name, first, want)
+		}
+	}
+}
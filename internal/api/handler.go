@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/models"
+	"abt-analytics-dashboard/internal/processor"
+)
+
+// ProcessorInterface is what the API needs from the data processor.
+// *processor.Processor implements it.
+type ProcessorInterface interface {
+	GetDashboardData() *models.DashboardData
+	GetProcessingStatus() models.ProcessingStatus
+	GetProcessingHistory() []models.ProcessingRun
+	GetLastFailedReport() (models.ProcessingReport, bool)
+	RunLog(after uint64) ([]processor.RunLogLine, bool, <-chan struct{})
+
+	QueryCountryRevenuesContext(ctx context.Context, query models.CountryRevenueQuery) ([]models.CountryRevenue, int, int, error)
+	QueryCountryRevenueGroupsContext(ctx context.Context, query models.CountryRevenueQuery) ([]models.CountryRevenueGroup, int, int, error)
+	FilterTopProducts(filter models.TopProductsFilter) []models.ProductFrequency
+	CountTopProducts(filter models.TopProductsFilter) int
+	GetStockPressure() []models.StockPressure
+	SearchProductsContext(ctx context.Context, query string, limit int) ([]models.ProductFrequency, error)
+	GetWeeklySales(from, to string) []models.WeeklySales
+	GetRegionProducts(region string, limit int) ([]models.RegionProduct, bool)
+	GetCategoryProducts(category string, limit int) ([]models.CategoryProduct, bool)
+	GetCountrySummariesByName() []models.CountrySummary
+	GetCountryDetail(country string) (models.CountryDetail, bool)
+	GetCountryTopCustomers(country string) ([]models.CountryCustomer, bool)
+	GetDimensions() models.Dimensions
+	GetDashboardDiff(since time.Time) (models.DashboardDiff, *models.DashboardData, bool)
+	GetSampleTransactions(country string) ([]models.Transaction, bool)
+
+	Process(ctx context.Context, src processor.DataSource) error
+	LoadSampleData() error
+	SetLowStockThreshold(n int)
+	SetExclusions(products, countries []string)
+	GetExclusions() models.Exclusions
+	StageDataset(path string) (*processor.StagedDataset, error)
+	GetStagedDataset() (*processor.StagedDataset, error)
+	PromoteStaged() (*processor.StagedDataset, error)
+	DiscardStaged() (*processor.StagedDataset, error)
+}
+
+var _ ProcessorInterface = (*processor.Processor)(nil)
+
+// NewHandler returns the API routes and middleware as a handler, without a
+// listener, for mounting in another service's mux. Routes are relative to
+// the handler's root, so it can be mounted under a prefix with
+// http.StripPrefix, e.g. http.StripPrefix("/analytics", handler) serves
+// /analytics/api/health. Configuration reloads and the HTTP server settings
+// are left to the embedding service.
+func NewHandler(proc ProcessorInterface, cfg *config.Config) http.Handler {
+	return newServer(proc, cfg).setupRoutes()
+}
+
+// newServer creates a server without its HTTP listener
+func newServer(proc ProcessorInterface, cfg *config.Config) *Server {
+	return &Server{
+		processor: proc,
+		config:    cfg,
+		watcher:   config.Watch(cfg, "", nil),
+
+		adminLimiter: newRateLimiter(),
+		coalescer:    newCoalescer(),
+	}
+}
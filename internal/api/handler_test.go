@@ -0,0 +1,39 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/processor"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewHandlerMountedUnderPrefix(t *testing.T) {
+	proc := processor.New()
+	proc.LoadSampleData()
+
+	mux := http.NewServeMux()
+	mux.Handle("/analytics/", http.StripPrefix("/analytics", NewHandler(proc, &config.Config{Port: ":8080"})))
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/analytics/api/health", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if source := decodeResponse(t, rr)["data_source"]; source != processor.SourceSample {
+		t.Errorf("Expected data source %q, got %v", processor.SourceSample, source)
+	}
+
+	// Routes with path variables resolve below the prefix too
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/analytics/api/countries/USA", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status %d for a country below the prefix, got %d", http.StatusOK, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/health", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d outside the prefix, got %d", http.StatusNotFound, rr.Code)
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxHeaderBytes bounds the size of request headers; larger headers get 431
+const maxHeaderBytes = 64 << 10
+
+// rejectTimeout bounds how long a refused connection is kept open while its
+// 503 response is written
+const rejectTimeout = time.Second
+
+// maxConcurrentRejects bounds the refused connections being answered at
+// once; connections refused beyond it are closed without a response
+const maxConcurrentRejects = 64
+
+// connLimitListener caps the number of open connections. Unlike
+// netutil.LimitListener, which leaves extra clients waiting in Accept, it
+// accepts connections over the cap, answers them with a 503 JSON error and
+// closes them, so refused clients learn why straight away. At most
+// maxConcurrentRejects are answered at a time, so a flood of connections
+// cannot pile up goroutines; the rest are closed at once.
+type connLimitListener struct {
+	net.Listener
+	slots     chan struct{}
+	rejecting chan struct{}
+}
+
+// newConnLimitListener wraps l so that at most n connections are open at once
+func newConnLimitListener(l net.Listener, n int) net.Listener {
+	return &connLimitListener{
+		Listener:  l,
+		slots:     make(chan struct{}, n),
+		rejecting: make(chan struct{}, maxConcurrentRejects),
+	}
+}
+
+func (l *connLimitListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		select {
+		case l.slots <- struct{}{}:
+			return &limitedConn{Conn: conn, release: func() { <-l.slots }}, nil
+		default:
+		}
+
+		select {
+		case l.rejecting <- struct{}{}:
+			go func() {
+				defer func() { <-l.rejecting }()
+				rejectConn(conn)
+			}()
+		default:
+			conn.Close()
+		}
+	}
+}
+
+// limitedConn frees its listener slot when it is closed
+type limitedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+// rejectConn writes a 503 JSON error to a connection over the limit and
+// closes it
+func rejectConn(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(rejectTimeout))
+
+	body, _ := json.Marshal(newErrorResponse("Too many open connections, try again later"))
+	response := fmt.Sprintf("HTTP/1.1 503 Service Unavailable\r\n"+
+		"Content-Type: application/json\r\nContent-Length: %d\r\nRetry-After: 1\r\nConnection: close\r\n\r\n%s",
+		len(body), body)
+	if _, err := io.WriteString(conn, response); err != nil {
+		log.Printf("Error rejecting connection from %s: %v", conn.RemoteAddr(), err)
+		return
+	}
+
+	// Drain what the client sent so closing does not reset the connection
+	// before it reads the response
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.CloseWrite()
+	}
+	io.Copy(io.Discard, io.LimitReader(conn, maxHeaderBytes))
+}
+
+// bodyLimitMiddleware limits request bodies to MAX_REQUEST_BODY_BYTES.
+// Bodies declared larger are refused with 413 up front; others are wrapped
+// so reading past the limit fails with *http.MaxBytesError.
+func (s *Server) bodyLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := s.maxRequestBodyBytes()
+		if r.ContentLength > int64(limit) {
+			s.writeBodyTooLargeResponse(w, limit)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, int64(limit))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeBodyTooLargeResponse writes the 413 error for bodies over the limit
+func (s *Server) writeBodyTooLargeResponse(w http.ResponseWriter, limit int) {
+	s.writeErrorResponse(w, http.StatusRequestEntityTooLarge,
+		fmt.Sprintf("Request body exceeds the %d byte limit (MAX_REQUEST_BODY_BYTES)", limit))
+}
+
+// isBodyTooLarge reports whether err came from reading past a body limit
+func isBodyTooLarge(err error) bool {
+	var tooLarge *http.MaxBytesError
+	return errors.As(err, &tooLarge)
+}
+
+// maxRequestBodyBytes returns the configured request body size limit
+func (s *Server) maxRequestBodyBytes() int {
+	if n := s.runtimeConfig().MaxRequestBodyBytes; n > 0 {
+		return n
+	}
+	return config.DefaultMaxRequestBodyBytes
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/processor"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryBodyLimit(t *testing.T) {
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, &config.Config{Port: ":8080", MaxRequestBodyBytes: 64}).setupRoutes()
+
+	small := `{"page_size": 5}`
+	large := `{"countries": ["` + strings.Repeat("x", 100) + `"]}`
+
+	testCases := []struct {
+		name          string
+		body          string
+		contentLength int64
+		status        int
+	}{
+		{"within limit", small, int64(len(small)), http.StatusOK},
+		{"declared too large", large, int64(len(large)), http.StatusRequestEntityTooLarge},
+		{"chunked too large", large, -1, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest("POST", "/api/revenue-by-country/query", strings.NewReader(tc.body))
+		req.ContentLength = tc.contentLength
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tc.name, tc.status, rr.Code, rr.Body.String())
+			continue
+		}
+		if tc.status == http.StatusRequestEntityTooLarge {
+			var response ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("%s: failed to parse response JSON: %v", tc.name, err)
+			}
+			if !response.Error || !strings.Contains(response.Message, "MAX_REQUEST_BODY_BYTES") {
+				t.Errorf("%s: expected a JSON error naming MAX_REQUEST_BODY_BYTES, got %+v", tc.name, response)
+			}
+		}
+	}
+}
+
+func TestAdminBodyLimit(t *testing.T) {
+	proc := processor.New()
+	cfg := &config.Config{Port: ":8080", AdminAPIKey: adminTestKey, MaxRequestBodyBytes: 16}
+	router := NewServer(proc, cfg).setupRoutes()
+
+	req := httptest.NewRequest("POST", "/api/admin/sample-data", strings.NewReader(strings.Repeat("x", 17)))
+	req.Header.Set("Authorization", "Bearer "+adminTestKey)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", rr.Code)
+	}
+}
+
+// getOnConn sends a keep-alive GET on conn and returns the response
+func getOnConn(t *testing.T, conn net.Conn) *http.Response {
+	t.Helper()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte("GET /api/health HTTP/1.1\r\nHost: test\r\n\r\n")); err != nil {
+		t.Fatalf("Failed to write request: %v", err)
+	}
+	response, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	return response
+}
+
+func TestConnLimitListener(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	server := NewServer(processor.New(), &config.Config{Port: ":8080"})
+	go server.server.Serve(newConnLimitListener(listener, 1))
+	defer server.server.Close()
+
+	addr := listener.Addr().String()
+	first, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	if response := getOnConn(t, first); response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected first connection to be served, got %d", response.StatusCode)
+	}
+
+	// The first connection is idle but still open, so the second is refused
+	second, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer second.Close()
+
+	response := getOnConn(t, second)
+	if response.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 over the limit, got %d", response.StatusCode)
+	}
+	var errResponse ErrorResponse
+	if err := json.NewDecoder(response.Body).Decode(&errResponse); err != nil || !errResponse.Error {
+		t.Errorf("Expected a JSON error body, got %+v (%v)", errResponse, err)
+	}
+
+	// Closing the first connection frees its slot
+	first.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		third, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+		status := getOnConn(t, third).StatusCode
+		third.Close()
+		if status == http.StatusOK {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the slot to be freed after closing, still got %d", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestConnLimitListenerBoundsRejections(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	limited := newConnLimitListener(listener, 1).(*connLimitListener)
+	limited.rejecting = make(chan struct{}, 1)
+	server := NewServer(processor.New(), &config.Config{Port: ":8080"})
+	go server.server.Serve(limited)
+	defer server.server.Close()
+
+	addr := listener.Addr().String()
+	first, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer first.Close()
+	if response := getOnConn(t, first); response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected first connection to be served, got %d", response.StatusCode)
+	}
+
+	// The second connection is refused with a 503 and, sending nothing,
+	// keeps its rejection busy draining until rejectTimeout
+	second, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer second.Close()
+	second.SetDeadline(time.Now().Add(5 * time.Second))
+	response, err := http.ReadResponse(bufio.NewReader(second), nil)
+	if err != nil || response.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 over the limit, got %v (%v)", response, err)
+	}
+
+	// With the only rejection slot taken, the third is closed unanswered
+	third, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer third.Close()
+	third.SetDeadline(time.Now().Add(rejectTimeout / 2))
+	if n, err := third.Read(make([]byte, 1)); n != 0 || err == nil || isTimeout(err) {
+		t.Errorf("Expected the connection to be closed without a response, got %d bytes (%v)", n, err)
+	}
+}
+
+// isTimeout reports whether err is a network timeout
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"net/http"
+
+	"golang.org/x/text/language"
+)
+
+// monthLanguages are the languages month names can be localized to, English
+// first so that it is the matcher's fallback
+var monthLanguages = []language.Tag{language.English, language.French, language.German, language.Spanish}
+
+// monthNames holds January to December for each of monthLanguages
+var monthNames = map[language.Tag][12]string{
+	language.English: {"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December"},
+	language.French: {"janvier", "février", "mars", "avril", "mai", "juin",
+		"juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+	language.German: {"Januar", "Februar", "März", "April", "Mai", "Juni",
+		"Juli", "August", "September", "Oktober", "November", "Dezember"},
+	language.Spanish: {"enero", "febrero", "marzo", "abril", "mayo", "junio",
+		"julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+}
+
+var monthMatcher = language.NewMatcher(monthLanguages)
+
+// negotiateMonthLanguage returns the language of the month names for the
+// request's Accept-Language header. A missing, malformed or unsupported
+// header gets English.
+func negotiateMonthLanguage(r *http.Request) language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		return language.English
+	}
+	_, index, confidence := monthMatcher.Match(tags...)
+	if confidence == language.No {
+		return language.English
+	}
+	return monthLanguages[index]
+}
+
+// localizeMonths returns sales with LocalizedMonth set in the request's
+// language when ENABLE_LOCALIZATION is on, and sales unchanged otherwise.
+// The processor's data is shared between requests, so the months are
+// copied rather than modified; their order is kept.
+func (s *Server) localizeMonths(w http.ResponseWriter, r *http.Request, sales []models.MonthlySales) []models.MonthlySales {
+	if !s.runtimeConfig().EnableLocalization {
+		return sales
+	}
+	w.Header().Add("Vary", "Accept-Language")
+	if sales == nil {
+		return nil
+	}
+
+	names := monthNames[negotiateMonthLanguage(r)]
+	localized := make([]models.MonthlySales, len(sales))
+	copy(localized, sales)
+	for i := range localized {
+		if number := localized[i].MonthNumber; number >= 1 && number <= 12 {
+			localized[i].LocalizedMonth = names[number-1]
+		}
+	}
+	return localized
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/models"
+	"abt-analytics-dashboard/internal/processor"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// getLocalizedMonths requests /api/sales-by-month with the given
+// Accept-Language header and returns the months served
+func getLocalizedMonths(t *testing.T, handler http.Handler, acceptLanguage string) ([]models.MonthlySales, *httptest.ResponseRecorder) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/sales-by-month", nil)
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response ListResponse[models.MonthlySales]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return response.Data, rr
+}
+
+func TestLocalizedMonthNames(t *testing.T) {
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, &config.Config{Port: ":8080", EnableLocalization: true}).setupRoutes()
+
+	tests := []struct {
+		acceptLanguage string
+		want           []string
+	}{
+		{"", []string{"January", "February", "December"}},
+		{"en-US,en;q=0.9", []string{"January", "February", "December"}},
+		{"fr-FR,fr;q=0.9,en;q=0.8", []string{"janvier", "février", "décembre"}},
+		{"ja-JP", []string{"January", "February", "December"}},
+		{"not a language tag", []string{"January", "February", "December"}},
+	}
+
+	for _, test := range tests {
+		months, rr := getLocalizedMonths(t, router, test.acceptLanguage)
+		if len(months) != 12 {
+			t.Fatalf("Accept-Language %q: expected 12 months, got %d", test.acceptLanguage, len(months))
+		}
+		got := []string{months[0].LocalizedMonth, months[1].LocalizedMonth, months[11].LocalizedMonth}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("Accept-Language %q: expected %v, got %v", test.acceptLanguage, test.want, got)
+				break
+			}
+		}
+		if months[0].Month != "January" || months[0].MonthNumber != 1 {
+			t.Errorf("Accept-Language %q: expected the English month and its number to be kept, got %q (%d)",
+				test.acceptLanguage, months[0].Month, months[0].MonthNumber)
+		}
+		if vary := rr.Header().Values("Vary"); !contains(vary, "Accept-Language") {
+			t.Errorf("Accept-Language %q: expected Vary to include Accept-Language, got %v", test.acceptLanguage, vary)
+		}
+	}
+
+	// Localizing a response must not leak into the shared data
+	if shared := proc.GetDashboardData().MonthlySales[0].LocalizedMonth; shared != "" {
+		t.Errorf("Expected the processor's months to stay unlocalized, got %q", shared)
+	}
+}
+
+func TestLocalizedMonthsDisabled(t *testing.T) {
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+
+	months, rr := getLocalizedMonths(t, router, "fr-FR")
+	for _, month := range months {
+		if month.LocalizedMonth != "" {
+			t.Fatalf("Expected no localized month when localization is disabled, got %q", month.LocalizedMonth)
+		}
+	}
+	if vary := rr.Header().Values("Vary"); contains(vary, "Accept-Language") {
+		t.Errorf("Expected Vary not to include Accept-Language, got %v", vary)
+	}
+}
+
+func TestLocalizedMonthsInDashboard(t *testing.T) {
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, &config.Config{Port: ":8080", EnableLocalization: true}).setupRoutes()
+
+	req := httptest.NewRequest("GET", "/api/dashboard", nil)
+	req.Header.Set("Accept-Language", "fr")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var response struct {
+		Data struct {
+			MonthlySales []models.MonthlySales `json:"monthly_sales"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Data.MonthlySales) == 0 || response.Data.MonthlySales[0].LocalizedMonth != "janvier" {
+		t.Errorf("Expected the dashboard's first month to be localized to janvier, got %+v", response.Data.MonthlySales)
+	}
+}
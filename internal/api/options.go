@@ -0,0 +1,133 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// QueryParam describes a query parameter accepted by a route
+type QueryParam struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// RouteOptionsResponse describes a route in reply to OPTIONS
+type RouteOptionsResponse struct {
+	Path            string       `json:"path"`
+	Methods         []string     `json:"methods"`
+	QueryParameters []QueryParam `json:"query_parameters"`
+}
+
+// responseParams are accepted by every route answering with a response
+// envelope through writeResponse
+var responseParams = []QueryParam{
+	{Name: "format", Description: "Response format: json (default), yaml, or ndjson for lists"},
+	{Name: "fields", Description: "Comma-separated fields to keep in each data row"},
+	{Name: "case", Description: "Key casing: snake (default) or camel"},
+}
+
+// limitParam is the result count of the product ranking routes
+var limitParam = QueryParam{Name: "limit", Description: "Number of results, 1-50 (default 10)"}
+
+// routeQueryParams lists the query parameters of each route by its path
+// template. Routes missing here take none.
+var routeQueryParams = map[string][]QueryParam{
+	"/api/health": {
+		{Name: "verbose", Description: "Set to true to add runtime statistics"},
+	},
+	"/api/revenue-by-country": withResponseParams(
+		QueryParam{Name: "countries", Description: "Comma-separated countries to include"},
+		QueryParam{Name: "products", Description: "Comma-separated products to include"},
+		QueryParam{Name: "min_revenue", Description: "Smallest total revenue of a row"},
+		QueryParam{Name: "min_avg_order", Description: "Smallest average order value of a row"},
+		QueryParam{Name: "max_avg_order", Description: "Largest average order value of a row"},
+		QueryParam{Name: "sort_by", Description: "Field to sort rows by (default total_revenue)"},
+		QueryParam{Name: "order", Description: "Sort order: asc or desc"},
+		QueryParam{Name: "page", Description: "Page number, from 1"},
+		QueryParam{Name: "page_size", Description: "Rows per page"},
+		QueryParam{Name: "shape", Description: "flat (default) for one row per country and product, or nested to group products by country"},
+	),
+	"/api/revenue-by-country/query": withResponseParams(),
+	"/api/top-products": withResponseParams(
+		QueryParam{Name: "rank_by", Description: "Ranking: orders (default) or units"},
+		QueryParam{Name: "max_stock", Description: "Only products with at most this much stock"},
+		QueryParam{Name: "out_of_stock", Description: "Set to true for products without stock only"},
+		QueryParam{Name: "active_since", Description: "Only products sold on or after this date (YYYY-MM-DD)"},
+	),
+	"/api/stock-pressure": withResponseParams(),
+	"/api/sales-by-month": withResponseParams(),
+	"/api/sales-by-week": withResponseParams(
+		QueryParam{Name: "from", Description: "First ISO week to include, such as 2024-W01"},
+		QueryParam{Name: "to", Description: "Last ISO week to include"},
+	),
+	"/api/top-regions": withResponseParams(
+		QueryParam{Name: "rank_by", Description: "Ranking: revenue (default) or items"},
+	),
+	"/api/dashboard":                          withResponseParams(),
+	"/api/dashboard/diff":                     withResponseParams(QueryParam{Name: "since", Description: "RFC 3339 time to report changes since, such as the last_updated seen before"}),
+	"/api/summary":                            withResponseParams(),
+	"/api/processing-status":                  withResponseParams(),
+	"/api/processing-report":                  withResponseParams(),
+	"/api/regions/{region}/products":          withResponseParams(limitParam),
+	"/api/categories/{category}/top-products": withResponseParams(limitParam),
+	"/api/products/search": withResponseParams(
+		QueryParam{Name: "q", Description: "Text to search product names for"},
+		limitParam,
+	),
+	"/api/countries": withResponseParams(
+		QueryParam{Name: "sort_by", Description: "Order: total_revenue (default) or country"},
+	),
+	"/api/countries/{country}":                withResponseParams(),
+	"/api/countries/{country}/sales-by-month": withResponseParams(),
+	"/api/countries/{country}/top-customers":  withResponseParams(),
+	"/api/dimensions":                         withResponseParams(),
+	"/api/admin/sample-transactions":          withResponseParams(QueryParam{Name: "country", Description: "Country to list the sample of; the overall sample when absent"}),
+	"/api/admin/processing-log":               {{Name: "after", Description: "Sequence number to resume after, like Last-Event-ID"}},
+}
+
+func withResponseParams(params ...QueryParam) []QueryParam {
+	return append(params, responseParams...)
+}
+
+// registerOptionsRoutes answers OPTIONS on every API route registered so
+// far with an Allow header listing the route's methods and a description of
+// its query parameters. OPTIONS on unknown paths still gets a 404.
+func (s *Server) registerOptionsRoutes(router *mux.Router) {
+	var paths []string
+	methods := make(map[string][]string)
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(path, "/api") {
+			return nil
+		}
+		routeMethods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		if _, seen := methods[path]; !seen {
+			paths = append(paths, path)
+		}
+		methods[path] = append(methods[path], routeMethods...)
+		return nil
+	})
+
+	for _, path := range paths {
+		allowed := methods[path]
+		sort.Strings(allowed)
+		allowed = append(allowed, http.MethodOptions)
+
+		params := routeQueryParams[path]
+		if params == nil {
+			params = make([]QueryParam, 0)
+		}
+		response := RouteOptionsResponse{Path: path, Methods: allowed, QueryParameters: params}
+		allow := strings.Join(allowed, ", ")
+		router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Allow", allow)
+			s.writeJSONResponse(w, http.StatusOK, response)
+		}).Methods(http.MethodOptions)
+	}
+}
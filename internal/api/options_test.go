@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// optionsRequest sends OPTIONS to target and decodes the route description
+func optionsRequest(t *testing.T, router http.Handler, target string) (*httptest.ResponseRecorder, RouteOptionsResponse) {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("OPTIONS", target, nil))
+
+	var response RouteOptionsResponse
+	if rr.Code == http.StatusOK {
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response JSON: %v", err)
+		}
+	}
+	return rr, response
+}
+
+func TestOptionsDataRoute(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr, response := optionsRequest(t, router, "/api/top-regions")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if allow := rr.Header().Get("Allow"); allow != "GET, OPTIONS" {
+		t.Errorf("Expected Allow 'GET, OPTIONS', got '%s'", allow)
+	}
+	if origin := rr.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
+		t.Errorf("Expected the CORS headers on OPTIONS, got origin '%s'", origin)
+	}
+	if response.Path != "/api/top-regions" || strings.Join(response.Methods, ",") != "GET,OPTIONS" {
+		t.Errorf("Expected the top regions route, got %+v", response)
+	}
+	params := make(map[string]bool)
+	for _, param := range response.QueryParameters {
+		params[param.Name] = param.Description != ""
+	}
+	for _, name := range []string{"rank_by", "format", "fields", "case"} {
+		if !params[name] {
+			t.Errorf("Expected a described %s parameter, got %+v", name, response.QueryParameters)
+		}
+	}
+
+	// Routes with path variables match any value
+	rr, response = optionsRequest(t, router, "/api/countries/Germany")
+	if rr.Code != http.StatusOK || response.Path != "/api/countries/{country}" {
+		t.Errorf("Expected the country detail route, got %d %+v", rr.Code, response)
+	}
+
+	rr, _ = optionsRequest(t, router, "/api/no-such-route")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown path, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestOptionsAdminRoute(t *testing.T) {
+	_, router := newAdminTestServer(t)
+
+	// Preflight requests carry no credentials, so they skip admin auth
+	for _, target := range []string{"/api/admin/upload", "/api/upload"} {
+		rr, response := optionsRequest(t, router, target)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d", target, http.StatusOK, rr.Code)
+		}
+		if allow := rr.Header().Get("Allow"); allow != "POST, OPTIONS" {
+			t.Errorf("%s: expected Allow 'POST, OPTIONS', got '%s'", target, allow)
+		}
+		if len(response.QueryParameters) != 0 {
+			t.Errorf("%s: expected no query parameters, got %+v", target, response.QueryParameters)
+		}
+	}
+
+	rr, response := optionsRequest(t, router, "/api/admin/processing-log")
+	if rr.Code != http.StatusOK || len(response.QueryParameters) != 1 || response.QueryParameters[0].Name != "after" {
+		t.Errorf("Expected the processing log's after parameter, got %d %+v", rr.Code, response)
+	}
+}
+
+func TestOptionsDescribesEveryRoute(t *testing.T) {
+	router := newQueryTestRouter()
+
+	for path := range routeQueryParams {
+		target := strings.NewReplacer("{region}", "Europe", "{category}", "Audio", "{country}", "Germany").Replace(path)
+		rr, response := optionsRequest(t, router, target)
+		if rr.Code != http.StatusOK || response.Path != path {
+			t.Errorf("Expected OPTIONS %s to describe %s, got %d %+v", target, path, rr.Code, response)
+		}
+	}
+}
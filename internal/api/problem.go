@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// problemContentType is the media type of problem details bodies (RFC 7807)
+const problemContentType = "application/problem+json"
+
+// invalidParamsProblem is the problem type of requests rejected for one or
+// more invalid parameters or body fields
+const invalidParamsProblem = "/problems/invalid-params"
+
+// InvalidParam names a rejected request parameter and why it was rejected
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ProblemResponse is an RFC 7807 problem details body
+type ProblemResponse struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
+
+// writeProblem writes a problem details body as application/problem+json
+func (s *Server) writeProblem(w http.ResponseWriter, problem ProblemResponse) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		log.Printf("Error encoding problem response: %v", err)
+	}
+}
+
+// writeValidationErrorResponse rejects a request with 400, reporting every
+// invalid field at once. The body is a problem details document, or the
+// legacy error envelope with an errors list while LEGACY_ERROR_ENVELOPE is
+// set.
+func (s *Server) writeValidationErrorResponse(w http.ResponseWriter, errs []fieldError) {
+	if s.runtimeConfig().LegacyErrorEnvelope {
+		response := newErrorResponse("Invalid request")
+		response.Errors = errs
+		s.writeJSONResponse(w, http.StatusBadRequest, response)
+		return
+	}
+
+	params := make([]InvalidParam, len(errs))
+	names := make([]string, len(errs))
+	for i, fe := range errs {
+		params[i] = InvalidParam{Name: fe.Field, Reason: fe.Message}
+		names[i] = fe.Field
+	}
+	s.writeProblem(w, ProblemResponse{
+		Type:          invalidParamsProblem,
+		Title:         "Invalid request",
+		Status:        http.StatusBadRequest,
+		Detail:        fmt.Sprintf("Invalid value for %s", strings.Join(names, ", ")),
+		InvalidParams: params,
+	})
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// decodeProblem parses a problem details response, failing unless it is
+// served as application/problem+json
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder) ProblemResponse {
+	t.Helper()
+
+	if contentType := rr.Header().Get("Content-Type"); contentType != problemContentType {
+		t.Errorf("Expected Content-Type %s, got %s", problemContentType, contentType)
+	}
+	var problem ProblemResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Failed to parse problem JSON: %v", err)
+	}
+	return problem
+}
+
+func TestValidationProblemReportsAllParams(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/revenue-by-country?min_revenue=lots&order=sideways&page=x", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	problem := decodeProblem(t, rr)
+	if problem.Type != invalidParamsProblem || problem.Title != "Invalid request" || problem.Status != http.StatusBadRequest {
+		t.Errorf("Expected an invalid params problem, got %+v", problem)
+	}
+	if problem.Detail != "Invalid value for min_revenue, page, order" {
+		t.Errorf("Expected the detail to name every parameter, got %q", problem.Detail)
+	}
+
+	want := []string{"min_revenue", "page", "order"}
+	if len(problem.InvalidParams) != len(want) {
+		t.Fatalf("Expected %d invalid params, got %+v", len(want), problem.InvalidParams)
+	}
+	for i, name := range want {
+		if param := problem.InvalidParams[i]; param.Name != name || param.Reason == "" {
+			t.Errorf("Expected a reason for %s, got %+v", name, param)
+		}
+	}
+}
+
+func TestValidationProblemForLimit(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/products/search?q=cam&limit=500", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	params := decodeProblem(t, rr).InvalidParams
+	if len(params) != 1 || params[0].Name != "limit" || params[0].Reason != "must be an integer between 1 and 50" {
+		t.Errorf("Expected the limit to be reported, got %+v", params)
+	}
+}
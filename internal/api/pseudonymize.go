@@ -0,0 +1,137 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"sync"
+)
+
+// userIDTag marks string fields holding a user identifier, as in
+// `pii:"user"`. When PSEUDONYMIZE_USERS is enabled those fields leave the
+// API as a keyed hash instead of the raw ID.
+const userIDTag = "pii"
+
+// pseudonymizeUserID returns the hex HMAC-SHA256 of id keyed by secret, so
+// the same user gets the same pseudonym in every response. An empty id
+// stays empty.
+func pseudonymizeUserID(secret, id string) string {
+	if id == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// pseudonymizeUsers returns body with every user ID field replaced by its
+// pseudonym when PSEUDONYMIZE_USERS is enabled. The processor's data is
+// shared between requests, so values holding user IDs are copied rather
+// than modified; bodies without user IDs are returned as they are.
+func (s *Server) pseudonymizeUsers(body interface{}) interface{} {
+	cfg := s.runtimeConfig()
+	if !cfg.PseudonymizeUsers || body == nil {
+		return body
+	}
+	v := reflect.ValueOf(body)
+	if !hasUserIDs(v.Type()) {
+		return body
+	}
+	return pseudonymizeValue(v, cfg.UserHashSecret).Interface()
+}
+
+// userIDTypes caches whether a type holds user ID fields, directly or in
+// nested values
+var userIDTypes sync.Map
+
+func hasUserIDs(t reflect.Type) bool {
+	if cached, ok := userIDTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	found := typeHasUserIDs(t, make(map[reflect.Type]bool))
+	userIDTypes.Store(t, found)
+	return found
+}
+
+// typeHasUserIDs inspects t, skipping types already being visited so that
+// recursive types terminate
+func typeHasUserIDs(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return typeHasUserIDs(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.IsExported() && (field.Tag.Get(userIDTag) == "user" || typeHasUserIDs(field.Type, visiting)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pseudonymizeValue returns a copy of v with user ID fields hashed. Only
+// the parts of v that hold user IDs are copied.
+func pseudonymizeValue(v reflect.Value, secret string) reflect.Value {
+	t := v.Type()
+	if !hasUserIDs(t) {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(t.Elem())
+		out.Elem().Set(pseudonymizeValue(v.Elem(), secret))
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(pseudonymizeValue(v.Index(i), secret))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(t).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(pseudonymizeValue(v.Index(i), secret))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(t, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), pseudonymizeValue(iter.Value(), secret))
+		}
+		return out
+	case reflect.Struct:
+		out := reflect.New(t).Elem()
+		out.Set(v)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Tag.Get(userIDTag) == "user" && field.Type.Kind() == reflect.String {
+				out.Field(i).SetString(pseudonymizeUserID(secret, v.Field(i).String()))
+				continue
+			}
+			out.Field(i).Set(pseudonymizeValue(v.Field(i), secret))
+		}
+		return out
+	}
+	return v
+}
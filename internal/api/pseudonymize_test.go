@@ -0,0 +1,158 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/models"
+	"abt-analytics-dashboard/internal/processor"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testUserHashSecret = "test-secret"
+
+// newPseudonymizeTestServer returns a server and two handlers that send the
+// same transactions as a list and as a single object, the way data
+// endpoints exposing user IDs would
+func newPseudonymizeTestServer(enabled bool, transactions []models.Transaction) (*Server, http.Handler, http.Handler) {
+	cfg := &config.Config{Port: ":8080", PseudonymizeUsers: enabled, UserHashSecret: testUserHashSecret}
+	server := NewServer(processor.New(), cfg)
+
+	list := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.writeResponse(w, r, http.StatusOK, newListResponse(transactions, Meta{}))
+	})
+	single := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.writeResponse(w, r, http.StatusOK, Response[*models.Transaction]{Data: &transactions[0]})
+	})
+	return server, list, single
+}
+
+func TestPseudonymizeUserID(t *testing.T) {
+	hash := pseudonymizeUserID(testUserHashSecret, "U1")
+	if len(hash) != 64 || strings.Contains(hash, "U1") {
+		t.Errorf("Expected a 64-character hex hash, got %q", hash)
+	}
+	if again := pseudonymizeUserID(testUserHashSecret, "U1"); again != hash {
+		t.Errorf("Expected a stable hash, got %q and %q", hash, again)
+	}
+	if other := pseudonymizeUserID("other-secret", "U1"); other == hash {
+		t.Error("Expected a different secret to give a different hash")
+	}
+	if empty := pseudonymizeUserID(testUserHashSecret, ""); empty != "" {
+		t.Errorf("Expected an empty ID to stay empty, got %q", empty)
+	}
+}
+
+func TestPseudonymizedUsersAcrossResponses(t *testing.T) {
+	transactions := []models.Transaction{
+		{TransactionID: "T1", UserID: "USER-42", ProductName: "Laptop"},
+		{TransactionID: "T2", UserID: "USER-7", ProductName: "Mouse"},
+	}
+	_, list, single := newPseudonymizeTestServer(true, transactions)
+	want := pseudonymizeUserID(testUserHashSecret, "USER-42")
+
+	rr := httptest.NewRecorder()
+	list.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if strings.Contains(rr.Body.String(), "USER-") {
+		t.Errorf("Expected no raw user IDs in the list response, got %s", rr.Body.String())
+	}
+	var listResponse ListResponse[models.Transaction]
+	if err := json.Unmarshal(rr.Body.Bytes(), &listResponse); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if listResponse.Data[0].UserID != want || listResponse.Data[0].ProductName != "Laptop" {
+		t.Errorf("Expected user %s with other fields intact, got %+v", want, listResponse.Data[0])
+	}
+
+	rr = httptest.NewRecorder()
+	single.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	var singleResponse Response[models.Transaction]
+	if err := json.Unmarshal(rr.Body.Bytes(), &singleResponse); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if singleResponse.Data.UserID != want {
+		t.Errorf("Expected the same pseudonym %s in both responses, got %s", want, singleResponse.Data.UserID)
+	}
+
+	rr = httptest.NewRecorder()
+	list.ServeHTTP(rr, httptest.NewRequest("GET", "/?format=yaml", nil))
+	if strings.Contains(rr.Body.String(), "USER-") || !strings.Contains(rr.Body.String(), want) {
+		t.Errorf("Expected only pseudonyms in the YAML response, got %s", rr.Body.String())
+	}
+
+	// The served data keeps the raw IDs
+	if transactions[0].UserID != "USER-42" {
+		t.Errorf("Expected the source data to be unchanged, got %s", transactions[0].UserID)
+	}
+}
+
+func TestUsersNotPseudonymizedWhenDisabled(t *testing.T) {
+	transactions := []models.Transaction{{TransactionID: "T1", UserID: "USER-42"}}
+	_, list, _ := newPseudonymizeTestServer(false, transactions)
+
+	rr := httptest.NewRecorder()
+	list.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(rr.Body.String(), `"user_id":"USER-42"`) {
+		t.Errorf("Expected the raw user ID when disabled, got %s", rr.Body.String())
+	}
+}
+
+func TestPseudonymizeLeavesOtherBodiesAlone(t *testing.T) {
+	server, _, _ := newPseudonymizeTestServer(true, []models.Transaction{{}})
+	products := []models.ProductFrequency{{ProductName: "Laptop"}}
+	body := newListResponse(products, Meta{})
+
+	got := server.pseudonymizeUsers(body).(ListResponse[models.ProductFrequency])
+	if &got.Data[0] != &products[0] {
+		t.Error("Expected a body without user IDs not to be copied")
+	}
+}
+
+func TestCountryTopCustomersPseudonymized(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "customers.csv")
+	content := "transaction_id,user_id,country,product_name,quantity,total_price\n" +
+		"TXN001,USER-42,Germany,Laptop,1,500\n" +
+		"TXN002,USER-7,Germany,Mouse,1,40\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+	proc := processor.New()
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	for _, enabled := range []bool{false, true} {
+		cfg := &config.Config{Port: ":8080", PseudonymizeUsers: enabled, UserHashSecret: testUserHashSecret}
+		router := NewServer(proc, cfg).setupRoutes()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries/Germany/top-customers", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		var response ListResponse[models.CountryCustomer]
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response JSON: %v", err)
+		}
+		want := "USER-42"
+		if enabled {
+			want = pseudonymizeUserID(testUserHashSecret, "USER-42")
+		}
+		if len(response.Data) != 2 || response.Data[0].UserID != want || response.Data[0].TotalRevenue != 500 {
+			t.Errorf("Expected %s as the top customer (pseudonymized: %v), got %+v", want, enabled, response.Data)
+		}
+		if response.Meta.Country != "Germany" {
+			t.Errorf("Expected meta country Germany, got %s", response.Meta.Country)
+		}
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries/Atlantis/top-customers", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for an unknown country, got %d", http.StatusNotFound, rr.Code)
+		}
+	}
+}
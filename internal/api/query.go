@@ -0,0 +1,239 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"abt-analytics-dashboard/internal/processor"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPageSize bounds the page_size of paged queries
+const maxPageSize = 10000
+
+// fieldError describes a single invalid request field
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// decodeCountryRevenueQuery decodes a JSON query body field by field so that
+// every malformed or unknown field is reported, not just the first one
+func decodeCountryRevenueQuery(body io.Reader) (models.CountryRevenueQuery, []fieldError) {
+	var query models.CountryRevenueQuery
+
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		return query, []fieldError{{Field: "body", Message: fmt.Sprintf("invalid JSON object: %v", err)}}
+	}
+
+	targets := map[string]interface{}{
+		"countries":     &query.Countries,
+		"products":      &query.Products,
+		"min_revenue":   &query.MinRevenue,
+		"min_avg_order": &query.MinAvgOrder,
+		"max_avg_order": &query.MaxAvgOrder,
+		"sort_by":       &query.SortBy,
+		"order":         &query.Order,
+		"page":          &query.Page,
+		"page_size":     &query.PageSize,
+		"shape":         &query.Shape,
+	}
+
+	errs := make([]fieldError, 0)
+	for _, field := range sortedKeys(raw) {
+		target, ok := targets[field]
+		if !ok {
+			errs = append(errs, fieldError{Field: field, Message: "unknown field"})
+			continue
+		}
+		if err := json.Unmarshal(raw[field], target); err != nil {
+			errs = append(errs, fieldError{Field: field, Message: fmt.Sprintf("invalid value: %s", jsonTypeMessage(err))})
+		}
+	}
+
+	return query, append(errs, validateCountryRevenueQuery(&query)...)
+}
+
+// parseCountryRevenueQuery builds a query from GET query parameters. List
+// parameters are comma-separated.
+func parseCountryRevenueQuery(values url.Values) (models.CountryRevenueQuery, []fieldError) {
+	var query models.CountryRevenueQuery
+	errs := make([]fieldError, 0)
+
+	query.Countries = splitList(values.Get("countries"))
+	query.Products = splitList(values.Get("products"))
+	query.SortBy = values.Get("sort_by")
+	query.Order = values.Get("order")
+	query.Shape = values.Get("shape")
+
+	if value := values.Get("min_revenue"); value != "" {
+		minRevenue, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			errs = append(errs, fieldError{Field: "min_revenue", Message: "must be a number"})
+		}
+		query.MinRevenue = minRevenue
+	}
+	for field, target := range map[string]**float64{"min_avg_order": &query.MinAvgOrder, "max_avg_order": &query.MaxAvgOrder} {
+		if value := values.Get(field); value != "" {
+			bound, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				errs = append(errs, fieldError{Field: field, Message: "must be a number"})
+				continue
+			}
+			*target = &bound
+		}
+	}
+	for field, target := range map[string]*int{"page": &query.Page, "page_size": &query.PageSize} {
+		if value := values.Get(field); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fieldError{Field: field, Message: "must be an integer"})
+			}
+			*target = parsed
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+
+	return query, append(errs, validateCountryRevenueQuery(&query)...)
+}
+
+// validateCountryRevenueQuery checks field values and applies defaults
+func validateCountryRevenueQuery(query *models.CountryRevenueQuery) []fieldError {
+	errs := make([]fieldError, 0)
+
+	if query.MinRevenue < 0 {
+		errs = append(errs, fieldError{Field: "min_revenue", Message: "must not be negative"})
+	}
+	if query.MinAvgOrder != nil && *query.MinAvgOrder < 0 {
+		errs = append(errs, fieldError{Field: "min_avg_order", Message: "must not be negative"})
+	}
+	if query.MaxAvgOrder != nil && *query.MaxAvgOrder < 0 {
+		errs = append(errs, fieldError{Field: "max_avg_order", Message: "must not be negative"})
+	} else if query.MinAvgOrder != nil && query.MaxAvgOrder != nil && *query.MaxAvgOrder < *query.MinAvgOrder {
+		errs = append(errs, fieldError{Field: "max_avg_order", Message: "must not be less than min_avg_order"})
+	}
+
+	if query.SortBy == "" {
+		query.SortBy = processor.SortByTotalRevenue
+	} else if !contains(processor.CountryRevenueSortFields, query.SortBy) {
+		errs = append(errs, fieldError{
+			Field:   "sort_by",
+			Message: fmt.Sprintf("must be one of %s", strings.Join(processor.CountryRevenueSortFields, ", ")),
+		})
+	}
+
+	if query.Order != "" && query.Order != "asc" && query.Order != "desc" {
+		errs = append(errs, fieldError{Field: "order", Message: "must be asc or desc"})
+	}
+
+	if query.Page < 0 {
+		errs = append(errs, fieldError{Field: "page", Message: "must be at least 1"})
+	} else if query.Page == 0 {
+		query.Page = 1
+	}
+
+	if query.PageSize < 0 || query.PageSize > maxPageSize {
+		errs = append(errs, fieldError{Field: "page_size", Message: fmt.Sprintf("must be between 1 and %d", maxPageSize)})
+	}
+
+	switch query.Shape {
+	case "":
+		query.Shape = models.ShapeFlat
+	case models.ShapeFlat, models.ShapeNested:
+	default:
+		errs = append(errs, fieldError{Field: "shape", Message: fmt.Sprintf("must be %s or %s", models.ShapeFlat, models.ShapeNested)})
+	}
+
+	return errs
+}
+
+// parseTopProductsFilter reads the top products ranking and stock filters
+// from URL query parameters
+func parseTopProductsFilter(values url.Values) (models.TopProductsFilter, []fieldError) {
+	var filter models.TopProductsFilter
+	errs := make([]fieldError, 0)
+
+	if value := values.Get("max_stock"); value != "" {
+		maxStock, err := strconv.Atoi(value)
+		if err != nil || maxStock < 0 {
+			errs = append(errs, fieldError{Field: "max_stock", Message: "must be a non-negative integer"})
+		} else {
+			filter.MaxStock = &maxStock
+		}
+	}
+
+	if value := values.Get("out_of_stock"); value != "" {
+		outOfStock, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fieldError{Field: "out_of_stock", Message: "must be true or false"})
+		} else {
+			filter.OutOfStock = outOfStock
+		}
+	}
+
+	if value := values.Get("active_since"); value != "" {
+		activeSince, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			errs = append(errs, fieldError{Field: "active_since", Message: "must be a date (YYYY-MM-DD)"})
+		} else {
+			filter.ActiveSince = activeSince
+		}
+	}
+
+	switch value := values.Get("rank_by"); value {
+	case "", models.RankByOrders:
+		filter.RankBy = models.RankByOrders
+	case models.RankByUnits:
+		filter.RankBy = value
+	default:
+		errs = append(errs, fieldError{Field: "rank_by", Message: "must be one of: orders, units"})
+	}
+
+	return filter, errs
+}
+
+// jsonTypeMessage shortens json.UnmarshalTypeError messages for clients
+func jsonTypeMessage(err error) string {
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		return fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value)
+	}
+	return err.Error()
+}
+
+// splitList splits a comma-separated parameter, dropping empty entries
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/models"
+	"abt-analytics-dashboard/internal/processor"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newQueryTestRouter() http.Handler {
+	proc := processor.New()
+	proc.LoadSampleData()
+	return NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+}
+
+func decodeResponse(t *testing.T, rr *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	return response
+}
+
+func TestQueryCountryRevenuesMatchesGet(t *testing.T) {
+	router := newQueryTestRouter()
+
+	testCases := []struct {
+		body  string
+		query string
+	}{
+		{`{}`, ""},
+		{`{"countries": ["USA", "uk"]}`, "?countries=USA,uk"},
+		{`{"products": ["Laptop"], "min_revenue": 20000}`, "?products=Laptop&min_revenue=20000"},
+		{`{"countries": ["USA"], "min_avg_order": 90, "max_avg_order": 110.5}`, "?countries=USA&min_avg_order=90&max_avg_order=110.5"},
+		{`{"sort_by": "country", "order": "desc", "page": 2, "page_size": 5}`, "?sort_by=country&order=desc&page=2&page_size=5"},
+	}
+
+	for _, tc := range testCases {
+		postRR := httptest.NewRecorder()
+		router.ServeHTTP(postRR, httptest.NewRequest("POST", "/api/revenue-by-country/query", strings.NewReader(tc.body)))
+
+		getRR := httptest.NewRecorder()
+		router.ServeHTTP(getRR, httptest.NewRequest("GET", "/api/revenue-by-country"+tc.query, nil))
+
+		if postRR.Code != http.StatusOK || getRR.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200 for both, got POST %d and GET %d", tc.body, postRR.Code, getRR.Code)
+		}
+
+		post := decodeResponse(t, postRR)
+		get := decodeResponse(t, getRR)
+
+		if !reflect.DeepEqual(post["data"], get["data"]) {
+			t.Errorf("%s: expected POST and GET data to match", tc.body)
+		}
+		if post["count"] != get["count"] {
+			t.Errorf("%s: expected matching counts, got %v and %v", tc.body, post["count"], get["count"])
+		}
+		if !reflect.DeepEqual(post["meta"], get["meta"]) {
+			t.Errorf("%s: expected matching meta, got %v and %v", tc.body, post["meta"], get["meta"])
+		}
+	}
+}
+
+func TestQueryCountryRevenuesFilters(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	body := `{"countries": ["USA"], "min_revenue": 30000, "page_size": 3}`
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/revenue-by-country/query", strings.NewReader(body)))
+
+	response := decodeResponse(t, rr)
+	data, ok := response["data"].([]interface{})
+	if !ok {
+		t.Fatal("Expected data to be an array")
+	}
+	if len(data) > 3 {
+		t.Errorf("Expected at most 3 rows, got %d", len(data))
+	}
+	for _, item := range data {
+		row := item.(map[string]interface{})
+		if row["country"] != "USA" {
+			t.Errorf("Expected only USA rows, got %v", row["country"])
+		}
+		if row["total_revenue"].(float64) < 30000 {
+			t.Errorf("Expected revenue >= 30000, got %v", row["total_revenue"])
+		}
+	}
+}
+
+func TestQueryCountryRevenuesValidation(t *testing.T) {
+	router := newQueryTestRouter()
+
+	body := `{"countries": "USA", "min_revenue": -5, "sort_by": "price", "order": "sideways", "page": -1, "page_size": 50000, "colour": "red"}`
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/revenue-by-country/query", strings.NewReader(body)))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	problem := decodeProblem(t, rr)
+	fields := make(map[string]bool)
+	for _, param := range problem.InvalidParams {
+		fields[param.Name] = true
+	}
+	for _, field := range []string{"countries", "min_revenue", "sort_by", "order", "page", "page_size", "colour"} {
+		if !fields[field] {
+			t.Errorf("Expected an error for field '%s', got %v", field, problem.InvalidParams)
+		}
+	}
+}
+
+func TestQueryCountryRevenuesInvalidJSON(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/revenue-by-country/query", strings.NewReader(`{"countries": [`)))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestGetCountryRevenuesInvalidParams(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/revenue-by-country?min_revenue=lots&page=x&page_size=y", nil))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	if params := decodeProblem(t, rr).InvalidParams; len(params) != 3 {
+		t.Errorf("Expected 3 field errors, got %v", params)
+	}
+}
+
+func TestGetCountryRevenuesNested(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/revenue-by-country?shape=nested&countries=USA,Japan", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var response ListResponse[models.CountryRevenueGroup]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Count != 2 || response.Meta.Shape != models.ShapeNested || response.Meta.TotalItems != 2 {
+		t.Fatalf("Expected 2 nested countries, got %d with meta %+v", response.Count, response.Meta)
+	}
+	if response.Data[0].TotalRevenue < response.Data[1].TotalRevenue {
+		t.Errorf("Expected countries by total revenue, got %v before %v", response.Data[0].TotalRevenue, response.Data[1].TotalRevenue)
+	}
+	for _, group := range response.Data {
+		sum := 0.0
+		for _, product := range group.Products {
+			sum += product.TotalRevenue
+		}
+		if math.Abs(group.TotalRevenue-sum) > 1e-6 {
+			t.Errorf("Expected %s total %v to equal the sum of its products %v", group.Country, group.TotalRevenue, sum)
+		}
+		if len(group.Products) == 0 {
+			t.Errorf("Expected products for %s", group.Country)
+		}
+	}
+
+	// The flat shape stays the default
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/revenue-by-country?countries=USA", nil))
+	rows, _ := decodeResponse(t, rr)["data"].([]interface{})
+	if len(rows) == 0 || rows[0].(map[string]interface{})["product_name"] == nil {
+		t.Errorf("Expected flat rows by default, got %v", rows)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/revenue-by-country?shape=tree", nil))
+	if params := decodeProblem(t, rr).InvalidParams; rr.Code != http.StatusBadRequest || len(params) != 1 || params[0].Name != "shape" {
+		t.Errorf("Expected the shape to be rejected, got %d %+v", rr.Code, params)
+	}
+}
+
+func TestGetCountryRevenuesAvgOrderBounds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.csv")
+	content := "transaction_id,transaction_date,country,region,product_name,quantity,total_price\n" +
+		"TXN001,2024-01-01,USA,North America,Laptop,1,100\n" +
+		"TXN002,2024-01-02,USA,North America,Laptop,1,100\n" +
+		"TXN003,2024-01-03,USA,North America,Phone,1,300\n" +
+		"TXN004,2024-01-04,USA,North America,Cable,1,10\n" +
+		"TXN005,2024-01-05,UK,Europe,Phone,1,100\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+	proc := processor.New()
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/revenue-by-country?countries=USA&min_avg_order=100&max_avg_order=300&page_size=1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var response ListResponse[models.CountryRevenue]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Count != 1 || response.Data[0].ProductName != "Phone" || response.Data[0].AverageOrderValue != 300 {
+		t.Errorf("Expected the USA Phone row first, got %+v", response.Data)
+	}
+	if response.Meta.Pagination == nil || response.Meta.Total != 2 {
+		t.Errorf("Expected a total of 2 rows within the bounds, got %+v", response.Meta.Pagination)
+	}
+	if response.Meta.AvgOrderFiltered == nil || *response.Meta.AvgOrderFiltered != 1 {
+		t.Errorf("Expected 1 row filtered out by the bounds, got %v", response.Meta.AvgOrderFiltered)
+	}
+	if response.Meta.MinAvgOrder == nil || *response.Meta.MinAvgOrder != 100 || *response.Meta.MaxAvgOrder != 300 {
+		t.Errorf("Expected the bounds to be echoed in meta, got %v and %v", response.Meta.MinAvgOrder, response.Meta.MaxAvgOrder)
+	}
+}
+
+func TestGetCountryRevenuesAvgOrderValidation(t *testing.T) {
+	router := newQueryTestRouter()
+
+	testCases := []struct {
+		query string
+		field string
+	}{
+		{"min_avg_order=cheap", "min_avg_order"},
+		{"max_avg_order=-1", "max_avg_order"},
+		{"min_avg_order=200&max_avg_order=100", "max_avg_order"},
+	}
+	for _, tc := range testCases {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/revenue-by-country?"+tc.query, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", tc.query, http.StatusBadRequest, rr.Code)
+			continue
+		}
+		params := decodeProblem(t, rr).InvalidParams
+		if len(params) != 1 || params[0].Name != tc.field {
+			t.Errorf("%s: expected one error for %s, got %v", tc.query, tc.field, params)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/revenue-by-country?min_avg_order=100&max_avg_order=100", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected equal bounds to be accepted, got status %d", rr.Code)
+	}
+}
+
+func TestCountryEndpointsReportFoldedRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tail.csv")
+	content := "product_name,quantity,total_price,country\n" +
+		"Laptop,1,1000,USA\nCable,1,1,USA\nPlug,1,1,USA\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+
+	for _, threshold := range []float64{0, 1} {
+		proc := processor.New()
+		proc.SetOtherBucketThreshold(threshold)
+		if err := proc.ProcessDataset(path); err != nil {
+			t.Fatalf("Failed to process dataset: %v", err)
+		}
+		router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+
+		for _, target := range []string{"/api/revenue-by-country", "/api/countries", "/api/countries/USA"} {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", target, nil))
+			meta, _ := decodeResponse(t, rr)["meta"].(map[string]interface{})
+
+			folded, ok := meta["folded_rows"]
+			if threshold == 0 && ok {
+				t.Errorf("%s: expected no folded_rows when folding is off, got %v", target, folded)
+			}
+			if threshold > 0 && (folded != float64(2) || meta["other_bucket_threshold"] != threshold) {
+				t.Errorf("%s: expected 2 folded rows at threshold %g, got %v", target, threshold, meta)
+			}
+		}
+	}
+}
+
+func TestCanceledRequestsWriteNothing(t *testing.T) {
+	router := newQueryTestRouter()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	requests := []*http.Request{
+		httptest.NewRequest("GET", "/api/revenue-by-country", nil),
+		httptest.NewRequest("GET", "/api/revenue-by-country?shape=nested", nil),
+		httptest.NewRequest("POST", "/api/revenue-by-country/query", strings.NewReader(`{"countries":["USA"]}`)),
+		httptest.NewRequest("GET", "/api/products/search?q=lap", nil),
+	}
+	for _, req := range requests {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req.WithContext(ctx))
+		if rr.Body.Len() != 0 || rr.Header().Get("Content-Type") != "" {
+			t.Errorf("%s %s: expected nothing written after cancellation, got %q", req.Method, req.URL, rr.Body.String())
+		}
+	}
+}
+
+func TestTimedOutRequestIsUnavailable(t *testing.T) {
+	router := newQueryTestRouter()
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/products/search?q=lap", nil).WithContext(ctx))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d past the deadline, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// maxRateLimitClients bounds the number of clients tracked by a
+// rateLimiter; idle clients are forgotten once it is reached
+const maxRateLimitClients = 10000
+
+// rateLimiter is a per-client token bucket. Each client may make a burst of
+// up to perMinute requests, refilled at perMinute per minute.
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*tokenBucket), now: time.Now}
+}
+
+// allow takes a token from client's bucket. When the bucket is empty it
+// returns false and how long until the next token is available.
+func (l *rateLimiter) allow(client string, perMinute int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	capacity := float64(perMinute)
+	rate := capacity / float64(time.Minute)
+
+	bucket, exists := l.buckets[client]
+	if !exists {
+		if len(l.buckets) >= maxRateLimitClients {
+			l.forgetIdle(now, rate, capacity)
+		}
+		bucket = &tokenBucket{tokens: capacity, last: now}
+		l.buckets[client] = bucket
+	}
+
+	bucket.tokens = math.Min(capacity, bucket.tokens+float64(now.Sub(bucket.last))*rate)
+	bucket.last = now
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - bucket.tokens) / rate)
+}
+
+// forgetIdle drops the buckets that have refilled completely, as they
+// behave the same as a new bucket
+func (l *rateLimiter) forgetIdle(now time.Time, rate, capacity float64) {
+	for client, bucket := range l.buckets {
+		if bucket.tokens+float64(now.Sub(bucket.last))*rate >= capacity {
+			delete(l.buckets, client)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterRefills(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter()
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 6; i++ {
+		if ok, _ := limiter.allow("a", 6); !ok {
+			t.Fatalf("Expected request %d within the burst to be allowed", i+1)
+		}
+	}
+	ok, retryAfter := limiter.allow("a", 6)
+	if ok {
+		t.Fatal("Expected the seventh request to be limited")
+	}
+	if retryAfter != 10*time.Second {
+		t.Errorf("Expected a retry after 10s, got %v", retryAfter)
+	}
+	if ok, _ := limiter.allow("b", 6); !ok {
+		t.Error("Expected another client to have its own bucket")
+	}
+
+	now = now.Add(10 * time.Second)
+	if ok, _ := limiter.allow("a", 6); !ok {
+		t.Error("Expected a token to be refilled after 10s")
+	}
+	if ok, _ := limiter.allow("a", 6); ok {
+		t.Error("Expected only one token to be refilled")
+	}
+}
+
+func TestRateLimiterForgetsIdleClients(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter()
+	limiter.now = func() time.Time { return now }
+
+	limiter.allow("busy", 60)
+	limiter.allow("busy", 60)
+	limiter.allow("idle", 60)
+	now = now.Add(time.Second)
+
+	// idle has refilled its one token, busy is still one short
+	limiter.forgetIdle(now, 60/float64(time.Minute), 60)
+	if _, ok := limiter.buckets["busy"]; !ok {
+		t.Error("Expected a partly refilled bucket to be kept")
+	}
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Error("Expected a refilled bucket to be forgotten")
+	}
+	now = now.Add(time.Minute)
+	limiter.forgetIdle(now, 60/float64(time.Minute), 60)
+	if len(limiter.buckets) != 0 {
+		t.Errorf("Expected full buckets to be forgotten, got %d", len(limiter.buckets))
+	}
+}
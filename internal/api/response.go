@@ -0,0 +1,237 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"net/http"
+	"time"
+)
+
+// Pagination describes the page of results returned by a paged list. It is
+// embedded in Meta, so its fields appear directly in the meta object.
+// OutOfRange flags a page past the last one, which is returned empty with
+// status 200.
+type Pagination struct {
+	Total      int  `json:"total"`
+	TotalItems int  `json:"total_items"`
+	TotalPages int  `json:"total_pages"`
+	Page       int  `json:"page"`
+	PageSize   int  `json:"page_size"`
+	HasNext    bool `json:"has_next"`
+	HasPrev    bool `json:"has_prev"`
+	OutOfRange bool `json:"out_of_range,omitempty"`
+}
+
+// newPagination describes page of a list of total items split into pages
+// of pageSize. A zero pageSize returns every item on a single page.
+func newPagination(total, page, pageSize int) *Pagination {
+	totalPages := 0
+	switch {
+	case total == 0:
+	case pageSize <= 0:
+		totalPages = 1
+	default:
+		totalPages = (total + pageSize - 1) / pageSize
+	}
+
+	return &Pagination{
+		Total:      total,
+		TotalItems: total,
+		TotalPages: totalPages,
+		Page:       page,
+		PageSize:   pageSize,
+		HasNext:    page < totalPages,
+		HasPrev:    page > 1,
+		OutOfRange: page > 1 && page > totalPages,
+	}
+}
+
+// Meta is the metadata object shared by every response envelope. Fields
+// that do not apply to an endpoint are left zero and omitted.
+type Meta struct {
+	Description       string     `json:"description,omitempty"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+	Timestamp         *time.Time `json:"timestamp,omitempty"`
+	ReportingCurrency string     `json:"reporting_currency,omitempty"`
+	SampleData        bool       `json:"sample_data,omitempty"`
+	*Pagination
+	SortBy               string     `json:"sort_by,omitempty"`
+	Shape                string     `json:"shape,omitempty"`
+	RankBy               string     `json:"rank_by,omitempty"`
+	Order                string     `json:"order,omitempty"`
+	Region               string     `json:"region,omitempty"`
+	Country              string     `json:"country,omitempty"`
+	Category             string     `json:"category,omitempty"`
+	Query                string     `json:"query,omitempty"`
+	Limit                int        `json:"limit,omitempty"`
+	Retention            string     `json:"retention,omitempty"`
+	MaxStock             *int       `json:"max_stock,omitempty"`
+	MinAvgOrder          *float64   `json:"min_avg_order,omitempty"`
+	MaxAvgOrder          *float64   `json:"max_avg_order,omitempty"`
+	AvgOrderFiltered     *int       `json:"avg_order_filtered,omitempty"`
+	FoldedRows           *int       `json:"folded_rows,omitempty"`
+	OtherBucketThreshold float64    `json:"other_bucket_threshold,omitempty"`
+	OutOfStock           bool       `json:"out_of_stock,omitempty"`
+	ActiveSince          string     `json:"active_since,omitempty"`
+	From                 string     `json:"from,omitempty"`
+	To                   string     `json:"to,omitempty"`
+	DataStartDate        *time.Time `json:"data_start_date,omitempty"`
+	DataEndDate          *time.Time `json:"data_end_date,omitempty"`
+
+	// Truncated and TotalAvailable are set when a ranking holds fewer
+	// entries than the data it was cut from
+	Truncated      bool `json:"truncated,omitempty"`
+	TotalAvailable int  `json:"total_available,omitempty"`
+}
+
+// markTruncated flags meta as truncated when available entries exceed the
+// returned ones
+func (m *Meta) markTruncated(returned, available int) {
+	if available > returned {
+		m.Truncated = true
+		m.TotalAvailable = available
+	}
+}
+
+// ListResponse is the envelope of list endpoints. It is streamed element by
+// element when written as JSON.
+type ListResponse[T any] struct {
+	Count int  `json:"count"`
+	Data  []T  `json:"data"`
+	Meta  Meta `json:"meta"`
+}
+
+// newListResponse builds a list envelope, inferring the element type
+func newListResponse[T any](items []T, meta Meta) ListResponse[T] {
+	return ListResponse[T]{Count: len(items), Data: items, Meta: meta}
+}
+
+func (l ListResponse[T]) streamJSON(w http.ResponseWriter, statusCode int, rename func(string) string) {
+	writeJSONList(w, statusCode, l.Data, l.Meta, rename)
+}
+
+func (l ListResponse[T]) streamNDJSON(w http.ResponseWriter, statusCode int, rename func(string) string) {
+	writeNDJSONList(w, statusCode, l.Data, ndjsonSummary{Type: "summary", Count: len(l.Data), LastUpdated: l.Meta.UpdatedAt}, rename)
+}
+
+// Response is the envelope of endpoints that return a single object
+type Response[T any] struct {
+	Data T    `json:"data"`
+	Meta Meta `json:"meta"`
+}
+
+// DashboardResponse is the complete dashboard envelope, streamed when
+// written as JSON
+type DashboardResponse Response[*models.DashboardData]
+
+func (d DashboardResponse) streamJSON(w http.ResponseWriter, statusCode int, rename func(string) string) {
+	writeDashboardJSON(w, statusCode, d.Data, d.Meta, rename)
+}
+
+// ErrorResponse is the envelope of every error. Errors lists the invalid
+// fields of a rejected request; Panic and Stack describe a recovered panic
+// outside production.
+type ErrorResponse struct {
+	Error     bool         `json:"error"`
+	Message   string       `json:"message"`
+	Errors    []fieldError `json:"errors,omitempty"`
+	Panic     string       `json:"panic,omitempty"`
+	Stack     string       `json:"stack,omitempty"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// newErrorResponse builds an error envelope stamped with the current time
+func newErrorResponse(message string) ErrorResponse {
+	return ErrorResponse{Error: true, Message: message, Timestamp: time.Now()}
+}
+
+// RootResponse describes the service and lists its endpoints
+type RootResponse struct {
+	Service     string            `json:"service"`
+	Version     string            `json:"version"`
+	Status      string            `json:"status"`
+	Environment string            `json:"environment"`
+	Endpoints   map[string]string `json:"endpoints"`
+}
+
+// HealthResponse is the flat body of /api/health, kept flat so that health
+// checks can read the status field directly
+type HealthResponse struct {
+	Status             string        `json:"status"`
+	Timestamp          time.Time     `json:"timestamp"`
+	LastDataUpdate     time.Time     `json:"last_data_update"`
+	ProcessingDuration string        `json:"processing_duration"`
+	RecordCount        int           `json:"record_count"`
+	DataSource         string        `json:"data_source"`
+	DistinctProducts   int           `json:"distinct_products"`
+	DistinctCountries  int           `json:"distinct_countries"`
+	DistinctRegions    int           `json:"distinct_regions"`
+	DistinctUsers      int           `json:"distinct_users"`
+	DataStartDate      *time.Time    `json:"data_start_date"`
+	DataEndDate        *time.Time    `json:"data_end_date"`
+	Runtime            *RuntimeStats `json:"runtime,omitempty"`
+}
+
+// RuntimeStats is the runtime section of a verbose health check
+type RuntimeStats struct {
+	GoVersion        string    `json:"go_version"`
+	Goroutines       int       `json:"goroutines"`
+	HeapAllocBytes   uint64    `json:"heap_alloc_bytes"`
+	HeapSysBytes     uint64    `json:"heap_sys_bytes"`
+	GCCount          uint32    `json:"gc_count"`
+	GCPauseTotalNs   uint64    `json:"gc_pause_total_ns"`
+	GCPauseTotal     string    `json:"gc_pause_total"`
+	Uptime           string    `json:"uptime"`
+	UptimeSeconds    float64   `json:"uptime_seconds"`
+	ProcessStartedAt time.Time `json:"process_started_at"`
+}
+
+// SampleDataResponse reports the data served after a sample data request
+type SampleDataResponse struct {
+	Changed     bool      `json:"changed"`
+	DataSource  string    `json:"data_source"`
+	LastUpdated time.Time `json:"last_updated"`
+	RecordCount int       `json:"record_count"`
+}
+
+// ConfigResponse is the effective configuration keyed by environment
+// variable, with secrets redacted, and the variables a reload can change
+type ConfigResponse struct {
+	Settings   map[string]interface{} `json:"settings"`
+	Reloadable []string               `json:"reloadable"`
+}
+
+// ExclusionsResponse lists the products and countries configured to be
+// excluded and the rows each exclusion left out of the served data.
+// Pending is set when the configured exclusions differ from those the
+// served data was processed with, until the dataset is reloaded.
+type ExclusionsResponse struct {
+	Configured models.Exclusions      `json:"configured"`
+	Applied    []models.ExcludedTotal `json:"applied"`
+	Pending    bool                   `json:"pending"`
+}
+
+// ConfigReloadResponse lists the variables a configuration reload applied
+// and the changed ones that need a restart
+type ConfigReloadResponse struct {
+	Changed []string `json:"changed"`
+	Ignored []string `json:"ignored"`
+}
+
+// UploadResponse reports the data served after a successful upload or the
+// promotion of a staged dataset
+type UploadResponse struct {
+	DataSource  string    `json:"data_source"`
+	LastUpdated time.Time `json:"last_updated"`
+	RecordCount int       `json:"record_count"`
+	Rows        int       `json:"rows"`
+	SkippedRows int       `json:"skipped_rows"`
+}
+
+// timeOrNil returns nil for the zero time so it encodes as null or is
+// omitted
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
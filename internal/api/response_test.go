@@ -0,0 +1,158 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/models"
+	"abt-analytics-dashboard/internal/processor"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetaOmitsUnusedFields(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-regions", nil))
+
+	var response struct {
+		Meta map[string]json.RawMessage `json:"meta"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+
+	for _, key := range []string{"description", "updated_at", "reporting_currency"} {
+		if _, ok := response.Meta[key]; !ok {
+			t.Errorf("Expected meta.%s to be present", key)
+		}
+	}
+	for _, key := range []string{"total", "page", "page_size", "sort_by", "region", "max_stock", "timestamp"} {
+		if _, ok := response.Meta[key]; ok {
+			t.Errorf("Expected meta.%s to be omitted, got %s", key, response.Meta[key])
+		}
+	}
+}
+
+func TestMetaFlattensPagination(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/revenue-by-country?page=2&page_size=5", nil))
+
+	var response ListResponse[models.CountryRevenue]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Meta.Pagination == nil {
+		t.Fatal("Expected pagination fields in meta")
+	}
+	if response.Meta.Page != 2 || response.Meta.PageSize != 5 || response.Meta.Total <= 5 {
+		t.Errorf("Expected page 2 of size 5, got %+v", *response.Meta.Pagination)
+	}
+	if response.Count != 5 {
+		t.Errorf("Expected count 5, got %d", response.Count)
+	}
+}
+
+func TestPaginationBoundaries(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/revenue-by-country?page_size=5", nil))
+	var first ListResponse[models.CountryRevenue]
+	if err := json.Unmarshal(rr.Body.Bytes(), &first); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	total := first.Meta.TotalItems
+	lastPage := (total + 4) / 5
+	if first.Meta.TotalPages != lastPage || !first.Meta.HasNext || first.Meta.HasPrev || first.Meta.OutOfRange {
+		t.Errorf("Expected page 1 of %d with a next page only, got %+v", lastPage, *first.Meta.Pagination)
+	}
+
+	tests := []struct {
+		page       int
+		count      int
+		hasNext    bool
+		hasPrev    bool
+		outOfRange bool
+	}{
+		{lastPage, total - (lastPage-1)*5, false, true, false},
+		{lastPage + 1, 0, false, true, true},
+		{999, 0, false, true, true},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", fmt.Sprintf("/api/revenue-by-country?page=%d&page_size=5", tt.page), nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("page %d: expected status %d, got %d", tt.page, http.StatusOK, rr.Code)
+			continue
+		}
+
+		var response ListResponse[models.CountryRevenue]
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response JSON: %v", err)
+		}
+		meta := response.Meta.Pagination
+		if response.Count != tt.count || meta.HasNext != tt.hasNext || meta.HasPrev != tt.hasPrev || meta.OutOfRange != tt.outOfRange {
+			t.Errorf("page %d: expected %d rows, has_next %v, has_prev %v, out_of_range %v, got %d rows and %+v",
+				tt.page, tt.count, tt.hasNext, tt.hasPrev, tt.outOfRange, response.Count, *meta)
+		}
+		if meta.TotalItems != total || meta.TotalPages != lastPage {
+			t.Errorf("page %d: expected %d items on %d pages, got %+v", tt.page, total, lastPage, *meta)
+		}
+	}
+}
+
+func TestNewPagination(t *testing.T) {
+	tests := []struct {
+		total, page, pageSize int
+		expected              Pagination
+	}{
+		{0, 1, 10, Pagination{Page: 1, PageSize: 10}},
+		{0, 2, 10, Pagination{Page: 2, PageSize: 10, HasPrev: true, OutOfRange: true}},
+		{25, 1, 0, Pagination{Total: 25, TotalItems: 25, TotalPages: 1, Page: 1}},
+		{25, 2, 10, Pagination{Total: 25, TotalItems: 25, TotalPages: 3, Page: 2, PageSize: 10, HasNext: true, HasPrev: true}},
+		{30, 3, 10, Pagination{Total: 30, TotalItems: 30, TotalPages: 3, Page: 3, PageSize: 10, HasPrev: true}},
+	}
+	for _, tt := range tests {
+		if got := newPagination(tt.total, tt.page, tt.pageSize); *got != tt.expected {
+			t.Errorf("newPagination(%d, %d, %d): expected %+v, got %+v", tt.total, tt.page, tt.pageSize, tt.expected, *got)
+		}
+	}
+}
+
+func TestListResponseKeyOrder(t *testing.T) {
+	encoded, err := json.Marshal(newListResponse([]string{"a"}, Meta{Description: "d"}))
+	if err != nil {
+		t.Fatalf("Failed to marshal list response: %v", err)
+	}
+
+	body := string(encoded)
+	if !(strings.Index(body, `"count"`) < strings.Index(body, `"data"`) && strings.Index(body, `"data"`) < strings.Index(body, `"meta"`)) {
+		t.Errorf("Expected count, data, meta order, got %s", body)
+	}
+}
+
+func TestErrorResponseShape(t *testing.T) {
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, &config.Config{Port: ":8080", LegacyErrorEnvelope: true}).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/revenue-by-country?page=abc&order=sideways", nil))
+
+	var response ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if !response.Error || response.Message != "Invalid request" || len(response.Errors) != 2 || response.Timestamp.IsZero() {
+		t.Errorf("Expected a populated validation error envelope, got %+v", response)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected the legacy envelope as application/json, got %s", contentType)
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runLogKeepAlive is how often an idle processing log stream sends a
+// comment, so that proxies do not close it
+const runLogKeepAlive = 15 * time.Second
+
+// streamProcessingLog streams the log lines of the current or most recent
+// processing run as server-sent events, one event per line with the line's
+// sequence number as its id. The stream stays open while the run goes on
+// and closes with an "end" event once it has finished. Reconnecting clients
+// resume after the Last-Event-ID header, or the ?after= parameter.
+func (s *Server) streamProcessingLog(w http.ResponseWriter, r *http.Request) {
+	after, err := runLogCursor(r)
+	if err != nil {
+		s.writeValidationErrorResponse(w, []fieldError{{Field: "after", Message: err.Error()}})
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	keepAlive := time.NewTicker(runLogKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		lines, running, changed := s.processor.RunLog(after)
+		for _, line := range lines {
+			fmt.Fprintf(w, "id: %d\n", line.Seq)
+			for _, text := range strings.Split(line.Message, "\n") {
+				fmt.Fprintf(w, "data: %s\n", text)
+			}
+			fmt.Fprint(w, "\n")
+			after = line.Seq
+		}
+		if !running {
+			fmt.Fprint(w, "event: end\ndata: processing finished\n\n")
+			rc.Flush()
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// runLogCursor returns the sequence number after which to stream lines
+func runLogCursor(r *http.Request) (uint64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("after")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	after, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.New("must be a non-negative integer")
+	}
+	return after, nil
+}
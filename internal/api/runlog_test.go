@@ -0,0 +1,135 @@
+package api
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readEvent reads one server-sent event, skipping comments
+func readEvent(t *testing.T, reader *bufio.Reader) (id, event, data string) {
+	t.Helper()
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if id != "" || event != "" || data != "" {
+				return id, event, data
+			}
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestProcessingLogStreamsRun(t *testing.T) {
+	proc, router := newAdminTestServer(t)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// The run waits on the pipe until the test sends the rows
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- proc.ProcessReader(pr) }()
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if _, running, _ := proc.RunLog(0); running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the run to start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	req, _ := http.NewRequest("GET", server.URL+"/api/admin/processing-log", nil)
+	req.Header.Set("Authorization", "Bearer "+adminTestKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %q", contentType)
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	// Lines logged so far arrive while the run is still going
+	_, _, data := readEvent(t, reader)
+	if !strings.HasPrefix(data, "Starting") {
+		t.Errorf("Expected the first line of the run, got %q", data)
+	}
+
+	io.WriteString(pw, "transaction_id,product_name,quantity,total_price\nTXN001,Laptop,1,100\n")
+	pw.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Expected no processing error, got %v", err)
+	}
+
+	var messages []string
+	for {
+		_, event, data := readEvent(t, reader)
+		if event == "end" {
+			break
+		}
+		messages = append(messages, data)
+	}
+	if len(messages) == 0 || !strings.HasPrefix(messages[len(messages)-1], "Data processing completed") {
+		t.Errorf("Expected the rest of the run before the end event, got %v", messages)
+	}
+}
+
+func TestProcessingLogAfterRun(t *testing.T) {
+	proc, router := newAdminTestServer(t)
+	lines, _, _ := proc.RunLog(0)
+
+	req := httptest.NewRequest("GET", "/api/admin/processing-log", nil)
+	req.Header.Set("Authorization", "Bearer "+adminTestKey)
+	req.Header.Set("Last-Event-ID", "1")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	body := rr.Body.String()
+	if strings.Contains(body, "id: 1\n") || !strings.Contains(body, "id: 2\n") {
+		t.Errorf("Expected the stream to resume after id 1, got %q", body)
+	}
+	if strings.Count(body, "id: ") != len(lines)-1 || !strings.HasSuffix(body, "event: end\ndata: processing finished\n\n") {
+		t.Errorf("Expected the remaining %d lines and an end event, got %q", len(lines)-1, body)
+	}
+}
+
+func TestProcessingLogErrors(t *testing.T) {
+	_, router := newAdminTestServer(t)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/admin/processing-log", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without the admin key, got %d", http.StatusUnauthorized, rr.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/api/admin/processing-log?after=-1", nil)
+	req.Header.Set("Authorization", "Bearer "+adminTestKey)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a bad cursor, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
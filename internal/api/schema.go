@@ -0,0 +1,219 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// schemaDialect is the JSON Schema version of the published schemas
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaTag marks struct fields whose encoding differs from what their Go
+// type implies: `jsonschema:"nullable"` is set on fields a custom
+// MarshalJSON encodes as null, such as zero times
+const schemaTag = "jsonschema"
+
+// responseSchemas maps each endpoint, by its name in the root endpoint
+// list, to the type of its response envelope. Schemas are generated from
+// these types, so they follow the structs as they change.
+var responseSchemas = map[string]reflect.Type{
+	"health":             reflect.TypeOf(HealthResponse{}),
+	"country_revenues":   reflect.TypeOf(ListResponse[models.CountryRevenue]{}),
+	"country_query":      reflect.TypeOf(ListResponse[models.CountryRevenue]{}),
+	"country_groups":     reflect.TypeOf(ListResponse[models.CountryRevenueGroup]{}),
+	"top_products":       reflect.TypeOf(ListResponse[models.ProductFrequency]{}),
+	"stock_pressure":     reflect.TypeOf(ListResponse[models.StockPressure]{}),
+	"monthly_sales":      reflect.TypeOf(ListResponse[models.MonthlySales]{}),
+	"weekly_sales":       reflect.TypeOf(ListResponse[models.WeeklySales]{}),
+	"top_regions":        reflect.TypeOf(ListResponse[models.RegionRevenue]{}),
+	"complete_dashboard": reflect.TypeOf(DashboardResponse{}),
+	"dashboard_diff":     reflect.TypeOf(Response[models.DashboardDiff]{}),
+	"summary":            reflect.TypeOf(Response[models.Summary]{}),
+	"processing_status":  reflect.TypeOf(Response[models.ProcessingStatus]{}),
+	"processing_report":  reflect.TypeOf(Response[models.ProcessingReport]{}),
+	"failed_report":      reflect.TypeOf(Response[models.ProcessingReport]{}),
+	"region_products":    reflect.TypeOf(ListResponse[models.RegionProduct]{}),
+	"category_products":  reflect.TypeOf(ListResponse[models.CategoryProduct]{}),
+	"product_search":     reflect.TypeOf(ListResponse[models.ProductFrequency]{}),
+	"countries":          reflect.TypeOf(ListResponse[models.CountrySummary]{}),
+	"country_detail":     reflect.TypeOf(Response[models.CountryDetail]{}),
+	"country_sales":      reflect.TypeOf(ListResponse[models.MonthlySales]{}),
+	"country_customers":  reflect.TypeOf(ListResponse[models.CountryCustomer]{}),
+	"dimensions":         reflect.TypeOf(Response[models.Dimensions]{}),
+	"error":              reflect.TypeOf(ErrorResponse{}),
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaGenerator builds a JSON Schema from Go types following the
+// encoding/json rules. Named struct types are emitted once under $defs and
+// referenced from where they are used.
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+// generateSchema returns the JSON Schema document of values of type t
+func generateSchema(name string, t reflect.Type) map[string]interface{} {
+	g := &schemaGenerator{defs: make(map[string]interface{})}
+	schema := g.structSchema(t)
+	schema["$schema"] = schemaDialect
+	schema["title"] = name
+	if len(g.defs) > 0 {
+		schema["$defs"] = g.defs
+	}
+	return schema
+}
+
+// typeSchema returns the schema of t, or a reference to it for named
+// struct types
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		return nullable(g.typeSchema(t.Elem()))
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return nullable(map[string]interface{}{"type": "array", "items": g.typeSchema(t.Elem())})
+	case reflect.Map:
+		return nullable(map[string]interface{}{"type": "object", "additionalProperties": g.typeSchema(t.Elem())})
+	case reflect.Struct:
+		name := schemaName(t)
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = nil // placeholder for recursive types
+			g.defs[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	default:
+		// interface{} values may hold anything
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema returns the object schema of struct type t. Fields without
+// omitempty are required and unknown properties are rejected.
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+	g.addFields(t, properties, &required, false)
+	sort.Strings(required)
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// addFields adds the encoded fields of struct type t. Fields of embedded
+// structs are promoted; those of embedded pointers are optional, since a
+// nil pointer encodes none of them.
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]interface{}, required *[]string, optional bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				g.addFields(embedded.Elem(), properties, required, true)
+				continue
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(embedded, properties, required, optional)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := g.typeSchema(field.Type)
+		if field.Tag.Get(schemaTag) == "nullable" {
+			schema = nullable(schema)
+		}
+		properties[name] = schema
+		if !optional && !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// schemaName is the $defs key of a struct type: its name, with generic
+// type arguments reduced to their own names
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	if open := strings.IndexByte(name, '['); open >= 0 {
+		args := strings.Split(strings.TrimSuffix(name[open+1:], "]"), ",")
+		for i, arg := range args {
+			args[i] = strings.TrimLeft(arg[strings.LastIndexByte(arg, '.')+1:], "*")
+		}
+		name = name[:open] + "_" + strings.Join(args, "_")
+	}
+	return name
+}
+
+// nullable widens schema to also accept null
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typ, "null"}
+		return schema
+	}
+	return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+}
+
+// getSchemaIndex lists the published response schemas by endpoint name
+func (s *Server) getSchemaIndex(w http.ResponseWriter, r *http.Request) {
+	index := make(map[string]string, len(responseSchemas))
+	for name := range responseSchemas {
+		index[name] = "/api/schema/" + name
+	}
+	response := Response[map[string]string]{
+		Data: index,
+		Meta: Meta{Description: "JSON Schemas of the response envelopes, keyed by endpoint name as listed at /api"},
+	}
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// getSchema serves the JSON Schema of one endpoint's response envelope
+func (s *Server) getSchema(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["endpoint"]
+	t, ok := responseSchemas[name]
+	if !ok {
+		s.writeErrorResponse(w, http.StatusNotFound, "No schema for endpoint "+name)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(generateSchema(name, t)); err != nil {
+		log.Printf("Error encoding JSON schema: %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/processor"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// validateSchema checks value, decoded from JSON, against the subset of
+// JSON Schema that generateSchema emits and returns every violation
+func validateSchema(root, schema map[string]interface{}, value interface{}, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		defs, _ := root["$defs"].(map[string]interface{})
+		target, ok := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: unresolved $ref %s", path, ref)}
+		}
+		return validateSchema(root, target, value, path)
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		for _, option := range anyOf {
+			if len(validateSchema(root, option.(map[string]interface{}), value, path)) == 0 {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("%s: matches no anyOf option", path)}
+	}
+
+	if typ, ok := schema["type"]; ok && !matchesType(typ, value) {
+		return []string{fmt.Sprintf("%s: expected type %v, got %T", path, typ, value)}
+	}
+
+	var errs []string
+	switch v := value.(type) {
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				errs = append(errs, validateSchema(root, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := v[name.(string)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing required property %s", path, name))
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if property, ok := properties[key].(map[string]interface{}); ok {
+				errs = append(errs, validateSchema(root, property, v[key], path+"."+key)...)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					errs = append(errs, fmt.Sprintf("%s: unexpected property %s", path, key))
+				}
+			case map[string]interface{}:
+				errs = append(errs, validateSchema(root, additional, v[key], path+"."+key)...)
+			}
+		}
+	}
+	return errs
+}
+
+// matchesType reports whether value has one of the JSON types in typ
+func matchesType(typ interface{}, value interface{}) bool {
+	types, ok := typ.([]interface{})
+	if !ok {
+		types = []interface{}{typ}
+	}
+	for _, t := range types {
+		switch t {
+		case "null":
+			if value == nil {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "integer":
+			if n, ok := value.(float64); ok && n == float64(int64(n)) {
+				return true
+			}
+		case "number":
+			if _, ok := value.(float64); ok {
+				return true
+			}
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]interface{}); ok {
+				return true
+			}
+		case "object":
+			if _, ok := value.(map[string]interface{}); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getJSON serves a GET request for target and decodes the response body
+func getJSON(t *testing.T, router http.Handler, target string) (int, interface{}) {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", target, nil))
+
+	var body interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to parse %s response JSON: %v", target, err)
+	}
+	return rr.Code, body
+}
+
+func newSchemaTestRouter() http.Handler {
+	proc := processor.New()
+	proc.LoadSampleData()
+	return NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+}
+
+func TestTopProductsMatchesPublishedSchema(t *testing.T) {
+	router := newSchemaTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/schema/top_products", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/schema+json" {
+		t.Errorf("Expected Content-Type application/schema+json, got %s", contentType)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &schema); err != nil {
+		t.Fatalf("Failed to parse schema JSON: %v", err)
+	}
+	if schema["$schema"] != schemaDialect {
+		t.Errorf("Expected $schema %s, got %v", schemaDialect, schema["$schema"])
+	}
+
+	status, body := getJSON(t, router, "/api/top-products")
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, status)
+	}
+	if errs := validateSchema(schema, schema, body, "$"); len(errs) > 0 {
+		t.Errorf("Expected /api/top-products to match its schema, got %v", errs)
+	}
+
+	// The schema must reject a response that breaks the contract
+	product := body.(map[string]interface{})["data"].([]interface{})[0].(map[string]interface{})
+	delete(product, "units_sold")
+	product["unexpected"] = true
+	errs := validateSchema(schema, schema, body, "$")
+	if len(errs) != 2 {
+		t.Errorf("Expected a missing and an unexpected property, got %v", errs)
+	}
+}
+
+func TestResponsesMatchPublishedSchemas(t *testing.T) {
+	router := newSchemaTestRouter()
+
+	tests := []struct {
+		schema string
+		target string
+	}{
+		{"health", "/api/health"},
+		{"country_revenues", "/api/revenue-by-country"},
+		{"country_groups", "/api/revenue-by-country?shape=nested"},
+		{"monthly_sales", "/api/sales-by-month"},
+		{"weekly_sales", "/api/sales-by-week"},
+		{"top_regions", "/api/top-regions"},
+		{"complete_dashboard", "/api/dashboard"},
+		{"dashboard_diff", "/api/dashboard/diff?since=2000-01-01T00:00:00Z"},
+		{"summary", "/api/summary"},
+		{"processing_status", "/api/processing-status"},
+		{"processing_report", "/api/processing-report"},
+		{"region_products", "/api/regions/Europe/products"},
+		{"category_products", "/api/categories/Audio/top-products"},
+		{"product_search", "/api/products/search?q=a"},
+		{"countries", "/api/countries"},
+		{"country_detail", "/api/countries/Germany"},
+		{"country_sales", "/api/countries/Germany/sales-by-month"},
+		{"country_customers", "/api/countries/Germany/top-customers"},
+		{"dimensions", "/api/dimensions"},
+		{"error", "/api/countries/Atlantis"},
+	}
+
+	for _, tt := range tests {
+		status, schema := getJSON(t, router, "/api/schema/"+tt.schema)
+		if status != http.StatusOK {
+			t.Errorf("Expected schema %s to be published, got status %d", tt.schema, status)
+			continue
+		}
+		_, body := getJSON(t, router, tt.target)
+		root := schema.(map[string]interface{})
+		if errs := validateSchema(root, root, body, "$"); len(errs) > 0 {
+			t.Errorf("Expected %s to match schema %s, got %v", tt.target, tt.schema, errs)
+		}
+	}
+}
+
+func TestSchemaIndex(t *testing.T) {
+	router := newSchemaTestRouter()
+
+	status, body := getJSON(t, router, "/api/schema")
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, status)
+	}
+	index := body.(map[string]interface{})["data"].(map[string]interface{})
+	if len(index) != len(responseSchemas) {
+		t.Errorf("Expected %d schemas in the index, got %d", len(responseSchemas), len(index))
+	}
+	if index["top_products"] != "/api/schema/top_products" {
+		t.Errorf("Expected the top_products schema link, got %v", index["top_products"])
+	}
+
+	if status, _ := getJSON(t, router, "/api/schema/unknown"); status != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown endpoint, got %d", http.StatusNotFound, status)
+	}
+}
@@ -2,41 +2,141 @@ package api
 
 import (
 	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/metrics"
+	"abt-analytics-dashboard/internal/models"
 	"abt-analytics-dashboard/internal/processor"
+	"abt-analytics-dashboard/internal/tracing"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
+// processStart records when the process started, for uptime reporting
+var processStart = time.Now()
+
 // Server represents the HTTP server
 type Server struct {
 	server    *http.Server
-	processor *processor.Processor
+	processor ProcessorInterface
 	config    *config.Config
+
+	// watcher holds the effective configuration, including the settings
+	// that can be reloaded at runtime
+	watcher *config.Watcher
+
+	// adminLimiter enforces ADMIN_RATE_LIMIT per client on admin routes
+	adminLimiter *rateLimiter
+
+	// coalescer shares responses among identical concurrent requests to
+	// the expensive data routes
+	coalescer *coalescer
+
+	// tracerProvider traces requests; nil disables tracing
+	tracerProvider trace.TracerProvider
+
+	// metrics is served at /metrics; nil disables the endpoint
+	metrics *metrics.Registry
 }
 
 // NewServer creates a new HTTP server instance
-func NewServer(proc *processor.Processor, cfg *config.Config) *Server {
-	s := &Server{
-		processor: proc,
-		config:    cfg,
+func NewServer(proc ProcessorInterface, cfg *config.Config) *Server {
+	s := newServer(proc, cfg)
+	s.server = &http.Server{
+		Addr:           cfg.Port,
+		Handler:        s.handler(),
+		ReadTimeout:    15 * time.Second,
+		WriteTimeout:   15 * time.Second,
+		IdleTimeout:    60 * time.Second,
+		MaxHeaderBytes: maxHeaderBytes,
 	}
 
-	router := s.setupRoutes()
+	return s
+}
 
-	s.server = &http.Server{
-		Addr:         cfg.Port,
-		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+// WatchEnvFile makes configuration reloads re-read envFile in addition to
+// the environment. baseEnv is os.Environ() as captured before envFile was
+// loaded at startup; those variables keep precedence over the file. Call it
+// before serving.
+func (s *Server) WatchEnvFile(envFile string, baseEnv []string) {
+	s.watcher = config.Watch(s.config, envFile, baseEnv)
+}
+
+// handler returns the routes as served by the HTTP server
+func (s *Server) handler() http.Handler {
+	handler := s.setupRoutes()
+
+	// h2c lets gateways speak HTTP/2 without TLS; HTTP/1.1 keeps working
+	if s.config.EnableH2C {
+		handler = h2c.NewHandler(handler, &http2.Server{})
 	}
+	return handler
+}
 
-	return s
+// SetTracerProvider traces every request with spans from provider; nil
+// disables tracing. Call it before serving.
+func (s *Server) SetTracerProvider(provider trace.TracerProvider) {
+	s.tracerProvider = provider
+	s.server.Handler = s.handler()
+}
+
+// SetMetrics serves registry at /metrics. Call it before serving.
+func (s *Server) SetMetrics(registry *metrics.Registry) {
+	s.metrics = registry
+}
+
+// runtimeConfig returns the effective configuration. Settings that can be
+// reloaded must be read through it rather than from s.config.
+func (s *Server) runtimeConfig() *config.Config {
+	return s.watcher.Current()
+}
+
+// ReloadConfig re-reads the configuration and applies the settings that can
+// change at runtime. Changes to other settings are logged and ignored until
+// the next restart; an invalid configuration is rejected as a whole.
+func (s *Server) ReloadConfig() (config.ReloadResult, error) {
+	result, err := s.watcher.Reload()
+	if err != nil {
+		log.Printf("Config reload failed, keeping current settings: %v", err)
+		return result, err
+	}
+
+	s.processor.SetLowStockThreshold(s.runtimeConfig().LowStockThreshold)
+	s.processor.SetExclusions(s.runtimeConfig().ExcludeProducts, s.runtimeConfig().ExcludeCountries)
+	for _, name := range result.Ignored {
+		log.Printf("Warning: %s changed but only takes effect after a restart", name)
+	}
+	if len(result.Changed) > 0 {
+		log.Printf("Config reloaded: %s updated", strings.Join(result.Changed, ", "))
+		for _, name := range result.Changed {
+			if name == "EXCLUDE_PRODUCTS" || name == "EXCLUDE_COUNTRIES" {
+				log.Printf("%s applies from the next dataset load", name)
+			}
+		}
+	} else {
+		log.Println("Config reloaded: no runtime settings changed")
+	}
+	return result, nil
 }
 
 // setupRoutes configures all API routes
@@ -44,22 +144,87 @@ func (s *Server) setupRoutes() http.Handler {
 	router := mux.NewRouter()
 
 	// Add middleware
+	router.Use(s.routeSpanMiddleware)
 	router.Use(s.loggingMiddleware)
+	router.Use(s.recoveryMiddleware)
 	router.Use(s.corsMiddleware)
+	router.Use(s.compressionMiddleware)
 
 	// API routes
 	api := router.PathPrefix("/api").Subrouter()
 	api.HandleFunc("/health", s.healthCheck).Methods("GET")
-	api.HandleFunc("/revenue-by-country", s.getCountryRevenues).Methods("GET")
+	api.HandleFunc("/revenue-by-country", s.coalesced(s.getCountryRevenues)).Methods("GET")
+	api.Handle("/revenue-by-country/query", s.bodyLimitMiddleware(http.HandlerFunc(s.queryCountryRevenues))).Methods("POST")
 	api.HandleFunc("/top-products", s.getTopProducts).Methods("GET")
+	api.HandleFunc("/stock-pressure", s.getStockPressure).Methods("GET")
 	api.HandleFunc("/sales-by-month", s.getMonthlySales).Methods("GET")
+	api.HandleFunc("/sales-by-week", s.getWeeklySales).Methods("GET")
 	api.HandleFunc("/top-regions", s.getTopRegions).Methods("GET")
-	api.HandleFunc("/dashboard", s.getDashboardData).Methods("GET")
+	api.HandleFunc("/dashboard", s.coalesced(s.getDashboardData)).Methods("GET")
+	api.HandleFunc("/dashboard/diff", s.getDashboardDiff).Methods("GET")
+	api.HandleFunc("/summary", s.getSummary).Methods("GET")
+	api.HandleFunc("/processing-status", s.getProcessingStatus).Methods("GET")
+	api.HandleFunc("/processing-report", s.getProcessingReport).Methods("GET")
+	api.HandleFunc("/processing-report/last-failed", s.getLastFailedReport).Methods("GET")
+	api.HandleFunc("/regions/{region}/products", s.getRegionProducts).Methods("GET")
+	api.HandleFunc("/categories/{category}/top-products", s.getCategoryProducts).Methods("GET")
+	api.HandleFunc("/products/search", s.coalesced(s.searchProducts)).Methods("GET")
+	api.HandleFunc("/countries", s.getCountrySummaries).Methods("GET")
+	api.HandleFunc("/countries/{country}", s.getCountryDetail).Methods("GET")
+	api.HandleFunc("/countries/{country}/sales-by-month", s.getCountryMonthlySales).Methods("GET")
+	api.HandleFunc("/countries/{country}/top-customers", s.getCountryTopCustomers).Methods("GET")
+	api.HandleFunc("/dimensions", s.getDimensions).Methods("GET")
+	api.HandleFunc("/schema", s.buildCached(s.getSchemaIndex)).Methods("GET")
+	api.HandleFunc("/schema/{endpoint}", s.buildCached(s.getSchema)).Methods("GET")
+
+	// Admin routes, and their aliases outside /api/admin, all go through
+	// the admin middleware chain
+	admin := api.PathPrefix("/admin").Subrouter()
+	for _, route := range s.adminRoutes() {
+		handler := s.adminChain(route)
+		admin.Handle(route.path, handler).Methods(route.method)
+		for _, alias := range route.aliases {
+			router.Handle(alias, handler).Methods(route.method)
+		}
+	}
 
-	// Static route for basic info
-	router.HandleFunc("/", s.rootHandler).Methods("GET")
+	// OPTIONS on any API route lists its methods and query parameters
+	s.registerOptionsRoutes(router)
 
-	return router
+	// Profiling endpoints are only exposed outside production
+	if !s.config.IsProduction() {
+		debugRouter := router.PathPrefix("/debug/pprof").Subrouter()
+		debugRouter.HandleFunc("/cmdline", pprof.Cmdline)
+		debugRouter.HandleFunc("/profile", pprof.Profile)
+		debugRouter.HandleFunc("/symbol", pprof.Symbol)
+		debugRouter.HandleFunc("/trace", pprof.Trace)
+		debugRouter.PathPrefix("/").HandlerFunc(pprof.Index)
+	}
+
+	// Human-readable status page
+	router.HandleFunc("/status", s.getStatusPage).Methods("GET")
+
+	// Prometheus metrics, when enabled
+	router.HandleFunc("/metrics", s.getMetrics).Methods("GET")
+
+	// Service info and endpoint list; also at / unless the frontend is served there
+	router.HandleFunc("/api", s.rootHandler).Methods("GET")
+	if s.config.StaticDir != "" {
+		router.PathPrefix("/").Handler(s.staticHandler(s.config.StaticDir)).Methods("GET", "HEAD")
+	} else {
+		router.HandleFunc("/", s.rootHandler).Methods("GET")
+	}
+
+	if s.tracerProvider == nil {
+		return router
+	}
+	// Server spans continue the caller's trace when the request carries a
+	// W3C traceparent header. Requests matching no route keep a span named
+	// after their method only.
+	return otelhttp.NewHandler(router, "http.server",
+		otelhttp.WithTracerProvider(s.tracerProvider),
+		otelhttp.WithPropagators(propagation.TraceContext{}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string { return r.Method }))
 }
 
 // Middleware functions
@@ -69,44 +234,138 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 
 		next.ServeHTTP(w, r)
 
+		var traceID string
+		if id := tracing.TraceIDFromContext(r.Context()); id != "" {
+			traceID = " trace_id=" + id
+		}
+
+		if s.runtimeConfig().DebugLogging() {
+			log.Printf(
+				"%s %s %s %v user-agent=%q%s",
+				r.Method,
+				r.RequestURI,
+				s.clientIP(r),
+				time.Since(start),
+				r.UserAgent(),
+				traceID,
+			)
+			return
+		}
+
 		log.Printf(
-			"%s %s %s %v",
+			"%s %s %s %v%s",
 			r.Method,
 			r.RequestURI,
-			r.RemoteAddr,
+			s.clientIP(r),
 			time.Since(start),
+			traceID,
 		)
 	})
 }
 
+// routeSpanMiddleware names the request's server span after its route
+// template, such as "GET /api/countries/{country}", so that spans group by
+// route rather than by path
+func (s *Server) routeSpanMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		if current := mux.CurrentRoute(r); current != nil && span.IsRecording() {
+			if template, err := current.GetPathTemplate(); err == nil {
+				span.SetName(r.Method + " " + template)
+				span.SetAttributes(attribute.String("http.route", template))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recoveryMiddleware turns handler panics into a 500 error response. Stack
+// traces are included in the response outside production only.
+func (s *Server) recoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				stack := debug.Stack()
+				log.Printf("Panic serving %s %s: %v\n%s", r.Method, r.RequestURI, rec, stack)
+
+				response := newErrorResponse("Internal Server Error")
+				if !s.config.IsProduction() {
+					response.Panic = fmt.Sprint(rec)
+					response.Stack = string(stack)
+				}
+				s.writeJSONResponse(w, http.StatusInternalServerError, response)
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsAllowedHeaders are the request headers browsers may send: the admin
+// key, conditional requests and event stream reconnects
+const corsAllowedHeaders = "Content-Type, Authorization, X-API-Key, If-None-Match, Last-Event-ID"
+
+// corsMiddleware sets the CORS headers. Preflight requests go on to the
+// route's OPTIONS handler; other responses expose CORS_EXPOSED_HEADERS to
+// scripts.
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if origin := s.allowedOrigin(r); origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if origin != "*" {
+				w.Header().Add("Vary", "Origin")
+			}
+		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
 
 		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
+			next.ServeHTTP(w, r)
 			return
 		}
 
+		exposed := s.runtimeConfig().CORSExposedHeaders
+		if exposed == nil {
+			exposed = config.DefaultCORSExposedHeaders
+		}
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(exposed, ", "))
+
 		next.ServeHTTP(w, r)
 	})
 }
 
 // Handler functions
 func (s *Server) rootHandler(w http.ResponseWriter, r *http.Request) {
-	response := map[string]interface{}{
-		"service": "ABT Analytics Dashboard API",
-		"version": "1.0.0",
-		"status":  "running",
-		"endpoints": map[string]string{
+	response := RootResponse{
+		Service:     "ABT Analytics Dashboard API",
+		Version:     Version,
+		Status:      "running",
+		Environment: s.environment(),
+		Endpoints: map[string]string{
 			"health":             "/api/health",
 			"country_revenues":   "/api/revenue-by-country",
+			"country_query":      "/api/revenue-by-country/query",
 			"top_products":       "/api/top-products",
+			"stock_pressure":     "/api/stock-pressure",
 			"monthly_sales":      "/api/sales-by-month",
+			"weekly_sales":       "/api/sales-by-week",
 			"top_regions":        "/api/top-regions",
 			"complete_dashboard": "/api/dashboard",
+			"dashboard_diff":     "/api/dashboard/diff",
+			"summary":            "/api/summary",
+			"processing_status":  "/api/processing-status",
+			"processing_report":  "/api/processing-report",
+			"failed_report":      "/api/processing-report/last-failed",
+			"region_products":    "/api/regions/{region}/products",
+			"category_products":  "/api/categories/{category}/top-products",
+			"product_search":     "/api/products/search",
+			"countries":          "/api/countries",
+			"country_detail":     "/api/countries/{country}",
+			"country_sales":      "/api/countries/{country}/sales-by-month",
+			"country_customers":  "/api/countries/{country}/top-customers",
+			"dimensions":         "/api/dimensions",
+			"schemas":            "/api/schema",
+			"status_page":        "/status",
 		},
 	}
 	s.writeJSONResponse(w, http.StatusOK, response)
@@ -114,81 +373,560 @@ func (s *Server) rootHandler(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) healthCheck(w http.ResponseWriter, r *http.Request) {
 	dashboardData := s.processor.GetDashboardData()
-	response := map[string]interface{}{
-		"status":              "healthy",
-		"timestamp":           time.Now(),
-		"last_data_update":    dashboardData.LastUpdated,
-		"processing_duration": dashboardData.ProcessingDuration.String(),
-		"record_count":        dashboardData.RecordCount,
+	response := HealthResponse{
+		Status:             "healthy",
+		Timestamp:          time.Now(),
+		LastDataUpdate:     dashboardData.LastUpdated,
+		ProcessingDuration: dashboardData.ProcessingDuration.String(),
+		RecordCount:        dashboardData.RecordCount,
+		DataSource:         dashboardData.DataSource,
+		DistinctProducts:   dashboardData.DistinctProducts,
+		DistinctCountries:  dashboardData.DistinctCountries,
+		DistinctRegions:    dashboardData.DistinctRegions,
+		DistinctUsers:      dashboardData.DistinctUsers,
+		DataStartDate:      timeOrNil(dashboardData.DataStartDate),
+		DataEndDate:        timeOrNil(dashboardData.DataEndDate),
+	}
+	if r.URL.Query().Get("verbose") == "true" {
+		stats := runtimeStats()
+		response.Runtime = &stats
 	}
 	s.writeJSONResponse(w, http.StatusOK, response)
 }
 
 func (s *Server) getCountryRevenues(w http.ResponseWriter, r *http.Request) {
-	data := s.processor.GetCountryRevenues()
-	response := map[string]interface{}{
-		"data":  data,
-		"count": len(data),
-		"meta": map[string]interface{}{
-			"description": "Country-level revenue data sorted by total revenue (descending)",
-			"updated_at":  s.processor.GetDashboardData().LastUpdated,
-		},
+	query, errs := parseCountryRevenueQuery(r.URL.Query())
+	if len(errs) > 0 {
+		s.writeValidationErrorResponse(w, errs)
+		return
 	}
-	s.writeJSONResponse(w, http.StatusOK, response)
+	s.writeCountryRevenues(w, r, query)
+}
+
+func (s *Server) queryCountryRevenues(w http.ResponseWriter, r *http.Request) {
+	// The body is limited by bodyLimitMiddleware; read it whole so that an
+	// oversized body is reported as such rather than as invalid JSON
+	body, err := io.ReadAll(r.Body)
+	if isBodyTooLarge(err) {
+		s.writeBodyTooLargeResponse(w, s.maxRequestBodyBytes())
+		return
+	}
+
+	query, errs := decodeCountryRevenueQuery(bytes.NewReader(body))
+	if len(errs) > 0 {
+		s.writeValidationErrorResponse(w, errs)
+		return
+	}
+	s.writeCountryRevenues(w, r, query)
+}
+
+// writeCountryRevenues runs a validated query and writes the list envelope
+// shared by the GET and POST country revenue endpoints, as flat rows or
+// nested by country
+func (s *Server) writeCountryRevenues(w http.ResponseWriter, r *http.Request, query models.CountryRevenueQuery) {
+	if query.Shape == models.ShapeNested {
+		data, total, avgOrderFiltered, err := s.processor.QueryCountryRevenueGroupsContext(r.Context(), query)
+		if err != nil {
+			s.writeAbandonedResponse(w, r, err)
+			return
+		}
+		meta := countryRevenueMeta(s.processor.GetDashboardData(), "Country revenue nested by country, countries by total revenue (descending) and their products by revenue", query, total, avgOrderFiltered)
+		meta.Shape = query.Shape
+		s.writeResponse(w, r, http.StatusOK, newListResponse(data, meta))
+		return
+	}
+
+	data, total, avgOrderFiltered, err := s.processor.QueryCountryRevenuesContext(r.Context(), query)
+	if err != nil {
+		s.writeAbandonedResponse(w, r, err)
+		return
+	}
+	meta := countryRevenueMeta(s.processor.GetDashboardData(), "Country-level revenue data sorted by total revenue (descending)", query, total, avgOrderFiltered)
+	meta.SortBy = query.SortBy
+	meta.Order = query.Order
+	s.writeResponse(w, r, http.StatusOK, newListResponse(data, meta))
+}
+
+// countryRevenueMeta builds the metadata shared by both shapes of the
+// country revenue response
+func countryRevenueMeta(data *models.DashboardData, description string, query models.CountryRevenueQuery, total, avgOrderFiltered int) Meta {
+	meta := countryRowsMeta(data, description)
+	meta.Pagination = newPagination(total, query.Page, query.PageSize)
+	if query.MinAvgOrder != nil || query.MaxAvgOrder != nil {
+		meta.MinAvgOrder = query.MinAvgOrder
+		meta.MaxAvgOrder = query.MaxAvgOrder
+		meta.AvgOrderFiltered = &avgOrderFiltered
+	}
+	return meta
 }
 
 func (s *Server) getTopProducts(w http.ResponseWriter, r *http.Request) {
-	data := s.processor.GetTopProducts()
-	response := map[string]interface{}{
-		"data":  data,
-		"count": len(data),
-		"meta": map[string]interface{}{
-			"description": "Top 20 most frequently purchased products with current stock",
-			"updated_at":  s.processor.GetDashboardData().LastUpdated,
-		},
+	filter, errs := parseTopProductsFilter(r.URL.Query())
+	if len(errs) > 0 {
+		s.writeValidationErrorResponse(w, errs)
+		return
 	}
-	s.writeJSONResponse(w, http.StatusOK, response)
+
+	description := fmt.Sprintf("Top %d products by orders (purchase_count: transactions containing the product), with units sold and current stock", processor.TopProductsLimit)
+	if filter.RankBy == models.RankByUnits {
+		description = fmt.Sprintf("Top %d products by units sold (units_sold: total quantity across transactions), with purchase count and current stock", processor.TopProductsLimit)
+	}
+
+	data := s.processor.FilterTopProducts(filter)
+	dashboardData := s.processor.GetDashboardData()
+	meta := Meta{
+		Description: description,
+		UpdatedAt:   timeOrNil(dashboardData.LastUpdated),
+		SampleData:  isSampleData(dashboardData),
+		RankBy:      filter.RankBy,
+		MaxStock:    filter.MaxStock,
+		OutOfStock:  filter.OutOfStock,
+	}
+	if !filter.ActiveSince.IsZero() {
+		meta.ActiveSince = filter.ActiveSince.Format("2006-01-02")
+	}
+	meta.markTruncated(len(data), s.processor.CountTopProducts(filter))
+	s.writeResponse(w, r, http.StatusOK, newListResponse(data, meta))
+}
+
+// getMetrics serves the processing metrics in the Prometheus text format
+func (s *Server) getMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.metrics == nil {
+		s.writeErrorResponse(w, http.StatusNotFound, "Metrics are disabled; set ENABLE_METRICS=true")
+		return
+	}
+	s.metrics.Handler().ServeHTTP(w, r)
 }
 
 func (s *Server) getMonthlySales(w http.ResponseWriter, r *http.Request) {
-	data := s.processor.GetMonthlySales()
-	response := map[string]interface{}{
-		"data":  data,
-		"count": len(data),
-		"meta": map[string]interface{}{
-			"description": "Monthly sales volume data highlighting peak sales periods",
-			"updated_at":  s.processor.GetDashboardData().LastUpdated,
-		},
+	data := s.processor.GetDashboardData()
+	meta := dataMeta(data, "Monthly sales volume data highlighting peak sales periods")
+	s.writeResponse(w, r, http.StatusOK, newListResponse(s.localizeMonths(w, r, data.MonthlySales), meta))
+}
+
+func (s *Server) getWeeklySales(w http.ResponseWriter, r *http.Request) {
+	var errs []fieldError
+	bounds := make(map[string]string, 2)
+	for _, name := range []string{"from", "to"} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		week, err := processor.ParseISOWeek(value)
+		if err != nil {
+			errs = append(errs, fieldError{Field: name, Message: err.Error()})
+			continue
+		}
+		bounds[name] = week
 	}
-	s.writeJSONResponse(w, http.StatusOK, response)
+	from, to := bounds["from"], bounds["to"]
+	if len(errs) == 0 && from != "" && to != "" && from > to {
+		errs = append(errs, fieldError{Field: "to", Message: fmt.Sprintf("week %s is before from week %s", to, from)})
+	}
+	if len(errs) > 0 {
+		s.writeValidationErrorResponse(w, errs)
+		return
+	}
+
+	data := s.processor.GetWeeklySales(from, to)
+	meta := dataMeta(s.processor.GetDashboardData(), "Weekly sales by ISO 8601 week in chronological order")
+	meta.From = from
+	meta.To = to
+	s.writeResponse(w, r, http.StatusOK, newListResponse(data, meta))
 }
 
 func (s *Server) getTopRegions(w http.ResponseWriter, r *http.Request) {
-	data := s.processor.GetTopRegions()
-	response := map[string]interface{}{
-		"data":  data,
-		"count": len(data),
-		"meta": map[string]interface{}{
-			"description": "Top 30 regions by total revenue and items sold",
-			"updated_at":  s.processor.GetDashboardData().LastUpdated,
+	rankBy := r.URL.Query().Get("rank_by")
+	switch rankBy {
+	case "":
+		rankBy = models.RankByRevenue
+	case models.RankByRevenue, models.RankByItems:
+	default:
+		s.writeValidationErrorResponse(w, []fieldError{{Field: "rank_by", Message: "must be one of: revenue, items"}})
+		return
+	}
+
+	description := "Top 30 regions by total revenue and items sold"
+	if rankBy == models.RankByItems {
+		description = "Top 30 regions by total revenue, ranked by items sold (units across transactions)"
+	}
+	dashboardData := s.processor.GetDashboardData()
+	regions := processor.TopRegionsRankedBy(dashboardData, rankBy)
+	meta := dataMeta(dashboardData, description)
+	meta.RankBy = rankBy
+	meta.markTruncated(len(regions), dashboardData.TopRegionsAvailable)
+	s.writeResponse(w, r, http.StatusOK, newListResponse(regions, meta))
+}
+
+func (s *Server) getStockPressure(w http.ResponseWriter, r *http.Request) {
+	pressures := s.processor.GetStockPressure()
+	if pressures == nil {
+		pressures = make([]models.StockPressure, 0)
+	}
+	meta := dataMeta(s.processor.GetDashboardData(), "Products sold while out of stock or with less stock than the quantity ordered, by revenue")
+	s.writeResponse(w, r, http.StatusOK, newListResponse(pressures, meta))
+}
+
+func (s *Server) getSummary(w http.ResponseWriter, r *http.Request) {
+	dashboardData := s.processor.GetDashboardData()
+	meta := dataMeta(dashboardData, "Rolling 7-day and 30-day revenue and orders relative to the latest transaction date, with prior-period deltas")
+	meta.DataStartDate = timeOrNil(dashboardData.DataStartDate)
+	meta.DataEndDate = timeOrNil(dashboardData.DataEndDate)
+	response := Response[models.Summary]{Data: dashboardData.Summary, Meta: meta}
+	s.writeResponse(w, r, http.StatusOK, response)
+}
+
+func (s *Server) getDimensions(w http.ResponseWriter, r *http.Request) {
+	meta := dataMeta(s.processor.GetDashboardData(), "Distinct countries, regions, categories and currencies with row counts, merged case-insensitively and in alphabetical order")
+	response := Response[models.Dimensions]{Data: s.processor.GetDimensions(), Meta: meta}
+	s.writeResponse(w, r, http.StatusOK, response)
+}
+
+func (s *Server) getProcessingStatus(w http.ResponseWriter, r *http.Request) {
+	response := Response[models.ProcessingStatus]{
+		Data: s.processor.GetProcessingStatus(),
+		Meta: Meta{
+			Description: "Progress of the current or most recent processing run, including reader/worker queue depth and per-worker row counts",
+			Timestamp:   timeOrNil(time.Now()),
+			SampleData:  isSampleData(s.processor.GetDashboardData()),
 		},
 	}
-	s.writeJSONResponse(w, http.StatusOK, response)
+	s.writeResponse(w, r, http.StatusOK, response)
+}
+
+func (s *Server) getProcessingReport(w http.ResponseWriter, r *http.Request) {
+	dashboardData := s.processor.GetDashboardData()
+	meta := dataMeta(dashboardData, "Report of the processing run that produced the current data, including warnings, per-file row counts, phase timings and throughput")
+	response := Response[models.ProcessingReport]{Data: dashboardData.Report, Meta: meta}
+	s.writeResponse(w, r, http.StatusOK, response)
+}
+
+func (s *Server) getLastFailedReport(w http.ResponseWriter, r *http.Request) {
+	report, ok := s.processor.GetLastFailedReport()
+	if !ok {
+		s.writeErrorResponse(w, http.StatusNotFound, "No processing run has been rejected for too many bad rows")
+		return
+	}
+
+	meta := dataMeta(s.processor.GetDashboardData(), "Report of the most recent processing run rejected for exceeding MAX_BAD_ROW_RATIO; its data was not served")
+	response := Response[models.ProcessingReport]{Data: report, Meta: meta}
+	s.writeResponse(w, r, http.StatusOK, response)
+}
+
+func (s *Server) getRegionProducts(w http.ResponseWriter, r *http.Request) {
+	region := mux.Vars(r)["region"]
+
+	limit, err := parseLimit(r, 10, 50)
+	if err != nil {
+		s.writeValidationErrorResponse(w, []fieldError{{Field: "limit", Message: err.Error()}})
+		return
+	}
+
+	data, ok := s.processor.GetRegionProducts(region, limit)
+	if !ok {
+		s.writeErrorResponse(w, http.StatusNotFound, fmt.Sprintf("Region '%s' not found", region))
+		return
+	}
+
+	meta := dataMeta(s.processor.GetDashboardData(), "Top products in the region by quantity sold")
+	meta.Region = region
+	meta.Limit = limit
+	s.writeResponse(w, r, http.StatusOK, newListResponse(data, meta))
+}
+
+func (s *Server) getCategoryProducts(w http.ResponseWriter, r *http.Request) {
+	category := mux.Vars(r)["category"]
+
+	limit, err := parseLimit(r, 10, 50)
+	if err != nil {
+		s.writeValidationErrorResponse(w, []fieldError{{Field: "limit", Message: err.Error()}})
+		return
+	}
+
+	data, ok := s.processor.GetCategoryProducts(category, limit)
+	if !ok {
+		s.writeErrorResponse(w, http.StatusNotFound, fmt.Sprintf("Category '%s' not found", category))
+		return
+	}
+
+	meta := dataMeta(s.processor.GetDashboardData(), "Top products in the category by orders (purchase_count), then units sold")
+	meta.Category = category
+	meta.Limit = limit
+	s.writeResponse(w, r, http.StatusOK, newListResponse(data, meta))
+}
+
+func (s *Server) searchProducts(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		s.writeValidationErrorResponse(w, []fieldError{{Field: "q", Message: "a search term is required"}})
+		return
+	}
+
+	limit, err := parseLimit(r, 10, 50)
+	if err != nil {
+		s.writeValidationErrorResponse(w, []fieldError{{Field: "limit", Message: err.Error()}})
+		return
+	}
+
+	data, err := s.processor.SearchProductsContext(r.Context(), query, limit)
+	if err != nil {
+		s.writeAbandonedResponse(w, r, err)
+		return
+	}
+	dashboardData := s.processor.GetDashboardData()
+	meta := Meta{
+		Description: "Products whose names match the query, best matches first, then by purchase count",
+		UpdatedAt:   timeOrNil(dashboardData.LastUpdated),
+		SampleData:  isSampleData(dashboardData),
+		Query:       query,
+		Limit:       limit,
+	}
+	s.writeResponse(w, r, http.StatusOK, newListResponse(data, meta))
+}
+
+func (s *Server) getCountrySummaries(w http.ResponseWriter, r *http.Request) {
+	sortBy := r.URL.Query().Get("sort_by")
+	switch sortBy {
+	case "", processor.SortByTotalRevenue:
+		data := s.processor.GetDashboardData()
+		meta := countryRowsMeta(data, "Revenue per country with its best-selling product and ISO country code, ordered by revenue")
+		meta.SortBy = processor.SortByTotalRevenue
+		s.writeResponse(w, r, http.StatusOK, newListResponse(data.CountrySummaries, meta))
+	case processor.SortByCountry:
+		meta := countryRowsMeta(s.processor.GetDashboardData(), "Revenue per country with its best-selling product and ISO country code, in alphabetical order")
+		meta.SortBy = processor.SortByCountry
+		s.writeResponse(w, r, http.StatusOK, newListResponse(s.processor.GetCountrySummariesByName(), meta))
+	default:
+		s.writeValidationErrorResponse(w, []fieldError{{
+			Field:   "sort_by",
+			Message: fmt.Sprintf("must be one of %s, %s", processor.SortByTotalRevenue, processor.SortByCountry),
+		}})
+	}
+}
+
+func (s *Server) getCountryDetail(w http.ResponseWriter, r *http.Request) {
+	country := mux.Vars(r)["country"]
+
+	data, ok := s.processor.GetCountryDetail(country)
+	if !ok {
+		s.writeErrorResponse(w, http.StatusNotFound, fmt.Sprintf("Country '%s' not found", country))
+		return
+	}
+
+	meta := countryRowsMeta(s.processor.GetDashboardData(), fmt.Sprintf("Totals, top %d products by revenue and monthly sales for the country", processor.CountryTopProductsLimit))
+	meta.Country = country
+	if !data.MonthlySalesRetained {
+		meta.Retention = fmt.Sprintf("Monthly series are retained for the top %d countries by total revenue", processor.CountryTrendLimit)
+	}
+	data.MonthlySales = s.localizeMonths(w, r, data.MonthlySales)
+	s.writeResponse(w, r, http.StatusOK, Response[models.CountryDetail]{Data: data, Meta: meta})
+}
+
+func (s *Server) getCountryMonthlySales(w http.ResponseWriter, r *http.Request) {
+	country := mux.Vars(r)["country"]
+
+	dashboardData := s.processor.GetDashboardData()
+	data, ok := dashboardData.CountryMonthlySales[country]
+	if !ok {
+		s.writeErrorResponse(w, http.StatusNotFound, fmt.Sprintf(
+			"Country '%s' not found; monthly series are only kept for the top %d countries by revenue",
+			country, processor.CountryTrendLimit))
+		return
+	}
+
+	meta := dataMeta(dashboardData, "Monthly sales for the country in chronological order")
+	meta.Country = country
+	meta.Retention = fmt.Sprintf("Monthly series are retained for the top %d countries by total revenue", processor.CountryTrendLimit)
+	s.writeResponse(w, r, http.StatusOK, newListResponse(s.localizeMonths(w, r, data), meta))
+}
+
+func (s *Server) getCountryTopCustomers(w http.ResponseWriter, r *http.Request) {
+	country := mux.Vars(r)["country"]
+
+	data, ok := s.processor.GetCountryTopCustomers(country)
+	if !ok {
+		s.writeErrorResponse(w, http.StatusNotFound, fmt.Sprintf("Country '%s' not found", country))
+		return
+	}
+
+	meta := dataMeta(s.processor.GetDashboardData(), fmt.Sprintf("Top %d users by revenue from their transactions in the country", processor.CountryTopCustomersLimit))
+	meta.Country = country
+	s.writeResponse(w, r, http.StatusOK, newListResponse(data, meta))
 }
 
 func (s *Server) getDashboardData(w http.ResponseWriter, r *http.Request) {
 	data := s.processor.GetDashboardData()
-	response := map[string]interface{}{
-		"data": data,
-		"meta": map[string]interface{}{
-			"description": "Complete dashboard data including all metrics",
-			"updated_at":  data.LastUpdated,
-		},
+	meta := countryRowsMeta(data, "Complete dashboard data including all metrics")
+	if s.runtimeConfig().EnableLocalization {
+		localized := *data
+		localized.MonthlySales = s.localizeMonths(w, r, data.MonthlySales)
+		data = &localized
+	}
+	s.writeResponse(w, r, http.StatusOK, DashboardResponse{Data: data, Meta: meta})
+}
+
+// getDashboardDiff reports what changed in the data since ?since=, or
+// answers 204 when nothing did
+func (s *Server) getDashboardDiff(w http.ResponseWriter, r *http.Request) {
+	value := r.URL.Query().Get("since")
+	if value == "" {
+		s.writeValidationErrorResponse(w, []fieldError{{Field: "since", Message: "is required"}})
+		return
+	}
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		s.writeValidationErrorResponse(w, []fieldError{{Field: "since", Message: "must be an RFC 3339 timestamp, such as 2024-01-15T10:00:00Z"}})
+		return
+	}
+
+	diff, data, changed := s.processor.GetDashboardDiff(since)
+	if !changed {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	meta := dataMeta(data, "Changes in country revenue, products and monthly sales since the given time")
+	s.writeResponse(w, r, http.StatusOK, Response[models.DashboardDiff]{Data: diff, Meta: meta})
+}
+
+// dataMeta returns the meta object of endpoints serving aggregated data:
+// the description plus when data was last updated and its currency. Pass
+// the snapshot the response data was read from, so both describe the same
+// dataset.
+func dataMeta(data *models.DashboardData, description string) Meta {
+	return Meta{
+		Description:       description,
+		UpdatedAt:         timeOrNil(data.LastUpdated),
+		ReportingCurrency: data.ReportingCurrency,
+		SampleData:        isSampleData(data),
 	}
-	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// isSampleData reports whether data was generated rather than loaded, so
+// that responses built from it are flagged and not mistaken for real numbers
+func isSampleData(data *models.DashboardData) bool {
+	return data.DataSource == processor.SourceSample
+}
+
+// countryRowsMeta is dataMeta for endpoints built from the country revenue
+// rows. When small rows were folded into "Other" it reports how many, so
+// clients know the product breakdown is incomplete.
+func countryRowsMeta(data *models.DashboardData, description string) Meta {
+	meta := dataMeta(data, description)
+	if data.Report.OtherBucketThreshold > 0 {
+		folded := data.Report.FoldedRows
+		meta.FoldedRows = &folded
+		meta.OtherBucketThreshold = data.Report.OtherBucketThreshold
+	}
+	return meta
 }
 
 // Helper functions
+
+// environment returns the configured environment name, defaulting to development
+func (s *Server) environment() string {
+	if s.config.Environment == "" {
+		return config.EnvDevelopment
+	}
+	return s.config.Environment
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin value for the
+// request, or an empty string when the origin is not allowed. No configured
+// origins means any origin is allowed.
+func (s *Server) allowedOrigin(r *http.Request) string {
+	origins := s.runtimeConfig().CORSAllowedOrigins
+	if len(origins) == 0 {
+		return "*"
+	}
+
+	origin := r.Header.Get("Origin")
+	for _, allowed := range origins {
+		if allowed == "*" {
+			return "*"
+		}
+		if origin != "" && allowed == origin {
+			return origin
+		}
+	}
+	return ""
+}
+
+// parseLimit reads the ?limit= query parameter, returning def when it is
+// absent and an error when it is not an integer between 1 and max
+func parseLimit(r *http.Request, def, max int) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return def, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > max {
+		return 0, fmt.Errorf("must be an integer between 1 and %d", max)
+	}
+	return limit, nil
+}
+
+// clientIP returns the address of the client that made the request.
+// Forwarded headers are only read when TRUST_PROXY is enabled and the peer
+// is a trusted proxy: any peer without TRUSTED_PROXIES, or one matching it.
+// X-Forwarded-For is then walked from the right, the end our own proxies
+// append to, skipping TRUSTED_PROXIES hops, and the first other hop is the
+// client. Without TRUSTED_PROXIES only the peer is trusted, so that is the
+// right-most hop. Entries further left are written by the client and never
+// taken over an untrusted hop. X-Real-IP is used when X-Forwarded-For is
+// absent.
+func (s *Server) clientIP(r *http.Request) string {
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		client = r.RemoteAddr
+	}
+
+	cfg := s.runtimeConfig()
+	proxies := cfg.TrustedProxies
+	if !cfg.TrustProxy || (len(proxies) > 0 && !ipAllowed(client, proxies)) {
+		return client
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				// Hops beyond an unparseable one cannot be attributed
+				break
+			}
+			client = hop
+			if !ipAllowed(hop, proxies) {
+				break
+			}
+		}
+		return client
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return client
+}
+
+func runtimeStats() RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	uptime := time.Since(processStart)
+	return RuntimeStats{
+		GoVersion:        runtime.Version(),
+		Goroutines:       runtime.NumGoroutine(),
+		HeapAllocBytes:   mem.HeapAlloc,
+		HeapSysBytes:     mem.HeapSys,
+		GCCount:          mem.NumGC,
+		GCPauseTotalNs:   mem.PauseTotalNs,
+		GCPauseTotal:     time.Duration(mem.PauseTotalNs).String(),
+		Uptime:           uptime.String(),
+		UptimeSeconds:    uptime.Seconds(),
+		ProcessStartedAt: processStart,
+	}
+}
+
 func (s *Server) writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -200,19 +938,87 @@ func (s *Server) writeJSONResponse(w http.ResponseWriter, statusCode int, data i
 }
 
 func (s *Server) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	response := map[string]interface{}{
-		"error":     true,
-		"message":   message,
-		"timestamp": time.Now(),
+	s.writeJSONResponse(w, statusCode, newErrorResponse(message))
+}
+
+// writeAbandonedResponse ends a request whose computation stopped because
+// its context ended. A client that went away gets nothing written, while a
+// request out of time is answered with 503.
+func (s *Server) writeAbandonedResponse(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		s.writeErrorResponse(w, http.StatusServiceUnavailable, "Request timed out")
+		return
 	}
-	s.writeJSONResponse(w, statusCode, response)
+	log.Printf("Abandoned %s %s: %v", r.Method, r.URL.Path, err)
+}
+
+// Handler returns the server's HTTP handler with all routes and middleware,
+// for serving it from an httptest.Server or another listener
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
 }
 
 // Server lifecycle methods
 func (s *Server) ListenAndServe() error {
-	return s.server.ListenAndServe()
+	listener, err := s.listen()
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener until Shutdown, as ListenAndServe
+// does on the configured address. It lets tests serve on an ephemeral port.
+func (s *Server) Serve(listener net.Listener) error {
+	if n := s.config.MaxConcurrentConnections; n > 0 {
+		listener = newConnLimitListener(listener, n)
+	}
+	return s.server.Serve(listener)
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.server.Shutdown(ctx)
+	err := s.server.Shutdown(ctx)
+	if s.config.ListenSocket != "" {
+		if removeErr := os.Remove(s.config.ListenSocket); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Printf("Error removing socket %s: %v", s.config.ListenSocket, removeErr)
+		}
+	}
+	return err
+}
+
+// listen opens a Unix domain socket when LISTEN_SOCKET is configured and a
+// TCP listener on the configured port otherwise
+func (s *Server) listen() (net.Listener, error) {
+	network, path := s.config.ListenAddress()
+	if network != "unix" {
+		return net.Listen(network, path)
+	}
+
+	// Remove a stale socket left behind by a previous run, but never
+	// delete anything that is not a socket
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("listen socket path %s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := s.config.SocketMode
+	if mode == 0 {
+		mode = 0660
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	log.Printf("Listening on unix socket %s (mode %04o)", path, mode)
+	return listener, nil
 }
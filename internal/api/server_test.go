@@ -2,13 +2,25 @@ package api
 
 import (
 	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/metrics"
+	"abt-analytics-dashboard/internal/models"
 	"abt-analytics-dashboard/internal/processor"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"golang.org/x/net/http2"
 )
 
 func TestNewServer(t *testing.T) {
@@ -64,29 +76,25 @@ func TestRootHandler(t *testing.T) {
 	}
 
 	// Parse response body
-	var response map[string]interface{}
+	var response RootResponse
 	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
 		t.Fatalf("Failed to parse response JSON: %v", err)
 	}
 
 	// Verify response structure
-	if response["service"] != "ABT Analytics Dashboard API" {
-		t.Errorf("Expected service name 'ABT Analytics Dashboard API', got '%v'", response["service"])
+	if response.Service != "ABT Analytics Dashboard API" {
+		t.Errorf("Expected service name 'ABT Analytics Dashboard API', got '%v'", response.Service)
 	}
-	if response["version"] != "1.0.0" {
-		t.Errorf("Expected version '1.0.0', got '%v'", response["version"])
+	if response.Version != "1.0.0" {
+		t.Errorf("Expected version '1.0.0', got '%v'", response.Version)
 	}
-	if response["status"] != "running" {
-		t.Errorf("Expected status 'running', got '%v'", response["status"])
+	if response.Status != "running" {
+		t.Errorf("Expected status 'running', got '%v'", response.Status)
 	}
 
 	// Check endpoints
-	endpoints, ok := response["endpoints"].(map[string]interface{})
-	if !ok {
-		t.Fatal("Expected endpoints to be a map")
-	}
-
-	expectedEndpoints := []string{"health", "country_revenues", "top_products", "monthly_sales", "top_regions", "complete_dashboard"}
+	endpoints := response.Endpoints
+	expectedEndpoints := []string{"health", "country_revenues", "top_products", "monthly_sales", "top_regions", "complete_dashboard", "summary"}
 	for _, endpoint := range expectedEndpoints {
 		if _, exists := endpoints[endpoint]; !exists {
 			t.Errorf("Expected endpoint '%s' to be present", endpoint)
@@ -140,6 +148,53 @@ func TestHealthCheck(t *testing.T) {
 	if _, exists := response["record_count"]; !exists {
 		t.Error("Expected record_count to be present")
 	}
+
+	for _, field := range []string{"distinct_products", "distinct_countries", "distinct_regions", "distinct_users"} {
+		if _, exists := response[field]; !exists {
+			t.Errorf("Expected %s to be present", field)
+		}
+	}
+}
+
+func TestHealthCheckVerbose(t *testing.T) {
+	cfg := &config.Config{Port: ":8080"}
+	proc := processor.New()
+	server := NewServer(proc, cfg)
+
+	getRuntime := func(query string) *RuntimeStats {
+		req, err := http.NewRequest("GET", "/api/health"+query, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(server.healthCheck).ServeHTTP(rr, req)
+
+		var response HealthResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response JSON: %v", err)
+		}
+		return response.Runtime
+	}
+
+	if stats := getRuntime(""); stats != nil {
+		t.Error("Expected runtime stats to be omitted without verbose=true")
+	}
+
+	first := getRuntime("?verbose=true")
+	if first == nil {
+		t.Fatal("Expected runtime stats with verbose=true")
+	}
+	if first.GoVersion == "" || first.Goroutines == 0 || first.HeapAllocBytes == 0 || first.Uptime == "" {
+		t.Errorf("Expected runtime fields to be populated, got %+v", first)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	second := getRuntime("?verbose=true")
+
+	if second.UptimeSeconds <= first.UptimeSeconds {
+		t.Errorf("Expected uptime to increase, got %v then %v", first.UptimeSeconds, second.UptimeSeconds)
+	}
 }
 
 func TestGetCountryRevenues(t *testing.T) {
@@ -163,30 +218,21 @@ func TestGetCountryRevenues(t *testing.T) {
 	}
 
 	// Parse response body
-	var response map[string]interface{}
+	var response ListResponse[models.CountryRevenue]
 	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
 		t.Fatalf("Failed to parse response JSON: %v", err)
 	}
 
 	// Verify response structure
-	if _, exists := response["data"]; !exists {
-		t.Error("Expected data field to be present")
-	}
-
-	if _, exists := response["count"]; !exists {
-		t.Error("Expected count field to be present")
+	if response.Count == 0 || response.Count != len(response.Data) {
+		t.Errorf("Expected count to match %d items, got %d", len(response.Data), response.Count)
 	}
 
-	meta, ok := response["meta"].(map[string]interface{})
-	if !ok {
-		t.Fatal("Expected meta to be a map")
-	}
-
-	if meta["description"] != "Country-level revenue data sorted by total revenue (descending)" {
-		t.Errorf("Expected description to match, got '%v'", meta["description"])
+	if response.Meta.Description != "Country-level revenue data sorted by total revenue (descending)" {
+		t.Errorf("Expected description to match, got '%v'", response.Meta.Description)
 	}
 
-	if _, exists := meta["updated_at"]; !exists {
+	if response.Meta.UpdatedAt == nil {
 		t.Error("Expected updated_at to be present in meta")
 	}
 }
@@ -212,27 +258,18 @@ func TestGetTopProducts(t *testing.T) {
 	}
 
 	// Parse response body
-	var response map[string]interface{}
+	var response ListResponse[models.ProductFrequency]
 	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
 		t.Fatalf("Failed to parse response JSON: %v", err)
 	}
 
 	// Verify response structure
-	if _, exists := response["data"]; !exists {
-		t.Error("Expected data field to be present")
-	}
-
-	if _, exists := response["count"]; !exists {
-		t.Error("Expected count field to be present")
-	}
-
-	meta, ok := response["meta"].(map[string]interface{})
-	if !ok {
-		t.Fatal("Expected meta to be a map")
+	if response.Count == 0 || response.Count != len(response.Data) {
+		t.Errorf("Expected count to match %d items, got %d", len(response.Data), response.Count)
 	}
 
-	if meta["description"] != "Top 20 most frequently purchased products with current stock" {
-		t.Errorf("Expected description to match, got '%v'", meta["description"])
+	if !strings.HasPrefix(response.Meta.Description, "Top 20 products by orders") {
+		t.Errorf("Expected description to match, got '%v'", response.Meta.Description)
 	}
 }
 
@@ -257,27 +294,26 @@ func TestGetMonthlySales(t *testing.T) {
 	}
 
 	// Parse response body
-	var response map[string]interface{}
+	var response ListResponse[models.MonthlySales]
 	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
 		t.Fatalf("Failed to parse response JSON: %v", err)
 	}
 
 	// Verify response structure
-	if _, exists := response["data"]; !exists {
-		t.Error("Expected data field to be present")
-	}
-
-	if _, exists := response["count"]; !exists {
-		t.Error("Expected count field to be present")
+	if response.Count == 0 || response.Count != len(response.Data) {
+		t.Errorf("Expected count to match %d items, got %d", len(response.Data), response.Count)
 	}
 
-	meta, ok := response["meta"].(map[string]interface{})
-	if !ok {
-		t.Fatal("Expected meta to be a map")
+	if response.Meta.Description != "Monthly sales volume data highlighting peak sales periods" {
+		t.Errorf("Expected description to match, got '%v'", response.Meta.Description)
 	}
 
-	if meta["description"] != "Monthly sales volume data highlighting peak sales periods" {
-		t.Errorf("Expected description to match, got '%v'", meta["description"])
+	for _, month := range response.Data {
+		if month.DaysWithSales <= 0 || month.DaysWithSales > 31 {
+			t.Errorf("Expected 1-31 days with sales in %s, got %d", month.Month, month.DaysWithSales)
+		} else if month.AverageDailySales != month.TotalSales/float64(month.DaysWithSales) {
+			t.Errorf("Expected average daily sales of %s to match its totals, got %+v", month.Month, month)
+		}
 	}
 }
 
@@ -302,27 +338,18 @@ func TestGetTopRegions(t *testing.T) {
 	}
 
 	// Parse response body
-	var response map[string]interface{}
+	var response ListResponse[models.RegionRevenue]
 	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
 		t.Fatalf("Failed to parse response JSON: %v", err)
 	}
 
 	// Verify response structure
-	if _, exists := response["data"]; !exists {
-		t.Error("Expected data field to be present")
-	}
-
-	if _, exists := response["count"]; !exists {
-		t.Error("Expected count field to be present")
-	}
-
-	meta, ok := response["meta"].(map[string]interface{})
-	if !ok {
-		t.Fatal("Expected meta to be a map")
+	if response.Count == 0 || response.Count != len(response.Data) {
+		t.Errorf("Expected count to match %d items, got %d", len(response.Data), response.Count)
 	}
 
-	if meta["description"] != "Top 30 regions by total revenue and items sold" {
-		t.Errorf("Expected description to match, got '%v'", meta["description"])
+	if response.Meta.Description != "Top 30 regions by total revenue and items sold" {
+		t.Errorf("Expected description to match, got '%v'", response.Meta.Description)
 	}
 }
 
@@ -347,23 +374,397 @@ func TestGetDashboardData(t *testing.T) {
 	}
 
 	// Parse response body
-	var response map[string]interface{}
+	var response Response[models.DashboardData]
 	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
 		t.Fatalf("Failed to parse response JSON: %v", err)
 	}
 
 	// Verify response structure
-	if _, exists := response["data"]; !exists {
-		t.Error("Expected data field to be present")
+	if len(response.Data.CountryRevenues) == 0 {
+		t.Error("Expected dashboard data to be present")
+	}
+
+	if response.Meta.Description != "Complete dashboard data including all metrics" {
+		t.Errorf("Expected description to match, got '%v'", response.Meta.Description)
+	}
+}
+
+func TestGetSummary(t *testing.T) {
+	cfg := &config.Config{Port: ":8080"}
+	proc := processor.New()
+	proc.LoadSampleData()
+	server := NewServer(proc, cfg)
+
+	req, err := http.NewRequest("GET", "/api/summary", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(server.getSummary)
+
+	handler.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, status)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
 	}
 
-	meta, ok := response["meta"].(map[string]interface{})
+	data, ok := response["data"].(map[string]interface{})
 	if !ok {
-		t.Fatal("Expected meta to be a map")
+		t.Fatal("Expected data to be a map")
+	}
+
+	for _, field := range []string{"revenue_last_7d", "revenue_last_30d", "orders_last_7d", "orders_last_30d", "revenue_delta_7d", "revenue_delta_30d"} {
+		if _, exists := data[field]; !exists {
+			t.Errorf("Expected field '%s' to be present", field)
+		}
+	}
+}
+
+func TestGetProcessingReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.csv")
+	csv := "transaction_id,country,Product Name,product_name,quantity,total_price\nTXN001,Germany,x,Laptop,1,1200\nTXN002,France,x,Mouse,2,40\n"
+	if err := os.WriteFile(path, []byte(csv), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	proc := processor.New()
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/processing-report", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var response Response[models.ProcessingReport]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Data.Rows != 2 {
+		t.Errorf("Expected 2 rows, got %d", response.Data.Rows)
+	}
+	if response.Data.Timings.Total <= 0 || response.Data.Timings.RowsPerSecond <= 0 {
+		t.Errorf("Expected timings and throughput, got %+v", response.Data.Timings)
+	}
+	if files := response.Data.Files; len(files) != 1 || files[0].Header == nil || len(files[0].Header.Fields) != 5 || len(files[0].Header.Ignored) != 1 {
+		t.Errorf("Expected the header mapping of the file, got %+v", files)
+	}
+	if response.Meta.Description == "" {
+		t.Error("Expected a description in meta")
+	}
+}
+
+func TestGetLastFailedReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "half_bad.csv")
+	csv := "transaction_id,product_name,quantity,total_price\nTXN1,Phone,1,100\nTXN2,Phone\nTXN3,Laptop,1,900\nTXN4\n"
+	if err := os.WriteFile(path, []byte(csv), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	proc := processor.New(processor.WithMaxBadRowRatio(0.4))
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/processing-report/last-failed", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("Expected status %d before any rejected run, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	if err := proc.ProcessDataset(path); !errors.Is(err, processor.ErrTooManyBadRows) {
+		t.Fatalf("Expected ErrTooManyBadRows, got %v", err)
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/processing-report/last-failed", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var response Response[models.ProcessingReport]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Data.Rows != 2 || response.Data.SkippedRows != 2 {
+		t.Errorf("Expected 2 rows read and 2 skipped, got %d and %d", response.Data.Rows, response.Data.SkippedRows)
+	}
+}
+
+func TestTopRegionsExcludeBlankRegions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blank_regions.csv")
+	csv := "transaction_id,country,region,product_name,quantity,total_price\nTXN001,Germany,Bavaria,Laptop,1,1200\nTXN002,Germany,,Mouse,2,40\nTXN003,France,,Keyboard,1,80\n"
+	if err := os.WriteFile(path, []byte(csv), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	proc := processor.New()
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-regions", nil))
+	var regions ListResponse[models.RegionRevenue]
+	if err := json.Unmarshal(rr.Body.Bytes(), &regions); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(regions.Data) != 1 || regions.Data[0].Region != "Bavaria" {
+		t.Errorf("Expected only Bavaria in the top regions, got %+v", regions.Data)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/summary", nil))
+	var summary Response[models.Summary]
+	if err := json.Unmarshal(rr.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if got := summary.Data.Unattributed.Region; got.TransactionCount != 2 || got.Revenue != 120 {
+		t.Errorf("Expected 2 unattributed region rows worth 120, got %+v", got)
+	}
+}
+
+// rankingServer serves a dataset of n products, each sold once in its own
+// region, with the products after the first out of stock
+func rankingServer(t *testing.T, n int) http.Handler {
+	t.Helper()
+	var csv strings.Builder
+	csv.WriteString("transaction_id,region,product_name,quantity,total_price,stock_quantity\n")
+	for i := 1; i <= n; i++ {
+		stock := 0
+		if i == 1 {
+			stock = 5
+		}
+		fmt.Fprintf(&csv, "TXN%03d,Region %02d,Product %02d,1,%d,%d\n", i, i, i, 100+i, stock)
+	}
+	path := filepath.Join(t.TempDir(), "rankings.csv")
+	if err := os.WriteFile(path, []byte(csv.String()), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	proc := processor.New()
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+}
+
+func TestTopRankingsReportTruncation(t *testing.T) {
+	tests := []struct {
+		name           string
+		products       int
+		target         string
+		count          int
+		totalAvailable int
+	}{
+		{"products below limit", 5, "/api/top-products", 5, 0},
+		{"products at limit", processor.TopProductsLimit, "/api/top-products", processor.TopProductsLimit, 0},
+		{"products above limit", 45, "/api/top-products", processor.TopProductsLimit, 45},
+		{"products by units above limit", 45, "/api/top-products?rank_by=units", processor.TopProductsLimit, 45},
+		{"filtered products above limit", 45, "/api/top-products?out_of_stock=true", processor.TopProductsLimit - 1, 44},
+		{"filtered products below limit", 5, "/api/top-products?out_of_stock=true", 4, 0},
+		{"regions below limit", 5, "/api/top-regions", 5, 0},
+		{"regions above limit", 45, "/api/top-regions?rank_by=items", 30, 45},
+	}
+
+	for _, test := range tests {
+		rr := httptest.NewRecorder()
+		rankingServer(t, test.products).ServeHTTP(rr, httptest.NewRequest("GET", test.target, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", test.name, rr.Code)
+		}
+		var response struct {
+			Count int                    `json:"count"`
+			Meta  map[string]interface{} `json:"meta"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: failed to parse response JSON: %v", test.name, err)
+		}
+		if response.Count != test.count {
+			t.Errorf("%s: expected %d entries, got %d", test.name, test.count, response.Count)
+		}
+
+		truncated, hasTruncated := response.Meta["truncated"]
+		total, hasTotal := response.Meta["total_available"]
+		if test.totalAvailable == 0 {
+			if hasTruncated || hasTotal {
+				t.Errorf("%s: expected no truncation in meta, got %v", test.name, response.Meta)
+			}
+		} else if truncated != true || total != float64(test.totalAvailable) {
+			t.Errorf("%s: expected truncated with %d available, got %v", test.name, test.totalAvailable, response.Meta)
+		}
+	}
+}
+
+func TestGetRegionProducts(t *testing.T) {
+	cfg := &config.Config{Port: ":8080"}
+	proc := processor.New()
+	proc.LoadSampleData()
+	server := NewServer(proc, cfg)
+	router := server.setupRoutes()
+
+	req := httptest.NewRequest("GET", "/api/regions/North%20America/products?limit=3", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, status)
+	}
+
+	var response ListResponse[models.RegionProduct]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+
+	if response.Count != 3 {
+		t.Errorf("Expected count 3, got %v", response.Count)
+	}
+	if response.Meta.Region != "North America" {
+		t.Errorf("Expected decoded region 'North America', got '%v'", response.Meta.Region)
+	}
+	if response.Meta.Limit != 3 {
+		t.Errorf("Expected limit 3 in meta, got %d", response.Meta.Limit)
+	}
+
+	data := response.Data
+	if len(data) != 3 {
+		t.Fatalf("Expected 3 products, got %v", data)
+	}
+	for i := 1; i < len(data); i++ {
+		if data[i-1].QuantitySold < data[i].QuantitySold {
+			t.Error("Expected products to be sorted by quantity sold (descending)")
+		}
+	}
+}
+
+// checkErrorBody checks the body of a rejected request: a problem details
+// document naming param for 400, the error envelope otherwise
+func checkErrorBody(t *testing.T, rr *httptest.ResponseRecorder, path, param string) {
+	t.Helper()
+
+	if rr.Code == http.StatusBadRequest {
+		problem := decodeProblem(t, rr)
+		if problem.Type != invalidParamsProblem || problem.Status != http.StatusBadRequest {
+			t.Errorf("%s: expected an invalid params problem, got %+v", path, problem)
+		}
+		if len(problem.InvalidParams) != 1 || problem.InvalidParams[0].Name != param {
+			t.Errorf("%s: expected %s to be reported, got %+v", path, param, problem.InvalidParams)
+		}
+		return
+	}
+
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("%s: expected Content-Type application/json, got %s", path, contentType)
+	}
+	var response ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if !response.Error || response.Message == "" || response.Timestamp.IsZero() {
+		t.Errorf("%s: expected the error envelope, got %+v", path, response)
+	}
+}
+
+func TestGetRegionProductsErrors(t *testing.T) {
+	cfg := &config.Config{Port: ":8080"}
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, cfg).setupRoutes()
+
+	testCases := []struct {
+		path   string
+		status int
+		param  string
+	}{
+		{"/api/regions/Atlantis/products", http.StatusNotFound, ""},
+		{"/api/regions/Europe/products?limit=0", http.StatusBadRequest, "limit"},
+		{"/api/regions/Europe/products?limit=abc", http.StatusBadRequest, "limit"},
+		{"/api/regions/Europe/products?limit=500", http.StatusBadRequest, "limit"},
+	}
+
+	for _, tc := range testCases {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", tc.path, nil))
+
+		if rr.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.path, tc.status, rr.Code)
+			continue
+		}
+
+		checkErrorBody(t, rr, tc.path, tc.param)
+	}
+}
+
+func TestGetCategoryProducts(t *testing.T) {
+	cfg := &config.Config{Port: ":8080"}
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, cfg).setupRoutes()
+
+	req := httptest.NewRequest("GET", "/api/categories/cOmPuTeRs/top-products?limit=2", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, status)
+	}
+
+	var response ListResponse[models.CategoryProduct]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+
+	if response.Count != 2 {
+		t.Errorf("Expected count 2, got %v", response.Count)
+	}
+	if response.Meta.Category != "cOmPuTeRs" {
+		t.Errorf("Expected category 'cOmPuTeRs' in meta, got '%v'", response.Meta.Category)
+	}
+	if response.Meta.Limit != 2 {
+		t.Errorf("Expected limit 2 in meta, got %d", response.Meta.Limit)
 	}
 
-	if meta["description"] != "Complete dashboard data including all metrics" {
-		t.Errorf("Expected description to match, got '%v'", meta["description"])
+	data := response.Data
+	if len(data) != 2 {
+		t.Fatalf("Expected 2 products, got %v", data)
+	}
+	if data[0].Rank != 1 || data[1].Rank != 2 {
+		t.Errorf("Expected ranks 1 and 2, got %d and %d", data[0].Rank, data[1].Rank)
+	}
+	if data[0].PurchaseCount < data[1].PurchaseCount {
+		t.Error("Expected products to be sorted by purchase count (descending)")
+	}
+}
+
+func TestGetCategoryProductsErrors(t *testing.T) {
+	cfg := &config.Config{Port: ":8080"}
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, cfg).setupRoutes()
+
+	testCases := []struct {
+		path   string
+		status int
+		param  string
+	}{
+		{"/api/categories/Garden/top-products", http.StatusNotFound, ""},
+		{"/api/categories/Audio/top-products?limit=0", http.StatusBadRequest, "limit"},
+		{"/api/categories/Audio/top-products?limit=abc", http.StatusBadRequest, "limit"},
+		{"/api/categories/Audio/top-products?limit=500", http.StatusBadRequest, "limit"},
+	}
+
+	for _, tc := range testCases {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", tc.path, nil))
+
+		if rr.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.path, tc.status, rr.Code)
+			continue
+		}
+
+		checkErrorBody(t, rr, tc.path, tc.param)
 	}
 }
 
@@ -398,6 +799,117 @@ func TestCorsMiddleware(t *testing.T) {
 	if corsMethods != "GET, POST, PUT, DELETE, OPTIONS" {
 		t.Errorf("Expected Access-Control-Allow-Methods to include all methods, got '%s'", corsMethods)
 	}
+
+	corsHeaders := rr.Header().Get("Access-Control-Allow-Headers")
+	for _, header := range []string{"Authorization", "X-API-Key", "Content-Type", "If-None-Match"} {
+		if !strings.Contains(corsHeaders, header) {
+			t.Errorf("Expected Access-Control-Allow-Headers to include %s, got '%s'", header, corsHeaders)
+		}
+	}
+	if exposed := rr.Header().Get("Access-Control-Expose-Headers"); exposed != "" {
+		t.Errorf("Expected no Access-Control-Expose-Headers on a preflight, got '%s'", exposed)
+	}
+}
+
+func TestCorsMiddlewareExposedHeaders(t *testing.T) {
+	router := NewServer(processor.New(), &config.Config{Port: ":8080"}).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/schema/health", nil))
+	exposed := rr.Header().Get("Access-Control-Expose-Headers")
+	if exposed != strings.Join(config.DefaultCORSExposedHeaders, ", ") {
+		t.Errorf("Expected the default exposed headers, got '%s'", exposed)
+	}
+	// Every exposed header the response carries is readable by scripts
+	if rr.Header().Get("ETag") == "" || !strings.Contains(exposed, "ETag") {
+		t.Errorf("Expected ETag to be set and exposed, got '%s'", exposed)
+	}
+
+	cfg := &config.Config{Port: ":8080", CORSExposedHeaders: []string{"ETag", "X-Request-ID"}}
+	router = NewServer(processor.New(), cfg).setupRoutes()
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/health", nil))
+	if exposed := rr.Header().Get("Access-Control-Expose-Headers"); exposed != "ETag, X-Request-ID" {
+		t.Errorf("Expected the configured exposed headers, got '%s'", exposed)
+	}
+}
+
+func TestEnvironmentWiring(t *testing.T) {
+	panicHandler := func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}
+
+	testCases := []struct {
+		environment string
+		origins     []string
+		pprofStatus int
+		showStack   bool
+	}{
+		{config.EnvDevelopment, []string{"*"}, http.StatusOK, true},
+		{config.EnvProduction, []string{"https://dash.example.com"}, http.StatusNotFound, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.environment, func(t *testing.T) {
+			cfg := &config.Config{Port: ":8080", Environment: tc.environment, CORSAllowedOrigins: tc.origins}
+			server := NewServer(processor.New(), cfg)
+			router := server.setupRoutes()
+
+			// pprof is only exposed outside production
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/debug/pprof/", nil))
+			if rr.Code != tc.pprofStatus {
+				t.Errorf("Expected pprof status %d, got %d", tc.pprofStatus, rr.Code)
+			}
+
+			// Root handler reports the environment
+			rr = httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+			var root map[string]interface{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &root); err != nil {
+				t.Fatalf("Failed to parse response JSON: %v", err)
+			}
+			if root["environment"] != tc.environment {
+				t.Errorf("Expected environment '%s', got '%v'", tc.environment, root["environment"])
+			}
+
+			// Stack traces are hidden in production
+			rr = httptest.NewRecorder()
+			server.recoveryMiddleware(http.HandlerFunc(panicHandler)).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+			if rr.Code != http.StatusInternalServerError {
+				t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+			}
+			var response map[string]interface{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response JSON: %v", err)
+			}
+			if _, exists := response["stack"]; exists != tc.showStack {
+				t.Errorf("Expected stack present=%v, got %v", tc.showStack, exists)
+			}
+		})
+	}
+}
+
+func TestCorsMiddlewareAllowedOrigins(t *testing.T) {
+	cfg := &config.Config{Port: ":8080", CORSAllowedOrigins: []string{"https://dash.example.com"}}
+	server := NewServer(processor.New(), cfg)
+	handler := server.corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/api/health", nil)
+	req.Header.Set("Origin", "https://dash.example.com")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if origin := rr.Header().Get("Access-Control-Allow-Origin"); origin != "https://dash.example.com" {
+		t.Errorf("Expected allowed origin to be echoed, got '%s'", origin)
+	}
+
+	req = httptest.NewRequest("GET", "/api/health", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if origin := rr.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+		t.Errorf("Expected no allowed origin for unknown origin, got '%s'", origin)
+	}
 }
 
 func TestLoggingMiddleware(t *testing.T) {
@@ -423,6 +935,61 @@ func TestLoggingMiddleware(t *testing.T) {
 	}
 }
 
+func TestClientIP(t *testing.T) {
+	proxies := []string{"10.0.0.0/8"}
+	testCases := []struct {
+		name       string
+		trustProxy bool
+		proxies    []string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		expected   string
+	}{
+		{"no headers", false, nil, "10.0.0.1:1234", "", "", "10.0.0.1"},
+		{"ignored single forwarded", false, nil, "10.0.0.1:1234", "203.0.113.5", "", "10.0.0.1"},
+		{"ignored chained forwarded", false, nil, "10.0.0.1:1234", "203.0.113.5, 10.0.0.2", "", "10.0.0.1"},
+		{"ignored real ip", false, nil, "10.0.0.1:1234", "", "203.0.113.9", "10.0.0.1"},
+		{"trusted single forwarded", true, nil, "10.0.0.1:1234", "203.0.113.5", "", "203.0.113.5"},
+		{"trusted peer takes right-most hop", true, nil, "10.0.0.1:1234", "203.0.113.5, 10.0.0.2, 10.0.0.3", "", "10.0.0.3"},
+		{"trusted skips invalid left hop", true, nil, "10.0.0.1:1234", "unknown, 203.0.113.5", "", "203.0.113.5"},
+		{"trusted real ip", true, nil, "10.0.0.1:1234", "", "203.0.113.9", "203.0.113.9"},
+		{"trusted forwarded wins over real ip", true, nil, "10.0.0.1:1234", "203.0.113.5", "203.0.113.9", "203.0.113.5"},
+		{"trusted without headers", true, nil, "10.0.0.1:1234", "", "", "10.0.0.1"},
+		{"remote addr without port", false, nil, "10.0.0.1", "", "", "10.0.0.1"},
+		{"spoofed left-most entry", true, nil, "10.0.0.1:1234", "192.0.2.66, 203.0.113.5", "", "203.0.113.5"},
+		{"spoofed entry in an earlier header", true, nil, "10.0.0.1:1234", "192.0.2.66; 203.0.113.5", "", "203.0.113.5"},
+		{"trusted proxies skipped", true, proxies, "10.0.0.1:1234", "203.0.113.5, 10.0.0.2, 10.0.0.3", "", "203.0.113.5"},
+		{"trusted proxies with spoofed left-most entry", true, proxies, "10.0.0.1:1234", "192.0.2.66, 203.0.113.5, 10.0.0.2", "", "203.0.113.5"},
+		{"trusted proxies all hops trusted", true, proxies, "10.0.0.1:1234", "10.0.0.3, 10.0.0.2", "", "10.0.0.3"},
+		{"trusted proxies stop at invalid hop", true, proxies, "10.0.0.1:1234", "192.0.2.66, unknown, 10.0.0.2", "", "10.0.0.2"},
+		{"untrusted peer ignores forwarded", true, proxies, "198.51.100.7:1234", "192.0.2.66", "", "198.51.100.7"},
+		{"untrusted peer ignores real ip", true, proxies, "198.51.100.7:1234", "", "192.0.2.66", "198.51.100.7"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := NewServer(processor.New(), &config.Config{Port: ":8080", TrustProxy: tc.trustProxy, TrustedProxies: tc.proxies})
+
+			req := httptest.NewRequest("GET", "/api/health", nil)
+			req.RemoteAddr = tc.remoteAddr
+			// Semicolons separate repeated X-Forwarded-For headers
+			if tc.forwarded != "" {
+				for _, header := range strings.Split(tc.forwarded, ";") {
+					req.Header.Add("X-Forwarded-For", strings.TrimSpace(header))
+				}
+			}
+			if tc.realIP != "" {
+				req.Header.Set("X-Real-IP", tc.realIP)
+			}
+
+			if ip := server.clientIP(req); ip != tc.expected {
+				t.Errorf("Expected client IP '%s', got '%s'", tc.expected, ip)
+			}
+		})
+	}
+}
+
 func TestWriteJSONResponse(t *testing.T) {
 	cfg := &config.Config{Port: ":8080"}
 	proc := processor.New()
@@ -500,8 +1067,91 @@ func TestServerShutdown(t *testing.T) {
 	}
 }
 
-func TestSetupRoutes(t *testing.T) {
-	cfg := &config.Config{Port: ":8080"}
+func TestUnixSocketListener(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "api.sock")
+
+	// A stale socket from a previous run must be replaced
+	stale, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to create stale socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	cfg := &config.Config{ListenSocket: socketPath, SocketMode: 0600}
+	proc := processor.New()
+	server := NewServer(proc, cfg)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		resp, err = client.Get("http://unix/api/health")
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Failed to reach server over unix socket: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatalf("Expected socket file to exist: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected socket mode 0600, got %04o", info.Mode().Perm())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Errorf("Expected no error on shutdown, got %v", err)
+	}
+	if err := <-serveErr; err != http.ErrServerClosed {
+		t.Errorf("Expected ErrServerClosed, got %v", err)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Error("Expected socket file to be removed on shutdown")
+	}
+}
+
+func TestUnixSocketListenerRefusesRegularFile(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "api.sock")
+	if err := os.WriteFile(socketPath, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	server := NewServer(processor.New(), &config.Config{ListenSocket: socketPath})
+	if err := server.ListenAndServe(); err == nil {
+		t.Fatal("Expected error when socket path is a regular file")
+	}
+
+	if _, err := os.Stat(socketPath); err != nil {
+		t.Error("Expected regular file to be left untouched")
+	}
+}
+
+func TestSetupRoutes(t *testing.T) {
+	cfg := &config.Config{Port: ":8080"}
 	proc := processor.New()
 	server := NewServer(proc, cfg)
 
@@ -519,6 +1169,7 @@ func TestSetupRoutes(t *testing.T) {
 		"/api/sales-by-month",
 		"/api/top-regions",
 		"/api/dashboard",
+		"/api/summary",
 	}
 
 	for _, route := range testRoutes {
@@ -536,3 +1187,818 @@ func TestSetupRoutes(t *testing.T) {
 		}
 	}
 }
+
+func TestGetCountryDetail(t *testing.T) {
+	router := newQueryTestRouter()
+	get := func(path string, out interface{}) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d: %s", path, rr.Code, rr.Body.String())
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), out); err != nil {
+			t.Fatalf("Failed to parse %s response JSON: %v", path, err)
+		}
+	}
+
+	var detail Response[models.CountryDetail]
+	get("/api/countries/Germany", &detail)
+	var summaries ListResponse[models.CountrySummary]
+	get("/api/countries", &summaries)
+	var months ListResponse[models.MonthlySales]
+	get("/api/countries/Germany/sales-by-month", &months)
+	var rows ListResponse[models.CountryRevenue]
+	get("/api/revenue-by-country?countries=Germany", &rows)
+
+	for _, summary := range summaries.Data {
+		if summary.Country == "Germany" && !reflect.DeepEqual(summary, detail.Data.CountrySummary) {
+			t.Errorf("Expected the detail totals to match /api/countries, got %+v and %+v", detail.Data.CountrySummary, summary)
+		}
+	}
+	if !detail.Data.MonthlySalesRetained || len(detail.Data.MonthlySales) != len(months.Data) {
+		t.Errorf("Expected the %d retained months, got %d", len(months.Data), len(detail.Data.MonthlySales))
+	}
+
+	revenueByProduct := make(map[string]float64)
+	for _, row := range rows.Data {
+		revenueByProduct[row.ProductName] += row.TotalRevenue
+	}
+	if len(detail.Data.TopProducts) == 0 || len(detail.Data.TopProducts) > processor.CountryTopProductsLimit {
+		t.Fatalf("Expected up to %d top products, got %d", processor.CountryTopProductsLimit, len(detail.Data.TopProducts))
+	}
+	for i, product := range detail.Data.TopProducts {
+		if product.TotalRevenue != revenueByProduct[product.ProductName] {
+			t.Errorf("Expected %s revenue %v from the country rows, got %v", product.ProductName, revenueByProduct[product.ProductName], product.TotalRevenue)
+		}
+		if i > 0 && product.TotalRevenue > detail.Data.TopProducts[i-1].TotalRevenue {
+			t.Errorf("Expected products ordered by revenue, got %s after %s", product.ProductName, detail.Data.TopProducts[i-1].ProductName)
+		}
+	}
+	if detail.Data.TopProducts[0].ProductName != detail.Data.BestProductName {
+		t.Errorf("Expected the first product to be the best seller %s, got %s", detail.Data.BestProductName, detail.Data.TopProducts[0].ProductName)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries/Atlantis", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for unknown country, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestGetCountryMonthlySales(t *testing.T) {
+	cfg := &config.Config{Port: ":8080"}
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, cfg).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries/Germany/sales-by-month", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var response struct {
+		Count int                    `json:"count"`
+		Data  []models.MonthlySales  `json:"data"`
+		Meta  map[string]interface{} `json:"meta"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+
+	if response.Count != 12 || len(response.Data) != 12 {
+		t.Fatalf("Expected 12 months, got %d", len(response.Data))
+	}
+	for i, month := range response.Data {
+		if expected := time.Month(i + 1).String(); month.Month != expected {
+			t.Errorf("Expected month %d to be %s, got %s", i, expected, month.Month)
+		}
+	}
+	if response.Meta["country"] != "Germany" {
+		t.Errorf("Expected meta country 'Germany', got %v", response.Meta["country"])
+	}
+	if retention, _ := response.Meta["retention"].(string); !strings.Contains(retention, "top") {
+		t.Errorf("Expected a retention note in meta, got %v", response.Meta["retention"])
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries/Atlantis/sales-by-month", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for unknown country, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestH2CHealthCheck(t *testing.T) {
+	proc := processor.New()
+	proc.LoadSampleData()
+	server := NewServer(proc, &config.Config{Port: ":0", EnableH2C: true})
+
+	ts := httptest.NewServer(server.server.Handler)
+	defer ts.Close()
+
+	// HTTP/2 over cleartext with prior knowledge
+	h2Client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+
+	resp, err := h2Client.Get(ts.URL + "/api/health")
+	if err != nil {
+		t.Fatalf("h2c request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Errorf("Expected HTTP/2, got %s", resp.Proto)
+	}
+
+	// HTTP/1.1 keeps working on the same handler
+	resp, err = http.Get(ts.URL + "/api/health")
+	if err != nil {
+		t.Fatalf("HTTP/1.1 request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ProtoMajor != 1 {
+		t.Errorf("Expected HTTP/1.1 200, got %s %d", resp.Proto, resp.StatusCode)
+	}
+}
+
+func TestGetTopProductsStockFilters(t *testing.T) {
+	cfg := &config.Config{Port: ":8080"}
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, cfg).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-products?out_of_stock=true&max_stock=10", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var response struct {
+		Data []models.ProductFrequency `json:"data"`
+		Meta map[string]interface{}    `json:"meta"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+
+	if len(response.Data) == 0 {
+		t.Fatal("Expected sample data to include out-of-stock products")
+	}
+	for i, product := range response.Data {
+		if product.StockStatus != models.StockOut || product.CurrentStock != 0 {
+			t.Errorf("Expected only out-of-stock products, got %+v", product)
+		}
+		if i > 0 && product.Rank <= response.Data[i-1].Rank {
+			t.Errorf("Expected original ranks in ascending order, got %d after %d", product.Rank, response.Data[i-1].Rank)
+		}
+	}
+	if response.Meta["out_of_stock"] != true || response.Meta["max_stock"] != float64(10) {
+		t.Errorf("Expected applied filters in meta, got %v", response.Meta)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-products?max_stock=-1&out_of_stock=maybe", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for invalid filters, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestGetTopProductsActiveSince(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dates.csv")
+	csv := "transaction_id,transaction_date,product_name,quantity,total_price\nTXN001,2024-05-01,Laptop,1,1000\nTXN002,2023-02-01,Mouse,1,20\n"
+	if err := os.WriteFile(path, []byte(csv), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	proc := processor.New()
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-products?active_since=2024-01-01", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var response ListResponse[models.ProductFrequency]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(response.Data) != 1 || response.Data[0].ProductName != "Laptop" {
+		t.Fatalf("Expected only Laptop, got %+v", response.Data)
+	}
+	if response.Data[0].FirstSold == nil || response.Data[0].LastSold == nil {
+		t.Errorf("Expected first_sold and last_sold, got %+v", response.Data[0])
+	}
+	if response.Meta.ActiveSince != "2024-01-01" {
+		t.Errorf("Expected active_since in meta, got %q", response.Meta.ActiveSince)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-products?active_since=yesterday", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid date, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "active_since") {
+		t.Errorf("Expected the active_since field in the error, got %s", rr.Body.String())
+	}
+}
+
+func TestGetTopProductsRankBy(t *testing.T) {
+	cfg := &config.Config{Port: ":8080"}
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, cfg).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-products?rank_by=units", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var response ListResponse[models.ProductFrequency]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if len(response.Data) == 0 {
+		t.Fatal("Expected sample top products")
+	}
+	for i := 1; i < len(response.Data); i++ {
+		if response.Data[i].UnitsSold > response.Data[i-1].UnitsSold {
+			t.Errorf("Expected products ordered by units sold, got %d after %d", response.Data[i].UnitsSold, response.Data[i-1].UnitsSold)
+		}
+	}
+	if response.Meta.RankBy != "units" || !strings.Contains(response.Meta.Description, "units_sold") {
+		t.Errorf("Expected units ranking described in meta, got %+v", response.Meta)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-products?rank_by=revenue", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown ranking, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "rank_by") {
+		t.Errorf("Expected the rank_by field in the error, got %s", rr.Body.String())
+	}
+}
+
+func TestGetProcessingStatus(t *testing.T) {
+	proc := processor.New()
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/processing-status", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var response struct {
+		Data models.ProcessingStatus `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Data.Running || response.Data.StartedAt != nil {
+		t.Errorf("Expected no run before processing, got %+v", response.Data)
+	}
+}
+
+func TestDataDateRangeInHealthAndSummary(t *testing.T) {
+	_, router := newAdminTestServer(t)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/health", nil))
+	health := decodeResponse(t, rr)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/summary", nil))
+	summaryMeta, _ := decodeResponse(t, rr)["meta"].(map[string]interface{})
+
+	// The admin test dataset has a single transaction on 2024-01-15
+	for name, doc := range map[string]map[string]interface{}{"health": health, "summary meta": summaryMeta} {
+		for _, key := range []string{"data_start_date", "data_end_date"} {
+			if value := doc[key]; value != "2024-01-15T00:00:00Z" {
+				t.Errorf("Expected %s %s 2024-01-15T00:00:00Z, got %v", name, key, value)
+			}
+		}
+	}
+
+	// Without data the range is null
+	server := NewServer(processor.New(), &config.Config{Port: ":8080"})
+	rr = httptest.NewRecorder()
+	http.HandlerFunc(server.healthCheck).ServeHTTP(rr, httptest.NewRequest("GET", "/api/health", nil))
+	empty := decodeResponse(t, rr)
+	for _, key := range []string{"data_start_date", "data_end_date"} {
+		if value, exists := empty[key]; !exists || value != nil {
+			t.Errorf("Expected %s to be present and null, got %v", key, value)
+		}
+	}
+}
+
+func TestSearchProducts(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/products/search?q=LAP&limit=5", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response ListResponse[models.ProductFrequency]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Count == 0 || response.Data[0].ProductName != "Laptop" {
+		t.Errorf("Expected Laptop as the best match, got %+v", response.Data)
+	}
+	if response.Meta.Query != "LAP" || response.Meta.Limit != 5 {
+		t.Errorf("Expected query and limit in meta, got %+v", response.Meta)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/products/search?q=o&limit=3", nil))
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Count != 3 {
+		t.Errorf("Expected limit to cap results at 3, got %d", response.Count)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/products/search?q=zzz", nil))
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Count != 0 || response.Data == nil {
+		t.Errorf("Expected an empty list for no matches, got %+v", response)
+	}
+
+	for _, path := range []string{"/api/products/search", "/api/products/search?q=cab&limit=51"} {
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestGetCountrySummaries(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response ListResponse[models.CountrySummary]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Count == 0 || response.Count != len(response.Data) {
+		t.Fatalf("Expected country summaries, got count %d with %d items", response.Count, len(response.Data))
+	}
+	for i, summary := range response.Data {
+		if summary.BestProductName == "" {
+			t.Errorf("Expected a best product for %s", summary.Country)
+		}
+		if i > 0 && summary.TotalRevenue > response.Data[i-1].TotalRevenue {
+			t.Errorf("Expected countries ordered by revenue, got %s after %s", summary.Country, response.Data[i-1].Country)
+		}
+	}
+	if !strings.Contains(rr.Body.String(), `"best_product_name"`) || !strings.Contains(rr.Body.String(), `"best_product_revenue"`) {
+		t.Errorf("Expected best_product_name and best_product_revenue in the response, got %s", rr.Body.String())
+	}
+}
+
+func TestGetCountrySummariesByName(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries?sort_by=country", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response ListResponse[models.CountrySummary]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Meta.SortBy != "country" || len(response.Data) == 0 {
+		t.Fatalf("Expected countries sorted by country, got sort_by %q with %d items", response.Meta.SortBy, len(response.Data))
+	}
+	for i, summary := range response.Data {
+		if summary.CountryCode == "" {
+			t.Errorf("Expected a country code for sample country %s", summary.Country)
+		}
+		if i > 0 && summary.Country < response.Data[i-1].Country {
+			t.Errorf("Expected alphabetical order, got %s after %s", summary.Country, response.Data[i-1].Country)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries?sort_by=flag", nil))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), `"sort_by"`) {
+		t.Errorf("Expected a sort_by validation error, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestGetWeeklySales(t *testing.T) {
+	router := newQueryTestRouter()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/sales-by-week", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response ListResponse[models.WeeklySales]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Count != 52 {
+		t.Fatalf("Expected 52 weeks of sample data, got %d", response.Count)
+	}
+
+	from, to := response.Data[10].Week, response.Data[19].StartDate.Format("2006-01-02")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/sales-by-week?from="+from+"&to="+to, nil))
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Count != 10 || response.Data[0].Week != from {
+		t.Errorf("Expected 10 weeks starting at %s, got %d", from, response.Count)
+	}
+	if response.Meta.From != from || response.Meta.To == "" {
+		t.Errorf("Expected the bounds in meta, got from %q to %q", response.Meta.From, response.Meta.To)
+	}
+}
+
+func TestGetWeeklySalesInvalidBounds(t *testing.T) {
+	router := newQueryTestRouter()
+
+	testCases := map[string][]string{
+		"?from=2024-W60&to=soon":     {"from", "to"},
+		"?from=2025-W10&to=2025-W02": {"to"},
+	}
+	for query, fields := range testCases {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/sales-by-week"+query, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, rr.Code)
+			continue
+		}
+
+		params := decodeProblem(t, rr).InvalidParams
+		if len(params) != len(fields) {
+			t.Errorf("%s: expected errors for %v, got %+v", query, fields, params)
+			continue
+		}
+		for i, field := range fields {
+			if params[i].Name != field {
+				t.Errorf("%s: expected an error for %s, got %s", query, field, params[i].Name)
+			}
+		}
+	}
+}
+
+func TestGetTopRegionsRankBy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regions.csv")
+	content := "transaction_id,region,product_name,quantity,total_price\n" +
+		"TXN001,Europe,Laptop,1,2000\n" +
+		"TXN002,Asia,Cable,40,200\n" +
+		"TXN003,Oceania,Mouse,5,150\n" +
+		"TXN004,Africa,Mouse,5,100\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+	proc := processor.New()
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+
+	testCases := map[string]struct {
+		rankBy string
+		order  []string
+	}{
+		"":                 {models.RankByRevenue, []string{"Europe", "Asia", "Oceania", "Africa"}},
+		"?rank_by=revenue": {models.RankByRevenue, []string{"Europe", "Asia", "Oceania", "Africa"}},
+		"?rank_by=items":   {models.RankByItems, []string{"Asia", "Africa", "Oceania", "Europe"}},
+	}
+	for query, tc := range testCases {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-regions"+query, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%q: expected status %d, got %d", query, http.StatusOK, rr.Code)
+		}
+
+		var response ListResponse[models.RegionRevenue]
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response JSON: %v", err)
+		}
+		var order []string
+		for _, region := range response.Data {
+			order = append(order, region.Region)
+		}
+		if strings.Join(order, ",") != strings.Join(tc.order, ",") {
+			t.Errorf("%q: expected order %v, got %v", query, tc.order, order)
+		}
+		if response.Meta.RankBy != tc.rankBy {
+			t.Errorf("%q: expected meta rank_by %s, got %s", query, tc.rankBy, response.Meta.RankBy)
+		}
+		if tc.rankBy == models.RankByItems && !strings.Contains(response.Meta.Description, "items sold") {
+			t.Errorf("%q: expected the description to name the items ranking, got %s", query, response.Meta.Description)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/top-regions?rank_by=units", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown ranking, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestGetDimensions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regions.csv")
+	content := "transaction_id,country,region,product_name,quantity,total_price\n" +
+		"TXN001,france,Île Maurice,Laptop,1,2000\n" +
+		"TXN002,France,Île Maurice,Cable,1,20\n" +
+		"TXN003,France,île maurice,Mouse,1,15\n" +
+		"TXN004,Finland,Åland,Mouse,1,10\n" +
+		"TXN005,Germany,Zürich,Mouse,1,10\n" +
+		"TXN006,Germany,Bavaria,Mouse,1,10\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+	proc := processor.New()
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/dimensions", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var response Response[models.Dimensions]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	expectedCountries := []models.DimensionValue{{Value: "Finland", Count: 1}, {Value: "France", Count: 3}, {Value: "Germany", Count: 2}}
+	if !reflect.DeepEqual(response.Data.Countries, expectedCountries) {
+		t.Errorf("Expected countries %v, got %v", expectedCountries, response.Data.Countries)
+	}
+	expectedRegions := []models.DimensionValue{{Value: "Åland", Count: 1}, {Value: "Bavaria", Count: 1}, {Value: "Île Maurice", Count: 3}, {Value: "Zürich", Count: 1}}
+	if !reflect.DeepEqual(response.Data.Regions, expectedRegions) {
+		t.Errorf("Expected regions %v, got %v", expectedRegions, response.Data.Regions)
+	}
+}
+
+func TestGetStockPressure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stock.csv")
+	content := "transaction_id,product_name,quantity,total_price,stock_quantity\n" +
+		"TXN001,Laptop,2,2000,1\n" +
+		"TXN002,Laptop,1,1000,0\n" +
+		"TXN003,Mouse,1,15,0\n" +
+		"TXN004,Cable,1,20,30\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+	proc := processor.New()
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/stock-pressure", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var response ListResponse[models.StockPressure]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	expected := []models.StockPressure{
+		{ProductName: "Laptop", Rows: 2, UnitsDemanded: 3, Revenue: 3000},
+		{ProductName: "Mouse", Rows: 1, UnitsDemanded: 1, Revenue: 15},
+	}
+	if response.Count != len(expected) || !reflect.DeepEqual(response.Data, expected) {
+		t.Errorf("Expected stock pressure %+v, got %d rows %+v", expected, response.Count, response.Data)
+	}
+}
+
+func TestWindows1252ProductNamesServed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cp1252.csv")
+	// \xc9 is É in Windows-1252
+	content := "transaction_id,product_name,quantity,total_price\n" +
+		"TXN001,\xc9couteurs sans fil,1,80\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+	proc := processor.New()
+	proc.SetCSVEncoding(processor.EncodingWindows1252)
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/products/search?q=%C3%A9couteurs", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var response ListResponse[models.ProductFrequency]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if response.Count != 1 || response.Data[0].ProductName != "Écouteurs sans fil" {
+		t.Errorf("Expected \"Écouteurs sans fil\", got %+v", response.Data)
+	}
+}
+
+// dataMetaPaths are endpoints whose meta describes the served data
+var dataMetaPaths = []string{
+	"/api/revenue-by-country",
+	"/api/revenue-by-country?shape=nested",
+	"/api/top-products",
+	"/api/stock-pressure",
+	"/api/sales-by-month",
+	"/api/sales-by-week",
+	"/api/top-regions",
+	"/api/dashboard",
+	"/api/summary",
+	"/api/processing-status",
+	"/api/processing-report",
+	"/api/countries",
+	"/api/dimensions",
+	"/api/products/search?q=a",
+}
+
+func TestSampleDataFlaggedInMeta(t *testing.T) {
+	router := newQueryTestRouter()
+
+	for _, path := range append(dataMetaPaths, "/api/countries/USA", "/api/regions/Europe/products") {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d", path, http.StatusOK, rr.Code)
+		}
+		meta, _ := decodeResponse(t, rr)["meta"].(map[string]interface{})
+		if meta["sample_data"] != true {
+			t.Errorf("%s: expected sample_data true in meta, got %v", path, meta)
+		}
+	}
+}
+
+func TestDatasetNotFlaggedAsSample(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sales.csv")
+	content := "transaction_id,country,region,product_name,quantity,total_price\n" +
+		"TXN001,France,Europe,Laptop,1,2000\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+	proc := processor.New()
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+
+	for _, path := range dataMetaPaths {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		meta, _ := decodeResponse(t, rr)["meta"].(map[string]interface{})
+		if _, ok := meta["sample_data"]; ok {
+			t.Errorf("%s: expected no sample_data in meta for a dataset, got %v", path, meta)
+		}
+	}
+}
+
+func TestEmptyDataModeServesEmptyAggregates(t *testing.T) {
+	proc := processor.New()
+	proc.LoadEmptyData()
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+
+	for _, path := range dataMetaPaths {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d", path, http.StatusOK, rr.Code)
+		}
+		response := decodeResponse(t, rr)
+		if data, ok := response["data"].([]interface{}); ok && len(data) != 0 {
+			t.Errorf("%s: expected no rows, got %d", path, len(data))
+		}
+		if meta, _ := response["meta"].(map[string]interface{}); meta["sample_data"] != nil {
+			t.Errorf("%s: expected no sample_data in meta, got %v", path, meta)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/health", nil))
+	if source := decodeResponse(t, rr)["data_source"]; source != processor.SourceEmpty {
+		t.Errorf("Expected data source %q, got %v", processor.SourceEmpty, source)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	cfg := &config.Config{Port: ":8080"}
+	registry := metrics.NewRegistry()
+	proc := processor.New(processor.WithMetrics(registry))
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, []byte("product_name,quantity,total_price\nLaptop,1,10\nBroken,1,10,extra\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	server := NewServer(proc, cfg)
+	rr := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 while metrics are disabled, got %d", rr.Code)
+	}
+
+	server.SetMetrics(registry)
+	rr = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != metrics.ContentType {
+		t.Fatalf("Expected 200 with the Prometheus text format, got %d (%s)", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rr.Body.String(), `processor_skipped_rows_total{reason="malformed"} 1`) {
+		t.Errorf("Expected the skipped row counter in the exposition, got:\n%s", rr.Body.String())
+	}
+}
+
+func TestDashboardDiffEndpoint(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	proc := processor.New(processor.WithClock(func() time.Time { return now }))
+	dir := t.TempDir()
+	load := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("transaction_id,transaction_date,country,product_name,quantity,total_price\n"+content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := proc.ProcessDataset(path); err != nil {
+			t.Fatalf("Failed to process dataset: %v", err)
+		}
+	}
+	load("first.csv", "T1,2024-01-10,USA,Laptop,1,1000\n")
+	loaded := now
+	now = now.Add(time.Hour)
+	load("second.csv", "T1,2024-01-10,USA,Laptop,1,1000\nT2,2024-01-11,USA,Mouse,1,20\n")
+
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+	get := func(target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", target, nil))
+		return rr
+	}
+
+	for _, target := range []string{"/api/dashboard/diff", "/api/dashboard/diff?since=yesterday"} {
+		if rr := get(target); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", target, rr.Code)
+		}
+	}
+
+	if rr := get("/api/dashboard/diff?since=" + now.Format(time.RFC3339)); rr.Code != http.StatusNoContent || rr.Body.Len() != 0 {
+		t.Errorf("Expected 204 without a body when nothing changed, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr := get("/api/dashboard/diff?since=" + loaded.Format(time.RFC3339))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response Response[models.DashboardDiff]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	diff := response.Data
+	if len(diff.Countries) != 1 || diff.Countries[0].Country != "USA" || diff.Countries[0].Change != 20 {
+		t.Errorf("Expected USA revenue to grow by 20, got %+v", diff.Countries)
+	}
+	if len(diff.NewProducts) != 1 || diff.NewProducts[0] != "Mouse" || len(diff.RemovedProducts) != 0 {
+		t.Errorf("Expected Mouse to be the only new product, got %v and %v", diff.NewProducts, diff.RemovedProducts)
+	}
+	if len(diff.Months) != 1 || diff.Months[0].MonthNumber != 1 || diff.Months[0].CurrentTotal != 1020 {
+		t.Errorf("Expected January to change to 1020, got %+v", diff.Months)
+	}
+}
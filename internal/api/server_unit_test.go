@@ -10,8 +10,8 @@ import (
 	"time"
 )
 
-// ProcessorInterface defines the methods that the API server needs
-type ProcessorInterface interface {
+// mockableProcessor defines the methods that TestServer needs
+type mockableProcessor interface {
 	GetCountryRevenues() []models.CountryRevenue
 	GetTopProducts() []models.ProductFrequency
 	GetMonthlySales() []models.MonthlySales
@@ -50,7 +50,7 @@ func (m *MockProcessor) GetDashboardData() *models.DashboardData {
 
 // TestServer is a test-specific server that uses the interface
 type TestServer struct {
-	processor ProcessorInterface
+	processor mockableProcessor
 	config    *config.Config
 }
 
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"abt-analytics-dashboard/internal/processor"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StageRequest is the body of POST /api/admin/stage. An empty Path stages
+// DATA_FILE_PATH, such as after the nightly file replaced it on disk.
+type StageRequest struct {
+	Path string `json:"path"`
+}
+
+// StagedDatasetResponse summarizes a staged dataset for checking it before
+// it is promoted
+type StagedDatasetResponse struct {
+	Path              string         `json:"path"`
+	StagedAt          time.Time      `json:"staged_at"`
+	RecordCount       int            `json:"record_count"`
+	Rows              int            `json:"rows"`
+	SkippedRows       int            `json:"skipped_rows"`
+	DistinctProducts  int            `json:"distinct_products"`
+	DistinctCountries int            `json:"distinct_countries"`
+	DistinctRegions   int            `json:"distinct_regions"`
+	DistinctUsers     int            `json:"distinct_users"`
+	DataStartDate     *time.Time     `json:"data_start_date"`
+	DataEndDate       *time.Time     `json:"data_end_date"`
+	Summary           models.Summary `json:"summary"`
+	Warnings          []string       `json:"warnings"`
+}
+
+// newStagedDatasetResponse summarizes staged
+func newStagedDatasetResponse(staged *processor.StagedDataset) StagedDatasetResponse {
+	data := staged.Data
+	warnings := data.Report.Warnings
+	if warnings == nil {
+		warnings = []string{}
+	}
+	return StagedDatasetResponse{
+		Path:              staged.Path,
+		StagedAt:          staged.StagedAt,
+		RecordCount:       data.RecordCount,
+		Rows:              data.Report.Rows,
+		SkippedRows:       data.Report.SkippedRows,
+		DistinctProducts:  data.DistinctProducts,
+		DistinctCountries: data.DistinctCountries,
+		DistinctRegions:   data.DistinctRegions,
+		DistinctUsers:     data.DistinctUsers,
+		DataStartDate:     timeOrNil(data.DataStartDate),
+		DataEndDate:       timeOrNil(data.DataEndDate),
+		Summary:           data.Summary,
+		Warnings:          warnings,
+	}
+}
+
+// stageDataset processes a dataset into the stage without serving it
+func (s *Server) stageDataset(w http.ResponseWriter, r *http.Request) {
+	// The body is limited by bodyLimitMiddleware; read it whole so that an
+	// oversized body is reported as such rather than as invalid JSON
+	body, err := io.ReadAll(r.Body)
+	if isBodyTooLarge(err) {
+		s.writeBodyTooLargeResponse(w, s.maxRequestBodyBytes())
+		return
+	}
+
+	var request StageRequest
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if err := json.Unmarshal(body, &request); err != nil {
+			s.writeValidationErrorResponse(w, []fieldError{{Field: "body", Message: fmt.Sprintf("invalid JSON object: %v", err)}})
+			return
+		}
+	}
+	path := request.Path
+	if path == "" {
+		path = s.config.DataFilePath
+	}
+	if path == "" {
+		s.writeValidationErrorResponse(w, []fieldError{{Field: "path", Message: "is required when DATA_FILE_PATH is not set"}})
+		return
+	}
+
+	log.Printf("Admin request from %s: staging dataset %s", s.clientIP(r), path)
+	staged, err := s.processor.StageDataset(path)
+
+	var headerErr *processor.InvalidHeaderError
+	switch {
+	case errors.Is(err, processor.ErrStageExists):
+		s.writeErrorResponse(w, http.StatusConflict, "A dataset is already staged; promote or discard it first")
+		return
+	case errors.Is(err, processor.ErrProcessingInProgress):
+		s.writeErrorResponse(w, http.StatusConflict, "Another dataset is being processed; retry once it has finished")
+		return
+	case errors.Is(err, processor.ErrNotFound), errors.Is(err, processor.ErrNotAFile), errors.Is(err, processor.ErrPermission):
+		s.writeErrorResponse(w, http.StatusUnprocessableEntity, fmt.Sprintf("Cannot stage %s: %v", path, err))
+		return
+	case errors.As(err, &headerErr):
+		s.writeErrorResponse(w, http.StatusUnprocessableEntity,
+			fmt.Sprintf("Dataset is missing the required CSV columns: %s", strings.Join(headerErr.Missing, ", ")))
+		return
+	case errors.Is(err, processor.ErrTooManyBadRows):
+		s.writeErrorResponse(w, http.StatusUnprocessableEntity, fmt.Sprintf("Dataset has too many malformed rows: %v", err))
+		return
+	case errors.Is(err, processor.ErrEmptyDataset):
+		s.writeErrorResponse(w, http.StatusUnprocessableEntity, "Dataset has no data rows")
+		return
+	case err != nil:
+		s.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to stage dataset: %v", err))
+		return
+	}
+
+	response := Response[StagedDatasetResponse]{
+		Data: newStagedDatasetResponse(staged),
+		Meta: Meta{Description: "Staged dataset; promote it to serve it or discard it"},
+	}
+	s.writeJSONResponse(w, http.StatusCreated, response)
+}
+
+// previewStagedDataset summarizes the staged dataset
+func (s *Server) previewStagedDataset(w http.ResponseWriter, r *http.Request) {
+	staged, err := s.processor.GetStagedDataset()
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusNotFound, "No dataset is staged")
+		return
+	}
+
+	response := Response[StagedDatasetResponse]{
+		Data: newStagedDatasetResponse(staged),
+		Meta: Meta{Description: "Staged dataset; promote it to serve it or discard it"},
+	}
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// promoteStagedDataset serves the staged dataset in place of the current
+// data
+func (s *Server) promoteStagedDataset(w http.ResponseWriter, r *http.Request) {
+	staged, err := s.processor.PromoteStaged()
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusNotFound, "No dataset is staged")
+		return
+	}
+	log.Printf("Admin request from %s: promoted staged dataset %s", s.clientIP(r), staged.Path)
+
+	data := staged.Data
+	response := UploadResponse{
+		DataSource:  data.DataSource,
+		LastUpdated: data.LastUpdated,
+		RecordCount: data.RecordCount,
+		Rows:        data.Report.Rows,
+		SkippedRows: data.Report.SkippedRows,
+	}
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// discardStagedDataset drops the staged dataset
+func (s *Server) discardStagedDataset(w http.ResponseWriter, r *http.Request) {
+	staged, err := s.processor.DiscardStaged()
+	if err != nil {
+		s.writeErrorResponse(w, http.StatusNotFound, "No dataset is staged")
+		return
+	}
+	log.Printf("Admin request from %s: discarded staged dataset %s", s.clientIP(r), staged.Path)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// adminBodyRequest serves an authenticated admin request with a body
+func adminBodyRequest(router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+adminTestKey)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// writeStageFile writes a nightly dataset with three transactions
+func writeStageFile(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "nightly.csv")
+	content := "transaction_id,transaction_date,country,region,product_name,quantity,total_price\n" +
+		"TXN001,2024-02-01,Japan,Asia,Camera,1,300\n" +
+		"TXN002,2024-02-02,Japan,Asia,Lens,2,200\n" +
+		"TXN003,2024-02-03,Brazil,Latin America,Phone,1,150\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write dataset: %v", err)
+	}
+	return path
+}
+
+// liveCountries returns the countries currently served
+func liveCountries(t *testing.T, router http.Handler) []string {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/countries", nil))
+	var response struct {
+		Data []struct {
+			Country string `json:"country"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	countries := make([]string, len(response.Data))
+	for i, country := range response.Data {
+		countries[i] = country.Country
+	}
+	return countries
+}
+
+func TestStagePreviewPromote(t *testing.T) {
+	_, router := newAdminTestServer(t)
+	path := writeStageFile(t)
+
+	rr := adminBodyRequest(router, "POST", "/api/admin/stage", `{"path":"`+path+`"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	if countries := liveCountries(t, router); len(countries) != 1 || countries[0] != "USA" {
+		t.Errorf("Expected the live data to be unchanged after staging, got %v", countries)
+	}
+
+	rr = adminBodyRequest(router, "POST", "/api/admin/stage", `{"path":"`+path+`"}`)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected status %d while a dataset is staged, got %d", http.StatusConflict, rr.Code)
+	}
+
+	rr = adminRequest(router, "GET", "/api/admin/stage/preview")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	var preview Response[StagedDatasetResponse]
+	if err := json.Unmarshal(rr.Body.Bytes(), &preview); err != nil {
+		t.Fatalf("Failed to parse response JSON: %v", err)
+	}
+	if preview.Data.Path != path || preview.Data.Rows != 3 || preview.Data.DistinctCountries != 2 {
+		t.Errorf("Expected a preview of 3 rows in 2 countries from %s, got %+v", path, preview.Data)
+	}
+	if countries := liveCountries(t, router); len(countries) != 1 || countries[0] != "USA" {
+		t.Errorf("Expected the live data to be unchanged after preview, got %v", countries)
+	}
+
+	rr = adminRequest(router, "POST", "/api/admin/promote")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if countries := liveCountries(t, router); len(countries) != 2 || countries[0] != "Japan" {
+		t.Errorf("Expected the staged countries to be live after promote, got %v", countries)
+	}
+
+	rr = adminRequest(router, "GET", "/api/admin/stage/preview")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d after promote, got %d", http.StatusNotFound, rr.Code)
+	}
+	rr = adminRequest(router, "POST", "/api/admin/promote")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d when nothing is staged, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestStageDiscard(t *testing.T) {
+	_, router := newAdminTestServer(t)
+	path := writeStageFile(t)
+
+	if rr := adminBodyRequest(router, "POST", "/api/admin/stage", `{"path":"`+path+`"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, rr.Code)
+	}
+	if rr := adminRequest(router, "POST", "/api/admin/discard"); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if countries := liveCountries(t, router); len(countries) != 1 || countries[0] != "USA" {
+		t.Errorf("Expected the live data to be unchanged after discard, got %v", countries)
+	}
+	if rr := adminRequest(router, "POST", "/api/admin/discard"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d when nothing is staged, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	// The stage is free again
+	if rr := adminBodyRequest(router, "POST", "/api/admin/stage", `{"path":"`+path+`"}`); rr.Code != http.StatusCreated {
+		t.Errorf("Expected status %d after discard, got %d", http.StatusCreated, rr.Code)
+	}
+}
+
+func TestStageErrors(t *testing.T) {
+	_, router := newAdminTestServer(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"missing file", `{"path":"` + filepath.Join(t.TempDir(), "missing.csv") + `"}`, http.StatusUnprocessableEntity},
+		{"no path or DATA_FILE_PATH", `{}`, http.StatusBadRequest},
+		{"invalid JSON", `{"path":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rr := adminBodyRequest(router, "POST", "/api/admin/stage", tt.body); rr.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, rr.Code)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/api/admin/stage", strings.NewReader(`{}`))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without the admin key, got %d", http.StatusUnauthorized, rr.Code)
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// staticIndex is the SPA entry point served for client-side routes
+const staticIndex = "index.html"
+
+// Cache-Control values for the frontend build. Hashed assets never change
+// under the same name; index.html must be revalidated so that a new build
+// is picked up.
+const (
+	cacheImmutable = "public, max-age=31536000, immutable"
+	cacheNoCache   = "no-cache"
+)
+
+// ValidateStaticDir checks that dir holds a frontend build with an index.html
+func ValidateStaticDir(dir string) error {
+	info, err := os.Stat(filepath.Join(dir, staticIndex))
+	if err != nil {
+		return fmt.Errorf("STATIC_DIR %s has no %s: %w", dir, staticIndex, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("STATIC_DIR %s: %s is a directory", dir, staticIndex)
+	}
+	return nil
+}
+
+// staticHandler serves the frontend build in dir. Existing files are served
+// as is; other paths without a file extension are client-side routes and get
+// index.html. Missing files with an extension, dotfiles and unknown /api
+// paths are 404s, so a typo in an asset or API URL is not answered with HTML.
+func (s *Server) staticHandler(dir string) http.Handler {
+	files := http.FileServer(http.Dir(dir))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)
+		if name == "/api" || strings.HasPrefix(name, "/api/") {
+			s.writeErrorResponse(w, http.StatusNotFound, fmt.Sprintf("No API endpoint at %s", name))
+			return
+		}
+		if strings.Contains(name, "/.") {
+			http.NotFound(w, r)
+			return
+		}
+
+		if name != "/"+staticIndex {
+			info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+			if err == nil && !info.IsDir() {
+				if isHashedAsset(path.Base(name)) {
+					w.Header().Set("Cache-Control", cacheImmutable)
+				} else {
+					w.Header().Set("Cache-Control", cacheNoCache)
+				}
+				files.ServeHTTP(w, r)
+				return
+			}
+			if path.Ext(name) != "" {
+				http.NotFound(w, r)
+				return
+			}
+		}
+
+		index, err := os.Open(filepath.Join(dir, staticIndex))
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusNotFound, "Frontend build not found")
+			return
+		}
+		defer index.Close()
+
+		info, err := index.Stat()
+		if err != nil {
+			s.writeErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+			return
+		}
+		w.Header().Set("Cache-Control", cacheNoCache)
+		http.ServeContent(w, r, staticIndex, info.ModTime(), index)
+	})
+}
+
+// isHashedAsset reports whether a file name carries a content hash, as in
+// "index-B3x9kQ2a.js" (Vite) or "main.8f3a2c1d.css" (Create React App): a
+// segment of at least 8 letters and digits, including a digit, separated by
+// '-' or '.' before the extension
+func isHashedAsset(name string) bool {
+	base := strings.TrimSuffix(name, path.Ext(name))
+	for _, segment := range strings.FieldsFunc(base, func(r rune) bool { return r == '-' || r == '.' }) {
+		if len(segment) < 8 || segment == base {
+			continue
+		}
+		hasDigit, alphanumeric := false, true
+		for _, r := range segment {
+			switch {
+			case unicode.IsDigit(r):
+				hasDigit = true
+			case r > unicode.MaxASCII || !unicode.IsLetter(r) && r != '_':
+				alphanumeric = false
+			}
+		}
+		if hasDigit && alphanumeric {
+			return true
+		}
+	}
+	return false
+}
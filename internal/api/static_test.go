@@ -0,0 +1,152 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/processor"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const staticTestIndex = `<!doctype html><div id="root"></div>`
+
+func newStaticTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html":               staticTestIndex,
+		"favicon.ico":              "icon",
+		"assets/index-B3x9kQ2a.js": "console.log('app')",
+		".env":                     "SECRET=1",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	proc := processor.New()
+	proc.LoadSampleData()
+	return NewServer(proc, &config.Config{Port: ":8080", StaticDir: dir}).setupRoutes()
+}
+
+func getStatic(router http.Handler, path string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+	return rr
+}
+
+func TestStaticAssets(t *testing.T) {
+	router := newStaticTestRouter(t)
+
+	rr := getStatic(router, "/assets/index-B3x9kQ2a.js")
+	if rr.Code != http.StatusOK || rr.Body.String() != "console.log('app')" {
+		t.Fatalf("Expected the asset, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if cache := rr.Header().Get("Cache-Control"); cache != cacheImmutable {
+		t.Errorf("Expected hashed asset Cache-Control %q, got %q", cacheImmutable, cache)
+	}
+
+	rr = getStatic(router, "/favicon.ico")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected favicon, got %d", rr.Code)
+	}
+	if cache := rr.Header().Get("Cache-Control"); cache != cacheNoCache {
+		t.Errorf("Expected unhashed file Cache-Control %q, got %q", cacheNoCache, cache)
+	}
+
+	for _, path := range []string{"/assets/missing-A1b2C3d4.js", "/.env"} {
+		if rr := getStatic(router, path); rr.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestStaticSPAFallback(t *testing.T) {
+	router := newStaticTestRouter(t)
+
+	for _, path := range []string{"/", "/index.html", "/dashboard", "/reports/2024/march"} {
+		rr := getStatic(router, path)
+		if rr.Code != http.StatusOK || rr.Body.String() != staticTestIndex {
+			t.Errorf("%s: expected index.html, got %d: %s", path, rr.Code, rr.Body.String())
+			continue
+		}
+		if cache := rr.Header().Get("Cache-Control"); cache != cacheNoCache {
+			t.Errorf("%s: expected Cache-Control %q, got %q", path, cacheNoCache, cache)
+		}
+		if contentType := rr.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+			t.Errorf("%s: expected text/html, got %s", path, contentType)
+		}
+	}
+}
+
+func TestStaticLeavesAPIRoutes(t *testing.T) {
+	router := newStaticTestRouter(t)
+
+	rr := getStatic(router, "/api/health")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"status":"healthy"`) {
+		t.Errorf("Expected the health endpoint, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = getStatic(router, "/api")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"endpoints"`) {
+		t.Errorf("Expected the endpoint list at /api, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = getStatic(router, "/api/no-such-endpoint")
+	if rr.Code != http.StatusNotFound || rr.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected a JSON 404 for unknown API paths, got %d (%s)", rr.Code, rr.Header().Get("Content-Type"))
+	}
+
+	rr = getStatic(router, "/status")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), "<html") {
+		t.Errorf("Expected the status page, got %d", rr.Code)
+	}
+}
+
+func TestRootWithoutStaticDir(t *testing.T) {
+	router := NewServer(processor.New(), &config.Config{Port: ":8080"}).setupRoutes()
+
+	for _, path := range []string{"/", "/api"} {
+		rr := getStatic(router, path)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"endpoints"`) {
+			t.Errorf("%s: expected the endpoint list, got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestIsHashedAsset(t *testing.T) {
+	testCases := map[string]bool{
+		"index-B3x9kQ2a.js":    true,
+		"main.8f3a2c1d.css":    true,
+		"vendor-components.js": false,
+		"logo2024x.png":        false,
+		"favicon.ico":          false,
+	}
+	for name, expected := range testCases {
+		if got := isHashedAsset(name); got != expected {
+			t.Errorf("isHashedAsset(%q): expected %v, got %v", name, expected, got)
+		}
+	}
+}
+
+func TestValidateStaticDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := ValidateStaticDir(dir); err == nil {
+		t.Error("Expected an error for a directory without index.html")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte(staticTestIndex), 0644); err != nil {
+		t.Fatalf("Failed to write index.html: %v", err)
+	}
+	if err := ValidateStaticDir(dir); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"bytes"
+	"crypto/rand"
+	"embed"
+	"encoding/base64"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+)
+
+// statusTopN is the number of products and regions listed on the status page
+const statusTopN = 10
+
+//go:embed templates/status.html
+var templateFS embed.FS
+
+// statusTemplate renders the human-readable /status page
+var statusTemplate = template.Must(template.New("status.html").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).ParseFS(templateFS, "templates/status.html"))
+
+// statusPage is the data rendered by statusTemplate
+type statusPage struct {
+	Nonce       string
+	Status      string
+	Environment string
+	Now         time.Time
+	Data        *models.DashboardData
+	TopProducts []models.ProductFrequency
+	TopRegions  []models.RegionRevenue
+	History     []models.ProcessingRun
+}
+
+// getStatusPage serves a self-contained HTML overview for operators. The
+// only style block is allowed through a per-request CSP nonce, so the page
+// works under a strict Content-Security-Policy.
+func (s *Server) getStatusPage(w http.ResponseWriter, r *http.Request) {
+	nonce, err := newNonce()
+	if err != nil {
+		log.Printf("Error generating CSP nonce: %v", err)
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	data := s.processor.GetDashboardData()
+	page := statusPage{
+		Nonce:       nonce,
+		Status:      "healthy",
+		Environment: s.environment(),
+		Now:         time.Now(),
+		Data:        data,
+		TopProducts: firstN(data.TopProducts, statusTopN),
+		TopRegions:  firstN(data.TopRegions, statusTopN),
+		History:     s.processor.GetProcessingHistory(),
+	}
+
+	var buf bytes.Buffer
+	if err := statusTemplate.Execute(&buf, page); err != nil {
+		log.Printf("Error rendering status page: %v", err)
+		s.writeErrorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'nonce-"+nonce+"'")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Printf("Error writing status page: %v", err)
+	}
+}
+
+// newNonce returns a random base64 value for a CSP nonce
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// firstN returns at most n leading items
+func firstN[T any](items []T, n int) []T {
+	if len(items) > n {
+		return items[:n]
+	}
+	return items
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/processor"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStatusPage(t *testing.T) {
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, &config.Config{Port: ":8080", Environment: config.EnvProduction, CORSAllowedOrigins: []string{"https://example.com"}}).setupRoutes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/status", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if contentType := rr.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Errorf("Expected HTML content type, got %s", contentType)
+	}
+
+	csp := rr.Header().Get("Content-Security-Policy")
+	if !strings.Contains(csp, "default-src 'none'") || !strings.Contains(csp, "style-src 'nonce-") {
+		t.Errorf("Expected a strict CSP with a style nonce, got %s", csp)
+	}
+
+	body := rr.Body.String()
+	data := proc.GetDashboardData()
+	products := proc.GetTopProducts()
+	regions := proc.GetTopRegions()
+
+	expected := []string{
+		"healthy",
+		config.EnvProduction,
+		fmt.Sprintf("<td class=\"num\">%d</td>", data.RecordCount),
+		data.LastUpdated.Format("2006-01-02 15:04:05 MST"),
+		products[0].ProductName,
+		regions[0].Region,
+		fmt.Sprintf("%.2f", regions[0].TotalRevenue),
+	}
+	for _, value := range expected {
+		if !strings.Contains(body, value) {
+			t.Errorf("Expected status page to contain '%s'", value)
+		}
+	}
+
+	if nonce := strings.TrimSuffix(strings.SplitN(csp, "'nonce-", 2)[1], "'"); !strings.Contains(body, `nonce="`+nonce+`"`) {
+		t.Error("Expected the style block to carry the CSP nonce")
+	}
+
+	if rows := strings.Count(body, "<tr><td class=\"num\">"); rows > 2*statusTopN {
+		t.Errorf("Expected at most %d ranked rows, got %d", 2*statusTopN, rows)
+	}
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// streamFlushInterval is the number of list elements written between flushes
+const streamFlushInterval = 1000
+
+// writeJSONList streams a {"count":n,"data":[...],"meta":{...}} envelope,
+// encoding one list element at a time so the response body is never held
+// in memory as a whole. The response uses chunked transfer encoding.
+// Object keys are renamed when rename is set.
+func writeJSONList[T any](w http.ResponseWriter, statusCode int, items []T, meta Meta, rename func(string) string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('{')
+	bw.WriteString(jsonKey("count", rename))
+	bw.WriteString(strconv.Itoa(len(items)))
+	bw.WriteByte(',')
+	bw.WriteString(jsonKey("data", rename))
+
+	encode := newElementEncoder(bw, rename)
+	if err := streamJSONArray(bw, w, items, encode); err != nil {
+		log.Printf("Error streaming JSON response: %v", err)
+		return
+	}
+
+	bw.WriteByte(',')
+	bw.WriteString(jsonKey("meta", rename))
+	if err := encode(meta); err != nil {
+		log.Printf("Error encoding JSON response meta: %v", err)
+		return
+	}
+	bw.WriteString("}\n")
+
+	if err := bw.Flush(); err != nil {
+		log.Printf("Error writing JSON response: %v", err)
+	}
+}
+
+// ndjsonSummary is the last line of an NDJSON list, telling consumers
+// reading line by line that the list is complete
+type ndjsonSummary struct {
+	Type        string     `json:"type"`
+	Count       int        `json:"count"`
+	LastUpdated *time.Time `json:"last_updated,omitempty"`
+}
+
+// writeNDJSONList streams items as newline-delimited JSON, one element per
+// line followed by the summary line, flushing periodically like
+// streamJSONArray. Object keys are renamed when rename is set.
+func writeNDJSONList[T any](w http.ResponseWriter, statusCode int, items []T, summary ndjsonSummary, rename func(string) string) {
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(statusCode)
+
+	flusher, _ := w.(http.Flusher)
+	bw := bufio.NewWriter(w)
+	writeLine := func(v interface{}) error {
+		line, err := json.Marshal(v)
+		if err == nil && rename != nil {
+			line, err = rekeyJSON(line, rename)
+			line = bytes.TrimSuffix(line, []byte("\n"))
+		}
+		if err != nil {
+			return err
+		}
+		bw.Write(line)
+		return bw.WriteByte('\n')
+	}
+
+	for i := range items {
+		if err := writeLine(&items[i]); err != nil {
+			log.Printf("Error streaming NDJSON response: %v", err)
+			return
+		}
+
+		if (i+1)%streamFlushInterval == 0 {
+			if err := bw.Flush(); err != nil {
+				log.Printf("Error writing NDJSON response: %v", err)
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+
+	if err := writeLine(summary); err != nil {
+		log.Printf("Error encoding NDJSON summary: %v", err)
+		return
+	}
+	if err := bw.Flush(); err != nil {
+		log.Printf("Error writing NDJSON response: %v", err)
+	}
+}
+
+// writeDashboardJSON streams the complete dashboard envelope. The country
+// revenue list, by far the largest part of the payload, is encoded element
+// by element; the remaining fields are small and encoded in one go. Object
+// keys are renamed when rename is set.
+func writeDashboardJSON(w http.ResponseWriter, statusCode int, data *models.DashboardData, meta Meta, rename func(string) string) {
+	rest := *data
+	rest.CountryRevenues = nil
+	encoded, err := json.Marshal(rest)
+	if err == nil && rename != nil {
+		encoded, err = rekeyJSON(encoded, rename)
+		encoded = bytes.TrimSuffix(encoded, []byte("\n"))
+	}
+
+	// An encoded DashboardData without country revenues starts with an
+	// empty list; the streamed list is written in its place
+	revenuesKey := jsonKey("country_revenues", rename)
+	prefix := "{" + revenuesKey + "[]"
+	if err != nil || !bytes.HasPrefix(encoded, []byte(prefix)) {
+		log.Printf("Error encoding JSON response: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('{')
+	bw.WriteString(jsonKey("data", rename))
+	bw.WriteByte('{')
+	bw.WriteString(revenuesKey)
+	encode := newElementEncoder(bw, rename)
+	if err := streamJSONArray(bw, w, data.CountryRevenues, encode); err != nil {
+		log.Printf("Error streaming JSON response: %v", err)
+		return
+	}
+
+	// The remaining fields follow the streamed list, closing the object
+	bw.Write(encoded[len(prefix):])
+
+	bw.WriteByte(',')
+	bw.WriteString(jsonKey("meta", rename))
+	if err := encode(meta); err != nil {
+		log.Printf("Error encoding JSON response meta: %v", err)
+		return
+	}
+	bw.WriteString("}\n")
+
+	if err := bw.Flush(); err != nil {
+		log.Printf("Error writing JSON response: %v", err)
+	}
+}
+
+// jsonKey returns key encoded as an object key followed by its colon,
+// renamed when rename is set
+func jsonKey(key string, rename func(string) string) string {
+	if rename != nil {
+		key = rename(key)
+	}
+	return strconv.Quote(key) + ":"
+}
+
+// newElementEncoder returns a function writing one value to bw as JSON,
+// with its object keys renamed when rename is set. Each value is re-keyed
+// on its own, so renaming never needs more than one element in memory.
+func newElementEncoder(bw *bufio.Writer, rename func(string) string) func(v interface{}) error {
+	encoder := json.NewEncoder(bw)
+	if rename == nil {
+		return encoder.Encode
+	}
+	return func(v interface{}) error {
+		raw, err := json.Marshal(v)
+		if err == nil {
+			raw, err = rekeyJSON(raw, rename)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = bw.Write(raw)
+		return err
+	}
+}
+
+// streamJSONArray writes items as a JSON array with encode, flushing the
+// buffered writer and the underlying response periodically
+func streamJSONArray[T any](bw *bufio.Writer, w http.ResponseWriter, items []T, encode func(v interface{}) error) error {
+	flusher, _ := w.(http.Flusher)
+
+	bw.WriteByte('[')
+	for i := range items {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		if err := encode(&items[i]); err != nil {
+			return err
+		}
+
+		if (i+1)%streamFlushInterval == 0 {
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+	bw.WriteByte(']')
+
+	return nil
+}
@@ -0,0 +1,330 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/models"
+	"abt-analytics-dashboard/internal/processor"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// discardResponseWriter is an http.ResponseWriter that drops the body, so
+// benchmarks measure encoding rather than response buffering. It records
+// the largest single write, i.e. the most response bytes held at once.
+type discardResponseWriter struct {
+	header   http.Header
+	maxWrite int
+}
+
+func (d *discardResponseWriter) Header() http.Header {
+	if d.header == nil {
+		d.header = make(http.Header)
+	}
+	return d.header
+}
+
+func (d *discardResponseWriter) Write(b []byte) (int, error) {
+	if len(b) > d.maxWrite {
+		d.maxWrite = len(b)
+	}
+	return len(b), nil
+}
+
+func (d *discardResponseWriter) WriteHeader(int) {}
+
+func (d *discardResponseWriter) Flush() {}
+
+func makeCountryRevenues(n int) []models.CountryRevenue {
+	revenues := make([]models.CountryRevenue, n)
+	for i := range revenues {
+		revenues[i] = models.CountryRevenue{
+			Country:          fmt.Sprintf("Country %d", i%200),
+			ProductName:      fmt.Sprintf("Product %d", i),
+			Currency:         "USD",
+			TotalRevenue:     float64(i) * 1.5,
+			TransactionCount: i % 1000,
+		}
+	}
+	return revenues
+}
+
+func TestWriteJSONListProducesValidJSON(t *testing.T) {
+	items := makeCountryRevenues(2*streamFlushInterval + 17)
+	meta := Meta{Description: "test <list> & more"}
+
+	rr := httptest.NewRecorder()
+	writeJSONList(rr, http.StatusOK, items, meta, nil)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected Content-Type 'application/json', got '%s'", contentType)
+	}
+	if rr.Header().Get("Content-Length") != "" {
+		t.Error("Expected no Content-Length header on streamed response")
+	}
+
+	var response ListResponse[models.CountryRevenue]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse streamed JSON: %v", err)
+	}
+
+	if response.Count != len(items) || len(response.Data) != len(items) {
+		t.Errorf("Expected %d items, got count %d and %d decoded", len(items), response.Count, len(response.Data))
+	}
+	if response.Data[len(items)-1] != items[len(items)-1] {
+		t.Errorf("Expected last item %+v, got %+v", items[len(items)-1], response.Data[len(items)-1])
+	}
+	if response.Meta.Description != "test <list> & more" {
+		t.Errorf("Expected meta to round-trip, got %v", response.Meta)
+	}
+}
+
+func TestWriteJSONListEmpty(t *testing.T) {
+	rr := httptest.NewRecorder()
+	writeJSONList(rr, http.StatusOK, []models.RegionRevenue{}, Meta{}, nil)
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse streamed JSON: %v", err)
+	}
+	if data, ok := response["data"].([]interface{}); !ok || len(data) != 0 {
+		t.Errorf("Expected empty data array, got %v", response["data"])
+	}
+}
+
+func TestWriteJSONListCamelCaseMatchesRekeyed(t *testing.T) {
+	items := makeCountryRevenues(streamFlushInterval + 3)
+	meta := Meta{Description: "camel", Pagination: newPagination(len(items), 1, 10)}
+
+	rr := httptest.NewRecorder()
+	writeJSONList(rr, http.StatusOK, items, meta, camelCase)
+
+	raw, err := json.Marshal(newListResponse(items, meta))
+	if err != nil {
+		t.Fatalf("Failed to encode list: %v", err)
+	}
+	if raw, err = rekeyJSON(raw, camelCase); err != nil {
+		t.Fatalf("Failed to re-key list: %v", err)
+	}
+
+	var streamed, rekeyed interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &streamed); err != nil {
+		t.Fatalf("Failed to parse streamed JSON: %v", err)
+	}
+	json.Unmarshal(raw, &rekeyed)
+	if !reflect.DeepEqual(streamed, rekeyed) {
+		t.Error("Expected the streamed camelCase list to match the re-keyed encoding")
+	}
+	if !strings.Contains(rr.Body.String(), `"productName"`) || strings.Contains(rr.Body.String(), `"product_name"`) {
+		t.Error("Expected element keys to be camelCase")
+	}
+}
+
+func TestDashboardStreamingCamelCase(t *testing.T) {
+	proc := processor.New()
+	proc.LoadSampleData()
+	data := proc.GetDashboardData()
+
+	rr := httptest.NewRecorder()
+	writeDashboardJSON(rr, http.StatusOK, data, Meta{Description: "camel"}, camelCase)
+
+	raw, err := json.Marshal(Response[*models.DashboardData]{Data: data, Meta: Meta{Description: "camel"}})
+	if err != nil {
+		t.Fatalf("Failed to encode dashboard: %v", err)
+	}
+	if raw, err = rekeyJSON(raw, camelCase); err != nil {
+		t.Fatalf("Failed to re-key dashboard: %v", err)
+	}
+
+	var streamed, rekeyed interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &streamed); err != nil {
+		t.Fatalf("Failed to parse streamed JSON: %v", err)
+	}
+	json.Unmarshal(raw, &rekeyed)
+	if !reflect.DeepEqual(streamed, rekeyed) {
+		t.Error("Expected the streamed camelCase dashboard to match the re-keyed encoding")
+	}
+}
+
+func TestDashboardStreamingMatchesModel(t *testing.T) {
+	proc := processor.New()
+	proc.LoadSampleData()
+	server := NewServer(proc, &config.Config{Port: ":8080"})
+
+	rr := httptest.NewRecorder()
+	server.getDashboardData(rr, httptest.NewRequest("GET", "/api/dashboard", nil))
+
+	body := rr.Body.String()
+	if count := strings.Count(body, `"country_revenues"`); count != 1 {
+		t.Errorf("Expected country_revenues exactly once, found %d", count)
+	}
+
+	var response Response[models.DashboardData]
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse streamed JSON: %v", err)
+	}
+
+	expected := proc.GetDashboardData()
+	if len(response.Data.CountryRevenues) != len(expected.CountryRevenues) {
+		t.Errorf("Expected %d country revenues, got %d", len(expected.CountryRevenues), len(response.Data.CountryRevenues))
+	}
+	if len(response.Data.TopProducts) != len(expected.TopProducts) {
+		t.Errorf("Expected %d top products, got %d", len(expected.TopProducts), len(response.Data.TopProducts))
+	}
+	if response.Data.RecordCount != expected.RecordCount {
+		t.Errorf("Expected record count %d, got %d", expected.RecordCount, response.Data.RecordCount)
+	}
+	if response.Meta.Description != "Complete dashboard data including all metrics" {
+		t.Errorf("Expected description to match, got '%v'", response.Meta.Description)
+	}
+}
+
+func BenchmarkCountryRevenuesBuffered(b *testing.B) {
+	items := makeCountryRevenues(500000)
+	server := NewServer(processor.New(), &config.Config{Port: ":8080"})
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	maxWrite := 0
+	for i := 0; i < b.N; i++ {
+		response := ListResponse[models.CountryRevenue]{
+			Count: len(items),
+			Data:  items,
+			Meta:  Meta{Description: "benchmark"},
+		}
+		w := &discardResponseWriter{}
+		server.writeJSONResponse(w, http.StatusOK, response)
+		maxWrite = w.maxWrite
+	}
+	b.ReportMetric(float64(maxWrite), "peak-buffer-B")
+}
+
+func BenchmarkCountryRevenuesStreaming(b *testing.B) {
+	items := makeCountryRevenues(500000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	maxWrite := 0
+	for i := 0; i < b.N; i++ {
+		w := &discardResponseWriter{}
+		writeJSONList(w, http.StatusOK, items, Meta{Description: "benchmark"}, nil)
+		maxWrite = w.maxWrite
+	}
+	b.ReportMetric(float64(maxWrite), "peak-buffer-B")
+}
+
+// readNDJSON decodes an NDJSON body line by line into its rows and the
+// trailing summary line
+func readNDJSON(t *testing.T, body io.Reader) ([]map[string]interface{}, ndjsonSummary) {
+	t.Helper()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Failed to read NDJSON body: %v", err)
+	}
+	if len(lines) == 0 {
+		t.Fatal("Expected at least the summary line, got an empty body")
+	}
+
+	rows := make([]map[string]interface{}, 0, len(lines)-1)
+	for i, line := range lines[:len(lines)-1] {
+		var row map[string]interface{}
+		if err := json.Unmarshal(line, &row); err != nil {
+			t.Fatalf("Line %d is not a JSON object: %v", i+1, err)
+		}
+		rows = append(rows, row)
+	}
+	var summary ndjsonSummary
+	if err := json.Unmarshal(lines[len(lines)-1], &summary); err != nil || summary.Type != "summary" {
+		t.Fatalf("Expected a summary line last, got %s", lines[len(lines)-1])
+	}
+	return rows, summary
+}
+
+func TestWriteNDJSONList(t *testing.T) {
+	items := makeCountryRevenues(2*streamFlushInterval + 17)
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	rr := httptest.NewRecorder()
+	writeNDJSONList(rr, http.StatusOK, items, ndjsonSummary{Type: "summary", Count: len(items), LastUpdated: &updated}, nil)
+
+	if contentType := rr.Header().Get("Content-Type"); contentType != ndjsonContentType {
+		t.Errorf("Expected Content-Type %s, got %s", ndjsonContentType, contentType)
+	}
+	if !rr.Flushed {
+		t.Error("Expected the stream to be flushed while writing")
+	}
+
+	rows, summary := readNDJSON(t, rr.Body)
+	if len(rows) != len(items) || summary.Count != len(items) {
+		t.Errorf("Expected %d rows, got %d lines and summary count %d", len(items), len(rows), summary.Count)
+	}
+	if summary.LastUpdated == nil || !summary.LastUpdated.Equal(updated) {
+		t.Errorf("Expected last_updated %v, got %v", updated, summary.LastUpdated)
+	}
+	if rows[len(rows)-1]["product_name"] != items[len(items)-1].ProductName {
+		t.Errorf("Expected last row %q, got %v", items[len(items)-1].ProductName, rows[len(rows)-1])
+	}
+}
+
+func TestCountryRevenuesNDJSON(t *testing.T) {
+	proc := processor.New()
+	proc.LoadSampleData()
+	router := NewServer(proc, &config.Config{Port: ":8080"}).setupRoutes()
+	total := len(proc.GetDashboardData().CountryRevenues)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/revenue-by-country?format=ndjson", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	rows, summary := readNDJSON(t, rr.Body)
+	if len(rows) != total || summary.Count != total {
+		t.Errorf("Expected all %d rows, got %d lines and summary count %d", total, len(rows), summary.Count)
+	}
+	if summary.LastUpdated == nil {
+		t.Error("Expected last_updated in the summary line")
+	}
+
+	// Compressed like any other response
+	req := httptest.NewRequest("GET", "/api/revenue-by-country?format=ndjson&case=camel", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if encoding := rr.Header().Get("Content-Encoding"); encoding != "gzip" {
+		t.Fatalf("Expected gzip Content-Encoding, got %q", encoding)
+	}
+	gz, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("Failed to read gzip body: %v", err)
+	}
+	rows, summary = readNDJSON(t, gz)
+	if len(rows) != total || summary.Count != total {
+		t.Errorf("Expected all %d rows compressed, got %d lines and summary count %d", total, len(rows), summary.Count)
+	}
+	if _, ok := rows[0]["productName"]; !ok {
+		t.Errorf("Expected camelCase keys with case=camel, got %v", rows[0])
+	}
+
+	// Single objects have no lines to stream
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/summary?format=ndjson", nil))
+	if rr.Code != http.StatusNotAcceptable {
+		t.Errorf("Expected status %d for ndjson on a single object, got %d", http.StatusNotAcceptable, rr.Code)
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTracingTestServer returns a router whose requests are traced into the
+// returned recorder
+func newTracingTestServer(t *testing.T) (*tracetest.SpanRecorder, http.Handler) {
+	t.Helper()
+
+	proc, _ := newAdminTestServer(t)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	proc.SetTracerProvider(provider)
+
+	server := NewServer(proc, &config.Config{Port: ":8080", AdminAPIKey: adminTestKey})
+	server.SetTracerProvider(provider)
+	return recorder, server.server.Handler
+}
+
+// findSpan returns the recorded span named name
+func findSpan(t *testing.T, spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+
+	for _, span := range spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	t.Fatalf("Expected a %s span, got %d spans", name, len(spans))
+	return nil
+}
+
+// spanAttribute returns the value of the attribute named key of span
+func spanAttribute(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, attr := range span.Attributes() {
+		if string(attr.Key) == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder, router := newTracingTestServer(t)
+
+	req := httptest.NewRequest("GET", "/api/countries/USA", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	span := findSpan(t, recorder.Ended(), "GET /api/countries/{country}")
+	if span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("Expected a server span, got kind %v", span.SpanKind())
+	}
+	if traceID, parent := span.SpanContext().TraceID(), span.Parent().SpanID(); traceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || parent.String() != "00f067aa0ba902b7" {
+		t.Errorf("Expected the span to continue the caller's trace, got trace %s parent %s", traceID, parent)
+	}
+	if got := spanAttribute(span, "http.status_code").AsInt64(); got != int64(rr.Code) {
+		t.Errorf("Expected status code attribute %d, got %v", rr.Code, got)
+	}
+	if got := spanAttribute(span, "http.route").AsString(); got != "/api/countries/{country}" {
+		t.Errorf("Expected http.route /api/countries/{country}, got %v", got)
+	}
+}
+
+func TestTracingUnmatchedRoute(t *testing.T) {
+	recorder, router := newTracingTestServer(t)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/no-such-route", nil))
+
+	span := findSpan(t, recorder.Ended(), "GET")
+	if got := spanAttribute(span, "http.status_code").AsInt64(); got != http.StatusNotFound {
+		t.Errorf("Expected status code attribute %d, got %v", http.StatusNotFound, got)
+	}
+}
+
+func TestTracingUploadSpans(t *testing.T) {
+	recorder, router := newTracingTestServer(t)
+
+	req := httptest.NewRequest("POST", "/api/admin/upload", strings.NewReader(uploadTestCSV))
+	req.Header.Set("Authorization", "Bearer "+adminTestKey)
+	req.Header.Set("Content-Type", "text/csv")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	spans := recorder.Ended()
+	request := findSpan(t, spans, "POST /api/admin/upload")
+	process := findSpan(t, spans, "processor.process")
+	if process.SpanContext().TraceID() != request.SpanContext().TraceID() || process.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Errorf("Expected processing to be traced under the request span")
+	}
+	for _, name := range []string{"processor.read", "processor.aggregate", "processor.finalize"} {
+		if span := findSpan(t, spans, name); span.Parent().SpanID() != process.SpanContext().SpanID() {
+			t.Errorf("Expected %s to be a child of processor.process", name)
+		}
+	}
+	if got := spanAttribute(findSpan(t, spans, "processor.read"), "processor.rows").AsInt64(); got != 2 {
+		t.Errorf("Expected 2 rows read, got %v", got)
+	}
+}
+
+func TestTracingAccessLog(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	recorder, router := newTracingTestServer(t)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/summary", nil))
+
+	traceID := findSpan(t, recorder.Ended(), "GET /api/summary").SpanContext().TraceID()
+	if !strings.Contains(logs.String(), "trace_id="+traceID.String()) {
+		t.Errorf("Expected the access log to include trace_id=%s, got %q", traceID, logs.String())
+	}
+}
+
+func TestAccessLogWithoutTracing(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	_, router := newAdminTestServer(t)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/summary", nil))
+
+	if strings.Contains(logs.String(), "trace_id=") {
+		t.Errorf("Expected no trace_id without tracing, got %q", logs.String())
+	}
+}
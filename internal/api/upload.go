@@ -0,0 +1,108 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/processor"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// uploadFormField is the multipart field carrying the uploaded CSV
+const uploadFormField = "file"
+
+// errMissingUploadFile is returned when a multipart upload has no file part
+var errMissingUploadFile = errors.New("multipart upload has no '" + uploadFormField + "' part")
+
+// uploadDataset replaces the served data with an uploaded CSV. The body is
+// either the CSV itself or a multipart form with a "file" part; in both cases
+// it is streamed straight into the processing pipeline, so nothing is
+// buffered in memory as a whole or spilled to temporary files. Bodies larger
+// than MAX_UPLOAD_BYTES are rejected with 413 and the current data is kept.
+func (s *Server) uploadDataset(w http.ResponseWriter, r *http.Request) {
+	limit := s.maxUploadBytes()
+	r.Body = http.MaxBytesReader(w, r.Body, int64(limit))
+	defer r.Body.Close()
+
+	dataset, err := uploadReader(r)
+	if err == nil {
+		log.Printf("Processing dataset upload from %s", s.clientIP(r))
+		err = s.processor.Process(r.Context(), processor.ReaderSource(dataset))
+	}
+
+	var headerErr *processor.InvalidHeaderError
+	switch {
+	case isBodyTooLarge(err):
+		s.writeErrorResponse(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Upload exceeds the %d byte limit (MAX_UPLOAD_BYTES)", limit))
+		return
+	case errors.As(err, &headerErr):
+		s.writeErrorResponse(w, http.StatusUnprocessableEntity,
+			fmt.Sprintf("Upload is missing the required CSV columns: %s", strings.Join(headerErr.Missing, ", ")))
+		return
+	case errors.Is(err, processor.ErrTooManyBadRows):
+		s.writeErrorResponse(w, http.StatusUnprocessableEntity, fmt.Sprintf("Upload has too many malformed rows: %v", err))
+		return
+	case errors.Is(err, processor.ErrEmptyDataset):
+		s.writeErrorResponse(w, http.StatusUnprocessableEntity, "Upload has no data rows")
+		return
+	case errors.Is(err, processor.ErrProcessingInProgress):
+		s.writeErrorResponse(w, http.StatusConflict, "Another dataset is being processed; retry once it has finished")
+		return
+	case errors.Is(err, processor.ErrAborted):
+		log.Printf("Dataset upload from %s aborted: %v", s.clientIP(r), err)
+		s.writeErrorResponse(w, http.StatusBadRequest, "Upload was aborted before it completed")
+		return
+	case err != nil:
+		s.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Failed to process upload: %v", err))
+		return
+	}
+
+	data := s.processor.GetDashboardData()
+	response := UploadResponse{
+		DataSource:  data.DataSource,
+		LastUpdated: data.LastUpdated,
+		RecordCount: data.RecordCount,
+		Rows:        data.Report.Rows,
+		SkippedRows: data.Report.SkippedRows,
+	}
+	s.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// uploadReader returns the CSV stream of an upload: the "file" part of a
+// multipart form, or the request body itself for any other content type
+func uploadReader(r *http.Request) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	parts, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			return nil, errMissingUploadFile
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == uploadFormField {
+			return part, nil
+		}
+	}
+}
+
+// maxUploadBytes returns the configured upload size limit
+func (s *Server) maxUploadBytes() int {
+	if n := s.runtimeConfig().MaxUploadBytes; n > 0 {
+		return n
+	}
+	return config.DefaultMaxUploadBytes
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/processor"
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const uploadTestLimit = 4096
+
+const uploadTestCSV = "transaction_id,transaction_date,country,region,product_name,quantity,total_price\n" +
+	"TXN100,2024-02-01,Canada,North America,Phone,2,400\n" +
+	"TXN101,2024-02-02,Germany,Europe,Tablet,1,300\n"
+
+func newUploadTestServer(t *testing.T) (*processor.Processor, http.Handler) {
+	t.Helper()
+
+	proc, _ := newAdminTestServer(t)
+	cfg := &config.Config{Port: ":8080", AdminAPIKey: adminTestKey, MaxUploadBytes: uploadTestLimit}
+	return proc, NewServer(proc, cfg).setupRoutes()
+}
+
+// multipartBody encodes content as the named file field of a multipart form
+func multipartBody(t *testing.T, field string, content io.Reader) (*bytes.Buffer, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("note", "weekly export"); err != nil {
+		t.Fatalf("Failed to write form field: %v", err)
+	}
+	part, err := writer.CreateFormFile(field, "data.csv")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		t.Fatalf("Failed to write form file: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close multipart writer: %v", err)
+	}
+	return &body, writer.FormDataContentType()
+}
+
+func postUpload(router http.Handler, body io.Reader, contentType string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/upload", body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+adminTestKey)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// assertNoTempFiles fails the test when anything was left in dir
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read temp dir: %v", err)
+	}
+	for _, entry := range entries {
+		t.Errorf("Expected no leftover temp files, found %s", entry.Name())
+	}
+}
+
+func TestUploadMultipart(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+
+	proc, router := newUploadTestServer(t)
+
+	body, contentType := multipartBody(t, "file", strings.NewReader(uploadTestCSV))
+	rr := postUpload(router, body, contentType)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	data := proc.GetDashboardData()
+	if data.DataSource != processor.SourceReader {
+		t.Errorf("Expected data source %s, got %s", processor.SourceReader, data.DataSource)
+	}
+	if data.Report.Rows != 2 {
+		t.Errorf("Expected 2 uploaded rows, got %d", data.Report.Rows)
+	}
+	assertNoTempFiles(t, tempDir)
+}
+
+func TestUploadRawCSV(t *testing.T) {
+	proc, router := newUploadTestServer(t)
+
+	rr := postUpload(router, strings.NewReader(uploadTestCSV), "text/csv")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rows := proc.GetDashboardData().Report.Rows; rows != 2 {
+		t.Errorf("Expected 2 uploaded rows, got %d", rows)
+	}
+}
+
+func TestUploadTooLarge(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+
+	proc, router := newUploadTestServer(t)
+
+	var csv strings.Builder
+	csv.WriteString(uploadTestCSV)
+	for csv.Len() <= 2*uploadTestLimit {
+		csv.WriteString("TXN102,2024-02-03,France,Europe,Laptop,1,900\n")
+	}
+
+	for name, upload := range map[string]func() *httptest.ResponseRecorder{
+		"multipart": func() *httptest.ResponseRecorder {
+			body, contentType := multipartBody(t, "file", strings.NewReader(csv.String()))
+			return postUpload(router, body, contentType)
+		},
+		"raw": func() *httptest.ResponseRecorder {
+			return postUpload(router, strings.NewReader(csv.String()), "text/csv")
+		},
+	} {
+		rr := upload()
+		if rr.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("%s: expected status 413, got %d: %s", name, rr.Code, rr.Body.String())
+		}
+		if contentType := rr.Header().Get("Content-Type"); contentType != "application/json" {
+			t.Errorf("%s: expected JSON error, got Content-Type %s", name, contentType)
+		}
+		if !strings.Contains(rr.Body.String(), "MAX_UPLOAD_BYTES") {
+			t.Errorf("%s: expected error to mention MAX_UPLOAD_BYTES, got %s", name, rr.Body.String())
+		}
+	}
+
+	// The previous data is kept
+	if source := proc.GetDashboardData().DataSource; source != processor.SourceDataset {
+		t.Errorf("Expected data source to stay %s, got %s", processor.SourceDataset, source)
+	}
+	assertNoTempFiles(t, tempDir)
+}
+
+func TestUploadMissingFilePart(t *testing.T) {
+	_, router := newUploadTestServer(t)
+
+	body, contentType := multipartBody(t, "attachment", strings.NewReader(uploadTestCSV))
+	rr := postUpload(router, body, contentType)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rr.Code)
+	}
+}
+
+func TestUploadInvalidHeader(t *testing.T) {
+	proc, router := newUploadTestServer(t)
+
+	rr := postUpload(router, strings.NewReader("transaction_id,product_name\nTXN1,Phone\n"), "text/csv")
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "quantity, total_price") {
+		t.Errorf("Expected the missing columns in the error, got %s", rr.Body.String())
+	}
+	if source := proc.GetDashboardData().DataSource; source != processor.SourceDataset {
+		t.Errorf("Expected data source to stay %s, got %s", processor.SourceDataset, source)
+	}
+}
+
+func TestUploadEmpty(t *testing.T) {
+	proc, router := newUploadTestServer(t)
+
+	rr := postUpload(router, strings.NewReader("transaction_id,product_name,quantity,total_price\n"), "text/csv")
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "no data rows") {
+		t.Errorf("Expected the error to say the upload is empty, got %s", rr.Body.String())
+	}
+	if source := proc.GetDashboardData().DataSource; source != processor.SourceDataset {
+		t.Errorf("Expected data source to stay %s, got %s", processor.SourceDataset, source)
+	}
+}
+
+func TestUploadAborted(t *testing.T) {
+	proc, router := newUploadTestServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest("POST", "/api/upload", strings.NewReader(uploadTestCSV)).WithContext(ctx)
+	req.Header.Set("Content-Type", "text/csv")
+	req.Header.Set("Authorization", "Bearer "+adminTestKey)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "aborted") {
+		t.Errorf("Expected a 400 aborted error, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if source := proc.GetDashboardData().DataSource; source != processor.SourceDataset {
+		t.Errorf("Expected data source to stay %s, got %s", processor.SourceDataset, source)
+	}
+}
+
+func TestUploadWhileProcessing(t *testing.T) {
+	proc, router := newUploadTestServer(t)
+
+	pending, writer := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- proc.ProcessReader(pending) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for !proc.GetProcessingStatus().Running {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for processing to start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	rr := postUpload(router, strings.NewReader(uploadTestCSV), "text/csv")
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected status %d while processing, got %d: %s", http.StatusConflict, rr.Code, rr.Body.String())
+	}
+
+	io.WriteString(writer, uploadTestCSV)
+	writer.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Expected the running load to succeed, got %v", err)
+	}
+	if rr := postUpload(router, strings.NewReader(uploadTestCSV), "text/csv"); rr.Code != http.StatusOK {
+		t.Errorf("Expected the upload to succeed afterwards, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestUploadRequiresKey(t *testing.T) {
+	_, router := newUploadTestServer(t)
+
+	req := httptest.NewRequest("POST", "/api/upload", strings.NewReader(uploadTestCSV))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,183 @@
+// Package apitest boots the complete API server, with its real router,
+// middleware and processor, on an ephemeral port for end-to-end tests, and
+// checks that shutting it down leaves no goroutines behind.
+package apitest
+
+import (
+	"abt-analytics-dashboard/internal/api"
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/processor"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// FixtureCSV is a small dataset covering every dimension the endpoints
+// group by: several countries, regions, categories, users, months and
+// stock levels, including out of stock sales
+const FixtureCSV = `transaction_id,transaction_date,user_id,country,region,product_id,product_name,category,price,quantity,total_price,stock_quantity,added_date
+T001,2024-01-05,U01,USA,North America,P1,Laptop,Electronics,1000.00,1,1000.00,25,2023-06-01
+T002,2024-01-19,U02,USA,North America,P2,Wireless Mouse,Accessories,25.00,2,50.00,150,2023-06-01
+T003,2024-02-03,U03,Germany,Europe,P1,Laptop,Electronics,1000.00,1,1000.00,24,2023-06-01
+T004,2024-02-14,U01,USA,North America,P3,Monitor,Electronics,300.00,1,300.00,0,2023-07-15
+T005,2024-03-02,U04,Japan,Asia Pacific,P4,Headphones,Audio,150.00,2,300.00,8,2023-08-20
+T006,2024-03-21,U03,Germany,Europe,P2,Wireless Mouse,Accessories,25.00,4,100.00,146,2023-06-01
+T007,2024-04-09,U05,Brazil,Latin America,P5,Keyboard,Accessories,75.00,1,75.00,1,2023-09-10
+T008,2024-04-28,U02,USA,North America,P4,Headphones,Audio,150.00,1,150.00,6,2023-08-20
+T009,2024-05-11,U06,Japan,Asia Pacific,P1,Laptop,Electronics,1000.00,2,2000.00,22,2023-06-01
+T010,2024-05-30,U04,Japan,Asia Pacific,P3,Monitor,Electronics,300.00,3,900.00,2,2023-07-15
+`
+
+// leakTimeout is how long Shutdown waits for the server's goroutines to
+// exit before reporting them as leaked
+const leakTimeout = 2 * time.Second
+
+// Server is the API served on a loopback port by the real server. Tests
+// using it must not run in parallel with others, since goroutine leaks are
+// found by counting every goroutine of the test binary.
+type Server struct {
+	// URL is the base URL of the server, without a trailing slash
+	URL string
+	// Client sends requests to the server over its own connections
+	Client *http.Client
+	// Processor is the processor serving the fixture
+	Processor *processor.Processor
+
+	server     *api.Server
+	transport  *http.Transport
+	served     chan error
+	goroutines int
+	stopped    bool
+}
+
+// Start processes csv with a new processor and serves it with cfg, or a
+// default configuration when cfg is nil. The server is shut down, and
+// checked for leaked goroutines, when the test ends.
+func Start(t testing.TB, csv string, cfg *config.Config) *Server {
+	t.Helper()
+
+	goroutines := runtime.NumGoroutine()
+
+	path := filepath.Join(t.TempDir(), "fixture.csv")
+	if err := os.WriteFile(path, []byte(csv), 0644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+	proc := processor.New()
+	if err := proc.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process fixture: %v", err)
+	}
+
+	if cfg == nil {
+		cfg = &config.Config{Port: "127.0.0.1:0"}
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	transport := &http.Transport{}
+	s := &Server{
+		URL:        "http://" + listener.Addr().String(),
+		Client:     &http.Client{Transport: transport, Timeout: 10 * time.Second},
+		Processor:  proc,
+		server:     api.NewServer(proc, cfg),
+		transport:  transport,
+		served:     make(chan error, 1),
+		goroutines: goroutines,
+	}
+	go func() {
+		s.served <- s.server.Serve(listener)
+	}()
+	t.Cleanup(func() { s.Shutdown(t) })
+	return s
+}
+
+// Response is a response read in full
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Do sends a request with an optional body and reads the response
+func (s *Server) Do(t testing.TB, method, path string, body []byte, header http.Header) Response {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	if err != nil {
+		t.Fatalf("%s %s: failed to build request: %v", method, path, err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: request failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s %s: failed to read response: %v", method, path, err)
+	}
+	return Response{Status: resp.StatusCode, Header: resp.Header, Body: data}
+}
+
+// Get sends a GET request and reads the response
+func (s *Server) Get(t testing.TB, path string) Response {
+	t.Helper()
+	return s.Do(t, http.MethodGet, path, nil, nil)
+}
+
+// Shutdown stops the server the way the service does, waits for it to stop
+// serving and fails the test if goroutines started since Start are still
+// running. Calling it again does nothing.
+func (s *Server) Shutdown(t testing.TB) {
+	t.Helper()
+
+	if s.stopped {
+		return
+	}
+	s.stopped = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), leakTimeout)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+	if err := <-s.served; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Expected the server to stop with %v, got %v", http.ErrServerClosed, err)
+	}
+	s.transport.CloseIdleConnections()
+
+	if leaked := waitForGoroutines(s.goroutines, leakTimeout); leaked != "" {
+		t.Errorf("Expected no goroutines left after shutdown, got:\n%s", leaked)
+	}
+}
+
+// waitForGoroutines waits until at most n goroutines are running, returning
+// the stacks of all goroutines if there are still more after timeout
+func waitForGoroutines(n int, timeout time.Duration) string {
+	deadline := time.Now().Add(timeout)
+	for runtime.NumGoroutine() > n {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<20)
+			return strings.TrimSpace(string(buf[:runtime.Stack(buf, true)]))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return ""
+}
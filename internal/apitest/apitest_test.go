@@ -0,0 +1,197 @@
+package apitest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// Envelope kinds checked by the endpoint battery
+const (
+	envelopeList   = "list"   // {"count":n,"data":[...],"meta":{...}}
+	envelopeObject = "object" // {"data":{...},"meta":{...}}
+	envelopeJSON   = "json"   // any JSON document, such as health and schemas
+	envelopeHTML   = "html"
+)
+
+// endpointCase is one request of the battery. Path is the route as listed
+// by the /api index, Request the concrete request sent for it. Status
+// defaults to 200; endpoints with nothing to report for the fixture answer
+// with the error envelope instead.
+type endpointCase struct {
+	path        string
+	method      string
+	request     string
+	body        string
+	envelope    string
+	contentType string
+	status      int
+}
+
+var endpointCases = []endpointCase{
+	{path: "/api/health", request: "/api/health", envelope: envelopeJSON},
+	{path: "/api/revenue-by-country", request: "/api/revenue-by-country", envelope: envelopeList},
+	{path: "/api/revenue-by-country/query", method: http.MethodPost, request: "/api/revenue-by-country/query", body: `{"countries":["USA"]}`, envelope: envelopeList},
+	{path: "/api/top-products", request: "/api/top-products", envelope: envelopeList},
+	{path: "/api/stock-pressure", request: "/api/stock-pressure", envelope: envelopeList},
+	{path: "/api/sales-by-month", request: "/api/sales-by-month", envelope: envelopeList},
+	{path: "/api/sales-by-week", request: "/api/sales-by-week", envelope: envelopeList},
+	{path: "/api/top-regions", request: "/api/top-regions", envelope: envelopeList},
+	{path: "/api/dashboard", request: "/api/dashboard", envelope: envelopeObject},
+	{path: "/api/dashboard/diff", request: "/api/dashboard/diff?since=2000-01-01T00:00:00Z", envelope: envelopeObject},
+	{path: "/api/summary", request: "/api/summary", envelope: envelopeObject},
+	{path: "/api/processing-status", request: "/api/processing-status", envelope: envelopeObject},
+	{path: "/api/processing-report", request: "/api/processing-report", envelope: envelopeObject},
+	{path: "/api/processing-report/last-failed", request: "/api/processing-report/last-failed", envelope: envelopeJSON, status: http.StatusNotFound},
+	{path: "/api/regions/{region}/products", request: "/api/regions/Europe/products", envelope: envelopeList},
+	{path: "/api/categories/{category}/top-products", request: "/api/categories/Electronics/top-products", envelope: envelopeList},
+	{path: "/api/products/search", request: "/api/products/search?q=lap", envelope: envelopeList},
+	{path: "/api/countries", request: "/api/countries", envelope: envelopeList},
+	{path: "/api/countries/{country}", request: "/api/countries/Japan", envelope: envelopeObject},
+	{path: "/api/countries/{country}/sales-by-month", request: "/api/countries/Japan/sales-by-month", envelope: envelopeList},
+	{path: "/api/countries/{country}/top-customers", request: "/api/countries/Japan/top-customers", envelope: envelopeList},
+	{path: "/api/dimensions", request: "/api/dimensions", envelope: envelopeObject},
+	{path: "/api/schema", request: "/api/schema", envelope: envelopeJSON},
+	{path: "/status", request: "/status", envelope: envelopeHTML, contentType: "text/html"},
+}
+
+func TestEndpointEnvelopes(t *testing.T) {
+	server := Start(t, FixtureCSV, nil)
+
+	for _, tc := range endpointCases {
+		method := tc.method
+		if method == "" {
+			method = http.MethodGet
+		}
+		var body []byte
+		if tc.body != "" {
+			body = []byte(tc.body)
+		}
+		header := http.Header{"Content-Type": {"application/json"}}
+		resp := server.Do(t, method, tc.request, body, header)
+
+		status := tc.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if resp.Status != status {
+			t.Errorf("%s %s: expected status %d, got %d: %s", method, tc.request, status, resp.Status, resp.Body)
+			continue
+		}
+		contentType := tc.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, contentType) {
+			t.Errorf("%s %s: expected Content-Type %s, got %q", method, tc.request, contentType, got)
+		}
+		if tc.envelope == envelopeHTML {
+			continue
+		}
+
+		var document map[string]json.RawMessage
+		if err := json.Unmarshal(resp.Body, &document); err != nil {
+			t.Errorf("%s %s: expected a JSON object, got %v: %s", method, tc.request, err, resp.Body)
+			continue
+		}
+		if tc.envelope != envelopeJSON {
+			assertEnvelope(t, method+" "+tc.request, tc.envelope, document)
+		}
+	}
+}
+
+// assertEnvelope checks the data, count and meta members of a response
+// envelope
+func assertEnvelope(t *testing.T, name, envelope string, document map[string]json.RawMessage) {
+	t.Helper()
+
+	var meta map[string]interface{}
+	if err := json.Unmarshal(document["meta"], &meta); err != nil || meta == nil {
+		t.Errorf("%s: expected a meta object, got %s", name, document["meta"])
+	}
+
+	if envelope == envelopeObject {
+		if data := strings.TrimSpace(string(document["data"])); data == "" || data == "null" {
+			t.Errorf("%s: expected data, got %q", name, data)
+		}
+		return
+	}
+
+	var data []json.RawMessage
+	if err := json.Unmarshal(document["data"], &data); err != nil || data == nil {
+		t.Errorf("%s: expected a data array, got %s", name, document["data"])
+		return
+	}
+	var count int
+	if err := json.Unmarshal(document["count"], &count); err != nil || count != len(data) {
+		t.Errorf("%s: expected count %d, got %s", name, len(data), document["count"])
+	}
+	if len(data) == 0 {
+		t.Errorf("%s: expected the fixture to produce rows", name)
+	}
+}
+
+func TestEveryIndexedEndpointCovered(t *testing.T) {
+	server := Start(t, FixtureCSV, nil)
+
+	var index struct {
+		Endpoints map[string]string `json:"endpoints"`
+	}
+	resp := server.Get(t, "/api")
+	if err := json.Unmarshal(resp.Body, &index); err != nil || len(index.Endpoints) == 0 {
+		t.Fatalf("Expected the endpoint index, got %v: %s", err, resp.Body)
+	}
+
+	covered := make(map[string]bool, len(endpointCases))
+	for _, tc := range endpointCases {
+		covered[tc.path] = true
+	}
+	for name, path := range index.Endpoints {
+		if !covered[path] {
+			t.Errorf("Expected endpoint %s (%s) to be covered by the envelope battery", name, path)
+		}
+	}
+}
+
+func TestErrorsUseTheErrorEnvelope(t *testing.T) {
+	server := Start(t, FixtureCSV, nil)
+
+	testCases := []struct {
+		request     string
+		status      int
+		contentType string
+	}{
+		{"/api/countries/Atlantis", http.StatusNotFound, "application/json"},
+		{"/api/regions/Atlantis/products", http.StatusNotFound, "application/json"},
+		{"/api/top-regions?rank_by=height", http.StatusBadRequest, "application/problem+json"},
+		{"/api/products/search", http.StatusBadRequest, "application/problem+json"},
+	}
+	for _, tc := range testCases {
+		resp := server.Get(t, tc.request)
+		if resp.Status != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.request, tc.status, resp.Status)
+		}
+		if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, tc.contentType) {
+			t.Errorf("%s: expected Content-Type %s, got %q", tc.request, tc.contentType, got)
+		}
+		if !json.Valid(resp.Body) {
+			t.Errorf("%s: expected a JSON body, got %s", tc.request, resp.Body)
+		}
+	}
+}
+
+func TestShutdownLeavesNoGoroutines(t *testing.T) {
+	server := Start(t, FixtureCSV, nil)
+
+	// Keep-alive, compressed and streamed responses all hold connections
+	// and buffers that must be released on shutdown
+	server.Get(t, "/api/dashboard")
+	server.Do(t, http.MethodGet, "/api/revenue-by-country?format=ndjson", nil, http.Header{"Accept-Encoding": {"gzip"}})
+	server.Do(t, http.MethodGet, "/api/top-products", nil, http.Header{"Accept": {"application/yaml"}})
+
+	server.Shutdown(t)
+	if _, err := server.Client.Get(server.URL + "/api/health"); err == nil {
+		t.Error("Expected requests to fail after shutdown")
+	}
+}
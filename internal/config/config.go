@@ -1,23 +1,546 @@
 package config
 
 import (
+	"flag"
+	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Supported values for the Environment field. An empty environment is
+// treated as development.
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Supported values for the LogLevel field
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+)
+
+// Supported values for the RecomputeTotals field, matching the processor's
+// RecomputeTotals policies. An empty value is treated as off.
+const (
+	RecomputeTotalsOff    = "off"
+	RecomputeTotalsFill   = "fill"
+	RecomputeTotalsAlways = "always"
+)
+
+// Supported values for the ProductNameNormalization field, matching the
+// processor's product name policies. An empty value is treated as full.
+const (
+	ProductNamesOff  = "off"
+	ProductNamesTrim = "trim"
+	ProductNamesFull = "full"
+)
+
+// Supported values for the CSVEncoding field, matching the processor's
+// encodings. An empty value is treated as utf-8.
+const (
+	CSVEncodingUTF8        = "utf-8"
+	CSVEncodingWindows1252 = "windows-1252"
+	CSVEncodingLatin1      = "latin-1"
+)
+
+// Supported values for the JSONCase field: the casing of JSON object keys
+// in responses. An empty value is treated as snake.
+const (
+	JSONCaseSnake = "snake"
+	JSONCaseCamel = "camel"
+)
+
+// Supported values for the DataMode field: what the server serves. An empty
+// value infers the mode from DATA_FILE_PATH and --sample.
+const (
+	DataModeDataset = "dataset"
+	DataModeSample  = "sample"
+	DataModeEmpty   = "empty"
+)
+
+// defaultMaxReadErrors matches processor.DefaultMaxReadErrors
+const defaultMaxReadErrors = 5
+
+// defaultLowStockThreshold matches processor.DefaultLowStockThreshold
+const defaultLowStockThreshold = 10
+
+// DefaultMaxUploadBytes is the size limit for dataset uploads used when
+// MaxUploadBytes is zero
+const DefaultMaxUploadBytes = 100 << 20
+
+// DefaultMaxRequestBodyBytes is the size limit for POST request bodies other
+// than uploads used when MaxRequestBodyBytes is zero
+const DefaultMaxRequestBodyBytes = 1 << 20
+
+// DefaultShutdownTimeout is how long a graceful shutdown waits for in-flight
+// requests when ShutdownTimeout is zero
+const DefaultShutdownTimeout = 30 * time.Second
+
+// DefaultAdminRateLimit is the number of admin requests per minute allowed
+// from one client unless ADMIN_RATE_LIMIT says otherwise
+const DefaultAdminRateLimit = 30
+
+// DefaultCORSExposedHeaders are the response headers browsers may read
+// unless CORS_EXPOSED_HEADERS says otherwise: those the API sets beyond
+// the CORS-safelisted ones
+var DefaultCORSExposedHeaders = []string{"ETag", "Retry-After", "WWW-Authenticate"}
+
 // Config holds the application configuration
 type Config struct {
-	Port         string
-	DataFilePath string
-	Environment  string
+	Port                     string
+	DataFilePath             string
+	DataMode                 string
+	Environment              string
+	Workers                  int
+	UseSampleData            bool
+	ValidateOnly             bool
+	HealthCheck              bool
+	Export                   bool
+	ExportPath               string
+	ExportPretty             bool
+	ConversionRatesFile      string
+	CountryCodesFile         string
+	RegionAliasesFile        string
+	CountryAliasesFile       string
+	ListenSocket             string
+	SocketMode               os.FileMode
+	TrustProxy               bool
+	TrustedProxies           []string
+	LogLevel                 string
+	CORSAllowedOrigins       []string
+	CORSExposedHeaders       []string
+	MaxReadErrors            int
+	MaxBadRows               int
+	MaxBadRowRatio           float64
+	RetainSampleTransactions int
+	AllowEmptyDataset        bool
+	LogSummary               bool
+	MaxAggregationKeys       int
+	OtherBucketThreshold     float64
+	RecomputeTotals          string
+	ProductNameNormalization string
+	CSVEncoding              string
+	EnableH2C                bool
+	LowStockThreshold        int
+	AdminAPIKey              string
+	DateFormats              []string
+	MaxUploadBytes           int
+	MaxConcurrentConnections int
+	MaxRequestBodyBytes      int
+	StaticDir                string
+	ShutdownTimeout          time.Duration
+	CSVMaxLineBytes          int
+	AdminAllowedIPs          []string
+	AdminRateLimit           int
+	PseudonymizeUsers        bool
+	UserHashSecret           string
+	JSONCase                 string
+	LegacyErrorEnvelope      bool
+	PipelineBatchSize        int
+	EnableLocalization       bool
+	EnableMetrics            bool
+	KeepBlankLocations       bool
+	ExcludeProducts          []string
+	ExcludeCountries         []string
+	DataTimezone             string
+	DisableBrotli            bool
 }
 
 // Load loads configuration from environment variables
 func Load() *Config {
 	return &Config{
-		Port:         ":" + os.Getenv("PORT"),
-		DataFilePath: os.Getenv("DATA_FILE_PATH"),
-		Environment:  os.Getenv("ENVIRONMENT"),
+		Port:                     ":" + os.Getenv("PORT"),
+		DataFilePath:             os.Getenv("DATA_FILE_PATH"),
+		DataMode:                 os.Getenv("DATA_MODE"),
+		Environment:              os.Getenv("ENVIRONMENT"),
+		Workers:                  getEnvInt("WORKERS", 0),
+		ConversionRatesFile:      os.Getenv("CONVERSION_RATES_FILE"),
+		CountryCodesFile:         os.Getenv("COUNTRY_CODES_FILE"),
+		RegionAliasesFile:        os.Getenv("REGION_ALIASES"),
+		CountryAliasesFile:       os.Getenv("COUNTRY_ALIASES"),
+		ListenSocket:             os.Getenv("LISTEN_SOCKET"),
+		SocketMode:               getEnvFileMode("LISTEN_SOCKET_MODE", 0660),
+		TrustProxy:               getEnvBool("TRUST_PROXY", false),
+		TrustedProxies:           getEnvList("TRUSTED_PROXIES", nil),
+		LogLevel:                 os.Getenv("LOG_LEVEL"),
+		CORSAllowedOrigins:       getEnvList("CORS_ALLOWED_ORIGINS", []string{"*"}),
+		CORSExposedHeaders:       getEnvList("CORS_EXPOSED_HEADERS", DefaultCORSExposedHeaders),
+		MaxReadErrors:            getEnvInt("MAX_READ_ERRORS", defaultMaxReadErrors),
+		MaxBadRows:               getEnvInt("MAX_BAD_ROWS", 0),
+		MaxBadRowRatio:           getEnvFloat("MAX_BAD_ROW_RATIO", 0),
+		RetainSampleTransactions: getEnvInt("RETAIN_SAMPLE_TRANSACTIONS", 0),
+		AllowEmptyDataset:        getEnvBool("ALLOW_EMPTY_DATASET", false),
+		LogSummary:               getEnvBool("LOG_SUMMARY", true),
+		MaxAggregationKeys:       getEnvInt("MAX_AGGREGATION_KEYS", 0),
+		OtherBucketThreshold:     getEnvFloat("OTHER_BUCKET_THRESHOLD", 0),
+		RecomputeTotals:          os.Getenv("RECOMPUTE_TOTALS"),
+		ProductNameNormalization: os.Getenv("PRODUCT_NAME_NORMALIZATION"),
+		CSVEncoding:              os.Getenv("CSV_ENCODING"),
+		EnableH2C:                getEnvBool("ENABLE_H2C", false),
+		LowStockThreshold:        getEnvInt("LOW_STOCK_THRESHOLD", defaultLowStockThreshold),
+		AdminAPIKey:              os.Getenv("ADMIN_API_KEY"),
+		DateFormats:              getEnvList("DATE_FORMATS", nil),
+		MaxUploadBytes:           getEnvInt("MAX_UPLOAD_BYTES", DefaultMaxUploadBytes),
+		MaxConcurrentConnections: getEnvInt("MAX_CONCURRENT_CONNECTIONS", 0),
+		MaxRequestBodyBytes:      getEnvInt("MAX_REQUEST_BODY_BYTES", DefaultMaxRequestBodyBytes),
+		StaticDir:                os.Getenv("STATIC_DIR"),
+		ShutdownTimeout:          getEnvDuration("SHUTDOWN_TIMEOUT", DefaultShutdownTimeout),
+		CSVMaxLineBytes:          getEnvInt("CSV_MAX_LINE_BYTES", 0),
+		AdminAllowedIPs:          getEnvList("ADMIN_ALLOWED_IPS", nil),
+		AdminRateLimit:           getEnvInt("ADMIN_RATE_LIMIT", DefaultAdminRateLimit),
+		PseudonymizeUsers:        getEnvBool("PSEUDONYMIZE_USERS", false),
+		UserHashSecret:           os.Getenv("USER_HASH_SECRET"),
+		JSONCase:                 os.Getenv("JSON_CASE"),
+		LegacyErrorEnvelope:      getEnvBool("LEGACY_ERROR_ENVELOPE", false),
+		PipelineBatchSize:        getEnvInt("PIPELINE_BATCH_SIZE", 0),
+		EnableLocalization:       getEnvBool("ENABLE_LOCALIZATION", false),
+		EnableMetrics:            getEnvBool("ENABLE_METRICS", false),
+		KeepBlankLocations:       getEnvBool("KEEP_BLANK_LOCATIONS", false),
+		ExcludeProducts:          getEnvList("EXCLUDE_PRODUCTS", nil),
+		ExcludeCountries:         getEnvList("EXCLUDE_COUNTRIES", nil),
+		DataTimezone:             os.Getenv("DATA_TIMEZONE"),
+		DisableBrotli:            getEnvBool("DISABLE_BROTLI", false),
+	}
+}
+
+// Validate checks that the configuration is consistent with its environment
+func (c *Config) Validate() error {
+	switch c.Environment {
+	case "", EnvDevelopment, EnvProduction:
+	default:
+		return fmt.Errorf("unknown environment %q (expected %q or %q)", c.Environment, EnvDevelopment, EnvProduction)
+	}
+
+	switch c.DataMode {
+	case "":
+	case DataModeDataset:
+		if c.DataFilePath == "" {
+			return fmt.Errorf("DATA_MODE=%s requires DATA_FILE_PATH (or --data)", c.DataMode)
+		}
+		if c.UseSampleData {
+			return fmt.Errorf("--sample cannot be combined with DATA_MODE=%s", c.DataMode)
+		}
+	case DataModeSample, DataModeEmpty:
+		if c.DataFilePath != "" {
+			return fmt.Errorf("DATA_FILE_PATH is set but DATA_MODE=%s does not read it; unset one of them", c.DataMode)
+		}
+		if c.UseSampleData && c.DataMode == DataModeEmpty {
+			return fmt.Errorf("--sample cannot be combined with DATA_MODE=%s", c.DataMode)
+		}
+	default:
+		return fmt.Errorf("unknown DATA_MODE %q (expected %q, %q or %q)", c.DataMode, DataModeDataset, DataModeSample, DataModeEmpty)
+	}
+
+	switch c.LogLevel {
+	case "", LogLevelDebug, LogLevelInfo:
+	default:
+		return fmt.Errorf("unknown log level %q (expected %q or %q)", c.LogLevel, LogLevelDebug, LogLevelInfo)
+	}
+
+	if c.IsProduction() {
+		if len(c.CORSAllowedOrigins) == 0 {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS must be set in production")
+		}
+		for _, origin := range c.CORSAllowedOrigins {
+			if origin == "*" {
+				return fmt.Errorf("wildcard CORS origin is not allowed in production; set CORS_ALLOWED_ORIGINS")
+			}
+		}
+	}
+
+	for _, header := range c.CORSExposedHeaders {
+		if strings.ContainsAny(header, " \t:;\"") {
+			return fmt.Errorf("invalid header name %q in CORS_EXPOSED_HEADERS", header)
+		}
+	}
+
+	switch c.RecomputeTotals {
+	case "", RecomputeTotalsOff, RecomputeTotalsFill, RecomputeTotalsAlways:
+	default:
+		return fmt.Errorf("unknown RECOMPUTE_TOTALS mode %q (expected %q, %q or %q)",
+			c.RecomputeTotals, RecomputeTotalsOff, RecomputeTotalsFill, RecomputeTotalsAlways)
+	}
+
+	switch c.ProductNameNormalization {
+	case "", ProductNamesOff, ProductNamesTrim, ProductNamesFull:
+	default:
+		return fmt.Errorf("unknown PRODUCT_NAME_NORMALIZATION mode %q (expected %q, %q or %q)",
+			c.ProductNameNormalization, ProductNamesOff, ProductNamesTrim, ProductNamesFull)
+	}
+
+	switch c.CSVEncoding {
+	case "", CSVEncodingUTF8, CSVEncodingWindows1252, CSVEncodingLatin1:
+	default:
+		return fmt.Errorf("unknown CSV_ENCODING %q (expected %q, %q or %q)",
+			c.CSVEncoding, CSVEncodingUTF8, CSVEncodingWindows1252, CSVEncodingLatin1)
+	}
+
+	switch c.JSONCase {
+	case "", JSONCaseSnake, JSONCaseCamel:
+	default:
+		return fmt.Errorf("unknown JSON_CASE %q (expected %q or %q)", c.JSONCase, JSONCaseSnake, JSONCaseCamel)
+	}
+
+	if c.DataTimezone != "" {
+		if _, err := time.LoadLocation(c.DataTimezone); err != nil {
+			return fmt.Errorf("unknown DATA_TIMEZONE %q (expected an IANA name such as \"America/Los_Angeles\")", c.DataTimezone)
+		}
+	}
+
+	if c.MaxReadErrors < 0 {
+		return fmt.Errorf("MAX_READ_ERRORS must not be negative, got %d", c.MaxReadErrors)
+	}
+
+	if c.MaxBadRows < 0 {
+		return fmt.Errorf("MAX_BAD_ROWS must not be negative, got %d", c.MaxBadRows)
+	}
+	if c.MaxBadRowRatio < 0 || c.MaxBadRowRatio > 1 {
+		return fmt.Errorf("MAX_BAD_ROW_RATIO must be a ratio from 0 to 1, got %g", c.MaxBadRowRatio)
+	}
+	if c.RetainSampleTransactions < 0 {
+		return fmt.Errorf("RETAIN_SAMPLE_TRANSACTIONS must not be negative, got %d", c.RetainSampleTransactions)
+	}
+
+	if c.LowStockThreshold < 0 {
+		return fmt.Errorf("LOW_STOCK_THRESHOLD must not be negative, got %d", c.LowStockThreshold)
+	}
+
+	if c.MaxAggregationKeys < 0 {
+		return fmt.Errorf("MAX_AGGREGATION_KEYS must not be negative, got %d", c.MaxAggregationKeys)
+	}
+
+	if c.OtherBucketThreshold < 0 || c.OtherBucketThreshold >= 100 {
+		return fmt.Errorf("OTHER_BUCKET_THRESHOLD must be a percentage from 0 up to 100, got %g", c.OtherBucketThreshold)
+	}
+
+	if c.MaxUploadBytes < 0 {
+		return fmt.Errorf("MAX_UPLOAD_BYTES must not be negative, got %d", c.MaxUploadBytes)
+	}
+
+	if c.MaxConcurrentConnections < 0 {
+		return fmt.Errorf("MAX_CONCURRENT_CONNECTIONS must not be negative, got %d", c.MaxConcurrentConnections)
+	}
+
+	if c.MaxRequestBodyBytes < 0 {
+		return fmt.Errorf("MAX_REQUEST_BODY_BYTES must not be negative, got %d", c.MaxRequestBodyBytes)
+	}
+
+	if c.CSVMaxLineBytes < 0 {
+		return fmt.Errorf("CSV_MAX_LINE_BYTES must not be negative, got %d", c.CSVMaxLineBytes)
 	}
+
+	if c.PipelineBatchSize < 0 {
+		return fmt.Errorf("PIPELINE_BATCH_SIZE must not be negative, got %d", c.PipelineBatchSize)
+	}
+
+	if err := validateAddressList("ADMIN_ALLOWED_IPS", c.AdminAllowedIPs); err != nil {
+		return err
+	}
+
+	if err := validateAddressList("TRUSTED_PROXIES", c.TrustedProxies); err != nil {
+		return err
+	}
+
+	if c.AdminRateLimit < 0 {
+		return fmt.Errorf("ADMIN_RATE_LIMIT must not be negative, got %d", c.AdminRateLimit)
+	}
+
+	if c.PseudonymizeUsers && c.UserHashSecret == "" {
+		return fmt.Errorf("USER_HASH_SECRET must be set when PSEUDONYMIZE_USERS is enabled")
+	}
+
+	if c.ShutdownTimeout < 0 {
+		return fmt.Errorf("SHUTDOWN_TIMEOUT must not be negative, got %v", c.ShutdownTimeout)
+	}
+
+	return nil
+}
+
+// validateAddressList checks that every entry of the list read from env is
+// an IP address or a CIDR range
+func validateAddressList(env string, entries []string) error {
+	for _, entry := range entries {
+		if net.ParseIP(entry) == nil {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return fmt.Errorf("%s entry %q is neither an IP address nor a CIDR range", env, entry)
+			}
+		}
+	}
+	return nil
+}
+
+// ListenAddress returns the network and address the server listens on: the
+// Unix socket when LISTEN_SOCKET is set and the TCP port otherwise
+func (c *Config) ListenAddress() (network, address string) {
+	if c.ListenSocket != "" {
+		return "unix", c.ListenSocket
+	}
+	return "tcp", c.Port
+}
+
+// ResolvedDataMode returns the data mode the server runs in: DATA_MODE when
+// set, otherwise sample with --sample, dataset when DATA_FILE_PATH is set
+// and sample in development. It is empty when production has none of them.
+func (c *Config) ResolvedDataMode() string {
+	switch {
+	case c.DataMode != "":
+		return c.DataMode
+	case c.UseSampleData:
+		return DataModeSample
+	case c.DataFilePath != "":
+		return DataModeDataset
+	case c.IsDevelopment():
+		return DataModeSample
+	}
+	return ""
+}
+
+// DataLocation returns the zone of DATA_TIMEZONE, UTC when it is unset or
+// unknown; Validate rejects unknown zones
+func (c *Config) DataLocation() *time.Location {
+	if c.DataTimezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(c.DataTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// IsProduction reports whether the server runs in the production environment
+func (c *Config) IsProduction() bool {
+	return c.Environment == EnvProduction
+}
+
+// IsDevelopment reports whether the server runs in the development
+// environment, which is the default when none is configured
+func (c *Config) IsDevelopment() bool {
+	return c.Environment == "" || c.Environment == EnvDevelopment
+}
+
+// DebugLogging reports whether verbose logging is enabled. An explicit
+// LOG_LEVEL wins; otherwise production logs at info and development at debug.
+func (c *Config) DebugLogging() bool {
+	if c.LogLevel != "" {
+		return c.LogLevel == LogLevelDebug
+	}
+	return !c.IsProduction()
+}
+
+// LoadWithFlags loads configuration from environment variables and then
+// applies command-line flags on top, so flags take precedence over env vars.
+func LoadWithFlags(args []string) (*Config, error) {
+	cfg := Load()
+
+	fs := flag.NewFlagSet("abt-analytics", flag.ContinueOnError)
+	port := fs.String("port", strings.TrimPrefix(cfg.Port, ":"), "port to listen on (env PORT)")
+	fs.StringVar(&cfg.DataFilePath, "data", cfg.DataFilePath, "path to the CSV dataset (env DATA_FILE_PATH)")
+	fs.StringVar(&cfg.Environment, "env", cfg.Environment, "runtime environment: development or production (env ENVIRONMENT)")
+	fs.IntVar(&cfg.Workers, "workers", cfg.Workers, "number of aggregation workers, 0 uses the CPU limit or count (env WORKERS)")
+	fs.BoolVar(&cfg.UseSampleData, "sample", false, "force sample data even when a dataset is configured")
+	fs.BoolVar(&cfg.ValidateOnly, "validate", false, "process the dataset, report the result and exit without serving")
+	fs.BoolVar(&cfg.HealthCheck, "healthcheck", false, "probe /api/health of the running server and exit 0 when healthy, 1 otherwise")
+	fs.BoolVar(&cfg.Export, "export", false, "process the dataset, write the aggregates to -out as JSON and exit without serving")
+	fs.StringVar(&cfg.ExportPath, "out", "", "file the export writes the aggregated dashboard data to")
+	fs.BoolVar(&cfg.ExportPretty, "pretty", false, "indent the exported JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg.Port = ":" + strings.TrimPrefix(*port, ":")
+	return cfg, nil
 }
 
+// getEnvInt reads an integer environment variable, returning fallback when
+// the variable is unset or not a valid integer
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+// getEnvFloat reads a floating-point environment variable, returning
+// fallback when the variable is unset or not a valid number
+func getEnvFloat(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
 
+// getEnvList reads a comma-separated environment variable, returning
+// fallback when the variable is unset
+func getEnvList(key string, fallback []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvBool reads a boolean environment variable, returning fallback when
+// the variable is unset or not a valid boolean
+func getEnvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+// getEnvDuration reads a duration such as "30s" or "1m30s" from an
+// environment variable, returning fallback when the variable is unset or
+// not a valid duration
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+// getEnvFileMode reads an octal file mode (e.g. "0660") from an environment
+// variable, returning fallback when the variable is unset or invalid
+func getEnvFileMode(key string, fallback os.FileMode) os.FileMode {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseUint(value, 8, 32)
+	if err != nil {
+		return fallback
+	}
+	return os.FileMode(parsed) & os.ModePerm
+}
@@ -2,7 +2,10 @@ package config
 
 import (
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -82,3 +85,805 @@ func TestConfigStruct(t *testing.T) {
 	}
 }
 
+func TestLoadWithFlagsDefaults(t *testing.T) {
+	os.Unsetenv("PORT")
+	os.Unsetenv("DATA_FILE_PATH")
+	os.Unsetenv("ENVIRONMENT")
+	os.Unsetenv("WORKERS")
+
+	cfg, err := LoadWithFlags(nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cfg.Port != ":" {
+		t.Errorf("Expected Port to be ':', got '%s'", cfg.Port)
+	}
+	if cfg.Workers != 0 {
+		t.Errorf("Expected Workers to be 0, got %d", cfg.Workers)
+	}
+	if cfg.UseSampleData || cfg.ValidateOnly || cfg.HealthCheck || cfg.Export || cfg.ExportPretty {
+		t.Error("Expected sample, validate, healthcheck, export and pretty flags to default to false")
+	}
+}
+
+func TestLoadWithFlagsExport(t *testing.T) {
+	cfg, err := LoadWithFlags([]string{"-export", "-data", "sales.csv", "-out", "dashboard.json", "-pretty"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !cfg.Export || !cfg.ExportPretty {
+		t.Error("Expected Export and ExportPretty to be true")
+	}
+	if cfg.DataFilePath != "sales.csv" || cfg.ExportPath != "dashboard.json" {
+		t.Errorf("Expected sales.csv exported to dashboard.json, got %s and %s", cfg.DataFilePath, cfg.ExportPath)
+	}
+}
+
+func TestLoadWithFlagsHealthCheck(t *testing.T) {
+	cfg, err := LoadWithFlags([]string{"-healthcheck", "-port", "9000"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !cfg.HealthCheck {
+		t.Error("Expected HealthCheck to be true")
+	}
+	if network, address := cfg.ListenAddress(); network != "tcp" || address != ":9000" {
+		t.Errorf("Expected tcp :9000, got %s %s", network, address)
+	}
+
+	cfg.ListenSocket = "/run/api.sock"
+	if network, address := cfg.ListenAddress(); network != "unix" || address != "/run/api.sock" {
+		t.Errorf("Expected unix /run/api.sock, got %s %s", network, address)
+	}
+}
+
+func TestLoadWithFlagsEnvOverridesDefault(t *testing.T) {
+	os.Setenv("PORT", "8080")
+	os.Setenv("WORKERS", "4")
+	defer func() {
+		os.Unsetenv("PORT")
+		os.Unsetenv("WORKERS")
+	}()
+
+	cfg, err := LoadWithFlags([]string{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cfg.Port != ":8080" {
+		t.Errorf("Expected Port to be ':8080', got '%s'", cfg.Port)
+	}
+	if cfg.Workers != 4 {
+		t.Errorf("Expected Workers to be 4, got %d", cfg.Workers)
+	}
+}
+
+func TestLoadWithFlagsOverridesEnv(t *testing.T) {
+	os.Setenv("PORT", "8080")
+	os.Setenv("DATA_FILE_PATH", "/env/data.csv")
+	os.Setenv("ENVIRONMENT", "development")
+	os.Setenv("WORKERS", "4")
+	defer func() {
+		os.Unsetenv("PORT")
+		os.Unsetenv("DATA_FILE_PATH")
+		os.Unsetenv("ENVIRONMENT")
+		os.Unsetenv("WORKERS")
+	}()
+
+	cfg, err := LoadWithFlags([]string{
+		"--port", "9000",
+		"--data", "./sales.csv",
+		"--env", "production",
+		"--workers", "2",
+		"--sample",
+		"--validate",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cfg.Port != ":9000" {
+		t.Errorf("Expected Port to be ':9000', got '%s'", cfg.Port)
+	}
+	if cfg.DataFilePath != "./sales.csv" {
+		t.Errorf("Expected DataFilePath to be './sales.csv', got '%s'", cfg.DataFilePath)
+	}
+	if cfg.Environment != "production" {
+		t.Errorf("Expected Environment to be 'production', got '%s'", cfg.Environment)
+	}
+	if cfg.Workers != 2 {
+		t.Errorf("Expected Workers to be 2, got %d", cfg.Workers)
+	}
+	if !cfg.UseSampleData {
+		t.Error("Expected UseSampleData to be true")
+	}
+	if !cfg.ValidateOnly {
+		t.Error("Expected ValidateOnly to be true")
+	}
+}
+
+func TestLoadWithFlagsInvalidFlag(t *testing.T) {
+	if _, err := LoadWithFlags([]string{"--unknown"}); err == nil {
+		t.Error("Expected error for unknown flag, got nil")
+	}
+}
+
+func TestLoadWithInvalidWorkersEnv(t *testing.T) {
+	os.Setenv("WORKERS", "many")
+	defer os.Unsetenv("WORKERS")
+
+	cfg := Load()
+
+	if cfg.Workers != 0 {
+		t.Errorf("Expected Workers to fall back to 0, got %d", cfg.Workers)
+	}
+}
+
+func TestLoadTrustProxy(t *testing.T) {
+	os.Setenv("TRUST_PROXY", "true")
+	defer os.Unsetenv("TRUST_PROXY")
+
+	if cfg := Load(); !cfg.TrustProxy {
+		t.Error("Expected TrustProxy to be true")
+	}
+
+	os.Setenv("TRUST_PROXY", "not-a-bool")
+	if cfg := Load(); cfg.TrustProxy {
+		t.Error("Expected TrustProxy to fall back to false")
+	}
+}
+
+func TestValidateEnvironment(t *testing.T) {
+	testCases := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"empty defaults to development", Config{}, false},
+		{"development with wildcard", Config{Environment: EnvDevelopment, CORSAllowedOrigins: []string{"*"}}, false},
+		{"production with explicit origins", Config{Environment: EnvProduction, CORSAllowedOrigins: []string{"https://dash.example.com"}}, false},
+		{"production with wildcard", Config{Environment: EnvProduction, CORSAllowedOrigins: []string{"*"}}, true},
+		{"production without origins", Config{Environment: EnvProduction}, true},
+		{"unknown environment", Config{Environment: "staging"}, true},
+		{"unknown log level", Config{LogLevel: "trace"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.wantErr && err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("Expected no validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEnvironmentDefaults(t *testing.T) {
+	dev := &Config{}
+	if !dev.IsDevelopment() || dev.IsProduction() {
+		t.Error("Expected empty environment to be treated as development")
+	}
+	if !dev.DebugLogging() {
+		t.Error("Expected debug logging in development")
+	}
+
+	prod := &Config{Environment: EnvProduction}
+	if prod.IsDevelopment() || !prod.IsProduction() {
+		t.Error("Expected production environment to be detected")
+	}
+	if prod.DebugLogging() {
+		t.Error("Expected info logging in production")
+	}
+
+	prod.LogLevel = LogLevelDebug
+	if !prod.DebugLogging() {
+		t.Error("Expected explicit LOG_LEVEL to override the environment default")
+	}
+}
+
+func TestLoadCORSAllowedOrigins(t *testing.T) {
+	os.Unsetenv("CORS_ALLOWED_ORIGINS")
+	if cfg := Load(); len(cfg.CORSAllowedOrigins) != 1 || cfg.CORSAllowedOrigins[0] != "*" {
+		t.Errorf("Expected default wildcard origin, got %v", cfg.CORSAllowedOrigins)
+	}
+
+	os.Setenv("CORS_ALLOWED_ORIGINS", "https://a.example.com, https://b.example.com")
+	defer os.Unsetenv("CORS_ALLOWED_ORIGINS")
+
+	cfg := Load()
+	if len(cfg.CORSAllowedOrigins) != 2 || cfg.CORSAllowedOrigins[1] != "https://b.example.com" {
+		t.Errorf("Expected two trimmed origins, got %v", cfg.CORSAllowedOrigins)
+	}
+}
+
+func TestLoadCORSExposedHeaders(t *testing.T) {
+	os.Unsetenv("CORS_EXPOSED_HEADERS")
+	if cfg := Load(); !reflect.DeepEqual(cfg.CORSExposedHeaders, DefaultCORSExposedHeaders) {
+		t.Errorf("Expected the default exposed headers, got %v", cfg.CORSExposedHeaders)
+	}
+
+	os.Setenv("CORS_EXPOSED_HEADERS", "ETag, X-Request-ID,Link")
+	defer os.Unsetenv("CORS_EXPOSED_HEADERS")
+
+	cfg := Load()
+	if !reflect.DeepEqual(cfg.CORSExposedHeaders, []string{"ETag", "X-Request-ID", "Link"}) {
+		t.Errorf("Expected three trimmed headers, got %v", cfg.CORSExposedHeaders)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid headers, got %v", err)
+	}
+
+	cfg.CORSExposedHeaders = []string{"X-Request ID"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for an invalid header name")
+	}
+}
+
+func TestLoadMaxReadErrors(t *testing.T) {
+	if cfg := Load(); cfg.MaxReadErrors != defaultMaxReadErrors {
+		t.Errorf("Expected MaxReadErrors default %d, got %d", defaultMaxReadErrors, cfg.MaxReadErrors)
+	}
+
+	os.Setenv("MAX_READ_ERRORS", "12")
+	defer os.Unsetenv("MAX_READ_ERRORS")
+
+	cfg := Load()
+	if cfg.MaxReadErrors != 12 {
+		t.Errorf("Expected MaxReadErrors 12, got %d", cfg.MaxReadErrors)
+	}
+
+	cfg.MaxReadErrors = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative MaxReadErrors")
+	}
+}
+
+func TestLoadMaxBadRows(t *testing.T) {
+	if cfg := Load(); cfg.MaxBadRows != 0 {
+		t.Errorf("Expected no bad row limit by default, got %d", cfg.MaxBadRows)
+	}
+
+	t.Setenv("MAX_BAD_ROWS", "100")
+	cfg := Load()
+	if cfg.MaxBadRows != 100 {
+		t.Errorf("Expected MaxBadRows 100, got %d", cfg.MaxBadRows)
+	}
+
+	cfg.MaxBadRows = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative MaxBadRows")
+	}
+}
+
+func TestLoadMaxBadRowRatio(t *testing.T) {
+	if cfg := Load(); cfg.MaxBadRowRatio != 0 {
+		t.Errorf("Expected no bad row ratio by default, got %g", cfg.MaxBadRowRatio)
+	}
+
+	t.Setenv("MAX_BAD_ROW_RATIO", "0.25")
+	cfg := Load()
+	if cfg.MaxBadRowRatio != 0.25 {
+		t.Errorf("Expected MaxBadRowRatio 0.25, got %g", cfg.MaxBadRowRatio)
+	}
+
+	for _, ratio := range []float64{-0.1, 1.5} {
+		cfg.MaxBadRowRatio = ratio
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected error for MaxBadRowRatio %g", ratio)
+		}
+	}
+}
+
+func TestLoadRetainSampleTransactions(t *testing.T) {
+	if cfg := Load(); cfg.RetainSampleTransactions != 0 {
+		t.Errorf("Expected sampling to be off by default, got %d", cfg.RetainSampleTransactions)
+	}
+
+	t.Setenv("RETAIN_SAMPLE_TRANSACTIONS", "25")
+	cfg := Load()
+	if cfg.RetainSampleTransactions != 25 {
+		t.Errorf("Expected RetainSampleTransactions 25, got %d", cfg.RetainSampleTransactions)
+	}
+
+	cfg.RetainSampleTransactions = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative RetainSampleTransactions")
+	}
+}
+
+func TestLoadLogSummary(t *testing.T) {
+	if cfg := Load(); !cfg.LogSummary {
+		t.Error("Expected LogSummary to default to true")
+	}
+
+	os.Setenv("LOG_SUMMARY", "false")
+	defer os.Unsetenv("LOG_SUMMARY")
+
+	if cfg := Load(); cfg.LogSummary {
+		t.Error("Expected LogSummary to be false")
+	}
+}
+
+func TestLoadAllowEmptyDataset(t *testing.T) {
+	if cfg := Load(); cfg.AllowEmptyDataset {
+		t.Error("Expected empty datasets to be rejected by default")
+	}
+
+	t.Setenv("ALLOW_EMPTY_DATASET", "true")
+	if cfg := Load(); !cfg.AllowEmptyDataset {
+		t.Error("Expected AllowEmptyDataset to be true")
+	}
+}
+
+func TestLoadMaxAggregationKeys(t *testing.T) {
+	if cfg := Load(); cfg.MaxAggregationKeys != 0 {
+		t.Errorf("Expected MaxAggregationKeys to default to 0, got %d", cfg.MaxAggregationKeys)
+	}
+
+	os.Setenv("MAX_AGGREGATION_KEYS", "1000")
+	defer os.Unsetenv("MAX_AGGREGATION_KEYS")
+
+	cfg := Load()
+	if cfg.MaxAggregationKeys != 1000 {
+		t.Errorf("Expected MaxAggregationKeys 1000, got %d", cfg.MaxAggregationKeys)
+	}
+
+	cfg.MaxAggregationKeys = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative MaxAggregationKeys")
+	}
+}
+
+func TestLoadOtherBucketThreshold(t *testing.T) {
+	if cfg := Load(); cfg.OtherBucketThreshold != 0 {
+		t.Errorf("Expected OtherBucketThreshold to default to 0, got %g", cfg.OtherBucketThreshold)
+	}
+
+	t.Setenv("OTHER_BUCKET_THRESHOLD", "0.01")
+	cfg := Load()
+	if cfg.OtherBucketThreshold != 0.01 {
+		t.Errorf("Expected OtherBucketThreshold 0.01, got %g", cfg.OtherBucketThreshold)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	for _, threshold := range []float64{-1, 100} {
+		cfg.OtherBucketThreshold = threshold
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected error for OtherBucketThreshold %g", threshold)
+		}
+	}
+}
+
+func TestValidateJSONCase(t *testing.T) {
+	for _, value := range []string{"", JSONCaseSnake, JSONCaseCamel} {
+		cfg := &Config{JSONCase: value}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected JSON_CASE %q to be valid, got %v", value, err)
+		}
+	}
+
+	cfg := &Config{JSONCase: "kebab"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for unknown JSON_CASE")
+	}
+}
+
+func TestValidateRecomputeTotals(t *testing.T) {
+	for _, mode := range []string{"", RecomputeTotalsOff, RecomputeTotalsFill, RecomputeTotalsAlways} {
+		cfg := &Config{RecomputeTotals: mode}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected mode %q to be valid, got %v", mode, err)
+		}
+	}
+
+	cfg := &Config{RecomputeTotals: "sometimes"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for unknown RECOMPUTE_TOTALS mode")
+	}
+}
+
+func TestLoadEnableH2C(t *testing.T) {
+	if cfg := Load(); cfg.EnableH2C {
+		t.Error("Expected EnableH2C to default to false")
+	}
+
+	os.Setenv("ENABLE_H2C", "true")
+	defer os.Unsetenv("ENABLE_H2C")
+
+	if cfg := Load(); !cfg.EnableH2C {
+		t.Error("Expected EnableH2C to be true")
+	}
+}
+
+func TestLoadLowStockThreshold(t *testing.T) {
+	if cfg := Load(); cfg.LowStockThreshold != defaultLowStockThreshold {
+		t.Errorf("Expected LowStockThreshold default %d, got %d", defaultLowStockThreshold, cfg.LowStockThreshold)
+	}
+
+	os.Setenv("LOW_STOCK_THRESHOLD", "25")
+	defer os.Unsetenv("LOW_STOCK_THRESHOLD")
+
+	cfg := Load()
+	if cfg.LowStockThreshold != 25 {
+		t.Errorf("Expected LowStockThreshold 25, got %d", cfg.LowStockThreshold)
+	}
+
+	cfg.LowStockThreshold = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative LowStockThreshold")
+	}
+}
+
+func TestLoadDateFormats(t *testing.T) {
+	if cfg := Load(); len(cfg.DateFormats) != 0 {
+		t.Errorf("Expected no DateFormats by default, got %v", cfg.DateFormats)
+	}
+
+	os.Setenv("DATE_FORMATS", "02.01.2006, Jan 2 2006")
+	defer os.Unsetenv("DATE_FORMATS")
+
+	cfg := Load()
+	if len(cfg.DateFormats) != 2 || cfg.DateFormats[0] != "02.01.2006" || cfg.DateFormats[1] != "Jan 2 2006" {
+		t.Errorf("Expected DateFormats [02.01.2006 Jan 2 2006], got %v", cfg.DateFormats)
+	}
+}
+
+func TestLoadMaxUploadBytes(t *testing.T) {
+	if cfg := Load(); cfg.MaxUploadBytes != DefaultMaxUploadBytes {
+		t.Errorf("Expected MaxUploadBytes default %d, got %d", DefaultMaxUploadBytes, cfg.MaxUploadBytes)
+	}
+
+	os.Setenv("MAX_UPLOAD_BYTES", "1048576")
+	defer os.Unsetenv("MAX_UPLOAD_BYTES")
+
+	cfg := Load()
+	if cfg.MaxUploadBytes != 1048576 {
+		t.Errorf("Expected MaxUploadBytes 1048576, got %d", cfg.MaxUploadBytes)
+	}
+
+	cfg.MaxUploadBytes = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative MaxUploadBytes")
+	}
+}
+
+func TestLoadConnectionAndBodyLimits(t *testing.T) {
+	cfg := Load()
+	if cfg.MaxConcurrentConnections != 0 || cfg.MaxRequestBodyBytes != DefaultMaxRequestBodyBytes {
+		t.Errorf("Expected unlimited connections and default body limit, got %d and %d",
+			cfg.MaxConcurrentConnections, cfg.MaxRequestBodyBytes)
+	}
+
+	os.Setenv("MAX_CONCURRENT_CONNECTIONS", "500")
+	os.Setenv("MAX_REQUEST_BODY_BYTES", "4096")
+	defer os.Unsetenv("MAX_CONCURRENT_CONNECTIONS")
+	defer os.Unsetenv("MAX_REQUEST_BODY_BYTES")
+
+	cfg = Load()
+	if cfg.MaxConcurrentConnections != 500 || cfg.MaxRequestBodyBytes != 4096 {
+		t.Errorf("Expected limits 500 and 4096, got %d and %d", cfg.MaxConcurrentConnections, cfg.MaxRequestBodyBytes)
+	}
+
+	cfg.MaxConcurrentConnections = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative MaxConcurrentConnections")
+	}
+	cfg.MaxConcurrentConnections = 0
+	cfg.MaxRequestBodyBytes = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative MaxRequestBodyBytes")
+	}
+}
+
+func TestLoadStaticDir(t *testing.T) {
+	if cfg := Load(); cfg.StaticDir != "" {
+		t.Errorf("Expected no static directory by default, got %q", cfg.StaticDir)
+	}
+
+	os.Setenv("STATIC_DIR", "./web/dist")
+	defer os.Unsetenv("STATIC_DIR")
+
+	if cfg := Load(); cfg.StaticDir != "./web/dist" {
+		t.Errorf("Expected static directory ./web/dist, got %q", cfg.StaticDir)
+	}
+}
+
+func TestLoadShutdownTimeout(t *testing.T) {
+	if cfg := Load(); cfg.ShutdownTimeout != DefaultShutdownTimeout {
+		t.Errorf("Expected default shutdown timeout %v, got %v", DefaultShutdownTimeout, cfg.ShutdownTimeout)
+	}
+
+	os.Setenv("SHUTDOWN_TIMEOUT", "1m30s")
+	defer os.Unsetenv("SHUTDOWN_TIMEOUT")
+	if cfg := Load(); cfg.ShutdownTimeout != 90*time.Second {
+		t.Errorf("Expected shutdown timeout 1m30s, got %v", cfg.ShutdownTimeout)
+	}
+
+	os.Setenv("SHUTDOWN_TIMEOUT", "soon")
+	cfg := Load()
+	if cfg.ShutdownTimeout != DefaultShutdownTimeout {
+		t.Errorf("Expected invalid SHUTDOWN_TIMEOUT to fall back to %v, got %v", DefaultShutdownTimeout, cfg.ShutdownTimeout)
+	}
+
+	cfg.ShutdownTimeout = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative ShutdownTimeout")
+	}
+}
+
+func TestLoadCSVMaxLineBytes(t *testing.T) {
+	if cfg := Load(); cfg.CSVMaxLineBytes != 0 {
+		t.Errorf("Expected no line limit override by default, got %d", cfg.CSVMaxLineBytes)
+	}
+
+	t.Setenv("CSV_MAX_LINE_BYTES", "8388608")
+	cfg := Load()
+	if cfg.CSVMaxLineBytes != 8<<20 {
+		t.Errorf("Expected a line limit of 8388608, got %d", cfg.CSVMaxLineBytes)
+	}
+
+	cfg.CSVMaxLineBytes = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative CSVMaxLineBytes")
+	}
+}
+
+func TestLoadPipelineBatchSize(t *testing.T) {
+	if cfg := Load(); cfg.PipelineBatchSize != 0 {
+		t.Errorf("Expected the default batch size, got %d", cfg.PipelineBatchSize)
+	}
+
+	t.Setenv("PIPELINE_BATCH_SIZE", "2000")
+	cfg := Load()
+	if cfg.PipelineBatchSize != 2000 {
+		t.Errorf("Expected a batch size of 2000, got %d", cfg.PipelineBatchSize)
+	}
+
+	cfg.PipelineBatchSize = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative PipelineBatchSize")
+	}
+}
+
+func TestLoadAdminAccessSettings(t *testing.T) {
+	cfg := Load()
+	if len(cfg.AdminAllowedIPs) != 0 || cfg.AdminRateLimit != DefaultAdminRateLimit {
+		t.Errorf("Expected no allowlist and the default rate limit, got %v and %d", cfg.AdminAllowedIPs, cfg.AdminRateLimit)
+	}
+
+	t.Setenv("ADMIN_ALLOWED_IPS", "10.0.0.0/8, 192.0.2.7")
+	t.Setenv("ADMIN_RATE_LIMIT", "5")
+	cfg = Load()
+	if len(cfg.AdminAllowedIPs) != 2 || cfg.AdminAllowedIPs[1] != "192.0.2.7" || cfg.AdminRateLimit != 5 {
+		t.Errorf("Expected two allowlist entries and a limit of 5, got %v and %d", cfg.AdminAllowedIPs, cfg.AdminRateLimit)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	cfg.AdminAllowedIPs = []string{"10.0.0.0/33"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for an invalid allowlist entry")
+	}
+	cfg.AdminAllowedIPs = nil
+	cfg.TrustedProxies = []string{"proxy.internal"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for an invalid trusted proxy entry")
+	}
+	cfg.TrustedProxies = nil
+	cfg.AdminRateLimit = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for negative AdminRateLimit")
+	}
+}
+
+func TestLoadUserPseudonymization(t *testing.T) {
+	cfg := Load()
+	if cfg.PseudonymizeUsers || cfg.UserHashSecret != "" {
+		t.Errorf("Expected pseudonymization off by default, got %v with secret %q", cfg.PseudonymizeUsers, cfg.UserHashSecret)
+	}
+
+	t.Setenv("PSEUDONYMIZE_USERS", "true")
+	cfg = Load()
+	if !cfg.PseudonymizeUsers {
+		t.Error("Expected pseudonymization to be enabled")
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for pseudonymization without USER_HASH_SECRET")
+	}
+
+	t.Setenv("USER_HASH_SECRET", "s3cret")
+	cfg = Load()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if cfg.Redacted()["USER_HASH_SECRET"] != redacted {
+		t.Errorf("Expected USER_HASH_SECRET to be redacted, got %v", cfg.Redacted()["USER_HASH_SECRET"])
+	}
+}
+
+func TestLoadLegacyErrorEnvelope(t *testing.T) {
+	if Load().LegacyErrorEnvelope {
+		t.Error("Expected problem details by default")
+	}
+
+	t.Setenv("LEGACY_ERROR_ENVELOPE", "true")
+	if !Load().LegacyErrorEnvelope {
+		t.Error("Expected the legacy error envelope to be enabled")
+	}
+}
+
+func TestLoadEnableLocalization(t *testing.T) {
+	if Load().EnableLocalization {
+		t.Error("Expected localization to be disabled by default")
+	}
+
+	t.Setenv("ENABLE_LOCALIZATION", "true")
+	if !Load().EnableLocalization {
+		t.Error("Expected localization to be enabled")
+	}
+}
+
+func TestLoadEnableMetrics(t *testing.T) {
+	if Load().EnableMetrics {
+		t.Error("Expected metrics to be disabled by default")
+	}
+
+	t.Setenv("ENABLE_METRICS", "true")
+	if !Load().EnableMetrics {
+		t.Error("Expected metrics to be enabled")
+	}
+}
+
+func TestLoadKeepBlankLocations(t *testing.T) {
+	if Load().KeepBlankLocations {
+		t.Error("Expected blank locations to be left out by default")
+	}
+
+	t.Setenv("KEEP_BLANK_LOCATIONS", "true")
+	if !Load().KeepBlankLocations {
+		t.Error("Expected blank locations to be kept")
+	}
+}
+
+func TestLoadExclusions(t *testing.T) {
+	if cfg := Load(); len(cfg.ExcludeProducts) != 0 || len(cfg.ExcludeCountries) != 0 {
+		t.Errorf("Expected no exclusions by default, got %v and %v", cfg.ExcludeProducts, cfg.ExcludeCountries)
+	}
+
+	t.Setenv("EXCLUDE_PRODUCTS", "Recalled Kettle, SKU-42")
+	t.Setenv("EXCLUDE_COUNTRIES", "Atlantis,XX")
+	cfg := Load()
+	if len(cfg.ExcludeProducts) != 2 || cfg.ExcludeProducts[0] != "Recalled Kettle" || cfg.ExcludeProducts[1] != "SKU-42" {
+		t.Errorf("Expected the excluded products, got %v", cfg.ExcludeProducts)
+	}
+	if len(cfg.ExcludeCountries) != 2 || cfg.ExcludeCountries[1] != "XX" {
+		t.Errorf("Expected the excluded countries, got %v", cfg.ExcludeCountries)
+	}
+}
+
+func TestLoadDataMode(t *testing.T) {
+	t.Setenv("DATA_MODE", DataModeEmpty)
+	if cfg := Load(); cfg.DataMode != DataModeEmpty {
+		t.Errorf("Expected DataMode %q, got %q", DataModeEmpty, cfg.DataMode)
+	}
+}
+
+func TestValidateDataMode(t *testing.T) {
+	testCases := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"dataset with a path", Config{DataMode: DataModeDataset, DataFilePath: "sales.csv"}, false},
+		{"dataset without a path", Config{DataMode: DataModeDataset}, true},
+		{"dataset with --sample", Config{DataMode: DataModeDataset, DataFilePath: "sales.csv", UseSampleData: true}, true},
+		{"sample", Config{DataMode: DataModeSample}, false},
+		{"sample with --sample", Config{DataMode: DataModeSample, UseSampleData: true}, false},
+		{"sample with a path", Config{DataMode: DataModeSample, DataFilePath: "sales.csv"}, true},
+		{"empty", Config{DataMode: DataModeEmpty}, false},
+		{"empty with a path", Config{DataMode: DataModeEmpty, DataFilePath: "sales.csv"}, true},
+		{"empty with --sample", Config{DataMode: DataModeEmpty, UseSampleData: true}, true},
+		{"unknown mode", Config{DataMode: "demo"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.wantErr && err == nil {
+				t.Error("Expected validation error, got nil")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("Expected no validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestResolvedDataMode(t *testing.T) {
+	testCases := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"explicit mode", Config{DataMode: DataModeEmpty, Environment: EnvProduction}, DataModeEmpty},
+		{"dataset path", Config{DataFilePath: "sales.csv"}, DataModeDataset},
+		{"sample flag overrides the path", Config{DataFilePath: "sales.csv", UseSampleData: true}, DataModeSample},
+		{"nothing in development", Config{}, DataModeSample},
+		{"nothing in production", Config{Environment: EnvProduction}, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.cfg.ResolvedDataMode(); got != tc.want {
+				t.Errorf("Expected data mode %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateProductNameNormalization(t *testing.T) {
+	for _, mode := range []string{"", ProductNamesOff, ProductNamesTrim, ProductNamesFull} {
+		cfg := &Config{ProductNameNormalization: mode}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected mode %q to be valid, got %v", mode, err)
+		}
+	}
+
+	cfg := &Config{ProductNameNormalization: "lower"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for unknown PRODUCT_NAME_NORMALIZATION mode")
+	}
+}
+
+func TestValidateCSVEncoding(t *testing.T) {
+	for _, encoding := range []string{"", CSVEncodingUTF8, CSVEncodingWindows1252, CSVEncodingLatin1} {
+		cfg := &Config{CSVEncoding: encoding}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Expected encoding %q to be valid, got %v", encoding, err)
+		}
+	}
+
+	cfg := &Config{CSVEncoding: "utf-16"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected error for unknown CSV_ENCODING")
+	}
+}
+
+func TestLoadDataTimezone(t *testing.T) {
+	cfg := Load()
+	if cfg.DataTimezone != "" || cfg.DataLocation() != time.UTC {
+		t.Errorf("Expected UTC by default, got %q", cfg.DataTimezone)
+	}
+
+	t.Setenv("DATA_TIMEZONE", "America/Los_Angeles")
+	cfg = Load()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected America/Los_Angeles to be valid, got %v", err)
+	}
+	if loc := cfg.DataLocation(); loc.String() != "America/Los_Angeles" {
+		t.Errorf("Expected the America/Los_Angeles zone, got %s", loc)
+	}
+
+	cfg.DataTimezone = "Mars/Olympus_Mons"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "DATA_TIMEZONE") {
+		t.Errorf("Expected an error naming DATA_TIMEZONE, got %v", err)
+	}
+}
+
+func TestLoadDisableBrotli(t *testing.T) {
+	if Load().DisableBrotli {
+		t.Error("Expected Brotli to be enabled by default")
+	}
+
+	t.Setenv("DISABLE_BROTLI", "true")
+	if !Load().DisableBrotli {
+		t.Error("Expected Brotli to be disabled")
+	}
+}
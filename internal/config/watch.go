@@ -0,0 +1,252 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// redacted replaces secret values in Redacted
+const redacted = "[REDACTED]"
+
+// setting ties a Config field to the environment variable it is loaded
+// from. Reloadable settings are safe to change while the server runs; the
+// others are read once at startup and need a restart.
+type setting struct {
+	field      string
+	env        string
+	reloadable bool
+	secret     bool
+}
+
+// settings lists every Config field loaded from the environment
+var settings = []setting{
+	{field: "Port", env: "PORT"},
+	{field: "DataFilePath", env: "DATA_FILE_PATH"},
+	{field: "DataMode", env: "DATA_MODE"},
+	{field: "Environment", env: "ENVIRONMENT"},
+	{field: "Workers", env: "WORKERS"},
+	{field: "ConversionRatesFile", env: "CONVERSION_RATES_FILE"},
+	{field: "CountryCodesFile", env: "COUNTRY_CODES_FILE"},
+	{field: "RegionAliasesFile", env: "REGION_ALIASES"},
+	{field: "CountryAliasesFile", env: "COUNTRY_ALIASES"},
+	{field: "ListenSocket", env: "LISTEN_SOCKET"},
+	{field: "SocketMode", env: "LISTEN_SOCKET_MODE"},
+	{field: "TrustProxy", env: "TRUST_PROXY", reloadable: true},
+	{field: "TrustedProxies", env: "TRUSTED_PROXIES", reloadable: true},
+	{field: "LogLevel", env: "LOG_LEVEL", reloadable: true},
+	{field: "CORSAllowedOrigins", env: "CORS_ALLOWED_ORIGINS", reloadable: true},
+	{field: "CORSExposedHeaders", env: "CORS_EXPOSED_HEADERS", reloadable: true},
+	{field: "MaxReadErrors", env: "MAX_READ_ERRORS"},
+	{field: "MaxBadRows", env: "MAX_BAD_ROWS"},
+	{field: "MaxBadRowRatio", env: "MAX_BAD_ROW_RATIO"},
+	{field: "RetainSampleTransactions", env: "RETAIN_SAMPLE_TRANSACTIONS"},
+	{field: "AllowEmptyDataset", env: "ALLOW_EMPTY_DATASET"},
+	{field: "LogSummary", env: "LOG_SUMMARY"},
+	{field: "MaxAggregationKeys", env: "MAX_AGGREGATION_KEYS"},
+	{field: "OtherBucketThreshold", env: "OTHER_BUCKET_THRESHOLD"},
+	{field: "RecomputeTotals", env: "RECOMPUTE_TOTALS"},
+	{field: "ProductNameNormalization", env: "PRODUCT_NAME_NORMALIZATION"},
+	{field: "CSVEncoding", env: "CSV_ENCODING"},
+	{field: "EnableH2C", env: "ENABLE_H2C"},
+	{field: "LowStockThreshold", env: "LOW_STOCK_THRESHOLD", reloadable: true},
+	{field: "AdminAPIKey", env: "ADMIN_API_KEY", reloadable: true, secret: true},
+	{field: "DateFormats", env: "DATE_FORMATS"},
+	{field: "MaxUploadBytes", env: "MAX_UPLOAD_BYTES", reloadable: true},
+	{field: "MaxConcurrentConnections", env: "MAX_CONCURRENT_CONNECTIONS"},
+	{field: "MaxRequestBodyBytes", env: "MAX_REQUEST_BODY_BYTES", reloadable: true},
+	{field: "StaticDir", env: "STATIC_DIR"},
+	{field: "ShutdownTimeout", env: "SHUTDOWN_TIMEOUT"},
+	{field: "CSVMaxLineBytes", env: "CSV_MAX_LINE_BYTES"},
+	{field: "AdminAllowedIPs", env: "ADMIN_ALLOWED_IPS", reloadable: true},
+	{field: "AdminRateLimit", env: "ADMIN_RATE_LIMIT", reloadable: true},
+	{field: "PseudonymizeUsers", env: "PSEUDONYMIZE_USERS", reloadable: true},
+	{field: "UserHashSecret", env: "USER_HASH_SECRET", reloadable: true, secret: true},
+	{field: "JSONCase", env: "JSON_CASE", reloadable: true},
+	{field: "LegacyErrorEnvelope", env: "LEGACY_ERROR_ENVELOPE", reloadable: true},
+	{field: "PipelineBatchSize", env: "PIPELINE_BATCH_SIZE"},
+	{field: "EnableLocalization", env: "ENABLE_LOCALIZATION", reloadable: true},
+	{field: "EnableMetrics", env: "ENABLE_METRICS"},
+	{field: "KeepBlankLocations", env: "KEEP_BLANK_LOCATIONS"},
+	{field: "ExcludeProducts", env: "EXCLUDE_PRODUCTS", reloadable: true},
+	{field: "ExcludeCountries", env: "EXCLUDE_COUNTRIES", reloadable: true},
+	{field: "DataTimezone", env: "DATA_TIMEZONE"},
+	{field: "DisableBrotli", env: "DISABLE_BROTLI", reloadable: true},
+}
+
+// ReloadableSettings returns the environment variables that Reload applies
+// without a restart
+func ReloadableSettings() []string {
+	names := make([]string, 0)
+	for _, s := range settings {
+		if s.reloadable {
+			names = append(names, s.env)
+		}
+	}
+	return names
+}
+
+// ReloadResult lists the environment variables whose values changed in a
+// reload: Changed were applied, Ignored need a restart to take effect
+type ReloadResult struct {
+	Changed []string
+	Ignored []string
+}
+
+// Watcher holds the effective configuration. Reload re-reads the env file
+// and the environment and atomically swaps in the reloadable settings, so
+// readers see either the old or the new configuration, never a mix.
+type Watcher struct {
+	current atomic.Pointer[Config]
+
+	// mu serializes reloads and guards the fields below
+	mu sync.Mutex
+
+	envFile string
+
+	// baseEnv holds the variables set before envFile was first loaded;
+	// they keep precedence over the file on reload, as they do at startup
+	baseEnv map[string]bool
+
+	// fileKeys are the variables last set from envFile, unset again when
+	// they are removed from the file
+	fileKeys map[string]bool
+
+	// loaded is the configuration the environment produced at startup,
+	// before command-line flags, with applied reloads merged in. Settings
+	// are compared against it, so a restart-only setting is reported when
+	// its environment value changed, on every reload until the restart.
+	loaded *Config
+}
+
+// Watch returns a Watcher serving cfg. envFile is the env file re-read on
+// Reload (none when empty) and baseEnv is os.Environ() as captured before
+// that file was loaded at startup.
+func Watch(cfg *Config, envFile string, baseEnv []string) *Watcher {
+	w := &Watcher{
+		envFile:  envFile,
+		baseEnv:  make(map[string]bool, len(baseEnv)),
+		fileKeys: make(map[string]bool),
+		loaded:   Load(),
+	}
+	for _, entry := range baseEnv {
+		key, _, _ := strings.Cut(entry, "=")
+		w.baseEnv[key] = true
+	}
+	if envFile != "" {
+		if values, err := godotenv.Read(envFile); err == nil {
+			for key := range values {
+				if !w.baseEnv[key] {
+					w.fileKeys[key] = true
+				}
+			}
+		}
+	}
+	w.current.Store(cfg)
+	return w
+}
+
+// Current returns the effective configuration. The returned Config must
+// not be modified.
+func (w *Watcher) Current() *Config {
+	return w.current.Load()
+}
+
+// Reload re-reads the env file and the environment. Changed reloadable
+// settings replace the current ones; changed restart-only settings are
+// reported as ignored and keep their current values. When the result does
+// not validate, nothing is applied and the error is returned.
+func (w *Watcher) Reload() (ReloadResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.applyEnvFile(); err != nil {
+		return ReloadResult{}, err
+	}
+
+	fresh := Load()
+	next, loaded := *w.Current(), *w.loaded
+	result := ReloadResult{Changed: make([]string, 0), Ignored: make([]string, 0)}
+	for _, s := range settings {
+		value := reflect.ValueOf(fresh).Elem().FieldByName(s.field)
+		if reflect.DeepEqual(value.Interface(), reflect.ValueOf(w.loaded).Elem().FieldByName(s.field).Interface()) {
+			continue
+		}
+		if !s.reloadable {
+			result.Ignored = append(result.Ignored, s.env)
+			continue
+		}
+		reflect.ValueOf(&next).Elem().FieldByName(s.field).Set(value)
+		reflect.ValueOf(&loaded).Elem().FieldByName(s.field).Set(value)
+		result.Changed = append(result.Changed, s.env)
+	}
+
+	if err := next.Validate(); err != nil {
+		return ReloadResult{}, fmt.Errorf("reloaded configuration is invalid: %w", err)
+	}
+
+	w.loaded = &loaded
+	w.current.Store(&next)
+	return result, nil
+}
+
+// applyEnvFile copies the env file into the environment, leaving variables
+// from baseEnv alone. A missing file clears the variables it used to set.
+func (w *Watcher) applyEnvFile() error {
+	if w.envFile == "" {
+		return nil
+	}
+
+	values, err := godotenv.Read(w.envFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", w.envFile, err)
+	}
+
+	for key := range w.fileKeys {
+		if _, ok := values[key]; !ok {
+			os.Unsetenv(key)
+			delete(w.fileKeys, key)
+		}
+	}
+	for key, value := range values {
+		if w.baseEnv[key] {
+			continue
+		}
+		os.Setenv(key, value)
+		w.fileKeys[key] = true
+	}
+	return nil
+}
+
+// Redacted returns the settings loaded from the environment keyed by
+// variable name, with secrets replaced so the result can be shown to
+// operators
+func (c *Config) Redacted() map[string]interface{} {
+	values := make(map[string]interface{}, len(settings))
+	for _, s := range settings {
+		value := reflect.ValueOf(c).Elem().FieldByName(s.field).Interface()
+		switch v := value.(type) {
+		case os.FileMode:
+			value = fmt.Sprintf("%04o", uint32(v))
+		case time.Duration:
+			value = v.String()
+		case []string:
+			if v == nil {
+				value = []string{}
+			}
+		}
+		if s.secret && value != "" {
+			value = redacted
+		}
+		values[s.env] = value
+	}
+	return values
+}
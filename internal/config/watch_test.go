@@ -0,0 +1,122 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeEnvFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+}
+
+func TestWatcherReloadsEnvFile(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	writeEnvFile(t, envFile, "LOW_STOCK_THRESHOLD=5\nLOG_LEVEL=info\nTRUST_PROXY=true\n")
+
+	// TRUST_PROXY is set in the real environment, so the file never wins
+	for _, key := range []string{"LOW_STOCK_THRESHOLD", "LOG_LEVEL", "DATA_FILE_PATH"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	t.Setenv("TRUST_PROXY", "false")
+	baseEnv := os.Environ()
+
+	// What godotenv.Load does at startup
+	os.Setenv("LOW_STOCK_THRESHOLD", "5")
+	os.Setenv("LOG_LEVEL", "info")
+
+	watcher := Watch(Load(), envFile, baseEnv)
+	if cfg := watcher.Current(); cfg.LowStockThreshold != 5 || cfg.TrustProxy {
+		t.Fatalf("Expected threshold 5 without proxy trust, got %d and %v", cfg.LowStockThreshold, cfg.TrustProxy)
+	}
+
+	writeEnvFile(t, envFile, "LOW_STOCK_THRESHOLD=25\nTRUST_PROXY=true\nDATA_FILE_PATH=other.csv\n")
+	result, err := watcher.Reload()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Join(result.Changed, ",") != "LOG_LEVEL,LOW_STOCK_THRESHOLD" {
+		t.Errorf("Expected LOG_LEVEL and LOW_STOCK_THRESHOLD to change, got %v", result.Changed)
+	}
+	if strings.Join(result.Ignored, ",") != "DATA_FILE_PATH" {
+		t.Errorf("Expected DATA_FILE_PATH to be ignored, got %v", result.Ignored)
+	}
+
+	cfg := watcher.Current()
+	if cfg.LowStockThreshold != 25 || cfg.LogLevel != "" || cfg.TrustProxy || cfg.DataFilePath != "" {
+		t.Errorf("Expected threshold 25, no log level, no proxy trust and the old data path, got %+v", cfg)
+	}
+
+	// A restart-only change keeps being reported until the restart
+	result, err = watcher.Reload()
+	if err != nil || len(result.Changed) != 0 || strings.Join(result.Ignored, ",") != "DATA_FILE_PATH" {
+		t.Errorf("Expected only DATA_FILE_PATH to be reported again, got %+v (%v)", result, err)
+	}
+}
+
+func TestWatcherKeepsFlagsAndLiteralSettings(t *testing.T) {
+	cfg, err := LoadWithFlags([]string{"--port", "9000", "--workers", "3"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	watcher := Watch(cfg, "", nil)
+
+	t.Setenv("MAX_UPLOAD_BYTES", "2048")
+	result, err := watcher.Reload()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Ignored) != 0 {
+		t.Errorf("Expected flag values not to be reported as changed, got %v", result.Ignored)
+	}
+	if current := watcher.Current(); current.Port != ":9000" || current.Workers != 3 || current.MaxUploadBytes != 2048 {
+		t.Errorf("Expected port :9000, 3 workers and a 2048 byte upload limit, got %s, %d and %d",
+			current.Port, current.Workers, current.MaxUploadBytes)
+	}
+	if cfg.MaxUploadBytes == 2048 {
+		t.Error("Expected the reload to leave the original Config untouched")
+	}
+}
+
+func TestWatcherRejectsInvalidReload(t *testing.T) {
+	watcher := Watch(Load(), "", nil)
+	before := watcher.Current()
+
+	t.Setenv("LOW_STOCK_THRESHOLD", "-1")
+	if _, err := watcher.Reload(); err == nil {
+		t.Fatal("Expected an error for a negative threshold")
+	}
+	if watcher.Current() != before {
+		t.Error("Expected the current configuration to be kept")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := &Config{
+		Port:            ":8080",
+		AdminAPIKey:     "secret",
+		SocketMode:      0660,
+		ShutdownTimeout: 45 * time.Second,
+	}
+
+	settings := cfg.Redacted()
+	if settings["ADMIN_API_KEY"] != redacted {
+		t.Errorf("Expected the admin key to be redacted, got %v", settings["ADMIN_API_KEY"])
+	}
+	if settings["LISTEN_SOCKET_MODE"] != "0660" || settings["SHUTDOWN_TIMEOUT"] != "45s" {
+		t.Errorf("Expected mode 0660 and timeout 45s, got %v and %v", settings["LISTEN_SOCKET_MODE"], settings["SHUTDOWN_TIMEOUT"])
+	}
+	if origins, ok := settings["CORS_ALLOWED_ORIGINS"].([]string); !ok || origins == nil {
+		t.Errorf("Expected an empty origin list rather than nil, got %#v", settings["CORS_ALLOWED_ORIGINS"])
+	}
+
+	if unset := (&Config{}).Redacted(); unset["ADMIN_API_KEY"] != "" {
+		t.Errorf("Expected an unset admin key to stay empty, got %v", unset["ADMIN_API_KEY"])
+	}
+}
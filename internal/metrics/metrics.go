@@ -0,0 +1,372 @@
+// Package metrics keeps counters, gauges and histograms and exposes them in
+// the Prometheus text format, so a Prometheus server can scrape them. A nil
+// *Registry is valid and records nothing, and so are the metrics it
+// returns; metrics are disabled unless ENABLE_METRICS is set.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ContentType is the media type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultDurationBuckets are histogram upper bounds, in seconds, for
+// durations from a tenth of a millisecond to ten seconds
+var DefaultDurationBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10}
+
+// metric is a registered metric family
+type metric interface {
+	kind() string
+	help() string
+	// write writes the samples of the family named name
+	write(w io.Writer, name string)
+}
+
+// Registry holds metrics by name
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// register adds m under name, or returns the metric already registered
+// under it so that callers share it. Registering a name again with another
+// kind of metric is a programming error.
+func (r *Registry) register(name string, m metric) metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.metrics[name]; ok {
+		if existing.kind() != m.kind() {
+			panic(fmt.Sprintf("metrics: %s registered as %s and %s", name, existing.kind(), m.kind()))
+		}
+		return existing
+	}
+	r.metrics[name] = m
+	return m
+}
+
+// WriteText writes every metric in the Prometheus text format, ordered by
+// name
+func (r *Registry) WriteText(w io.Writer) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	families := make(map[string]metric, len(r.metrics))
+	for name, m := range r.metrics {
+		families[name] = m
+	}
+	r.mu.Unlock()
+
+	sort.Strings(names)
+	for _, name := range names {
+		m := families[name]
+		fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(m.help()))
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, m.kind())
+		m.write(w, name)
+	}
+}
+
+// Handler serves the registry in the Prometheus text format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.WriteText(w)
+	})
+}
+
+// Counter is a value that only goes up
+type Counter struct {
+	value atomic.Int64
+}
+
+// Add increases the counter by n
+func (c *Counter) Add(n int64) {
+	if c != nil {
+		c.value.Add(n)
+	}
+}
+
+// Inc increases the counter by one
+func (c *Counter) Inc() { c.Add(1) }
+
+// Value returns the current count
+func (c *Counter) Value() int64 {
+	if c == nil {
+		return 0
+	}
+	return c.value.Load()
+}
+
+// Gauge is a value that goes up and down
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set sets the gauge to value
+func (g *Gauge) Set(value float64) {
+	if g != nil {
+		g.bits.Store(math.Float64bits(value))
+	}
+}
+
+// Add adds delta, which may be negative, to the gauge
+func (g *Gauge) Add(delta float64) {
+	if g == nil {
+		return
+	}
+	for {
+		old := g.bits.Load()
+		if g.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+// Value returns the current value
+func (g *Gauge) Value() float64 {
+	if g == nil {
+		return 0
+	}
+	return math.Float64frombits(g.bits.Load())
+}
+
+// Histogram counts observations in buckets of upper bounds
+type Histogram struct {
+	bounds []float64
+	counts []atomic.Int64 // one per bound, plus +Inf
+	sum    Gauge
+	count  atomic.Int64
+}
+
+// Observe records value
+func (h *Histogram) Observe(value float64) {
+	if h == nil {
+		return
+	}
+	i := sort.SearchFloat64s(h.bounds, value)
+	h.counts[i].Add(1)
+	h.sum.Add(value)
+	h.count.Add(1)
+}
+
+// ObserveDuration records the seconds elapsed since start
+func (h *Histogram) ObserveDuration(start time.Time) {
+	if h != nil {
+		h.Observe(time.Since(start).Seconds())
+	}
+}
+
+// Count returns the number of observations
+func (h *Histogram) Count() int64 {
+	if h == nil {
+		return 0
+	}
+	return h.count.Load()
+}
+
+// counterFamily is a counter without labels
+type counterFamily struct {
+	Counter
+	helpText string
+}
+
+func (f *counterFamily) kind() string { return "counter" }
+func (f *counterFamily) help() string { return f.helpText }
+func (f *counterFamily) write(w io.Writer, name string) {
+	fmt.Fprintf(w, "%s %d\n", name, f.Value())
+}
+
+// NewCounter registers a counter
+func (r *Registry) NewCounter(name, help string) *Counter {
+	if r == nil {
+		return nil
+	}
+	return &r.register(name, &counterFamily{helpText: help}).(*counterFamily).Counter
+}
+
+// gaugeFamily is a gauge without labels
+type gaugeFamily struct {
+	Gauge
+	helpText string
+}
+
+func (f *gaugeFamily) kind() string { return "gauge" }
+func (f *gaugeFamily) help() string { return f.helpText }
+func (f *gaugeFamily) write(w io.Writer, name string) {
+	fmt.Fprintf(w, "%s %s\n", name, formatFloat(f.Value()))
+}
+
+// NewGauge registers a gauge
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	if r == nil {
+		return nil
+	}
+	return &r.register(name, &gaugeFamily{helpText: help}).(*gaugeFamily).Gauge
+}
+
+// histogramFamily is a histogram without labels
+type histogramFamily struct {
+	Histogram
+	helpText string
+}
+
+func (f *histogramFamily) kind() string { return "histogram" }
+func (f *histogramFamily) help() string { return f.helpText }
+func (f *histogramFamily) write(w io.Writer, name string) {
+	cumulative := int64(0)
+	for i, bound := range f.bounds {
+		cumulative += f.counts[i].Load()
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", name, formatFloat(bound), cumulative)
+	}
+	cumulative += f.counts[len(f.bounds)].Load()
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, cumulative)
+	fmt.Fprintf(w, "%s_sum %s\n", name, formatFloat(f.sum.Value()))
+	fmt.Fprintf(w, "%s_count %d\n", name, f.Count())
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds,
+// which must be sorted in increasing order
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	if r == nil {
+		return nil
+	}
+	f := &histogramFamily{helpText: help}
+	f.bounds = append([]float64(nil), buckets...)
+	f.counts = make([]atomic.Int64, len(buckets)+1)
+	return &r.register(name, f).(*histogramFamily).Histogram
+}
+
+// CounterVec is a family of counters told apart by the value of one label
+type CounterVec struct {
+	label    string
+	helpText string
+	mu       sync.Mutex
+	counters map[string]*Counter
+}
+
+func (v *CounterVec) kind() string { return "counter" }
+func (v *CounterVec) help() string { return v.helpText }
+func (v *CounterVec) write(w io.Writer, name string) {
+	v.mu.Lock()
+	values := make([]string, 0, len(v.counters))
+	for value := range v.counters {
+		values = append(values, value)
+	}
+	v.mu.Unlock()
+
+	sort.Strings(values)
+	for _, value := range values {
+		fmt.Fprintf(w, "%s{%s=\"%s\"} %d\n", name, v.label, escapeLabel(value), v.With(value).Value())
+	}
+}
+
+// NewCounterVec registers a family of counters labelled label
+func (r *Registry) NewCounterVec(name, help, label string) *CounterVec {
+	if r == nil {
+		return nil
+	}
+	return r.register(name, &CounterVec{label: label, helpText: help, counters: make(map[string]*Counter)}).(*CounterVec)
+}
+
+// With returns the counter for the label value, creating it at zero
+func (v *CounterVec) With(value string) *Counter {
+	if v == nil {
+		return nil
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, ok := v.counters[value]
+	if !ok {
+		c = &Counter{}
+		v.counters[value] = c
+	}
+	return c
+}
+
+// GaugeVec is a family of gauges told apart by the value of one label
+type GaugeVec struct {
+	label    string
+	helpText string
+	mu       sync.Mutex
+	gauges   map[string]*Gauge
+}
+
+func (v *GaugeVec) kind() string { return "gauge" }
+func (v *GaugeVec) help() string { return v.helpText }
+func (v *GaugeVec) write(w io.Writer, name string) {
+	v.mu.Lock()
+	values := make([]string, 0, len(v.gauges))
+	for value := range v.gauges {
+		values = append(values, value)
+	}
+	v.mu.Unlock()
+
+	sort.Strings(values)
+	for _, value := range values {
+		fmt.Fprintf(w, "%s{%s=\"%s\"} %s\n", name, v.label, escapeLabel(value), formatFloat(v.With(value).Value()))
+	}
+}
+
+// NewGaugeVec registers a family of gauges labelled label
+func (r *Registry) NewGaugeVec(name, help, label string) *GaugeVec {
+	if r == nil {
+		return nil
+	}
+	return r.register(name, &GaugeVec{label: label, helpText: help, gauges: make(map[string]*Gauge)}).(*GaugeVec)
+}
+
+// With returns the gauge for the label value, creating it at zero
+func (v *GaugeVec) With(value string) *Gauge {
+	if v == nil {
+		return nil
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	g, ok := v.gauges[value]
+	if !ok {
+		g = &Gauge{}
+		v.gauges[value] = g
+	}
+	return g
+}
+
+// formatFloat formats a sample value the way Prometheus parses it
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// escapeHelp escapes backslashes and line feeds in help text
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+// escapeLabel escapes backslashes, double quotes and line feeds in a label
+// value
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
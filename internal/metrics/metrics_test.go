@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounter("jobs_total", "Jobs run").Add(3)
+	registry.NewGauge("queue_depth", "Items queued").Set(2.5)
+	histogram := registry.NewHistogram("job_seconds", "Job duration", []float64{0.1, 1})
+	histogram.Observe(0.05)
+	histogram.Observe(0.1)
+	histogram.Observe(4)
+	skipped := registry.NewCounterVec("skipped_total", "Skipped items", "reason")
+	skipped.With("too \"long\"").Inc()
+	skipped.With("bad").Add(2)
+
+	var out strings.Builder
+	registry.WriteText(&out)
+	want := `# HELP job_seconds Job duration
+# TYPE job_seconds histogram
+job_seconds_bucket{le="0.1"} 2
+job_seconds_bucket{le="1"} 2
+job_seconds_bucket{le="+Inf"} 3
+job_seconds_sum 4.15
+job_seconds_count 3
+# HELP jobs_total Jobs run
+# TYPE jobs_total counter
+jobs_total 3
+# HELP queue_depth Items queued
+# TYPE queue_depth gauge
+queue_depth 2.5
+# HELP skipped_total Skipped items
+# TYPE skipped_total counter
+skipped_total{reason="bad"} 2
+skipped_total{reason="too \"long\""} 1
+`
+	if out.String() != want {
+		t.Errorf("Unexpected exposition:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestRegisterSharesMetrics(t *testing.T) {
+	registry := NewRegistry()
+	first := registry.NewCounter("jobs_total", "Jobs run")
+	second := registry.NewCounter("jobs_total", "Jobs run")
+	first.Inc()
+	if second.Value() != 1 {
+		t.Errorf("Expected a name registered twice to share its counter, got %d", second.Value())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a name with another kind to panic")
+		}
+	}()
+	registry.NewGauge("jobs_total", "Jobs run")
+}
+
+func TestNilRegistry(t *testing.T) {
+	var registry *Registry
+	counter := registry.NewCounter("jobs_total", "Jobs run")
+	gauge := registry.NewGauge("queue_depth", "Items queued")
+	histogram := registry.NewHistogram("job_seconds", "Job duration", DefaultDurationBuckets)
+	vec := registry.NewCounterVec("skipped_total", "Skipped items", "reason")
+
+	counter.Inc()
+	gauge.Add(1)
+	histogram.Observe(1)
+	vec.With("bad").Inc()
+	if counter.Value() != 0 || gauge.Value() != 0 || histogram.Count() != 0 || vec.With("bad").Value() != 0 {
+		t.Error("Expected metrics of a nil registry to record nothing")
+	}
+
+	rr := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if rr.Body.Len() != 0 || rr.Header().Get("Content-Type") != ContentType {
+		t.Errorf("Expected an empty exposition, got %q (%s)", rr.Body.String(), rr.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// dashboardFields has DashboardData's fields but not its MarshalJSON
+// method, so it can be encoded with the default rules
+type dashboardFields DashboardData
+
+// MarshalJSON encodes the dashboard with consistent field presence: empty
+// collections are always encoded as [] rather than null, and a zero
+// LastUpdated (no data loaded yet) or data date range is encoded as null
+func (d DashboardData) MarshalJSON() ([]byte, error) {
+	d.CountryRevenues = emptyIfNil(d.CountryRevenues)
+	d.CountrySummaries = emptyIfNil(d.CountrySummaries)
+	d.TopProducts = emptyIfNil(d.TopProducts)
+	d.MonthlySales = emptyIfNil(d.MonthlySales)
+	d.WeeklySales = emptyIfNil(d.WeeklySales)
+	d.TopRegions = emptyIfNil(d.TopRegions)
+	d.Report.Currencies = emptyIfNil(d.Report.Currencies)
+	d.Report.Warnings = emptyIfNil(d.Report.Warnings)
+	d.Report.UnmappedCountries = emptyIfNil(d.Report.UnmappedCountries)
+	d.Report.Files = emptyIfNil(d.Report.Files)
+	d.Report.Pipeline.WorkerRows = emptyIfNil(d.Report.Pipeline.WorkerRows)
+
+	return json.Marshal(struct {
+		dashboardFields
+		LastUpdated   *time.Time `json:"last_updated"`
+		DataStartDate *time.Time `json:"data_start_date"`
+		DataEndDate   *time.Time `json:"data_end_date"`
+	}{
+		dashboardFields: dashboardFields(d),
+		LastUpdated:     timeOrNil(d.LastUpdated),
+		DataStartDate:   timeOrNil(d.DataStartDate),
+		DataEndDate:     timeOrNil(d.DataEndDate),
+	})
+}
+
+// summaryFields has Summary's fields but not its MarshalJSON method
+type summaryFields Summary
+
+// MarshalJSON encodes a zero AsOf (no dated transactions) as null
+func (s Summary) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		summaryFields
+		AsOf *time.Time `json:"as_of"`
+	}{
+		summaryFields: summaryFields(s),
+		AsOf:          timeOrNil(s.AsOf),
+	})
+}
+
+// dashboardDiffFields has DashboardDiff's fields but not its MarshalJSON
+// method
+type dashboardDiffFields DashboardDiff
+
+// MarshalJSON encodes empty change lists as [] and a zero From (no earlier
+// data) as null
+func (d DashboardDiff) MarshalJSON() ([]byte, error) {
+	d.Countries = emptyIfNil(d.Countries)
+	d.NewProducts = emptyIfNil(d.NewProducts)
+	d.RemovedProducts = emptyIfNil(d.RemovedProducts)
+	d.Months = emptyIfNil(d.Months)
+
+	return json.Marshal(struct {
+		dashboardDiffFields
+		From *time.Time `json:"from"`
+	}{
+		dashboardDiffFields: dashboardDiffFields(d),
+		From:                timeOrNil(d.From),
+	})
+}
+
+// emptyIfNil returns an empty, non-nil slice in place of nil
+func emptyIfNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+// timeOrNil returns nil for the zero time so it encodes as null
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDashboardDataMarshalJSONZeroValue(t *testing.T) {
+	jsonData, err := json.Marshal(DashboardData{})
+	if err != nil {
+		t.Fatalf("Failed to marshal DashboardData: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(jsonData, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal DashboardData: %v", err)
+	}
+
+	for _, key := range []string{"country_revenues", "top_products", "monthly_sales", "top_regions"} {
+		if list, ok := decoded[key].([]interface{}); !ok || len(list) != 0 {
+			t.Errorf("Expected %s to be [], got %v", key, decoded[key])
+		}
+	}
+
+	report := decoded["processing_report"].(map[string]interface{})
+	for _, key := range []string{"currencies", "warnings", "files"} {
+		if list, ok := report[key].([]interface{}); !ok || len(list) != 0 {
+			t.Errorf("Expected processing_report.%s to be [], got %v", key, report[key])
+		}
+	}
+
+	if value, exists := decoded["last_updated"]; !exists || value != nil {
+		t.Errorf("Expected last_updated to be present and null, got %v", value)
+	}
+	for _, key := range []string{"data_start_date", "data_end_date"} {
+		if value, exists := decoded[key]; !exists || value != nil {
+			t.Errorf("Expected %s to be present and null, got %v", key, value)
+		}
+	}
+	if value := decoded["summary"].(map[string]interface{})["as_of"]; value != nil {
+		t.Errorf("Expected summary.as_of to be null, got %v", value)
+	}
+	if strings.Contains(string(jsonData), "0001-01-01") {
+		t.Errorf("Expected no zero timestamps in %s", jsonData)
+	}
+}
+
+func TestDashboardDataMarshalJSONRoundTrip(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	original := DashboardData{
+		CountryRevenues: []CountryRevenue{{Country: "USA", ProductName: "Laptop", TotalRevenue: 100, TransactionCount: 1}},
+		Summary:         Summary{AsOf: now, RevenueLast7d: 50},
+		LastUpdated:     now,
+		RecordCount:     1,
+	}
+
+	jsonData, err := json.Marshal(&original)
+	if err != nil {
+		t.Fatalf("Failed to marshal DashboardData: %v", err)
+	}
+
+	var decoded DashboardData
+	if err := json.Unmarshal(jsonData, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal DashboardData: %v", err)
+	}
+
+	if !decoded.LastUpdated.Equal(now) || !decoded.Summary.AsOf.Equal(now) {
+		t.Errorf("Expected timestamps to round-trip, got %v and %v", decoded.LastUpdated, decoded.Summary.AsOf)
+	}
+	if len(decoded.CountryRevenues) != 1 || decoded.CountryRevenues[0] != original.CountryRevenues[0] {
+		t.Errorf("Expected country revenues to round-trip, got %+v", decoded.CountryRevenues)
+	}
+	if decoded.Summary.RevenueLast7d != 50 || decoded.RecordCount != 1 {
+		t.Errorf("Expected scalar fields to round-trip, got %+v", decoded)
+	}
+}
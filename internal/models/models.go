@@ -6,56 +6,597 @@ import "time"
 type Transaction struct {
 	TransactionID   string    `json:"transaction_id" csv:"transaction_id"`
 	TransactionDate time.Time `json:"transaction_date" csv:"transaction_date"`
-	UserID          string    `json:"user_id" csv:"user_id"`
+	UserID          string    `json:"user_id" csv:"user_id" pii:"user"`
 	Country         string    `json:"country" csv:"country"`
 	Region          string    `json:"region" csv:"region"`
+	Currency        string    `json:"currency" csv:"currency"`
 	ProductID       string    `json:"product_id" csv:"product_id"`
 	ProductName     string    `json:"product_name" csv:"product_name"`
-	Category        string    `json:"category" csv:"category"`
-	Price           float64   `json:"price" csv:"price"`
-	Quantity        int       `json:"quantity" csv:"quantity"`
-	TotalPrice      float64   `json:"total_price" csv:"total_price"`
-	StockQuantity   int       `json:"stock_quantity" csv:"stock_quantity"`
-	AddedDate       time.Time `json:"added_date" csv:"added_date"`
+	// DisplayName is the product name as exported, before it was
+	// normalized into ProductName; empty when normalization is off
+	DisplayName   string    `json:"display_name,omitempty" csv:"-"`
+	Category      string    `json:"category" csv:"category"`
+	Price         float64   `json:"price" csv:"price"`
+	Quantity      int       `json:"quantity" csv:"quantity"`
+	TotalPrice    float64   `json:"total_price" csv:"total_price"`
+	StockQuantity int       `json:"stock_quantity" csv:"stock_quantity"`
+	AddedDate     time.Time `json:"added_date" csv:"added_date"`
+	// StockReported is set when the row had a valid stock_quantity, so
+	// that a zero StockQuantity means no stock rather than no value
+	StockReported bool `json:"-" csv:"-"`
+	// DateHasOffset is set when transaction_date carried its own UTC
+	// offset, before it was converted to the data timezone
+	DateHasOffset bool `json:"-" csv:"-"`
 }
 
 // CountryRevenue represents country-level revenue data
 type CountryRevenue struct {
 	Country          string  `json:"country"`
 	ProductName      string  `json:"product_name"`
+	Currency         string  `json:"currency"`
 	TotalRevenue     float64 `json:"total_revenue"`
 	TransactionCount int     `json:"transaction_count"`
+	ItemsSold        int     `json:"items_sold"`
+
+	// AverageOrderValue is TotalRevenue divided by TransactionCount
+	AverageOrderValue float64 `json:"average_order_value"`
+}
+
+// CountrySummary rolls a country's revenue rows up into one entry, along
+// with its best-selling product by revenue. CountryCode is the ISO 3166-1
+// alpha-2 code for the country, empty when the name is not recognised.
+// TotalRevenue and BestProductRevenue are left at zero when the dataset's
+// amounts are in more than one currency; the RevenueByCurrency maps hold
+// the revenue then.
+type CountrySummary struct {
+	Country                      string             `json:"country"`
+	CountryCode                  string             `json:"country_code"`
+	TotalRevenue                 float64            `json:"total_revenue"`
+	TransactionCount             int                `json:"transaction_count"`
+	ItemsSold                    int                `json:"items_sold"`
+	ProductCount                 int                `json:"product_count"`
+	BestProductName              string             `json:"best_product_name"`
+	BestProductRevenue           float64            `json:"best_product_revenue"`
+	RevenueByCurrency            map[string]float64 `json:"revenue_by_currency,omitempty"`
+	BestProductRevenueByCurrency map[string]float64 `json:"best_product_revenue_by_currency,omitempty"`
+}
+
+// CountryProduct is a product's sales within a single country. Like the
+// country's summary, TotalRevenue is left at zero when the dataset's
+// amounts are in more than one currency, and RevenueByCurrency holds the
+// revenue then.
+type CountryProduct struct {
+	ProductName       string             `json:"product_name"`
+	TotalRevenue      float64            `json:"total_revenue"`
+	TransactionCount  int                `json:"transaction_count"`
+	ItemsSold         int                `json:"items_sold"`
+	RevenueByCurrency map[string]float64 `json:"revenue_by_currency,omitempty"`
+}
+
+// CountryCustomer is a user's spending within a single country, summed
+// across currencies
+type CountryCustomer struct {
+	UserID           string  `json:"user_id" pii:"user"`
+	TotalRevenue     float64 `json:"total_revenue"`
+	TransactionCount int     `json:"transaction_count"`
+	ItemsSold        int     `json:"items_sold"`
 }
 
-// ProductFrequency represents product purchase frequency data
+// CountryDetail is everything known about one country: its rollup, its
+// top products by revenue and its monthly trend. MonthlySalesRetained is
+// false when the country is outside the countries whose monthly series are
+// kept, in which case MonthlySales is empty.
+type CountryDetail struct {
+	CountrySummary
+	TopProducts          []CountryProduct `json:"top_products"`
+	MonthlySales         []MonthlySales   `json:"monthly_sales"`
+	MonthlySalesRetained bool             `json:"monthly_sales_retained"`
+}
+
+// CountryRevenueGroup nests the revenue rows of one country under it, one
+// product per name. TotalRevenue is the sum of its products' revenue. Like
+// the products' totals, it is left at zero when the dataset's amounts are
+// in more than one currency, and RevenueByCurrency holds the revenue then.
+type CountryRevenueGroup struct {
+	Country           string             `json:"country"`
+	TotalRevenue      float64            `json:"total_revenue"`
+	Products          []CountryProduct   `json:"products"`
+	RevenueByCurrency map[string]float64 `json:"revenue_by_currency,omitempty"`
+}
+
+// Shapes of the country revenue response. Flat lists one row per country,
+// product and currency; nested groups the rows by country.
+const (
+	ShapeFlat   = "flat"
+	ShapeNested = "nested"
+)
+
+// CountryRevenueQuery describes filtering, sorting and paging of country
+// revenue rows. A zero PageSize returns every matching row. MinAvgOrder and
+// MaxAvgOrder bound AverageOrderValue inclusively when set.
+type CountryRevenueQuery struct {
+	Countries   []string `json:"countries"`
+	Products    []string `json:"products"`
+	MinRevenue  float64  `json:"min_revenue"`
+	MinAvgOrder *float64 `json:"min_avg_order"`
+	MaxAvgOrder *float64 `json:"max_avg_order"`
+	SortBy      string   `json:"sort_by"`
+	Order       string   `json:"order"`
+	Page        int      `json:"page"`
+	PageSize    int      `json:"page_size"`
+	Shape       string   `json:"shape"`
+}
+
+// Stock status values for ProductFrequency.StockStatus
+const (
+	StockInStock = "in_stock"
+	StockLow     = "low"
+	StockOut     = "out"
+)
+
+// Ranking metrics for the top products. Orders counts the transaction rows
+// a product appears in; units sums their quantities.
+const (
+	RankByOrders = "orders"
+	RankByUnits  = "units"
+)
+
+// Ranking metrics for the top regions. Items sums the quantities sold.
+const (
+	RankByRevenue = "revenue"
+	RankByItems   = "items"
+)
+
+// ProductFrequency represents product purchase frequency data. Rank is the
+// product's 1-based position in the purchase ranking, kept when the list is
+// filtered.
 type ProductFrequency struct {
+	Rank          int    `json:"rank"`
 	ProductName   string `json:"product_name"`
 	PurchaseCount int    `json:"purchase_count"`
+	UnitsSold     int    `json:"units_sold"`
 	CurrentStock  int    `json:"current_stock"`
+	StockStatus   string `json:"stock_status"`
+	// FirstSold and LastSold are the earliest and latest transaction dates
+	// of the product; nil when none of its rows had a valid date
+	FirstSold *time.Time `json:"first_sold,omitempty"`
+	LastSold  *time.Time `json:"last_sold,omitempty"`
+}
+
+// TopProductsFilter narrows the ranked top products by stock level and
+// recent sales. A nil MaxStock applies no stock ceiling and a zero
+// ActiveSince no date bound. RankBy picks the ranking, RankByOrders when
+// empty.
+type TopProductsFilter struct {
+	MaxStock    *int
+	OutOfStock  bool
+	ActiveSince time.Time
+	RankBy      string
+}
+
+// StockPressure estimates the demand for a product that its stock could
+// not cover: the rows sold while the product's stock was zero or below the
+// quantity ordered, with their units and revenue
+type StockPressure struct {
+	ProductName   string  `json:"product_name"`
+	Rows          int     `json:"rows"`
+	UnitsDemanded int     `json:"units_demanded"`
+	Revenue       float64 `json:"revenue"`
 }
 
 // MonthlySales represents monthly sales volume data
 type MonthlySales struct {
-	Month       string  `json:"month"`
-	Year        int     `json:"year"`
-	TotalSales  float64 `json:"total_sales"`
-	SalesVolume int     `json:"sales_volume"`
+	Month           string             `json:"month"`
+	Year            int                `json:"year"`
+	MonthNumber     int                `json:"month_number"`
+	TotalSales      float64            `json:"total_sales"`
+	SalesVolume     int                `json:"sales_volume"`
+	SalesByCurrency map[string]float64 `json:"sales_by_currency,omitempty"`
+
+	// DaysWithSales counts the distinct dates with transactions in the
+	// month, and AverageDailySales divides TotalSales by it, so that a
+	// partial month is averaged over the days it actually covers
+	DaysWithSales     int     `json:"days_with_sales,omitempty"`
+	AverageDailySales float64 `json:"average_daily_sales,omitempty"`
+
+	// MoMGrowthPercent and YoYGrowthPercent compare TotalSales with the
+	// previous month and the same month a year earlier; they are omitted
+	// when that month is absent or had no sales
+	MoMGrowthPercent *float64 `json:"mom_growth_percent,omitempty"`
+	YoYGrowthPercent *float64 `json:"yoy_growth_percent,omitempty"`
+
+	// LocalizedMonth names MonthNumber in the language of the request, when
+	// localization is enabled. It is set per response and only for display;
+	// Month stays the English name and ordering uses Year and MonthNumber.
+	LocalizedMonth string `json:"localized_month,omitempty"`
+}
+
+// WeeklySales represents sales volume in one ISO 8601 week. Year is the ISO
+// year, which differs from the calendar year for days around New Year that
+// belong to the last week of one year or the first week of the next; Week
+// combines both as "2025-W01" and StartDate is the week's Monday.
+type WeeklySales struct {
+	Week            string             `json:"week"`
+	Year            int                `json:"year"`
+	WeekNumber      int                `json:"week_number"`
+	StartDate       time.Time          `json:"start_date"`
+	TotalSales      float64            `json:"total_sales"`
+	SalesVolume     int                `json:"sales_volume"`
+	SalesByCurrency map[string]float64 `json:"sales_by_currency,omitempty"`
 }
 
 // RegionRevenue represents region-level revenue data
 type RegionRevenue struct {
-	Region       string  `json:"region"`
+	Region            string             `json:"region"`
+	TotalRevenue      float64            `json:"total_revenue"`
+	ItemsSold         int                `json:"items_sold"`
+	TransactionCount  int                `json:"transaction_count"`
+	AverageBasketSize float64            `json:"average_basket_size"`
+	AverageOrderValue float64            `json:"average_order_value"`
+	RevenueByCurrency map[string]float64 `json:"revenue_by_currency,omitempty"`
+}
+
+// RegionProduct represents a product's sales within a single region
+type RegionProduct struct {
+	ProductName  string  `json:"product_name"`
+	QuantitySold int     `json:"quantity_sold"`
 	TotalRevenue float64 `json:"total_revenue"`
-	ItemsSold    int     `json:"items_sold"`
+}
+
+// CategoryProduct represents a product's sales within a category. Rank is
+// the product's 1-based position in the category ranking.
+type CategoryProduct struct {
+	Rank          int     `json:"rank"`
+	ProductName   string  `json:"product_name"`
+	PurchaseCount int     `json:"purchase_count"`
+	UnitsSold     int     `json:"units_sold"`
+	TotalRevenue  float64 `json:"total_revenue"`
+}
+
+// Summary holds rolling revenue and order windows relative to the latest
+// transaction date in the dataset, along with the preceding windows so
+// the dashboard can show deltas
+type Summary struct {
+	AsOf            time.Time `json:"as_of" jsonschema:"nullable"`
+	RevenueLast7d   float64   `json:"revenue_last_7d"`
+	RevenueLast30d  float64   `json:"revenue_last_30d"`
+	OrdersLast7d    int       `json:"orders_last_7d"`
+	OrdersLast30d   int       `json:"orders_last_30d"`
+	RevenuePrior7d  float64   `json:"revenue_prior_7d"`
+	RevenuePrior30d float64   `json:"revenue_prior_30d"`
+	OrdersPrior7d   int       `json:"orders_prior_7d"`
+	OrdersPrior30d  int       `json:"orders_prior_30d"`
+	RevenueDelta7d  float64   `json:"revenue_delta_7d"`
+	RevenueDelta30d float64   `json:"revenue_delta_30d"`
+	OrdersDelta7d   int       `json:"orders_delta_7d"`
+	OrdersDelta30d  int       `json:"orders_delta_30d"`
+
+	// Unattributed is the revenue of rows without a region or country,
+	// over the whole dataset
+	Unattributed Unattributed `json:"unattributed"`
+}
+
+// Unattributed totals the rows with an empty region or country. Unless
+// blank locations are kept, those rows are left out of the region or
+// country aggregates and only counted here; a row missing both is counted
+// in both.
+type Unattributed struct {
+	Region  UnattributedTotal `json:"region"`
+	Country UnattributedTotal `json:"country"`
+}
+
+// UnattributedTotal is the revenue and number of rows missing a location
+type UnattributedTotal struct {
+	Revenue          float64 `json:"revenue"`
+	TransactionCount int     `json:"transaction_count"`
+}
+
+// ProcessingReport summarises notable conditions found while processing a dataset
+type ProcessingReport struct {
+	Currencies      []string     `json:"currencies"`
+	Warnings        []string     `json:"warnings"`
+	Files           []FileReport `json:"files"`
+	Rows            int          `json:"rows"`
+	SkippedRows     int          `json:"skipped_rows"`
+	ReadErrors      int          `json:"read_errors"`
+	OversizedLines  int          `json:"oversized_lines"`
+	TruncatedFields int          `json:"truncated_fields"`
+	// UnmappedCountries lists country names with no ISO country code
+	UnmappedCountries []string       `json:"unmapped_countries"`
+	TotalMismatches   int            `json:"total_price_mismatches"`
+	FirstDate         string         `json:"first_date,omitempty"`
+	LastDate          string         `json:"last_date,omitempty"`
+	Truncated         bool           `json:"truncated"`
+	Overflow          map[string]int `json:"overflow,omitempty"`
+	// FoldedRows counts the country revenue rows folded into "Other" for
+	// earning less than OtherBucketThreshold percent of the total revenue
+	// in their currency
+	FoldedRows int `json:"folded_rows"`
+	// DuplicateRows counts the rows skipped for repeating an earlier
+	// transaction_id, when deduplication is enabled
+	DuplicateRows        int               `json:"duplicate_rows,omitempty"`
+	OtherBucketThreshold float64           `json:"other_bucket_threshold,omitempty"`
+	Pipeline             PipelineStats     `json:"pipeline"`
+	Timings              ProcessingTimings `json:"timings"`
+	// UnknownRegions and UnknownCountries count the rows per name that
+	// matched no entry of REGION_ALIASES or COUNTRY_ALIASES
+	UnknownRegions   map[string]int `json:"unknown_regions,omitempty"`
+	UnknownCountries map[string]int `json:"unknown_countries,omitempty"`
+	// MergedProductNames counts the product name spellings merged into
+	// another one for differing only in whitespace or case
+	MergedProductNames int `json:"merged_product_names,omitempty"`
+	// InvalidUTF8Rows counts the rows holding bytes that are not valid
+	// UTF-8, when files are read as UTF-8
+	InvalidUTF8Rows int `json:"invalid_utf8_rows,omitempty"`
+	// Unattributed totals the rows without a region or country
+	Unattributed Unattributed `json:"unattributed"`
+	// Excluded totals the rows left out by each product and country
+	// exclusion in force for the run, including those that matched nothing
+	Excluded []ExcludedTotal `json:"excluded,omitempty"`
+	// Timezone is the IANA zone dates were bucketed in, and OffsetDateRows
+	// counts the rows whose transaction_date carried its own UTC offset
+	// and was converted to it
+	Timezone       string `json:"timezone,omitempty"`
+	OffsetDateRows int    `json:"offset_date_rows,omitempty"`
+	// MixedTotals is set when amounts were aggregated in more than one
+	// currency, unconverted for want of rates. Totals that would add them
+	// together are then left at zero; the per-currency totals hold the
+	// revenue.
+	MixedTotals bool `json:"mixed_totals,omitempty"`
+}
+
+// Exclusions are the products, by name or product_id, and the countries,
+// by name or ISO code, whose rows are left out of every aggregate
+type Exclusions struct {
+	Products  []string `json:"products"`
+	Countries []string `json:"countries"`
+}
+
+// Kinds of ExcludedTotal
+const (
+	ExcludedProduct = "product"
+	ExcludedCountry = "country"
+)
+
+// ExcludedTotal is the revenue and number of the rows left out by one
+// exclusion. A row matching a product and a country exclusion is counted
+// by both.
+type ExcludedTotal struct {
+	Kind             string  `json:"kind"`
+	Key              string  `json:"key"`
+	Revenue          float64 `json:"revenue"`
+	TransactionCount int     `json:"transaction_count"`
+}
+
+// ProcessingTimings breaks a processing run down by phase. Reading and
+// aggregation overlap, so Aggregate only covers draining the workers after
+// the last row was read; the three phases add up to Total.
+type ProcessingTimings struct {
+	Read          time.Duration `json:"read"`
+	Aggregate     time.Duration `json:"aggregate"`
+	Finalize      time.Duration `json:"finalize"`
+	Total         time.Duration `json:"total"`
+	RowsPerSecond float64       `json:"rows_per_second"`
+}
+
+// PipelineStats describes backpressure between the CSV reader and the
+// aggregation workers. A queue that stays near capacity means the workers
+// are the bottleneck; one that stays near empty means the reader is.
+// Transactions are queued in batches of BatchSize, so the queue holds at
+// most QueueCapacity*BatchSize transactions; the capacity and depths count
+// batches.
+type PipelineStats struct {
+	QueueCapacity int     `json:"queue_capacity"`
+	BatchSize     int     `json:"batch_size"`
+	QueueDepth    int     `json:"queue_depth"`
+	MaxQueueDepth int     `json:"max_queue_depth"`
+	WorkerRows    []int64 `json:"worker_rows"`
+	RowsProcessed int64   `json:"rows_processed"`
+}
+
+// ProcessingStatus reports the progress of the current or most recent
+// processing run
+type ProcessingStatus struct {
+	Running   bool          `json:"running"`
+	Source    string        `json:"source,omitempty"`
+	StartedAt *time.Time    `json:"started_at"`
+	Pipeline  PipelineStats `json:"pipeline"`
+}
+
+// FileReport records how many rows were read from a single dataset file, how
+// many malformed rows were skipped and how many transient read errors were
+// tolerated along the way. Skipped includes the OversizedLines that exceeded
+// the line length limit; TruncatedFields counts text values cut to length.
+// InvalidUTF8Rows counts the rows that are not valid UTF-8 when files are
+// read as UTF-8. Header is how the file's columns were mapped to transaction fields; it is
+// nil for an empty file.
+type FileReport struct {
+	Path            string         `json:"path"`
+	Rows            int            `json:"rows"`
+	Skipped         int            `json:"skipped"`
+	ReadErrors      int            `json:"read_errors"`
+	OversizedLines  int            `json:"oversized_lines"`
+	TruncatedFields int            `json:"truncated_fields"`
+	InvalidUTF8Rows int            `json:"invalid_utf8_rows,omitempty"`
+	Header          *HeaderMapping `json:"header,omitempty"`
+}
+
+// HeaderMapping is how the columns of a CSV header were resolved. Fields
+// lists the column read into each transaction field, in column order;
+// Ignored lists the columns that were not read and why.
+type HeaderMapping struct {
+	Fields  []ColumnMapping `json:"fields"`
+	Ignored []ColumnMapping `json:"ignored"`
+}
+
+// ColumnMapping is one column of a CSV header: its name as written in the
+// file, its 0-based position, and the field it was read into or the reason
+// it was ignored
+type ColumnMapping struct {
+	Column string `json:"column"`
+	Index  int    `json:"index"`
+	Field  string `json:"field,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ProcessingRun records one load of dashboard data. Error is set for a
+// failed reload, in which case the previous data was kept.
+type ProcessingRun struct {
+	Source    string        `json:"source"`
+	Path      string        `json:"path,omitempty"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Records   int           `json:"records"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// DimensionValue is a distinct value of a dimension, such as a country,
+// with the number of rows that have it
+type DimensionValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Dimensions lists the distinct values of each filterable dimension. Values
+// that differ only in case are merged under their most common spelling and
+// each list is sorted alphabetically, ignoring case and accents.
+type Dimensions struct {
+	Countries  []DimensionValue `json:"countries"`
+	Regions    []DimensionValue `json:"regions"`
+	Categories []DimensionValue `json:"categories"`
+	Currencies []DimensionValue `json:"currencies"`
 }
 
 // DashboardData contains all pre-aggregated dashboard data
 type DashboardData struct {
 	CountryRevenues    []CountryRevenue   `json:"country_revenues"`
+	CountrySummaries   []CountrySummary   `json:"country_summaries"`
 	TopProducts        []ProductFrequency `json:"top_products"`
 	MonthlySales       []MonthlySales     `json:"monthly_sales"`
+	WeeklySales        []WeeklySales      `json:"weekly_sales"`
 	TopRegions         []RegionRevenue    `json:"top_regions"`
-	LastUpdated        time.Time          `json:"last_updated"`
+	Summary            Summary            `json:"summary"`
+	LastUpdated        time.Time          `json:"last_updated" jsonschema:"nullable"`
 	ProcessingDuration time.Duration      `json:"processing_duration"`
 	RecordCount        int                `json:"record_count"`
+	DistinctProducts   int                `json:"distinct_products"`
+	DistinctCountries  int                `json:"distinct_countries"`
+	DistinctRegions    int                `json:"distinct_regions"`
+	DistinctUsers      int                `json:"distinct_users"`
+	ReportingCurrency  string             `json:"reporting_currency"`
+	Report             ProcessingReport   `json:"processing_report"`
+	DataSource         string             `json:"data_source"`
+
+	// DataStartDate and DataEndDate are the earliest and latest transaction
+	// dates in the data; both are zero when no transaction has a date
+	DataStartDate time.Time `json:"data_start_date" jsonschema:"nullable"`
+	DataEndDate   time.Time `json:"data_end_date" jsonschema:"nullable"`
+
+	// RegionProducts, CategoryProducts, CountryMonthlySales and
+	// CountryTopCustomers are served per region, category and country by
+	// their own endpoints and, like ProductIndex, kept out of the complete
+	// dashboard payload. CategoryProducts is keyed by the lower-cased
+	// category name.
+	RegionProducts      map[string][]RegionProduct   `json:"-"`
+	CategoryProducts    map[string][]CategoryProduct `json:"-"`
+	CountryMonthlySales map[string][]MonthlySales    `json:"-"`
+	CountryTopCustomers map[string][]CountryCustomer `json:"-"`
+
+	// TopProductsByUnits ranks the top products by units sold rather than
+	// by purchase count. It is served by the top products endpoint and kept
+	// out of the complete dashboard payload.
+	TopProductsByUnits []ProductFrequency `json:"-"`
+
+	// StockPressure lists the products sold against zero or insufficient
+	// stock, by revenue. It is served by the stock pressure endpoint.
+	StockPressure []StockPressure `json:"-"`
+
+	// ProductIndex holds every product, ranked by purchase count, for
+	// product search
+	ProductIndex []ProductSearchEntry `json:"-"`
+
+	// TopProductsAvailable and TopRegionsAvailable count every product and
+	// region the rankings were cut from, so that responses can tell a
+	// complete ranking from a truncated one
+	TopProductsAvailable int `json:"-"`
+	TopRegionsAvailable  int `json:"-"`
+
+	// RevenueIndex locates the rows of CountryRevenues by country and
+	// product, and ProductKeyOrder holds the offsets into ProductIndex in
+	// order of name, so that filtered queries and prefix searches need not
+	// scan every row. Both are built with the data.
+	RevenueIndex    RevenueIndex `json:"-"`
+	ProductKeyOrder []int        `json:"-"`
+
+	// Dimensions is served by the dimensions endpoint
+	Dimensions Dimensions `json:"-"`
+
+	// CurrencyOrder lists the currencies amounts were aggregated in, the
+	// one with the most rows first. With more than one, rankings compare
+	// per-currency revenue in this order, since the totals are left at zero.
+	CurrencyOrder []string `json:"-"`
+
+	// SampleTransactions and CountrySampleTransactions are raw transactions
+	// sampled overall and per country for debugging, served by an admin
+	// endpoint. Both are nil unless sampling is enabled.
+	SampleTransactions        []Transaction            `json:"-"`
+	CountrySampleTransactions map[string][]Transaction `json:"-"`
+}
+
+// ProductSearchEntry is a product in the search index along with its
+// lowercased name, computed once when the data is loaded
+type ProductSearchEntry struct {
+	Product ProductFrequency
+	Key     string
+}
+
+// RevenueIndex maps lowercased country and product names to the offsets of
+// their rows in CountryRevenues, in increasing order so that rows read
+// through it keep the order of the slice
+type RevenueIndex struct {
+	ByCountry map[string][]int
+	ByProduct map[string][]int
+}
+
+// DashboardDiff is what changed between two dashboard snapshots. From is
+// when the earlier snapshot was loaded, zero when it held no data, and To
+// when the current one was. Complete is false when the data was replaced
+// more than once since the time asked about, so that changes made before
+// From are not included.
+type DashboardDiff struct {
+	From     time.Time `json:"from" jsonschema:"nullable"`
+	To       time.Time `json:"to"`
+	Complete bool      `json:"complete"`
+
+	Countries       []CountryRevenueDelta `json:"countries"`
+	NewProducts     []string              `json:"new_products"`
+	RemovedProducts []string              `json:"removed_products"`
+	Months          []MonthlySalesDelta   `json:"months"`
+}
+
+// Empty reports whether the diff holds no changes
+func (d DashboardDiff) Empty() bool {
+	return len(d.Countries) == 0 && len(d.NewProducts) == 0 && len(d.RemovedProducts) == 0 && len(d.Months) == 0
+}
+
+// CountryRevenueDelta is the change in a country's total revenue. A country
+// that is new or was removed has a zero Previous or Current revenue. When
+// either snapshot has amounts in several currencies, revenue is compared
+// per currency and Currency names the one the delta is in.
+type CountryRevenueDelta struct {
+	Country         string  `json:"country"`
+	Currency        string  `json:"currency,omitempty"`
+	PreviousRevenue float64 `json:"previous_revenue"`
+	CurrentRevenue  float64 `json:"current_revenue"`
+	Change          float64 `json:"change"`
+}
+
+// MonthlySalesDelta is the change in a month's total sales
+type MonthlySalesDelta struct {
+	Month         string  `json:"month"`
+	Year          int     `json:"year"`
+	MonthNumber   int     `json:"month_number"`
+	PreviousTotal float64 `json:"previous_total"`
+	CurrentTotal  float64 `json:"current_total"`
+	Change        float64 `json:"change"`
 }
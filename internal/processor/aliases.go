@@ -0,0 +1,169 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Aliases maps variant spellings of a name, matched ignoring case and
+// repeated whitespace, to one canonical name. Canonical names resolve to
+// themselves.
+type Aliases map[string]string
+
+// NewAliases builds an alias table from variant to canonical name mappings
+func NewAliases(mapping map[string]string) Aliases {
+	aliases := make(Aliases, 2*len(mapping))
+	for _, canonical := range mapping {
+		canonical = collapseSpaces(canonical)
+		aliases[aliasKey(canonical)] = canonical
+	}
+	for variant, canonical := range mapping {
+		aliases[aliasKey(variant)] = collapseSpaces(canonical)
+	}
+	return aliases
+}
+
+// LoadAliases reads variant to canonical name mappings from a JSON file of
+// the form {"EMEA": "Europe", "N. America": "North America"}
+func LoadAliases(filePath string) (Aliases, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aliases file: %w", err)
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse aliases file: %w", err)
+	}
+	for variant, canonical := range raw {
+		if aliasKey(variant) == "" {
+			return nil, fmt.Errorf("aliases file contains an empty name")
+		}
+		if aliasKey(canonical) == "" {
+			return nil, fmt.Errorf("empty canonical name for alias %q", variant)
+		}
+	}
+
+	return NewAliases(raw), nil
+}
+
+// Resolve returns the canonical name for name, and false when the table
+// does not know it
+func (a Aliases) Resolve(name string) (string, bool) {
+	canonical, ok := a[aliasKey(name)]
+	return canonical, ok
+}
+
+// aliasKey folds a name for alias lookups
+func aliasKey(name string) string {
+	return strings.ToLower(collapseSpaces(name))
+}
+
+// collapseSpaces trims name and collapses runs of whitespace to one space
+func collapseSpaces(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// titleCase upper-cases the first letter of every word in name and
+// lower-cases the rest, so "north AMERICA" becomes "North America"
+func titleCase(name string) string {
+	runes := []rune(strings.ToLower(name))
+	start := true
+	for i, r := range runes {
+		if start && unicode.IsLetter(r) {
+			runes[i] = unicode.ToUpper(r)
+		}
+		start = unicode.IsSpace(r) || r == '-' || r == '/'
+	}
+	return string(runes)
+}
+
+// SetRegionAliases collapses region names onto canonical names while
+// parsing. Region values are always trimmed and title-cased; with aliases
+// set, values matching no alias are also counted in the processing report.
+// Passing nil removes the aliases.
+func (p *Processor) SetRegionAliases(aliases Aliases) {
+	p.regionAliases = aliases
+}
+
+// SetCountryAliases collapses country names onto canonical names while
+// parsing, counting values that match no alias in the processing report.
+// Unlike regions, countries are only trimmed otherwise, so "USA" keeps
+// matching its country code. Passing nil removes the aliases.
+func (p *Processor) SetCountryAliases(aliases Aliases) {
+	p.countryAliases = aliases
+}
+
+// canonicalRegion returns the normalized region name of a raw CSV value
+func (p *Processor) canonicalRegion(value string) string {
+	region := titleCase(collapseSpaces(value))
+	return resolveAlias(p.regionAliases, &p.unknownRegions, region)
+}
+
+// canonicalCountry returns the normalized country name of a raw CSV value
+func (p *Processor) canonicalCountry(value string) string {
+	return resolveAlias(p.countryAliases, &p.unknownCountries, strings.TrimSpace(value))
+}
+
+// resolveAlias maps name through aliases, recording it in unknown when the
+// table is set but has no entry for it
+func resolveAlias(aliases Aliases, unknown *nameCounts, name string) string {
+	if aliases == nil || name == "" {
+		return name
+	}
+	if canonical, ok := aliases.Resolve(name); ok {
+		return canonical
+	}
+	unknown.add(name)
+	return name
+}
+
+// nameCounts counts occurrences of names seen from concurrent readers
+type nameCounts struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *nameCounts) add(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[name]++
+}
+
+func (c *nameCounts) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts = nil
+}
+
+// snapshot returns a copy of the counts, or nil when there are none
+func (c *nameCounts) snapshot() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.counts) == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(c.counts))
+	for name, n := range c.counts {
+		counts[name] = n
+	}
+	return counts
+}
+
+// unaliasedWarning describes names that matched no alias
+func unaliasedWarning(kind string, counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("%d %s names match no alias and were kept as is: %s", len(names), kind, strings.Join(names, ", "))
+}
@@ -0,0 +1,161 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegionNormalizationMergesBuckets(t *testing.T) {
+	// EMEA is aliased; the other spellings differ only in case and spaces
+	path := writeTestFile(t, "regions.csv", `transaction_id,region,product_name,quantity,total_price
+TXN001,Europe,Laptop,1,100
+TXN002,europe ,Mouse,2,20
+TXN003,  EUROPE,Camera,1,50
+TXN004,EMEA,Laptop,1,100
+TXN005,emea,Mouse,1,10
+TXN006,north   america,Laptop,1,200
+TXN007,Atlantis,Laptop,1,5
+`)
+
+	processor := New()
+	processor.SetRegionAliases(NewAliases(map[string]string{"EMEA": "Europe", "North America": "North America"}))
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	regions := processor.GetTopRegions()
+	byName := make(map[string]float64)
+	for _, region := range regions {
+		byName[region.Region] = region.TotalRevenue
+	}
+	if len(regions) != 3 {
+		t.Fatalf("Expected 3 merged regions, got %+v", regions)
+	}
+	if byName["Europe"] != 280 {
+		t.Errorf("Expected a single Europe bucket with revenue 280, got %v", byName)
+	}
+	if byName["North America"] != 200 {
+		t.Errorf("Expected North America with revenue 200, got %v", byName)
+	}
+
+	if _, ok := processor.GetRegionProducts("Europe", 10); !ok {
+		t.Error("Expected region products under the canonical name")
+	}
+
+	report := processor.GetDashboardData().Report
+	if len(report.UnknownRegions) != 1 || report.UnknownRegions["Atlantis"] != 1 {
+		t.Errorf("Expected Atlantis counted as unknown once, got %v", report.UnknownRegions)
+	}
+	if !containsWarning(report.Warnings, "Atlantis") {
+		t.Errorf("Expected a warning naming Atlantis, got %v", report.Warnings)
+	}
+}
+
+func TestRegionNormalizationWithoutAliases(t *testing.T) {
+	path := writeTestFile(t, "regions.csv", `transaction_id,region,product_name,quantity,total_price
+TXN001,asia,Laptop,1,100
+TXN002,ASIA ,Mouse,1,20
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	regions := processor.GetTopRegions()
+	if len(regions) != 1 || regions[0].Region != "Asia" || regions[0].TotalRevenue != 120 {
+		t.Errorf("Expected one title-cased Asia bucket, got %+v", regions)
+	}
+	if report := processor.GetDashboardData().Report; report.UnknownRegions != nil {
+		t.Errorf("Expected no unknown regions without aliases, got %v", report.UnknownRegions)
+	}
+}
+
+func TestCountryAliases(t *testing.T) {
+	path := writeTestFile(t, "countries.csv", `transaction_id,country,product_name,quantity,total_price
+TXN001,Deutschland,Laptop,1,100
+TXN002,germany,Mouse,1,20
+TXN003,Germany,Camera,1,30
+TXN004,USA,Laptop,1,50
+`)
+
+	processor := New()
+	processor.SetCountryAliases(NewAliases(map[string]string{"Deutschland": "Germany"}))
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	summaries := processor.GetCountrySummaries()
+	revenue := make(map[string]float64)
+	codes := make(map[string]string)
+	for _, summary := range summaries {
+		revenue[summary.Country] = summary.TotalRevenue
+		codes[summary.Country] = summary.CountryCode
+	}
+	if len(summaries) != 2 || revenue["Germany"] != 150 {
+		t.Errorf("Expected one Germany bucket with revenue 150, got %v", revenue)
+	}
+	if codes["USA"] != "US" {
+		t.Errorf("Expected USA to keep its spelling and code, got %v", codes)
+	}
+
+	report := processor.GetDashboardData().Report
+	if len(report.UnknownCountries) != 1 || report.UnknownCountries["USA"] != 1 {
+		t.Errorf("Expected USA counted as unknown, got %v", report.UnknownCountries)
+	}
+}
+
+func TestTitleCase(t *testing.T) {
+	tests := map[string]string{
+		"europe":          "Europe",
+		"NORTH AMERICA":   "North America",
+		"asia-pacific":    "Asia-Pacific",
+		"middle east/AFR": "Middle East/Afr",
+		"":                "",
+	}
+	for input, want := range tests {
+		if got := titleCase(input); got != want {
+			t.Errorf("Expected titleCase(%q) to be %q, got %q", input, want, got)
+		}
+	}
+}
+
+func TestLoadAliases(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "aliases.json")
+	if err := os.WriteFile(path, []byte(`{"EMEA": "Europe", "n.  america": "North America"}`), 0644); err != nil {
+		t.Fatalf("Failed to write aliases file: %v", err)
+	}
+
+	aliases, err := LoadAliases(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for input, want := range map[string]string{"emea": "Europe", "N. America": "North America", "europe": "Europe"} {
+		if got, ok := aliases.Resolve(input); !ok || got != want {
+			t.Errorf("Expected %q to resolve to %q, got %q (%v)", input, want, got, ok)
+		}
+	}
+	if _, ok := aliases.Resolve("Asia"); ok {
+		t.Error("Expected Asia to be unknown")
+	}
+
+	invalid := map[string]string{
+		"bad.json":       `{"EMEA": `,
+		"empty.json":     `{" ": "Europe"}`,
+		"canonical.json": `{"EMEA": ""}`,
+	}
+	for name, content := range invalid {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write aliases file: %v", err)
+		}
+		if _, err := LoadAliases(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := LoadAliases(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
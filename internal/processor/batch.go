@@ -0,0 +1,90 @@
+package processor
+
+import (
+	"sync"
+	"time"
+
+	"abt-analytics-dashboard/internal/metrics"
+	"abt-analytics-dashboard/internal/models"
+)
+
+// DefaultBatchSize is the number of transactions the reader sends to the
+// aggregation workers in one channel operation
+const DefaultBatchSize = 500
+
+// batchPool recycles the transaction slices passed from the reader to the
+// workers, so that a run allocates about as many batches as are in flight
+// rather than one per batch read
+type batchPool struct {
+	size int
+	pool sync.Pool
+}
+
+func newBatchPool(size int) *batchPool {
+	return &batchPool{size: size}
+}
+
+// get returns an empty batch with room for size transactions
+func (b *batchPool) get() []models.Transaction {
+	if batch, ok := b.pool.Get().(*[]models.Transaction); ok {
+		return (*batch)[:0]
+	}
+	return make([]models.Transaction, 0, b.size)
+}
+
+// put returns a batch the workers are done with
+func (b *batchPool) put(batch []models.Transaction) {
+	clear(batch)
+	batch = batch[:0]
+	b.pool.Put(&batch)
+}
+
+// queueBatches returns the capacity, in batches, of a queue holding at most
+// bufferSize transactions; it holds at least one batch
+func queueBatches(bufferSize, batchSize int) int {
+	if n := bufferSize / batchSize; n > 0 {
+		return n
+	}
+	return 1
+}
+
+// batchSender collects the transactions read into batches and sends each
+// full batch to the workers. Batches may span the files of a run. When
+// parse is set, it observes how long each batch took to fill.
+type batchSender struct {
+	ch    chan<- []models.Transaction
+	pool  *batchPool
+	batch []models.Transaction
+
+	parse   *metrics.Histogram
+	started time.Time
+}
+
+func newBatchSender(ch chan<- []models.Transaction, pool *batchPool) *batchSender {
+	return &batchSender{ch: ch, pool: pool}
+}
+
+// send adds a transaction to the current batch, sending the batch once it
+// is full
+func (s *batchSender) send(transaction models.Transaction) {
+	if s.batch == nil {
+		s.batch = s.pool.get()
+		if s.parse != nil {
+			s.started = time.Now()
+		}
+	}
+	s.batch = append(s.batch, transaction)
+	if len(s.batch) >= s.pool.size {
+		s.flush()
+	}
+}
+
+// flush sends the current batch, if it holds any transactions
+func (s *batchSender) flush() {
+	if len(s.batch) > 0 {
+		// Time spent waiting for the workers is not parsing
+		s.parse.ObserveDuration(s.started)
+		s.ch <- s.batch
+		s.batch = nil
+	}
+}
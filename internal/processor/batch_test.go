@@ -0,0 +1,163 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// batchTestCSV returns rows transactions spread over countries, regions,
+// products, users and days. Prices are multiples of 0.25, so sums are exact
+// whatever order the workers add them in.
+func batchTestCSV(rows int) string {
+	countries := []string{"USA", "Germany", "Japan", "Brazil", "India"}
+	regions := []string{"North America", "Europe", "Asia Pacific", "Latin America"}
+
+	var b strings.Builder
+	b.WriteString("transaction_id,transaction_date,user_id,country,region,product_name,category,price,quantity,total_price\n")
+	for i := 0; i < rows; i++ {
+		quantity := i%3 + 1
+		price := float64(i%40+1) * 0.25
+		fmt.Fprintf(&b, "T%06d,2024-%02d-%02d,U%03d,%s,%s,Product %d,Category %d,%.2f,%d,%.2f\n",
+			i, i%12+1, i%28+1, i%150, countries[i%len(countries)], regions[i%len(regions)],
+			i%40, i%6, price, quantity, price*float64(quantity))
+	}
+	return b.String()
+}
+
+// batchParityView keeps the parts of the data that do not depend on map
+// iteration order or timing, keyed so that rankings with ties compare equal
+type batchParityView struct {
+	Rows              int
+	CountryRevenues   map[string]models.CountryRevenue
+	TopRegions        map[string]models.RegionRevenue
+	MonthlySales      []models.MonthlySales
+	WeeklySales       []models.WeeklySales
+	Summary           models.Summary
+	Dimensions        models.Dimensions
+	DistinctProducts  int
+	DistinctCountries int
+	DistinctUsers     int
+	WorkerRowsTotal   int64
+}
+
+func newBatchParityView(data *models.DashboardData) batchParityView {
+	view := batchParityView{
+		Rows:              data.Report.Rows,
+		CountryRevenues:   make(map[string]models.CountryRevenue),
+		TopRegions:        make(map[string]models.RegionRevenue),
+		MonthlySales:      data.MonthlySales,
+		WeeklySales:       data.WeeklySales,
+		Summary:           data.Summary,
+		Dimensions:        data.Dimensions,
+		DistinctProducts:  data.DistinctProducts,
+		DistinctCountries: data.DistinctCountries,
+		DistinctUsers:     data.DistinctUsers,
+		WorkerRowsTotal:   data.Report.Pipeline.RowsProcessed,
+	}
+	for _, revenue := range data.CountryRevenues {
+		view.CountryRevenues[revenue.Country+"|"+revenue.ProductName+"|"+revenue.Currency] = revenue
+	}
+	for _, region := range data.TopRegions {
+		view.TopRegions[region.Region] = region
+	}
+	return view
+}
+
+func TestBatchSizeParity(t *testing.T) {
+	// Two files whose row counts are not multiples of the batch size, so
+	// batches span files and the last one is partial
+	dir := t.TempDir()
+	lines := strings.SplitAfter(batchTestCSV(2503), "\n")
+	parts := map[string]string{
+		"part1.csv": strings.Join(lines[:1201], ""),
+		"part2.csv": lines[0] + strings.Join(lines[1201:], ""),
+	}
+	for name, content := range parts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+	pattern := filepath.Join(dir, "*.csv")
+
+	views := make(map[int]batchParityView)
+	for _, batchSize := range []int{1, 7, DefaultBatchSize} {
+		processor := New(WithWorkers(4), WithBatchSize(batchSize))
+		if err := processor.ProcessDataset(pattern); err != nil {
+			t.Fatalf("Batch size %d: expected no error, got %v", batchSize, err)
+		}
+		data := processor.GetDashboardData()
+		if data.Report.Pipeline.BatchSize != batchSize {
+			t.Errorf("Expected batch size %d in the pipeline stats, got %d", batchSize, data.Report.Pipeline.BatchSize)
+		}
+		views[batchSize] = newBatchParityView(data)
+	}
+
+	want := views[1]
+	if want.Rows != 2503 || want.WorkerRowsTotal != 2503 {
+		t.Fatalf("Expected 2503 rows aggregated, got %d read and %d aggregated", want.Rows, want.WorkerRowsTotal)
+	}
+	for _, batchSize := range []int{7, DefaultBatchSize} {
+		if got := views[batchSize]; !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected batch size %d to match batch size 1, got %+v, want %+v", batchSize, got, want)
+		}
+	}
+}
+
+func TestQueueBatchesCapsBufferedRows(t *testing.T) {
+	testCases := []struct {
+		bufferSize, batchSize, want int
+	}{
+		{1000, 500, 2},
+		{1000, 1, 1000},
+		{1000, 300, 3},
+		{100, 500, 1},
+	}
+	for _, tc := range testCases {
+		if got := queueBatches(tc.bufferSize, tc.batchSize); got != tc.want {
+			t.Errorf("queueBatches(%d, %d): expected %d, got %d", tc.bufferSize, tc.batchSize, tc.want, got)
+		}
+	}
+}
+
+func TestBatchPoolReturnsEmptyBatches(t *testing.T) {
+	pool := newBatchPool(4)
+	batch := pool.get()
+	if len(batch) != 0 || cap(batch) != 4 {
+		t.Fatalf("Expected an empty batch with room for 4, got len %d cap %d", len(batch), cap(batch))
+	}
+
+	batch = append(batch, models.Transaction{TransactionID: "T1"})
+	pool.put(batch)
+	if reused := pool.get(); len(reused) != 0 || cap(reused) < 4 {
+		t.Errorf("Expected an empty batch back from the pool, got len %d cap %d", len(reused), cap(reused))
+	} else if reused[:1][0].TransactionID != "" {
+		t.Error("Expected returned batches to be cleared")
+	}
+}
+
+// BenchmarkProcessBatchSize processes the same 200k rows sending one
+// transaction per channel operation and in batches of the default size
+func BenchmarkProcessBatchSize(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "bench.csv")
+	if err := os.WriteFile(path, []byte(batchTestCSV(200000)), 0644); err != nil {
+		b.Fatalf("Failed to write benchmark file: %v", err)
+	}
+
+	for _, batchSize := range []int{1, DefaultBatchSize} {
+		b.Run(fmt.Sprintf("batch=%d", batchSize), func(b *testing.B) {
+			processor := New(WithBatchSize(batchSize))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := processor.ProcessDataset(path); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
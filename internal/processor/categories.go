@@ -0,0 +1,66 @@
+package processor
+
+import (
+	"sort"
+	"strings"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+// categoryProductLimit bounds how many products are kept per category
+const categoryProductLimit = 50
+
+// categoryKey folds a category name for case-insensitive grouping and lookup
+func categoryKey(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// sortCategoryProducts ranks the products within each category by purchase
+// count, then units sold and name, keeping only the top limit entries per
+// category
+func sortCategoryProducts(categoryProductMap map[string]map[string]*models.CategoryProduct, limit int) map[string][]models.CategoryProduct {
+	result := make(map[string][]models.CategoryProduct, len(categoryProductMap))
+	for category, productMap := range categoryProductMap {
+		products := make([]models.CategoryProduct, 0, len(productMap))
+		for _, product := range productMap {
+			products = append(products, *product)
+		}
+
+		sort.Slice(products, func(i, j int) bool {
+			a, b := products[i], products[j]
+			if a.PurchaseCount != b.PurchaseCount {
+				return a.PurchaseCount > b.PurchaseCount
+			}
+			if a.UnitsSold != b.UnitsSold {
+				return a.UnitsSold > b.UnitsSold
+			}
+			return a.ProductName < b.ProductName
+		})
+
+		if len(products) > limit {
+			products = products[:limit]
+		}
+		for i := range products {
+			products[i].Rank = i + 1
+		}
+		result[category] = products
+	}
+
+	return result
+}
+
+// GetCategoryProducts returns up to limit top products of a category,
+// matching the category name case-insensitively. The second return value
+// is false when the category is unknown.
+func (p *Processor) GetCategoryProducts(category string, limit int) ([]models.CategoryProduct, bool) {
+	data := p.data.Load()
+
+	products, ok := data.CategoryProducts[categoryKey(category)]
+	if !ok {
+		return nil, false
+	}
+	if limit > 0 && len(products) > limit {
+		products = products[:limit]
+	}
+	return products, true
+}
@@ -0,0 +1,146 @@
+package processor
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+const categoryFixture = `transaction_id,product_name,category,quantity,total_price
+TXN001,Laptop,Computers,1,1200
+TXN002,Mouse,computers,3,60
+TXN003,Mouse,COMPUTERS,1,20
+TXN004,Keyboard,Computers,2,100
+TXN005,Laptop,Computers,1,1100
+TXN006,Headphones,Audio,1,150
+TXN007,Speakers,audio,2,300
+TXN008,Headphones, Audio ,4,600
+TXN009,Monitor,Computers,1,300
+TXN010,Cable,,5,25
+`
+
+// manualCategoryRanking aggregates the fixture rows of category independently
+// of the processor and ranks them the same way
+func manualCategoryRanking(t *testing.T, fixture, category string) []models.CategoryProduct {
+	t.Helper()
+
+	byName := make(map[string]*models.CategoryProduct)
+	lines := strings.Split(strings.TrimSpace(fixture), "\n")
+	for _, line := range lines[1:] {
+		fields := strings.Split(line, ",")
+		if !strings.EqualFold(strings.TrimSpace(fields[2]), category) {
+			continue
+		}
+		quantity, err := strconv.Atoi(fields[3])
+		if err != nil {
+			t.Fatalf("Invalid fixture quantity %q", fields[3])
+		}
+		price, err := strconv.ParseFloat(fields[4], 64)
+		if err != nil {
+			t.Fatalf("Invalid fixture price %q", fields[4])
+		}
+
+		product, ok := byName[fields[1]]
+		if !ok {
+			product = &models.CategoryProduct{ProductName: fields[1]}
+			byName[fields[1]] = product
+		}
+		product.PurchaseCount++
+		product.UnitsSold += quantity
+		product.TotalRevenue += price
+	}
+
+	ranking := make([]models.CategoryProduct, 0, len(byName))
+	for _, product := range byName {
+		ranking = append(ranking, *product)
+	}
+	sort.Slice(ranking, func(i, j int) bool {
+		if ranking[i].PurchaseCount != ranking[j].PurchaseCount {
+			return ranking[i].PurchaseCount > ranking[j].PurchaseCount
+		}
+		if ranking[i].UnitsSold != ranking[j].UnitsSold {
+			return ranking[i].UnitsSold > ranking[j].UnitsSold
+		}
+		return ranking[i].ProductName < ranking[j].ProductName
+	})
+	for i := range ranking {
+		ranking[i].Rank = i + 1
+	}
+	return ranking
+}
+
+func TestCategoryProductsMatchManualAggregation(t *testing.T) {
+	path := writeTestFile(t, "categories.csv", categoryFixture)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, category := range []string{"Computers", "Audio"} {
+		expected := manualCategoryRanking(t, categoryFixture, category)
+
+		got, ok := processor.GetCategoryProducts(category, 0)
+		if !ok {
+			t.Fatalf("Expected category %s to exist", category)
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("Expected %d products in %s, got %+v", len(expected), category, got)
+		}
+		for i, want := range expected {
+			if got[i] != want {
+				t.Errorf("Expected %s product %d to be %+v, got %+v", category, i, want, got[i])
+			}
+		}
+	}
+}
+
+func TestGetCategoryProductsCaseInsensitiveAndLimit(t *testing.T) {
+	path := writeTestFile(t, "categories.csv", categoryFixture)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	products, ok := processor.GetCategoryProducts("cOmPuTeRs", 2)
+	if !ok {
+		t.Fatal("Expected case-insensitive category lookup to succeed")
+	}
+	if len(products) != 2 {
+		t.Fatalf("Expected 2 products (limit), got %d", len(products))
+	}
+	if products[0].ProductName != "Mouse" || products[1].ProductName != "Laptop" {
+		t.Errorf("Expected Mouse then Laptop, got %+v", products)
+	}
+
+	if _, ok := processor.GetCategoryProducts("Garden", 10); ok {
+		t.Error("Expected unknown category to be reported as missing")
+	}
+	if _, ok := processor.GetCategoryProducts("", 10); ok {
+		t.Error("Expected rows without a category to be left out of the rankings")
+	}
+}
+
+func TestSampleDataHasMultipleCategories(t *testing.T) {
+	processor := New()
+	processor.LoadSampleData()
+
+	data := processor.GetDashboardData()
+	if len(data.CategoryProducts) < 2 {
+		t.Fatalf("Expected sample data to cover multiple categories, got %d", len(data.CategoryProducts))
+	}
+	for category, products := range data.CategoryProducts {
+		if len(products) == 0 {
+			t.Errorf("Expected category %s to have products", category)
+		}
+		for i, product := range products {
+			if product.Rank != i+1 {
+				t.Errorf("Expected %s product %d to have rank %d, got %d", category, i, i+1, product.Rank)
+			}
+		}
+	}
+}
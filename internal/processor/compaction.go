@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"fmt"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+// SetOtherBucketThreshold folds country×product revenue rows earning less
+// than percent of the total revenue in their currency into one OtherBucket
+// row per country and currency when a dataset is processed. Totals,
+// transaction counts and items sold are kept; only the product breakdown is
+// lost. Zero, the default, disables folding.
+func (p *Processor) SetOtherBucketThreshold(percent float64) {
+	p.otherBucketThreshold = percent
+}
+
+// foldSmallCountryRevenues merges the rows of countryMap whose revenue is
+// below percent of the total revenue in their currency into an OtherBucket
+// product of their country and currency, returning how many rows were
+// folded. Each currency has its own cutoff, since unconverted amounts in
+// different currencies cannot be compared. A country with a single small
+// row keeps it as is, since folding it would save nothing.
+func foldSmallCountryRevenues(countryMap map[string]*models.CountryRevenue, percent float64) int {
+	if percent <= 0 {
+		return 0
+	}
+
+	totals := make(map[string]float64)
+	for _, row := range countryMap {
+		totals[row.Currency] += row.TotalRevenue
+	}
+
+	// Group the small rows by their country and currency
+	small := make(map[string][]string)
+	for key, row := range countryMap {
+		if row.TotalRevenue < totals[row.Currency]*percent/100 && row.ProductName != OtherBucket {
+			group := otherBucketKey(row.Country, row.Currency)
+			small[group] = append(small[group], key)
+		}
+	}
+
+	folded := 0
+	for group, keys := range small {
+		other, exists := countryMap[group]
+		if !exists && len(keys) < 2 {
+			continue
+		}
+		if !exists {
+			first := countryMap[keys[0]]
+			other = &models.CountryRevenue{Country: first.Country, ProductName: OtherBucket, Currency: first.Currency}
+			countryMap[group] = other
+		}
+		for _, key := range keys {
+			row := countryMap[key]
+			other.TotalRevenue += row.TotalRevenue
+			other.TransactionCount += row.TransactionCount
+			other.ItemsSold += row.ItemsSold
+			delete(countryMap, key)
+			folded++
+		}
+	}
+	return folded
+}
+
+// otherBucketKey is the countryMap key of a country's OtherBucket row
+func otherBucketKey(country, currency string) string {
+	return fmt.Sprintf("%s-%s-%s", country, OtherBucket, currency)
+}
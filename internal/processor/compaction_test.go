@@ -0,0 +1,178 @@
+package processor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+const compactionCSV = "product_name,quantity,total_price,country\n" +
+	"Laptop,1,4500,USA\n" +
+	"Laptop,2,4500,USA\n" +
+	"Cable,1,1,USA\n" +
+	"Plug,2,2,USA\n" +
+	"Strap,3,3,USA\n" +
+	"Phone,1,988,UK\n" +
+	"Case,1,2,UK\n" +
+	"Pen,1,3,Germany\n" +
+	"Clip,4,2,Germany\n"
+
+// processCompaction processes compactionCSV with the given threshold
+func processCompaction(t *testing.T, threshold float64) *models.DashboardData {
+	t.Helper()
+
+	processor := New()
+	processor.SetOtherBucketThreshold(threshold)
+	if err := processor.ProcessDataset(writeTestFile(t, "compaction.csv", compactionCSV)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return processor.GetDashboardData()
+}
+
+// countryProducts maps each country to its products' revenue rows
+func countryProducts(data *models.DashboardData) map[string]map[string]models.CountryRevenue {
+	products := make(map[string]map[string]models.CountryRevenue)
+	for _, row := range data.CountryRevenues {
+		if products[row.Country] == nil {
+			products[row.Country] = make(map[string]models.CountryRevenue)
+		}
+		products[row.Country][row.ProductName] = row
+	}
+	return products
+}
+
+func TestOtherBucketThresholdFoldsSmallRows(t *testing.T) {
+	plain := processCompaction(t, 0)
+	folded := processCompaction(t, 1) // rows below 100.01 of 10001
+
+	if plain.Report.FoldedRows != 0 || len(plain.CountryRevenues) != 8 {
+		t.Errorf("Expected no folding by default, got %d folded and %d rows", plain.Report.FoldedRows, len(plain.CountryRevenues))
+	}
+	if folded.Report.FoldedRows != 5 || folded.Report.OtherBucketThreshold != 1 {
+		t.Errorf("Expected 5 folded rows at a 1%% threshold, got %d (threshold %g)", folded.Report.FoldedRows, folded.Report.OtherBucketThreshold)
+	}
+
+	products := countryProducts(folded)
+	usa := products["USA"]
+	if len(usa) != 2 || usa["Laptop"].TotalRevenue != 9000 {
+		t.Errorf("Expected USA to keep Laptop and one Other row, got %+v", usa)
+	}
+	if other := usa[OtherBucket]; other.TotalRevenue != 6 || other.TransactionCount != 3 || other.ItemsSold != 6 || other.AverageOrderValue != 2 {
+		t.Errorf("Expected USA Other to hold 6 revenue over 3 transactions and 6 items, got %+v", other)
+	}
+	if _, ok := products["UK"]["Case"]; !ok || len(products["UK"]) != 2 {
+		t.Errorf("Expected a single small UK row to be kept, got %+v", products["UK"])
+	}
+	if germany := products["Germany"]; len(germany) != 1 || germany[OtherBucket].TotalRevenue != 5 {
+		t.Errorf("Expected Germany's rows to be folded into Other, got %+v", germany)
+	}
+}
+
+func TestOtherBucketThresholdPreservesTotals(t *testing.T) {
+	plain := processCompaction(t, 0)
+	folded := processCompaction(t, 1)
+
+	type totals struct {
+		revenue      float64
+		transactions int
+		items        int
+	}
+	sum := func(rows []models.CountryRevenue) map[string]totals {
+		result := make(map[string]totals)
+		for _, row := range rows {
+			t := result[row.Country]
+			t.revenue += row.TotalRevenue
+			t.transactions += row.TransactionCount
+			t.items += row.ItemsSold
+			result[row.Country] = t
+		}
+		return result
+	}
+	if want, got := sum(plain.CountryRevenues), sum(folded.CountryRevenues); !reflect.DeepEqual(want, got) {
+		t.Errorf("Expected per-country totals %+v, got %+v", want, got)
+	}
+
+	for i, want := range plain.CountrySummaries {
+		got := folded.CountrySummaries[i]
+		if got.Country != want.Country || got.TotalRevenue != want.TotalRevenue ||
+			got.TransactionCount != want.TransactionCount || got.ItemsSold != want.ItemsSold {
+			t.Errorf("Expected summary %+v, got %+v", want, got)
+		}
+	}
+
+	summaries := make(map[string]models.CountrySummary)
+	for _, summary := range folded.CountrySummaries {
+		summaries[summary.Country] = summary
+	}
+	if best := summaries["USA"].BestProductName; best != "Laptop" {
+		t.Errorf("Expected Laptop to stay the USA best seller, got %s", best)
+	}
+	if best := summaries["Germany"].BestProductName; best != OtherBucket {
+		t.Errorf("Expected Other as the Germany best seller when nothing else sold, got %s", best)
+	}
+}
+
+func TestFoldSmallCountryRevenuesMergesIntoExistingOther(t *testing.T) {
+	countryMap := map[string]*models.CountryRevenue{
+		"USA-Laptop-USD":             {Country: "USA", ProductName: "Laptop", Currency: "USD", TotalRevenue: 1000, TransactionCount: 1},
+		"USA-Cable-USD":              {Country: "USA", ProductName: "Cable", Currency: "USD", TotalRevenue: 1, TransactionCount: 1},
+		otherBucketKey("USA", "USD"): {Country: "USA", ProductName: OtherBucket, Currency: "USD", TotalRevenue: 2, TransactionCount: 2},
+		"USA-Plug-EUR":               {Country: "USA", ProductName: "Plug", Currency: "EUR", TotalRevenue: 1, TransactionCount: 1},
+	}
+
+	if folded := foldSmallCountryRevenues(countryMap, 10); folded != 1 {
+		t.Errorf("Expected 1 folded row, got %d", folded)
+	}
+	if other := countryMap[otherBucketKey("USA", "USD")]; other.TotalRevenue != 3 || other.TransactionCount != 3 {
+		t.Errorf("Expected Cable to join the existing Other row, got %+v", other)
+	}
+	if _, ok := countryMap["USA-Plug-EUR"]; !ok {
+		t.Error("Expected the only EUR row to be kept")
+	}
+}
+
+func TestOtherBucketThresholdPerCurrency(t *testing.T) {
+	// Against the total of both currencies, every USD row would fall under
+	// a 1% cutoff; against the USD total, only Cable and Plug do
+	processor := New()
+	processor.SetOtherBucketThreshold(1)
+	path := writeTestFile(t, "compaction-mixed.csv", `product_name,quantity,total_price,country,currency
+Laptop,1,900,USA,USD
+Phone,1,600,USA,USD
+Cable,1,5,USA,USD
+Plug,1,5,USA,USD
+Camera,1,995000,Japan,JPY
+Lens,1,40000,Japan,JPY
+Strap,1,500,Japan,JPY
+Cap,1,500,Japan,JPY
+`)
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data := processor.GetDashboardData()
+
+	products := countryProducts(data)
+	for _, name := range []string{"Laptop", "Phone", OtherBucket} {
+		if _, ok := products["USA"][name]; !ok {
+			t.Errorf("Expected a USA %s row, got %+v", name, products["USA"])
+		}
+	}
+	if other := products["USA"][OtherBucket]; other.TotalRevenue != 10 || other.Currency != "USD" {
+		t.Errorf("Expected Cable and Plug folded into a 10 USD Other row, got %+v", other)
+	}
+	if other := products["Japan"][OtherBucket]; other.TotalRevenue != 1000 || len(products["Japan"]) != 3 {
+		t.Errorf("Expected Strap and Cap folded into a 1000 JPY Other row, got %+v", products["Japan"])
+	}
+	if data.Report.FoldedRows != 4 {
+		t.Errorf("Expected 4 folded rows, got %d", data.Report.FoldedRows)
+	}
+	warned := false
+	for _, warning := range data.Report.Warnings {
+		warned = warned || strings.Contains(warning, "against the total revenue in their own currency")
+	}
+	if !warned {
+		t.Errorf("Expected a warning about folding mixed currencies, got %v", data.Report.Warnings)
+	}
+}
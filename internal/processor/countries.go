@@ -0,0 +1,195 @@
+package processor
+
+import (
+	"sort"
+	"strings"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+// summarizeCountries rolls the country×product revenue rows up to one entry
+// per country, ordered by total revenue, with each country's highest-revenue
+// product as its best seller. Revenue is also kept per currency. When the
+// rows are in more than one of currencies, the order amounts were
+// aggregated in, revenue in different currencies is never added together:
+// the totals are left at zero, and countries and products are compared by
+// their revenue in each currency in turn instead. Ties between products go
+// to the alphabetically first name, and ties between countries are ordered
+// by name, so the result does not depend on map order. Rows folded into
+// OtherBucket are only the best seller of a country that has nothing else.
+// Each country gets its ISO code from codes; names without one, other than
+// the overflow bucket, are returned in alphabetical order as unmapped.
+func summarizeCountries(revenues []models.CountryRevenue, codes map[string]string, currencies []string) ([]models.CountrySummary, []string) {
+	mixed := len(currencies) > 1
+	summaries := make(map[string]*models.CountrySummary)
+	products := make(map[string]map[string]*productRevenue)
+	for _, row := range revenues {
+		summary, exists := summaries[row.Country]
+		if !exists {
+			summary = &models.CountrySummary{
+				Country:     row.Country,
+				CountryCode: codes[normalizeCountryName(row.Country)],
+			}
+			summaries[row.Country] = summary
+			products[row.Country] = make(map[string]*productRevenue)
+		}
+		product, exists := products[row.Country][row.ProductName]
+		if !exists {
+			product = &productRevenue{}
+			products[row.Country][row.ProductName] = product
+		}
+		if !mixed {
+			summary.TotalRevenue += row.TotalRevenue
+			product.total += row.TotalRevenue
+		}
+		addCurrencyAmount(&summary.RevenueByCurrency, row.Currency, row.TotalRevenue)
+		addCurrencyAmount(&product.byCurrency, row.Currency, row.TotalRevenue)
+		summary.TransactionCount += row.TransactionCount
+		summary.ItemsSold += row.ItemsSold
+	}
+
+	result := make([]models.CountrySummary, 0, len(summaries))
+	unmapped := make(map[string]bool)
+	for country, summary := range summaries {
+		if summary.CountryCode == "" && strings.TrimSpace(country) != "" && country != OtherBucket {
+			unmapped[country] = true
+		}
+		var best *productRevenue
+		for product, revenue := range products[country] {
+			// Folded rows are not a product; they only win when nothing else sold
+			if product == OtherBucket && len(products[country]) > 1 {
+				continue
+			}
+			if c := revenue.compare(best, currencies); best == nil || c > 0 || c == 0 && product < summary.BestProductName {
+				best = revenue
+				summary.BestProductName = product
+			}
+		}
+		if best != nil {
+			summary.BestProductRevenue = best.total
+			summary.BestProductRevenueByCurrency = best.byCurrency
+		}
+		summary.ProductCount = len(products[country])
+		result = append(result, *summary)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalRevenue != result[j].TotalRevenue {
+			return result[i].TotalRevenue > result[j].TotalRevenue
+		}
+		if c := compareByCurrency(result[i].RevenueByCurrency, result[j].RevenueByCurrency, currencies); c != 0 {
+			return c > 0
+		}
+		return result[i].Country < result[j].Country
+	})
+
+	return result, sortedKeys(unmapped)
+}
+
+// productRevenue is a product's revenue within a country, in total and per
+// currency
+type productRevenue struct {
+	total      float64
+	byCurrency map[string]float64
+}
+
+// compare compares r with other by total revenue and then by the revenue in
+// each of currencies in turn, as compareByCurrency does. A nil other is
+// smaller than anything.
+func (r *productRevenue) compare(other *productRevenue, currencies []string) int {
+	switch {
+	case other == nil:
+		return 1
+	case r.total > other.total:
+		return 1
+	case r.total < other.total:
+		return -1
+	}
+	return compareByCurrency(r.byCurrency, other.byCurrency, currencies)
+}
+
+// GetCountrySummaries returns the per-country rollup, including each
+// country's best-selling product
+func (p *Processor) GetCountrySummaries() []models.CountrySummary {
+	data := p.data.Load()
+	return data.CountrySummaries
+}
+
+// GetCountrySummariesByName returns the per-country rollup in alphabetical
+// order of country name, ignoring case and accents
+func (p *Processor) GetCountrySummariesByName() []models.CountrySummary {
+	source := p.data.Load().CountrySummaries
+	summaries := make([]models.CountrySummary, len(source))
+	copy(summaries, source)
+
+	less := displayOrder()
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return less(summaries[i].Country, summaries[j].Country)
+	})
+	return summaries
+}
+
+// CountryTopProductsLimit is the number of products in a country's detail
+const CountryTopProductsLimit = 10
+
+// GetCountryDetail composes the rollup, top products and monthly trend of
+// one country. Products are ranked by revenue, compared per currency when
+// the dataset's amounts are in more than one, as the rollup is. The second
+// return value is false when the country is not in the dataset.
+func (p *Processor) GetCountryDetail(country string) (models.CountryDetail, bool) {
+	data := p.data.Load()
+
+	detail := models.CountryDetail{
+		TopProducts:  make([]models.CountryProduct, 0),
+		MonthlySales: make([]models.MonthlySales, 0),
+	}
+	found := false
+	for _, summary := range data.CountrySummaries {
+		if summary.Country == country {
+			detail.CountrySummary = summary
+			found = true
+			break
+		}
+	}
+	if !found {
+		return detail, false
+	}
+
+	mixed := len(data.CurrencyOrder) > 1
+	products := make(map[string]*models.CountryProduct)
+	for _, row := range countryRows(data, country) {
+		product, exists := products[row.ProductName]
+		if !exists {
+			product = &models.CountryProduct{ProductName: row.ProductName}
+			products[row.ProductName] = product
+		}
+		if !mixed {
+			product.TotalRevenue += row.TotalRevenue
+		}
+		addCurrencyAmount(&product.RevenueByCurrency, row.Currency, row.TotalRevenue)
+		product.TransactionCount += row.TransactionCount
+		product.ItemsSold += row.ItemsSold
+	}
+	for _, product := range products {
+		detail.TopProducts = append(detail.TopProducts, *product)
+	}
+	sort.Slice(detail.TopProducts, func(i, j int) bool {
+		a, b := detail.TopProducts[i], detail.TopProducts[j]
+		if a.TotalRevenue != b.TotalRevenue {
+			return a.TotalRevenue > b.TotalRevenue
+		}
+		if c := compareByCurrency(a.RevenueByCurrency, b.RevenueByCurrency, data.CurrencyOrder); c != 0 {
+			return c > 0
+		}
+		return a.ProductName < b.ProductName
+	})
+	if len(detail.TopProducts) > CountryTopProductsLimit {
+		detail.TopProducts = detail.TopProducts[:CountryTopProductsLimit]
+	}
+
+	if series, ok := data.CountryMonthlySales[country]; ok {
+		detail.MonthlySales = series
+		detail.MonthlySalesRetained = true
+	}
+	return detail, true
+}
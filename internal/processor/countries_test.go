@@ -0,0 +1,183 @@
+package processor
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+func TestCountrySummariesBestProduct(t *testing.T) {
+	// USA: Laptop 1500 over three rows beats a single 1200 Monitor sale.
+	// Germany: Keyboard and Mouse tie at 100, so Keyboard wins by name.
+	// Japan: one product only.
+	path := writeTestFile(t, "countries.csv", `transaction_id,country,product_name,quantity,total_price
+TXN001,USA,Laptop,1,500
+TXN002,USA,Laptop,1,500
+TXN003,USA,Laptop,1,500
+TXN004,USA,Monitor,1,1200
+TXN005,Germany,Mouse,1,60
+TXN006,Germany,Mouse,1,40
+TXN007,Germany,Keyboard,1,100
+TXN008,Japan,Camera,1,300
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []models.CountrySummary{
+		{Country: "USA", CountryCode: "US", TotalRevenue: 2700, TransactionCount: 4, ItemsSold: 4, ProductCount: 2, BestProductName: "Laptop", BestProductRevenue: 1500},
+		{Country: "Japan", CountryCode: "JP", TotalRevenue: 300, TransactionCount: 1, ItemsSold: 1, ProductCount: 1, BestProductName: "Camera", BestProductRevenue: 300},
+		{Country: "Germany", CountryCode: "DE", TotalRevenue: 200, TransactionCount: 3, ItemsSold: 3, ProductCount: 2, BestProductName: "Keyboard", BestProductRevenue: 100},
+	}
+	summaries := processor.GetCountrySummaries()
+	if len(summaries) != len(expected) {
+		t.Fatalf("Expected %d countries, got %+v", len(expected), summaries)
+	}
+	for i, want := range expected {
+		if !reflect.DeepEqual(summaries[i], want) {
+			t.Errorf("Expected summary %d to be %+v, got %+v", i, want, summaries[i])
+		}
+	}
+}
+
+func TestSummarizeCountriesTies(t *testing.T) {
+	// Equal totals order countries by name; a product sold in several rows
+	// is counted once with its revenue added up
+	summaries, _ := summarizeCountries([]models.CountryRevenue{
+		{Country: "Spain", ProductName: "Router", Currency: "EUR", TotalRevenue: 50, TransactionCount: 1},
+		{Country: "Spain", ProductName: OtherBucket, Currency: "EUR", TotalRevenue: 50, TransactionCount: 1},
+		{Country: "Spain", ProductName: "Webcam", Currency: "EUR", TotalRevenue: 50, TransactionCount: 1},
+		{Country: "France", ProductName: "Webcam", Currency: "EUR", TotalRevenue: 150, TransactionCount: 2},
+	}, defaultCountryCodes, []string{"EUR"})
+
+	if len(summaries) != 2 || summaries[0].Country != "France" || summaries[1].Country != "Spain" {
+		t.Fatalf("Expected France then Spain, got %+v", summaries)
+	}
+	if spain := summaries[1]; spain.BestProductName != "Router" || spain.BestProductRevenue != 50 || spain.ProductCount != 3 {
+		t.Errorf("Expected Router with 50 as Spain's best seller, got %+v", spain)
+	}
+}
+
+func TestSummarizeCountriesMixedCurrencies(t *testing.T) {
+	// Without conversion rates, 100 USD and 10000 JPY are not 10100 of
+	// anything: totals stay at zero and USD, with the most rows, ranks first
+	summaries, _ := summarizeCountries([]models.CountryRevenue{
+		{Country: "Japan", ProductName: "Camera", Currency: "USD", TotalRevenue: 100, TransactionCount: 1},
+		{Country: "Japan", ProductName: "Camera", Currency: "JPY", TotalRevenue: 10000, TransactionCount: 1},
+		{Country: "Japan", ProductName: "Lens", Currency: "USD", TotalRevenue: 150, TransactionCount: 1},
+		{Country: "Korea", ProductName: "Phone", Currency: "JPY", TotalRevenue: 90000, TransactionCount: 1},
+		{Country: "USA", ProductName: "Laptop", Currency: "USD", TotalRevenue: 200, TransactionCount: 2},
+	}, defaultCountryCodes, []string{"USD", "JPY"})
+
+	var order []string
+	for _, summary := range summaries {
+		order = append(order, summary.Country)
+		if summary.TotalRevenue != 0 || summary.BestProductRevenue != 0 {
+			t.Errorf("Expected no cross-currency totals, got %+v", summary)
+		}
+	}
+	if want := []string{"Japan", "USA", "Korea"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected countries ranked by USD then JPY revenue %v, got %v", want, order)
+	}
+
+	japan := summaries[0]
+	if want := map[string]float64{"USD": 250, "JPY": 10000}; !reflect.DeepEqual(japan.RevenueByCurrency, want) {
+		t.Errorf("Expected Japan's revenue per currency %v, got %v", want, japan.RevenueByCurrency)
+	}
+	if japan.BestProductName != "Lens" || !reflect.DeepEqual(japan.BestProductRevenueByCurrency, map[string]float64{"USD": 150}) {
+		t.Errorf("Expected Lens, first by USD revenue, as Japan's best seller, got %+v", japan)
+	}
+}
+
+func TestSampleDataCountrySummaries(t *testing.T) {
+	processor := New()
+	processor.LoadSampleData()
+
+	summaries := processor.GetCountrySummaries()
+	if len(summaries) == 0 {
+		t.Fatal("Expected country summaries for sample data")
+	}
+	for _, summary := range summaries {
+		if summary.BestProductName == "" || summary.BestProductRevenue <= 0 || summary.BestProductRevenue > summary.TotalRevenue {
+			t.Errorf("Expected a best seller within the country total, got %+v", summary)
+		}
+	}
+}
+
+func TestGetCountryDetail(t *testing.T) {
+	// Laptop is sold in two rows and is counted once; 11 products in total,
+	// so the cheapest one falls outside the top 10
+	csv := "transaction_id,country,product_name,quantity,total_price,currency,transaction_date\n" +
+		"TXN000,USA,Laptop,2,500,USD,2024-01-10\n" +
+		"TXN001,USA,Laptop,1,400,USD,2024-02-10\n"
+	for i := 1; i <= 10; i++ {
+		csv += fmt.Sprintf("TXN1%02d,USA,Product %02d,1,%d,USD,2024-02-10\n", i, i, i*10)
+	}
+	path := writeTestFile(t, "detail.csv", csv)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	detail, ok := processor.GetCountryDetail("USA")
+	if !ok {
+		t.Fatal("Expected USA to be found")
+	}
+	if detail.TransactionCount != 12 || detail.ItemsSold != 13 || detail.ProductCount != 11 {
+		t.Errorf("Expected 12 transactions, 13 items and 11 products, got %+v", detail.CountrySummary)
+	}
+	if len(detail.TopProducts) != CountryTopProductsLimit {
+		t.Fatalf("Expected %d top products, got %d", CountryTopProductsLimit, len(detail.TopProducts))
+	}
+	laptop := models.CountryProduct{ProductName: "Laptop", TotalRevenue: 900, TransactionCount: 2, ItemsSold: 3,
+		RevenueByCurrency: map[string]float64{"USD": 900}}
+	if !reflect.DeepEqual(detail.TopProducts[0], laptop) {
+		t.Errorf("Expected %+v first, got %+v", laptop, detail.TopProducts[0])
+	}
+	if last := detail.TopProducts[CountryTopProductsLimit-1]; last.ProductName != "Product 02" {
+		t.Errorf("Expected Product 02 last, got %s", last.ProductName)
+	}
+	if !detail.MonthlySalesRetained || len(detail.MonthlySales) != 2 {
+		t.Errorf("Expected 2 retained months, got %d", len(detail.MonthlySales))
+	}
+
+	if _, ok := processor.GetCountryDetail("Atlantis"); ok {
+		t.Error("Expected an unknown country not to be found")
+	}
+}
+
+func TestGetCountryDetailMixedCurrencies(t *testing.T) {
+	// The JPY camera would top a ranking on summed amounts; compared per
+	// currency, the USD lens comes first, as USD has the most rows
+	path := writeTestFile(t, "detail-mixed.csv", `transaction_id,country,product_name,quantity,total_price,currency
+TXN001,Japan,Camera,1,100,USD
+TXN002,Japan,Camera,1,10000,JPY
+TXN003,Japan,Lens,1,150,USD
+TXN004,USA,Laptop,1,900,USD
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	detail, ok := processor.GetCountryDetail("Japan")
+	if !ok {
+		t.Fatal("Expected Japan to be found")
+	}
+	if detail.TotalRevenue != 0 || detail.BestProductRevenue != 0 || detail.BestProductName != "Lens" {
+		t.Errorf("Expected no cross-currency totals and Lens as the best seller, got %+v", detail.CountrySummary)
+	}
+	want := []models.CountryProduct{
+		{ProductName: "Lens", TransactionCount: 1, ItemsSold: 1, RevenueByCurrency: map[string]float64{"USD": 150}},
+		{ProductName: "Camera", TransactionCount: 2, ItemsSold: 2, RevenueByCurrency: map[string]float64{"USD": 100, "JPY": 10000}},
+	}
+	if !reflect.DeepEqual(detail.TopProducts, want) {
+		t.Errorf("Expected top products %+v, got %+v", want, detail.TopProducts)
+	}
+}
@@ -0,0 +1,165 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// defaultCountryCodes maps common country names, and the abbreviations
+// datasets use for them, to ISO 3166-1 alpha-2 codes. Keys are lowercase.
+var defaultCountryCodes = map[string]string{
+	"argentina":                "AR",
+	"australia":                "AU",
+	"austria":                  "AT",
+	"bangladesh":               "BD",
+	"belgium":                  "BE",
+	"brazil":                   "BR",
+	"bulgaria":                 "BG",
+	"canada":                   "CA",
+	"chile":                    "CL",
+	"china":                    "CN",
+	"colombia":                 "CO",
+	"croatia":                  "HR",
+	"czech republic":           "CZ",
+	"czechia":                  "CZ",
+	"denmark":                  "DK",
+	"egypt":                    "EG",
+	"estonia":                  "EE",
+	"finland":                  "FI",
+	"france":                   "FR",
+	"germany":                  "DE",
+	"great britain":            "GB",
+	"greece":                   "GR",
+	"hong kong":                "HK",
+	"hungary":                  "HU",
+	"iceland":                  "IS",
+	"india":                    "IN",
+	"indonesia":                "ID",
+	"ireland":                  "IE",
+	"israel":                   "IL",
+	"italy":                    "IT",
+	"japan":                    "JP",
+	"kenya":                    "KE",
+	"latvia":                   "LV",
+	"lithuania":                "LT",
+	"luxembourg":               "LU",
+	"malaysia":                 "MY",
+	"mexico":                   "MX",
+	"morocco":                  "MA",
+	"netherlands":              "NL",
+	"new zealand":              "NZ",
+	"nigeria":                  "NG",
+	"norway":                   "NO",
+	"pakistan":                 "PK",
+	"peru":                     "PE",
+	"philippines":              "PH",
+	"poland":                   "PL",
+	"portugal":                 "PT",
+	"qatar":                    "QA",
+	"romania":                  "RO",
+	"russia":                   "RU",
+	"saudi arabia":             "SA",
+	"singapore":                "SG",
+	"slovakia":                 "SK",
+	"slovenia":                 "SI",
+	"south africa":             "ZA",
+	"south korea":              "KR",
+	"korea":                    "KR",
+	"spain":                    "ES",
+	"sri lanka":                "LK",
+	"sweden":                   "SE",
+	"switzerland":              "CH",
+	"taiwan":                   "TW",
+	"thailand":                 "TH",
+	"turkey":                   "TR",
+	"uae":                      "AE",
+	"uk":                       "GB",
+	"ukraine":                  "UA",
+	"united arab emirates":     "AE",
+	"united kingdom":           "GB",
+	"united states":            "US",
+	"united states of america": "US",
+	"us":                       "US",
+	"usa":                      "US",
+	"vietnam":                  "VN",
+}
+
+// normalizeCountryName returns the key country names are matched on, so
+// that lookups ignore case and surrounding whitespace
+func normalizeCountryName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// LoadCountryCodes reads extra country name to ISO 3166-1 alpha-2 code
+// mappings from a JSON file of the form {"Côte d'Ivoire": "CI"}
+func LoadCountryCodes(filePath string) (map[string]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read country codes file: %w", err)
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse country codes file: %w", err)
+	}
+
+	codes := make(map[string]string, len(raw))
+	for name, code := range raw {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+			return nil, fmt.Errorf("invalid country code %q for %s", code, name)
+		}
+		if normalizeCountryName(name) == "" {
+			return nil, fmt.Errorf("country codes file contains an empty country name")
+		}
+		codes[name] = code
+	}
+
+	return codes, nil
+}
+
+// SetCountryCodes adds country name mappings on top of the built-in ones,
+// replacing built-in codes for the same names. Passing nil restores the
+// built-in mappings.
+func (p *Processor) SetCountryCodes(codes map[string]string) {
+	if codes == nil {
+		p.countryCodes = nil
+		return
+	}
+
+	merged := make(map[string]string, len(defaultCountryCodes)+len(codes))
+	for name, code := range defaultCountryCodes {
+		merged[name] = code
+	}
+	for name, code := range codes {
+		merged[normalizeCountryName(name)] = code
+	}
+	p.countryCodes = merged
+}
+
+// countryCodeTable returns the mappings used to look up country codes
+func (p *Processor) countryCodeTable() map[string]string {
+	if p.countryCodes == nil {
+		return defaultCountryCodes
+	}
+	return p.countryCodes
+}
+
+// unmappedCountriesWarning describes the countries that have no ISO code
+func unmappedCountriesWarning(unmapped []string) string {
+	return fmt.Sprintf("%d countries have no ISO country code and are shown without a flag: %s",
+		len(unmapped), strings.Join(unmapped, ", "))
+}
+
+// sortedKeys returns the keys of set in alphabetical order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
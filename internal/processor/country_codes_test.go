@@ -0,0 +1,108 @@
+package processor
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCountryCodesMapping(t *testing.T) {
+	// "usa" and " GERMANY " match case-insensitively; Atlantis is unmapped
+	path := writeTestFile(t, "codes.csv", `transaction_id,country,product_name,quantity,total_price
+TXN001,usa,Laptop,1,500
+TXN002, GERMANY ,Mouse,1,60
+TXN003,Atlantis,Camera,1,300
+TXN004,Atlantis,Camera,1,300
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	codes := make(map[string]string)
+	for _, summary := range processor.GetCountrySummaries() {
+		codes[summary.Country] = summary.CountryCode
+	}
+	if codes["usa"] != "US" || codes["GERMANY"] != "DE" {
+		t.Errorf("Expected US and DE for mapped names, got %v", codes)
+	}
+	if code, ok := codes["Atlantis"]; !ok || code != "" {
+		t.Errorf("Expected Atlantis without a code, got %q", code)
+	}
+
+	report := processor.GetDashboardData().Report
+	if len(report.UnmappedCountries) != 1 || report.UnmappedCountries[0] != "Atlantis" {
+		t.Errorf("Expected Atlantis to be reported as unmapped, got %v", report.UnmappedCountries)
+	}
+	if !containsWarning(report.Warnings, "Atlantis") {
+		t.Errorf("Expected a warning naming Atlantis, got %v", report.Warnings)
+	}
+}
+
+func TestSetCountryCodes(t *testing.T) {
+	path := writeTestFile(t, "codes.csv", `transaction_id,country,product_name,quantity,total_price
+TXN001,Atlantis,Camera,1,300
+TXN002,USA,Laptop,1,500
+`)
+	codes, err := LoadCountryCodes(writeTestFile(t, "codes.json", `{"ATLANTIS": "at", "USA": "UM"}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	processor := New()
+	processor.SetCountryCodes(codes)
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, summary := range processor.GetCountrySummaries() {
+		if summary.Country == "Atlantis" && summary.CountryCode != "AT" {
+			t.Errorf("Expected AT from the codes file, got %q", summary.CountryCode)
+		}
+		if summary.Country == "USA" && summary.CountryCode != "UM" {
+			t.Errorf("Expected the codes file to override USA, got %q", summary.CountryCode)
+		}
+	}
+	if unmapped := processor.GetDashboardData().Report.UnmappedCountries; len(unmapped) != 0 {
+		t.Errorf("Expected no unmapped countries, got %v", unmapped)
+	}
+	if defaultCountryCodes["usa"] != "US" {
+		t.Error("Expected the built-in mappings to be left unchanged")
+	}
+}
+
+func TestLoadCountryCodesErrors(t *testing.T) {
+	if _, err := LoadCountryCodes(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+	if _, err := LoadCountryCodes(writeTestFile(t, "bad.json", "not json")); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+	if _, err := LoadCountryCodes(writeTestFile(t, "long.json", `{"Atlantis": "ATL"}`)); err == nil {
+		t.Error("Expected error for a three-letter code")
+	}
+	if _, err := LoadCountryCodes(writeTestFile(t, "empty.json", `{" ": "AT"}`)); err == nil {
+		t.Error("Expected error for an empty country name")
+	}
+}
+
+func TestCountrySummariesByName(t *testing.T) {
+	path := writeTestFile(t, "names.csv", `transaction_id,country,product_name,quantity,total_price
+TXN001,japan,Camera,1,900
+TXN002,France,Laptop,1,500
+TXN003,Brazil,Mouse,1,100
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	summaries := processor.GetCountrySummariesByName()
+	if len(summaries) != 3 || summaries[0].Country != "Brazil" || summaries[1].Country != "France" || summaries[2].Country != "japan" {
+		t.Errorf("Expected Brazil, France, japan, got %+v", summaries)
+	}
+	if processor.GetCountrySummaries()[0].Country != "japan" {
+		t.Error("Expected the revenue ordering to be left unchanged")
+	}
+}
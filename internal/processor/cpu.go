@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// cgroupRoot is where the container's cgroup filesystem is mounted
+const cgroupRoot = "/sys/fs/cgroup"
+
+// quotaReader reports the CPU limit of the container the process runs in,
+// in CPUs. ok is false when no limit is set.
+type quotaReader interface {
+	CPUQuota() (quota float64, ok bool, err error)
+}
+
+// cgroupQuotaReader reads the CFS quota from cgroup v2 (cpu.max) or, failing
+// that, cgroup v1 (cpu/cpu.cfs_quota_us and cpu/cpu.cfs_period_us)
+type cgroupQuotaReader struct {
+	root string
+}
+
+func (r cgroupQuotaReader) CPUQuota() (float64, bool, error) {
+	data, err := os.ReadFile(filepath.Join(r.root, "cpu.max"))
+	if err == nil {
+		// "max 100000" when unlimited, otherwise "<quota> <period>"
+		fields := strings.Fields(string(data))
+		if len(fields) != 2 {
+			return 0, false, fmt.Errorf("unexpected cpu.max contents %q", data)
+		}
+		if fields[0] == "max" {
+			return 0, false, nil
+		}
+		return quotaFromStrings(fields[0], fields[1])
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return 0, false, err
+	}
+
+	quota, err := os.ReadFile(filepath.Join(r.root, "cpu", "cpu.cfs_quota_us"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	period, err := os.ReadFile(filepath.Join(r.root, "cpu", "cpu.cfs_period_us"))
+	if err != nil {
+		return 0, false, err
+	}
+	if strings.TrimSpace(string(quota)) == "-1" {
+		return 0, false, nil
+	}
+	return quotaFromStrings(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+}
+
+// quotaFromStrings divides a CFS quota by its period
+func quotaFromStrings(quota, period string) (float64, bool, error) {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid CPU quota %q: %w", quota, err)
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0, false, fmt.Errorf("invalid CPU period %q", period)
+	}
+	if q <= 0 {
+		return 0, false, nil
+	}
+	return q / p, true, nil
+}
+
+// defaultWorkers returns the CPU limit reported by reader, rounded down
+// and at least 1, or numCPU when no limit is set or it is higher
+func defaultWorkers(reader quotaReader, numCPU int) int {
+	quota, ok, err := reader.CPUQuota()
+	if err != nil {
+		log.Printf("Warning: could not read the CPU limit, using %d CPUs: %v", numCPU, err)
+		return numCPU
+	}
+	if !ok {
+		return numCPU
+	}
+
+	workers := int(quota)
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > numCPU {
+		workers = numCPU
+	}
+	log.Printf("Detected a CPU limit of %.2f CPUs; defaulting to %d workers", quota, workers)
+	return workers
+}
+
+// detectedWorkers reads the CPU limit once per process
+var detectedWorkers = sync.OnceValue(func() int {
+	return defaultWorkers(cgroupQuotaReader{root: cgroupRoot}, runtime.NumCPU())
+})
+
+// DefaultWorkers returns the number of aggregation workers used when none
+// is configured: the container CPU limit when one is set, otherwise
+// runtime.NumCPU()
+func DefaultWorkers() int {
+	return detectedWorkers()
+}
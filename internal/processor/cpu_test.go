@@ -0,0 +1,89 @@
+package processor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeQuotaReader reports a fixed CPU limit
+type fakeQuotaReader struct {
+	quota float64
+	ok    bool
+	err   error
+}
+
+func (f fakeQuotaReader) CPUQuota() (float64, bool, error) {
+	return f.quota, f.ok, f.err
+}
+
+func TestDefaultWorkers(t *testing.T) {
+	cases := []struct {
+		name     string
+		reader   fakeQuotaReader
+		expected int
+	}{
+		{"no limit", fakeQuotaReader{}, 64},
+		{"two CPUs", fakeQuotaReader{quota: 2, ok: true}, 2},
+		{"fractional limit rounds down", fakeQuotaReader{quota: 2.5, ok: true}, 2},
+		{"limit below one CPU", fakeQuotaReader{quota: 0.5, ok: true}, 1},
+		{"limit above the CPU count", fakeQuotaReader{quota: 128, ok: true}, 64},
+		{"unreadable limit", fakeQuotaReader{err: errors.New("permission denied")}, 64},
+	}
+
+	for _, tc := range cases {
+		if got := defaultWorkers(tc.reader, 64); got != tc.expected {
+			t.Errorf("%s: expected %d workers, got %d", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestCgroupQuotaReader(t *testing.T) {
+	writeCgroupFile := func(root, name, content string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	v2 := t.TempDir()
+	writeCgroupFile(v2, "cpu.max", "200000 100000\n")
+	if quota, ok, err := (cgroupQuotaReader{root: v2}).CPUQuota(); err != nil || !ok || quota != 2 {
+		t.Errorf("Expected a cgroup v2 limit of 2 CPUs, got %v %v %v", quota, ok, err)
+	}
+
+	unlimited := t.TempDir()
+	writeCgroupFile(unlimited, "cpu.max", "max 100000\n")
+	if _, ok, err := (cgroupQuotaReader{root: unlimited}).CPUQuota(); err != nil || ok {
+		t.Errorf("Expected no limit for max, got %v %v", ok, err)
+	}
+
+	v1 := t.TempDir()
+	writeCgroupFile(v1, "cpu/cpu.cfs_quota_us", "150000\n")
+	writeCgroupFile(v1, "cpu/cpu.cfs_period_us", "100000\n")
+	if quota, ok, err := (cgroupQuotaReader{root: v1}).CPUQuota(); err != nil || !ok || quota != 1.5 {
+		t.Errorf("Expected a cgroup v1 limit of 1.5 CPUs, got %v %v %v", quota, ok, err)
+	}
+
+	v1Unlimited := t.TempDir()
+	writeCgroupFile(v1Unlimited, "cpu/cpu.cfs_quota_us", "-1\n")
+	writeCgroupFile(v1Unlimited, "cpu/cpu.cfs_period_us", "100000\n")
+	if _, ok, err := (cgroupQuotaReader{root: v1Unlimited}).CPUQuota(); err != nil || ok {
+		t.Errorf("Expected no limit for a -1 quota, got %v %v", ok, err)
+	}
+
+	if _, ok, err := (cgroupQuotaReader{root: t.TempDir()}).CPUQuota(); err != nil || ok {
+		t.Errorf("Expected no limit without cgroup files, got %v %v", ok, err)
+	}
+
+	invalid := t.TempDir()
+	writeCgroupFile(invalid, "cpu.max", "lots\n")
+	if _, _, err := (cgroupQuotaReader{root: invalid}).CPUQuota(); err == nil {
+		t.Error("Expected error for malformed cpu.max")
+	}
+}
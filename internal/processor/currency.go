@@ -0,0 +1,237 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// mixedCurrency is the reporting currency used when amounts in several
+// currencies could not be normalized
+const mixedCurrency = "mixed"
+
+// ConversionRates holds exchange rates used to normalize amounts into a
+// single base currency. Each rate is the value of one unit of the currency
+// expressed in the base currency.
+type ConversionRates struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// LoadConversionRates reads conversion rates from a JSON file of the form
+// {"base": "USD", "rates": {"EUR": 1.08, "JPY": 0.0067}}
+func LoadConversionRates(filePath string) (*ConversionRates, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversion rates file: %w", err)
+	}
+
+	var raw ConversionRates
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse conversion rates file: %w", err)
+	}
+
+	if strings.TrimSpace(raw.Base) == "" {
+		return nil, fmt.Errorf("conversion rates file must specify a base currency")
+	}
+
+	rates := &ConversionRates{
+		Base:  normalizeCurrency(raw.Base),
+		Rates: make(map[string]float64, len(raw.Rates)),
+	}
+	for currency, rate := range raw.Rates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("invalid conversion rate %v for %s", rate, currency)
+		}
+		rates.Rates[normalizeCurrency(currency)] = rate
+	}
+
+	return rates, nil
+}
+
+// Convert converts an amount in the given currency into the base currency.
+// Amounts with no currency are assumed to already be in the base currency.
+// The second return value is false when no rate is known for the currency.
+func (r *ConversionRates) Convert(amount float64, currency string) (float64, bool) {
+	currency = normalizeCurrency(currency)
+	if currency == "" || currency == r.Base {
+		return amount, true
+	}
+
+	rate, ok := r.Rates[currency]
+	if !ok {
+		return amount, false
+	}
+	return amount * rate, true
+}
+
+// SetConversionRates configures the rates used to normalize revenue into a
+// single reporting currency. Passing nil disables conversion.
+func (p *Processor) SetConversionRates(rates *ConversionRates) {
+	p.rates = rates
+}
+
+// normalizeAmount returns the transaction total and the currency it should be
+// reported in, converting to the base currency when rates are configured
+func (p *Processor) normalizeAmount(transaction models.Transaction) (float64, string) {
+	currency := normalizeCurrency(transaction.Currency)
+	if p.rates == nil {
+		return transaction.TotalPrice, currency
+	}
+
+	if amount, ok := p.rates.Convert(transaction.TotalPrice, currency); ok {
+		return amount, p.rates.Base
+	}
+	return transaction.TotalPrice, currency
+}
+
+// currencyReport derives the reporting currency and any mixed-currency
+// warnings from the set of currencies seen in the dataset
+func (p *Processor) currencyReport(currencyMap map[string]int) (string, []string, []string) {
+	currencies := make([]string, 0, len(currencyMap))
+	for currency := range currencyMap {
+		if currency != "" {
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Strings(currencies)
+
+	warnings := make([]string, 0)
+
+	if p.rates != nil {
+		for _, currency := range currencies {
+			if currency == p.rates.Base {
+				continue
+			}
+			if _, ok := p.rates.Rates[currency]; !ok {
+				warnings = append(warnings, fmt.Sprintf(
+					"no conversion rate for %s (%d transactions); amounts left unconverted",
+					currency, currencyMap[currency]))
+			}
+		}
+		return p.rates.Base, currencies, warnings
+	}
+
+	// Without rates, amounts without a currency cannot be added to those in
+	// another one either
+	blank := currencyMap[""]
+	switch {
+	case len(currencies) == 0:
+		return "", currencies, warnings
+	case len(currencies) == 1 && blank == 0:
+		return currencies[0], currencies, warnings
+	case len(currencies) == 1:
+		warnings = append(warnings, fmt.Sprintf(
+			"%d transactions have no currency and no conversion rates were provided, so they are not added to the %s amounts; revenue is reported per currency",
+			blank, currencies[0]))
+		return mixedCurrency, currencies, warnings
+	default:
+		warnings = append(warnings, fmt.Sprintf(
+			"dataset contains multiple currencies (%s) and no conversion rates were provided; revenue is reported per currency",
+			strings.Join(currencies, ", ")))
+		return mixedCurrency, currencies, warnings
+	}
+}
+
+// normalizeCurrency canonicalizes a currency code
+func normalizeCurrency(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// addCurrencyAmount adds an amount to a per-currency total, allocating the
+// map on first use. Amounts without a currency are not tracked.
+func addCurrencyAmount(totals *map[string]float64, currency string, amount float64) {
+	if currency == "" {
+		return
+	}
+	if *totals == nil {
+		*totals = make(map[string]float64)
+	}
+	(*totals)[currency] += amount
+}
+
+// currencyOrder returns the currencies amounts were aggregated in, the one
+// with the most rows first and ties by code
+func currencyOrder(amountCurrencies map[string]int) []string {
+	currencies := make([]string, 0, len(amountCurrencies))
+	for currency := range amountCurrencies {
+		currencies = append(currencies, currency)
+	}
+	sort.Slice(currencies, func(i, j int) bool {
+		a, b := amountCurrencies[currencies[i]], amountCurrencies[currencies[j]]
+		if a != b {
+			return a > b
+		}
+		return currencies[i] < currencies[j]
+	})
+	return currencies
+}
+
+// compareByCurrency compares two per-currency amounts one currency at a
+// time, in the given order, returning a positive number when a is larger at
+// the first currency where they differ, a negative one when b is, and 0
+// when they are equal in every currency
+func compareByCurrency(a, b map[string]float64, currencies []string) int {
+	for _, currency := range currencies {
+		if x, y := a[currency], b[currency]; x != y {
+			if x > y {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// clearMixedTotals zeroes every aggregate total that adds amounts together
+// regardless of their currency, for a run whose amounts were aggregated in
+// more than one currency. Totals kept per currency, such as the country
+// revenue rows and the sales_by_currency maps, are left alone. The country
+// rollups built later from those rows, the summaries, country details,
+// nested country revenue and Other bucket folding, check the run's
+// currencies themselves.
+func clearMixedTotals(agg *aggregates) {
+	for _, month := range agg.monthMap {
+		month.TotalSales = 0
+	}
+	for _, months := range agg.countryMonthMap {
+		for _, month := range months {
+			month.TotalSales = 0
+		}
+	}
+	for _, week := range agg.weekMap {
+		week.TotalSales = 0
+	}
+	for _, day := range agg.dayMap {
+		day.Revenue = 0
+	}
+	for _, region := range agg.regionMap {
+		region.TotalRevenue = 0
+	}
+	for _, products := range agg.regionProductMap {
+		for _, product := range products {
+			product.TotalRevenue = 0
+		}
+	}
+	for _, products := range agg.categoryProductMap {
+		for _, product := range products {
+			product.TotalRevenue = 0
+		}
+	}
+	for _, customers := range agg.countryCustomerMap {
+		for _, customer := range customers {
+			customer.TotalRevenue = 0
+		}
+	}
+	for _, pressure := range agg.stockPressureMap {
+		pressure.Revenue = 0
+	}
+	for i := range agg.excluded {
+		agg.excluded[i].Revenue = 0
+	}
+	agg.unattributed.Country.Revenue = 0
+	agg.unattributed.Region.Revenue = 0
+}
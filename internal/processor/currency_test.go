@@ -0,0 +1,240 @@
+package processor
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+const mixedCurrencyCSV = `transaction_id,transaction_date,user_id,country,region,product_id,product_name,category,price,quantity,total_price,stock_quantity,added_date,currency
+TXN001,2024-01-15,USER001,Japan,Asia Pacific,PROD001,Laptop,Electronics,1000,1,1000,10,2024-01-01,USD
+TXN002,2024-01-16,USER002,Japan,Asia Pacific,PROD001,Laptop,Electronics,150000,1,150000,10,2024-01-01,JPY
+TXN003,2024-01-17,USER003,Japan,Asia Pacific,PROD001,Laptop,Electronics,1000,1,1000,10,2024-01-01,usd
+`
+
+func TestProcessDatasetKeepsCurrenciesSeparateWithoutRates(t *testing.T) {
+	path := writeTestFile(t, "mixed.csv", mixedCurrencyCSV)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	revenues := processor.GetCountryRevenues()
+	if len(revenues) != 2 {
+		t.Fatalf("Expected 2 country revenue rows (one per currency), got %d", len(revenues))
+	}
+
+	totals := make(map[string]float64)
+	for _, revenue := range revenues {
+		totals[revenue.Currency] = revenue.TotalRevenue
+	}
+	if totals["USD"] != 2000 {
+		t.Errorf("Expected USD revenue 2000, got %f", totals["USD"])
+	}
+	if totals["JPY"] != 150000 {
+		t.Errorf("Expected JPY revenue 150000, got %f", totals["JPY"])
+	}
+
+	data := processor.GetDashboardData()
+	if data.ReportingCurrency != mixedCurrency {
+		t.Errorf("Expected reporting currency '%s', got '%s'", mixedCurrency, data.ReportingCurrency)
+	}
+	if len(data.Report.Currencies) != 2 {
+		t.Errorf("Expected 2 currencies in report, got %v", data.Report.Currencies)
+	}
+	if len(data.Report.Warnings) != 1 {
+		t.Errorf("Expected 1 mixed-currency warning, got %v", data.Report.Warnings)
+	}
+
+	regions := processor.GetTopRegions()
+	if len(regions) != 1 {
+		t.Fatalf("Expected 1 region, got %d", len(regions))
+	}
+	if regions[0].RevenueByCurrency["JPY"] != 150000 || regions[0].RevenueByCurrency["USD"] != 2000 {
+		t.Errorf("Expected per-currency region totals, got %v", regions[0].RevenueByCurrency)
+	}
+	for _, region := range regions {
+		if region.TotalRevenue != 0 {
+			t.Errorf("Expected no mixed-currency total for %s, got %f", region.Region, region.TotalRevenue)
+		}
+	}
+
+	sales := processor.GetMonthlySales()
+	if len(sales) != 1 {
+		t.Fatalf("Expected 1 month, got %d", len(sales))
+	}
+	for _, month := range sales {
+		if month.TotalSales != 0 {
+			t.Errorf("Expected no mixed-currency total for %s, got %f", month.Month, month.TotalSales)
+		}
+	}
+	if sales[0].SalesByCurrency["JPY"] != 150000 || sales[0].SalesByCurrency["USD"] != 2000 {
+		t.Errorf("Expected per-currency monthly totals, got %v", sales[0].SalesByCurrency)
+	}
+	if !data.Report.MixedTotals {
+		t.Error("Expected the report to flag the mixed totals")
+	}
+}
+
+func TestTopRegionsRankedByCurrencyWhenMixed(t *testing.T) {
+	path := writeTestFile(t, "mixed.csv", mixedCurrencyCSV+
+		"TXN004,2024-01-18,USER004,Germany,Europe,PROD001,Laptop,Electronics,500,1,500,10,2024-01-01,USD\n"+
+		"TXN005,2024-01-19,USER005,Germany,Europe,PROD001,Laptop,Electronics,9000000,1,9000000,10,2024-01-01,JPY\n")
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	// USD has the most rows, so it decides the ranking despite Europe's
+	// larger JPY revenue
+	regions := processor.GetTopRegions()
+	if len(regions) != 2 || regions[0].Region != "Asia Pacific" || regions[1].Region != "Europe" {
+		t.Errorf("Expected Asia Pacific ranked by its USD revenue ahead of Europe, got %+v", regions)
+	}
+}
+
+func TestBlankCurrencyCountsAsItsOwn(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	processor := New(WithClock(func() time.Time { return now }))
+	processor.SetOtherBucketThreshold(1)
+	header := "transaction_id,country,region,product_name,quantity,total_price,currency\n"
+	first := writeTestFile(t, "eur.csv", header+
+		"T1,France,Europe,Laptop,1,1000,EUR\n"+
+		"T2,France,Europe,Mouse,1,40,EUR\n"+
+		"T3,Spain,Europe,Laptop,1,900,EUR\n")
+	if err := processor.ProcessDataset(first); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+	loaded := now
+
+	// Rows without a currency are not EUR; adding them to the EUR amounts
+	// would put Spain ahead of France and fold France's EUR rows
+	now = now.Add(time.Hour)
+	second := writeTestFile(t, "blank.csv", header+
+		"T1,France,Europe,Laptop,1,1000,EUR\n"+
+		"T2,France,Europe,Mouse,1,40,EUR\n"+
+		"T3,Spain,Europe,Laptop,1,900,EUR\n"+
+		"T4,Spain,Europe,Camera,1,500000,\n"+
+		"T5,France,Europe,Camera,1,100,\n")
+	if err := processor.ProcessDataset(second); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	data := processor.GetDashboardData()
+	if !data.Report.MixedTotals || data.ReportingCurrency != mixedCurrency {
+		t.Errorf("Expected blank and EUR amounts to be flagged as mixed, got %v and %q", data.Report.MixedTotals, data.ReportingCurrency)
+	}
+	if len(data.Report.Warnings) == 0 {
+		t.Error("Expected a warning about the transactions without a currency")
+	}
+	if data.Report.FoldedRows != 0 {
+		t.Errorf("Expected no EUR row under 1%% of the EUR total, got %d folded", data.Report.FoldedRows)
+	}
+
+	summaries := processor.GetCountrySummaries()
+	if len(summaries) != 2 || summaries[0].Country != "France" || summaries[1].Country != "Spain" {
+		t.Fatalf("Expected France ranked ahead of Spain by EUR revenue, got %+v", summaries)
+	}
+	for _, summary := range summaries {
+		if summary.TotalRevenue != 0 || summary.BestProductRevenue != 0 {
+			t.Errorf("Expected no cross-currency totals, got %+v", summary)
+		}
+	}
+	if want := map[string]float64{"EUR": 1040}; !reflect.DeepEqual(summaries[0].RevenueByCurrency, want) {
+		t.Errorf("Expected France's EUR revenue %v, got %v", want, summaries[0].RevenueByCurrency)
+	}
+
+	detail, ok := processor.GetCountryDetail("Spain")
+	if !ok || detail.TotalRevenue != 0 || detail.TopProducts[0].ProductName != "Laptop" || detail.TopProducts[0].TotalRevenue != 0 {
+		t.Errorf("Expected Spain's detail without cross-currency totals, Laptop first, got %+v", detail)
+	}
+
+	groups, _, _ := processor.QueryCountryRevenueGroups(models.CountryRevenueQuery{})
+	if len(groups) != 2 || groups[0].Country != "France" || groups[0].TotalRevenue != 0 || groups[1].TotalRevenue != 0 {
+		t.Errorf("Expected nested France then Spain without totals, got %+v", groups)
+	}
+
+	// Compared per currency, only the EUR revenue of both snapshots counts
+	diff, _, changed := processor.GetDashboardDiff(loaded)
+	if changed && len(diff.Countries) != 0 {
+		t.Errorf("Expected no EUR revenue change, got %+v", diff.Countries)
+	}
+}
+
+func TestProcessDatasetConvertsWithRates(t *testing.T) {
+	path := writeTestFile(t, "mixed.csv", mixedCurrencyCSV)
+	ratesPath := writeTestFile(t, "rates.json", `{"base": "usd", "rates": {"JPY": 0.01}}`)
+
+	rates, err := LoadConversionRates(ratesPath)
+	if err != nil {
+		t.Fatalf("Failed to load conversion rates: %v", err)
+	}
+
+	processor := New()
+	processor.SetConversionRates(rates)
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	revenues := processor.GetCountryRevenues()
+	if len(revenues) != 1 {
+		t.Fatalf("Expected 1 normalized country revenue row, got %d", len(revenues))
+	}
+	if revenues[0].Currency != "USD" {
+		t.Errorf("Expected currency 'USD', got '%s'", revenues[0].Currency)
+	}
+	if revenues[0].TotalRevenue != 3500 {
+		t.Errorf("Expected normalized revenue 3500, got %f", revenues[0].TotalRevenue)
+	}
+	if sales := processor.GetMonthlySales(); len(sales) != 1 || sales[0].TotalSales != 3500 {
+		t.Errorf("Expected normalized monthly sales of 3500, got %+v", sales)
+	}
+
+	data := processor.GetDashboardData()
+	if data.ReportingCurrency != "USD" {
+		t.Errorf("Expected reporting currency 'USD', got '%s'", data.ReportingCurrency)
+	}
+	if len(data.Report.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", data.Report.Warnings)
+	}
+}
+
+func TestProcessDatasetWarnsOnMissingRate(t *testing.T) {
+	path := writeTestFile(t, "mixed.csv", mixedCurrencyCSV)
+
+	processor := New()
+	processor.SetConversionRates(&ConversionRates{Base: "USD", Rates: map[string]float64{}})
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	if len(processor.GetCountryRevenues()) != 2 {
+		t.Errorf("Expected unconverted JPY revenue to stay in its own row")
+	}
+	if regions := processor.GetTopRegions(); len(regions) != 1 || regions[0].TotalRevenue != 0 {
+		t.Errorf("Expected no region total adding unconverted JPY to USD, got %+v", regions)
+	}
+	if len(processor.GetDashboardData().Report.Warnings) != 1 {
+		t.Errorf("Expected 1 missing-rate warning, got %v", processor.GetDashboardData().Report.Warnings)
+	}
+}
+
+func TestLoadConversionRatesErrors(t *testing.T) {
+	if _, err := LoadConversionRates(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+	if _, err := LoadConversionRates(writeTestFile(t, "bad.json", "not json")); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+	if _, err := LoadConversionRates(writeTestFile(t, "nobase.json", `{"rates": {"EUR": 1.1}}`)); err == nil {
+		t.Error("Expected error for missing base currency")
+	}
+	if _, err := LoadConversionRates(writeTestFile(t, "neg.json", `{"base": "USD", "rates": {"EUR": -1}}`)); err == nil {
+		t.Error("Expected error for non-positive rate")
+	}
+}
@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"sort"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+// CountryTopCustomersLimit is the number of users ranked per country by the
+// revenue of their transactions there
+const CountryTopCustomersLimit = 10
+
+// addCountryCustomer adds a transaction's revenue to its user's total in
+// its country. Rows without a user are left out. Callers must hold agg.mu.
+func addCountryCustomer(agg *aggregates, transaction models.Transaction, amount float64) {
+	if transaction.UserID == "" {
+		return
+	}
+
+	countryKey := cappedKey(agg, overflowCustomerCountries, agg.countryCustomerMap, transaction.Country)
+	customers, exists := agg.countryCustomerMap[countryKey]
+	if !exists {
+		customers = make(map[string]*models.CountryCustomer)
+		agg.countryCustomerMap[countryKey] = customers
+	}
+	userKey := cappedKey(agg, overflowCountryCustomers, customers, transaction.UserID)
+	customer, exists := customers[userKey]
+	if !exists {
+		customer = &models.CountryCustomer{UserID: userKey}
+		customers[userKey] = customer
+	}
+	customer.TotalRevenue += amount
+	customer.TransactionCount++
+	customer.ItemsSold += transaction.Quantity
+}
+
+// sortCountryCustomers ranks the users within each country by revenue,
+// keeping the top limit per country. Ties are ordered by user ID. Users
+// folded into OtherBucket by the key limit are not a customer and are
+// left out.
+func sortCountryCustomers(countryCustomerMap map[string]map[string]*models.CountryCustomer, limit int) map[string][]models.CountryCustomer {
+	result := make(map[string][]models.CountryCustomer, len(countryCustomerMap))
+	for country, customerMap := range countryCustomerMap {
+		customers := make([]models.CountryCustomer, 0, len(customerMap))
+		for userID, customer := range customerMap {
+			if userID != OtherBucket {
+				customers = append(customers, *customer)
+			}
+		}
+
+		sort.Slice(customers, func(i, j int) bool {
+			if customers[i].TotalRevenue != customers[j].TotalRevenue {
+				return customers[i].TotalRevenue > customers[j].TotalRevenue
+			}
+			return customers[i].UserID < customers[j].UserID
+		})
+
+		if len(customers) > limit {
+			customers = customers[:limit]
+		}
+		result[country] = customers
+	}
+	return result
+}
+
+// GetCountryTopCustomers returns the users who spent the most in a country,
+// highest revenue first. The second return value is false when the country
+// is not in the dataset; countries without user IDs have no customers.
+func (p *Processor) GetCountryTopCustomers(country string) ([]models.CountryCustomer, bool) {
+	data := p.data.Load()
+
+	for _, summary := range data.CountrySummaries {
+		if summary.Country == country {
+			customers, ok := data.CountryTopCustomers[country]
+			if !ok {
+				customers = make([]models.CountryCustomer, 0)
+			}
+			return customers, true
+		}
+	}
+	return nil, false
+}
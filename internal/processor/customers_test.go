@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCountryTopCustomers(t *testing.T) {
+	content := "transaction_id,user_id,country,product_name,quantity,total_price\n" +
+		"TXN001,U1,Germany,Laptop,1,500\n" +
+		"TXN002,U2,Germany,Mouse,2,40\n" +
+		"TXN003,U2,Germany,Monitor,1,300\n" +
+		"TXN004,U3,Germany,Cable,1,340\n" +
+		"TXN005,U1,Japan,Camera,1,50\n" +
+		"TXN006,,Japan,Lens,1,900\n"
+	path := writeTestFile(t, "sales.csv", content)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	germany, ok := processor.GetCountryTopCustomers("Germany")
+	if !ok || len(germany) != 3 {
+		t.Fatalf("Expected 3 German customers, got %+v (%v)", germany, ok)
+	}
+	// U2 and U3 tie on 340 and are ordered by user ID
+	order := []string{"U1", "U2", "U3"}
+	for i, customer := range germany {
+		if customer.UserID != order[i] {
+			t.Errorf("Expected %s at position %d, got %+v", order[i], i+1, germany)
+			break
+		}
+	}
+	if u2 := germany[1]; u2.TotalRevenue != 340 || u2.TransactionCount != 2 || u2.ItemsSold != 3 {
+		t.Errorf("Expected U2 with 340 over 2 transactions and 3 items, got %+v", u2)
+	}
+
+	// Users are ranked per country, and rows without a user are left out
+	japan, ok := processor.GetCountryTopCustomers("Japan")
+	if !ok || len(japan) != 1 || japan[0].UserID != "U1" || japan[0].TotalRevenue != 50 {
+		t.Errorf("Expected only U1 with 50 in Japan, got %+v", japan)
+	}
+
+	if _, ok := processor.GetCountryTopCustomers("Atlantis"); ok {
+		t.Error("Expected no customers for an unknown country")
+	}
+}
+
+func TestCountryTopCustomersBounded(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("transaction_id,user_id,country,product_name,quantity,total_price\n")
+	for i := 0; i < CountryTopCustomersLimit+5; i++ {
+		fmt.Fprintf(&b, "TXN%03d,U%02d,France,Laptop,1,%d\n", i, i, 100+i)
+	}
+	path := writeTestFile(t, "sales.csv", b.String())
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	customers, _ := processor.GetCountryTopCustomers("France")
+	if len(customers) != CountryTopCustomersLimit {
+		t.Fatalf("Expected %d customers, got %d", CountryTopCustomersLimit, len(customers))
+	}
+	if top := customers[0]; top.UserID != "U14" || top.TotalRevenue != 114 {
+		t.Errorf("Expected the biggest spender U14 first, got %+v", top)
+	}
+	if last := customers[len(customers)-1]; last.UserID != "U05" {
+		t.Errorf("Expected U05 to be the last one kept, got %+v", last)
+	}
+}
+
+func TestCountryTopCustomersSkipOverflow(t *testing.T) {
+	content := "transaction_id,user_id,country,product_name,quantity,total_price\n" +
+		"TXN001,U1,Germany,Laptop,1,10\n" +
+		"TXN002,U2,Germany,Laptop,1,20\n" +
+		"TXN003,U3,Germany,Laptop,1,900\n"
+	path := writeTestFile(t, "sales.csv", content)
+
+	processor := New()
+	processor.SetMaxAggregationKeys(2)
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	customers, _ := processor.GetCountryTopCustomers("Germany")
+	for _, customer := range customers {
+		if customer.UserID == OtherBucket {
+			t.Errorf("Expected the overflow bucket not to be ranked, got %+v", customers)
+		}
+	}
+	if overflow := processor.GetDashboardData().Report.Overflow[overflowCountryCustomers]; overflow != 1 {
+		t.Errorf("Expected 1 overflowing customer row, got %d", overflow)
+	}
+}
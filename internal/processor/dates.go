@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultDateFormats are the layouts tried for transaction_date and
+// added_date after any configured layouts
+var defaultDateFormats = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	time.RFC3339Nano,
+	"01/02/2006",
+	"01-02-2006",
+	"2006/01/02",
+}
+
+// SetDateFormats sets extra Go time layouts that are tried before the
+// built-in ones, so the dataset's own format matches on the first attempt
+func (p *Processor) SetDateFormats(layouts []string) {
+	p.dateFormats = layouts
+}
+
+// SetDataTimezone sets the zone dates are bucketed into months, weeks and
+// days in. Dates with an offset are converted to it and dates without one
+// are read in it; nil, the default, uses UTC.
+func (p *Processor) SetDataTimezone(loc *time.Location) {
+	p.dataTimezone = loc
+}
+
+// dataLocation returns the zone set with SetDataTimezone
+func (p *Processor) dataLocation() *time.Location {
+	if p.dataTimezone == nil {
+		return time.UTC
+	}
+	return p.dataTimezone
+}
+
+// parseDate parses a date column value like parseDateOffset, without
+// reporting whether it had an offset
+func (p *Processor) parseDate(value string) (time.Time, bool) {
+	date, _, ok := p.parseDateOffset(value)
+	return date, ok
+}
+
+// parseDateOffset parses a date column value using the configured layouts,
+// then Unix epoch seconds, then the default layouts, and returns it in the
+// data timezone. offset is set when the value carried its own UTC offset or
+// zone. ok is false when no format matches.
+func (p *Processor) parseDateOffset(value string) (date time.Time, offset bool, ok bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false, false
+	}
+	loc := p.dataLocation()
+
+	for _, layout := range p.dateFormats {
+		if date, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return date.In(loc), layoutHasZone(layout), true
+		}
+	}
+
+	if isDigits(value) {
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Unix(seconds, 0).In(loc), false, true
+		}
+	}
+
+	for _, layout := range defaultDateFormats {
+		if date, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return date.In(loc), layoutHasZone(layout), true
+		}
+	}
+
+	return time.Time{}, false, false
+}
+
+// layoutHasZone reports whether a Go time layout reads a UTC offset or zone
+// abbreviation
+func layoutHasZone(layout string) bool {
+	return strings.Contains(layout, "Z07") || strings.Contains(layout, "-07") || strings.Contains(layout, "MST")
+}
+
+// startOfDay returns midnight of t's day in t's own location. Unlike
+// Truncate, which rounds in UTC, it keeps the day of non-UTC dates.
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// isDigits reports whether s consists only of ASCII digits
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
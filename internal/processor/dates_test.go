@@ -0,0 +1,175 @@
+package processor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+func TestParseDateFormats(t *testing.T) {
+	processor := New()
+
+	testCases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"2024-01-15", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"2024-01-15 10:30:00", time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)},
+		{"2024-01-15T10:30:00Z", time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)},
+		{"2024-01-15T10:30:00+02:00", time.Date(2024, 1, 15, 8, 30, 0, 0, time.UTC)},
+		{"2024-01-15T10:30:00.123456789Z", time.Date(2024, 1, 15, 10, 30, 0, 123456789, time.UTC)},
+		{"1705314600", time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)},
+		{"01/15/2024", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"01-15-2024", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"2024/01/15", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		date, ok := processor.parseDate(tc.value)
+		if !ok {
+			t.Errorf("Expected %q to parse", tc.value)
+			continue
+		}
+		if !date.Equal(tc.expected) {
+			t.Errorf("Expected %q to parse as %v, got %v", tc.value, tc.expected, date)
+		}
+	}
+}
+
+func TestParseDateRejectsUnknownFormats(t *testing.T) {
+	processor := New()
+
+	for _, value := range []string{"", "15.01.2024", "yesterday"} {
+		if date, ok := processor.parseDate(value); ok {
+			t.Errorf("Expected %q not to parse, got %v", value, date)
+		}
+	}
+}
+
+func TestParseDateCustomFormats(t *testing.T) {
+	processor := New()
+	processor.SetDateFormats([]string{"02.01.2006", "20060102"})
+
+	date, ok := processor.parseDate("15.01.2024")
+	if !ok || !date.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 15.01.2024 to parse as 2024-01-15, got %v (ok=%v)", date, ok)
+	}
+
+	// Custom layouts are tried before epoch detection
+	date, ok = processor.parseDate("20240115")
+	if !ok || !date.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected 20240115 to parse as 2024-01-15, got %v (ok=%v)", date, ok)
+	}
+
+	// Built-in layouts still apply
+	if _, ok := processor.parseDate("2024-01-15"); !ok {
+		t.Error("Expected built-in layouts to still parse with custom formats set")
+	}
+}
+
+func TestProcessDatasetEpochDates(t *testing.T) {
+	path := writeTestFile(t, "epoch.csv", "transaction_id,transaction_date,product_name,quantity,price,total_price\n"+
+		"T1,1705314600,Widget,1,10.00,10.00\n"+
+		"T2,2024-01-16T09:00:00Z,Widget,1,10.00,10.00\n")
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data := processor.GetDashboardData()
+	if data.Report.Rows != 2 {
+		t.Fatalf("Expected 2 rows, got %d", data.Report.Rows)
+	}
+	if data.Report.FirstDate != "2024-01-15" || data.Report.LastDate != "2024-01-16" {
+		t.Errorf("Expected date range 2024-01-15..2024-01-16, got %s..%s", data.Report.FirstDate, data.Report.LastDate)
+	}
+}
+
+// Both fixtures hold the same instants around the January/February 2024
+// boundary, written with different offsets, plus a date-only value and an
+// epoch
+const (
+	timezoneFixture = `transaction_id,transaction_date,product_name,quantity,total_price
+TXN1,2024-01-31T23:30:00-08:00,Laptop,1,100
+TXN2,2024-02-01T05:00:00Z,Mouse,1,10
+TXN3,2024-02-01,Cable,1,1
+TXN4,2024-01-15T23:30:00-08:00,Monitor,1,1000
+TXN5,1706745600,Desk,1,10000
+`
+	timezoneFixtureRewritten = `transaction_id,transaction_date,product_name,quantity,total_price
+TXN1,2024-02-01T07:30:00Z,Laptop,1,100
+TXN2,2024-01-31T21:00:00-08:00,Mouse,1,10
+TXN3,2024-02-01,Cable,1,1
+TXN4,2024-01-16T02:30:00-05:00,Monitor,1,1000
+TXN5,1706745600,Desk,1,10000
+`
+)
+
+func TestDataTimezoneBucketing(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("Failed to load America/Los_Angeles: %v", err)
+	}
+
+	tests := []struct {
+		loc       *time.Location
+		zone      string
+		january   float64
+		february  float64
+		firstDate string
+	}{
+		{nil, "UTC", 1000, 10111, "2024-01-16"},
+		{losAngeles, "America/Los_Angeles", 11110, 1, "2024-01-15"},
+	}
+
+	for _, test := range tests {
+		var monthly [][]models.MonthlySales
+		for _, fixture := range []string{timezoneFixture, timezoneFixtureRewritten} {
+			processor := New()
+			processor.SetDataTimezone(test.loc)
+			if err := processor.ProcessDataset(writeTestFile(t, "timezone.csv", fixture)); err != nil {
+				t.Fatalf("%s: expected no error, got %v", test.zone, err)
+			}
+			data := processor.GetDashboardData()
+			monthly = append(monthly, data.MonthlySales)
+
+			if data.Report.Timezone != test.zone || data.Report.OffsetDateRows != 3 {
+				t.Errorf("%s: expected 3 offset rows in %s, got %d in %q",
+					test.zone, test.zone, data.Report.OffsetDateRows, data.Report.Timezone)
+			}
+			if data.Report.FirstDate != test.firstDate || data.Report.LastDate != "2024-02-01" {
+				t.Errorf("%s: expected dates from %s to 2024-02-01, got %s to %s",
+					test.zone, test.firstDate, data.Report.FirstDate, data.Report.LastDate)
+			}
+		}
+
+		sales := monthly[0]
+		if january := findMonth(t, sales, "January", 2024); january.TotalSales != test.january {
+			t.Errorf("%s: expected January sales of %v, got %v", test.zone, test.january, january.TotalSales)
+		}
+		if february := findMonth(t, sales, "February", 2024); february.TotalSales != test.february {
+			t.Errorf("%s: expected February sales of %v, got %v", test.zone, test.february, february.TotalSales)
+		}
+		if !reflect.DeepEqual(monthly[0], monthly[1]) {
+			t.Errorf("%s: expected the same instants to bucket alike whatever their offset, got %+v and %+v",
+				test.zone, monthly[0], monthly[1])
+		}
+	}
+}
+
+func TestParseDateInDataTimezone(t *testing.T) {
+	processor := New()
+	processor.SetDataTimezone(time.FixedZone("UTC-8", -8*60*60))
+
+	date, offset, ok := processor.parseDateOffset("2024-01-15")
+	if !ok || offset || !date.Equal(time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected a date-only value to be midnight in the data timezone, got %v (offset=%v, ok=%v)", date, offset, ok)
+	}
+	date, offset, ok = processor.parseDateOffset("2024-01-15T10:30:00Z")
+	if !ok || !offset || date.Hour() != 2 || date.Day() != 15 {
+		t.Errorf("Expected an offset value converted to 02:30 on the 15th, got %v (offset=%v, ok=%v)", date, offset, ok)
+	}
+}
@@ -0,0 +1,165 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// diffTolerance is the smallest revenue or sales change reported by a diff.
+// Workers sum revenue in no fixed order, so reprocessing the same rows can
+// differ in the last bits of a total.
+const diffTolerance = 0.005
+
+// GetDashboardDiff returns what changed in the served data since the given
+// time along with the snapshot it was diffed up to, and false when the data
+// was not replaced after it or the replacement changed nothing. Only the snapshot served before the current
+// one is kept, so when the data was replaced more than once since then the
+// diff covers the last replacement only and is not Complete.
+func (p *Processor) GetDashboardDiff(since time.Time) (models.DashboardDiff, *models.DashboardData, bool) {
+	p.mu.Lock()
+	current, previous := p.data.Load(), p.previous
+	p.mu.Unlock()
+
+	if previous == nil || !current.LastUpdated.After(since) {
+		return models.DashboardDiff{}, current, false
+	}
+
+	perCurrency := len(previous.CurrencyOrder) > 1 || len(current.CurrencyOrder) > 1
+	diff := models.DashboardDiff{
+		From:      previous.LastUpdated,
+		To:        current.LastUpdated,
+		Complete:  !previous.LastUpdated.After(since),
+		Countries: diffCountryRevenues(previous.CountrySummaries, current.CountrySummaries, perCurrency),
+		Months:    diffMonthlySales(previous.MonthlySales, current.MonthlySales),
+	}
+	diff.NewProducts, diff.RemovedProducts = diffProducts(previous.ProductIndex, current.ProductIndex)
+	if diff.Empty() {
+		return models.DashboardDiff{}, current, false
+	}
+	return diff, current, true
+}
+
+// diffCountryRevenues returns the countries whose total revenue changed,
+// by the size of the change, largest first. With perCurrency, for data
+// whose totals are left at zero because amounts are in several currencies,
+// each country's revenue is compared per currency instead, one delta per
+// country and currency.
+func diffCountryRevenues(previous, current []models.CountrySummary, perCurrency bool) []models.CountryRevenueDelta {
+	type deltaKey struct{ country, currency string }
+	revenues := make(map[deltaKey]*models.CountryRevenueDelta)
+	delta := func(country, currency string) *models.CountryRevenueDelta {
+		key := deltaKey{country, currency}
+		d, ok := revenues[key]
+		if !ok {
+			d = &models.CountryRevenueDelta{Country: country, Currency: currency}
+			revenues[key] = d
+		}
+		return d
+	}
+	revenue := func(summary models.CountrySummary) map[string]float64 {
+		if perCurrency {
+			return summary.RevenueByCurrency
+		}
+		return map[string]float64{"": summary.TotalRevenue}
+	}
+	for _, summary := range previous {
+		for currency, amount := range revenue(summary) {
+			delta(summary.Country, currency).PreviousRevenue = amount
+		}
+	}
+	for _, summary := range current {
+		for currency, amount := range revenue(summary) {
+			delta(summary.Country, currency).CurrentRevenue = amount
+		}
+	}
+
+	deltas := make([]models.CountryRevenueDelta, 0)
+	for _, d := range revenues {
+		d.Change = d.CurrentRevenue - d.PreviousRevenue
+		if math.Abs(d.Change) >= diffTolerance {
+			deltas = append(deltas, *d)
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		if a, b := math.Abs(deltas[i].Change), math.Abs(deltas[j].Change); a != b {
+			return a > b
+		}
+		if deltas[i].Country != deltas[j].Country {
+			return deltas[i].Country < deltas[j].Country
+		}
+		return deltas[i].Currency < deltas[j].Currency
+	})
+	return deltas
+}
+
+// diffProducts returns the names of the products only in current and only
+// in previous, each in alphabetical order
+func diffProducts(previous, current []models.ProductSearchEntry) (added, removed []string) {
+	names := func(index []models.ProductSearchEntry) map[string]struct{} {
+		set := make(map[string]struct{}, len(index))
+		for _, entry := range index {
+			set[entry.Product.ProductName] = struct{}{}
+		}
+		return set
+	}
+	before, after := names(previous), names(current)
+
+	added, removed = make([]string, 0), make([]string, 0)
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// diffMonthlySales returns the months whose total sales changed, in
+// chronological order
+func diffMonthlySales(previous, current []models.MonthlySales) []models.MonthlySalesDelta {
+	months := make(map[int]*models.MonthlySalesDelta)
+	delta := func(sale models.MonthlySales) *models.MonthlySalesDelta {
+		index, ok := monthIndex(sale)
+		if !ok {
+			return nil
+		}
+		d, exists := months[index]
+		if !exists {
+			d = &models.MonthlySalesDelta{Month: sale.Month, Year: sale.Year, MonthNumber: index%12 + 1}
+			months[index] = d
+		}
+		return d
+	}
+	for _, sale := range previous {
+		if d := delta(sale); d != nil {
+			d.PreviousTotal = sale.TotalSales
+		}
+	}
+	for _, sale := range current {
+		if d := delta(sale); d != nil {
+			d.CurrentTotal = sale.TotalSales
+		}
+	}
+
+	indexes := make([]int, 0, len(months))
+	for index, d := range months {
+		d.Change = d.CurrentTotal - d.PreviousTotal
+		if math.Abs(d.Change) >= diffTolerance {
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Ints(indexes)
+	deltas := make([]models.MonthlySalesDelta, 0, len(indexes))
+	for _, index := range indexes {
+		deltas = append(deltas, *months[index])
+	}
+	return deltas
+}
@@ -0,0 +1,135 @@
+package processor
+
+import (
+	"testing"
+	"time"
+)
+
+const diffHeader = "transaction_id,transaction_date,country,region,product_name,quantity,total_price\n"
+
+// newDiffTestProcessor returns a processor whose clock reads *now
+func newDiffTestProcessor(now *time.Time) *Processor {
+	return New(WithClock(func() time.Time { return *now }))
+}
+
+func TestDashboardDiffAfterReload(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	processor := newDiffTestProcessor(&now)
+
+	first := writeTestFile(t, "first.csv", diffHeader+
+		"T1,2024-01-10,USA,North America,Laptop,1,1000\n"+
+		"T2,2024-01-12,Germany,Europe,Mouse,2,50\n"+
+		"T3,2024-02-03,France,Europe,Keyboard,1,80\n")
+	if err := processor.ProcessDataset(first); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+	loaded := now
+
+	now = now.Add(time.Hour)
+	second := writeTestFile(t, "second.csv", diffHeader+
+		"T1,2024-01-10,USA,North America,Laptop,1,1000\n"+
+		"T2,2024-01-12,Germany,Europe,Mouse,2,50\n"+
+		"T4,2024-02-05,Germany,Europe,Monitor,1,300\n"+
+		"T5,2024-03-01,Spain,Europe,Mouse,1,25\n")
+	if err := processor.ProcessDataset(second); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	diff, data, changed := processor.GetDashboardDiff(loaded)
+	if !changed {
+		t.Fatal("Expected changes since the first load")
+	}
+	if !diff.Complete || !diff.From.Equal(loaded) || !diff.To.Equal(now) {
+		t.Errorf("Expected a complete diff from %v to %v, got %+v", loaded, now, diff)
+	}
+	if data != processor.GetDashboardData() {
+		t.Error("Expected the diff to return the snapshot it was diffed up to")
+	}
+
+	// Germany gained 300, Spain is new with 25 and France lost its 80;
+	// the USA is unchanged
+	wantCountries := map[string]float64{"Germany": 300, "France": -80, "Spain": 25}
+	if len(diff.Countries) != len(wantCountries) {
+		t.Fatalf("Expected %d country changes, got %+v", len(wantCountries), diff.Countries)
+	}
+	for _, delta := range diff.Countries {
+		if want, ok := wantCountries[delta.Country]; !ok || delta.Change != want {
+			t.Errorf("Unexpected change for %s: %+v", delta.Country, delta)
+		}
+	}
+	if diff.Countries[0].Country != "Germany" {
+		t.Errorf("Expected the largest change first, got %s", diff.Countries[0].Country)
+	}
+
+	if len(diff.NewProducts) != 1 || diff.NewProducts[0] != "Monitor" {
+		t.Errorf("Expected Monitor to be new, got %v", diff.NewProducts)
+	}
+	if len(diff.RemovedProducts) != 1 || diff.RemovedProducts[0] != "Keyboard" {
+		t.Errorf("Expected Keyboard to be removed, got %v", diff.RemovedProducts)
+	}
+
+	// January is unchanged; February went from 80 to 300 and March is new
+	if len(diff.Months) != 2 {
+		t.Fatalf("Expected 2 month changes, got %+v", diff.Months)
+	}
+	feb, mar := diff.Months[0], diff.Months[1]
+	if feb.MonthNumber != 2 || feb.PreviousTotal != 80 || feb.CurrentTotal != 300 || feb.Change != 220 {
+		t.Errorf("Unexpected February change: %+v", feb)
+	}
+	if mar.MonthNumber != 3 || mar.Year != 2024 || mar.PreviousTotal != 0 || mar.Change != 25 {
+		t.Errorf("Unexpected March change: %+v", mar)
+	}
+
+	if _, _, changed := processor.GetDashboardDiff(now); changed {
+		t.Error("Expected no changes since the second load")
+	}
+
+	// Only one earlier snapshot is kept: asking from before the first load
+	// reports the last reload, flagged as incomplete
+	diff, _, changed = processor.GetDashboardDiff(loaded.Add(-time.Minute))
+	if !changed || diff.Complete {
+		t.Errorf("Expected an incomplete diff from before the first load, got %+v (changed %v)", diff, changed)
+	}
+}
+
+func TestDashboardDiffUnchangedReload(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	processor := newDiffTestProcessor(&now)
+	path := writeTestFile(t, "data.csv", diffHeader+
+		"T1,2024-01-10,USA,North America,Laptop,1,1000.10\n"+
+		"T2,2024-01-12,USA,North America,Mouse,3,0.10\n")
+
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+	loaded := now
+	now = now.Add(time.Hour)
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	if diff, _, changed := processor.GetDashboardDiff(loaded); changed {
+		t.Errorf("Expected reprocessing the same data to change nothing, got %+v", diff)
+	}
+}
+
+func TestDashboardDiffFirstLoad(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	processor := newDiffTestProcessor(&now)
+	if _, _, changed := processor.GetDashboardDiff(now.Add(-time.Hour)); changed {
+		t.Error("Expected no changes before any data was loaded")
+	}
+
+	path := writeTestFile(t, "data.csv", diffHeader+"T1,2024-01-10,USA,North America,Laptop,1,1000\n")
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	diff, _, changed := processor.GetDashboardDiff(now.Add(-time.Hour))
+	if !changed || !diff.Complete || !diff.From.IsZero() {
+		t.Fatalf("Expected a complete diff from no data, got %+v (changed %v)", diff, changed)
+	}
+	if len(diff.Countries) != 1 || diff.Countries[0].Change != 1000 || len(diff.NewProducts) != 1 {
+		t.Errorf("Expected everything to be new, got %+v", diff)
+	}
+}
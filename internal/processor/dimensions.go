@@ -0,0 +1,111 @@
+package processor
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+// Dimensions listed by GetDimensions
+const (
+	dimensionCountries  = "countries"
+	dimensionRegions    = "regions"
+	dimensionCategories = "categories"
+	dimensionCurrencies = "currencies"
+)
+
+// overflowDimensionValues counts the rows whose dimension value was not
+// listed because the dimension reached the aggregation key limit
+const overflowDimensionValues = "dimension_values"
+
+// countDimension counts a row's spelling of a dimension value. Blank
+// values are not listed. Callers must hold agg.mu.
+func countDimension(agg *aggregates, dimension, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+
+	values, exists := agg.dimensions[dimension]
+	if !exists {
+		values = make(map[string]int)
+		agg.dimensions[dimension] = values
+	}
+	if _, exists := values[value]; !exists && agg.maxKeys > 0 && len(values) >= agg.maxKeys {
+		agg.overflow[overflowDimensionValues]++
+		return
+	}
+	values[value]++
+}
+
+// buildDimensions canonicalizes the counted spellings of every dimension
+func buildDimensions(dimensions map[string]map[string]int) models.Dimensions {
+	return models.Dimensions{
+		Countries:  canonicalDimensionValues(dimensions[dimensionCountries]),
+		Regions:    canonicalDimensionValues(dimensions[dimensionRegions]),
+		Categories: canonicalDimensionValues(dimensions[dimensionCategories]),
+		Currencies: canonicalDimensionValues(dimensions[dimensionCurrencies]),
+	}
+}
+
+// canonicalDimensionValues merges spellings that differ only in case, such
+// as "usa" and "USA", into one value with their combined row count. The
+// most common spelling names the value, ties going to the first in byte
+// order. Values are sorted by displayOrder.
+func canonicalDimensionValues(counts map[string]int) []models.DimensionValue {
+	fold := cases.Fold()
+	type group struct {
+		value     string
+		valueRows int
+		rows      int
+	}
+	groups := make(map[string]*group, len(counts))
+	for value, rows := range counts {
+		key := fold.String(value)
+		g, exists := groups[key]
+		if !exists {
+			g = &group{}
+			groups[key] = g
+		}
+		g.rows += rows
+		if rows > g.valueRows || rows == g.valueRows && value < g.value {
+			g.value, g.valueRows = value, rows
+		}
+	}
+
+	values := make([]models.DimensionValue, 0, len(groups))
+	for _, g := range groups {
+		values = append(values, models.DimensionValue{Value: g.value, Count: g.rows})
+	}
+	less := displayOrder()
+	sort.Slice(values, func(i, j int) bool {
+		return less(values[i].Value, values[j].Value)
+	})
+	return values
+}
+
+// displayOrder returns a comparison for names shown in lists: the root
+// collation ignoring case and accents, so "Île-de-France" sorts with the
+// I's rather than after "Z", then byte order to keep the order total. The
+// returned function is not safe for concurrent use.
+func displayOrder() func(a, b string) bool {
+	collator := collate.New(language.Und, collate.Loose)
+	return func(a, b string) bool {
+		if c := collator.CompareString(a, b); c != 0 {
+			return c < 0
+		}
+		return a < b
+	}
+}
+
+// GetDimensions returns the distinct countries, regions, categories and
+// currencies of the current data with their row counts, for filter
+// dropdowns
+func (p *Processor) GetDimensions() models.Dimensions {
+	return p.data.Load().Dimensions
+}
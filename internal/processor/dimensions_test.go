@@ -0,0 +1,74 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"reflect"
+	"testing"
+)
+
+func dimensionValues(values []models.DimensionValue) []string {
+	names := make([]string, len(values))
+	for i, value := range values {
+		names[i] = value.Value
+	}
+	return names
+}
+
+func TestGetDimensionsMergesCase(t *testing.T) {
+	path := writeTestFile(t, "sales.csv", "transaction_id,country,region,category,currency,product_name,quantity,total_price\n"+
+		"TXN001,USA,North America,Audio,USD,Speakers,1,100\n"+
+		"TXN002,usa,North America,audio,usd,Speakers,1,100\n"+
+		"TXN003,USA,north america,Audio,USD,Speakers,1,100\n"+
+		"TXN004,Usa,North America,AUDIO,USD,Speakers,1,100\n"+
+		"TXN005,germany,Europe,Mobile,EUR,Tablet,1,100\n"+
+		"TXN006,Germany,Europe,Mobile,EUR,Tablet,1,100\n")
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	dimensions := processor.GetDimensions()
+
+	expectedCountries := []models.DimensionValue{{Value: "Germany", Count: 2}, {Value: "USA", Count: 4}}
+	if !reflect.DeepEqual(dimensions.Countries, expectedCountries) {
+		t.Errorf("Expected countries %v, got %v", expectedCountries, dimensions.Countries)
+	}
+	if names := dimensionValues(dimensions.Regions); !reflect.DeepEqual(names, []string{"Europe", "North America"}) {
+		t.Errorf("Expected regions [Europe North America], got %v", names)
+	}
+	if names := dimensionValues(dimensions.Categories); !reflect.DeepEqual(names, []string{"Audio", "Mobile"}) {
+		t.Errorf("Expected categories [Audio Mobile], got %v", names)
+	}
+	if names := dimensionValues(dimensions.Currencies); !reflect.DeepEqual(names, []string{"EUR", "USD"}) {
+		t.Errorf("Expected currencies [EUR USD], got %v", names)
+	}
+}
+
+func TestCanonicalDimensionValuesCollatesAccents(t *testing.T) {
+	counts := map[string]int{
+		"Zeeland":       3,
+		"Île-de-France": 5,
+		"île-de-france": 1,
+		"Åland":         2,
+		"Bavaria":       4,
+		"Ile-de-Paris":  1,
+		"Aquitaine":     1,
+	}
+
+	values := canonicalDimensionValues(counts)
+	expected := []string{"Åland", "Aquitaine", "Bavaria", "Île-de-France", "Ile-de-Paris", "Zeeland"}
+	if names := dimensionValues(values); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	if values[3].Count != 6 {
+		t.Errorf("Expected Île-de-France to count 6 rows, got %d", values[3].Count)
+	}
+
+	// Ties pick the same spelling and order on every run
+	tied := map[string]int{"Usa": 1, "USA": 1, "usa": 1}
+	for i := 0; i < 20; i++ {
+		if values := canonicalDimensionValues(tied); len(values) != 1 || values[0].Value != "USA" || values[0].Count != 3 {
+			t.Fatalf("Expected USA with 3 rows, got %v", values)
+		}
+	}
+}
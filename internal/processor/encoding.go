@@ -0,0 +1,55 @@
+package processor
+
+import (
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Character encodings of CSV files
+const (
+	// EncodingUTF8 reads files as UTF-8, the default
+	EncodingUTF8 = "utf-8"
+	// EncodingWindows1252 reads files exported as Windows-1252 (cp1252),
+	// as Excel does on Western European Windows
+	EncodingWindows1252 = "windows-1252"
+	// EncodingLatin1 reads files as ISO-8859-1
+	EncodingLatin1 = "latin-1"
+)
+
+// SetCSVEncoding sets the character encoding CSV files are decoded from
+// into UTF-8; an empty encoding is treated as EncodingUTF8. Files read as
+// UTF-8 count the rows holding invalid UTF-8, a hint that they need
+// another encoding.
+func (p *Processor) SetCSVEncoding(encoding string) {
+	p.csvEncoding = encoding
+}
+
+// decodeCSV returns r decoded from the CSV encoding into UTF-8
+func (p *Processor) decodeCSV(r io.Reader) io.Reader {
+	switch p.csvEncoding {
+	case EncodingWindows1252:
+		return charmap.Windows1252.NewDecoder().Reader(r)
+	case EncodingLatin1:
+		return charmap.ISO8859_1.NewDecoder().Reader(r)
+	default:
+		return r
+	}
+}
+
+// detectsInvalidUTF8 reports whether rows are checked for invalid UTF-8,
+// which is done when files are read as UTF-8
+func (p *Processor) detectsInvalidUTF8() bool {
+	return p.csvEncoding == "" || p.csvEncoding == EncodingUTF8
+}
+
+// validUTF8 reports whether every field of a record is valid UTF-8
+func validUTF8(record []string) bool {
+	for _, field := range record {
+		if !utf8.ValidString(field) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+)
+
+// cp1252CSV is a Windows-1252 export: \xc9 is É, \xfc is ü and \x80 is €
+const cp1252CSV = "transaction_id,product_name,category,quantity,total_price\n" +
+	"T1,\xc9couteurs sans fil,Audio,1,80\n" +
+	"T2,K\xfchlschrank,Haushalt,1,600\n" +
+	"T3,Gutschein 10 \x80,Geschenke,2,20\n"
+
+func productNameSet(processor *Processor) map[string]bool {
+	names := make(map[string]bool)
+	for _, product := range processor.GetTopProducts() {
+		names[product.ProductName] = true
+	}
+	return names
+}
+
+func TestCSVEncodingWindows1252(t *testing.T) {
+	for _, encoding := range []string{EncodingWindows1252, EncodingLatin1} {
+		processor := New()
+		processor.SetCSVEncoding(encoding)
+		if err := processor.ProcessDataset(writeTestFile(t, "cp1252.csv", cp1252CSV)); err != nil {
+			t.Fatalf("%s: expected no error, got %v", encoding, err)
+		}
+
+		names := productNameSet(processor)
+		for _, name := range []string{"Écouteurs sans fil", "Kühlschrank"} {
+			if !names[name] {
+				t.Errorf("%s: expected product %q, got %v", encoding, name, names)
+			}
+		}
+		if report := processor.GetDashboardData().Report; report.InvalidUTF8Rows != 0 {
+			t.Errorf("%s: expected no invalid UTF-8 rows once decoded, got %d", encoding, report.InvalidUTF8Rows)
+		}
+	}
+
+	// \x80 is € in Windows-1252 but a control character in ISO-8859-1
+	processor := New()
+	processor.SetCSVEncoding(EncodingWindows1252)
+	if err := processor.ProcessDataset(writeTestFile(t, "cp1252.csv", cp1252CSV)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if names := productNameSet(processor); !names["Gutschein 10 €"] {
+		t.Errorf("Expected the euro sign decoded, got %v", names)
+	}
+}
+
+func TestInvalidUTF8RowsCounted(t *testing.T) {
+	for _, encoding := range []string{"", EncodingUTF8} {
+		processor := New()
+		processor.SetCSVEncoding(encoding)
+		if err := processor.ProcessDataset(writeTestFile(t, "cp1252.csv", cp1252CSV)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		report := processor.GetDashboardData().Report
+		if report.InvalidUTF8Rows != 3 || len(report.Files) != 1 || report.Files[0].InvalidUTF8Rows != 3 {
+			t.Errorf("Encoding %q: expected 3 invalid UTF-8 rows in the report and its file, got %+v", encoding, report)
+		}
+		warned := false
+		for _, warning := range report.Warnings {
+			warned = warned || strings.Contains(warning, "CSV_ENCODING")
+		}
+		if !warned {
+			t.Errorf("Encoding %q: expected a warning suggesting CSV_ENCODING, got %v", encoding, report.Warnings)
+		}
+	}
+
+	processor := New()
+	if err := processor.ProcessDataset(writeTestFile(t, "utf8.csv", "transaction_id,product_name,quantity,total_price\nT1,Écouteurs sans fil,1,80\n")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report := processor.GetDashboardData().Report; report.InvalidUTF8Rows != 0 {
+		t.Errorf("Expected no invalid UTF-8 rows in a UTF-8 file, got %d", report.InvalidUTF8Rows)
+	}
+}
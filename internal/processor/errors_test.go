@@ -0,0 +1,181 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessDatasetNotFound(t *testing.T) {
+	err := New().ProcessDataset(filepath.Join(t.TempDir(), "missing.csv"))
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestProcessDatasetInvalidHeader(t *testing.T) {
+	cases := map[string]struct {
+		content string
+		missing []string
+	}{
+		"missing columns":  {"transaction_id,product_name,price\nTXN1,Phone,10\n", []string{"quantity", "total_price"}},
+		"no known columns": {"transaction_id\n", requiredColumns},
+	}
+
+	for name, tc := range cases {
+		err := New().ProcessDataset(writeTestFile(t, "header.csv", tc.content))
+		if !errors.Is(err, ErrInvalidHeader) {
+			t.Fatalf("%s: expected ErrInvalidHeader, got %v", name, err)
+		}
+		var headerErr *InvalidHeaderError
+		if !errors.As(err, &headerErr) || strings.Join(headerErr.Missing, ",") != strings.Join(tc.missing, ",") {
+			t.Errorf("%s: expected missing columns %v, got %v", name, tc.missing, err)
+		}
+	}
+}
+
+func TestProcessDatasetEmpty(t *testing.T) {
+	cases := map[string]string{
+		"empty file":       "",
+		"header only":      "transaction_id,product_name,quantity,total_price\n",
+		"only bad rows":    "transaction_id,product_name,quantity,total_price\nTXN1,Phone\n",
+		"blank lines only": "transaction_id,product_name,quantity,total_price\n\n\n",
+	}
+
+	for name, content := range cases {
+		processor := New()
+		processor.LoadSampleData()
+		err := processor.ProcessDataset(writeTestFile(t, "empty.csv", content))
+		if !errors.Is(err, ErrEmptyDataset) {
+			t.Errorf("%s: expected ErrEmptyDataset, got %v", name, err)
+		}
+		if source := processor.GetDashboardData().DataSource; source != SourceSample {
+			t.Errorf("%s: expected the previous data to stay in place, got %s", name, source)
+		}
+	}
+}
+
+func TestProcessDatasetAllowEmpty(t *testing.T) {
+	for name, content := range map[string]string{"empty file": "", "header only": "product_name,quantity,total_price\n"} {
+		processor := New()
+		processor.SetAllowEmptyDataset(true)
+		if err := processor.ProcessDataset(writeTestFile(t, "empty.csv", content)); err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+
+		data := processor.GetDashboardData()
+		if data.DataSource != SourceDataset || data.Report.Rows != 0 || len(data.CountryRevenues) != 0 {
+			t.Errorf("%s: expected empty dataset data with 0 rows, got %s with %d rows", name, data.DataSource, data.Report.Rows)
+		}
+		if !containsWarning(data.Report.Warnings, "no data rows") {
+			t.Errorf("%s: expected a warning about the empty dataset, got %v", name, data.Report.Warnings)
+		}
+	}
+}
+
+func TestProcessDatasetEmptyShard(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"part_1.csv": "product_name,quantity,total_price\nLaptop,1,100\n",
+		"part_2.csv": "",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	processor := New()
+	if err := processor.ProcessDataset(dir); err != nil {
+		t.Fatalf("Expected an empty shard to be accepted, got %v", err)
+	}
+	if rows := processor.GetDashboardData().Report.Rows; rows != 1 {
+		t.Errorf("Expected 1 row, got %d", rows)
+	}
+}
+
+func TestProcessDatasetTooManyBadRows(t *testing.T) {
+	path := writeTestFile(t, "bad.csv", `transaction_id,product_name,quantity,total_price
+TXN1,Phone,1,100
+TXN2,Phone
+TXN3,Phone,1,100,extra
+TXN4,Phone,1,100
+`)
+
+	processor := New()
+	processor.SetMaxBadRows(1)
+	if err := processor.ProcessDataset(path); !errors.Is(err, ErrTooManyBadRows) {
+		t.Errorf("Expected ErrTooManyBadRows, got %v", err)
+	}
+
+	processor.SetMaxBadRows(0)
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Errorf("Expected no error without a limit, got %v", err)
+	}
+}
+
+func TestProcessDatasetBadRowRatio(t *testing.T) {
+	// Half of the rows have the wrong number of fields
+	path := writeTestFile(t, "half_bad.csv", `transaction_id,product_name,quantity,total_price
+TXN1,Phone,1,100
+TXN2,Phone
+TXN3,Laptop,1,900
+TXN4,Phone,1,100,extra
+TXN5,Phone,2,200
+TXN6
+TXN7,Tablet,1,300
+TXN8,Tablet,1
+`)
+
+	processor := New()
+	processor.LoadSampleData()
+	processor.SetMaxBadRowRatio(0.4)
+	err := processor.ProcessDataset(path)
+	if !errors.Is(err, ErrTooManyBadRows) {
+		t.Fatalf("Expected ErrTooManyBadRows above the ratio, got %v", err)
+	}
+	var ratioErr *BadRowRatioError
+	if !errors.As(err, &ratioErr) {
+		t.Fatalf("Expected a *BadRowRatioError, got %T", err)
+	}
+	if ratioErr.Skipped != 4 || ratioErr.Total != 8 || ratioErr.Report.Rows != 4 || ratioErr.Report.SkippedRows != 4 {
+		t.Errorf("Expected 4 of 8 rows skipped in the error and its report, got %+v", ratioErr)
+	}
+	if source := processor.GetDashboardData().DataSource; source != SourceSample {
+		t.Errorf("Expected the partial aggregates not to be served, got data source %q", source)
+	}
+	failed, ok := processor.GetLastFailedReport()
+	if !ok || failed.Rows != 4 || failed.SkippedRows != 4 || len(failed.Files) != 1 {
+		t.Errorf("Expected the rejected run's report to be kept, got %+v (%v)", failed, ok)
+	}
+
+	processor.SetMaxBadRowRatio(0.5)
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error at the ratio, got %v", err)
+	}
+	data := processor.GetDashboardData()
+	if data.DataSource != SourceDataset || data.Report.SkippedRows != 4 {
+		t.Errorf("Expected the dataset to be served with 4 skipped rows, got source %q and %d skipped",
+			data.DataSource, data.Report.SkippedRows)
+	}
+	if failed, ok := processor.GetLastFailedReport(); !ok || failed.SkippedRows != 4 {
+		t.Errorf("Expected the rejected run's report to outlast a successful run, got %+v (%v)", failed, ok)
+	}
+}
+
+func TestProcessReaderContextAborted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	processor := New()
+	processor.LoadSampleData()
+	err := processor.ProcessReaderContext(ctx, strings.NewReader("transaction_id,product_name,quantity,total_price\nTXN1,Phone,1,100\n"))
+	if !errors.Is(err, ErrAborted) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected ErrAborted wrapping context.Canceled, got %v", err)
+	}
+	if source := processor.GetDashboardData().DataSource; source != SourceSample {
+		t.Errorf("Expected the sample data to stay in place, got %s", source)
+	}
+}
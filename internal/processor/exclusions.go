@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"strings"
+)
+
+// exclusions are the products and countries whose rows are left out of
+// the aggregates, as set with SetExclusions
+type exclusions struct {
+	list models.Exclusions
+
+	// products maps lowercased product names and IDs, and countries maps
+	// normalized country names and upper-cased codes, to their index in
+	// list
+	products  map[string]int
+	countries map[string]int
+}
+
+// SetExclusions leaves the rows of the given products, by name or
+// product_id, and countries, by name or ISO code, out of every aggregate
+// from the next processing run on. Names match case-insensitively. The
+// rows left out are totalled per exclusion in the processing report.
+// Empty values are ignored; passing none removes the exclusions.
+func (p *Processor) SetExclusions(products, countries []string) {
+	products, countries = nonEmpty(products), nonEmpty(countries)
+	if len(products) == 0 && len(countries) == 0 {
+		p.exclusions.Store(nil)
+		return
+	}
+
+	e := &exclusions{
+		list:      models.Exclusions{Products: products, Countries: countries},
+		products:  make(map[string]int, len(products)),
+		countries: make(map[string]int, len(countries)),
+	}
+	for i, product := range products {
+		e.products[strings.ToLower(product)] = i
+	}
+	for i, country := range countries {
+		e.countries[normalizeCountryName(country)] = i
+		if code := strings.ToUpper(country); len(code) == 2 {
+			e.countries[code] = i
+		}
+	}
+	p.exclusions.Store(e)
+}
+
+// nonEmpty returns the values that are not blank, trimmed
+func nonEmpty(values []string) []string {
+	kept := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
+
+// GetExclusions returns the exclusions applied by the next processing run,
+// which may differ from those of the served data until it is reloaded
+func (p *Processor) GetExclusions() models.Exclusions {
+	e := p.exclusions.Load()
+	if e == nil {
+		return models.Exclusions{Products: []string{}, Countries: []string{}}
+	}
+	return e.list
+}
+
+// excludedTotals lists a total per exclusion, products first, each in the
+// order they were set
+func (e *exclusions) excludedTotals() []models.ExcludedTotal {
+	if e == nil {
+		return nil
+	}
+	totals := make([]models.ExcludedTotal, 0, len(e.list.Products)+len(e.list.Countries))
+	for _, product := range e.list.Products {
+		totals = append(totals, models.ExcludedTotal{Kind: models.ExcludedProduct, Key: product})
+	}
+	for _, country := range e.list.Countries {
+		totals = append(totals, models.ExcludedTotal{Kind: models.ExcludedCountry, Key: country})
+	}
+	return totals
+}
+
+// excludeTransaction reports whether transaction is excluded, adding it to
+// the total of every exclusion it matches. Callers must hold agg.mu.
+func excludeTransaction(agg *aggregates, transaction models.Transaction, amount float64) bool {
+	e := agg.exclude
+	if e == nil {
+		return false
+	}
+
+	excluded := false
+	count := func(index int) {
+		agg.excluded[index].Revenue += amount
+		agg.excluded[index].TransactionCount++
+		excluded = true
+	}
+
+	// A row counts once per kind, even when both its product name and ID
+	// are excluded
+	if i, ok := e.products[strings.ToLower(transaction.ProductName)]; ok {
+		count(i)
+	} else if i, ok := e.products[strings.ToLower(transaction.ProductID)]; ok && transaction.ProductID != "" {
+		count(i)
+	}
+
+	if transaction.Country != "" {
+		country := normalizeCountryName(transaction.Country)
+		if i, ok := e.countries[country]; ok {
+			count(len(e.list.Products) + i)
+		} else if code, ok := agg.countryCodes[country]; ok {
+			if i, ok := e.countries[code]; ok {
+				count(len(e.list.Products) + i)
+			}
+		}
+	}
+	return excluded
+}
@@ -0,0 +1,109 @@
+package processor
+
+import (
+	"reflect"
+	"testing"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+const exclusionsFixture = `transaction_id,product_id,country,region,product_name,quantity,total_price
+TXN001,P1,Germany,Bavaria,Laptop,1,1200
+TXN002,P2,Germany,Bavaria,Mouse,2,40
+TXN003,P3,France,Normandy,Keyboard,1,80
+TXN004,P1,France,Normandy,Laptop,1,1100
+TXN005,P4,Canada,Ontario,Monitor,1,300
+TXN006,P2,Canada,Ontario,Mouse,1,20
+`
+
+func processExcluded(t *testing.T, processor *Processor) *models.DashboardData {
+	t.Helper()
+	if err := processor.ProcessDataset(writeTestFile(t, "exclusions.csv", exclusionsFixture)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return processor.GetDashboardData()
+}
+
+func countryRevenueTotal(data *models.DashboardData) float64 {
+	total := 0.0
+	for _, row := range data.CountryRevenues {
+		total += row.TotalRevenue
+	}
+	return total
+}
+
+func TestExclusionsRemoveRowsFromEveryAggregate(t *testing.T) {
+	processor := New()
+	// Laptop by name and Mouse by product ID, Germany by name and Canada
+	// by ISO code
+	processor.SetExclusions([]string{"laptop", " P2 ", ""}, []string{"Germany", "ca"})
+	data := processExcluded(t, processor)
+
+	for _, row := range data.CountryRevenues {
+		if row.Country != "France" || row.ProductName != "Keyboard" {
+			t.Errorf("Expected only France's keyboard row, got %+v", row)
+		}
+	}
+	for _, product := range data.TopProducts {
+		if product.ProductName != "Keyboard" {
+			t.Errorf("Expected only the keyboard in the top products, got %+v", product)
+		}
+	}
+	if results := processor.SearchProducts("laptop", 10); len(results) != 0 {
+		t.Errorf("Expected no search results for an excluded product, got %+v", results)
+	}
+	for _, summary := range data.CountrySummaries {
+		if summary.Country != "France" {
+			t.Errorf("Expected only France in the country summaries, got %+v", summary)
+		}
+	}
+	for _, region := range data.TopRegions {
+		if region.Region != "Normandy" {
+			t.Errorf("Expected only Normandy in the top regions, got %+v", region)
+		}
+	}
+	if _, ok := processor.GetCountryDetail("Germany"); ok {
+		t.Error("Expected no detail for an excluded country")
+	}
+	if total := countryRevenueTotal(data); total != 80 {
+		t.Errorf("Expected a revenue total of 80, got %v", total)
+	}
+
+	want := []models.ExcludedTotal{
+		{Kind: models.ExcludedProduct, Key: "laptop", Revenue: 2300, TransactionCount: 2},
+		{Kind: models.ExcludedProduct, Key: "P2", Revenue: 60, TransactionCount: 2},
+		{Kind: models.ExcludedCountry, Key: "Germany", Revenue: 1240, TransactionCount: 2},
+		{Kind: models.ExcludedCountry, Key: "ca", Revenue: 320, TransactionCount: 2},
+	}
+	if !reflect.DeepEqual(data.Report.Excluded, want) {
+		t.Errorf("Expected excluded totals %+v, got %+v", want, data.Report.Excluded)
+	}
+}
+
+func TestExclusionsClearedOnNextRun(t *testing.T) {
+	processor := New()
+	processor.SetExclusions([]string{"Monitor"}, nil)
+	if total := countryRevenueTotal(processExcluded(t, processor)); total != 2440 {
+		t.Fatalf("Expected a revenue total of 2440 without the monitor, got %v", total)
+	}
+
+	// Changed exclusions leave the served data alone until the next run
+	processor.SetExclusions(nil, []string{" "})
+	if got := processor.GetExclusions(); len(got.Products) != 0 || len(got.Countries) != 0 {
+		t.Errorf("Expected blank exclusions to clear them, got %+v", got)
+	}
+	if excluded := processor.GetDashboardData().Report.Excluded; len(excluded) != 1 {
+		t.Errorf("Expected the served report to keep its exclusion, got %+v", excluded)
+	}
+
+	data := processExcluded(t, processor)
+	if total := countryRevenueTotal(data); total != 2740 {
+		t.Errorf("Expected a revenue total of 2740, got %v", total)
+	}
+	if data.Report.Excluded != nil {
+		t.Errorf("Expected no excluded totals, got %+v", data.Report.Excluded)
+	}
+	if results := processor.SearchProducts("monitor", 10); len(results) != 1 {
+		t.Errorf("Expected the monitor to be searchable again, got %+v", results)
+	}
+}
@@ -0,0 +1,123 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// supportedExtensions lists the dataset file extensions picked up when
+// DATA_FILE_PATH points at a directory
+var supportedExtensions = []string{".csv", ".csv.gz"}
+
+// resolveDataFiles expands a dataset path into the list of files to process.
+// The path may be a single file, a directory (all supported files inside it)
+// or a glob pattern. Files are returned in lexical order.
+func resolveDataFiles(path string) ([]string, error) {
+	if strings.ContainsAny(path, "*?[") {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dataset pattern %s: %w", path, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%w: no files match %s", ErrNotFound, path)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if err := validateDataFile(match); err != nil {
+				return nil, err
+			}
+		}
+		return matches, nil
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, classifyPathError(absPath, err)
+	}
+	if !info.IsDir() {
+		if err := validateDataFile(path); err != nil {
+			return nil, err
+		}
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(absPath)
+	if err != nil {
+		return nil, classifyPathError(absPath, err)
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !isSupportedDataFile(entry.Name()) {
+			continue
+		}
+		file := filepath.Join(path, entry.Name())
+		if err := validateDataFile(file); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: no %s files in directory %s", ErrNotFound, strings.Join(supportedExtensions, " or "), absPath)
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// isSupportedDataFile reports whether a file name has a supported extension
+func isSupportedDataFile(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range supportedExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDataFile checks that the dataset path exists, is a regular file
+// and is readable before any processing starts
+func validateDataFile(filePath string) error {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		absPath = filePath
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return classifyPathError(absPath, err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%w: %s", ErrNotAFile, absPath)
+	}
+
+	file, err := os.Open(absPath)
+	if err != nil {
+		return classifyPathError(absPath, err)
+	}
+	file.Close()
+
+	return nil
+}
+
+// classifyPathError maps an os error onto the typed dataset path errors
+func classifyPathError(absPath string, err error) error {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("%w: %s", ErrNotFound, absPath)
+	case errors.Is(err, os.ErrPermission):
+		return fmt.Errorf("%w: %s", ErrPermission, absPath)
+	default:
+		return fmt.Errorf("failed to access %s: %w", absPath, err)
+	}
+}
@@ -0,0 +1,135 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const shardHeader = "transaction_id,transaction_date,user_id,country,region,product_id,product_name,category,price,quantity,total_price,stock_quantity,added_date\n"
+
+var shardRows = []string{
+	"TXN001,2024-01-15,USER001,USA,North America,PROD001,Laptop,Electronics,1000,1,1000,10,2024-01-01\n" +
+		"TXN002,2024-02-10,USER002,UK,Europe,PROD002,Phone,Electronics,500,2,1000,20,2024-01-01\n",
+	"TXN003,2024-01-20,USER003,USA,North America,PROD002,Phone,Electronics,500,1,500,19,2024-01-01\n",
+	"TXN004,2024-03-05,USER004,Germany,Europe,PROD001,Laptop,Electronics,1000,3,3000,7,2024-01-01\n" +
+		"TXN005,2024-03-06,USER005,UK,Europe,PROD001,Laptop,Electronics,1000,1,1000,6,2024-01-01\n",
+}
+
+// writeShards writes the shard fixtures into dir, gzipping the last one
+func writeShards(t *testing.T, dir string) {
+	t.Helper()
+
+	for i, rows := range shardRows {
+		content := []byte(shardHeader + rows)
+		name := filepath.Join(dir, fmt.Sprintf("sales_part_%03d.csv", i))
+
+		if i == len(shardRows)-1 {
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			gz.Write(content)
+			gz.Close()
+			content = buf.Bytes()
+			name += ".gz"
+		}
+
+		if err := os.WriteFile(name, content, 0644); err != nil {
+			t.Fatalf("Failed to write shard: %v", err)
+		}
+	}
+}
+
+// revenueTotals keys country revenue rows so results can be compared
+// regardless of sort order among equal totals
+func revenueTotals(revenues []models.CountryRevenue) map[string]float64 {
+	totals := make(map[string]float64)
+	for _, revenue := range revenues {
+		totals[revenue.Country+"/"+revenue.ProductName] = revenue.TotalRevenue
+	}
+	return totals
+}
+
+func TestProcessDatasetShardsMatchSingleFile(t *testing.T) {
+	dir := t.TempDir()
+	writeShards(t, dir)
+
+	combined := shardHeader
+	for _, rows := range shardRows {
+		combined += rows
+	}
+	single := New()
+	if err := single.ProcessDataset(writeTestFile(t, "combined.csv", combined)); err != nil {
+		t.Fatalf("Failed to process combined file: %v", err)
+	}
+
+	for _, path := range []string{dir, filepath.Join(dir, "sales_part_*")} {
+		sharded := New()
+		if err := sharded.ProcessDataset(path); err != nil {
+			t.Fatalf("Failed to process shards from %s: %v", path, err)
+		}
+
+		expected := revenueTotals(single.GetCountryRevenues())
+		actual := revenueTotals(sharded.GetCountryRevenues())
+		if len(actual) != len(expected) {
+			t.Fatalf("Expected %d country revenue rows, got %d", len(expected), len(actual))
+		}
+		for key, total := range expected {
+			if actual[key] != total {
+				t.Errorf("Expected %s revenue %f, got %f", key, total, actual[key])
+			}
+		}
+
+		if len(sharded.GetMonthlySales()) != len(single.GetMonthlySales()) {
+			t.Errorf("Expected %d months, got %d", len(single.GetMonthlySales()), len(sharded.GetMonthlySales()))
+		}
+		if len(sharded.GetTopRegions()) != len(single.GetTopRegions()) {
+			t.Errorf("Expected %d regions, got %d", len(single.GetTopRegions()), len(sharded.GetTopRegions()))
+		}
+
+		expectedRows := []int{2, 1, 2}
+		files := sharded.GetDashboardData().Report.Files
+		if len(files) != len(expectedRows) {
+			t.Fatalf("Expected %d file reports, got %d", len(expectedRows), len(files))
+		}
+		for i, file := range files {
+			if file.Rows != expectedRows[i] {
+				t.Errorf("Expected %d rows for %s, got %d", expectedRows[i], file.Path, file.Rows)
+			}
+		}
+	}
+}
+
+func TestResolveDataFilesSkipsUnsupportedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeShards(t, dir)
+	os.WriteFile(filepath.Join(dir, "README.txt"), []byte("notes"), 0644)
+
+	files, err := resolveDataFiles(dir)
+	if err != nil {
+		t.Fatalf("Failed to resolve files: %v", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("Expected 3 dataset files, got %v", files)
+	}
+}
+
+func TestResolveDataFilesNoGlobMatches(t *testing.T) {
+	_, err := resolveDataFiles(filepath.Join(t.TempDir(), "*.csv"))
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestProcessDatasetCorruptGzip(t *testing.T) {
+	path := writeTestFile(t, "broken.csv.gz", "not gzip data")
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err == nil {
+		t.Error("Expected error for corrupt gzip file")
+	}
+}
@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"time"
+)
+
+// applyGrowth sets MoMGrowthPercent and YoYGrowthPercent on each entry by
+// comparing TotalSales with the previous calendar month and with the same
+// month a year earlier. A growth figure stays nil when that month is absent
+// or had no sales, rather than being guessed across gaps.
+func applyGrowth(sales []models.MonthlySales) {
+	byMonth := make(map[int]float64, len(sales))
+	for _, sale := range sales {
+		if index, ok := monthIndex(sale); ok {
+			byMonth[index] = sale.TotalSales
+		}
+	}
+
+	for i := range sales {
+		index, ok := monthIndex(sales[i])
+		if !ok {
+			continue
+		}
+		if prior, ok := byMonth[index-1]; ok {
+			sales[i].MoMGrowthPercent = growthPercent(sales[i].TotalSales, prior)
+		}
+		if prior, ok := byMonth[index-12]; ok {
+			sales[i].YoYGrowthPercent = growthPercent(sales[i].TotalSales, prior)
+		}
+	}
+}
+
+// monthIndex numbers months consecutively (year*12 + month) so that the
+// previous month and the same month last year are index-1 and index-12.
+// It uses MonthNumber, falling back to the English Month name for entries
+// built without one.
+func monthIndex(sale models.MonthlySales) (int, bool) {
+	number := sale.MonthNumber
+	if number < 1 || number > 12 {
+		month, err := time.Parse("January", sale.Month)
+		if err != nil {
+			return 0, false
+		}
+		number = int(month.Month())
+	}
+	return sale.Year*12 + number - 1, true
+}
+
+// growthPercent returns the percentage change from prior to current, or nil
+// when prior is zero and the change is undefined
+func growthPercent(current, prior float64) *float64 {
+	if prior == 0 {
+		return nil
+	}
+	growth := (current - prior) / prior * 100
+	return &growth
+}
@@ -0,0 +1,124 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func findMonth(t *testing.T, sales []models.MonthlySales, month string, year int) models.MonthlySales {
+	t.Helper()
+
+	for _, sale := range sales {
+		if sale.Month == month && sale.Year == year {
+			return sale
+		}
+	}
+	t.Fatalf("Expected %s %d in monthly sales", month, year)
+	return models.MonthlySales{}
+}
+
+func assertGrowth(t *testing.T, name string, got *float64, want float64) {
+	t.Helper()
+
+	if got == nil {
+		t.Errorf("Expected %s %.2f, got nil", name, want)
+	} else if math.Abs(*got-want) > 0.01 {
+		t.Errorf("Expected %s %.2f, got %.2f", name, want, *got)
+	}
+}
+
+func assertNoGrowth(t *testing.T, name string, got *float64) {
+	t.Helper()
+
+	if got != nil {
+		t.Errorf("Expected %s to be nil, got %.2f", name, *got)
+	}
+}
+
+func TestMonthlySalesGrowth(t *testing.T) {
+	path := writeTestFile(t, "growth.csv", `transaction_id,transaction_date,product_name,quantity,total_price
+TXN001,2023-01-10,Laptop,1,100
+TXN002,2023-03-05,Laptop,1,0
+TXN003,2024-01-12,Laptop,1,150
+TXN004,2024-02-01,Laptop,1,120
+TXN005,2024-02-20,Laptop,1,80
+TXN006,2024-03-15,Laptop,1,50
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sales := processor.GetMonthlySales()
+
+	// Prior-year month present; previous month (December 2023) missing
+	january := findMonth(t, sales, "January", 2024)
+	assertGrowth(t, "January YoY", january.YoYGrowthPercent, 50)
+	assertNoGrowth(t, "January MoM", january.MoMGrowthPercent)
+
+	// Previous month present; prior-year month missing
+	february := findMonth(t, sales, "February", 2024)
+	assertGrowth(t, "February MoM", february.MoMGrowthPercent, 33.33)
+	assertNoGrowth(t, "February YoY", february.YoYGrowthPercent)
+
+	// Prior-year month had zero sales
+	march := findMonth(t, sales, "March", 2024)
+	assertGrowth(t, "March MoM", march.MoMGrowthPercent, -75)
+	assertNoGrowth(t, "March YoY", march.YoYGrowthPercent)
+
+	// February 2023 is absent, so March 2023 has no month-over-month figure
+	assertNoGrowth(t, "March 2023 MoM", findMonth(t, sales, "March", 2023).MoMGrowthPercent)
+
+	encoded, err := json.Marshal(march)
+	if err != nil {
+		t.Fatalf("Failed to marshal monthly sales: %v", err)
+	}
+	if strings.Contains(string(encoded), "yoy_growth_percent") || strings.Contains(string(encoded), "Inf") {
+		t.Errorf("Expected yoy_growth_percent to be omitted, got %s", encoded)
+	}
+}
+
+func TestGrowthPercentZeroPrior(t *testing.T) {
+	assertNoGrowth(t, "growth from zero", growthPercent(100, 0))
+	assertGrowth(t, "growth to zero", growthPercent(0, 100), -100)
+}
+
+func TestMonthlySalesAverageDaily(t *testing.T) {
+	// March has sales on 5 days, two of them with several transactions; the
+	// first one is just after midnight in the data timezone
+	path := writeTestFile(t, "daily.csv", `transaction_id,transaction_date,product_name,quantity,total_price
+TXN001,2024-02-10,Laptop,1,290
+TXN002,2024-03-01T00:30:00+02:00,Laptop,1,100
+TXN003,2024-03-01,Mouse,1,20
+TXN004,2024-03-04,Laptop,1,100
+TXN005,2024-03-04,Mouse,2,40
+TXN006,2024-03-04,Cable,1,10
+TXN007,2024-03-11,Laptop,1,100
+TXN008,2024-03-12,Mouse,1,20
+TXN009,2024-03-20,Laptop,1,110
+`)
+
+	processor := New()
+	processor.SetDataTimezone(time.FixedZone("UTC+2", 2*60*60))
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sales := processor.GetMonthlySales()
+
+	march := findMonth(t, sales, "March", 2024)
+	if march.DaysWithSales != 5 {
+		t.Errorf("Expected 5 days with sales in March, got %d", march.DaysWithSales)
+	}
+	if math.Abs(march.AverageDailySales-100) > 0.001 {
+		t.Errorf("Expected average daily sales 100 (500 over 5 days), got %.2f", march.AverageDailySales)
+	}
+
+	february := findMonth(t, sales, "February", 2024)
+	if february.DaysWithSales != 1 || february.AverageDailySales != 290 {
+		t.Errorf("Expected February averaged over its single day, got %d days and %.2f", february.DaysWithSales, february.AverageDailySales)
+	}
+}
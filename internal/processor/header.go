@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+// transactionColumns are the CSV columns read into transaction fields
+var transactionColumns = []string{
+	"transaction_id", "transaction_date", "user_id", "country", "region", "currency",
+	"product_id", "product_name", "category", "price", "quantity", "total_price",
+	"stock_quantity", "added_date",
+}
+
+// Reasons a header column is not read
+const (
+	ignoredUnknown   = "unknown column"
+	ignoredDuplicate = "duplicate column; a later column with the same name is read"
+)
+
+// resolveHeader maps the columns of a CSV header to transaction fields.
+// Names match case-insensitively with surrounding spaces trimmed; when a
+// name repeats, the last column is read. It returns the column index of
+// each field, used for every row of the file, and the mapping for the
+// processing report.
+func resolveHeader(headers []string) (map[string]int, models.HeaderMapping) {
+	headerMap := make(map[string]int)
+	for i, header := range headers {
+		headerMap[strings.TrimSpace(strings.ToLower(header))] = i
+	}
+
+	known := make(map[string]bool, len(transactionColumns))
+	for _, column := range transactionColumns {
+		known[column] = true
+	}
+
+	mapping := models.HeaderMapping{
+		Fields:  make([]models.ColumnMapping, 0, len(headers)),
+		Ignored: make([]models.ColumnMapping, 0),
+	}
+	for i, header := range headers {
+		name := strings.TrimSpace(strings.ToLower(header))
+		column := models.ColumnMapping{Column: header, Index: i}
+		switch {
+		case !known[name]:
+			column.Reason = ignoredUnknown
+			mapping.Ignored = append(mapping.Ignored, column)
+		case headerMap[name] != i:
+			column.Reason = ignoredDuplicate
+			mapping.Ignored = append(mapping.Ignored, column)
+		default:
+			column.Field = name
+			mapping.Fields = append(mapping.Fields, column)
+		}
+	}
+	return headerMap, mapping
+}
+
+// describeHeaderMapping summarizes a header mapping on one log line, e.g.
+// `product_name="Product Name"(2), quantity(3); ignored: "notes"(4) unknown column`.
+// Columns named exactly like their field are listed by the field alone.
+func describeHeaderMapping(mapping models.HeaderMapping) string {
+	parts := make([]string, len(mapping.Fields))
+	for i, column := range mapping.Fields {
+		if column.Column == column.Field {
+			parts[i] = fmt.Sprintf("%s(%d)", column.Field, column.Index)
+		} else {
+			parts[i] = fmt.Sprintf("%s=%q(%d)", column.Field, column.Column, column.Index)
+		}
+	}
+	description := strings.Join(parts, ", ")
+
+	if len(mapping.Ignored) > 0 {
+		ignored := make([]string, len(mapping.Ignored))
+		for i, column := range mapping.Ignored {
+			ignored[i] = fmt.Sprintf("%q(%d) %s", column.Column, column.Index, column.Reason)
+		}
+		description += "; ignored: " + strings.Join(ignored, ", ")
+	}
+	return description
+}
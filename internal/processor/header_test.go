@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHeaderMappingReported(t *testing.T) {
+	content := " Transaction_ID ,PRODUCT_NAME,notes,Quantity,quantity,Total_Price\n" +
+		"TXN001,Laptop,gift,9,1,100\n"
+	path := writeTestFile(t, "mapped.csv", content)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	report := processor.GetDashboardData().Report
+	if len(report.Files) != 1 || report.Files[0].Header == nil {
+		t.Fatalf("Expected a header mapping for the file, got %+v", report.Files)
+	}
+
+	header := report.Files[0].Header
+	wantFields := []models.ColumnMapping{
+		{Column: " Transaction_ID ", Index: 0, Field: "transaction_id"},
+		{Column: "PRODUCT_NAME", Index: 1, Field: "product_name"},
+		{Column: "quantity", Index: 4, Field: "quantity"},
+		{Column: "Total_Price", Index: 5, Field: "total_price"},
+	}
+	if !reflect.DeepEqual(header.Fields, wantFields) {
+		t.Errorf("Expected fields %+v, got %+v", wantFields, header.Fields)
+	}
+	wantIgnored := []models.ColumnMapping{
+		{Column: "notes", Index: 2, Reason: ignoredUnknown},
+		{Column: "Quantity", Index: 3, Reason: ignoredDuplicate},
+	}
+	if !reflect.DeepEqual(header.Ignored, wantIgnored) {
+		t.Errorf("Expected ignored columns %+v, got %+v", wantIgnored, header.Ignored)
+	}
+
+	// The later quantity column is the one read
+	if units := processor.GetTopProducts()[0].UnitsSold; units != 1 {
+		t.Errorf("Expected 1 unit from the last quantity column, got %d", units)
+	}
+}
+
+func TestHeaderMappingLogged(t *testing.T) {
+	path := writeTestFile(t, "mapped.csv", "Product Name,product_name,quantity,total_price\nx,Laptop,1,100\n")
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	lines, _, _ := processor.RunLog(0)
+	logged := 0
+	for _, line := range lines {
+		if strings.HasPrefix(line.Message, "Header mapping: ") {
+			logged++
+			want := `product_name(1), quantity(2), total_price(3); ignored: "Product Name"(0) unknown column`
+			if !strings.HasSuffix(line.Message, want) {
+				t.Errorf("Expected the mapping %q, got %q", want, line.Message)
+			}
+		}
+	}
+	if logged != 1 {
+		t.Errorf("Expected the mapping to be logged once, got %d times", logged)
+	}
+}
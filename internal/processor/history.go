@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"fmt"
+)
+
+// Values of DashboardData.DataSource and ProcessingRun.Source
+const (
+	SourceNone    = "none"
+	SourceDataset = "dataset"
+	SourceReader  = "reader"
+	SourceSample  = "sample"
+	SourceEmpty   = "empty"
+)
+
+// historyLimit bounds how many processing runs are remembered
+const historyLimit = 20
+
+// swapDashboardData replaces the served dashboard data and records the run
+// that produced it
+func (p *Processor) swapDashboardData(data *models.DashboardData, run models.ProcessingRun) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.storeData(data)
+	p.appendHistory(run)
+}
+
+// storeData serves data, keeping the data it replaces as the previous
+// snapshot for GetDashboardDiff; callers hold p.mu
+func (p *Processor) storeData(data *models.DashboardData) {
+	p.previous = p.data.Load()
+	p.data.Store(data)
+}
+
+// recordRun records a run that did not replace the served data
+func (p *Processor) recordRun(run models.ProcessingRun) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.appendHistory(run)
+}
+
+// appendHistory adds run to the bounded history; callers hold p.mu
+func (p *Processor) appendHistory(run models.ProcessingRun) {
+	p.history = append(p.history, run)
+	if len(p.history) > historyLimit {
+		p.history = p.history[len(p.history)-historyLimit:]
+	}
+}
+
+// GetProcessingHistory returns the most recent processing runs, newest first
+func (p *Processor) GetProcessingHistory() []models.ProcessingRun {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	history := make([]models.ProcessingRun, len(p.history))
+	for i, run := range p.history {
+		history[len(p.history)-1-i] = run
+	}
+	return history
+}
+
+// setLastFailedReport keeps the report of a run rejected for skipping too
+// many rows
+func (p *Processor) setLastFailedReport(report models.ProcessingReport) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lastFailedReport = &report
+}
+
+// GetLastFailedReport returns the report of the most recent run rejected
+// for skipping more than the MAX_BAD_ROW_RATIO share of its rows. The
+// second return value is false when no run has been rejected.
+func (p *Processor) GetLastFailedReport() (models.ProcessingReport, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.lastFailedReport == nil {
+		return models.ProcessingReport{}, false
+	}
+	return *p.lastFailedReport, true
+}
+
+// describeSources summarises the files read by a run for its history entry
+func describeSources(sources []string) string {
+	switch len(sources) {
+	case 0:
+		return ""
+	case 1:
+		return sources[0]
+	default:
+		return fmt.Sprintf("%s (+%d more)", sources[0], len(sources)-1)
+	}
+}
@@ -0,0 +1,158 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"sort"
+	"strings"
+)
+
+// indexData builds the lookup structures of data from its country revenue
+// rows and product index. It must run once those are final.
+func indexData(data *models.DashboardData) {
+	data.RevenueIndex = buildRevenueIndex(data.CountryRevenues)
+	data.ProductKeyOrder = buildProductKeyOrder(data.ProductIndex)
+}
+
+// buildRevenueIndex maps the lowercased country and product name of each
+// row to the offsets of the rows that have it
+func buildRevenueIndex(rows []models.CountryRevenue) models.RevenueIndex {
+	index := models.RevenueIndex{
+		ByCountry: make(map[string][]int),
+		ByProduct: make(map[string][]int),
+	}
+	for i, row := range rows {
+		country := strings.ToLower(row.Country)
+		index.ByCountry[country] = append(index.ByCountry[country], i)
+		product := strings.ToLower(row.ProductName)
+		index.ByProduct[product] = append(index.ByProduct[product], i)
+	}
+	return index
+}
+
+// buildProductKeyOrder returns the offsets of the search index entries in
+// order of their lowercased name, ties in index order
+func buildProductKeyOrder(index []models.ProductSearchEntry) []int {
+	order := make([]int, len(index))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return index[order[i]].Key < index[order[j]].Key
+	})
+	return order
+}
+
+// indexedRows returns the offsets of the rows matching the country and
+// product filters, both lowercased sets, in increasing order. It returns
+// false when neither filter is set or the data was stored without an
+// index, so that the caller scans every row instead.
+func indexedRows(index models.RevenueIndex, countries, products map[string]struct{}) ([]int, bool) {
+	if index.ByCountry == nil || (len(countries) == 0 && len(products) == 0) {
+		return nil, false
+	}
+	var offsets []int
+	if len(countries) > 0 {
+		offsets = unionOffsets(index.ByCountry, countries)
+	}
+	if len(products) > 0 {
+		byProduct := unionOffsets(index.ByProduct, products)
+		if len(countries) > 0 {
+			byProduct = intersectOffsets(offsets, byProduct)
+		}
+		offsets = byProduct
+	}
+	return offsets, true
+}
+
+// countryRows returns the country revenue rows of country, in their order,
+// reading only the indexed rows when data has an index
+func countryRows(data *models.DashboardData, country string) []models.CountryRevenue {
+	var rows []models.CountryRevenue
+	offsets, indexed := indexedRows(data.RevenueIndex, map[string]struct{}{strings.ToLower(country): {}}, nil)
+	if !indexed {
+		for _, row := range data.CountryRevenues {
+			if row.Country == country {
+				rows = append(rows, row)
+			}
+		}
+		return rows
+	}
+	for _, offset := range offsets {
+		// The index ignores case; the detail does not
+		if row := data.CountryRevenues[offset]; row.Country == country {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// unionOffsets merges the offset lists of keys into one increasing list
+func unionOffsets(lists map[string][]int, keys map[string]struct{}) []int {
+	var offsets []int
+	for key := range keys {
+		offsets = append(offsets, lists[key]...)
+	}
+	// The lists of different keys never share an offset
+	if len(keys) > 1 {
+		sort.Ints(offsets)
+	}
+	return offsets
+}
+
+// intersectOffsets returns the offsets in both increasing lists
+func intersectOffsets(a, b []int) []int {
+	offsets := make([]int, 0, min(len(a), len(b)))
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			offsets = append(offsets, a[i])
+			i++
+			j++
+		}
+	}
+	return offsets
+}
+
+// prefixSearch answers a product search from the names starting with the
+// lowercased query alone, found by binary search of the name order. It
+// returns false when those are fewer than limit, or the data has no name
+// order, as the search must then also scan for names containing the query.
+func prefixSearch(data *models.DashboardData, query string, limit int) ([]models.ProductFrequency, bool) {
+	index, order := data.ProductIndex, data.ProductKeyOrder
+	if len(order) != len(index) {
+		return nil, false
+	}
+
+	var exact, prefix []int
+	start := sort.Search(len(order), func(i int) bool { return index[order[i]].Key >= query })
+	for _, offset := range order[start:] {
+		key := index[offset].Key
+		if !strings.HasPrefix(key, query) {
+			break
+		}
+		if key == query {
+			exact = append(exact, offset)
+		} else {
+			prefix = append(prefix, offset)
+		}
+	}
+	if len(exact)+len(prefix) < limit {
+		return nil, false
+	}
+
+	// Within each match quality, results keep purchase count order
+	sort.Ints(exact)
+	sort.Ints(prefix)
+	results := make([]models.ProductFrequency, 0, limit)
+	for _, offset := range append(exact, prefix...) {
+		if len(results) == limit {
+			break
+		}
+		results = append(results, index[offset].Product)
+	}
+	return results, true
+}
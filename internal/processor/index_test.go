@@ -0,0 +1,179 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+// syntheticData builds indexed dashboard data of countries × products
+// country revenue rows with random totals, and a search index of the
+// products with names sharing prefixes
+func syntheticData(rng *rand.Rand, countries, products int) *models.DashboardData {
+	data := &models.DashboardData{}
+	productMap := make(map[string]*models.ProductFrequency, products)
+	for p := 0; p < products; p++ {
+		name := fmt.Sprintf("%s Product %d", []string{"Cable", "Cam", "Camera", "Desk", "Laptop"}[p%5], p)
+		productMap[name] = &models.ProductFrequency{ProductName: name, PurchaseCount: 1 + rng.Intn(500)}
+		for c := 0; c < countries; c++ {
+			transactions := 1 + rng.Intn(50)
+			revenue := float64(rng.Intn(100000)) / 100
+			data.CountryRevenues = append(data.CountryRevenues, withAverageOrderValue(models.CountryRevenue{
+				Country:          fmt.Sprintf("Country %d", c),
+				ProductName:      name,
+				TotalRevenue:     revenue,
+				TransactionCount: transactions,
+			}))
+		}
+	}
+	sort.SliceStable(data.CountryRevenues, func(i, j int) bool {
+		return data.CountryRevenues[i].TotalRevenue > data.CountryRevenues[j].TotalRevenue
+	})
+	data.ProductIndex = New().buildProductIndex(productMap)
+	indexData(data)
+	return data
+}
+
+// withoutIndex returns a processor serving a copy of data without its
+// indexes, so that every query scans all rows
+func withoutIndex(data *models.DashboardData) *Processor {
+	unindexed := *data
+	unindexed.RevenueIndex = models.RevenueIndex{}
+	unindexed.ProductKeyOrder = nil
+	p := New()
+	p.data.Store(&unindexed)
+	return p
+}
+
+// randomSubset picks up to n of values at random, sometimes changing their
+// case or adding names that are not in the data
+func randomSubset(rng *rand.Rand, values []string, n int) []string {
+	subset := make([]string, 0, n)
+	for i := rng.Intn(n + 1); i > 0; i-- {
+		value := values[rng.Intn(len(values))]
+		switch rng.Intn(4) {
+		case 0:
+			value = strings.ToUpper(value)
+		case 1:
+			value = "Unknown " + value
+		}
+		subset = append(subset, value)
+	}
+	return subset
+}
+
+func TestIndexedQueriesMatchScan(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	data := syntheticData(rng, 12, 40)
+	indexed := New()
+	indexed.data.Store(data)
+	scanned := withoutIndex(data)
+
+	var countries, products []string
+	for c := 0; c < 12; c++ {
+		countries = append(countries, fmt.Sprintf("Country %d", c))
+	}
+	for _, entry := range data.ProductIndex {
+		products = append(products, entry.Product.ProductName)
+	}
+
+	for i := 0; i < 500; i++ {
+		query := models.CountryRevenueQuery{
+			Countries:  randomSubset(rng, countries, 3),
+			Products:   randomSubset(rng, products, 3),
+			MinRevenue: float64(rng.Intn(3)) * 200,
+			SortBy:     CountryRevenueSortFields[rng.Intn(len(CountryRevenueSortFields))],
+			Page:       1 + rng.Intn(3),
+			PageSize:   rng.Intn(20),
+		}
+		if rng.Intn(3) == 0 {
+			maxAvgOrder := float64(rng.Intn(50))
+			query.MaxAvgOrder = &maxAvgOrder
+		}
+
+		gotRows, gotTotal, gotFiltered := indexed.QueryCountryRevenues(query)
+		wantRows, wantTotal, wantFiltered := scanned.QueryCountryRevenues(query)
+		if gotTotal != wantTotal || gotFiltered != wantFiltered || !reflect.DeepEqual(gotRows, wantRows) {
+			t.Fatalf("Query %+v: indexed result (%d rows, %d filtered) differs from scan (%d rows, %d filtered)",
+				query, gotTotal, gotFiltered, wantTotal, wantFiltered)
+		}
+
+		gotGroups, _, _ := indexed.QueryCountryRevenueGroups(query)
+		wantGroups, _, _ := scanned.QueryCountryRevenueGroups(query)
+		if !reflect.DeepEqual(gotGroups, wantGroups) {
+			t.Fatalf("Query %+v: indexed groups differ from scan", query)
+		}
+	}
+
+	for _, country := range []string{"Country 3", "country 3", "Country 99"} {
+		got, gotOK := indexed.GetCountryDetail(country)
+		want, wantOK := scanned.GetCountryDetail(country)
+		if gotOK != wantOK || !reflect.DeepEqual(got, want) {
+			t.Errorf("Country %q: indexed detail differs from scan", country)
+		}
+	}
+}
+
+func TestIndexedSearchMatchesScan(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	data := syntheticData(rng, 1, 200)
+	indexed := New()
+	indexed.data.Store(data)
+	scanned := withoutIndex(data)
+
+	queries := []string{"c", "ca", "cam", "camera", "CAMERA PRODUCT 7", "cable product 10", "desk", "product", "top", "lptp", "zzz"}
+	for _, query := range queries {
+		for _, limit := range []int{1, 5, 10, 50, 500} {
+			got := indexed.SearchProducts(query, limit)
+			want := scanned.SearchProducts(query, limit)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Search %q (limit %d): indexed results differ from scan", query, limit)
+			}
+		}
+	}
+}
+
+func TestIndexRebuiltOnProcess(t *testing.T) {
+	path := writeTestFile(t, "indexed.csv", unattributedFixture)
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data := processor.GetDashboardData()
+	if len(data.RevenueIndex.ByProduct["laptop"]) == 0 {
+		t.Errorf("Expected the laptop rows to be indexed, got %v", data.RevenueIndex.ByProduct)
+	}
+	if len(data.ProductKeyOrder) != len(data.ProductIndex) {
+		t.Errorf("Expected every product in the name order, got %d of %d", len(data.ProductKeyOrder), len(data.ProductIndex))
+	}
+}
+
+// BenchmarkFilteredQuery compares a filtered country revenue query over
+// 500k rows read through the index with a scan of every row
+func BenchmarkFilteredQuery(b *testing.B) {
+	data := syntheticData(rand.New(rand.NewSource(1)), 200, 2500)
+	query := models.CountryRevenueQuery{Countries: []string{"Country 7"}, Products: []string{"Laptop Product 4"}, PageSize: 50}
+
+	for _, bench := range []struct {
+		name      string
+		processor *Processor
+	}{
+		{"indexed", func() *Processor { p := New(); p.data.Store(data); return p }()},
+		{"scan", withoutIndex(data)},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, _, _, err := bench.processor.QueryCountryRevenuesContext(context.Background(), query); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package processor
+
+import "fmt"
+
+// OtherBucket is the key that new rows are folded into once an aggregation
+// map holds the configured maximum number of keys
+const OtherBucket = "Other"
+
+// Names of the capped aggregation maps, as reported in the processing
+// report's overflow counts
+const (
+	overflowCountryRevenues   = "country_revenues"
+	overflowProducts          = "products"
+	overflowRegions           = "regions"
+	overflowRegionProducts    = "region_products"
+	overflowCategoryProducts  = "category_products"
+	overflowCountryMonths     = "country_months"
+	overflowCountries         = "countries"
+	overflowUsers             = "users"
+	overflowCustomerCountries = "customer_countries"
+	overflowCountryCustomers  = "country_customers"
+)
+
+// SetMaxAggregationKeys caps the number of keys held by each aggregation
+// map. Once a map is full, rows with new keys are folded into OtherBucket
+// and counted as overflow, bounding memory use for datasets with huge
+// cardinality. Zero or a negative value disables the cap.
+func (p *Processor) SetMaxAggregationKeys(n int) {
+	p.maxAggregationKeys = n
+}
+
+// cappedKey returns key when m already holds it or still has room, and
+// OtherBucket otherwise, counting the overflow against name. Callers must
+// hold agg.mu.
+func cappedKey[V any](agg *aggregates, name string, m map[string]V, key string) string {
+	if agg.maxKeys <= 0 {
+		return key
+	}
+	if _, exists := m[key]; exists || len(m) < agg.maxKeys {
+		return key
+	}
+	agg.overflow[name]++
+	return OtherBucket
+}
+
+// addCapped adds key to a distinct-value set unless the set is full, in
+// which case the row is counted as overflow against name. Callers must hold
+// agg.mu.
+func addCapped(agg *aggregates, name string, set map[string]struct{}, key string) {
+	if _, exists := set[key]; exists {
+		return
+	}
+	if agg.maxKeys > 0 && len(set) >= agg.maxKeys {
+		agg.overflow[name]++
+		return
+	}
+	set[key] = struct{}{}
+}
+
+// overflowWarning describes a truncated aggregation for the processing report
+func overflowWarning(maxKeys int, overflow map[string]int) string {
+	return fmt.Sprintf(
+		"aggregation key limit of %d reached; rows with new keys were folded into %q (overflow: %v)",
+		maxKeys, OtherBucket, overflow)
+}
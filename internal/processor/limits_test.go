@@ -0,0 +1,91 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// highCardinalityCSV returns a dataset with n distinct products and users,
+// all sold in the same country and region
+func highCardinalityCSV(n int) string {
+	var b strings.Builder
+	b.WriteString("transaction_id,transaction_date,user_id,country,region,product_id,product_name,category,price,quantity,total_price,stock_quantity,added_date\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "TXN%03d,2024-01-15,USER%03d,USA,North America,PROD%03d,Product %03d,Electronics,10,1,10,5,2024-01-01\n", i, i, i, i)
+	}
+	return b.String()
+}
+
+func TestProcessDatasetFoldsOverflowIntoOther(t *testing.T) {
+	path := writeTestFile(t, "wide.csv", highCardinalityCSV(10))
+
+	processor := New()
+	processor.SetWorkers(1)
+	processor.SetMaxAggregationKeys(3)
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	products := processor.GetTopProducts()
+	if len(products) != 4 {
+		t.Fatalf("Expected 3 products plus the Other bucket, got %d", len(products))
+	}
+	if products[0].ProductName != OtherBucket || products[0].PurchaseCount != 7 {
+		t.Errorf("Expected Other bucket with 7 purchases first, got %+v", products[0])
+	}
+
+	revenues := processor.GetCountryRevenues()
+	if len(revenues) != 4 {
+		t.Fatalf("Expected 3 country rows plus the Other bucket, got %d", len(revenues))
+	}
+	if revenues[0].Country != OtherBucket || revenues[0].TotalRevenue != 70 {
+		t.Errorf("Expected Other country row with revenue 70, got %+v", revenues[0])
+	}
+
+	regionProducts, ok := processor.GetRegionProducts("North America", 10)
+	if !ok || len(regionProducts) != 4 {
+		t.Errorf("Expected 4 region products, got %+v", regionProducts)
+	}
+
+	data := processor.GetDashboardData()
+	if !data.Report.Truncated {
+		t.Error("Expected processing report to be marked truncated")
+	}
+	for name, expected := range map[string]int{
+		overflowProducts:        7,
+		overflowCountryRevenues: 7,
+		overflowRegionProducts:  7,
+		overflowUsers:           7,
+	} {
+		if data.Report.Overflow[name] != expected {
+			t.Errorf("Expected %s overflow %d, got %d", name, expected, data.Report.Overflow[name])
+		}
+	}
+	if _, exists := data.Report.Overflow[overflowRegions]; exists {
+		t.Error("Expected no region overflow for a single region")
+	}
+	if data.DistinctUsers != 3 {
+		t.Errorf("Expected distinct users to stop at the cap of 3, got %d", data.DistinctUsers)
+	}
+	if len(data.Report.Warnings) != 1 {
+		t.Errorf("Expected a truncation warning, got %v", data.Report.Warnings)
+	}
+}
+
+func TestProcessDatasetWithoutCapIsNotTruncated(t *testing.T) {
+	path := writeTestFile(t, "wide.csv", highCardinalityCSV(10))
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	data := processor.GetDashboardData()
+	if data.Report.Truncated || len(data.Report.Overflow) != 0 {
+		t.Errorf("Expected no truncation, got %+v", data.Report)
+	}
+	if len(data.TopProducts) != 10 {
+		t.Errorf("Expected 10 products, got %d", len(data.TopProducts))
+	}
+}
@@ -0,0 +1,152 @@
+package processor
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log"
+	"strings"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+// DefaultMaxLineBytes is the longest CSV line read when SetMaxLineBytes has
+// not set another limit
+const DefaultMaxLineBytes = 1 << 20
+
+// MaxFieldChars is the number of characters kept of each text field stored
+// from a transaction; longer values are truncated
+const MaxFieldChars = 512
+
+// largeRecordBytes is the record size above which stored fields are copied
+// out of the record, so that they do not keep the whole line in memory
+const largeRecordBytes = 64 << 10
+
+// SetMaxLineBytes sets the longest CSV line, in bytes, that is read. Longer
+// lines are skipped and counted as oversized. Values <= 0 restore
+// DefaultMaxLineBytes.
+func (p *Processor) SetMaxLineBytes(n int) {
+	if n <= 0 {
+		n = DefaultMaxLineBytes
+	}
+	p.maxLineBytes = n
+}
+
+// lineLimitReader passes its input through one line at a time, dropping
+// lines longer than max bytes without buffering more than max bytes of
+// them. The limit applies to physical lines, so a quoted field spanning
+// several lines is measured line by line.
+type lineLimitReader struct {
+	r       *bufio.Reader
+	max     int
+	pending []byte
+	err     error
+
+	// lines counts the lines read and dropped the lines skipped
+	lines   int
+	dropped int
+
+	// logf reports skipped lines
+	logf func(format string, args ...interface{})
+}
+
+func newLineLimitReader(r io.Reader, max int) *lineLimitReader {
+	// Room for max bytes plus the line terminator; bufio enforces a minimum
+	// buffer size, so short lines are also checked against max
+	return &lineLimitReader{r: bufio.NewReaderSize(r, max+1), max: max, logf: log.Printf}
+}
+
+func (l *lineLimitReader) Read(p []byte) (int, error) {
+	for len(l.pending) == 0 {
+		if l.err != nil {
+			err := l.err
+			l.err = nil
+			return 0, err
+		}
+
+		line, err := l.r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) || lineLength(line) > l.max {
+			l.lines++
+			l.dropped++
+			l.logf("Skipping line %d: longer than %d bytes", l.lines, l.max)
+			if errors.Is(err, bufio.ErrBufferFull) {
+				err = l.skipLine()
+			}
+			l.err = err
+			continue
+		}
+		if len(line) > 0 {
+			l.lines++
+		}
+		l.pending, l.err = line, err
+	}
+
+	n := copy(p, l.pending)
+	l.pending = l.pending[n:]
+	return n, nil
+}
+
+// skipLine discards input up to and including the next line terminator
+func (l *lineLimitReader) skipLine() error {
+	for {
+		_, err := l.r.ReadSlice('\n')
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return err
+		}
+	}
+}
+
+// lineLength returns the length of line without its line terminator
+func lineLength(line []byte) int {
+	n := len(line)
+	if n > 0 && line[n-1] == '\n' {
+		n--
+		if n > 0 && line[n-1] == '\r' {
+			n--
+		}
+	}
+	return n
+}
+
+// capFields truncates the text fields of t that are longer than
+// MaxFieldChars and returns how many were truncated. When detach is set,
+// every field is copied so it no longer shares memory with its record.
+func capFields(t *models.Transaction, detach bool) int {
+	truncated := 0
+	fields := []*string{&t.TransactionID, &t.UserID, &t.ProductID, &t.ProductName, &t.Category, &t.Country, &t.Region, &t.Currency}
+	for _, field := range fields {
+		if value, ok := truncateField(*field); ok {
+			*field = value
+			truncated++
+		}
+		if detach {
+			*field = strings.Clone(*field)
+		}
+	}
+
+	// DisplayName is the same CSV field as ProductName, so it is capped
+	// without being counted again
+	if value, ok := truncateField(t.DisplayName); ok {
+		t.DisplayName = value
+	}
+	if detach {
+		t.DisplayName = strings.Clone(t.DisplayName)
+	}
+	return truncated
+}
+
+// truncateField cuts value to MaxFieldChars characters, reporting whether it
+// was longer
+func truncateField(value string) (string, bool) {
+	if len(value) <= MaxFieldChars {
+		return value, false
+	}
+	chars := 0
+	for i := range value {
+		if chars == MaxFieldChars {
+			return value[:i], true
+		}
+		chars++
+	}
+	return value, false
+}
@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
+	"unsafe"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+func TestOversizedLinesAreSkipped(t *testing.T) {
+	// A 5MB free-text column on the second row, well past the 64KiB limit
+	notes := strings.Repeat("lorem ipsum ", 5<<20/12)
+	path := writeTestFile(t, "long_lines.csv", "transaction_id,product_name,notes,quantity,total_price\n"+
+		"TXN001,Laptop,short,1,100\n"+
+		"TXN002,Laptop,"+notes+",1,100\n"+
+		"TXN003,Mouse,short,2,20\n"+
+		"TXN004,Mouse,"+notes)
+
+	processor := New()
+	processor.SetMaxLineBytes(64 << 10)
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected the load to succeed, got %v", err)
+	}
+
+	report := processor.GetDashboardData().Report
+	if report.Rows != 2 || report.OversizedLines != 2 || report.SkippedRows != 2 {
+		t.Errorf("Expected 2 rows and 2 skipped oversized lines, got %d rows, %d oversized, %d skipped",
+			report.Rows, report.OversizedLines, report.SkippedRows)
+	}
+	if len(report.Files) != 1 || report.Files[0].OversizedLines != 2 {
+		t.Errorf("Expected the file report to count 2 oversized lines, got %+v", report.Files)
+	}
+	if !containsWarning(report.Warnings, "lines longer than 65536 bytes") {
+		t.Errorf("Expected an oversized line warning, got %v", report.Warnings)
+	}
+}
+
+func TestOversizedHeaderFails(t *testing.T) {
+	path := writeTestFile(t, "long_header.csv", strings.Repeat("column,", 100)+"\nTXN001\n")
+
+	processor := New()
+	processor.SetMaxLineBytes(64)
+	if err := processor.ProcessDataset(path); err == nil || !strings.Contains(err.Error(), "header") {
+		t.Errorf("Expected a header error, got %v", err)
+	}
+}
+
+func TestLongFieldsAreTruncated(t *testing.T) {
+	longName := strings.Repeat("é", MaxFieldChars+100)
+	path := writeTestFile(t, "long_fields.csv", "transaction_id,product_name,country,quantity,total_price\n"+
+		"TXN001,"+longName+",USA,1,100\n"+
+		"TXN002,Mouse,"+strings.Repeat("X", 600)+",1,10\n")
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data := processor.GetDashboardData()
+	if data.Report.TruncatedFields != 2 {
+		t.Errorf("Expected 2 truncated fields, got %d", data.Report.TruncatedFields)
+	}
+	for _, product := range data.TopProducts {
+		if count := utf8.RuneCountInString(product.ProductName); count > MaxFieldChars {
+			t.Errorf("Expected product names of at most %d characters, got %d", MaxFieldChars, count)
+		}
+		if !utf8.ValidString(product.ProductName) {
+			t.Error("Expected truncation to keep whole characters")
+		}
+	}
+}
+
+func TestLineLimitReader(t *testing.T) {
+	input := "ok\n" + strings.Repeat("x", 20) + "\nfits-ten!\n" + strings.Repeat("y", 11) + "\r\nlast"
+	reader := newLineLimitReader(strings.NewReader(input), 10)
+
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(output) != "ok\nfits-ten!\nlast" {
+		t.Errorf("Expected the long lines to be dropped, got %q", output)
+	}
+	if reader.dropped != 2 || reader.lines != 5 {
+		t.Errorf("Expected 2 of 5 lines dropped, got %d of %d", reader.dropped, reader.lines)
+	}
+}
+
+func TestCapFieldsDetaches(t *testing.T) {
+	record := "TXN001,Laptop"
+	transaction := models.Transaction{TransactionID: record[:6], ProductName: record[7:]}
+
+	if truncated := capFields(&transaction, true); truncated != 0 {
+		t.Errorf("Expected no truncation, got %d", truncated)
+	}
+	if transaction.TransactionID != "TXN001" || transaction.ProductName != "Laptop" {
+		t.Errorf("Expected fields to keep their values, got %+v", transaction)
+	}
+	if unsafe.StringData(transaction.ProductName) == unsafe.StringData(record[7:]) {
+		t.Error("Expected the product name to be copied out of the record")
+	}
+}
+
+func containsWarning(warnings []string, text string) bool {
+	for _, warning := range warnings {
+		if strings.Contains(warning, text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// loadSummaryTopN is the number of countries and regions listed in the
+// startup load summary
+const loadSummaryTopN = 5
+
+// FormatLoadSummary renders a compact, multi-line overview of a finished
+// dataset load for the startup log: row counts, throughput, distinct counts,
+// the date range covered and the top countries and regions by revenue
+func FormatLoadSummary(data *models.DashboardData) string {
+	var b strings.Builder
+	report := data.Report
+
+	b.WriteString("Dataset load summary\n")
+	fmt.Fprintf(&b, "  rows:        %d processed, %d skipped, %d read errors\n",
+		report.Rows, report.SkippedRows, report.ReadErrors)
+
+	rate := 0.0
+	if seconds := data.ProcessingDuration.Seconds(); seconds > 0 {
+		rate = float64(report.Rows) / seconds
+	}
+	fmt.Fprintf(&b, "  duration:    %v (%.0f rows/sec)\n", data.ProcessingDuration, rate)
+	if timings := report.Timings; timings.Total > 0 {
+		fmt.Fprintf(&b, "  phases:      read %v, aggregate %v, finalize %v\n", timings.Read, timings.Aggregate, timings.Finalize)
+	}
+	fmt.Fprintf(&b, "  distinct:    %d products, %d countries, %d regions, %d users\n",
+		data.DistinctProducts, data.DistinctCountries, data.DistinctRegions, data.DistinctUsers)
+
+	if report.FirstDate != "" {
+		fmt.Fprintf(&b, "  date range:  %s to %s\n", report.FirstDate, report.LastDate)
+	} else {
+		b.WriteString("  date range:  none\n")
+	}
+
+	b.WriteString("  top countries:\n")
+	for i, country := range topCountryTotals(data.CountryRevenues, loadSummaryTopN) {
+		fmt.Fprintf(&b, "    %d. %s %s\n", i+1, country.Country, formatAmount(country.TotalRevenue, country.Currency))
+	}
+
+	b.WriteString("  top regions:\n")
+	for i, region := range data.TopRegions {
+		if i == loadSummaryTopN {
+			break
+		}
+		fmt.Fprintf(&b, "    %d. %s %s\n", i+1, region.Region, formatAmount(region.TotalRevenue, data.ReportingCurrency))
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// topCountryTotals rolls country/product rows up to one total per country
+// and currency, returning the limit largest by revenue
+func topCountryTotals(rows []models.CountryRevenue, limit int) []models.CountryRevenue {
+	totals := make(map[string]*models.CountryRevenue)
+	for _, row := range rows {
+		key := row.Country + "\x00" + row.Currency
+		total, exists := totals[key]
+		if !exists {
+			total = &models.CountryRevenue{Country: row.Country, Currency: row.Currency}
+			totals[key] = total
+		}
+		total.TotalRevenue += row.TotalRevenue
+		total.TransactionCount += row.TransactionCount
+	}
+
+	result := make([]models.CountryRevenue, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalRevenue != result[j].TotalRevenue {
+			return result[i].TotalRevenue > result[j].TotalRevenue
+		}
+		return result[i].Country < result[j].Country
+	})
+
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// formatAmount renders a revenue figure with its currency when it is a
+// single, known currency
+func formatAmount(amount float64, currency string) string {
+	if currency == "" || currency == mixedCurrency {
+		return fmt.Sprintf("%.2f", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
@@ -0,0 +1,89 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatLoadSummary(t *testing.T) {
+	data := &models.DashboardData{
+		CountryRevenues: []models.CountryRevenue{
+			{Country: "USA", ProductName: "Laptop", TotalRevenue: 300, TransactionCount: 3, Currency: "USD"},
+			{Country: "UK", ProductName: "Laptop", TotalRevenue: 250, TransactionCount: 2, Currency: "USD"},
+			{Country: "USA", ProductName: "Phone", TotalRevenue: 200, TransactionCount: 4, Currency: "USD"},
+			{Country: "France", ProductName: "Phone", TotalRevenue: 50, TransactionCount: 1, Currency: "USD"},
+			{Country: "Spain", ProductName: "Phone", TotalRevenue: 40, TransactionCount: 1, Currency: "USD"},
+			{Country: "Italy", ProductName: "Phone", TotalRevenue: 30, TransactionCount: 1, Currency: "USD"},
+			{Country: "Chile", ProductName: "Phone", TotalRevenue: 20, TransactionCount: 1, Currency: "USD"},
+		},
+		TopRegions: []models.RegionRevenue{
+			{Region: "North America", TotalRevenue: 500},
+			{Region: "Europe", TotalRevenue: 370},
+		},
+		ProcessingDuration: 2 * time.Second,
+		DistinctProducts:   2,
+		DistinctCountries:  6,
+		DistinctRegions:    2,
+		DistinctUsers:      12,
+		ReportingCurrency:  "USD",
+		Report: models.ProcessingReport{
+			Rows:        13,
+			SkippedRows: 1,
+			FirstDate:   "2024-01-01",
+			LastDate:    "2024-03-31",
+		},
+	}
+
+	summary := FormatLoadSummary(data)
+
+	expected := []string{
+		"13 processed, 1 skipped, 0 read errors",
+		"2s (6 rows/sec)",
+		"2 products, 6 countries, 2 regions, 12 users",
+		"2024-01-01 to 2024-03-31",
+		"1. USA 500.00 USD",
+		"2. UK 250.00 USD",
+		"5. Italy 30.00 USD",
+		"1. North America 500.00 USD",
+		"2. Europe 370.00 USD",
+	}
+	for _, line := range expected {
+		if !strings.Contains(summary, line) {
+			t.Errorf("Expected summary to contain '%s', got:\n%s", line, summary)
+		}
+	}
+
+	if strings.Contains(summary, "Chile") {
+		t.Errorf("Expected only the top %d countries, got:\n%s", loadSummaryTopN, summary)
+	}
+}
+
+func TestFormatLoadSummaryEmpty(t *testing.T) {
+	summary := FormatLoadSummary(&models.DashboardData{})
+
+	if !strings.Contains(summary, "0 processed") {
+		t.Errorf("Expected zero rows, got:\n%s", summary)
+	}
+	if !strings.Contains(summary, "date range:  none") {
+		t.Errorf("Expected no date range, got:\n%s", summary)
+	}
+}
+
+func TestProcessDatasetReportsRowsAndDateRange(t *testing.T) {
+	path := writeTestFile(t, "range.csv", flakyCSV+"TXN005,2024-02-01,USER005\n")
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	report := processor.GetDashboardData().Report
+	if report.Rows != 4 || report.SkippedRows != 1 {
+		t.Errorf("Expected 4 rows and 1 skipped, got %d and %d", report.Rows, report.SkippedRows)
+	}
+	if report.FirstDate != "2024-01-15" || report.LastDate != "2024-01-18" {
+		t.Errorf("Expected date range 2024-01-15 to 2024-01-18, got %s to %s", report.FirstDate, report.LastDate)
+	}
+}
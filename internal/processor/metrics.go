@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/metrics"
+	"abt-analytics-dashboard/internal/models"
+)
+
+// Reasons rows are skipped, as the reason label of
+// processor_skipped_rows_total
+const (
+	skipMalformed = "malformed"
+	skipOversized = "oversized_line"
+	skipDuplicate = "duplicate"
+)
+
+// pipelineMetrics are the processing metrics registered in the registry of
+// Options.Metrics. A nil *pipelineMetrics, used when metrics are disabled,
+// records nothing and costs a nil check per call.
+type pipelineMetrics struct {
+	batchParse    *metrics.Histogram
+	queueDepth    *metrics.Gauge
+	activeWorkers *metrics.Gauge
+	skippedRows   *metrics.CounterVec
+	phaseSeconds  *metrics.GaugeVec
+}
+
+// newPipelineMetrics registers the processing metrics in registry, or
+// returns nil when registry is nil
+func newPipelineMetrics(registry *metrics.Registry) *pipelineMetrics {
+	if registry == nil {
+		return nil
+	}
+	m := &pipelineMetrics{
+		batchParse: registry.NewHistogram("processor_batch_parse_seconds",
+			"Time spent reading and parsing the rows of one batch sent to the workers", metrics.DefaultDurationBuckets),
+		queueDepth: registry.NewGauge("processor_queue_depth_batches",
+			"Batches waiting in the queue between the reader and the workers"),
+		activeWorkers: registry.NewGauge("processor_active_workers",
+			"Aggregation workers currently running"),
+		skippedRows: registry.NewCounterVec("processor_skipped_rows_total",
+			"Rows skipped while processing datasets, by reason", "reason"),
+		phaseSeconds: registry.NewGaugeVec("processor_last_run_phase_seconds",
+			"Duration of each phase of the last successful processing run", "phase"),
+	}
+	// Export every reason from the start, so rates work before the first skip
+	for _, reason := range []string{skipMalformed, skipOversized, skipDuplicate} {
+		m.skippedRows.With(reason)
+	}
+	return m
+}
+
+// observeFile counts the rows a file skipped
+func (m *pipelineMetrics) observeFile(report models.FileReport) {
+	if m == nil {
+		return
+	}
+	m.skippedRows.With(skipMalformed).Add(int64(report.Skipped - report.OversizedLines))
+	m.skippedRows.With(skipOversized).Add(int64(report.OversizedLines))
+}
+
+// observeRun counts the duplicate rows of a finished run and records its
+// phase durations
+func (m *pipelineMetrics) observeRun(report models.ProcessingReport) {
+	if m == nil {
+		return
+	}
+	m.skippedRows.With(skipDuplicate).Add(int64(report.DuplicateRows))
+	m.phaseSeconds.With("read").Set(report.Timings.Read.Seconds())
+	m.phaseSeconds.With("aggregate").Set(report.Timings.Aggregate.Seconds())
+	m.phaseSeconds.With("finalize").Set(report.Timings.Finalize.Seconds())
+	m.phaseSeconds.With("total").Set(report.Timings.Total.Seconds())
+}
+
+// setQueueDepth records a queue depth sample
+func (m *pipelineMetrics) setQueueDepth(depth int) {
+	if m != nil {
+		m.queueDepth.Set(float64(depth))
+	}
+}
+
+// workerStarted and workerDone track the running aggregation workers
+func (m *pipelineMetrics) workerStarted() {
+	if m != nil {
+		m.activeWorkers.Add(1)
+	}
+}
+
+func (m *pipelineMetrics) workerDone() {
+	if m != nil {
+		m.activeWorkers.Add(-1)
+	}
+}
@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/metrics"
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// scrape returns the samples written by registry, keyed by metric name and
+// labels as they appear in the text format
+func scrape(t *testing.T, registry *metrics.Registry) map[string]float64 {
+	t.Helper()
+	var out strings.Builder
+	registry.WriteText(&out)
+
+	samples := make(map[string]float64)
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("Invalid sample line %q: %v", line, err)
+		}
+		samples[line[:i]] = value
+	}
+	return samples
+}
+
+func TestMetricsMatchProcessingReport(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("transaction_id,transaction_date,country,region,product_name,quantity,total_price\n")
+	for i := 0; i < 1200; i++ {
+		fmt.Fprintf(&b, "TXN%05d,2024-01-15,USA,North America,Product %d,1,10\n", i, i%50)
+	}
+	b.WriteString("TXN00001,2024-01-16,USA,North America,Product 1,1,10\n")
+	b.WriteString("TXN00002,2024-01-16,USA,North America,Product 2,1,10\n")
+	b.WriteString("TXN09000,2024-01-16,USA,North America,Product 3,1,10,extra\n")
+	b.WriteString("TXN09001,2024-01-16,USA,North America," + strings.Repeat("x", 300) + ",1,10\n")
+	path := writeTestFile(t, "metrics.csv", b.String())
+
+	registry := metrics.NewRegistry()
+	processor := New(WithMetrics(registry), WithDedup(true), WithMaxLineBytes(200), WithWorkers(2))
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+	report := processor.GetDashboardData().Report
+	if report.SkippedRows != 2 || report.OversizedLines != 1 || report.DuplicateRows != 2 {
+		t.Fatalf("Unexpected fixture report: skipped %d, oversized %d, duplicates %d",
+			report.SkippedRows, report.OversizedLines, report.DuplicateRows)
+	}
+
+	samples := scrape(t, registry)
+	checks := map[string]int{
+		`processor_skipped_rows_total{reason="malformed"}`:      report.SkippedRows - report.OversizedLines,
+		`processor_skipped_rows_total{reason="oversized_line"}`: report.OversizedLines,
+		`processor_skipped_rows_total{reason="duplicate"}`:      report.DuplicateRows,
+		`processor_active_workers`:                              0,
+		`processor_queue_depth_batches`:                         0,
+	}
+	for name, want := range checks {
+		if got, ok := samples[name]; !ok || got != float64(want) {
+			t.Errorf("Expected %s = %d, got %v (present %v)", name, want, got, ok)
+		}
+	}
+
+	// 1202 rows in batches of DefaultBatchSize: two full batches and one partial
+	wantBatches := float64((report.Rows + DefaultBatchSize - 1) / DefaultBatchSize)
+	if got := samples["processor_batch_parse_seconds_count"]; got != wantBatches {
+		t.Errorf("Expected %v batch parse observations, got %v", wantBatches, got)
+	}
+	if got := samples[`processor_batch_parse_seconds_bucket{le="+Inf"}`]; got != wantBatches {
+		t.Errorf("Expected the +Inf bucket to hold every batch, got %v", got)
+	}
+	if got := samples[`processor_last_run_phase_seconds{phase="total"}`]; got != report.Timings.Total.Seconds() {
+		t.Errorf("Expected the total phase to match the report, got %v want %v", got, report.Timings.Total.Seconds())
+	}
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	processor := New()
+	if processor.metrics != nil {
+		t.Fatal("Expected no metrics without a registry")
+	}
+
+	// The nil metrics record nothing and must not panic
+	processor.metrics.workerStarted()
+	processor.metrics.setQueueDepth(3)
+	path := writeTestFile(t, "plain.csv", "product_name,quantity,total_price\nLaptop,1,10\n")
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+}
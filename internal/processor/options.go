@@ -0,0 +1,154 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/metrics"
+	"time"
+)
+
+// Options configures a Processor created with New. Start from
+// DefaultOptions, or pass Option values to New, which applies them to the
+// defaults.
+type Options struct {
+	// Workers is the number of aggregation goroutines; values <= 0 use
+	// DefaultWorkers()
+	Workers int
+	// BufferSize is the capacity of the queue between the reader and the
+	// workers; values <= 0 use the default of 1000 transactions
+	BufferSize int
+	// BatchSize is the number of transactions sent to the workers at once;
+	// values <= 0 use DefaultBatchSize. The queue holds BufferSize
+	// transactions rounded down to whole batches, and at least one batch.
+	BatchSize int
+
+	// MaxReadErrors, MaxBadRows, MaxLineBytes and MaxAggregationKeys are
+	// the limits set by the Set methods of the same names
+	MaxReadErrors      int
+	MaxBadRows         int
+	MaxLineBytes       int
+	MaxAggregationKeys int
+	// MaxBadRowRatio is the share of rows that may be skipped, as set by
+	// SetMaxBadRowRatio
+	MaxBadRowRatio float64
+
+	// Dedup skips rows repeating the transaction_id of an earlier row of
+	// the same run. The IDs seen are held in memory until the run ends.
+	Dedup bool
+	// ValidateOnly processes datasets without serving them: the run is
+	// recorded in the processing history and the served data is kept
+	ValidateOnly bool
+
+	// Clock returns the current time for the timestamps of the data and
+	// staged datasets; nil uses time.Now
+	Clock func() time.Time
+
+	// Metrics receives the processing metrics: batch parse durations,
+	// queue depth, active workers, skipped rows by reason and phase
+	// durations. nil, the default, disables them.
+	Metrics *metrics.Registry
+}
+
+// Option sets a field of Options
+type Option func(*Options)
+
+// DefaultOptions returns the options of a processor created by New()
+func DefaultOptions() Options {
+	return Options{
+		BufferSize:    transactionQueueSize,
+		BatchSize:     DefaultBatchSize,
+		MaxReadErrors: DefaultMaxReadErrors,
+		MaxLineBytes:  DefaultMaxLineBytes,
+		Clock:         time.Now,
+	}
+}
+
+// WithOptions replaces all options with opts
+func WithOptions(opts Options) Option {
+	return func(o *Options) { *o = opts }
+}
+
+// WithWorkers sets Options.Workers
+func WithWorkers(n int) Option {
+	return func(o *Options) { o.Workers = n }
+}
+
+// WithBufferSize sets Options.BufferSize
+func WithBufferSize(n int) Option {
+	return func(o *Options) { o.BufferSize = n }
+}
+
+// WithBatchSize sets Options.BatchSize
+func WithBatchSize(n int) Option {
+	return func(o *Options) { o.BatchSize = n }
+}
+
+// WithMaxReadErrors sets Options.MaxReadErrors
+func WithMaxReadErrors(n int) Option {
+	return func(o *Options) { o.MaxReadErrors = n }
+}
+
+// WithMaxBadRows sets Options.MaxBadRows
+func WithMaxBadRows(n int) Option {
+	return func(o *Options) { o.MaxBadRows = n }
+}
+
+// WithMaxBadRowRatio sets Options.MaxBadRowRatio
+func WithMaxBadRowRatio(ratio float64) Option {
+	return func(o *Options) { o.MaxBadRowRatio = ratio }
+}
+
+// WithMaxLineBytes sets Options.MaxLineBytes
+func WithMaxLineBytes(n int) Option {
+	return func(o *Options) { o.MaxLineBytes = n }
+}
+
+// WithMaxAggregationKeys sets Options.MaxAggregationKeys
+func WithMaxAggregationKeys(n int) Option {
+	return func(o *Options) { o.MaxAggregationKeys = n }
+}
+
+// WithDedup sets Options.Dedup
+func WithDedup(dedup bool) Option {
+	return func(o *Options) { o.Dedup = dedup }
+}
+
+// WithValidateOnly sets Options.ValidateOnly
+func WithValidateOnly(validateOnly bool) Option {
+	return func(o *Options) { o.ValidateOnly = validateOnly }
+}
+
+// WithClock sets Options.Clock
+func WithClock(clock func() time.Time) Option {
+	return func(o *Options) { o.Clock = clock }
+}
+
+// WithMetrics sets Options.Metrics
+func WithMetrics(registry *metrics.Registry) Option {
+	return func(o *Options) { o.Metrics = registry }
+}
+
+// apply configures p from opts through the setters, so that both share
+// their handling of out-of-range values
+func (p *Processor) apply(opts Options) {
+	p.SetWorkers(opts.Workers)
+	p.bufferSize = opts.BufferSize
+	if p.bufferSize <= 0 {
+		p.bufferSize = transactionQueueSize
+	}
+	p.batchSize = opts.BatchSize
+	if p.batchSize <= 0 {
+		p.batchSize = DefaultBatchSize
+	}
+	p.maxReadErrors = DefaultMaxReadErrors
+	p.SetMaxReadErrors(opts.MaxReadErrors)
+	p.SetMaxBadRows(opts.MaxBadRows)
+	p.SetMaxBadRowRatio(opts.MaxBadRowRatio)
+	p.SetMaxLineBytes(opts.MaxLineBytes)
+	p.SetMaxAggregationKeys(opts.MaxAggregationKeys)
+	p.dedup = opts.Dedup
+	p.validateOnly = opts.ValidateOnly
+	p.now = opts.Clock
+	if p.now == nil {
+		p.now = time.Now
+	}
+	p.metrics = newPipelineMetrics(opts.Metrics)
+}
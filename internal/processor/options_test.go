@@ -0,0 +1,151 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewOptions(t *testing.T) {
+	processor := New()
+	if processor.bufferSize != transactionQueueSize || processor.maxReadErrors != DefaultMaxReadErrors || processor.maxLineBytes != DefaultMaxLineBytes {
+		t.Errorf("Expected the default limits, got buffer %d, read errors %d, line bytes %d",
+			processor.bufferSize, processor.maxReadErrors, processor.maxLineBytes)
+	}
+
+	processor = New(
+		WithWorkers(3),
+		WithBufferSize(10),
+		WithMaxReadErrors(0),
+		WithMaxBadRows(7),
+		WithMaxLineBytes(-1),
+		WithMaxAggregationKeys(100),
+	)
+	if processor.workers != 3 || processor.bufferSize != 10 || processor.maxBadRows != 7 || processor.maxAggregationKeys != 100 {
+		t.Errorf("Expected the options to be applied, got %+v", processor)
+	}
+	if processor.maxReadErrors != 0 {
+		t.Errorf("Expected zero read errors to be kept, got %d", processor.maxReadErrors)
+	}
+	if processor.maxLineBytes != DefaultMaxLineBytes {
+		t.Errorf("Expected an invalid line limit to fall back to the default, got %d", processor.maxLineBytes)
+	}
+}
+
+func TestClockPinsTimestamps(t *testing.T) {
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	path := writeTestFile(t, "sales.csv", "product_name,quantity,total_price,country\nLaptop,1,100,USA\n")
+
+	processor := New(WithClock(func() time.Time { return fixed }))
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := processor.GetDashboardData().LastUpdated; !got.Equal(fixed) {
+		t.Errorf("Expected LastUpdated %v, got %v", fixed, got)
+	}
+
+	staged, err := processor.StageDataset(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !staged.StagedAt.Equal(fixed) || !staged.Data.LastUpdated.Equal(fixed) {
+		t.Errorf("Expected the staged timestamps at %v, got %v and %v", fixed, staged.StagedAt, staged.Data.LastUpdated)
+	}
+}
+
+func TestDedupSkipsRepeatedTransactions(t *testing.T) {
+	content := "transaction_id,product_name,quantity,total_price,country\n" +
+		"TXN001,Laptop,1,100,USA\n" +
+		"TXN002,Laptop,1,100,USA\n" +
+		"TXN001,Laptop,1,100,USA\n" +
+		",Laptop,1,100,USA\n" +
+		",Laptop,1,100,USA\n"
+	path := writeTestFile(t, "sales.csv", content)
+
+	processor := New(WithDedup(true))
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data := processor.GetDashboardData()
+	if data.Report.DuplicateRows != 1 {
+		t.Errorf("Expected 1 duplicate row, got %d", data.Report.DuplicateRows)
+	}
+	if !containsWarning(data.Report.Warnings, "repeating an earlier transaction_id") {
+		t.Errorf("Expected a duplicate warning, got %v", data.Report.Warnings)
+	}
+	if revenue := data.CountryRevenues[0].TotalRevenue; revenue != 400 {
+		t.Errorf("Expected revenue 400 without the repeat, got %v", revenue)
+	}
+
+	// Without dedup every row counts
+	processor = New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if revenue := processor.GetDashboardData().CountryRevenues[0].TotalRevenue; revenue != 500 {
+		t.Errorf("Expected revenue 500, got %v", revenue)
+	}
+}
+
+func TestValidateOnlyKeepsServedData(t *testing.T) {
+	path := writeTestFile(t, "sales.csv", "product_name,quantity,total_price,country\nLaptop,1,100,USA\nCamera,1,50,Japan\n")
+
+	processor := New(WithValidateOnly(true))
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if source := processor.GetDashboardData().DataSource; source != SourceNone {
+		t.Errorf("Expected the data to stay unloaded, got source %s", source)
+	}
+	history := processor.GetProcessingHistory()
+	if len(history) != 1 || history[0].Records != 2 {
+		t.Errorf("Expected the validation run in the history, got %+v", history)
+	}
+
+	bad := writeTestFile(t, "bad.csv", "product_name\nLaptop\n")
+	if err := processor.ProcessDataset(bad); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("Expected ErrInvalidHeader, got %v", err)
+	}
+}
+
+// partsSource is a DataSource serving each part from memory
+type partsSource map[string]string
+
+func (s partsSource) Kind() string { return "test" }
+
+func (s partsSource) Parts() ([]string, error) { return []string{"a", "b"}, nil }
+
+func (s partsSource) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	content, ok := s[name]
+	if !ok {
+		return nil, errors.New("no such part")
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func TestProcessDataSource(t *testing.T) {
+	src := partsSource{
+		"a": "product_name,quantity,total_price,country\nLaptop,1,100,USA\n",
+		"b": "product_name,quantity,total_price,country\nCamera,1,50,Japan\n",
+	}
+
+	processor := New(WithBufferSize(1))
+	if err := processor.Process(context.Background(), src); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data := processor.GetDashboardData()
+	if data.DataSource != "test" || data.Report.Rows != 2 || len(data.Report.Files) != 2 {
+		t.Errorf("Expected 2 rows from 2 parts of the test source, got %s with %+v", data.DataSource, data.Report)
+	}
+
+	delete(src, "b")
+	if err := processor.Process(context.Background(), src); err == nil || !strings.Contains(err.Error(), "b: no such part") {
+		t.Errorf("Expected the failing part to be named, got %v", err)
+	}
+	if processor.GetDashboardData() != data {
+		t.Error("Expected the served data to be kept after a failed run")
+	}
+}
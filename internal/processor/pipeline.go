@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"sync/atomic"
+	"time"
+)
+
+// queueSampleInterval is how often the transaction queue depth is sampled
+// while a dataset is processed
+const queueSampleInterval = 50 * time.Millisecond
+
+// pipelineStats tracks reader/worker backpressure for one processing run.
+// Workers and the sampler only touch atomics, so no locks are taken in the
+// hot path.
+type pipelineStats struct {
+	source        string
+	startedAt     time.Time
+	queueCapacity int
+	batchSize     int
+	queueDepth    atomic.Int64
+	maxQueueDepth atomic.Int64
+	workerRows    []atomic.Int64
+	running       atomic.Bool
+
+	// metrics, when set, exports the queue depth samples
+	metrics *pipelineMetrics
+}
+
+// newPipelineStats creates the stats for a run with the given queue
+// capacity in batches, batch size and number of workers
+func newPipelineStats(source string, queueCapacity, batchSize, workers int) *pipelineStats {
+	stats := &pipelineStats{
+		source:        source,
+		startedAt:     time.Now(),
+		queueCapacity: queueCapacity,
+		batchSize:     batchSize,
+		workerRows:    make([]atomic.Int64, workers),
+	}
+	stats.running.Store(true)
+	return stats
+}
+
+// observeQueue records a queue depth sample
+func (s *pipelineStats) observeQueue(depth int) {
+	s.queueDepth.Store(int64(depth))
+	s.metrics.setQueueDepth(depth)
+	for {
+		max := s.maxQueueDepth.Load()
+		if int64(depth) <= max || s.maxQueueDepth.CompareAndSwap(max, int64(depth)) {
+			return
+		}
+	}
+}
+
+// sampleQueue samples the depth of queue until stop is closed
+func (s *pipelineStats) sampleQueue(queue <-chan []models.Transaction, stop <-chan struct{}) {
+	ticker := time.NewTicker(queueSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.observeQueue(len(queue))
+		}
+	}
+}
+
+// snapshot returns the current pipeline counters
+func (s *pipelineStats) snapshot() models.PipelineStats {
+	stats := models.PipelineStats{
+		QueueCapacity: s.queueCapacity,
+		BatchSize:     s.batchSize,
+		QueueDepth:    int(s.queueDepth.Load()),
+		MaxQueueDepth: int(s.maxQueueDepth.Load()),
+		WorkerRows:    make([]int64, len(s.workerRows)),
+	}
+	for i := range s.workerRows {
+		stats.WorkerRows[i] = s.workerRows[i].Load()
+		stats.RowsProcessed += stats.WorkerRows[i]
+	}
+	return stats
+}
+
+// GetProcessingStatus reports the progress of the current or most recent
+// processing run
+func (p *Processor) GetProcessingStatus() models.ProcessingStatus {
+	stats := p.pipeline.Load()
+	if stats == nil {
+		return models.ProcessingStatus{Pipeline: models.PipelineStats{WorkerRows: make([]int64, 0)}}
+	}
+
+	startedAt := stats.startedAt
+	return models.ProcessingStatus{
+		Running:   stats.running.Load(),
+		Source:    stats.source,
+		StartedAt: &startedAt,
+		Pipeline:  stats.snapshot(),
+	}
+}
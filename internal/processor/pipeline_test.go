@@ -0,0 +1,74 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPipelineWorkerRowsSumToRowCount(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("transaction_id,transaction_date,country,region,product_name,quantity,total_price\n")
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&b, "TXN%05d,2024-01-15,USA,North America,Product %d,1,10\n", i, i%50)
+	}
+	path := writeTestFile(t, "pipeline.csv", b.String())
+
+	processor := New()
+	processor.SetWorkers(4)
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	report := processor.GetDashboardData().Report
+	pipeline := report.Pipeline
+
+	if len(pipeline.WorkerRows) != 4 {
+		t.Fatalf("Expected 4 worker counters, got %d", len(pipeline.WorkerRows))
+	}
+
+	var sum int64
+	for _, rows := range pipeline.WorkerRows {
+		sum += rows
+	}
+	if sum != int64(report.Rows) || pipeline.RowsProcessed != int64(report.Rows) {
+		t.Errorf("Expected worker rows to sum to %d, got %d (rows_processed %d)", report.Rows, sum, pipeline.RowsProcessed)
+	}
+	if pipeline.BatchSize != DefaultBatchSize || pipeline.QueueCapacity != transactionQueueSize/DefaultBatchSize {
+		t.Errorf("Expected %d batches of %d, got %d of %d", transactionQueueSize/DefaultBatchSize, DefaultBatchSize, pipeline.QueueCapacity, pipeline.BatchSize)
+	}
+	if pipeline.MaxQueueDepth < 0 || pipeline.MaxQueueDepth > pipeline.QueueCapacity {
+		t.Errorf("Expected max queue depth within [0, %d], got %d", pipeline.QueueCapacity, pipeline.MaxQueueDepth)
+	}
+
+	status := processor.GetProcessingStatus()
+	if status.Running {
+		t.Error("Expected the run to be finished")
+	}
+	if status.Source != SourceDataset || status.StartedAt == nil {
+		t.Errorf("Expected a finished dataset run, got %+v", status)
+	}
+	if status.Pipeline.RowsProcessed != int64(report.Rows) {
+		t.Errorf("Expected status to report %d rows, got %d", report.Rows, status.Pipeline.RowsProcessed)
+	}
+}
+
+func TestPipelineObserveQueueKeepsMaximum(t *testing.T) {
+	stats := newPipelineStats(SourceDataset, 10, DefaultBatchSize, 1)
+
+	for _, depth := range []int{3, 8, 2} {
+		stats.observeQueue(depth)
+	}
+
+	snapshot := stats.snapshot()
+	if snapshot.MaxQueueDepth != 8 || snapshot.QueueDepth != 2 {
+		t.Errorf("Expected max depth 8 and current depth 2, got %d and %d", snapshot.MaxQueueDepth, snapshot.QueueDepth)
+	}
+}
+
+func TestProcessingStatusBeforeAnyRun(t *testing.T) {
+	status := New().GetProcessingStatus()
+	if status.Running || status.StartedAt != nil || len(status.Pipeline.WorkerRows) != 0 {
+		t.Errorf("Expected an empty status, got %+v", status)
+	}
+}
@@ -2,82 +2,464 @@ package processor
 
 import (
 	"abt-analytics-dashboard/internal/models"
-	"bufio"
+	"abt-analytics-dashboard/internal/tracing"
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"os"
-	"runtime"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Errors returned by ProcessDataset when the dataset path cannot be used
+var (
+	ErrNotFound   = errors.New("data file not found")
+	ErrNotAFile   = errors.New("data path is not a regular file")
+	ErrPermission = errors.New("data file is not readable")
 )
 
+// ErrTooManyReadErrors is returned when reading a dataset hits more
+// consecutive I/O errors than the configured limit. The returned error also
+// wraps the last underlying read error.
+var ErrTooManyReadErrors = errors.New("too many consecutive read errors")
+
+// ErrTooManyBadRows is returned when a file has more skipped rows than the
+// limit set with SetMaxBadRows
+var ErrTooManyBadRows = errors.New("too many malformed rows")
+
+// BadRowRatioError is returned when more of a dataset's rows were skipped
+// than the ratio set with SetMaxBadRowRatio. Report is the report of the
+// rejected run, whose data was not served.
+type BadRowRatioError struct {
+	Skipped int
+	Total   int
+	Limit   float64
+	Report  models.ProcessingReport
+}
+
+func (e *BadRowRatioError) Error() string {
+	return fmt.Sprintf("%v: %d of %d rows skipped (%.1f%%), more than the limit of %g%%",
+		ErrTooManyBadRows, e.Skipped, e.Total, 100*float64(e.Skipped)/float64(e.Total), 100*e.Limit)
+}
+
+func (e *BadRowRatioError) Is(target error) bool {
+	return target == ErrTooManyBadRows
+}
+
+// ErrAborted is returned when processing stops because its context was
+// cancelled, such as when an upload's client disconnects. The returned error
+// also wraps the context's error.
+var ErrAborted = errors.New("processing aborted")
+
+// ErrEmptyDataset is returned when a dataset has no valid data rows, such as
+// an empty or header-only file, unless SetAllowEmptyDataset allows it
+var ErrEmptyDataset = errors.New("dataset has no data rows")
+
+// ErrProcessingInProgress is returned when a dataset is processed or staged
+// while another processing run is still going. The running one is not
+// affected.
+var ErrProcessingInProgress = errors.New("dataset processing already in progress")
+
+// ErrInvalidHeader is matched by errors.Is for every *InvalidHeaderError
+var ErrInvalidHeader = errors.New("invalid CSV header")
+
+// requiredColumns are the CSV columns a dataset must have
+var requiredColumns = []string{"product_name", "quantity", "total_price"}
+
+// InvalidHeaderError is returned when a CSV file has no header or its
+// header lacks required columns
+type InvalidHeaderError struct {
+	Missing []string
+}
+
+func (e *InvalidHeaderError) Error() string {
+	return fmt.Sprintf("%v: missing required columns %s", ErrInvalidHeader, strings.Join(e.Missing, ", "))
+}
+
+func (e *InvalidHeaderError) Is(target error) bool {
+	return target == ErrInvalidHeader
+}
+
+// transactionQueueSize is the capacity of the channel between the CSV
+// reader and the aggregation workers
+const transactionQueueSize = 1000
+
+// TopProductsLimit is the number of products in each top products ranking
+const TopProductsLimit = 20
+
+// regionProductLimit bounds how many products are kept per region
+const regionProductLimit = 50
+
+// CountryTrendLimit is the number of countries, ranked by total revenue,
+// for which a monthly sales series is retained
+const CountryTrendLimit = 20
+
+// DefaultMaxReadErrors is the number of consecutive record read errors
+// tolerated before a dataset load is aborted
+const DefaultMaxReadErrors = 5
+
+// readerSourceName identifies data passed to ProcessReader in the
+// processing report
+const readerSourceName = "(reader)"
+
+// tracerName is the instrumentation scope of the processor's spans
+const tracerName = "abt-analytics-dashboard/internal/processor"
+
 // Processor handles data processing and aggregation
 type Processor struct {
-	dashboardData *models.DashboardData
-	mu            sync.RWMutex
+	// data is the served snapshot. It is never modified once stored, so
+	// readers load it without locking and see one consistent dataset;
+	// processing builds a new snapshot and swaps it in.
+	data atomic.Pointer[models.DashboardData]
+
+	// mu guards history, the staged dataset, the previous snapshot and the
+	// last failed report
+	mu sync.Mutex
+
+	// previous is the snapshot served before data, kept for diffs
+	previous *models.DashboardData
+
+	// lastFailedReport is the report of the most recent run rejected for
+	// skipping too many rows, whose data was never served
+	lastFailedReport *models.ProcessingReport
+
+	// staged is the dataset waiting to be promoted, if any; staging is set
+	// while one is being processed
+	staged  *StagedDataset
+	staging bool
+
+	// processing is set while a run builds new data; runs are serialized
+	// so that they do not share the per-run counters
+	processing atomic.Bool
+
+	workers       int
+	bufferSize    int
+	batchSize     int
+	rates         *ConversionRates
+	countryCodes  map[string]string
+	maxReadErrors int
+	maxLineBytes  int
+	maxBadRows    int
+	maxBadRatio   float64
+	allowEmpty    bool
+	keepBlank     bool
+	exclusions    atomic.Pointer[exclusions]
+	dedup         bool
+	validateOnly  bool
+	now           func() time.Time
+
+	maxAggregationKeys   int
+	sampleSize           int
+	otherBucketThreshold float64
+	recomputeTotals      string
+	productNames         string
+	csvEncoding          string
+	lowStockThreshold    atomic.Int64
+	dateFormats          []string
+	dataTimezone         *time.Location
+	history              []models.ProcessingRun
+	tracer               trace.Tracer
+	metrics              *pipelineMetrics
+	pipeline             atomic.Pointer[pipelineStats]
+	totalMismatches      atomic.Int64
+	regionAliases        Aliases
+	countryAliases       Aliases
+	unknownRegions       nameCounts
+	unknownCountries     nameCounts
+
+	// runLog keeps the log lines of the current or most recent run
+	runLog runLog
+}
+
+// New creates a new processor instance with the default options, changed
+// by opts in order
+func New(opts ...Option) *Processor {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	p := &Processor{tracer: noop.NewTracerProvider().Tracer(tracerName)}
+	p.apply(options)
+	p.data.Store(emptyDashboardData(SourceNone))
+	p.lowStockThreshold.Store(DefaultLowStockThreshold)
+	return p
+}
+
+// emptyDashboardData returns dashboard data without any aggregates, whose
+// lists encode as [] rather than null
+func emptyDashboardData(source string) *models.DashboardData {
+	return &models.DashboardData{
+		CountryRevenues:  make([]models.CountryRevenue, 0),
+		CountrySummaries: make([]models.CountrySummary, 0),
+		TopProducts:      make([]models.ProductFrequency, 0),
+		MonthlySales:     make([]models.MonthlySales, 0),
+		WeeklySales:      make([]models.WeeklySales, 0),
+		TopRegions:       make([]models.RegionRevenue, 0),
+		DataSource:       source,
+	}
+}
+
+// LoadEmptyData serves empty aggregates, for running without a dataset and
+// without sample data
+func (p *Processor) LoadEmptyData() {
+	data := emptyDashboardData(SourceEmpty)
+	data.LastUpdated = p.now()
+	data.Report = models.ProcessingReport{
+		Warnings: make([]string, 0),
+		Files:    make([]models.FileReport, 0),
+	}
+	p.swapDashboardData(data, models.ProcessingRun{
+		Source:    SourceEmpty,
+		StartedAt: data.LastUpdated,
+	})
+}
+
+// SetTracerProvider traces dataset processing with spans from provider.
+// Tracing is disabled by default and by a nil provider.
+func (p *Processor) SetTracerProvider(provider trace.TracerProvider) {
+	if provider == nil {
+		provider = noop.NewTracerProvider()
+	}
+	p.tracer = provider.Tracer(tracerName)
+}
+
+// SetWorkers sets the number of aggregation worker goroutines used by
+// ProcessDataset. Values <= 0 fall back to DefaultWorkers().
+func (p *Processor) SetWorkers(n int) {
+	p.workers = n
+}
+
+// SetMaxBadRows sets how many malformed or over-long rows a file may have
+// before processing fails with ErrTooManyBadRows. Zero, the default, skips
+// any number of rows; negative values are ignored.
+func (p *Processor) SetMaxBadRows(n int) {
+	if n >= 0 {
+		p.maxBadRows = n
+	}
+}
+
+// SetMaxBadRowRatio sets the share of a dataset's rows, from 0 to 1, that
+// may be skipped as malformed or over-long. Once reading completes, a run
+// skipping more fails with a *BadRowRatioError and its data is not served.
+// Zero, the default, disables the check; values outside the range are
+// ignored.
+func (p *Processor) SetMaxBadRowRatio(ratio float64) {
+	if ratio >= 0 && ratio <= 1 {
+		p.maxBadRatio = ratio
+	}
+}
+
+// SetAllowEmptyDataset lets a dataset without data rows load as empty data
+// with a warning instead of failing with ErrEmptyDataset
+func (p *Processor) SetAllowEmptyDataset(allow bool) {
+	p.allowEmpty = allow
+}
+
+// SetKeepBlankLocations aggregates rows without a region or country under
+// an empty region or country name, as before such rows were counted as
+// unattributed instead. They are counted as unattributed either way.
+func (p *Processor) SetKeepBlankLocations(keep bool) {
+	p.keepBlank = keep
 }
 
-// New creates a new processor instance
-func New() *Processor {
-	return &Processor{
-		dashboardData: &models.DashboardData{
-			CountryRevenues: make([]models.CountryRevenue, 0),
-			TopProducts:     make([]models.ProductFrequency, 0),
-			MonthlySales:    make([]models.MonthlySales, 0),
-			TopRegions:      make([]models.RegionRevenue, 0),
-		},
+// SetMaxReadErrors sets how many consecutive record read errors are tolerated
+// before processing fails with ErrTooManyReadErrors. Zero aborts on the first
+// error; negative values are ignored.
+func (p *Processor) SetMaxReadErrors(n int) {
+	if n >= 0 {
+		p.maxReadErrors = n
 	}
 }
 
-// ProcessDataset processes the CSV dataset using concurrent workers
+// ProcessDataset processes the CSV dataset using concurrent workers. The path
+// may name a single file, a directory of shards or a glob pattern; matching
+// files are read sequentially and aggregated into one DashboardData.
 func (p *Processor) ProcessDataset(filePath string) error {
-	start := time.Now()
+	return p.Process(context.Background(), FileSource(filePath))
+}
+
+// ProcessReader processes CSV data from a single reader, such as a network
+// stream, using the same pipeline as ProcessDataset
+func (p *Processor) ProcessReader(r io.Reader) error {
+	return p.ProcessReaderContext(context.Background(), r)
+}
+
+// ProcessReaderContext is ProcessReader that stops reading with ErrAborted
+// once ctx is done, keeping the data currently served
+func (p *Processor) ProcessReaderContext(ctx context.Context, r io.Reader) error {
+	return p.Process(ctx, ReaderSource(r))
+}
+
+// contextReader fails reads with ErrAborted once its context is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
 
-	file, err := os.Open(filePath)
+func (c *contextReader) Read(b []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrAborted, err)
+	}
+	n, err := c.r.Read(b)
+	if err != nil && err != io.EOF && c.ctx.Err() != nil {
+		return n, fmt.Errorf("%w: %w", ErrAborted, c.ctx.Err())
+	}
+	return n, err
+}
+
+// process reads each source in turn with read and aggregates the resulting
+// transactions into the dashboard data
+func (p *Processor) process(ctx context.Context, start time.Time, source string, sources []string, read func(string, *batchSender) (models.FileReport, error)) error {
+	if err := p.beginProcessing(); err != nil {
+		return err
+	}
+	defer p.endProcessing()
+	p.runLog.begin()
+	defer p.runLog.end()
+
+	data, run, err := p.build(ctx, start, source, sources, read)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		var ratioErr *BadRowRatioError
+		if errors.As(err, &ratioErr) {
+			p.setLastFailedReport(ratioErr.Report)
+		}
+		p.logf("Data processing failed: %v", err)
+		return err
+	}
+	if p.validateOnly {
+		p.recordRun(run)
+		p.logf("Dataset validated in %v: %d rows; the served data was kept", time.Since(start), run.Records)
+		return nil
 	}
-	defer file.Close()
+	p.swapDashboardData(data, run)
 
-	// Create channels for concurrent processing
-	transactionCh := make(chan models.Transaction, 1000)
+	p.logf("Data processing completed in %v", time.Since(start))
+	return nil
+}
+
+// beginProcessing claims the processor for a run, or fails with
+// ErrProcessingInProgress while another run holds it
+func (p *Processor) beginProcessing() error {
+	if !p.processing.CompareAndSwap(false, true) {
+		return ErrProcessingInProgress
+	}
+	return nil
+}
+
+// endProcessing releases the processor once a run has finished, whether or
+// not it succeeded
+func (p *Processor) endProcessing() {
+	p.processing.Store(false)
+}
+
+// build reads each source in turn with read and aggregates the resulting
+// transactions into new dashboard data, without serving it. The run
+// describes the processing for the history. The phases are traced as
+// children of the span in ctx.
+func (p *Processor) build(ctx context.Context, start time.Time, source string, sources []string, read func(string, *batchSender) (models.FileReport, error)) (*models.DashboardData, models.ProcessingRun, error) {
+	ctx, span := p.tracer.Start(ctx, "processor.process", trace.WithAttributes(
+		attribute.String("processor.source", source),
+		attribute.Int("processor.files", len(sources))))
+	defer span.End()
+
+	p.totalMismatches.Store(0)
+	p.unknownRegions.reset()
+	p.unknownCountries.reset()
+
+	// Create channels for concurrent processing. Transactions travel in
+	// pooled batches; the queue holds at most bufferSize of them.
+	batches := newBatchPool(p.batchSize)
+	batchCh := make(chan []models.Transaction, queueBatches(p.bufferSize, p.batchSize))
 	errorCh := make(chan error, 1)
 	done := make(chan struct{})
 
 	// Start aggregation workers
-	numWorkers := runtime.NumCPU()
-	log.Printf("Starting %d worker goroutines for data processing", numWorkers)
+	numWorkers := p.workers
+	if numWorkers <= 0 {
+		numWorkers = DefaultWorkers()
+	}
+	p.logf("Starting %d worker goroutines for data processing", numWorkers)
 
-	// Aggregation maps with mutexes for concurrent access
-	countryMap := make(map[string]*models.CountryRevenue)
-	productMap := make(map[string]*models.ProductFrequency)
-	monthMap := make(map[string]*models.MonthlySales)
-	regionMap := make(map[string]*models.RegionRevenue)
+	// Track queue depth and per-worker throughput for backpressure metrics
+	stats := newPipelineStats(source, cap(batchCh), p.batchSize, numWorkers)
+	stats.metrics = p.metrics
+	p.pipeline.Store(stats)
+	defer stats.running.Store(false)
+	go stats.sampleQueue(batchCh, done)
+
+	// Aggregation maps with a mutex for concurrent access
+	agg := newAggregates(p.maxAggregationKeys)
+	agg.samples = newTransactionSamples(p.sampleSize)
+	agg.keepBlankLocations = p.keepBlank
+	if agg.exclude = p.exclusions.Load(); agg.exclude != nil {
+		agg.excluded = agg.exclude.excludedTotals()
+		agg.countryCodes = p.countryCodeTable()
+	}
+	if p.dedup {
+		agg.seenIDs = make(map[string]struct{})
+	}
+
+	// Reading and aggregation overlap, so their spans run side by side
+	_, aggregateSpan := p.tracer.Start(ctx, "processor.aggregate", trace.WithAttributes(attribute.Int("processor.workers", numWorkers)))
+	readCtx, readSpan := p.tracer.Start(ctx, "processor.read")
 
 	var wg sync.WaitGroup
-	var mu sync.Mutex
 
 	// Start worker goroutines
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
-		go func() {
+		go func(processed *atomic.Int64) {
 			defer wg.Done()
-			p.aggregateWorker(transactionCh, &mu, countryMap, productMap, monthMap, regionMap)
-		}()
+			p.metrics.workerStarted()
+			defer p.metrics.workerDone()
+			p.aggregateWorker(batchCh, batches, agg, processed)
+		}(&stats.workerRows[i])
 	}
 
 	// Start CSV reader goroutine
+	fileReports := make([]models.FileReport, 0, len(sources))
+	rows, skipped, readErrors := 0, 0, 0
+	oversizedLines, truncatedFields, invalidUTF8Rows := 0, 0, 0
+	var readDone time.Time
 	go func() {
-		defer close(transactionCh)
-		if err := p.readCSV(file, transactionCh); err != nil {
-			errorCh <- err
-			return
+		defer close(batchCh)
+		defer func() { readDone = time.Now() }()
+		defer readSpan.End()
+		sender := newBatchSender(batchCh, batches)
+		if p.metrics != nil {
+			sender.parse = p.metrics.batchParse
 		}
+		defer sender.flush()
+		for _, name := range sources {
+			report, err := p.readSource(readCtx, source, name, sender, read)
+			p.metrics.observeFile(report)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", name, err)
+				tracing.RecordError(readSpan, err)
+				errorCh <- err
+				return
+			}
+			report.Path = name
+			rows += report.Rows
+			skipped += report.Skipped
+			readErrors += report.ReadErrors
+			oversizedLines += report.OversizedLines
+			truncatedFields += report.TruncatedFields
+			invalidUTF8Rows += report.InvalidUTF8Rows
+			fileReports = append(fileReports, report)
+		}
+		readSpan.SetAttributes(attribute.Int("processor.rows", rows), attribute.Int("processor.skipped_rows", skipped))
 	}()
 
 	// Wait for all workers to complete
@@ -86,74 +468,352 @@ func (p *Processor) ProcessDataset(filePath string) error {
 		close(done)
 	}()
 
-	// Wait for completion or error
+	// Wait for completion, then check whether the reader failed
+	<-done
+	drained := time.Now()
+	p.metrics.setQueueDepth(0)
+	aggregateSpan.End()
 	select {
 	case err := <-errorCh:
-		return fmt.Errorf("error during processing: %w", err)
-	case <-done:
+		err = fmt.Errorf("error during processing: %w", err)
+		tracing.RecordError(span, err)
+		return nil, models.ProcessingRun{}, err
+	default:
 		// Processing completed successfully
 	}
+	if rows == 0 && !p.allowEmpty {
+		err := fmt.Errorf("%w: %s", ErrEmptyDataset, describeSources(sources))
+		tracing.RecordError(span, err)
+		return nil, models.ProcessingRun{}, err
+	}
+
+	_, finalizeSpan := p.tracer.Start(ctx, "processor.finalize")
+	defer finalizeSpan.End()
+
+	reportingCurrency, currencies, warnings := p.currencyReport(agg.currencyMap)
+	mixedTotals := len(agg.amountCurrencies) > 1
+	if mixedTotals {
+		clearMixedTotals(agg)
+	}
+	firstDate, lastDate := dateRange(agg.dayMap)
+	truncated := len(agg.overflow) > 0
+	if truncated {
+		warnings = append(warnings, overflowWarning(agg.maxKeys, agg.overflow))
+	}
+	if rows == 0 {
+		warnings = append(warnings, "the dataset has no data rows")
+	}
+	if agg.duplicates > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d rows repeating an earlier transaction_id were skipped", agg.duplicates))
+	}
+	if oversizedLines > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d lines longer than %d bytes were skipped", oversizedLines, p.maxLineBytes))
+	}
+	if truncatedFields > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d text fields longer than %d characters were truncated", truncatedFields, MaxFieldChars))
+	}
+	if invalidUTF8Rows > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d rows are not valid UTF-8; set CSV_ENCODING if the files use another encoding", invalidUTF8Rows))
+	}
+	if total := agg.unattributed.Region; total.TransactionCount > 0 {
+		warnings = append(warnings, unattributedWarning("region", total, p.keepBlank))
+	}
+	if total := agg.unattributed.Country; total.TransactionCount > 0 {
+		warnings = append(warnings, unattributedWarning("country", total, p.keepBlank))
+	}
+	unknownRegions := p.unknownRegions.snapshot()
+	if len(unknownRegions) > 0 {
+		warnings = append(warnings, unaliasedWarning("region", unknownRegions))
+	}
+	unknownCountries := p.unknownCountries.snapshot()
+	if len(unknownCountries) > 0 {
+		warnings = append(warnings, unaliasedWarning("country", unknownCountries))
+	}
+	productNames, mergedProductNames := canonicalProductNames(agg.productSpellings, p.productNames != ProductNamesTrim)
+	renameProducts(agg, productNames)
+	if mergedProductNames > 0 {
+		p.logf("Merged %d product name spellings differing only in whitespace or case", mergedProductNames)
+	}
+	foldedRows := foldSmallCountryRevenues(agg.countryMap, p.otherBucketThreshold)
+	if foldedRows > 0 {
+		p.logf("Folded %d country revenue rows below %g%% of their currency's total revenue into %q", foldedRows, p.otherBucketThreshold, OtherBucket)
+		if mixedTotals {
+			warnings = append(warnings, fmt.Sprintf(
+				"amounts are in several currencies, so %d country revenue rows were folded into %q against the total revenue in their own currency",
+				foldedRows, OtherBucket))
+		}
+	}
+	countryRevenues := p.sortCountryRevenues(agg.countryMap)
+	amountCurrencies := currencyOrder(agg.amountCurrencies)
+	countrySummaries, unmappedCountries := summarizeCountries(countryRevenues, p.countryCodeTable(), amountCurrencies)
+	if len(unmappedCountries) > 0 {
+		warnings = append(warnings, unmappedCountriesWarning(unmappedCountries))
+	}
+	for _, warning := range warnings {
+		p.logf("Warning: %s", warning)
+	}
 
-	// Convert maps to sorted slices and store in dashboard data
-	p.mu.Lock()
-	p.dashboardData.CountryRevenues = p.sortCountryRevenues(countryMap)
-	p.dashboardData.TopProducts = p.sortTopProducts(productMap, 20)
-	p.dashboardData.MonthlySales = p.sortMonthlySales(monthMap)
-	p.dashboardData.TopRegions = p.sortTopRegions(regionMap, 30)
-	p.dashboardData.LastUpdated = time.Now()
-	p.dashboardData.ProcessingDuration = time.Since(start)
-	p.dashboardData.RecordCount = len(countryMap) // Approximate record count
-	p.mu.Unlock()
+	// Convert maps to sorted slices
+	data := &models.DashboardData{DataSource: source}
+	data.CountryRevenues = countryRevenues
+	data.CountrySummaries = countrySummaries
+	data.TopProducts = p.sortTopProducts(agg.productMap, TopProductsLimit, models.RankByOrders)
+	data.TopProductsByUnits = p.sortTopProducts(agg.productMap, TopProductsLimit, models.RankByUnits)
+	data.ProductIndex = p.buildProductIndex(agg.productMap)
+	data.TopProductsAvailable = len(agg.productMap)
+	data.StockPressure = sortStockPressure(agg.stockPressureMap)
+	indexData(data)
+	countDaysWithSales(agg.monthMap, agg.dayMap)
+	data.MonthlySales = p.sortMonthlySales(agg.monthMap)
+	data.WeeklySales = sortWeeklySales(agg.weekMap)
+	data.TopRegions = p.sortTopRegions(agg.regionMap, 30, amountCurrencies)
+	data.TopRegionsAvailable = len(agg.regionMap)
+	data.Summary = computeSummary(agg.dayMap)
+	data.Summary.Unattributed = agg.unattributed
+	data.RegionProducts = p.sortRegionProducts(agg.regionProductMap, regionProductLimit)
+	data.CategoryProducts = sortCategoryProducts(agg.categoryProductMap, categoryProductLimit)
+	data.CountryMonthlySales = p.sortCountryMonthlySales(agg.countryMonthMap, CountryTrendLimit)
+	data.CountryTopCustomers = sortCountryCustomers(agg.countryCustomerMap, CountryTopCustomersLimit)
+	data.SampleTransactions, data.CountrySampleTransactions = agg.samples.sampleTransactions()
+	data.Dimensions = buildDimensions(agg.dimensions)
+	data.CurrencyOrder = amountCurrencies
+	finished := time.Now()
+	timings := phaseTimings(start, readDone, drained, finished, rows)
+	p.logf("Processing phases: read %v, aggregate %v, finalize %v (%.0f rows/sec)",
+		timings.Read, timings.Aggregate, timings.Finalize, timings.RowsPerSecond)
 
-	log.Printf("Data processing completed in %v", time.Since(start))
+	data.LastUpdated = p.now()
+	data.ProcessingDuration = timings.Total
+	data.RecordCount = len(agg.countryMap) // Approximate record count
+	data.DistinctProducts = countNonEmptyKeys(agg.productMap)
+	data.DistinctCountries = countNonEmptyKeys(agg.countrySet)
+	data.DistinctRegions = countNonEmptyKeys(agg.regionMap)
+	data.DistinctUsers = countNonEmptyKeys(agg.userSet)
+	data.ReportingCurrency = reportingCurrency
+	data.DataStartDate = agg.startDate
+	data.DataEndDate = agg.endDate
+	data.Report = models.ProcessingReport{
+		Currencies:           currencies,
+		Warnings:             warnings,
+		Files:                fileReports,
+		Rows:                 rows,
+		SkippedRows:          skipped,
+		ReadErrors:           readErrors,
+		OversizedLines:       oversizedLines,
+		TruncatedFields:      truncatedFields,
+		InvalidUTF8Rows:      invalidUTF8Rows,
+		UnmappedCountries:    unmappedCountries,
+		TotalMismatches:      int(p.totalMismatches.Load()),
+		FirstDate:            firstDate,
+		LastDate:             lastDate,
+		Truncated:            truncated,
+		Overflow:             agg.overflow,
+		FoldedRows:           foldedRows,
+		DuplicateRows:        agg.duplicates,
+		OtherBucketThreshold: p.otherBucketThreshold,
+		Pipeline:             stats.snapshot(),
+		Timings:              timings,
+		UnknownRegions:       unknownRegions,
+		UnknownCountries:     unknownCountries,
+		MergedProductNames:   mergedProductNames,
+		Unattributed:         agg.unattributed,
+		Excluded:             agg.excluded,
+		MixedTotals:          mixedTotals,
+		Timezone:             p.dataLocation().String(),
+		OffsetDateRows:       agg.offsetDates,
+	}
+	if err := p.checkBadRowRatio(data.Report); err != nil {
+		tracing.RecordError(span, err)
+		return nil, models.ProcessingRun{}, err
+	}
+	p.metrics.observeRun(data.Report)
+	run := models.ProcessingRun{
+		Source:    source,
+		Path:      describeSources(sources),
+		StartedAt: start,
+		Duration:  data.ProcessingDuration,
+		Records:   rows,
+	}
+	span.SetAttributes(attribute.Int("processor.rows", rows))
+	return data, run, nil
+}
+
+// checkBadRowRatio fails with a *BadRowRatioError when the run of report
+// skipped more than the share of its rows set with SetMaxBadRowRatio
+func (p *Processor) checkBadRowRatio(report models.ProcessingReport) error {
+	total := report.Rows + report.SkippedRows
+	if p.maxBadRatio == 0 || total == 0 {
+		return nil
+	}
+	if float64(report.SkippedRows)/float64(total) > p.maxBadRatio {
+		return &BadRowRatioError{Skipped: report.SkippedRows, Total: total, Limit: p.maxBadRatio, Report: report}
+	}
 	return nil
 }
 
-// readCSV reads CSV file and sends transactions to channel
-func (p *Processor) readCSV(file *os.File, transactionCh chan<- models.Transaction) error {
-	reader := csv.NewReader(bufio.NewReader(file))
+// phaseTimings splits a run that started at start into its read, aggregate
+// and finalize phases and computes the overall row throughput
+func phaseTimings(start, readDone, drained, finished time.Time, rows int) models.ProcessingTimings {
+	timings := models.ProcessingTimings{
+		Read:      readDone.Sub(start),
+		Aggregate: drained.Sub(readDone),
+		Finalize:  finished.Sub(drained),
+		Total:     finished.Sub(start),
+	}
+	if seconds := timings.Total.Seconds(); seconds > 0 {
+		timings.RowsPerSecond = float64(rows) / seconds
+	}
+	return timings
+}
+
+// readSource reads one source with read inside a span. Dataset files are
+// stat'ed so the span records their size.
+func (p *Processor) readSource(ctx context.Context, source, name string, sender *batchSender, read func(string, *batchSender) (models.FileReport, error)) (models.FileReport, error) {
+	_, span := p.tracer.Start(ctx, "processor.read_file", trace.WithAttributes(attribute.String("file.path", name)))
+	defer span.End()
+
+	if source == SourceDataset {
+		if info, err := os.Stat(name); err == nil {
+			span.SetAttributes(attribute.Int64("file.size", info.Size()))
+		}
+	}
+
+	report, err := read(name, sender)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return report, err
+	}
+	span.SetAttributes(attribute.Int("processor.rows", report.Rows), attribute.Int("processor.skipped_rows", report.Skipped))
+	return report, nil
+}
+
+// readCSV reads CSV data and sends transactions to the workers through
+// sender, returning the number of records read and read errors tolerated. Malformed records and
+// lines longer than maxLineBytes are skipped; I/O errors are retried until
+// more than maxReadErrors occur in a row.
+func (p *Processor) readCSV(r io.Reader, sender *batchSender) (models.FileReport, error) {
+	lines := newLineLimitReader(p.decodeCSV(r), p.maxLineBytes)
+	lines.logf = p.logf
+	reader := csv.NewReader(lines)
 	reader.LazyQuotes = true
 
 	// Read header
 	headers, err := reader.Read()
+	if err == io.EOF {
+		// An empty file holds no rows; whether that is an error is decided
+		// for the dataset as a whole
+		return models.FileReport{}, nil
+	}
+	if errors.Is(err, ErrAborted) {
+		return models.FileReport{}, err
+	}
 	if err != nil {
-		return fmt.Errorf("failed to read header: %w", err)
+		return models.FileReport{}, fmt.Errorf("failed to read header: %w", err)
+	}
+	if lines.dropped > 0 {
+		return models.FileReport{}, fmt.Errorf("failed to read header: line is longer than %d bytes", p.maxLineBytes)
 	}
 
-	// Map headers to indices
-	headerMap := make(map[string]int)
-	for i, header := range headers {
-		headerMap[strings.TrimSpace(strings.ToLower(header))] = i
+	// Map headers to indices once for the whole file
+	headerMap, header := resolveHeader(headers)
+	missing := make([]string, 0)
+	for _, column := range requiredColumns {
+		if _, ok := headerMap[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return models.FileReport{}, &InvalidHeaderError{Missing: missing}
 	}
+	p.logf("Header mapping: %s", describeHeaderMapping(header))
 
 	recordCount := 0
+	skipped := 0
+	readErrors := 0
+	truncatedFields := 0
+	invalidUTF8Rows := 0
+	detectInvalidUTF8 := p.detectsInvalidUTF8()
+	consecutiveErrors := 0
+	report := func() models.FileReport {
+		return models.FileReport{
+			Rows:            recordCount,
+			Skipped:         skipped + lines.dropped,
+			ReadErrors:      readErrors,
+			OversizedLines:  lines.dropped,
+			TruncatedFields: truncatedFields,
+			InvalidUTF8Rows: invalidUTF8Rows,
+			Header:          &header,
+		}
+	}
+	tooManyBadRows := func() error {
+		if bad := skipped + lines.dropped; p.maxBadRows > 0 && bad > p.maxBadRows {
+			return fmt.Errorf("%w: %d rows skipped by record %d, more than the limit of %d",
+				ErrTooManyBadRows, bad, recordCount, p.maxBadRows)
+		}
+		return nil
+	}
 	for {
+		if err := tooManyBadRows(); err != nil {
+			return report(), err
+		}
+
 		record, err := reader.Read()
 		if err == io.EOF {
 			break
 		}
+		if errors.Is(err, ErrAborted) {
+			return report(), err
+		}
 		if err != nil {
-			log.Printf("Error reading record %d: %v", recordCount, err)
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				p.logf("Error parsing record %d: %v", recordCount, err)
+				skipped++
+				continue
+			}
+
+			readErrors++
+			consecutiveErrors++
+			if consecutiveErrors > p.maxReadErrors {
+				return report(),
+					fmt.Errorf("%w (%d in a row after record %d): %w", ErrTooManyReadErrors, consecutiveErrors, recordCount, err)
+			}
+			p.logf("Error reading record %d (attempt %d of %d): %v", recordCount, consecutiveErrors, p.maxReadErrors, err)
 			continue
 		}
+		consecutiveErrors = 0
+		if detectInvalidUTF8 && !validUTF8(record) {
+			invalidUTF8Rows++
+		}
 
 		transaction, err := p.parseTransaction(record, headerMap)
 		if err != nil {
-			log.Printf("Error parsing record %d: %v", recordCount, err)
+			p.logf("Error parsing record %d: %v", recordCount, err)
+			skipped++
 			continue
 		}
 
-		transactionCh <- transaction
+		recordBytes := 0
+		for _, field := range record {
+			recordBytes += len(field)
+		}
+		truncatedFields += capFields(&transaction, recordBytes > largeRecordBytes)
+
+		sender.send(transaction)
 		recordCount++
 
 		// Log progress for large datasets
 		if recordCount%100000 == 0 {
-			log.Printf("Processed %d records", recordCount)
+			p.logf("Processed %d records", recordCount)
 		}
 	}
 
-	log.Printf("Finished reading %d records from CSV", recordCount)
-	return nil
+	if err := tooManyBadRows(); err != nil {
+		return report(), err
+	}
+
+	p.logf("Finished reading %d records from CSV", recordCount)
+	return report(), nil
 }
 
 // parseTransaction parses a CSV record into a Transaction struct
@@ -170,21 +830,27 @@ func (p *Processor) parseTransaction(record []string, headerMap map[string]int)
 		transaction.ProductID = strings.TrimSpace(record[idx])
 	}
 	if idx, ok := headerMap["product_name"]; ok && idx < len(record) {
-		transaction.ProductName = strings.TrimSpace(record[idx])
+		p.normalizeProductName(&transaction, record[idx])
 	}
 	if idx, ok := headerMap["category"]; ok && idx < len(record) {
 		transaction.Category = strings.TrimSpace(record[idx])
 	}
 	if idx, ok := headerMap["country"]; ok && idx < len(record) {
-		transaction.Country = strings.TrimSpace(record[idx])
+		transaction.Country = p.canonicalCountry(record[idx])
 	}
 	if idx, ok := headerMap["region"]; ok && idx < len(record) {
-		transaction.Region = strings.TrimSpace(record[idx])
+		transaction.Region = p.canonicalRegion(record[idx])
+	}
+	if idx, ok := headerMap["currency"]; ok && idx < len(record) {
+		transaction.Currency = normalizeCurrency(record[idx])
 	}
 
+	hasPrice, hasQuantity := false, false
+
 	if idx, ok := headerMap["price"]; ok && idx < len(record) {
 		if price, err := strconv.ParseFloat(strings.TrimSpace(record[idx]), 64); err == nil {
 			transaction.Price = price
+			hasPrice = true
 		}
 	}
 
@@ -197,131 +863,389 @@ func (p *Processor) parseTransaction(record []string, headerMap map[string]int)
 	if idx, ok := headerMap["quantity"]; ok && idx < len(record) {
 		if quantity, err := strconv.Atoi(strings.TrimSpace(record[idx])); err == nil {
 			transaction.Quantity = quantity
+			hasQuantity = true
 		}
 	}
 
+	if hasPrice && hasQuantity {
+		p.applyTotalsPolicy(&transaction)
+	}
+
 	if idx, ok := headerMap["stock_quantity"]; ok && idx < len(record) {
 		if stock, err := strconv.Atoi(strings.TrimSpace(record[idx])); err == nil {
 			transaction.StockQuantity = stock
+			transaction.StockReported = true
 		}
 	}
 
-	// Parse transaction_date
+	// Parse transaction_date and added_date
 	if idx, ok := headerMap["transaction_date"]; ok && idx < len(record) {
-		dateStr := strings.TrimSpace(record[idx])
-		// Try multiple date formats
-		formats := []string{
-			"2006-01-02",
-			"2006-01-02 15:04:05",
-			"01/02/2006",
-			"01-02-2006",
-			"2006/01/02",
-		}
-		for _, format := range formats {
-			if date, err := time.Parse(format, dateStr); err == nil {
-				transaction.TransactionDate = date
-				break
-			}
+		if date, offset, ok := p.parseDateOffset(record[idx]); ok {
+			transaction.TransactionDate = date
+			transaction.DateHasOffset = offset
 		}
 	}
-
-	// Parse added_date
 	if idx, ok := headerMap["added_date"]; ok && idx < len(record) {
-		dateStr := strings.TrimSpace(record[idx])
-		// Try multiple date formats
-		formats := []string{
-			"2006-01-02",
-			"2006-01-02 15:04:05",
-			"01/02/2006",
-			"01-02-2006",
-			"2006/01/02",
-		}
-		for _, format := range formats {
-			if date, err := time.Parse(format, dateStr); err == nil {
-				transaction.AddedDate = date
-				break
-			}
+		if date, ok := p.parseDate(record[idx]); ok {
+			transaction.AddedDate = date
 		}
 	}
 
 	return transaction, nil
 }
 
-// aggregateWorker processes transactions and updates aggregation maps
-func (p *Processor) aggregateWorker(
-	transactionCh <-chan models.Transaction,
-	mu *sync.Mutex,
-	countryMap map[string]*models.CountryRevenue,
-	productMap map[string]*models.ProductFrequency,
-	monthMap map[string]*models.MonthlySales,
-	regionMap map[string]*models.RegionRevenue,
-) {
-	for transaction := range transactionCh {
-		mu.Lock()
-
-		// Aggregate country revenue
-		countryKey := fmt.Sprintf("%s-%s", transaction.Country, transaction.ProductName)
-		if countryRev, exists := countryMap[countryKey]; exists {
-			countryRev.TotalRevenue += transaction.TotalPrice
+// aggregates holds the intermediate maps built while processing a dataset.
+// Workers update them while holding mu.
+type aggregates struct {
+	mu                 sync.Mutex
+	countryMap         map[string]*models.CountryRevenue
+	productMap         map[string]*models.ProductFrequency
+	stockPressureMap   map[string]*models.StockPressure
+	monthMap           map[string]*models.MonthlySales
+	weekMap            map[string]*models.WeeklySales
+	regionMap          map[string]*models.RegionRevenue
+	currencyMap        map[string]int
+	amountCurrencies   map[string]int
+	dayMap             map[string]*dailyTotal
+	regionProductMap   map[string]map[string]*models.RegionProduct
+	categoryProductMap map[string]map[string]*models.CategoryProduct
+	countryMonthMap    map[string]map[string]*models.MonthlySales
+	countryCustomerMap map[string]map[string]*models.CountryCustomer
+	countrySet         map[string]struct{}
+	userSet            map[string]struct{}
+
+	// productSpellings counts the raw spellings of each normalized product
+	// name, when product names are normalized
+	productSpellings map[string]map[string]int
+
+	// startDate and endDate are the earliest and latest non-zero
+	// transaction dates seen
+	startDate time.Time
+	endDate   time.Time
+
+	// maxKeys caps each map's size (0 means unlimited); overflow counts the
+	// rows folded into OtherBucket per map once the cap was reached
+	maxKeys  int
+	overflow map[string]int
+
+	// samples holds the raw transactions kept for debugging
+	samples *transactionSamples
+
+	// dimensions counts the spellings of each dimension's values
+	dimensions map[string]map[string]int
+
+	// seenIDs holds the transaction IDs aggregated so far when rows are
+	// deduplicated, and duplicates counts the rows skipped as repeats
+	seenIDs    map[string]struct{}
+	duplicates int
+
+	// offsetDates counts the rows whose transaction_date carried its own
+	// UTC offset
+	offsetDates int
+
+	// unattributed totals the rows without a region or country. Those rows
+	// are left out of the region and country aggregates unless
+	// keepBlankLocations is set.
+	unattributed       models.Unattributed
+	keepBlankLocations bool
+
+	// exclude, when set, leaves rows out of every aggregate and totals them
+	// in excluded, indexed like exclude.excludedTotals. countryCodes looks
+	// up the codes countries can be excluded by.
+	exclude      *exclusions
+	excluded     []models.ExcludedTotal
+	countryCodes map[string]string
+}
+
+// newAggregates creates an empty set of aggregation maps holding at most
+// maxKeys keys each, or unlimited keys when maxKeys <= 0
+func newAggregates(maxKeys int) *aggregates {
+	return &aggregates{
+		maxKeys:            maxKeys,
+		overflow:           make(map[string]int),
+		countryMap:         make(map[string]*models.CountryRevenue),
+		productMap:         make(map[string]*models.ProductFrequency),
+		stockPressureMap:   make(map[string]*models.StockPressure),
+		monthMap:           make(map[string]*models.MonthlySales),
+		weekMap:            make(map[string]*models.WeeklySales),
+		regionMap:          make(map[string]*models.RegionRevenue),
+		currencyMap:        make(map[string]int),
+		amountCurrencies:   make(map[string]int),
+		dayMap:             make(map[string]*dailyTotal),
+		regionProductMap:   make(map[string]map[string]*models.RegionProduct),
+		categoryProductMap: make(map[string]map[string]*models.CategoryProduct),
+		countryMonthMap:    make(map[string]map[string]*models.MonthlySales),
+		countryCustomerMap: make(map[string]map[string]*models.CountryCustomer),
+		countrySet:         make(map[string]struct{}),
+		userSet:            make(map[string]struct{}),
+		productSpellings:   make(map[string]map[string]int),
+		dimensions:         make(map[string]map[string]int),
+	}
+}
+
+// unattributedWarning describes the rows without a location of the given
+// kind, "region" or "country"
+func unattributedWarning(kind string, total models.UnattributedTotal, kept bool) string {
+	if kept {
+		return fmt.Sprintf("%d rows without a %s are aggregated under a blank %s", total.TransactionCount, kind, kind)
+	}
+	return fmt.Sprintf("%d rows without a %s (revenue %.2f) are left out of the %s aggregates", total.TransactionCount, kind, total.Revenue, kind)
+}
+
+// countUnattributed adds a row to total when its location is empty, and
+// reports whether the row is aggregated under that location: it is when the
+// location is set or blank locations are kept. Callers must hold agg.mu.
+func countUnattributed(agg *aggregates, total *models.UnattributedTotal, location string, amount float64) bool {
+	if location != "" {
+		return true
+	}
+	total.Revenue += amount
+	total.TransactionCount++
+	return agg.keepBlankLocations
+}
+
+// aggregateWorker aggregates the batches of transactions it receives,
+// returning each batch to the pool once it is done with it
+func (p *Processor) aggregateWorker(batchCh <-chan []models.Transaction, batches *batchPool, agg *aggregates, processed *atomic.Int64) {
+	amounts := make([]float64, 0, batches.size)
+	currencies := make([]string, 0, batches.size)
+	foldName := p.productNameFolder()
+	for batch := range batchCh {
+		// Revenue is kept per currency unless it can be normalized, and
+		// product names are folded; that needs no lock, so it is done for
+		// the whole batch first
+		amounts, currencies = amounts[:0], currencies[:0]
+		for i, transaction := range batch {
+			amount, currency := p.normalizeAmount(transaction)
+			amounts = append(amounts, amount)
+			currencies = append(currencies, currency)
+			if foldName != nil {
+				batch[i].ProductName = foldName(transaction.ProductName)
+			}
+		}
+
+		agg.mu.Lock()
+		for i, transaction := range batch {
+			processed.Add(1)
+			aggregateTransaction(agg, transaction, amounts[i], currencies[i])
+		}
+		agg.mu.Unlock()
+
+		batches.put(batch)
+	}
+}
+
+// aggregateTransaction adds one transaction, whose revenue is amount in
+// currency, to the aggregation maps. Callers must hold agg.mu.
+func aggregateTransaction(agg *aggregates, transaction models.Transaction, amount float64, currency string) {
+	if transaction.DateHasOffset {
+		agg.offsetDates++
+	}
+	if agg.seenIDs != nil && transaction.TransactionID != "" {
+		if _, seen := agg.seenIDs[transaction.TransactionID]; seen {
+			agg.duplicates++
+			return
+		}
+		agg.seenIDs[transaction.TransactionID] = struct{}{}
+	}
+	// Amounts without a currency count as one of their own, so that adding
+	// them to amounts in a known currency is flagged as mixing currencies
+	agg.amountCurrencies[currency]++
+	if excludeTransaction(agg, transaction, amount) {
+		return
+	}
+
+	agg.currencyMap[transaction.Currency]++
+	addCapped(agg, overflowCountries, agg.countrySet, transaction.Country)
+	addCapped(agg, overflowUsers, agg.userSet, transaction.UserID)
+
+	// Rows without a country or region are counted as unattributed and,
+	// unless blank locations are kept, left out of those aggregates
+	attributeCountry := countUnattributed(agg, &agg.unattributed.Country, transaction.Country, amount)
+	attributeRegion := countUnattributed(agg, &agg.unattributed.Region, transaction.Region, amount)
+
+	// Aggregate country revenue
+	if attributeCountry {
+		country, countryProduct := transaction.Country, transaction.ProductName
+		countryKey := fmt.Sprintf("%s-%s-%s", country, countryProduct, currency)
+		if cappedKey(agg, overflowCountryRevenues, agg.countryMap, countryKey) == OtherBucket {
+			country, countryProduct = OtherBucket, OtherBucket
+			countryKey = fmt.Sprintf("%s-%s-%s", country, countryProduct, currency)
+		}
+		if countryRev, exists := agg.countryMap[countryKey]; exists {
+			countryRev.TotalRevenue += amount
 			countryRev.TransactionCount++
+			countryRev.ItemsSold += transaction.Quantity
 		} else {
-			countryMap[countryKey] = &models.CountryRevenue{
-				Country:          transaction.Country,
-				ProductName:      transaction.ProductName,
-				TotalRevenue:     transaction.TotalPrice,
+			agg.countryMap[countryKey] = &models.CountryRevenue{
+				Country:          country,
+				ProductName:      countryProduct,
+				Currency:         currency,
+				TotalRevenue:     amount,
 				TransactionCount: 1,
+				ItemsSold:        transaction.Quantity,
 			}
 		}
+	}
 
-		// Aggregate product frequency
-		if product, exists := productMap[transaction.ProductName]; exists {
-			product.PurchaseCount++
-			if transaction.StockQuantity > 0 {
-				product.CurrentStock = transaction.StockQuantity // Keep latest stock value
-			}
-		} else {
-			productMap[transaction.ProductName] = &models.ProductFrequency{
-				ProductName:   transaction.ProductName,
-				PurchaseCount: 1,
-				CurrentStock:  transaction.StockQuantity,
-			}
+	// Aggregate product frequency
+	productKey := cappedKey(agg, overflowProducts, agg.productMap, transaction.ProductName)
+	if product, exists := agg.productMap[productKey]; exists {
+		product.PurchaseCount++
+		product.UnitsSold += transaction.Quantity
+		if transaction.StockQuantity > 0 {
+			product.CurrentStock = transaction.StockQuantity // Keep latest stock value
 		}
+	} else {
+		agg.productMap[productKey] = &models.ProductFrequency{
+			ProductName:   productKey,
+			PurchaseCount: 1,
+			UnitsSold:     transaction.Quantity,
+			CurrentStock:  transaction.StockQuantity,
+		}
+	}
+	recordSaleDate(agg.productMap[productKey], transaction.TransactionDate)
+	recordStockPressure(agg, productKey, transaction, amount)
+	if productKey == transaction.ProductName {
+		countProductSpelling(agg, transaction)
+	}
 
-		// Aggregate monthly sales (use transaction_date)
-		monthKey := fmt.Sprintf("%d-%02d", transaction.TransactionDate.Year(), transaction.TransactionDate.Month())
-		if monthlySales, exists := monthMap[monthKey]; exists {
-			monthlySales.TotalSales += transaction.TotalPrice
-			monthlySales.SalesVolume += transaction.Quantity
-		} else {
-			monthMap[monthKey] = &models.MonthlySales{
-				Month:       transaction.TransactionDate.Format("January"),
-				Year:        transaction.TransactionDate.Year(),
-				TotalSales:  transaction.TotalPrice,
-				SalesVolume: transaction.Quantity,
-			}
+	// Aggregate monthly sales (use transaction_date)
+	monthKey := monthBucket(transaction.TransactionDate)
+	monthlySales, exists := agg.monthMap[monthKey]
+	if !exists {
+		monthlySales = &models.MonthlySales{
+			Month:       transaction.TransactionDate.Format("January"),
+			Year:        transaction.TransactionDate.Year(),
+			MonthNumber: int(transaction.TransactionDate.Month()),
 		}
+		agg.monthMap[monthKey] = monthlySales
+	}
+	monthlySales.TotalSales += amount
+	monthlySales.SalesVolume += transaction.Quantity
+	addCurrencyAmount(&monthlySales.SalesByCurrency, currency, amount)
 
-		// Aggregate region revenue
-		if region, exists := regionMap[transaction.Region]; exists {
-			region.TotalRevenue += transaction.TotalPrice
-			region.ItemsSold += transaction.Quantity
-		} else {
-			regionMap[transaction.Region] = &models.RegionRevenue{
-				Region:       transaction.Region,
-				TotalRevenue: transaction.TotalPrice,
-				ItemsSold:    transaction.Quantity,
-			}
+	// Aggregate monthly sales per country for the country trend series
+	if attributeCountry {
+		countryMonthKey := cappedKey(agg, overflowCountryMonths, agg.countryMonthMap, transaction.Country)
+		countryMonths, exists := agg.countryMonthMap[countryMonthKey]
+		if !exists {
+			countryMonths = make(map[string]*models.MonthlySales)
+			agg.countryMonthMap[countryMonthKey] = countryMonths
+		}
+		countryMonth, exists := countryMonths[monthKey]
+		if !exists {
+			countryMonth = &models.MonthlySales{Month: monthlySales.Month, Year: monthlySales.Year, MonthNumber: monthlySales.MonthNumber}
+			countryMonths[monthKey] = countryMonth
+		}
+		countryMonth.TotalSales += amount
+		countryMonth.SalesVolume += transaction.Quantity
+		addCurrencyAmount(&countryMonth.SalesByCurrency, currency, amount)
+	}
+
+	// Aggregate region revenue
+	if attributeRegion {
+		regionKey := cappedKey(agg, overflowRegions, agg.regionMap, transaction.Region)
+		region, exists := agg.regionMap[regionKey]
+		if !exists {
+			region = &models.RegionRevenue{Region: regionKey}
+			agg.regionMap[regionKey] = region
+		}
+		region.TotalRevenue += amount
+		region.ItemsSold += transaction.Quantity
+		region.TransactionCount++
+		addCurrencyAmount(&region.RevenueByCurrency, currency, amount)
+
+		// Aggregate product quantities within each region
+		products, exists := agg.regionProductMap[regionKey]
+		if !exists {
+			products = make(map[string]*models.RegionProduct)
+			agg.regionProductMap[regionKey] = products
+		}
+		regionProductKey := cappedKey(agg, overflowRegionProducts, products, transaction.ProductName)
+		product, exists := products[regionProductKey]
+		if !exists {
+			product = &models.RegionProduct{ProductName: regionProductKey}
+			products[regionProductKey] = product
 		}
+		product.QuantitySold += transaction.Quantity
+		product.TotalRevenue += amount
+	}
+
+	// Aggregate daily totals for the rolling summary windows
+	if !transaction.TransactionDate.IsZero() {
+		dayKey := transaction.TransactionDate.Format("2006-01-02")
+		day, exists := agg.dayMap[dayKey]
+		if !exists {
+			day = &dailyTotal{Date: startOfDay(transaction.TransactionDate)}
+			agg.dayMap[dayKey] = day
+		}
+		day.Revenue += amount
+		day.Orders++
+
+		// Aggregate ISO week sales
+		weekKey := isoWeekKey(transaction.TransactionDate)
+		week, exists := agg.weekMap[weekKey]
+		if !exists {
+			week = newWeeklySales(transaction.TransactionDate)
+			agg.weekMap[weekKey] = week
+		}
+		week.TotalSales += amount
+		week.SalesVolume += transaction.Quantity
+		addCurrencyAmount(&week.SalesByCurrency, currency, amount)
+
+		if agg.startDate.IsZero() || transaction.TransactionDate.Before(agg.startDate) {
+			agg.startDate = transaction.TransactionDate
+		}
+		if transaction.TransactionDate.After(agg.endDate) {
+			agg.endDate = transaction.TransactionDate
+		}
+	}
+
+	// Aggregate products within each category; rows without one are
+	// left out of the category rankings
+	if category := categoryKey(transaction.Category); category != "" {
+		products, exists := agg.categoryProductMap[category]
+		if !exists {
+			products = make(map[string]*models.CategoryProduct)
+			agg.categoryProductMap[category] = products
+		}
+		categoryProductKey := cappedKey(agg, overflowCategoryProducts, products, transaction.ProductName)
+		product, exists := products[categoryProductKey]
+		if !exists {
+			product = &models.CategoryProduct{ProductName: categoryProductKey}
+			products[categoryProductKey] = product
+		}
+		product.PurchaseCount++
+		product.UnitsSold += transaction.Quantity
+		product.TotalRevenue += amount
+	}
+
+	if attributeCountry {
+		addCountryCustomer(agg, transaction, amount)
+	}
+	countDimension(agg, dimensionCountries, transaction.Country)
+	countDimension(agg, dimensionRegions, transaction.Region)
+	countDimension(agg, dimensionCategories, transaction.Category)
+	countDimension(agg, dimensionCurrencies, transaction.Currency)
+	agg.samples.add(agg, transaction)
 
-		mu.Unlock()
+}
+
+// countNonEmptyKeys returns the number of keys in m, ignoring the empty key
+// that collects rows where the column was missing
+func countNonEmptyKeys[V any](m map[string]V) int {
+	if _, ok := m[""]; ok {
+		return len(m) - 1
 	}
+	return len(m)
 }
 
 // Sorting functions
 func (p *Processor) sortCountryRevenues(countryMap map[string]*models.CountryRevenue) []models.CountryRevenue {
 	revenues := make([]models.CountryRevenue, 0, len(countryMap))
 	for _, rev := range countryMap {
-		revenues = append(revenues, *rev)
+		revenues = append(revenues, withAverageOrderValue(*rev))
 	}
 
 	sort.Slice(revenues, func(i, j int) bool {
@@ -331,23 +1255,82 @@ func (p *Processor) sortCountryRevenues(countryMap map[string]*models.CountryRev
 	return revenues
 }
 
-func (p *Processor) sortTopProducts(productMap map[string]*models.ProductFrequency, limit int) []models.ProductFrequency {
+// recordSaleDate widens the product's first and last sold dates to include
+// date. Zero dates, from rows without a valid transaction_date, are ignored.
+func recordSaleDate(product *models.ProductFrequency, date time.Time) {
+	if date.IsZero() {
+		return
+	}
+	if product.FirstSold == nil || date.Before(*product.FirstSold) {
+		product.FirstSold = &date
+	}
+	if product.LastSold == nil || date.After(*product.LastSold) {
+		product.LastSold = &date
+	}
+}
+
+// sortTopProducts ranks products by purchase count (models.RankByOrders) or
+// units sold (models.RankByUnits), breaking ties by the other count and
+// then by name
+func (p *Processor) sortTopProducts(productMap map[string]*models.ProductFrequency, limit int, rankBy string) []models.ProductFrequency {
 	products := make([]models.ProductFrequency, 0, len(productMap))
 	for _, product := range productMap {
 		products = append(products, *product)
 	}
 
+	primary := func(product models.ProductFrequency) int { return product.PurchaseCount }
+	secondary := func(product models.ProductFrequency) int { return product.UnitsSold }
+	if rankBy == models.RankByUnits {
+		primary, secondary = secondary, primary
+	}
 	sort.Slice(products, func(i, j int) bool {
-		return products[i].PurchaseCount > products[j].PurchaseCount
+		if a, b := primary(products[i]), primary(products[j]); a != b {
+			return a > b
+		}
+		if a, b := secondary(products[i]), secondary(products[j]); a != b {
+			return a > b
+		}
+		return products[i].ProductName < products[j].ProductName
 	})
 
 	if len(products) > limit {
 		products = products[:limit]
 	}
 
+	for i := range products {
+		products[i].Rank = i + 1
+		products[i].StockStatus = p.stockStatus(products[i].CurrentStock)
+	}
+
 	return products
 }
 
+// monthBucket returns the key of the month a transaction date falls in
+func monthBucket(date time.Time) string {
+	return fmt.Sprintf("%d-%02d", date.Year(), date.Month())
+}
+
+// countDaysWithSales sets the days with sales of each month from the daily
+// totals, which hold one entry per distinct transaction date, and averages
+// the month's sales over them. Days are matched by their key, the date in
+// the data timezone.
+func countDaysWithSales(monthMap map[string]*models.MonthlySales, dayMap map[string]*dailyTotal) {
+	for dayKey := range dayMap {
+		date, err := time.Parse("2006-01-02", dayKey)
+		if err != nil {
+			continue
+		}
+		if month, ok := monthMap[monthBucket(date)]; ok {
+			month.DaysWithSales++
+		}
+	}
+	for _, month := range monthMap {
+		if month.DaysWithSales > 0 {
+			month.AverageDailySales = month.TotalSales / float64(month.DaysWithSales)
+		}
+	}
+}
+
 func (p *Processor) sortMonthlySales(monthMap map[string]*models.MonthlySales) []models.MonthlySales {
 	sales := make([]models.MonthlySales, 0, len(monthMap))
 	for _, sale := range monthMap {
@@ -358,21 +1341,27 @@ func (p *Processor) sortMonthlySales(monthMap map[string]*models.MonthlySales) [
 		if sales[i].Year != sales[j].Year {
 			return sales[i].Year > sales[j].Year
 		}
-		return sales[i].TotalSales > sales[j].TotalSales
+		if sales[i].TotalSales != sales[j].TotalSales {
+			return sales[i].TotalSales > sales[j].TotalSales
+		}
+		return sales[i].MonthNumber < sales[j].MonthNumber
 	})
+	applyGrowth(sales)
 
 	return sales
 }
 
-func (p *Processor) sortTopRegions(regionMap map[string]*models.RegionRevenue, limit int) []models.RegionRevenue {
+// sortTopRegions ranks regions by revenue, keeping the top limit. Regions
+// with the same total revenue, as all are when totals are left at zero for
+// mixed currencies, are ranked by their per-currency revenue, comparing
+// currencies in the given order.
+func (p *Processor) sortTopRegions(regionMap map[string]*models.RegionRevenue, limit int, currencies []string) []models.RegionRevenue {
 	regions := make([]models.RegionRevenue, 0, len(regionMap))
 	for _, region := range regionMap {
-		regions = append(regions, *region)
+		regions = append(regions, withRegionAverages(*region))
 	}
 
-	sort.Slice(regions, func(i, j int) bool {
-		return regions[i].TotalRevenue > regions[j].TotalRevenue
-	})
+	rankRegions(regions, models.RankByRevenue, currencies)
 
 	if len(regions) > limit {
 		regions = regions[:limit]
@@ -381,37 +1370,200 @@ func (p *Processor) sortTopRegions(regionMap map[string]*models.RegionRevenue, l
 	return regions
 }
 
-// GetDashboardData returns the current dashboard data (thread-safe)
+// rankRegions sorts regions by total revenue (models.RankByRevenue) or
+// items sold (models.RankByItems). Revenue ties are broken by the revenue in
+// each of currencies in turn, and remaining ties by region name.
+func rankRegions(regions []models.RegionRevenue, rankBy string, currencies []string) {
+	sort.Slice(regions, func(i, j int) bool {
+		if rankBy == models.RankByItems {
+			if a, b := regions[i].ItemsSold, regions[j].ItemsSold; a != b {
+				return a > b
+			}
+		} else {
+			if a, b := regions[i].TotalRevenue, regions[j].TotalRevenue; a != b {
+				return a > b
+			}
+			if c := compareByCurrency(regions[i].RevenueByCurrency, regions[j].RevenueByCurrency, currencies); c != 0 {
+				return c > 0
+			}
+		}
+		return regions[i].Region < regions[j].Region
+	})
+}
+
+// withRegionAverages fills in the average basket size (items per
+// transaction) and average order value of a region. Both stay zero for a
+// region without transactions.
+func withRegionAverages(region models.RegionRevenue) models.RegionRevenue {
+	if region.TransactionCount > 0 {
+		region.AverageBasketSize = float64(region.ItemsSold) / float64(region.TransactionCount)
+		region.AverageOrderValue = region.TotalRevenue / float64(region.TransactionCount)
+	}
+	return region
+}
+
+// withAverageOrderValue fills in the row's average order value
+func withAverageOrderValue(revenue models.CountryRevenue) models.CountryRevenue {
+	if revenue.TransactionCount > 0 {
+		revenue.AverageOrderValue = revenue.TotalRevenue / float64(revenue.TransactionCount)
+	}
+	return revenue
+}
+
+// sortRegionProducts ranks the products within each region by quantity sold,
+// keeping only the top limit entries per region
+func (p *Processor) sortRegionProducts(regionProductMap map[string]map[string]*models.RegionProduct, limit int) map[string][]models.RegionProduct {
+	result := make(map[string][]models.RegionProduct, len(regionProductMap))
+	for region, productMap := range regionProductMap {
+		products := make([]models.RegionProduct, 0, len(productMap))
+		for _, product := range productMap {
+			products = append(products, *product)
+		}
+
+		sort.Slice(products, func(i, j int) bool {
+			if products[i].QuantitySold != products[j].QuantitySold {
+				return products[i].QuantitySold > products[j].QuantitySold
+			}
+			return products[i].ProductName < products[j].ProductName
+		})
+
+		if len(products) > limit {
+			products = products[:limit]
+		}
+		result[region] = products
+	}
+
+	return result
+}
+
+// sortCountryMonthlySales keeps the monthly series of the limit countries
+// with the highest total revenue, each ordered chronologically
+func (p *Processor) sortCountryMonthlySales(countryMonthMap map[string]map[string]*models.MonthlySales, limit int) map[string][]models.MonthlySales {
+	type countryTotal struct {
+		country string
+		revenue float64
+	}
+
+	totals := make([]countryTotal, 0, len(countryMonthMap))
+	for country, months := range countryMonthMap {
+		total := countryTotal{country: country}
+		for _, month := range months {
+			total.revenue += month.TotalSales
+		}
+		totals = append(totals, total)
+	}
+
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].revenue != totals[j].revenue {
+			return totals[i].revenue > totals[j].revenue
+		}
+		return totals[i].country < totals[j].country
+	})
+	if len(totals) > limit {
+		totals = totals[:limit]
+	}
+
+	result := make(map[string][]models.MonthlySales, len(totals))
+	for _, total := range totals {
+		months := countryMonthMap[total.country]
+
+		// Month keys are YYYY-MM, so they sort chronologically
+		keys := make([]string, 0, len(months))
+		for key := range months {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		series := make([]models.MonthlySales, 0, len(keys))
+		for _, key := range keys {
+			series = append(series, *months[key])
+		}
+		applyGrowth(series)
+		result[total.country] = series
+	}
+
+	return result
+}
+
+// GetDashboardData returns the current dashboard data snapshot. It is
+// shared between callers and must not be modified. Handlers that need
+// several fields should load it once, so that they all come from the same
+// dataset even if a reload swaps in a new one meanwhile.
 func (p *Processor) GetDashboardData() *models.DashboardData {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-	return p.dashboardData
+	return p.data.Load()
 }
 
 // GetCountryRevenues returns country revenue data
 func (p *Processor) GetCountryRevenues() []models.CountryRevenue {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-	return p.dashboardData.CountryRevenues
+	data := p.data.Load()
+	return data.CountryRevenues
 }
 
 // GetTopProducts returns top products data
 func (p *Processor) GetTopProducts() []models.ProductFrequency {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-	return p.dashboardData.TopProducts
+	data := p.data.Load()
+	return data.TopProducts
 }
 
 // GetMonthlySales returns monthly sales data
 func (p *Processor) GetMonthlySales() []models.MonthlySales {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-	return p.dashboardData.MonthlySales
+	data := p.data.Load()
+	return data.MonthlySales
 }
 
 // GetTopRegions returns top regions data
 func (p *Processor) GetTopRegions() []models.RegionRevenue {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-	return p.dashboardData.TopRegions
+	data := p.data.Load()
+	return data.TopRegions
+}
+
+// GetTopRegionsRankedBy returns the retained top regions ranked by rankBy,
+// models.RankByRevenue or models.RankByItems
+func (p *Processor) GetTopRegionsRankedBy(rankBy string) []models.RegionRevenue {
+	return TopRegionsRankedBy(p.data.Load(), rankBy)
+}
+
+// TopRegionsRankedBy returns the top regions retained in data ranked by
+// rankBy, for callers that already hold a snapshot and need the regions to
+// match the rest of it
+func TopRegionsRankedBy(data *models.DashboardData, rankBy string) []models.RegionRevenue {
+	if rankBy != models.RankByItems {
+		return data.TopRegions
+	}
+
+	ranked := make([]models.RegionRevenue, len(data.TopRegions))
+	copy(ranked, data.TopRegions)
+	rankRegions(ranked, rankBy, data.CurrencyOrder)
+	return ranked
+}
+
+// GetRegionProducts returns up to limit best-selling products in a region.
+// The second return value is false when the region is unknown.
+func (p *Processor) GetRegionProducts(region string, limit int) ([]models.RegionProduct, bool) {
+	data := p.data.Load()
+
+	products, ok := data.RegionProducts[region]
+	if !ok {
+		return nil, false
+	}
+	if limit > 0 && len(products) > limit {
+		products = products[:limit]
+	}
+	return products, true
+}
+
+// GetCountryMonthlySales returns the chronological monthly sales series for
+// a country. The second return value is false when the country is unknown or
+// not among the CountryTrendLimit countries retained.
+func (p *Processor) GetCountryMonthlySales(country string) ([]models.MonthlySales, bool) {
+	data := p.data.Load()
+
+	series, ok := data.CountryMonthlySales[country]
+	return series, ok
+}
+
+// GetSummary returns the rolling revenue summary
+func (p *Processor) GetSummary() models.Summary {
+	data := p.data.Load()
+	return data.Summary
 }
@@ -2,10 +2,27 @@ package processor
 
 import (
 	"abt-analytics-dashboard/internal/models"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
 
+// writeTestFile writes content to a file in a temporary directory and returns its path
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	return path
+}
+
 func TestNew(t *testing.T) {
 	processor := New()
 
@@ -13,22 +30,22 @@ func TestNew(t *testing.T) {
 		t.Fatal("Expected processor to be created, got nil")
 	}
 
-	if processor.dashboardData == nil {
-		t.Fatal("Expected dashboardData to be initialized, got nil")
+	if processor.GetDashboardData() == nil {
+		t.Fatal("Expected dashboard data to be initialized, got nil")
 	}
 
 	// Check that slices are initialized
-	if len(processor.dashboardData.CountryRevenues) != 0 {
-		t.Errorf("Expected empty CountryRevenues slice, got %d items", len(processor.dashboardData.CountryRevenues))
+	if len(processor.GetDashboardData().CountryRevenues) != 0 {
+		t.Errorf("Expected empty CountryRevenues slice, got %d items", len(processor.GetDashboardData().CountryRevenues))
 	}
-	if len(processor.dashboardData.TopProducts) != 0 {
-		t.Errorf("Expected empty TopProducts slice, got %d items", len(processor.dashboardData.TopProducts))
+	if len(processor.GetDashboardData().TopProducts) != 0 {
+		t.Errorf("Expected empty TopProducts slice, got %d items", len(processor.GetDashboardData().TopProducts))
 	}
-	if len(processor.dashboardData.MonthlySales) != 0 {
-		t.Errorf("Expected empty MonthlySales slice, got %d items", len(processor.dashboardData.MonthlySales))
+	if len(processor.GetDashboardData().MonthlySales) != 0 {
+		t.Errorf("Expected empty MonthlySales slice, got %d items", len(processor.GetDashboardData().MonthlySales))
 	}
-	if len(processor.dashboardData.TopRegions) != 0 {
-		t.Errorf("Expected empty TopRegions slice, got %d items", len(processor.dashboardData.TopRegions))
+	if len(processor.GetDashboardData().TopRegions) != 0 {
+		t.Errorf("Expected empty TopRegions slice, got %d items", len(processor.GetDashboardData().TopRegions))
 	}
 }
 
@@ -239,7 +256,7 @@ func TestSortTopProducts(t *testing.T) {
 		"product4": {ProductName: "Product4", PurchaseCount: 400, CurrentStock: 100},
 	}
 
-	sorted := processor.sortTopProducts(productMap, 3)
+	sorted := processor.sortTopProducts(productMap, 3, models.RankByOrders)
 
 	if len(sorted) != 3 {
 		t.Errorf("Expected 3 sorted items (limit), got %d", len(sorted))
@@ -257,6 +274,62 @@ func TestSortTopProducts(t *testing.T) {
 	}
 }
 
+func TestProductPurchaseCountAndUnitsSold(t *testing.T) {
+	// Mouse is bought in more orders, Cable in more units
+	path := writeTestFile(t, "products.csv", `transaction_id,product_name,quantity,total_price
+TXN001,Mouse,1,20
+TXN002,Mouse,1,20
+TXN003,Mouse,2,40
+TXN004,Cable,10,50
+TXN005,Cable,5,25
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	byOrders := processor.FilterTopProducts(models.TopProductsFilter{})
+	if len(byOrders) != 2 {
+		t.Fatalf("Expected 2 products, got %+v", byOrders)
+	}
+	if byOrders[0].ProductName != "Mouse" || byOrders[0].PurchaseCount != 3 || byOrders[0].UnitsSold != 4 {
+		t.Errorf("Expected Mouse first by orders with 3 purchases and 4 units, got %+v", byOrders[0])
+	}
+	if byOrders[1].ProductName != "Cable" || byOrders[1].PurchaseCount != 2 || byOrders[1].UnitsSold != 15 {
+		t.Errorf("Expected Cable second by orders with 2 purchases and 15 units, got %+v", byOrders[1])
+	}
+
+	byUnits := processor.FilterTopProducts(models.TopProductsFilter{RankBy: models.RankByUnits})
+	if len(byUnits) != 2 {
+		t.Fatalf("Expected 2 products, got %+v", byUnits)
+	}
+	if byUnits[0].ProductName != "Cable" || byUnits[0].Rank != 1 {
+		t.Errorf("Expected Cable ranked first by units, got %+v", byUnits[0])
+	}
+	if byUnits[1].ProductName != "Mouse" || byUnits[1].Rank != 2 {
+		t.Errorf("Expected Mouse ranked second by units, got %+v", byUnits[1])
+	}
+}
+
+func TestSortTopProductsByUnits(t *testing.T) {
+	processor := New()
+
+	productMap := map[string]*models.ProductFrequency{
+		"a": {ProductName: "A", PurchaseCount: 10, UnitsSold: 10},
+		"b": {ProductName: "B", PurchaseCount: 2, UnitsSold: 40},
+		"c": {ProductName: "C", PurchaseCount: 5, UnitsSold: 40},
+	}
+
+	sorted := processor.sortTopProducts(productMap, 3, models.RankByUnits)
+
+	// C and B tie on units; C has more purchases
+	names := []string{sorted[0].ProductName, sorted[1].ProductName, sorted[2].ProductName}
+	if names[0] != "C" || names[1] != "B" || names[2] != "A" {
+		t.Errorf("Expected order [C B A], got %v", names)
+	}
+}
+
 func TestSortMonthlySales(t *testing.T) {
 	processor := New()
 
@@ -299,7 +372,7 @@ func TestSortTopRegions(t *testing.T) {
 		"region3": {Region: "Asia", TotalRevenue: 5000.0, ItemsSold: 500},
 	}
 
-	sorted := processor.sortTopRegions(regionMap, 2)
+	sorted := processor.sortTopRegions(regionMap, 2, nil)
 
 	if len(sorted) != 2 {
 		t.Errorf("Expected 2 sorted items (limit), got %d", len(sorted))
@@ -314,6 +387,127 @@ func TestSortTopRegions(t *testing.T) {
 	}
 }
 
+// rankingTestRegions is a dataset where Europe leads by revenue, Asia by
+// items sold, and Africa and Oceania tie on items
+const rankingTestRegions = `transaction_id,region,product_name,quantity,total_price
+TXN001,Europe,Laptop,1,2000
+TXN002,Asia,Cable,40,200
+TXN003,Oceania,Mouse,5,150
+TXN004,Africa,Mouse,5,100
+`
+
+func TestGetTopRegionsRankedBy(t *testing.T) {
+	processor := New()
+	if err := processor.ProcessDataset(writeTestFile(t, "regions.csv", rankingTestRegions)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	names := func(regions []models.RegionRevenue) string {
+		var list []string
+		for _, region := range regions {
+			list = append(list, region.Region)
+		}
+		return strings.Join(list, ",")
+	}
+
+	if got := names(processor.GetTopRegionsRankedBy(models.RankByRevenue)); got != "Europe,Asia,Oceania,Africa" {
+		t.Errorf("Expected the revenue ranking, got %s", got)
+	}
+	if got := names(processor.GetTopRegionsRankedBy(models.RankByItems)); got != "Asia,Africa,Oceania,Europe" {
+		t.Errorf("Expected the items ranking with ties by name, got %s", got)
+	}
+	if got := names(processor.GetTopRegions()); got != "Europe,Asia,Oceania,Africa" {
+		t.Errorf("Expected the served ranking to stay by revenue, got %s", got)
+	}
+}
+
+func TestRegionAverages(t *testing.T) {
+	// Europe: 3 transactions, 7 items, 300 revenue; Asia: 1 transaction
+	path := writeTestFile(t, "regions.csv", `transaction_id,region,product_name,quantity,total_price
+TXN001,Europe,Laptop,1,150
+TXN002,Europe,Mouse,4,100
+TXN003,Europe,Cable,2,50
+TXN004,Asia,Camera,3,90
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []models.RegionRevenue{
+		{Region: "Europe", TotalRevenue: 300, ItemsSold: 7, TransactionCount: 3, AverageBasketSize: 7.0 / 3, AverageOrderValue: 100},
+		{Region: "Asia", TotalRevenue: 90, ItemsSold: 3, TransactionCount: 1, AverageBasketSize: 3, AverageOrderValue: 90},
+	}
+	regions := processor.GetTopRegions()
+	if len(regions) != len(expected) {
+		t.Fatalf("Expected %d regions, got %+v", len(expected), regions)
+	}
+	for i, want := range expected {
+		got := regions[i]
+		if got.Region != want.Region || got.TotalRevenue != want.TotalRevenue || got.ItemsSold != want.ItemsSold ||
+			got.TransactionCount != want.TransactionCount || got.AverageBasketSize != want.AverageBasketSize ||
+			got.AverageOrderValue != want.AverageOrderValue {
+			t.Errorf("Expected region %d to be %+v, got %+v", i, want, got)
+		}
+	}
+}
+
+func TestProcessingTimings(t *testing.T) {
+	path := writeTestFile(t, "timings.csv", `transaction_id,region,product_name,quantity,total_price
+TXN001,Europe,Laptop,1,150
+TXN002,Europe,Mouse,4,100
+TXN003,Asia,Camera,3,90
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data := processor.GetDashboardData()
+	timings := data.Report.Timings
+	if timings.Read <= 0 || timings.Aggregate < 0 || timings.Finalize < 0 {
+		t.Errorf("Expected non-negative phase timings with a positive read phase, got %+v", timings)
+	}
+	sum := timings.Read + timings.Aggregate + timings.Finalize
+	if diff := timings.Total - sum; diff < -time.Millisecond || diff > time.Millisecond {
+		t.Errorf("Expected phases to add up to total %v, got %v", timings.Total, sum)
+	}
+	if timings.Total != data.ProcessingDuration {
+		t.Errorf("Expected total %v to match processing duration %v", timings.Total, data.ProcessingDuration)
+	}
+	if timings.RowsPerSecond <= 0 {
+		t.Errorf("Expected positive rows/sec, got %f", timings.RowsPerSecond)
+	}
+}
+
+func TestPhaseTimings(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	timings := phaseTimings(start, start.Add(3*time.Second), start.Add(4*time.Second), start.Add(5*time.Second), 1000)
+
+	if timings.Read != 3*time.Second || timings.Aggregate != time.Second || timings.Finalize != time.Second {
+		t.Errorf("Expected 3s/1s/1s phases, got %+v", timings)
+	}
+	if timings.Total != 5*time.Second {
+		t.Errorf("Expected total 5s, got %v", timings.Total)
+	}
+	if timings.RowsPerSecond != 200 {
+		t.Errorf("Expected 200 rows/sec, got %f", timings.RowsPerSecond)
+	}
+
+	if empty := phaseTimings(start, start, start, start, 10); empty.RowsPerSecond != 0 {
+		t.Errorf("Expected zero rows/sec for an instant run, got %f", empty.RowsPerSecond)
+	}
+}
+
+func TestRegionAveragesWithoutTransactions(t *testing.T) {
+	region := withRegionAverages(models.RegionRevenue{Region: "Empty"})
+	if region.AverageBasketSize != 0 || region.AverageOrderValue != 0 {
+		t.Errorf("Expected zero averages without transactions, got %+v", region)
+	}
+}
+
 func TestGetDashboardData(t *testing.T) {
 	processor := New()
 
@@ -453,32 +647,525 @@ func TestLoadSampleData(t *testing.T) {
 	processor := New()
 
 	// Initially empty
-	if len(processor.dashboardData.CountryRevenues) != 0 {
+	if len(processor.GetDashboardData().CountryRevenues) != 0 {
 		t.Error("Expected initial CountryRevenues to be empty")
 	}
 
 	processor.LoadSampleData()
 
 	// Should be populated after loading
-	if len(processor.dashboardData.CountryRevenues) == 0 {
+	if len(processor.GetDashboardData().CountryRevenues) == 0 {
 		t.Error("Expected CountryRevenues to be populated after loading sample data")
 	}
-	if len(processor.dashboardData.TopProducts) == 0 {
+	if len(processor.GetDashboardData().TopProducts) == 0 {
 		t.Error("Expected TopProducts to be populated after loading sample data")
 	}
-	if len(processor.dashboardData.MonthlySales) == 0 {
+	if len(processor.GetDashboardData().MonthlySales) == 0 {
 		t.Error("Expected MonthlySales to be populated after loading sample data")
 	}
-	if len(processor.dashboardData.TopRegions) == 0 {
+	if len(processor.GetDashboardData().TopRegions) == 0 {
 		t.Error("Expected TopRegions to be populated after loading sample data")
 	}
+	for _, region := range processor.GetDashboardData().TopRegions {
+		if region.AverageOrderValue != region.TotalRevenue/float64(region.TransactionCount) {
+			t.Errorf("Expected sample average order value for %s to match its totals, got %+v", region.Region, region)
+		}
+	}
 
 	// Verify metadata is set
-	if processor.dashboardData.LastUpdated.IsZero() {
+	if processor.GetDashboardData().LastUpdated.IsZero() {
 		t.Error("Expected LastUpdated to be set after loading sample data")
 	}
 	// Note: ProcessingDuration might be very small for sample data
-	if processor.dashboardData.RecordCount == 0 {
+	if processor.GetDashboardData().RecordCount == 0 {
 		t.Error("Expected RecordCount to be set after loading sample data")
 	}
 }
+
+func TestProcessDatasetMissingFile(t *testing.T) {
+	processor := New()
+
+	err := processor.ProcessDataset(filepath.Join(t.TempDir(), "missing.csv"))
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestProcessDatasetEmptyDirectory(t *testing.T) {
+	processor := New()
+
+	err := processor.ProcessDataset(t.TempDir())
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestProcessDatasetDirectoryMatch(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "shard.csv"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	processor := New()
+
+	err := processor.ProcessDataset(filepath.Join(dir, "*.csv"))
+	if !errors.Is(err, ErrNotAFile) {
+		t.Errorf("Expected ErrNotAFile, got %v", err)
+	}
+}
+
+func TestProcessDatasetPermissionDenied(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Skipping permission test when running as root")
+	}
+
+	path := writeTestFile(t, "locked.csv", "transaction_id\nTXN001\n")
+	if err := os.Chmod(path, 0000); err != nil {
+		t.Fatalf("Failed to chmod test file: %v", err)
+	}
+	defer os.Chmod(path, 0644)
+
+	processor := New()
+
+	err := processor.ProcessDataset(path)
+	if !errors.Is(err, ErrPermission) {
+		t.Errorf("Expected ErrPermission, got %v", err)
+	}
+}
+
+func TestProcessDatasetErrorIncludesAbsolutePath(t *testing.T) {
+	processor := New()
+
+	err := processor.ProcessDataset("definitely-missing.csv")
+	if err == nil {
+		t.Fatal("Expected error for missing file")
+	}
+
+	absPath, _ := filepath.Abs("definitely-missing.csv")
+	if !strings.Contains(err.Error(), absPath) {
+		t.Errorf("Expected error to mention %s, got %v", absPath, err)
+	}
+}
+
+func TestProcessDatasetRegionProducts(t *testing.T) {
+	path := writeTestFile(t, "regions.csv", `transaction_id,transaction_date,country,region,product_name,quantity,total_price
+TXN001,2024-01-15,USA,North America,Laptop,2,2000
+TXN002,2024-01-16,USA,North America,Phone,5,2500
+TXN003,2024-01-17,Canada,North America,Laptop,4,4000
+TXN004,2024-01-18,Canada,North America,Tablet,1,300
+TXN005,2024-01-19,UK,Europe,Tablet,3,900
+TXN006,2024-01-20,UK,Europe,Phone,1,500
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	// North America: Laptop 6, Phone 5, Tablet 1
+	products, ok := processor.GetRegionProducts("North America", 0)
+	if !ok {
+		t.Fatal("Expected North America to be found")
+	}
+	expected := []models.RegionProduct{
+		{ProductName: "Laptop", QuantitySold: 6, TotalRevenue: 6000},
+		{ProductName: "Phone", QuantitySold: 5, TotalRevenue: 2500},
+		{ProductName: "Tablet", QuantitySold: 1, TotalRevenue: 300},
+	}
+	if len(products) != len(expected) {
+		t.Fatalf("Expected %d products, got %d", len(expected), len(products))
+	}
+	for i := range expected {
+		if products[i] != expected[i] {
+			t.Errorf("Expected rank %d to be %+v, got %+v", i+1, expected[i], products[i])
+		}
+	}
+
+	// Europe: Tablet 3, Phone 1
+	products, _ = processor.GetRegionProducts("Europe", 1)
+	if len(products) != 1 || products[0].ProductName != "Tablet" {
+		t.Errorf("Expected Europe top product to be Tablet, got %+v", products)
+	}
+
+	if _, ok := processor.GetRegionProducts("Antarctica", 10); ok {
+		t.Error("Expected unknown region to be reported as missing")
+	}
+}
+
+func TestProcessDatasetDistinctCounts(t *testing.T) {
+	path := writeTestFile(t, "distinct.csv", `transaction_id,transaction_date,user_id,country,region,product_name,quantity,total_price
+TXN001,2024-01-15,USER001,USA,North America,Laptop,1,1000
+TXN002,2024-01-16,USER001,USA,North America,Phone,1,500
+TXN003,2024-01-17,USER002,Canada,North America,Laptop,1,1000
+TXN004,2024-01-18,USER003,UK,Europe,Tablet,1,300
+TXN005,2024-01-19,USER003,UK,Europe,Tablet,1,300
+TXN006,2024-01-20,,UK,,Monitor,1,200
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	data := processor.GetDashboardData()
+	if data.DistinctProducts != 4 {
+		t.Errorf("Expected 4 distinct products, got %d", data.DistinctProducts)
+	}
+	if data.DistinctCountries != 3 {
+		t.Errorf("Expected 3 distinct countries, got %d", data.DistinctCountries)
+	}
+	if data.DistinctRegions != 2 {
+		t.Errorf("Expected 2 distinct regions, got %d", data.DistinctRegions)
+	}
+	if data.DistinctUsers != 3 {
+		t.Errorf("Expected 3 distinct users, got %d", data.DistinctUsers)
+	}
+}
+
+var errTransient = errors.New("transient I/O error")
+
+// flakyReader serves its content one line per Read call and fails the calls
+// whose 1-based index is listed in failOn
+type flakyReader struct {
+	lines  []string
+	failOn map[int]bool
+	calls  int
+}
+
+func newFlakyReader(content string, failOn ...int) *flakyReader {
+	reader := &flakyReader{lines: strings.SplitAfter(content, "\n"), failOn: make(map[int]bool)}
+	for _, call := range failOn {
+		reader.failOn[call] = true
+	}
+	return reader
+}
+
+func (r *flakyReader) Read(p []byte) (int, error) {
+	r.calls++
+	if r.failOn[r.calls] {
+		return 0, errTransient
+	}
+	if len(r.lines) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.lines[0])
+	r.lines[0] = r.lines[0][n:]
+	if r.lines[0] == "" {
+		r.lines = r.lines[1:]
+	}
+	return n, nil
+}
+
+const flakyCSV = `transaction_id,transaction_date,user_id,country,region,product_id,product_name,category,price,quantity,total_price,stock_quantity,added_date
+TXN001,2024-01-15,USER001,USA,North America,PROD001,Laptop,Electronics,100,1,100,10,2024-01-01
+TXN002,2024-01-16,USER002,USA,North America,PROD001,Laptop,Electronics,100,1,100,10,2024-01-01
+TXN003,2024-01-17,USER003,USA,North America,PROD001,Laptop,Electronics,100,1,100,10,2024-01-01
+TXN004,2024-01-18,USER004,USA,North America,PROD001,Laptop,Electronics,100,1,100,10,2024-01-01
+`
+
+func TestProcessReaderToleratesTransientErrors(t *testing.T) {
+	processor := New()
+	processor.SetMaxReadErrors(2)
+
+	// Two consecutive failures, then a third isolated one
+	if err := processor.ProcessReader(newFlakyReader(flakyCSV, 3, 4, 6)); err != nil {
+		t.Fatalf("Expected transient errors to be tolerated, got %v", err)
+	}
+
+	revenues := processor.GetCountryRevenues()
+	if len(revenues) != 1 || revenues[0].TransactionCount != 4 {
+		t.Errorf("Expected all 4 transactions to be aggregated, got %+v", revenues)
+	}
+
+	report := processor.GetDashboardData().Report
+	if report.ReadErrors != 3 {
+		t.Errorf("Expected 3 read errors in report, got %d", report.ReadErrors)
+	}
+	if len(report.Files) != 1 || report.Files[0].Path != readerSourceName || report.Files[0].Rows != 4 {
+		t.Errorf("Expected a single reader file report with 4 rows, got %+v", report.Files)
+	}
+}
+
+func TestProcessReaderTooManyReadErrors(t *testing.T) {
+	processor := New()
+	processor.SetMaxReadErrors(2)
+
+	err := processor.ProcessReader(newFlakyReader(flakyCSV, 3, 4, 5))
+	if !errors.Is(err, ErrTooManyReadErrors) {
+		t.Fatalf("Expected ErrTooManyReadErrors, got %v", err)
+	}
+	if !errors.Is(err, errTransient) {
+		t.Errorf("Expected error to wrap the last read error, got %v", err)
+	}
+}
+
+func TestProcessReaderZeroToleranceFailsFast(t *testing.T) {
+	processor := New()
+	processor.SetMaxReadErrors(0)
+
+	if err := processor.ProcessReader(newFlakyReader(flakyCSV, 3)); !errors.Is(err, ErrTooManyReadErrors) {
+		t.Errorf("Expected ErrTooManyReadErrors on first failure, got %v", err)
+	}
+}
+
+func TestProcessReaderSkipsMalformedRecords(t *testing.T) {
+	processor := New()
+	processor.SetMaxReadErrors(0)
+
+	content := flakyCSV + "TXN005,\"unterminated\n"
+	if err := processor.ProcessReader(strings.NewReader(content)); err != nil {
+		t.Fatalf("Expected malformed records not to count as read errors, got %v", err)
+	}
+	if processor.GetDashboardData().Report.ReadErrors != 0 {
+		t.Errorf("Expected no read errors, got %d", processor.GetDashboardData().Report.ReadErrors)
+	}
+}
+
+func TestProcessDatasetCountryMonthlySales(t *testing.T) {
+	path := writeTestFile(t, "trend.csv", `transaction_id,transaction_date,country,region,product_name,quantity,total_price
+TXN001,2024-03-15,USA,North America,Laptop,2,2000
+TXN002,2023-12-16,USA,North America,Phone,5,2500
+TXN003,2024-01-17,USA,North America,Laptop,4,4000
+TXN004,2024-03-18,USA,North America,Tablet,1,300
+TXN005,2024-01-19,UK,Europe,Tablet,3,900
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	series, ok := processor.GetCountryMonthlySales("USA")
+	if !ok {
+		t.Fatal("Expected USA to be retained")
+	}
+
+	expected := []struct {
+		month string
+		year  int
+		sales float64
+	}{
+		{"December", 2023, 2500},
+		{"January", 2024, 4000},
+		{"March", 2024, 2300},
+	}
+	if len(series) != len(expected) {
+		t.Fatalf("Expected %d months, got %d", len(expected), len(series))
+	}
+	for i, e := range expected {
+		if series[i].Month != e.month || series[i].Year != e.year || series[i].TotalSales != e.sales {
+			t.Errorf("Expected month %d to be %s %d with %f, got %+v", i, e.month, e.year, e.sales, series[i])
+		}
+	}
+
+	if _, ok := processor.GetCountryMonthlySales("Atlantis"); ok {
+		t.Error("Expected unknown country to be reported as missing")
+	}
+}
+
+func TestSortCountryMonthlySalesRetainsTopCountries(t *testing.T) {
+	processor := New()
+
+	countryMonthMap := map[string]map[string]*models.MonthlySales{
+		"USA":    {"2024-01": {Month: "January", Year: 2024, TotalSales: 500}},
+		"UK":     {"2024-01": {Month: "January", Year: 2024, TotalSales: 300}},
+		"France": {"2024-01": {Month: "January", Year: 2024, TotalSales: 100}, "2024-02": {Month: "February", Year: 2024, TotalSales: 350}},
+	}
+
+	result := processor.sortCountryMonthlySales(countryMonthMap, 2)
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 retained countries, got %d", len(result))
+	}
+	if _, ok := result["USA"]; !ok {
+		t.Error("Expected USA to be retained")
+	}
+	if _, ok := result["France"]; !ok {
+		t.Error("Expected France to be retained")
+	}
+	if _, ok := result["UK"]; ok {
+		t.Error("Expected UK to be dropped")
+	}
+}
+
+func TestDashboardJSONFieldPresence(t *testing.T) {
+	encode := func(p *Processor) map[string]interface{} {
+		jsonData, err := json.Marshal(p.GetDashboardData())
+		if err != nil {
+			t.Fatalf("Failed to marshal dashboard data: %v", err)
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(jsonData, &decoded); err != nil {
+			t.Fatalf("Failed to unmarshal dashboard data: %v", err)
+		}
+		return decoded
+	}
+
+	fresh := encode(New())
+
+	populated := New()
+	populated.LoadSampleData()
+	loaded := encode(populated)
+
+	if len(fresh) != len(loaded) {
+		t.Errorf("Expected the same fields for fresh and populated data, got %d and %d", len(fresh), len(loaded))
+	}
+	for key, value := range loaded {
+		freshValue, exists := fresh[key]
+		if !exists {
+			t.Errorf("Expected fresh dashboard to include '%s'", key)
+			continue
+		}
+		if _, isList := value.([]interface{}); isList {
+			if _, ok := freshValue.([]interface{}); !ok {
+				t.Errorf("Expected fresh '%s' to be [], got %v", key, freshValue)
+			}
+		}
+	}
+
+	if fresh["last_updated"] != nil {
+		t.Errorf("Expected fresh last_updated to be null, got %v", fresh["last_updated"])
+	}
+	if loaded["last_updated"] == nil {
+		t.Error("Expected populated last_updated to be set")
+	}
+}
+
+func TestProcessDatasetDataDateRange(t *testing.T) {
+	path := writeTestFile(t, "range.csv", `transaction_id,transaction_date,country,product_name,quantity,total_price
+TXN001,2024-03-10 14:00:00,USA,Laptop,1,1000
+TXN002,,USA,Mouse,2,50
+TXN003,2023-11-02 08:30:00,Canada,Laptop,1,1000
+TXN004,not a date,Canada,Mouse,1,25
+TXN005,2024-05-31 23:59:59,USA,Monitor,1,300
+TXN006,2024-01-15,Canada,Monitor,1,300
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data := processor.GetDashboardData()
+	expectedStart := time.Date(2023, 11, 2, 8, 30, 0, 0, time.UTC)
+	expectedEnd := time.Date(2024, 5, 31, 23, 59, 59, 0, time.UTC)
+	if !data.DataStartDate.Equal(expectedStart) {
+		t.Errorf("Expected DataStartDate %v, got %v", expectedStart, data.DataStartDate)
+	}
+	if !data.DataEndDate.Equal(expectedEnd) {
+		t.Errorf("Expected DataEndDate %v, got %v", expectedEnd, data.DataEndDate)
+	}
+}
+
+func TestProcessDatasetDataDateRangeWithoutDates(t *testing.T) {
+	path := writeTestFile(t, "undated.csv", "transaction_id,transaction_date,product_name,quantity,total_price\nTXN001,,Laptop,1,1000\n")
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data := processor.GetDashboardData()
+	if !data.DataStartDate.IsZero() || !data.DataEndDate.IsZero() {
+		t.Errorf("Expected zero date range, got %v to %v", data.DataStartDate, data.DataEndDate)
+	}
+}
+
+// waitForProcessing waits until a processing run holds p
+func waitForProcessing(t *testing.T, p *Processor) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !p.processing.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for processing to start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConcurrentProcessingRejected(t *testing.T) {
+	processor := New()
+	header := "product_name,quantity,total_price,country\n"
+
+	// Both loads block on their pipes, so whichever starts first is still
+	// running when the other one is made
+	readers := make([]*io.PipeReader, 2)
+	writers := make([]*io.PipeWriter, 2)
+	results := make(chan error, 2)
+	for i := range readers {
+		readers[i], writers[i] = io.Pipe()
+		go func(r io.Reader) {
+			results <- processor.ProcessReader(r)
+		}(readers[i])
+	}
+
+	// The rejected load returns at once; then release the running one
+	first := <-results
+	if !errors.Is(first, ErrProcessingInProgress) {
+		t.Fatalf("Expected ErrProcessingInProgress, got %v", first)
+	}
+	for _, w := range writers {
+		go func(w *io.PipeWriter) {
+			io.WriteString(w, header+"Laptop,1,100,USA\n")
+			w.Close()
+		}(w)
+	}
+	if second := <-results; second != nil {
+		t.Fatalf("Expected the running load to succeed, got %v", second)
+	}
+	if history := processor.GetProcessingHistory(); len(history) != 1 {
+		t.Errorf("Expected only the successful run in the history, got %+v", history)
+	}
+
+	// Staging and reloading are rejected while a load runs
+	blocked, blocker := io.Pipe()
+	go func() { results <- processor.ProcessReader(blocked) }()
+	waitForProcessing(t, processor)
+	path := writeTestFile(t, "sales.csv", header+"Camera,1,50,Japan\n")
+	if _, err := processor.StageDataset(path); !errors.Is(err, ErrProcessingInProgress) {
+		t.Errorf("Expected staging to be rejected, got %v", err)
+	}
+	if err := processor.Reload(path); !errors.Is(err, ErrProcessingInProgress) {
+		t.Errorf("Expected the reload to be rejected, got %v", err)
+	}
+	if err := processor.LoadSampleData(); !errors.Is(err, ErrProcessingInProgress) {
+		t.Errorf("Expected loading sample data to be rejected, got %v", err)
+	}
+	if history := processor.GetProcessingHistory(); len(history) != 1 {
+		t.Errorf("Expected rejected runs to stay out of the history, got %+v", history)
+	}
+
+	// A failed run releases the processor too
+	io.WriteString(blocker, "no,required,columns\n")
+	blocker.Close()
+	if err := <-results; !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("Expected ErrInvalidHeader, got %v", err)
+	}
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Errorf("Expected a load after the failure to succeed, got %v", err)
+	}
+	if _, err := processor.StageDataset(path); err != nil {
+		t.Errorf("Expected staging after the loads to succeed, got %v", err)
+	}
+}
+
+func TestLoadEmptyData(t *testing.T) {
+	processor := New()
+	processor.LoadSampleData()
+	processor.LoadEmptyData()
+
+	data := processor.GetDashboardData()
+	if data.DataSource != SourceEmpty {
+		t.Errorf("Expected data source %q, got %q", SourceEmpty, data.DataSource)
+	}
+	if data.CountryRevenues == nil || len(data.CountryRevenues) != 0 || len(data.TopProducts) != 0 || len(data.MonthlySales) != 0 {
+		t.Errorf("Expected empty aggregates, got %d country rows, %d products and %d months",
+			len(data.CountryRevenues), len(data.TopProducts), len(data.MonthlySales))
+	}
+	if data.LastUpdated.IsZero() {
+		t.Error("Expected LastUpdated to be set after loading empty data")
+	}
+	if history := processor.GetProcessingHistory(); len(history) != 2 || history[0].Source != SourceEmpty {
+		t.Errorf("Expected the empty load to be recorded after the sample load, got %+v", history)
+	}
+}
@@ -11,11 +11,11 @@ func createMockProcessor() *Processor {
 	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
 
 	processor := New()
-	processor.dashboardData = &models.DashboardData{
+	processor.data.Store(&models.DashboardData{
 		LastUpdated:        now,
 		ProcessingDuration: 5 * time.Second,
 		RecordCount:        1000,
-	}
+	})
 
 	return processor
 }
@@ -243,7 +243,7 @@ func TestSortTopProductsWithMockData(t *testing.T) {
 		"product4": {ProductName: "Monitor", PurchaseCount: 400, CurrentStock: 100},
 	}
 
-	sorted := processor.sortTopProducts(productMap, 3)
+	sorted := processor.sortTopProducts(productMap, 3, models.RankByOrders)
 
 	if len(sorted) != 3 {
 		t.Errorf("Expected 3 sorted items (limit), got %d", len(sorted))
@@ -327,7 +327,7 @@ func TestSortTopRegionsWithMockData(t *testing.T) {
 		"region3": {Region: "Asia", TotalRevenue: 5000.0, ItemsSold: 500},
 	}
 
-	sorted := processor.sortTopRegions(regionMap, 2)
+	sorted := processor.sortTopRegions(regionMap, 2, nil)
 
 	if len(sorted) != 2 {
 		t.Errorf("Expected 2 sorted items (limit), got %d", len(sorted))
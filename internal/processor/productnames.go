@@ -0,0 +1,166 @@
+package processor
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+// Policies for normalizing product names
+const (
+	// ProductNamesOff keeps product names exactly as exported
+	ProductNamesOff = "off"
+	// ProductNamesTrim trims product names and collapses runs of
+	// whitespace inside them, merging names that differ only in spacing
+	ProductNamesTrim = "trim"
+	// ProductNamesFull also merges names that differ only in case, named
+	// after their most common spelling
+	ProductNamesFull = "full"
+)
+
+// productNameCacheSize bounds the folded product names each worker caches
+const productNameCacheSize = 10000
+
+// SetProductNameNormalization sets how product names are normalized while
+// parsing. An empty mode is treated as ProductNamesFull.
+func (p *Processor) SetProductNameNormalization(mode string) {
+	p.productNames = mode
+}
+
+// normalizeProductName sets the transaction's product name from its raw CSV
+// value. Unless normalization is off, the raw value is kept as DisplayName.
+func (p *Processor) normalizeProductName(transaction *models.Transaction, value string) {
+	if p.productNames == ProductNamesOff {
+		transaction.ProductName = value
+		return
+	}
+	name := strings.TrimSpace(value)
+	if !singleSpaced(name) {
+		name = collapseSpaces(name)
+	}
+	if name != "" {
+		transaction.ProductName = name
+		transaction.DisplayName = value
+	}
+}
+
+// singleSpaced reports whether name, already trimmed, is ASCII with words
+// separated by single spaces, so collapsing its whitespace is a no-op
+func singleSpaced(name string) bool {
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case c >= utf8.RuneSelf, c < ' ':
+			return false
+		case c == ' ' && name[i-1] == ' ':
+			return false
+		}
+	}
+	return true
+}
+
+// productNameFolder returns the function a worker applies to product names
+// before aggregating them: case folding in full mode, so that spellings
+// differing in case share one key until renameProducts picks the canonical
+// one, and nil otherwise. Folded names are cached, since most rows repeat a
+// product seen before. Each worker needs its own, as neither the Caser nor
+// the cache is safe for concurrent use.
+func (p *Processor) productNameFolder() func(string) string {
+	switch p.productNames {
+	case ProductNamesOff, ProductNamesTrim:
+		return nil
+	}
+	fold := cases.Fold()
+	folded := make(map[string]string)
+	return func(name string) string {
+		if key, ok := folded[name]; ok {
+			return key
+		}
+		key := fold.String(name)
+		if len(folded) < productNameCacheSize {
+			folded[name] = key
+		}
+		return key
+	}
+}
+
+// countProductSpelling counts a row's raw spelling of its normalized product
+// name, for products not folded into OtherBucket. Callers must hold agg.mu.
+func countProductSpelling(agg *aggregates, transaction models.Transaction) {
+	if transaction.DisplayName == "" {
+		return
+	}
+	spellings, exists := agg.productSpellings[transaction.ProductName]
+	if !exists {
+		spellings = make(map[string]int)
+		agg.productSpellings[transaction.ProductName] = spellings
+	}
+	spellings[transaction.DisplayName]++
+}
+
+// canonicalProductNames returns the product name of every case-folded name
+// counted in full mode: its most common spelling with whitespace collapsed,
+// ties going to the first in byte order. It also returns how many raw
+// spellings were merged into another one.
+func canonicalProductNames(spellings map[string]map[string]int, full bool) (map[string]string, int) {
+	names := make(map[string]string, len(spellings))
+	merged := 0
+	for key, counts := range spellings {
+		merged += len(counts) - 1
+		if !full {
+			continue
+		}
+
+		collapsed := make(map[string]int, len(counts))
+		for spelling, rows := range counts {
+			collapsed[collapseSpaces(spelling)] += rows
+		}
+		var name string
+		var nameRows int
+		for spelling, rows := range collapsed {
+			if rows > nameRows || rows == nameRows && spelling < name {
+				name, nameRows = spelling, rows
+			}
+		}
+		if name != key {
+			names[key] = name
+		}
+	}
+	return names, merged
+}
+
+// renameProducts replaces the case-folded product names of the aggregates
+// with their canonical spelling
+func renameProducts(agg *aggregates, names map[string]string) {
+	if len(names) == 0 {
+		return
+	}
+	rename := func(name *string) {
+		if canonical, ok := names[*name]; ok {
+			*name = canonical
+		}
+	}
+
+	for _, product := range agg.productMap {
+		rename(&product.ProductName)
+	}
+	for _, revenue := range agg.countryMap {
+		rename(&revenue.ProductName)
+	}
+	for _, pressure := range agg.stockPressureMap {
+		rename(&pressure.ProductName)
+	}
+	for _, products := range agg.regionProductMap {
+		for _, product := range products {
+			rename(&product.ProductName)
+		}
+	}
+	for _, products := range agg.categoryProductMap {
+		for _, product := range products {
+			rename(&product.ProductName)
+		}
+	}
+	agg.samples.renameProducts(rename)
+}
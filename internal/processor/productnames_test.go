@@ -0,0 +1,122 @@
+package processor
+
+import (
+	"testing"
+)
+
+const productVariantsCSV = `transaction_id,country,region,product_name,quantity,total_price
+TXN001,USA,North America,Wireless Headphones,1,100
+TXN002,USA,North America,wireless headphones ,2,200
+TXN003,Germany,Europe,Wireless  Headphones,1,100
+TXN004,Germany,Europe,WIRELESS HEADPHONES,1,100
+TXN005,USA,North America,Wireless Headphones,1,100
+TXN006,USA,North America,Mouse,1,20
+`
+
+func TestProductNameVariantsMerged(t *testing.T) {
+	path := writeTestFile(t, "variants.csv", productVariantsCSV)
+
+	processor := New()
+	processor.SetSampleTransactions(10)
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data := processor.GetDashboardData()
+
+	if len(data.TopProducts) != 2 {
+		t.Fatalf("Expected 2 products after merging, got %+v", data.TopProducts)
+	}
+	headphones := data.TopProducts[0]
+	if headphones.ProductName != "Wireless Headphones" || headphones.PurchaseCount != 5 || headphones.UnitsSold != 6 {
+		t.Errorf("Expected Wireless Headphones with 5 purchases and 6 units, got %+v", headphones)
+	}
+	// Four raw spellings were merged into the most common one
+	if data.Report.MergedProductNames != 3 {
+		t.Errorf("Expected 3 merged product name spellings, got %d", data.Report.MergedProductNames)
+	}
+
+	for _, revenue := range data.CountryRevenues {
+		if revenue.ProductName != "Wireless Headphones" && revenue.ProductName != "Mouse" {
+			t.Errorf("Expected canonical product names in country revenue, got %q", revenue.ProductName)
+		}
+	}
+	if products := data.RegionProducts["Europe"]; len(products) != 1 || products[0].ProductName != "Wireless Headphones" || products[0].QuantitySold != 2 {
+		t.Errorf("Expected one merged product in Europe, got %+v", products)
+	}
+
+	for _, transaction := range data.SampleTransactions {
+		if transaction.TransactionID == "TXN002" {
+			if transaction.ProductName != "Wireless Headphones" || transaction.DisplayName != "wireless headphones " {
+				t.Errorf("Expected the canonical name with the original spelling as display name, got %q and %q",
+					transaction.ProductName, transaction.DisplayName)
+			}
+		}
+	}
+}
+
+func TestProductNameNormalizationModes(t *testing.T) {
+	testCases := []struct {
+		mode     string
+		products int
+		merged   int
+	}{
+		// Every distinct raw spelling is its own product
+		{ProductNamesOff, 5, 0},
+		// "Wireless  Headphones" joins "Wireless Headphones"; case still differs
+		{ProductNamesTrim, 4, 1},
+		{ProductNamesFull, 2, 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.mode, func(t *testing.T) {
+			path := writeTestFile(t, "variants.csv", productVariantsCSV)
+			processor := New()
+			processor.SetProductNameNormalization(tc.mode)
+			if err := processor.ProcessDataset(path); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			data := processor.GetDashboardData()
+			if len(data.TopProducts) != tc.products {
+				t.Errorf("Expected %d products, got %d", tc.products, len(data.TopProducts))
+			}
+			if data.Report.MergedProductNames != tc.merged {
+				t.Errorf("Expected %d merged spellings, got %d", tc.merged, data.Report.MergedProductNames)
+			}
+		})
+	}
+}
+
+func TestCanonicalProductNamesTies(t *testing.T) {
+	names, merged := canonicalProductNames(map[string]map[string]int{
+		"ssd": {"SSD": 2, "ssd": 2, " SSD": 1},
+	}, true)
+	if names["ssd"] != "SSD" {
+		t.Errorf("Expected SSD to win with 3 rows once whitespace is collapsed, got %q", names["ssd"])
+	}
+	if merged != 2 {
+		t.Errorf("Expected 2 merged spellings, got %d", merged)
+	}
+
+	names, _ = canonicalProductNames(map[string]map[string]int{
+		"tablet": {"Tablet": 1, "TABLET": 1},
+	}, true)
+	if names["tablet"] != "TABLET" {
+		t.Errorf("Expected ties to go to the first spelling in byte order, got %q", names["tablet"])
+	}
+}
+
+func TestSingleSpaced(t *testing.T) {
+	testCases := map[string]bool{
+		"Wireless Headphones":  true,
+		"SSD":                  true,
+		"":                     true,
+		"Wireless  Headphones": false,
+		"Wireless\tHeadphones": false,
+		"Café Grinder":         false,
+	}
+	for name, want := range testCases {
+		if got := singleSpaced(name); got != want {
+			t.Errorf("singleSpaced(%q): expected %v, got %v", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"context"
+	"sort"
+	"strings"
+)
+
+// cancelCheckInterval is how many rows on-demand queries and searches
+// scan between checks of their context
+const cancelCheckInterval = 1024
+
+// Sort fields accepted by QueryCountryRevenues
+const (
+	SortByTotalRevenue     = "total_revenue"
+	SortByTransactionCount = "transaction_count"
+	SortByCountry          = "country"
+	SortByProductName      = "product_name"
+)
+
+// CountryRevenueSortFields lists the valid CountryRevenueQuery.SortBy values
+var CountryRevenueSortFields = []string{SortByTotalRevenue, SortByTransactionCount, SortByCountry, SortByProductName}
+
+// QueryCountryRevenues filters, sorts and pages the country revenue rows.
+// It returns the requested page, the total number of matching rows and how
+// many rows passing the other filters were excluded by the average order
+// value bounds. Country and product filters match case-insensitively.
+func (p *Processor) QueryCountryRevenues(query models.CountryRevenueQuery) ([]models.CountryRevenue, int, int) {
+	rows, total, avgOrderFiltered, _ := p.QueryCountryRevenuesContext(context.Background(), query)
+	return rows, total, avgOrderFiltered
+}
+
+// QueryCountryRevenuesContext is QueryCountryRevenues stopping early with
+// ctx's error once ctx is done, such as when the client of a request has
+// gone away
+func (p *Processor) QueryCountryRevenuesContext(ctx context.Context, query models.CountryRevenueQuery) ([]models.CountryRevenue, int, int, error) {
+	matches, avgOrderFiltered, err := filterCountryRevenues(ctx, p.data.Load(), query)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	sortCountryRevenueRows(matches, query.SortBy, query.Order)
+	return pageOf(matches, query.Page, query.PageSize), len(matches), avgOrderFiltered, nil
+}
+
+// QueryCountryRevenueGroups nests the country revenue rows matching the
+// filters of query by country, highest country total first, with each
+// country's products by revenue. Pages count countries; SortBy and Order
+// are not applied. It returns the requested page, the total number of
+// countries and the rows excluded by the average order value bounds.
+func (p *Processor) QueryCountryRevenueGroups(query models.CountryRevenueQuery) ([]models.CountryRevenueGroup, int, int) {
+	groups, total, avgOrderFiltered, _ := p.QueryCountryRevenueGroupsContext(context.Background(), query)
+	return groups, total, avgOrderFiltered
+}
+
+// QueryCountryRevenueGroupsContext is QueryCountryRevenueGroups stopping
+// early with ctx's error once ctx is done
+func (p *Processor) QueryCountryRevenueGroupsContext(ctx context.Context, query models.CountryRevenueQuery) ([]models.CountryRevenueGroup, int, int, error) {
+	data := p.data.Load()
+	matches, avgOrderFiltered, err := filterCountryRevenues(ctx, data, query)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	groups, err := nestCountryRevenues(ctx, matches, data.CurrencyOrder)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return pageOf(groups, query.Page, query.PageSize), len(groups), avgOrderFiltered, nil
+}
+
+// filterCountryRevenues returns the country revenue rows of data passing
+// the filters of query and how many were excluded by the average order
+// bounds, or ctx's error once ctx is done
+func filterCountryRevenues(ctx context.Context, data *models.DashboardData, query models.CountryRevenueQuery) ([]models.CountryRevenue, int, error) {
+	source := data.CountryRevenues
+
+	countries := toLowerSet(query.Countries)
+	products := toLowerSet(query.Products)
+
+	// With a country or product filter only the indexed rows are read;
+	// they already pass both filters
+	offsets, indexed := indexedRows(data.RevenueIndex, countries, products)
+	candidates := len(source)
+	if indexed {
+		candidates = len(offsets)
+	}
+
+	avgOrderFiltered := 0
+	matches := make([]models.CountryRevenue, 0, candidates)
+	for i := 0; i < candidates; i++ {
+		if i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, 0, err
+			}
+		}
+		var revenue models.CountryRevenue
+		if indexed {
+			revenue = source[offsets[i]]
+		} else {
+			revenue = source[i]
+			if len(countries) > 0 {
+				if _, ok := countries[strings.ToLower(revenue.Country)]; !ok {
+					continue
+				}
+			}
+			if len(products) > 0 {
+				if _, ok := products[strings.ToLower(revenue.ProductName)]; !ok {
+					continue
+				}
+			}
+		}
+		if revenue.TotalRevenue < query.MinRevenue {
+			continue
+		}
+		if !inAvgOrderBounds(revenue.AverageOrderValue, query.MinAvgOrder, query.MaxAvgOrder) {
+			avgOrderFiltered++
+			continue
+		}
+		matches = append(matches, revenue)
+	}
+	return matches, avgOrderFiltered, nil
+}
+
+// nestCountryRevenues groups rows by country, adding up each product's
+// rows. Revenue is also kept per currency; when currencies, the order
+// amounts were aggregated in, holds more than one, revenue in different
+// currencies is never added together: the totals are left at zero and
+// countries and products are ordered by their revenue in each currency in
+// turn instead. Otherwise countries are ordered by total revenue and
+// products by revenue. Ties go by name. It stops with ctx's error once ctx
+// is done.
+func nestCountryRevenues(ctx context.Context, rows []models.CountryRevenue, currencies []string) ([]models.CountryRevenueGroup, error) {
+	mixed := len(currencies) > 1
+	products := make(map[string]map[string]*models.CountryProduct)
+	for i, row := range rows {
+		if i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		countryProducts, exists := products[row.Country]
+		if !exists {
+			countryProducts = make(map[string]*models.CountryProduct)
+			products[row.Country] = countryProducts
+		}
+		product, exists := countryProducts[row.ProductName]
+		if !exists {
+			product = &models.CountryProduct{ProductName: row.ProductName}
+			countryProducts[row.ProductName] = product
+		}
+		if !mixed {
+			product.TotalRevenue += row.TotalRevenue
+		}
+		addCurrencyAmount(&product.RevenueByCurrency, row.Currency, row.TotalRevenue)
+		product.TransactionCount += row.TransactionCount
+		product.ItemsSold += row.ItemsSold
+	}
+
+	groups := make([]models.CountryRevenueGroup, 0, len(products))
+	for country, countryProducts := range products {
+		group := models.CountryRevenueGroup{
+			Country:  country,
+			Products: make([]models.CountryProduct, 0, len(countryProducts)),
+		}
+		for _, product := range countryProducts {
+			group.Products = append(group.Products, *product)
+		}
+		sort.Slice(group.Products, func(i, j int) bool {
+			a, b := group.Products[i], group.Products[j]
+			if a.TotalRevenue != b.TotalRevenue {
+				return a.TotalRevenue > b.TotalRevenue
+			}
+			if c := compareByCurrency(a.RevenueByCurrency, b.RevenueByCurrency, currencies); c != 0 {
+				return c > 0
+			}
+			return a.ProductName < b.ProductName
+		})
+		for _, product := range group.Products {
+			group.TotalRevenue += product.TotalRevenue
+			for currency, revenue := range product.RevenueByCurrency {
+				addCurrencyAmount(&group.RevenueByCurrency, currency, revenue)
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].TotalRevenue != groups[j].TotalRevenue {
+			return groups[i].TotalRevenue > groups[j].TotalRevenue
+		}
+		if c := compareByCurrency(groups[i].RevenueByCurrency, groups[j].RevenueByCurrency, currencies); c != 0 {
+			return c > 0
+		}
+		return groups[i].Country < groups[j].Country
+	})
+	return groups, nil
+}
+
+// pageOf returns the given 1-based page of items, every item when pageSize
+// is zero and an empty page past the last one
+func pageOf[T any](items []T, page, pageSize int) []T {
+	if pageSize <= 0 {
+		return items
+	}
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * pageSize
+	if start >= len(items) {
+		return make([]T, 0)
+	}
+	end := start + pageSize
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end]
+}
+
+// inAvgOrderBounds reports whether value lies within the inclusive bounds;
+// a nil bound is not applied
+func inAvgOrderBounds(value float64, min, max *float64) bool {
+	if min != nil && value < *min {
+		return false
+	}
+	if max != nil && value > *max {
+		return false
+	}
+	return true
+}
+
+// sortCountryRevenueRows orders rows by the given field. Numeric fields
+// default to descending order and text fields to ascending order.
+func sortCountryRevenueRows(rows []models.CountryRevenue, sortBy, order string) {
+	if sortBy == "" {
+		sortBy = SortByTotalRevenue
+	}
+
+	var less func(a, b models.CountryRevenue) bool
+	descending := true
+	switch sortBy {
+	case SortByTransactionCount:
+		less = func(a, b models.CountryRevenue) bool { return a.TransactionCount < b.TransactionCount }
+	case SortByCountry:
+		less = func(a, b models.CountryRevenue) bool { return a.Country < b.Country }
+		descending = false
+	case SortByProductName:
+		less = func(a, b models.CountryRevenue) bool { return a.ProductName < b.ProductName }
+		descending = false
+	default:
+		less = func(a, b models.CountryRevenue) bool { return a.TotalRevenue < b.TotalRevenue }
+	}
+
+	switch order {
+	case "asc":
+		descending = false
+	case "desc":
+		descending = true
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if descending {
+			return less(rows[j], rows[i])
+		}
+		return less(rows[i], rows[j])
+	})
+}
+
+// toLowerSet builds a lookup set of lower-cased values
+func toLowerSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		set[strings.ToLower(strings.TrimSpace(value))] = struct{}{}
+	}
+	return set
+}
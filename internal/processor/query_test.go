@@ -0,0 +1,221 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func createQueryProcessor() *Processor {
+	processor := New()
+	processor.GetDashboardData().CountryRevenues = []models.CountryRevenue{
+		{Country: "USA", ProductName: "Laptop", TotalRevenue: 500, TransactionCount: 5},
+		{Country: "UK", ProductName: "Phone", TotalRevenue: 400, TransactionCount: 9},
+		{Country: "USA", ProductName: "Phone", TotalRevenue: 300, TransactionCount: 3},
+		{Country: "Germany", ProductName: "Laptop", TotalRevenue: 200, TransactionCount: 7},
+		{Country: "UK", ProductName: "Tablet", TotalRevenue: 100, TransactionCount: 1},
+	}
+	for i, revenue := range processor.GetDashboardData().CountryRevenues {
+		processor.GetDashboardData().CountryRevenues[i] = withAverageOrderValue(revenue)
+	}
+	return processor
+}
+
+func TestQueryCountryRevenuesFilters(t *testing.T) {
+	processor := createQueryProcessor()
+
+	rows, total, _ := processor.QueryCountryRevenues(models.CountryRevenueQuery{
+		Countries:  []string{"usa", "UK"},
+		Products:   []string{"phone"},
+		MinRevenue: 350,
+	})
+
+	if total != 1 || len(rows) != 1 {
+		t.Fatalf("Expected 1 matching row, got %d (total %d)", len(rows), total)
+	}
+	if rows[0].Country != "UK" || rows[0].ProductName != "Phone" {
+		t.Errorf("Expected UK Phone, got %+v", rows[0])
+	}
+}
+
+func TestQueryCountryRevenuesSorting(t *testing.T) {
+	processor := createQueryProcessor()
+
+	testCases := []struct {
+		sortBy   string
+		order    string
+		expected []float64
+	}{
+		{"", "", []float64{500, 400, 300, 200, 100}},
+		{SortByTotalRevenue, "asc", []float64{100, 200, 300, 400, 500}},
+		{SortByTransactionCount, "", []float64{400, 200, 500, 300, 100}},
+		{SortByCountry, "", []float64{200, 400, 100, 500, 300}},
+		{SortByProductName, "desc", []float64{100, 400, 300, 500, 200}},
+	}
+
+	for _, tc := range testCases {
+		rows, _, _ := processor.QueryCountryRevenues(models.CountryRevenueQuery{SortBy: tc.sortBy, Order: tc.order})
+		for i, revenue := range tc.expected {
+			if rows[i].TotalRevenue != revenue {
+				t.Errorf("sort_by=%s order=%s: expected row %d revenue %f, got %f", tc.sortBy, tc.order, i, revenue, rows[i].TotalRevenue)
+			}
+		}
+	}
+}
+
+func TestQueryCountryRevenuesPaging(t *testing.T) {
+	processor := createQueryProcessor()
+
+	rows, total, _ := processor.QueryCountryRevenues(models.CountryRevenueQuery{Page: 2, PageSize: 2})
+	if total != 5 {
+		t.Errorf("Expected total 5, got %d", total)
+	}
+	if len(rows) != 2 || rows[0].TotalRevenue != 300 {
+		t.Errorf("Expected second page starting at 300, got %+v", rows)
+	}
+
+	rows, _, _ = processor.QueryCountryRevenues(models.CountryRevenueQuery{Page: 3, PageSize: 2})
+	if len(rows) != 1 {
+		t.Errorf("Expected 1 row on the last page, got %d", len(rows))
+	}
+
+	rows, total, _ = processor.QueryCountryRevenues(models.CountryRevenueQuery{Page: 9, PageSize: 2})
+	if len(rows) != 0 || total != 5 {
+		t.Errorf("Expected empty page past the end with total 5, got %d rows (total %d)", len(rows), total)
+	}
+}
+
+func TestQueryCountryRevenuesAvgOrderBounds(t *testing.T) {
+	processor := createQueryProcessor()
+	bound := func(v float64) *float64 { return &v }
+
+	// Average order values: USA Laptop 100, UK Phone 44.4, USA Phone 100,
+	// Germany Laptop 28.6, UK Tablet 100
+	testCases := []struct {
+		name     string
+		query    models.CountryRevenueQuery
+		total    int
+		filtered int
+	}{
+		{"inclusive bounds", models.CountryRevenueQuery{MinAvgOrder: bound(100), MaxAvgOrder: bound(100)}, 3, 2},
+		{"min only", models.CountryRevenueQuery{MinAvgOrder: bound(44)}, 4, 1},
+		{"max only", models.CountryRevenueQuery{MaxAvgOrder: bound(44)}, 1, 4},
+		{"just above a value", models.CountryRevenueQuery{MinAvgOrder: bound(100.01)}, 0, 5},
+		{"with country filter", models.CountryRevenueQuery{Countries: []string{"uk"}, MinAvgOrder: bound(50)}, 1, 1},
+		{"no bounds", models.CountryRevenueQuery{}, 5, 0},
+	}
+
+	for _, tc := range testCases {
+		rows, total, filtered := processor.QueryCountryRevenues(tc.query)
+		if total != tc.total || len(rows) != tc.total || filtered != tc.filtered {
+			t.Errorf("%s: expected %d rows with %d filtered, got %d rows (total %d) with %d filtered",
+				tc.name, tc.total, tc.filtered, len(rows), total, filtered)
+		}
+	}
+
+	rows, total, filtered := processor.QueryCountryRevenues(models.CountryRevenueQuery{MinAvgOrder: bound(100), Page: 2, PageSize: 2})
+	if total != 3 || filtered != 2 || len(rows) != 1 {
+		t.Errorf("Expected the last of 3 filtered rows on page 2, got %d rows (total %d, filtered %d)", len(rows), total, filtered)
+	}
+}
+
+func TestQueryCountryRevenueGroups(t *testing.T) {
+	processor := createQueryProcessor()
+	// A second row of the same product is merged into one product
+	data := processor.GetDashboardData()
+	data.CountryRevenues = append(data.CountryRevenues,
+		withAverageOrderValue(models.CountryRevenue{Country: "USA", ProductName: "Laptop", TotalRevenue: 50, TransactionCount: 1}))
+
+	groups, total, _ := processor.QueryCountryRevenueGroups(models.CountryRevenueQuery{})
+	if total != 3 || len(groups) != 3 {
+		t.Fatalf("Expected 3 countries, got %d (total %d)", len(groups), total)
+	}
+	for i, country := range []string{"USA", "UK", "Germany"} {
+		if groups[i].Country != country {
+			t.Errorf("Expected %s at %d, got %s", country, i, groups[i].Country)
+		}
+	}
+
+	for _, group := range groups {
+		sum := 0.0
+		for _, product := range group.Products {
+			sum += product.TotalRevenue
+		}
+		if group.TotalRevenue != sum {
+			t.Errorf("Expected %s total %v to equal the sum of its products %v", group.Country, group.TotalRevenue, sum)
+		}
+	}
+	usa := groups[0]
+	if len(usa.Products) != 2 || usa.Products[0].ProductName != "Laptop" || usa.Products[0].TotalRevenue != 550 || usa.Products[0].TransactionCount != 6 {
+		t.Errorf("Expected the USA laptops summed first, got %+v", usa.Products)
+	}
+
+	// Filters apply to the rows and pages count countries
+	groups, total, _ = processor.QueryCountryRevenueGroups(models.CountryRevenueQuery{Products: []string{"phone"}, Page: 2, PageSize: 1})
+	if total != 2 || len(groups) != 1 || groups[0].Country != "USA" || groups[0].TotalRevenue != 300 {
+		t.Errorf("Expected the second of 2 phone countries to be USA with 300, got %+v (total %d)", groups, total)
+	}
+}
+
+func TestQueryCountryRevenueGroupsMixedCurrencies(t *testing.T) {
+	processor := New()
+	data := processor.GetDashboardData()
+	data.CurrencyOrder = []string{"USD", "JPY"}
+	data.CountryRevenues = []models.CountryRevenue{
+		{Country: "Japan", ProductName: "Camera", Currency: "USD", TotalRevenue: 100, TransactionCount: 1},
+		{Country: "Japan", ProductName: "Camera", Currency: "JPY", TotalRevenue: 10000, TransactionCount: 1},
+		{Country: "Japan", ProductName: "Lens", Currency: "USD", TotalRevenue: 150, TransactionCount: 1},
+		{Country: "Korea", ProductName: "Phone", Currency: "JPY", TotalRevenue: 90000, TransactionCount: 1},
+		{Country: "USA", ProductName: "Laptop", Currency: "USD", TotalRevenue: 200, TransactionCount: 1},
+	}
+
+	groups, total, _ := processor.QueryCountryRevenueGroups(models.CountryRevenueQuery{})
+	if total != 3 || len(groups) != 3 {
+		t.Fatalf("Expected 3 countries, got %d (total %d)", len(groups), total)
+	}
+	// Compared in USD first, as it has the most rows, then in JPY
+	for i, country := range []string{"Japan", "USA", "Korea"} {
+		if groups[i].Country != country {
+			t.Errorf("Expected %s at %d, got %s", country, i, groups[i].Country)
+		}
+	}
+	for _, group := range groups {
+		if group.TotalRevenue != 0 {
+			t.Errorf("Expected no cross-currency total for %s, got %v", group.Country, group.TotalRevenue)
+		}
+		for _, product := range group.Products {
+			if product.TotalRevenue != 0 {
+				t.Errorf("Expected no cross-currency total for %s, got %v", product.ProductName, product.TotalRevenue)
+			}
+		}
+	}
+
+	japan := groups[0]
+	if want := map[string]float64{"USD": 250, "JPY": 10000}; !reflect.DeepEqual(japan.RevenueByCurrency, want) {
+		t.Errorf("Expected Japan's revenue per currency %v, got %v", want, japan.RevenueByCurrency)
+	}
+	if len(japan.Products) != 2 || japan.Products[0].ProductName != "Lens" ||
+		!reflect.DeepEqual(japan.Products[1].RevenueByCurrency, map[string]float64{"USD": 100, "JPY": 10000}) {
+		t.Errorf("Expected Lens then Camera with its revenue per currency, got %+v", japan.Products)
+	}
+}
+
+func TestQueryCountryRevenuesContextCanceled(t *testing.T) {
+	processor := createQueryProcessor()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if rows, _, _, err := processor.QueryCountryRevenuesContext(ctx, models.CountryRevenueQuery{}); !errors.Is(err, context.Canceled) || rows != nil {
+		t.Errorf("Expected no rows and context.Canceled, got %d rows and %v", len(rows), err)
+	}
+	if groups, _, _, err := processor.QueryCountryRevenueGroupsContext(ctx, models.CountryRevenueQuery{}); !errors.Is(err, context.Canceled) || groups != nil {
+		t.Errorf("Expected no groups and context.Canceled, got %d groups and %v", len(groups), err)
+	}
+
+	rows, total, _, err := processor.QueryCountryRevenuesContext(context.Background(), models.CountryRevenueQuery{})
+	if err != nil || total != 5 || len(rows) != 5 {
+		t.Errorf("Expected all 5 rows with a live context, got %d of %d and %v", len(rows), total, err)
+	}
+}
@@ -0,0 +1,28 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"errors"
+	"time"
+)
+
+// Reload reprocesses the dataset at path, such as after the files were
+// replaced on disk. The served data is only replaced when processing
+// succeeds; a failed reload is recorded in the processing history with its
+// error and the previous data stays in place. A reload rejected with
+// ErrProcessingInProgress is not recorded, as the running load will be.
+func (p *Processor) Reload(path string) error {
+	start := time.Now()
+
+	err := p.ProcessDataset(path)
+	if err != nil && !errors.Is(err, ErrProcessingInProgress) {
+		p.recordRun(models.ProcessingRun{
+			Source:    SourceDataset,
+			Path:      path,
+			StartedAt: start,
+			Duration:  time.Since(start),
+			Error:     err.Error(),
+		})
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+package processor
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestReloadReplacesData(t *testing.T) {
+	path := writeTestFile(t, "reload.csv", "transaction_id,transaction_date,country,product_name,quantity,total_price\nTXN001,2024-01-15,USA,Laptop,1,1000\n")
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	content := "transaction_id,transaction_date,country,product_name,quantity,total_price\nTXN001,2024-01-15,USA,Laptop,1,1000\nTXN002,2024-01-16,Canada,Mouse,2,50\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to rewrite dataset: %v", err)
+	}
+	if err := processor.Reload(path); err != nil {
+		t.Fatalf("Expected reload to succeed, got %v", err)
+	}
+
+	if rows := processor.GetDashboardData().Report.Rows; rows != 2 {
+		t.Errorf("Expected 2 rows after reload, got %d", rows)
+	}
+	history := processor.GetProcessingHistory()
+	if len(history) != 2 || history[0].Records != 2 || history[0].Error != "" {
+		t.Errorf("Expected successful reload as newest history entry, got %+v", history)
+	}
+}
+
+func TestReloadFailureKeepsData(t *testing.T) {
+	path := writeTestFile(t, "reload.csv", "transaction_id,transaction_date,country,product_name,quantity,total_price\nTXN001,2024-01-15,USA,Laptop,1,1000\n")
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	before := processor.GetDashboardData()
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Failed to remove dataset: %v", err)
+	}
+	err := processor.Reload(path)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	if processor.GetDashboardData() != before {
+		t.Error("Expected the previous data to be kept after a failed reload")
+	}
+	history := processor.GetProcessingHistory()
+	if len(history) != 2 || history[0].Error == "" || history[0].Path != path {
+		t.Errorf("Expected failed reload recorded with its error, got %+v", history)
+	}
+	if history[1].Error != "" {
+		t.Errorf("Expected the initial load to have no error, got %q", history[1].Error)
+	}
+}
@@ -0,0 +1,101 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// RunLogCapacity is the number of log lines kept from the current or most
+// recent processing run; older lines are dropped first
+const RunLogCapacity = 2000
+
+// RunLogLine is a log line emitted during a processing run. Seq numbers
+// lines in the order they were logged and is never reused.
+type RunLogLine struct {
+	Seq     uint64    `json:"seq"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// runLog is a ring buffer of the log lines of the current or most recent
+// processing run. Readers wait on changed, which is closed and replaced
+// whenever a line is added or a run starts or ends.
+type runLog struct {
+	mu      sync.Mutex
+	lines   []RunLogLine
+	first   int
+	seq     uint64
+	running bool
+	changed chan struct{}
+}
+
+// begin clears the buffer for a new run
+func (l *runLog) begin() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = l.lines[:0]
+	l.first = 0
+	l.running = true
+	l.notify()
+}
+
+// end marks the run as finished
+func (l *runLog) end() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.running = false
+	l.notify()
+}
+
+func (l *runLog) add(message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.seq++
+	line := RunLogLine{Seq: l.seq, Time: time.Now(), Message: message}
+	if len(l.lines) < RunLogCapacity {
+		l.lines = append(l.lines, line)
+	} else {
+		l.lines[l.first] = line
+		l.first = (l.first + 1) % RunLogCapacity
+	}
+	l.notify()
+}
+
+// notify wakes the waiting readers. The caller holds mu.
+func (l *runLog) notify() {
+	if l.changed != nil {
+		close(l.changed)
+	}
+	l.changed = make(chan struct{})
+}
+
+// logf logs a processing message and records it in the run log
+func (p *Processor) logf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Print(message)
+	p.runLog.add(message)
+}
+
+// RunLog returns the lines of the current or most recent processing run
+// numbered after the given sequence number, oldest first, and whether the
+// run is still going. The returned channel is closed on the next change,
+// so callers can wait for more lines.
+func (p *Processor) RunLog(after uint64) ([]RunLogLine, bool, <-chan struct{}) {
+	l := &p.runLog
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.changed == nil {
+		l.changed = make(chan struct{})
+	}
+
+	var lines []RunLogLine
+	for i := range l.lines {
+		line := l.lines[(l.first+i)%len(l.lines)]
+		if line.Seq > after {
+			lines = append(lines, line)
+		}
+	}
+	return lines, l.running, l.changed
+}
@@ -0,0 +1,104 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunLogCapturesRun(t *testing.T) {
+	path := writeTestFile(t, "sales.csv", `transaction_id,product_name,quantity,total_price
+TXN001,Laptop,1,100
+`)
+
+	processor := New()
+	if lines, running, _ := processor.RunLog(0); len(lines) != 0 || running {
+		t.Fatalf("Expected an empty log before any run, got %v (running %v)", lines, running)
+	}
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	lines, running, _ := processor.RunLog(0)
+	if running {
+		t.Error("Expected the run to be finished")
+	}
+	if len(lines) == 0 || !strings.HasPrefix(lines[0].Message, "Starting") {
+		t.Fatalf("Expected the run's log lines, got %+v", lines)
+	}
+	if last := lines[len(lines)-1].Message; !strings.HasPrefix(last, "Data processing completed") {
+		t.Errorf("Expected the completion line last, got %q", last)
+	}
+
+	after, _, _ := processor.RunLog(lines[1].Seq)
+	if len(after) != len(lines)-2 || after[0].Seq != lines[2].Seq {
+		t.Errorf("Expected the lines after seq %d, got %+v", lines[1].Seq, after)
+	}
+
+	// A new run replaces the previous run's lines
+	if err := processor.ProcessDataset(path + ".missing"); err == nil {
+		t.Fatal("Expected an error for a missing file")
+	}
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, _, _ := processor.RunLog(0)
+	if len(second) != len(lines) || second[0].Seq <= lines[len(lines)-1].Seq {
+		t.Errorf("Expected only the second run's lines, got %+v", second)
+	}
+}
+
+func TestRunLogFailureLogged(t *testing.T) {
+	path := writeTestFile(t, "bad.csv", "transaction_id,quantity\nTXN001,1\n")
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err == nil {
+		t.Fatal("Expected an error for a missing column")
+	}
+	lines, _, _ := processor.RunLog(0)
+	if len(lines) == 0 || !strings.HasPrefix(lines[len(lines)-1].Message, "Data processing failed") {
+		t.Errorf("Expected the failure in the run log, got %+v", lines)
+	}
+}
+
+func TestRunLogBounded(t *testing.T) {
+	processor := New()
+	processor.runLog.begin()
+	for i := 0; i < RunLogCapacity+10; i++ {
+		processor.runLog.add("line")
+	}
+
+	lines, _, _ := processor.RunLog(0)
+	if len(lines) != RunLogCapacity {
+		t.Fatalf("Expected %d lines, got %d", RunLogCapacity, len(lines))
+	}
+	if lines[0].Seq != 11 || lines[len(lines)-1].Seq != RunLogCapacity+10 {
+		t.Errorf("Expected the newest lines in order, got seq %d to %d", lines[0].Seq, lines[len(lines)-1].Seq)
+	}
+}
+
+func TestRunLogNotifiesReaders(t *testing.T) {
+	processor := New()
+	_, _, changed := processor.RunLog(0)
+
+	processor.runLog.begin()
+	select {
+	case <-changed:
+	default:
+		t.Fatal("Expected readers to be notified when a run starts")
+	}
+
+	_, running, changed := processor.RunLog(0)
+	if !running {
+		t.Error("Expected the run to be going")
+	}
+	processor.logf("step %d", 1)
+	select {
+	case <-changed:
+	default:
+		t.Fatal("Expected readers to be notified of a new line")
+	}
+	lines, _, _ := processor.RunLog(0)
+	if len(lines) != 1 || lines[0].Message != "step 1" {
+		t.Errorf("Expected the logged line, got %+v", lines)
+	}
+}
@@ -2,16 +2,23 @@ package processor
 
 import (
 	"abt-analytics-dashboard/internal/models"
+	"fmt"
 	"math/rand"
 	"time"
 )
 
-// LoadSampleData generates sample data for development and testing
-func (p *Processor) LoadSampleData() {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+// LoadSampleData generates sample data for development and testing. Like a
+// dataset load, it fails with ErrProcessingInProgress while another run
+// holds the processor, so that run cannot replace the sample data with
+// older aggregates once it finishes.
+func (p *Processor) LoadSampleData() error {
+	if err := p.beginProcessing(); err != nil {
+		return err
+	}
+	defer p.endProcessing()
 
 	start := time.Now()
+	data := &models.DashboardData{DataSource: SourceSample}
 
 	// Sample countries and regions
 	countries := []string{"USA", "UK", "Germany", "France", "Japan", "Canada", "Australia", "Brazil", "India", "China"}
@@ -22,9 +29,18 @@ func (p *Processor) LoadSampleData() {
 		"Speakers", "Microphone", "Webcam", "Router", "Hard Drive",
 		"SSD", "Graphics Card", "Processor", "Memory", "Motherboard",
 	}
+	categories := map[string][]string{
+		"Audio":      {"Wireless Headphones", "Speakers", "Microphone"},
+		"Mobile":     {"Smartphone", "Tablet", "Smartwatch"},
+		"Computers":  {"Laptop", "Monitor", "Keyboard", "Mouse"},
+		"Components": {"Hard Drive", "SSD", "Graphics Card", "Processor", "Memory", "Motherboard"},
+		"Imaging":    {"Camera", "Webcam"},
+		"Gaming":     {"Gaming Console"},
+		"Networking": {"Router"},
+	}
 
 	// Generate sample country revenues
-	p.dashboardData.CountryRevenues = make([]models.CountryRevenue, 0)
+	data.CountryRevenues = make([]models.CountryRevenue, 0)
 	for _, country := range countries {
 		for i, product := range products {
 			if i > 5 && rand.Float32() > 0.7 { // Skip some combinations
@@ -33,51 +49,226 @@ func (p *Processor) LoadSampleData() {
 			revenue := models.CountryRevenue{
 				Country:          country,
 				ProductName:      product,
+				Currency:         "USD",
 				TotalRevenue:     rand.Float64()*50000 + 10000, // $10k-$60k
 				TransactionCount: rand.Intn(500) + 50,          // 50-550 transactions
 			}
-			p.dashboardData.CountryRevenues = append(p.dashboardData.CountryRevenues, revenue)
+			revenue.ItemsSold = revenue.TransactionCount + rand.Intn(revenue.TransactionCount*2)
+			data.CountryRevenues = append(data.CountryRevenues, withAverageOrderValue(revenue))
 		}
 	}
+	data.CurrencyOrder = []string{"USD"}
+	data.CountrySummaries, _ = summarizeCountries(data.CountryRevenues, p.countryCodeTable(), data.CurrencyOrder)
 
-	// Generate sample top products
-	p.dashboardData.TopProducts = make([]models.ProductFrequency, len(products))
+	// Generate sample top products, a few of them low or out of stock
+	productMap := make(map[string]*models.ProductFrequency, len(products))
 	for i, product := range products {
-		p.dashboardData.TopProducts[i] = models.ProductFrequency{
+		stock := rand.Intn(500) + 50 // 50-550 stock
+		switch i % 7 {
+		case 3:
+			stock = 0
+		case 5:
+			stock = rand.Intn(int(p.lowStockThreshold.Load()) + 1)
+		}
+		purchases := rand.Intn(10000) + 1000                                                // 1000-11000 purchases
+		firstSold := time.Now().AddDate(0, 0, -rand.Intn(600)-120).Truncate(24 * time.Hour) // 120-720 days ago
+		lastSold := time.Now().AddDate(0, 0, -rand.Intn(90)).Truncate(24 * time.Hour)       // within 90 days
+		productMap[product] = &models.ProductFrequency{
 			ProductName:   product,
-			PurchaseCount: rand.Intn(10000) + 1000, // 1000-11000 purchases
-			CurrentStock:  rand.Intn(500) + 50,     // 50-550 stock
+			PurchaseCount: purchases,
+			UnitsSold:     purchases + rand.Intn(2*purchases), // 1-3 units per purchase
+			CurrentStock:  stock,
+			FirstSold:     &firstSold,
+			LastSold:      &lastSold,
+		}
+	}
+	data.TopProducts = p.sortTopProducts(productMap, len(products), models.RankByOrders)
+	data.TopProductsByUnits = p.sortTopProducts(productMap, len(products), models.RankByUnits)
+	data.ProductIndex = p.buildProductIndex(productMap)
+	data.TopProductsAvailable = len(productMap)
+
+	// Generate sample stock pressure for the products out of stock
+	pressureMap := make(map[string]*models.StockPressure)
+	for _, product := range productMap {
+		if product.CurrentStock == 0 {
+			rows := rand.Intn(80) + 5 // 5-85 rows
+			pressureMap[product.ProductName] = &models.StockPressure{
+				ProductName:   product.ProductName,
+				Rows:          rows,
+				UnitsDemanded: rows + rand.Intn(2*rows),
+				Revenue:       float64(rows) * (rand.Float64()*300 + 20), // $20-$320 per order
+			}
 		}
 	}
+	data.StockPressure = sortStockPressure(pressureMap)
 
 	// Generate sample monthly sales (last 12 months)
-	p.dashboardData.MonthlySales = make([]models.MonthlySales, 12)
+	data.MonthlySales = make([]models.MonthlySales, 12)
 	months := []string{
 		"January", "February", "March", "April", "May", "June",
 		"July", "August", "September", "October", "November", "December",
 	}
 	currentYear := time.Now().Year()
 	for i, month := range months {
-		p.dashboardData.MonthlySales[i] = models.MonthlySales{
-			Month:       month,
-			Year:        currentYear,
-			TotalSales:  rand.Float64()*200000 + 100000, // $100k-$300k
-			SalesVolume: rand.Intn(5000) + 2000,         // 2000-7000 items
+		// Every day of the month has sales, up to today in the current one
+		days := time.Date(currentYear, time.Month(i+2), 0, 0, 0, 0, 0, time.UTC).Day()
+		if time.Month(i+1) == start.Month() {
+			days = start.Day()
+		}
+		data.MonthlySales[i] = models.MonthlySales{
+			Month:         month,
+			Year:          currentYear,
+			MonthNumber:   i + 1,
+			TotalSales:    rand.Float64()*200000 + 100000, // $100k-$300k
+			SalesVolume:   rand.Intn(5000) + 2000,         // 2000-7000 items
+			DaysWithSales: days,
+		}
+		data.MonthlySales[i].AverageDailySales = data.MonthlySales[i].TotalSales / float64(days)
+	}
+	applyGrowth(data.MonthlySales)
+
+	// Generate sample weekly sales for the 52 weeks up to the current one
+	weekMap := make(map[string]*models.WeeklySales, 52)
+	for i := 0; i < 52; i++ {
+		week := newWeeklySales(start.AddDate(0, 0, -7*i))
+		week.TotalSales = rand.Float64()*50000 + 25000 // $25k-$75k
+		week.SalesVolume = rand.Intn(1200) + 500       // 500-1700 items
+		weekMap[week.Week] = week
+	}
+	data.WeeklySales = sortWeeklySales(weekMap)
+
+	// Generate sample per-country monthly series covering the same 12 months
+	countryMonthMap := make(map[string]map[string]*models.MonthlySales, len(countries))
+	for _, country := range countries {
+		countryMonthMap[country] = make(map[string]*models.MonthlySales, len(months))
+		for i, month := range months {
+			countryMonthMap[country][fmt.Sprintf("%d-%02d", currentYear, i+1)] = &models.MonthlySales{
+				Month:       month,
+				Year:        currentYear,
+				MonthNumber: i + 1,
+				TotalSales:  rand.Float64()*20000 + 10000, // $10k-$30k
+				SalesVolume: rand.Intn(500) + 200,         // 200-700 items
+			}
+		}
+	}
+	data.CountryMonthlySales = p.sortCountryMonthlySales(countryMonthMap, CountryTrendLimit)
+
+	// Generate sample customers per country, more than are ranked
+	countryCustomerMap := make(map[string]map[string]*models.CountryCustomer, len(countries))
+	for i, country := range countries {
+		countryCustomerMap[country] = make(map[string]*models.CountryCustomer, 15)
+		for j := 0; j < 15; j++ {
+			userID := fmt.Sprintf("user-%02d%03d", i, j)
+			transactions := rand.Intn(40) + 1 // 1-40 transactions
+			countryCustomerMap[country][userID] = &models.CountryCustomer{
+				UserID:           userID,
+				TotalRevenue:     float64(transactions) * (rand.Float64()*300 + 50), // $50-$350 per order
+				TransactionCount: transactions,
+				ItemsSold:        transactions + rand.Intn(2*transactions),
+			}
 		}
 	}
+	data.CountryTopCustomers = sortCountryCustomers(countryCustomerMap, CountryTopCustomersLimit)
 
 	// Generate sample top regions
-	p.dashboardData.TopRegions = make([]models.RegionRevenue, len(regions))
+	data.TopRegions = make([]models.RegionRevenue, len(regions))
+	data.TopRegionsAvailable = len(regions)
 	for i, region := range regions {
-		p.dashboardData.TopRegions[i] = models.RegionRevenue{
-			Region:       region,
-			TotalRevenue: rand.Float64()*500000 + 200000, // $200k-$700k
-			ItemsSold:    rand.Intn(20000) + 5000,        // 5000-25000 items
+		data.TopRegions[i] = withRegionAverages(models.RegionRevenue{
+			Region:           region,
+			TotalRevenue:     rand.Float64()*500000 + 200000, // $200k-$700k
+			ItemsSold:        rand.Intn(20000) + 5000,        // 5000-25000 items
+			TransactionCount: rand.Intn(3000) + 2000,         // 2000-5000 transactions
+		})
+	}
+
+	// Generate sample per-region product rankings
+	regionProductMap := make(map[string]map[string]*models.RegionProduct, len(regions))
+	for _, region := range regions {
+		regionProductMap[region] = make(map[string]*models.RegionProduct, len(products))
+		for _, product := range products {
+			regionProductMap[region][product] = &models.RegionProduct{
+				ProductName:  product,
+				QuantitySold: rand.Intn(2000) + 100,       // 100-2100 items
+				TotalRevenue: rand.Float64()*80000 + 5000, // $5k-$85k
+			}
+		}
+	}
+	data.RegionProducts = p.sortRegionProducts(regionProductMap, regionProductLimit)
+
+	// Generate sample per-category rankings from the product totals
+	categoryProductMap := make(map[string]map[string]*models.CategoryProduct, len(categories))
+	for category, names := range categories {
+		categoryProductMap[categoryKey(category)] = make(map[string]*models.CategoryProduct, len(names))
+		for _, name := range names {
+			product := productMap[name]
+			categoryProductMap[categoryKey(category)][name] = &models.CategoryProduct{
+				ProductName:   name,
+				PurchaseCount: product.PurchaseCount,
+				UnitsSold:     product.UnitsSold,
+				TotalRevenue:  float64(product.UnitsSold) * (rand.Float64()*400 + 20), // $20-$420 per unit
+			}
+		}
+	}
+	data.CategoryProducts = sortCategoryProducts(categoryProductMap, categoryProductLimit)
+
+	// Derive the sample dimension values from the generated rows
+	dimensionMap := map[string]map[string]int{
+		dimensionCountries:  make(map[string]int, len(countries)),
+		dimensionRegions:    make(map[string]int, len(regions)),
+		dimensionCategories: make(map[string]int, len(categories)),
+		dimensionCurrencies: make(map[string]int, 1),
+	}
+	for _, summary := range data.CountrySummaries {
+		dimensionMap[dimensionCountries][summary.Country] = summary.TransactionCount
+		dimensionMap[dimensionCurrencies]["USD"] += summary.TransactionCount
+	}
+	for _, region := range data.TopRegions {
+		dimensionMap[dimensionRegions][region.Region] = region.TransactionCount
+	}
+	for category, names := range categories {
+		for _, name := range names {
+			dimensionMap[dimensionCategories][category] += productMap[name].PurchaseCount
 		}
 	}
+	data.Dimensions = buildDimensions(dimensionMap)
+
+	// Generate sample daily totals (last 60 days) for the rolling summary
+	dayMap := make(map[string]*dailyTotal)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for i := 0; i < 60; i++ {
+		date := today.AddDate(0, 0, -i)
+		dayMap[date.Format("2006-01-02")] = &dailyTotal{
+			Date:    date,
+			Revenue: rand.Float64()*10000 + 3000, // $3k-$13k
+			Orders:  rand.Intn(200) + 50,         // 50-250 orders
+		}
+	}
+	data.Summary = computeSummary(dayMap)
+	data.DataStartDate = today.AddDate(0, 0, -59)
+	data.DataEndDate = today
+	indexData(data)
 
 	// Set metadata
-	p.dashboardData.LastUpdated = time.Now()
-	p.dashboardData.ProcessingDuration = time.Since(start)
-	p.dashboardData.RecordCount = len(p.dashboardData.CountryRevenues)
+	data.LastUpdated = p.now()
+	data.ProcessingDuration = time.Since(start)
+	data.RecordCount = len(data.CountryRevenues)
+	data.DistinctProducts = len(products)
+	data.DistinctCountries = len(countries)
+	data.DistinctRegions = len(regions)
+	data.DistinctUsers = rand.Intn(40000) + 10000 // 10k-50k users
+	data.ReportingCurrency = "USD"
+	data.Report = models.ProcessingReport{
+		Currencies: []string{"USD"},
+		Warnings:   make([]string, 0),
+		Files:      make([]models.FileReport, 0),
+	}
+
+	p.swapDashboardData(data, models.ProcessingRun{
+		Source:    SourceSample,
+		StartedAt: start,
+		Duration:  data.ProcessingDuration,
+		Records:   data.RecordCount,
+	})
+	return nil
 }
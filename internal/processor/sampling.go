@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"math/rand"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+// overflowSampleCountries counts the rows sampled into OtherBucket once the
+// per-country reservoirs reach the aggregation key limit
+const overflowSampleCountries = "sample_countries"
+
+// SetSampleTransactions keeps a uniform random sample of up to n raw
+// transactions from each dataset, both overall and per country, for
+// checking aggregates against the rows behind them. Zero, the default,
+// keeps no sample; negative values are ignored.
+func (p *Processor) SetSampleTransactions(n int) {
+	if n >= 0 {
+		p.sampleSize = n
+	}
+}
+
+// reservoir holds a uniform random sample of at most size transactions out
+// of all those offered, using Algorithm R
+type reservoir struct {
+	items []models.Transaction
+	seen  int
+}
+
+func (r *reservoir) add(transaction models.Transaction, size int) {
+	r.seen++
+	if len(r.items) < size {
+		r.items = append(r.items, transaction)
+		return
+	}
+	if i := rand.Intn(r.seen); i < size {
+		r.items[i] = transaction
+	}
+}
+
+// transactionSamples are the overall and per-country reservoirs of a run
+type transactionSamples struct {
+	size      int
+	all       reservoir
+	countries map[string]*reservoir
+}
+
+func newTransactionSamples(size int) *transactionSamples {
+	return &transactionSamples{size: size, countries: make(map[string]*reservoir)}
+}
+
+// add offers a transaction to the overall and its country's reservoir.
+// Countries beyond the aggregation key limit share OtherBucket's. Callers
+// must hold agg.mu.
+func (s *transactionSamples) add(agg *aggregates, transaction models.Transaction) {
+	if s.size <= 0 {
+		return
+	}
+	s.all.add(transaction, s.size)
+
+	country := cappedKey(agg, overflowSampleCountries, s.countries, transaction.Country)
+	r, exists := s.countries[country]
+	if !exists {
+		r = &reservoir{}
+		s.countries[country] = r
+	}
+	r.add(transaction, s.size)
+}
+
+// renameProducts applies rename to the product name of every sampled
+// transaction
+func (s *transactionSamples) renameProducts(rename func(*string)) {
+	for i := range s.all.items {
+		rename(&s.all.items[i].ProductName)
+	}
+	for _, r := range s.countries {
+		for i := range r.items {
+			rename(&r.items[i].ProductName)
+		}
+	}
+}
+
+// sampleTransactions returns the overall sample and the samples by country,
+// or nil for both when sampling is disabled
+func (s *transactionSamples) sampleTransactions() ([]models.Transaction, map[string][]models.Transaction) {
+	if s.size <= 0 {
+		return nil, nil
+	}
+	all := s.all.items
+	if all == nil {
+		all = make([]models.Transaction, 0)
+	}
+	byCountry := make(map[string][]models.Transaction, len(s.countries))
+	for country, r := range s.countries {
+		byCountry[country] = r.items
+	}
+	return all, byCountry
+}
+
+// GetSampleTransactions returns the raw transactions sampled from the
+// current dataset for a country, or overall when country is empty. The
+// second return value is false when sampling is disabled or no transaction
+// of the country was sampled.
+func (p *Processor) GetSampleTransactions(country string) ([]models.Transaction, bool) {
+	data := p.data.Load()
+	if data.SampleTransactions == nil {
+		return nil, false
+	}
+	if country == "" {
+		return data.SampleTransactions, true
+	}
+	sample, ok := data.CountrySampleTransactions[country]
+	return sample, ok
+}
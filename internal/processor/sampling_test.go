@@ -0,0 +1,123 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// samplingDataset returns a CSV with rows transactions for Germany and
+// three for Japan
+func samplingDataset(t *testing.T, rows int) string {
+	t.Helper()
+
+	var b strings.Builder
+	b.WriteString("transaction_id,country,product_name,quantity,total_price\n")
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&b, "DE%04d,Germany,Laptop,1,%d\n", i, 100+i)
+	}
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(&b, "JP%04d,Japan,Camera,1,50\n", i)
+	}
+	return writeTestFile(t, "sales.csv", b.String())
+}
+
+func TestSampleTransactionsBounded(t *testing.T) {
+	path := samplingDataset(t, 500)
+
+	processor := New()
+	processor.SetSampleTransactions(10)
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	all, ok := processor.GetSampleTransactions("")
+	if !ok || len(all) != 10 {
+		t.Fatalf("Expected an overall sample of 10, got %d (%v)", len(all), ok)
+	}
+
+	germany, ok := processor.GetSampleTransactions("Germany")
+	if !ok || len(germany) != 10 {
+		t.Fatalf("Expected a Germany sample of 10, got %d (%v)", len(germany), ok)
+	}
+	seen := make(map[string]bool)
+	for _, transaction := range germany {
+		if transaction.Country != "Germany" || !strings.HasPrefix(transaction.TransactionID, "DE") {
+			t.Errorf("Expected only German rows, got %+v", transaction)
+		}
+		if seen[transaction.TransactionID] {
+			t.Errorf("Expected distinct rows, got %s twice", transaction.TransactionID)
+		}
+		seen[transaction.TransactionID] = true
+	}
+
+	japan, ok := processor.GetSampleTransactions("Japan")
+	if !ok || len(japan) != 3 {
+		t.Errorf("Expected all 3 Japanese rows, got %d (%v)", len(japan), ok)
+	}
+	if _, ok := processor.GetSampleTransactions("France"); ok {
+		t.Error("Expected no sample for a country without rows")
+	}
+}
+
+func TestSampleTransactionsDisabled(t *testing.T) {
+	processor := New()
+	if err := processor.ProcessDataset(samplingDataset(t, 5)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := processor.GetSampleTransactions(""); ok {
+		t.Error("Expected no sample by default")
+	}
+	if data := processor.GetDashboardData(); data.SampleTransactions != nil || data.CountrySampleTransactions != nil {
+		t.Error("Expected no sampled transactions in the data")
+	}
+}
+
+func TestSampleTransactionsCountryLimit(t *testing.T) {
+	path := writeTestFile(t, "sales.csv", `transaction_id,country,product_name,quantity,total_price
+TXN001,Germany,Laptop,1,100
+TXN002,France,Laptop,1,100
+TXN003,Japan,Laptop,1,100
+TXN004,Brazil,Laptop,1,100
+`)
+
+	processor := New()
+	processor.SetSampleTransactions(5)
+	processor.SetMaxAggregationKeys(2)
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	countries := processor.GetDashboardData().CountrySampleTransactions
+	if len(countries) != 3 || len(countries[OtherBucket]) != 2 {
+		t.Errorf("Expected 2 countries and the rest under %q, got %v", OtherBucket, countries)
+	}
+}
+
+func TestReservoirCap(t *testing.T) {
+	var r reservoir
+	for i := 0; i < 10000; i++ {
+		r.add(models.Transaction{TransactionID: fmt.Sprint(i)}, 5)
+	}
+	if len(r.items) != 5 || r.seen != 10000 {
+		t.Fatalf("Expected 5 of 10000 rows kept, got %d of %d", len(r.items), r.seen)
+	}
+
+	// Every row has the same chance of being kept: 2 in 10
+	kept := make(map[string]int)
+	for trial := 0; trial < 2000; trial++ {
+		var r reservoir
+		for i := 0; i < 10; i++ {
+			r.add(models.Transaction{TransactionID: fmt.Sprint(i)}, 2)
+		}
+		for _, transaction := range r.items {
+			kept[transaction.TransactionID]++
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if n := kept[fmt.Sprint(i)]; n < 250 || n > 550 {
+			t.Errorf("Expected row %d to be kept about 400 times, got %d", i, n)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"context"
+	"strings"
+)
+
+// Match qualities for product search, best first
+const (
+	matchExact = iota
+	matchPrefix
+	matchWordPrefix
+	matchSubstring
+	matchFuzzy
+	matchNone
+)
+
+// minFuzzyQueryLength is the shortest query matched fuzzily; shorter
+// queries would match nearly every name
+const minFuzzyQueryLength = 3
+
+// buildProductIndex ranks every named product by purchase count and pairs
+// it with its lowercased name, so searches never lowercase per request
+func (p *Processor) buildProductIndex(productMap map[string]*models.ProductFrequency) []models.ProductSearchEntry {
+	products := p.sortTopProducts(productMap, len(productMap), models.RankByOrders)
+
+	index := make([]models.ProductSearchEntry, 0, len(products))
+	for _, product := range products {
+		if product.ProductName == "" {
+			continue
+		}
+		index = append(index, models.ProductSearchEntry{
+			Product: product,
+			Key:     strings.ToLower(product.ProductName),
+		})
+	}
+	return index
+}
+
+// SearchProducts returns up to limit products whose names match query
+// case-insensitively. Exact names rank first, then names starting with the
+// query, names with a word starting with it, names containing it and
+// finally names containing its letters in order; ties keep purchase count
+// order.
+func (p *Processor) SearchProducts(query string, limit int) []models.ProductFrequency {
+	results, _ := p.SearchProductsContext(context.Background(), query, limit)
+	return results
+}
+
+// SearchProductsContext is SearchProducts stopping early with ctx's error
+// once ctx is done, such as when the client of a request has gone away
+func (p *Processor) SearchProductsContext(ctx context.Context, query string, limit int) ([]models.ProductFrequency, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" || limit <= 0 {
+		return []models.ProductFrequency{}, nil
+	}
+
+	data := p.data.Load()
+	if results, ok := prefixSearch(data, query, limit); ok {
+		return results, nil
+	}
+	index := data.ProductIndex
+
+	// The index is in purchase count order, so bucketing by quality keeps
+	// that order within each bucket
+	var buckets [matchNone][]models.ProductFrequency
+	for i, entry := range index {
+		if i%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if quality := matchQuality(entry.Key, query); quality != matchNone {
+			buckets[quality] = append(buckets[quality], entry.Product)
+		}
+	}
+
+	results := make([]models.ProductFrequency, 0, limit)
+	for _, bucket := range buckets {
+		for _, product := range bucket {
+			if len(results) == limit {
+				return results, nil
+			}
+			results = append(results, product)
+		}
+	}
+	return results, nil
+}
+
+// matchQuality grades how well a lowercased name matches a lowercased query
+func matchQuality(name, query string) int {
+	switch {
+	case name == query:
+		return matchExact
+	case strings.HasPrefix(name, query):
+		return matchPrefix
+	case strings.Contains(name, " "+query):
+		return matchWordPrefix
+	case strings.Contains(name, query):
+		return matchSubstring
+	case len(query) >= minFuzzyQueryLength && isSubsequence(name, query):
+		return matchFuzzy
+	default:
+		return matchNone
+	}
+}
+
+// isSubsequence reports whether the bytes of query appear in name in order,
+// such as "cbl" in "usb cable"
+func isSubsequence(name, query string) bool {
+	i := 0
+	for j := 0; j < len(name) && i < len(query); j++ {
+		if name[j] == query[i] {
+			i++
+		}
+	}
+	return i == len(query)
+}
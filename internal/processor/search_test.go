@@ -0,0 +1,122 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func newSearchTestProcessor(t *testing.T) *Processor {
+	t.Helper()
+
+	// Purchase counts: HDMI Cable 4, USB Cable 3, Cable Organizer 2, others 1
+	path := writeTestFile(t, "search.csv", `transaction_id,product_name,quantity,total_price,stock_quantity
+TXN001,USB Cable,1,10,100
+TXN002,USB Cable,1,10,100
+TXN003,USB Cable,1,10,100
+TXN004,HDMI Cable,1,20,40
+TXN005,HDMI Cable,1,20,40
+TXN006,HDMI Cable,1,20,40
+TXN007,HDMI Cable,1,20,40
+TXN008,Cable Organizer,1,15,20
+TXN009,Cable Organizer,1,15,20
+TXN010,Cable,1,5,0
+TXN011,Laptop Stand,1,30,15
+TXN012,Scabbard,1,40,5
+TXN013,Coax Bundle,1,25,10
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return processor
+}
+
+func productNames(t *testing.T, processor *Processor, query string, limit int) []string {
+	t.Helper()
+
+	results := processor.SearchProducts(query, limit)
+	names := make([]string, len(results))
+	for i, product := range results {
+		names[i] = product.ProductName
+	}
+	return names
+}
+
+func assertNames(t *testing.T, query string, got, want []string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("%q: expected %v, got %v", query, want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%q: expected %v, got %v", query, want, got)
+		}
+	}
+}
+
+func TestSearchProductsPrefix(t *testing.T) {
+	processor := newSearchTestProcessor(t)
+
+	// Exact match first, then prefix, then word prefix by purchase count, then fuzzy
+	got := productNames(t, processor, "CABLE", 10)
+	assertNames(t, "CABLE", got, []string{"Cable", "Cable Organizer", "HDMI Cable", "USB Cable", "Coax Bundle"})
+
+	got = productNames(t, processor, "lap", 10)
+	assertNames(t, "lap", got, []string{"Laptop Stand"})
+}
+
+func TestSearchProductsSubstring(t *testing.T) {
+	processor := newSearchTestProcessor(t)
+
+	// "cab" starts "Cable Organizer" and "Cable", starts a word in
+	// "HDMI Cable" and "USB Cable", is contained in "Scabbard" and its
+	// letters appear in order in "Coax Bundle"
+	got := productNames(t, processor, "cab", 10)
+	assertNames(t, "cab", got, []string{"Cable Organizer", "Cable", "HDMI Cable", "USB Cable", "Scabbard", "Coax Bundle"})
+}
+
+func TestSearchProductsFuzzy(t *testing.T) {
+	processor := newSearchTestProcessor(t)
+
+	got := productNames(t, processor, "cbl", 10)
+	assertNames(t, "cbl", got, []string{"HDMI Cable", "USB Cable", "Cable Organizer", "Cable", "Coax Bundle"})
+
+	// Short queries are not matched fuzzily
+	got = productNames(t, processor, "cx", 10)
+	assertNames(t, "cx", got, []string{})
+}
+
+func TestSearchProductsNoMatch(t *testing.T) {
+	processor := newSearchTestProcessor(t)
+
+	for _, query := range []string{"monitor", "", "   "} {
+		if results := processor.SearchProducts(query, 10); len(results) != 0 {
+			t.Errorf("%q: expected no results, got %v", query, results)
+		}
+	}
+}
+
+func TestSearchProductsLimit(t *testing.T) {
+	processor := newSearchTestProcessor(t)
+
+	got := productNames(t, processor, "cable", 2)
+	assertNames(t, "cable", got, []string{"Cable", "Cable Organizer"})
+
+	results := processor.SearchProducts("cable", 1)
+	if len(results) != 1 || results[0].StockStatus == "" || results[0].Rank == 0 {
+		t.Errorf("Expected one ranked result with stock status, got %+v", results)
+	}
+}
+
+func TestSearchProductsContextCanceled(t *testing.T) {
+	processor := newSearchTestProcessor(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if results, err := processor.SearchProductsContext(ctx, "cable", 10); !errors.Is(err, context.Canceled) || results != nil {
+		t.Errorf("Expected no results and context.Canceled, got %v and %v", results, err)
+	}
+}
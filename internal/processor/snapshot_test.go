@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"sync"
+	"testing"
+)
+
+const snapshotTestCSV = `transaction_id,transaction_date,user_id,country,region,product_id,product_name,category,price,quantity,total_price,stock_quantity,added_date
+T1,2025-01-15,U1,Canada,Ontario,P1,Laptop,Electronics,1000,1,1000,5,2024-01-01
+T2,2025-02-10,U2,Japan,Tokyo,P2,Phone,Electronics,500,2,1000,10,2024-01-01
+`
+
+// TestConcurrentReadsDuringReload runs the getters while the dataset is
+// reloaded; run with -race to check that readers never see a snapshot
+// being built
+func TestConcurrentReadsDuringReload(t *testing.T) {
+	path := writeTestFile(t, "transactions.csv", snapshotTestCSV)
+	processor := New()
+
+	const reloads = 20
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				data := processor.GetDashboardData()
+				if data == nil {
+					t.Error("Expected a snapshot, got nil")
+					return
+				}
+				_ = data.LastUpdated
+				processor.GetCountryRevenues()
+				processor.GetTopProducts()
+				processor.GetMonthlySales()
+				processor.GetTopRegions()
+				processor.GetSummary()
+				processor.GetCountrySummaries()
+				processor.GetCountryMonthlySales("Canada")
+				processor.GetRegionProducts("Ontario", 5)
+				processor.SearchProducts("lap", 5)
+			}
+		}()
+	}
+
+	for i := 0; i < reloads; i++ {
+		if err := processor.Reload(path); err != nil {
+			t.Errorf("Expected reload to succeed, got %v", err)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	if len(processor.GetCountryRevenues()) != 2 {
+		t.Errorf("Expected 2 country revenues after reload, got %d", len(processor.GetCountryRevenues()))
+	}
+}
+
+// lockedSnapshot serves data behind an RWMutex, the way the processor did
+// before the atomic snapshot; it is the baseline for BenchmarkReads
+type lockedSnapshot struct {
+	mu   sync.RWMutex
+	data *models.DashboardData
+}
+
+func (l *lockedSnapshot) get() *models.DashboardData {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.data
+}
+
+// BenchmarkReads compares the read path of a handler, a data getter plus
+// the snapshot for its metadata, with the atomic snapshot and with the
+// former mutex-guarded pointer
+func BenchmarkReads(b *testing.B) {
+	processor := New()
+	processor.LoadSampleData()
+
+	b.Run("Snapshot", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				data := processor.GetDashboardData()
+				_ = data.TopRegions
+				_ = data.LastUpdated
+			}
+		})
+	})
+
+	b.Run("RWMutex", func(b *testing.B) {
+		locked := &lockedSnapshot{data: processor.GetDashboardData()}
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = locked.get().TopRegions
+				_ = locked.get().LastUpdated
+			}
+		})
+	})
+}
@@ -0,0 +1,111 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// DataSource is a dataset that Process can load: a list of named parts,
+// read in order and aggregated together, and a way to open each of them
+type DataSource interface {
+	// Kind is the data source recorded in the data and the processing
+	// history, such as SourceDataset or SourceReader
+	Kind() string
+	// Parts returns the names of the parts to read, in order
+	Parts() ([]string, error)
+	// Open returns the CSV data of a part
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// FileSource returns the dataset at path, which may name a single file, a
+// directory of shards or a glob pattern. Files ending in .gz are
+// decompressed transparently.
+func FileSource(path string) DataSource {
+	return fileSource(path)
+}
+
+type fileSource string
+
+func (s fileSource) Kind() string { return SourceDataset }
+
+func (s fileSource) Parts() ([]string, error) { return resolveDataFiles(string(s)) }
+
+func (s fileSource) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".gz") {
+		return file, nil
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open gzip stream: %w", err)
+	}
+	return &gzipFile{Reader: gz, file: file}, nil
+}
+
+// gzipFile closes both the decompressor and the file under it
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (f *gzipFile) Close() error {
+	f.Reader.Close()
+	return f.file.Close()
+}
+
+// ReaderSource returns the CSV data read from r, such as a network stream
+// or an upload, as a single part. Reading stops when the context passed to
+// Process is cancelled.
+func ReaderSource(r io.Reader) DataSource {
+	return readerSource{r: r}
+}
+
+type readerSource struct {
+	r io.Reader
+}
+
+func (s readerSource) Kind() string { return SourceReader }
+
+func (s readerSource) Parts() ([]string, error) { return []string{readerSourceName}, nil }
+
+func (s readerSource) Open(ctx context.Context, _ string) (io.ReadCloser, error) {
+	return io.NopCloser(&contextReader{ctx: ctx, r: s.r}), nil
+}
+
+// Process loads the dataset of src and serves the result, replacing the
+// current data once processing succeeds. ProcessDataset and ProcessReader
+// are shorthands for the built-in sources. One dataset is processed or
+// staged at a time; a call made while another runs fails at once with
+// ErrProcessingInProgress.
+func (p *Processor) Process(ctx context.Context, src DataSource) error {
+	start := time.Now()
+
+	parts, err := src.Parts()
+	if err != nil {
+		return err
+	}
+	return p.process(ctx, start, src.Kind(), parts, p.sourceReader(ctx, src))
+}
+
+// sourceReader returns the read function of the pipeline for src
+func (p *Processor) sourceReader(ctx context.Context, src DataSource) func(string, *batchSender) (models.FileReport, error) {
+	return func(name string, sender *batchSender) (models.FileReport, error) {
+		r, err := src.Open(ctx, name)
+		if err != nil {
+			return models.FileReport{}, err
+		}
+		defer r.Close()
+		return p.readCSV(r, sender)
+	}
+}
@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// ErrStageExists is returned when a dataset is staged while another one is
+// staged or still being processed
+var ErrStageExists = errors.New("a dataset is already staged")
+
+// ErrNothingStaged is returned when there is no staged dataset to preview,
+// promote or discard
+var ErrNothingStaged = errors.New("no dataset is staged")
+
+// StagedDataset is a processed dataset waiting to be promoted. Its Data is
+// not served until then.
+type StagedDataset struct {
+	Path     string
+	StagedAt time.Time
+	Data     *models.DashboardData
+
+	run models.ProcessingRun
+}
+
+// StageDataset processes the dataset at path, as ProcessDataset does, into
+// a staged dataset while the current data keeps being served. Only one
+// dataset can be staged at a time: until it is promoted or discarded,
+// staging another fails with ErrStageExists. Staging while a dataset is
+// being processed fails with ErrProcessingInProgress.
+func (p *Processor) StageDataset(path string) (*StagedDataset, error) {
+	p.mu.Lock()
+	if p.staging || p.staged != nil {
+		p.mu.Unlock()
+		return nil, ErrStageExists
+	}
+	p.staging = true
+	p.mu.Unlock()
+
+	staged, err := p.stage(path)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.staging = false
+	if err != nil {
+		return nil, err
+	}
+	p.staged = staged
+	return staged, nil
+}
+
+// stage builds the staged dataset for path
+func (p *Processor) stage(path string) (*StagedDataset, error) {
+	start := time.Now()
+	if err := p.beginProcessing(); err != nil {
+		return nil, err
+	}
+	defer p.endProcessing()
+	p.runLog.begin()
+	defer p.runLog.end()
+
+	src := FileSource(path)
+	files, err := src.Parts()
+	if err != nil {
+		return nil, err
+	}
+	data, run, err := p.build(context.Background(), start, src.Kind(), files, p.sourceReader(context.Background(), src))
+	if err != nil {
+		p.logf("Staging %s failed: %v", path, err)
+		return nil, err
+	}
+
+	p.logf("Dataset %s staged in %v", path, time.Since(start))
+	return &StagedDataset{Path: path, StagedAt: p.now(), Data: data, run: run}, nil
+}
+
+// GetStagedDataset returns the staged dataset, or ErrNothingStaged
+func (p *Processor) GetStagedDataset() (*StagedDataset, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.staged == nil {
+		return nil, ErrNothingStaged
+	}
+	return p.staged, nil
+}
+
+// PromoteStaged atomically replaces the served data with the staged
+// dataset and records its processing run. The stage is then empty.
+func (p *Processor) PromoteStaged() (*StagedDataset, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	staged := p.staged
+	if staged == nil {
+		return nil, ErrNothingStaged
+	}
+	p.staged = nil
+	p.storeData(staged.Data)
+	p.appendHistory(staged.run)
+	log.Printf("Staged dataset %s promoted", staged.Path)
+	return staged, nil
+}
+
+// DiscardStaged drops the staged dataset without serving it
+func (p *Processor) DiscardStaged() (*StagedDataset, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	staged := p.staged
+	if staged == nil {
+		return nil, ErrNothingStaged
+	}
+	p.staged = nil
+	log.Printf("Staged dataset %s discarded", staged.Path)
+	return staged, nil
+}
@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStageAndPromote(t *testing.T) {
+	live := writeTestFile(t, "live.csv", "product_name,quantity,total_price,country\nLaptop,1,100,USA\n")
+	nightly := writeTestFile(t, "nightly.csv", "product_name,quantity,total_price,country\nCamera,1,300,Japan\nLens,1,200,Japan\n")
+
+	processor := New()
+	if err := processor.ProcessDataset(live); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	runs := len(processor.GetProcessingHistory())
+
+	staged, err := processor.StageDataset(nightly)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if staged.Data.Report.Rows != 2 {
+		t.Errorf("Expected 2 staged rows, got %d", staged.Data.Report.Rows)
+	}
+	if got := processor.GetCountryRevenues()[0].Country; got != "USA" {
+		t.Errorf("Expected USA to stay live while staged, got %s", got)
+	}
+	if _, err := processor.StageDataset(nightly); !errors.Is(err, ErrStageExists) {
+		t.Errorf("Expected ErrStageExists, got %v", err)
+	}
+
+	promoted, err := processor.PromoteStaged()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if promoted != staged || processor.GetDashboardData() != staged.Data {
+		t.Error("Expected the staged data to be served after promote")
+	}
+	if history := processor.GetProcessingHistory(); len(history) != runs+1 || history[0].Path != nightly {
+		t.Errorf("Expected the staged run to be recorded on promote, got %+v", history)
+	}
+	if _, err := processor.GetStagedDataset(); !errors.Is(err, ErrNothingStaged) {
+		t.Errorf("Expected ErrNothingStaged after promote, got %v", err)
+	}
+}
+
+func TestStageFailureFreesStage(t *testing.T) {
+	processor := New()
+	if _, err := processor.StageDataset(writeTestFile(t, "bad.csv", "transaction_id\n")); !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("Expected ErrInvalidHeader, got %v", err)
+	}
+	if _, err := processor.DiscardStaged(); !errors.Is(err, ErrNothingStaged) {
+		t.Errorf("Expected ErrNothingStaged, got %v", err)
+	}
+	if _, err := processor.StageDataset(writeTestFile(t, "good.csv", "product_name,quantity,total_price\nLaptop,1,100\n")); err != nil {
+		t.Errorf("Expected staging to succeed after a failure, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"sort"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+// DefaultLowStockThreshold is the stock level at or below which an in-stock
+// product is reported as low
+const DefaultLowStockThreshold = 10
+
+// SetLowStockThreshold sets the stock level at or below which a product's
+// StockStatus is low. It applies to data processed after the call and is
+// safe to call while data is being processed or served.
+func (p *Processor) SetLowStockThreshold(n int) {
+	p.lowStockThreshold.Store(int64(n))
+}
+
+// stockStatus classifies a stock level as out, low or in stock
+func (p *Processor) stockStatus(stock int) string {
+	switch {
+	case stock <= 0:
+		return models.StockOut
+	case int64(stock) <= p.lowStockThreshold.Load():
+		return models.StockLow
+	default:
+		return models.StockInStock
+	}
+}
+
+// FilterTopProducts returns the top products, ranked by filter.RankBy, that
+// match the filter. Filtering happens after ranking, so each product keeps
+// its original Rank.
+func (p *Processor) FilterTopProducts(filter models.TopProductsFilter) []models.ProductFrequency {
+	data := p.data.Load()
+
+	ranked := data.TopProducts
+	if filter.RankBy == models.RankByUnits {
+		ranked = data.TopProductsByUnits
+	}
+
+	products := make([]models.ProductFrequency, 0)
+	for _, product := range ranked {
+		if matchesTopProductsFilter(product, filter) {
+			products = append(products, product)
+		}
+	}
+	return products
+}
+
+// CountTopProducts returns the number of products, among all those the
+// rankings were cut from, that match the filter. FilterTopProducts returns
+// fewer when matching products rank below TopProductsLimit.
+func (p *Processor) CountTopProducts(filter models.TopProductsFilter) int {
+	data := p.data.Load()
+	if filter.MaxStock == nil && !filter.OutOfStock && filter.ActiveSince.IsZero() {
+		return data.TopProductsAvailable
+	}
+
+	count := 0
+	for _, entry := range data.ProductIndex {
+		if matchesTopProductsFilter(entry.Product, filter) {
+			count++
+		}
+	}
+	return count
+}
+
+// matchesTopProductsFilter reports whether product meets the stock and
+// activity conditions of filter
+func matchesTopProductsFilter(product models.ProductFrequency, filter models.TopProductsFilter) bool {
+	if filter.MaxStock != nil && product.CurrentStock > *filter.MaxStock {
+		return false
+	}
+	if filter.OutOfStock && product.StockStatus != models.StockOut {
+		return false
+	}
+	return filter.ActiveSince.IsZero() || (product.LastSold != nil && !product.LastSold.Before(filter.ActiveSince))
+}
+
+// recordStockPressure counts a transaction toward its product's stock
+// pressure when it was sold with no stock left or with less stock than the
+// quantity ordered. Rows without a stock_quantity are not counted. Callers
+// must hold agg.mu.
+func recordStockPressure(agg *aggregates, productKey string, transaction models.Transaction, amount float64) {
+	if !transaction.StockReported || (transaction.StockQuantity > 0 && transaction.StockQuantity >= transaction.Quantity) {
+		return
+	}
+	pressure, exists := agg.stockPressureMap[productKey]
+	if !exists {
+		pressure = &models.StockPressure{ProductName: productKey}
+		agg.stockPressureMap[productKey] = pressure
+	}
+	pressure.Rows++
+	pressure.UnitsDemanded += transaction.Quantity
+	pressure.Revenue += amount
+}
+
+// sortStockPressure returns the stock pressure of every product, highest
+// revenue first
+func sortStockPressure(pressureMap map[string]*models.StockPressure) []models.StockPressure {
+	pressures := make([]models.StockPressure, 0, len(pressureMap))
+	for _, pressure := range pressureMap {
+		pressures = append(pressures, *pressure)
+	}
+	sort.Slice(pressures, func(i, j int) bool {
+		if pressures[i].Revenue != pressures[j].Revenue {
+			return pressures[i].Revenue > pressures[j].Revenue
+		}
+		return pressures[i].ProductName < pressures[j].ProductName
+	})
+	return pressures
+}
+
+// GetStockPressure returns the products sold against zero or insufficient
+// stock, highest revenue first
+func (p *Processor) GetStockPressure() []models.StockPressure {
+	return p.data.Load().StockPressure
+}
@@ -0,0 +1,198 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStockStatusBoundaries(t *testing.T) {
+	processor := New()
+	processor.SetLowStockThreshold(5)
+
+	testCases := []struct {
+		stock    int
+		expected string
+	}{
+		{-1, models.StockOut},
+		{0, models.StockOut},
+		{1, models.StockLow},
+		{5, models.StockLow},
+		{6, models.StockInStock},
+	}
+
+	for _, tc := range testCases {
+		if status := processor.stockStatus(tc.stock); status != tc.expected {
+			t.Errorf("Expected stock %d to be %s, got %s", tc.stock, tc.expected, status)
+		}
+	}
+
+	processor.SetLowStockThreshold(0)
+	if status := processor.stockStatus(1); status != models.StockInStock {
+		t.Errorf("Expected stock 1 to be in stock with a zero threshold, got %s", status)
+	}
+}
+
+func createStockProcessor() *Processor {
+	processor := New()
+	processor.SetLowStockThreshold(5)
+	processor.GetDashboardData().TopProducts = processor.sortTopProducts(map[string]*models.ProductFrequency{
+		"Laptop":  {ProductName: "Laptop", PurchaseCount: 50, CurrentStock: 100},
+		"Phone":   {ProductName: "Phone", PurchaseCount: 40, CurrentStock: 0},
+		"Tablet":  {ProductName: "Tablet", PurchaseCount: 30, CurrentStock: 5},
+		"Monitor": {ProductName: "Monitor", PurchaseCount: 20, CurrentStock: 6},
+		"Mouse":   {ProductName: "Mouse", PurchaseCount: 10, CurrentStock: 0},
+	}, TopProductsLimit, models.RankByOrders)
+	return processor
+}
+
+func TestFilterTopProducts(t *testing.T) {
+	processor := createStockProcessor()
+	maxStock := func(n int) *int { return &n }
+
+	testCases := []struct {
+		name     string
+		filter   models.TopProductsFilter
+		expected []int
+	}{
+		{"no filter", models.TopProductsFilter{}, []int{1, 2, 3, 4, 5}},
+		{"max stock at low boundary", models.TopProductsFilter{MaxStock: maxStock(5)}, []int{2, 3, 5}},
+		{"max stock above low boundary", models.TopProductsFilter{MaxStock: maxStock(6)}, []int{2, 3, 4, 5}},
+		{"max stock zero", models.TopProductsFilter{MaxStock: maxStock(0)}, []int{2, 5}},
+		{"out of stock", models.TopProductsFilter{OutOfStock: true}, []int{2, 5}},
+		{"both", models.TopProductsFilter{MaxStock: maxStock(5), OutOfStock: true}, []int{2, 5}},
+	}
+
+	for _, tc := range testCases {
+		products := processor.FilterTopProducts(tc.filter)
+		if len(products) != len(tc.expected) {
+			t.Errorf("%s: expected %d products, got %d", tc.name, len(tc.expected), len(products))
+			continue
+		}
+		for i, rank := range tc.expected {
+			if products[i].Rank != rank {
+				t.Errorf("%s: expected rank %d at position %d, got %d", tc.name, rank, i, products[i].Rank)
+			}
+		}
+	}
+
+	products := processor.FilterTopProducts(models.TopProductsFilter{})
+	statuses := []string{models.StockInStock, models.StockOut, models.StockLow, models.StockInStock, models.StockOut}
+	for i, status := range statuses {
+		if products[i].StockStatus != status {
+			t.Errorf("Expected %s to be %s, got %s", products[i].ProductName, status, products[i].StockStatus)
+		}
+	}
+}
+
+func TestCountTopProducts(t *testing.T) {
+	processor := createStockProcessor()
+	data := processor.GetDashboardData()
+	data.ProductIndex = make([]models.ProductSearchEntry, 0, len(data.TopProducts)+1)
+	for _, product := range data.TopProducts {
+		data.ProductIndex = append(data.ProductIndex, models.ProductSearchEntry{Product: product})
+	}
+	// A product ranked below the retained top products
+	data.ProductIndex = append(data.ProductIndex, models.ProductSearchEntry{
+		Product: models.ProductFrequency{ProductName: "Cable", StockStatus: models.StockOut},
+	})
+	data.TopProductsAvailable = len(data.ProductIndex)
+	data.TopProducts = data.TopProducts[:4]
+
+	maxStock := 5
+	for _, tc := range []struct {
+		name     string
+		filter   models.TopProductsFilter
+		expected int
+	}{
+		{"no filter", models.TopProductsFilter{}, 6},
+		{"out of stock", models.TopProductsFilter{OutOfStock: true}, 3},
+		{"max stock", models.TopProductsFilter{MaxStock: &maxStock}, 4},
+		{"active since", models.TopProductsFilter{ActiveSince: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}, 0},
+	} {
+		if count := processor.CountTopProducts(tc.filter); count != tc.expected {
+			t.Errorf("%s: expected %d products, got %d", tc.name, tc.expected, count)
+		}
+	}
+}
+
+func TestProductSaleDates(t *testing.T) {
+	// Rows are out of chronological order; TXN004 has no valid date
+	path := writeTestFile(t, "dates.csv", `transaction_id,transaction_date,product_name,quantity,total_price
+TXN001,2024-03-10,Laptop,1,1000
+TXN002,2024-01-05,Laptop,1,1000
+TXN003,2024-06-20,Laptop,1,1000
+TXN004,not-a-date,Laptop,1,1000
+TXN005,2023-12-31,Mouse,1,20
+TXN006,,Cable,1,5
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	products := make(map[string]models.ProductFrequency)
+	for _, product := range processor.GetTopProducts() {
+		products[product.ProductName] = product
+	}
+
+	date := func(value string) time.Time {
+		parsed, _ := time.Parse("2006-01-02", value)
+		return parsed
+	}
+	laptop := products["Laptop"]
+	if laptop.FirstSold == nil || !laptop.FirstSold.Equal(date("2024-01-05")) {
+		t.Errorf("Expected Laptop first sold on 2024-01-05, got %v", laptop.FirstSold)
+	}
+	if laptop.LastSold == nil || !laptop.LastSold.Equal(date("2024-06-20")) {
+		t.Errorf("Expected Laptop last sold on 2024-06-20, got %v", laptop.LastSold)
+	}
+	if cable := products["Cable"]; cable.FirstSold != nil || cable.LastSold != nil {
+		t.Errorf("Expected no sale dates for Cable without dated rows, got %v and %v", cable.FirstSold, cable.LastSold)
+	}
+
+	active := processor.FilterTopProducts(models.TopProductsFilter{ActiveSince: date("2024-01-01")})
+	if len(active) != 1 || active[0].ProductName != "Laptop" {
+		t.Errorf("Expected only Laptop sold since 2024-01-01, got %+v", active)
+	}
+	active = processor.FilterTopProducts(models.TopProductsFilter{ActiveSince: date("2023-12-31")})
+	if len(active) != 2 {
+		t.Errorf("Expected Laptop and Mouse sold on or after 2023-12-31, got %+v", active)
+	}
+}
+
+func TestStockPressure(t *testing.T) {
+	content := "transaction_id,product_name,quantity,total_price,stock_quantity\n" +
+		"T1,Laptop,1,1000,0\n" + // out of stock
+		"T2,Laptop,3,3000,2\n" + // less stock than ordered
+		"T3,Laptop,2,2000,2\n" + // covered exactly
+		"T4,Mouse,1,20,50\n" +
+		"T5,Cable,2,10,0\n" +
+		"T6,Cable,1,5,\n" // no stock reported
+	processor := New()
+	if err := processor.ProcessDataset(writeTestFile(t, "stock.csv", content)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []models.StockPressure{
+		{ProductName: "Laptop", Rows: 2, UnitsDemanded: 4, Revenue: 4000},
+		{ProductName: "Cable", Rows: 1, UnitsDemanded: 2, Revenue: 10},
+	}
+	if pressures := processor.GetStockPressure(); !reflect.DeepEqual(pressures, expected) {
+		t.Errorf("Expected stock pressure %+v, got %+v", expected, pressures)
+	}
+}
+
+func TestStockPressureNeedsStockColumn(t *testing.T) {
+	content := "transaction_id,product_name,quantity,total_price\n" +
+		"T1,Laptop,1,1000\n"
+	processor := New()
+	if err := processor.ProcessDataset(writeTestFile(t, "nostock.csv", content)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if pressures := processor.GetStockPressure(); len(pressures) != 0 {
+		t.Errorf("Expected no stock pressure without a stock_quantity column, got %+v", pressures)
+	}
+}
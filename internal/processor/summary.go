@@ -0,0 +1,94 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"math"
+	"time"
+)
+
+// dailyTotal accumulates revenue and order counts for a single day
+type dailyTotal struct {
+	Date    time.Time
+	Revenue float64
+	Orders  int
+}
+
+// window accumulates the totals for one rolling window
+type window struct {
+	revenue float64
+	orders  int
+}
+
+// computeSummary derives the rolling 7-day and 30-day windows, plus the
+// windows immediately preceding them, relative to the latest day present
+func computeSummary(dayMap map[string]*dailyTotal) models.Summary {
+	var asOf time.Time
+	for _, day := range dayMap {
+		if day.Date.After(asOf) {
+			asOf = day.Date
+		}
+	}
+	if asOf.IsZero() {
+		return models.Summary{}
+	}
+
+	var last7, prior7, last30, prior30 window
+	for _, day := range dayMap {
+		// Days back from the latest date: 0 is the latest day itself.
+		// Rounded, as days across a daylight saving change are not 24
+		// hours long.
+		age := int(math.Round(asOf.Sub(day.Date).Hours() / 24))
+
+		switch {
+		case age < 7:
+			last7.add(day)
+		case age < 14:
+			prior7.add(day)
+		}
+		switch {
+		case age < 30:
+			last30.add(day)
+		case age < 60:
+			prior30.add(day)
+		}
+	}
+
+	return models.Summary{
+		AsOf:            asOf,
+		RevenueLast7d:   last7.revenue,
+		RevenueLast30d:  last30.revenue,
+		OrdersLast7d:    last7.orders,
+		OrdersLast30d:   last30.orders,
+		RevenuePrior7d:  prior7.revenue,
+		RevenuePrior30d: prior30.revenue,
+		OrdersPrior7d:   prior7.orders,
+		OrdersPrior30d:  prior30.orders,
+		RevenueDelta7d:  last7.revenue - prior7.revenue,
+		RevenueDelta30d: last30.revenue - prior30.revenue,
+		OrdersDelta7d:   last7.orders - prior7.orders,
+		OrdersDelta30d:  last30.orders - prior30.orders,
+	}
+}
+
+func (w *window) add(day *dailyTotal) {
+	w.revenue += day.Revenue
+	w.orders += day.Orders
+}
+
+// dateRange returns the first and last transaction dates present, formatted
+// as YYYY-MM-DD, or empty strings when there are no dated transactions
+func dateRange(dayMap map[string]*dailyTotal) (string, string) {
+	var first, last time.Time
+	for _, day := range dayMap {
+		if first.IsZero() || day.Date.Before(first) {
+			first = day.Date
+		}
+		if day.Date.After(last) {
+			last = day.Date
+		}
+	}
+	if first.IsZero() {
+		return "", ""
+	}
+	return first.Format("2006-01-02"), last.Format("2006-01-02")
+}
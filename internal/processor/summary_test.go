@@ -0,0 +1,81 @@
+package processor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeSummaryWindows(t *testing.T) {
+	asOf := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	dayMap := make(map[string]*dailyTotal)
+	addDay := func(daysBack int, revenue float64, orders int) {
+		date := asOf.AddDate(0, 0, -daysBack)
+		dayMap[date.Format("2006-01-02")] = &dailyTotal{Date: date, Revenue: revenue, Orders: orders}
+	}
+
+	addDay(0, 100, 1)  // last 7d, last 30d
+	addDay(6, 200, 2)  // last 7d, last 30d
+	addDay(7, 400, 4)  // prior 7d, last 30d
+	addDay(13, 800, 8) // prior 7d, last 30d
+	addDay(14, 50, 5)  // last 30d
+	addDay(30, 25, 3)  // prior 30d
+	addDay(60, 999, 9) // outside every window
+
+	summary := computeSummary(dayMap)
+
+	if !summary.AsOf.Equal(asOf) {
+		t.Errorf("Expected AsOf %v, got %v", asOf, summary.AsOf)
+	}
+	if summary.RevenueLast7d != 300 || summary.OrdersLast7d != 3 {
+		t.Errorf("Expected last 7d 300/3, got %f/%d", summary.RevenueLast7d, summary.OrdersLast7d)
+	}
+	if summary.RevenuePrior7d != 1200 || summary.OrdersPrior7d != 12 {
+		t.Errorf("Expected prior 7d 1200/12, got %f/%d", summary.RevenuePrior7d, summary.OrdersPrior7d)
+	}
+	if summary.RevenueLast30d != 1550 || summary.OrdersLast30d != 20 {
+		t.Errorf("Expected last 30d 1550/20, got %f/%d", summary.RevenueLast30d, summary.OrdersLast30d)
+	}
+	if summary.RevenuePrior30d != 25 || summary.OrdersPrior30d != 3 {
+		t.Errorf("Expected prior 30d 25/3, got %f/%d", summary.RevenuePrior30d, summary.OrdersPrior30d)
+	}
+	if summary.RevenueDelta7d != -900 || summary.OrdersDelta7d != -9 {
+		t.Errorf("Expected 7d deltas -900/-9, got %f/%d", summary.RevenueDelta7d, summary.OrdersDelta7d)
+	}
+	if summary.RevenueDelta30d != 1525 || summary.OrdersDelta30d != 17 {
+		t.Errorf("Expected 30d deltas 1525/17, got %f/%d", summary.RevenueDelta30d, summary.OrdersDelta30d)
+	}
+}
+
+func TestComputeSummaryEmpty(t *testing.T) {
+	summary := computeSummary(map[string]*dailyTotal{})
+
+	if !summary.AsOf.IsZero() || summary.RevenueLast30d != 0 {
+		t.Errorf("Expected zero summary for empty data, got %+v", summary)
+	}
+}
+
+func TestProcessDatasetSummaryUsesMaxTransactionDate(t *testing.T) {
+	path := writeTestFile(t, "summary.csv", `transaction_id,transaction_date,country,region,product_name,quantity,total_price
+TXN001,2024-03-31,USA,North America,Laptop,1,100
+TXN002,2024-03-20,USA,North America,Laptop,1,50
+TXN003,2024-01-01,USA,North America,Laptop,1,10
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Failed to process dataset: %v", err)
+	}
+
+	summary := processor.GetSummary()
+	expectedAsOf := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	if !summary.AsOf.Equal(expectedAsOf) {
+		t.Errorf("Expected AsOf %v, got %v", expectedAsOf, summary.AsOf)
+	}
+	if summary.RevenueLast7d != 100 {
+		t.Errorf("Expected revenue last 7d 100, got %f", summary.RevenueLast7d)
+	}
+	if summary.RevenueLast30d != 150 || summary.OrdersLast30d != 2 {
+		t.Errorf("Expected last 30d 150/2, got %f/%d", summary.RevenueLast30d, summary.OrdersLast30d)
+	}
+}
@@ -0,0 +1,45 @@
+package processor
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"math"
+)
+
+// Policies for reconciling total_price with price × quantity
+const (
+	// RecomputeTotalsOff trusts total_price as exported
+	RecomputeTotalsOff = "off"
+	// RecomputeTotalsFill computes the total only when total_price is 0 or missing
+	RecomputeTotalsFill = "fill"
+	// RecomputeTotalsAlways always uses price × quantity and counts rows
+	// whose exported total disagreed
+	RecomputeTotalsAlways = "always"
+)
+
+// totalMismatchTolerance is the largest difference between total_price and
+// price × quantity that is not counted as a mismatch
+const totalMismatchTolerance = 0.01
+
+// SetRecomputeTotals sets the total_price reconciliation policy used while
+// parsing. An empty mode is treated as RecomputeTotalsOff.
+func (p *Processor) SetRecomputeTotals(mode string) {
+	p.recomputeTotals = mode
+}
+
+// applyTotalsPolicy reconciles the transaction's TotalPrice with its Price
+// and Quantity according to the configured policy
+func (p *Processor) applyTotalsPolicy(transaction *models.Transaction) {
+	computed := transaction.Price * float64(transaction.Quantity)
+
+	switch p.recomputeTotals {
+	case RecomputeTotalsFill:
+		if transaction.TotalPrice == 0 {
+			transaction.TotalPrice = computed
+		}
+	case RecomputeTotalsAlways:
+		if math.Abs(transaction.TotalPrice-computed) > totalMismatchTolerance {
+			p.totalMismatches.Add(1)
+		}
+		transaction.TotalPrice = computed
+	}
+}
@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"testing"
+)
+
+const staleTotalsCSV = `transaction_id,transaction_date,user_id,country,region,product_id,product_name,category,price,quantity,total_price,stock_quantity,added_date
+TXN001,2024-01-15,USER001,USA,North America,PROD001,Laptop,Electronics,100,2,200,10,2024-01-01
+TXN002,2024-01-16,USER002,USA,North America,PROD001,Laptop,Electronics,100,3,250,10,2024-01-01
+TXN003,2024-01-17,USER003,USA,North America,PROD001,Laptop,Electronics,100,1,0,10,2024-01-01
+TXN004,2024-01-18,USER004,USA,North America,PROD001,Laptop,Electronics,100,1,,10,2024-01-01
+TXN005,2024-01-19,USER005,USA,North America,PROD001,Laptop,Electronics,33.333,3,100.004,10,2024-01-01
+`
+
+func TestRecomputeTotalsModes(t *testing.T) {
+	testCases := []struct {
+		mode       string
+		revenue    float64
+		mismatches int
+	}{
+		// Exported totals as-is: 200 + 250 + 0 + 0 + 100.004
+		{"", 550.004, 0},
+		{RecomputeTotalsOff, 550.004, 0},
+		// Missing and zero totals filled: 200 + 250 + 100 + 100 + 100.004
+		{RecomputeTotalsFill, 750.004, 0},
+		// Always price × quantity: 200 + 300 + 100 + 100 + 99.999; only
+		// TXN002 is beyond tolerance, the zero and missing totals also count
+		{RecomputeTotalsAlways, 799.999, 3},
+	}
+
+	for _, tc := range testCases {
+		path := writeTestFile(t, "totals.csv", staleTotalsCSV)
+
+		processor := New()
+		processor.SetRecomputeTotals(tc.mode)
+		if err := processor.ProcessDataset(path); err != nil {
+			t.Fatalf("mode %q: failed to process dataset: %v", tc.mode, err)
+		}
+
+		revenues := processor.GetCountryRevenues()
+		if len(revenues) != 1 {
+			t.Fatalf("mode %q: expected 1 country row, got %d", tc.mode, len(revenues))
+		}
+		if diff := revenues[0].TotalRevenue - tc.revenue; diff > 1e-6 || diff < -1e-6 {
+			t.Errorf("mode %q: expected revenue %f, got %f", tc.mode, tc.revenue, revenues[0].TotalRevenue)
+		}
+		if mismatches := processor.GetDashboardData().Report.TotalMismatches; mismatches != tc.mismatches {
+			t.Errorf("mode %q: expected %d mismatches, got %d", tc.mode, tc.mismatches, mismatches)
+		}
+	}
+}
+
+func TestRecomputeTotalsNeedsPriceAndQuantity(t *testing.T) {
+	processor := New()
+	processor.SetRecomputeTotals(RecomputeTotalsAlways)
+
+	headerMap := map[string]int{"price": 0, "quantity": 1, "total_price": 2}
+	transaction, err := processor.parseTransaction([]string{"", "2", "150"}, headerMap)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if transaction.TotalPrice != 150 {
+		t.Errorf("Expected total to be kept without a price, got %f", transaction.TotalPrice)
+	}
+}
@@ -0,0 +1,90 @@
+package processor
+
+import (
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTracedProcessor returns a processor whose spans end up in the returned
+// recorder
+func newTracedProcessor() (*Processor, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	processor := New()
+	processor.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	return processor, recorder
+}
+
+// spanAttribute returns the value of the attribute named key of span
+func spanAttribute(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, attr := range span.Attributes() {
+		if string(attr.Key) == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestProcessDatasetSpans(t *testing.T) {
+	path := writeTestFile(t, "traced.csv", "product_name,quantity,total_price,country\nLaptop,1,100,USA\nPhone,2,50,Canada\n")
+
+	processor, recorder := newTracedProcessor()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	process, ok := spans["processor.process"]
+	if !ok {
+		t.Fatalf("Expected a processor.process span, got %v", recorder.Ended())
+	}
+	if process.Parent().IsValid() {
+		t.Error("Expected processor.process to be a root span")
+	}
+	for _, name := range []string{"processor.read", "processor.aggregate", "processor.finalize"} {
+		if span, ok := spans[name]; !ok || span.Parent().SpanID() != process.SpanContext().SpanID() {
+			t.Errorf("Expected %s to be a child of processor.process", name)
+		}
+	}
+
+	file := spans["processor.read_file"]
+	if file == nil || file.Parent().SpanID() != spans["processor.read"].SpanContext().SpanID() {
+		t.Fatal("Expected processor.read_file to be a child of processor.read")
+	}
+	if spanAttribute(file, "file.path").AsString() != path || spanAttribute(file, "processor.rows").AsInt64() != 2 {
+		t.Errorf("Expected file attributes for %s with 2 rows, got %v", path, file.Attributes())
+	}
+	if size := spanAttribute(file, "file.size").AsInt64(); size == 0 {
+		t.Errorf("Expected the file size to be recorded, got %v", file.Attributes())
+	}
+}
+
+func TestProcessDatasetSpanError(t *testing.T) {
+	processor, recorder := newTracedProcessor()
+	if err := processor.ProcessDataset(writeTestFile(t, "bad.csv", "transaction_id\n")); !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("Expected ErrInvalidHeader, got %v", err)
+	}
+
+	found := false
+	for _, span := range recorder.Ended() {
+		if span.Name() == "processor.process" {
+			found = true
+			if span.Status().Code != codes.Error {
+				t.Errorf("Expected the processor.process span to record the error, got %+v", span.Status())
+			}
+		}
+		if span.Name() == "processor.finalize" {
+			t.Error("Expected no processor.finalize span for a failed run")
+		}
+	}
+	if !found {
+		t.Error("Expected a processor.process span")
+	}
+}
@@ -0,0 +1,96 @@
+package processor
+
+import (
+	"math"
+	"testing"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+const unattributedFixture = `transaction_id,country,region,product_name,quantity,total_price
+TXN001,Germany,Bavaria,Laptop,1,1200
+TXN002,Germany,,Mouse,2,40
+TXN003,,Ontario,Keyboard,1,80
+TXN004,,,Cable,3,15
+TXN005,France,Normandy,Laptop,1,1100
+`
+
+func processUnattributed(t *testing.T, keepBlank bool) *Processor {
+	t.Helper()
+	path := writeTestFile(t, "unattributed.csv", unattributedFixture)
+
+	processor := New()
+	processor.SetKeepBlankLocations(keepBlank)
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return processor
+}
+
+func TestBlankLocationsAreUnattributed(t *testing.T) {
+	data := processUnattributed(t, false).GetDashboardData()
+
+	for _, region := range data.TopRegions {
+		if region.Region == "" {
+			t.Errorf("Expected no blank region in the top regions, got %+v", region)
+		}
+	}
+	for region := range data.RegionProducts {
+		if region == "" {
+			t.Error("Expected no blank region in the region products")
+		}
+	}
+	for _, country := range data.CountryRevenues {
+		if country.Country == "" {
+			t.Errorf("Expected no blank country in the country revenues, got %+v", country)
+		}
+	}
+	for _, summary := range data.CountrySummaries {
+		if summary.Country == "" {
+			t.Errorf("Expected no blank country in the country summaries, got %+v", summary)
+		}
+	}
+
+	want := models.Unattributed{
+		Region:  models.UnattributedTotal{Revenue: 55, TransactionCount: 2},
+		Country: models.UnattributedTotal{Revenue: 95, TransactionCount: 2},
+	}
+	for name, got := range map[string]models.Unattributed{
+		"summary": data.Summary.Unattributed,
+		"report":  data.Report.Unattributed,
+	} {
+		if got.Region.TransactionCount != want.Region.TransactionCount || math.Abs(got.Region.Revenue-want.Region.Revenue) > 1e-9 {
+			t.Errorf("Expected %s unattributed region %+v, got %+v", name, want.Region, got.Region)
+		}
+		if got.Country.TransactionCount != want.Country.TransactionCount || math.Abs(got.Country.Revenue-want.Country.Revenue) > 1e-9 {
+			t.Errorf("Expected %s unattributed country %+v, got %+v", name, want.Country, got.Country)
+		}
+	}
+
+	// Rows without a location are still processed
+	if data.Report.Rows != 5 {
+		t.Errorf("Expected 5 rows, got %d", data.Report.Rows)
+	}
+}
+
+func TestKeepBlankLocations(t *testing.T) {
+	data := processUnattributed(t, true).GetDashboardData()
+
+	var blank *models.RegionRevenue
+	for i := range data.TopRegions {
+		if data.TopRegions[i].Region == "" {
+			blank = &data.TopRegions[i]
+		}
+	}
+	if blank == nil {
+		t.Fatalf("Expected the blank region to be kept, got %+v", data.TopRegions)
+	}
+	if blank.TransactionCount != 2 || math.Abs(blank.TotalRevenue-55) > 1e-9 {
+		t.Errorf("Expected the blank region to hold 2 transactions worth 55, got %+v", *blank)
+	}
+
+	// Kept rows are still reported as unattributed
+	if got := data.Summary.Unattributed.Country.TransactionCount; got != 2 {
+		t.Errorf("Expected 2 unattributed country rows, got %d", got)
+	}
+}
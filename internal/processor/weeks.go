@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"abt-analytics-dashboard/internal/models"
+)
+
+// isoWeekKey returns the ISO 8601 week of t as "2025-W01". Keys sort
+// chronologically as strings.
+func isoWeekKey(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%04d-W%02d", year, week)
+}
+
+// newWeeklySales returns an empty entry for the ISO week containing t
+func newWeeklySales(t time.Time) *models.WeeklySales {
+	year, week := t.ISOWeek()
+	return &models.WeeklySales{
+		Week:       isoWeekKey(t),
+		Year:       year,
+		WeekNumber: week,
+		StartDate:  isoWeekStart(t),
+	}
+}
+
+// isoWeekStart returns midnight UTC on the Monday of the ISO week containing t
+func isoWeekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+	return day.AddDate(0, 0, -offset)
+}
+
+// ParseISOWeek parses a week bound given either as an ISO week ("2025-W01")
+// or as a date ("2025-01-01"), which stands for the ISO week containing it,
+// and returns the week's key
+func ParseISOWeek(value string) (string, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return isoWeekKey(date), nil
+	}
+
+	if len(value) != len("2006-W01") || value[4:6] != "-W" || !isDigits(value[:4]) || !isDigits(value[6:]) {
+		return "", fmt.Errorf("%q is not an ISO week (2025-W01) or a date (2025-01-01)", value)
+	}
+	year, _ := strconv.Atoi(value[:4])
+	week, _ := strconv.Atoi(value[6:])
+
+	// December 28th always falls in the last ISO week of its year
+	if _, weeks := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek(); week < 1 || week > weeks {
+		return "", fmt.Errorf("%d has ISO weeks 1 to %d, got week %d", year, weeks, week)
+	}
+	return fmt.Sprintf("%04d-W%02d", year, week), nil
+}
+
+// sortWeeklySales orders the weekly entries chronologically
+func sortWeeklySales(weekMap map[string]*models.WeeklySales) []models.WeeklySales {
+	weeks := make([]models.WeeklySales, 0, len(weekMap))
+	for _, week := range weekMap {
+		weeks = append(weeks, *week)
+	}
+
+	sort.Slice(weeks, func(i, j int) bool {
+		return weeks[i].Week < weeks[j].Week
+	})
+
+	return weeks
+}
+
+// GetWeeklySales returns the weekly sales from the ISO week from through the
+// ISO week to, both inclusive and given as keys from ParseISOWeek. An empty
+// bound leaves that side open.
+func (p *Processor) GetWeeklySales(from, to string) []models.WeeklySales {
+	data := p.data.Load()
+
+	weeks := make([]models.WeeklySales, 0, len(data.WeeklySales))
+	for _, week := range data.WeeklySales {
+		if (from == "" || week.Week >= from) && (to == "" || week.Week <= to) {
+			weeks = append(weeks, week)
+		}
+	}
+	return weeks
+}
@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWeeklySalesYearBoundary(t *testing.T) {
+	// 2020 has 53 ISO weeks, so 2021-01-03 (a Sunday) still belongs to
+	// 2020-W53. 2024-12-30 (a Monday) starts 2025-W01, which also holds
+	// 2025-01-01, while 2024-12-29 (a Sunday) ends 2024-W52.
+	path := writeTestFile(t, "weeks.csv", `transaction_id,transaction_date,product_name,quantity,total_price
+TXN001,2021-01-03,Laptop,1,100
+TXN002,2021-01-04,Laptop,2,200
+TXN003,2024-12-29,Mouse,1,10
+TXN004,2024-12-30,Mouse,3,30
+TXN005,2025-01-01,Keyboard,1,50
+TXN006,2025-01-05,Keyboard,1,50
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []struct {
+		week        string
+		year        int
+		number      int
+		start       string
+		totalSales  float64
+		salesVolume int
+	}{
+		{"2020-W53", 2020, 53, "2020-12-28", 100, 1},
+		{"2021-W01", 2021, 1, "2021-01-04", 200, 2},
+		{"2024-W52", 2024, 52, "2024-12-23", 10, 1},
+		{"2025-W01", 2025, 1, "2024-12-30", 130, 5},
+	}
+	weeks := processor.GetWeeklySales("", "")
+	if len(weeks) != len(expected) {
+		t.Fatalf("Expected %d weeks, got %+v", len(expected), weeks)
+	}
+	for i, want := range expected {
+		got := weeks[i]
+		if got.Week != want.week || got.Year != want.year || got.WeekNumber != want.number {
+			t.Errorf("Expected week %d to be %s (%d, %d), got %s (%d, %d)",
+				i, want.week, want.year, want.number, got.Week, got.Year, got.WeekNumber)
+		}
+		if start := got.StartDate.Format("2006-01-02"); start != want.start || got.StartDate.Weekday() != time.Monday {
+			t.Errorf("%s: expected start date %s, got %s", want.week, want.start, start)
+		}
+		if got.TotalSales != want.totalSales || got.SalesVolume != want.salesVolume {
+			t.Errorf("%s: expected sales %.0f and volume %d, got %.0f and %d",
+				want.week, want.totalSales, want.salesVolume, got.TotalSales, got.SalesVolume)
+		}
+	}
+
+	filtered := processor.GetWeeklySales("2021-W01", "2024-W52")
+	if len(filtered) != 2 || filtered[0].Week != "2021-W01" || filtered[1].Week != "2024-W52" {
+		t.Errorf("Expected 2021-W01 and 2024-W52 within the bounds, got %+v", filtered)
+	}
+}
+
+func TestWeeklySalesSkipsUndatedRows(t *testing.T) {
+	path := writeTestFile(t, "undated.csv", `transaction_id,transaction_date,product_name,quantity,total_price
+TXN001,,Laptop,1,100
+TXN002,2024-06-12,Laptop,1,100
+`)
+
+	processor := New()
+	if err := processor.ProcessDataset(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if weeks := processor.GetWeeklySales("", ""); len(weeks) != 1 || weeks[0].Week != "2024-W24" {
+		t.Errorf("Expected only 2024-W24, got %+v", weeks)
+	}
+}
+
+func TestParseISOWeek(t *testing.T) {
+	valid := map[string]string{
+		"2025-W01":   "2025-W01",
+		"2020-W53":   "2020-W53",
+		"2024-12-30": "2025-W01",
+		"2021-01-03": "2020-W53",
+		"2024-06-12": "2024-W24",
+	}
+	for value, expected := range valid {
+		week, err := ParseISOWeek(value)
+		if err != nil || week != expected {
+			t.Errorf("ParseISOWeek(%q): expected %s, got %q (%v)", value, expected, week, err)
+		}
+	}
+
+	for _, value := range []string{"2024-W53", "2025-W00", "2025-W1", "2025W01", "2025-w01", "2025-W+1", "last week", ""} {
+		if week, err := ParseISOWeek(value); err == nil {
+			t.Errorf("ParseISOWeek(%q): expected an error, got %s", value, week)
+		}
+	}
+}
+
+func TestSampleDataWeeklySales(t *testing.T) {
+	processor := New()
+	processor.LoadSampleData()
+
+	weeks := processor.GetWeeklySales("", "")
+	if len(weeks) != 52 {
+		t.Fatalf("Expected 52 sample weeks, got %d", len(weeks))
+	}
+	for i := 1; i < len(weeks); i++ {
+		if gap := weeks[i].StartDate.Sub(weeks[i-1].StartDate); gap != 7*24*time.Hour {
+			t.Errorf("Expected consecutive weeks, got %s after %s", weeks[i].Week, weeks[i-1].Week)
+		}
+	}
+}
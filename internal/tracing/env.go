@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Defaults of the OTEL_ settings read by FromEnv
+const (
+	defaultServiceName = "abt-analytics-dashboard"
+	defaultEndpoint    = "http://localhost:4318"
+	tracesPath         = "/v1/traces"
+)
+
+// FromEnv returns a tracer provider exporting over OTLP/HTTP as configured
+// by the standard OpenTelemetry environment variables, or nil when tracing
+// is not configured. Tracing is enabled by OTEL_TRACES_EXPORTER=otlp or by
+// setting OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT,
+// and disabled by OTEL_SDK_DISABLED=true or OTEL_TRACES_EXPORTER=none.
+// Spans are sent protobuf-encoded, so OTEL_EXPORTER_OTLP_PROTOCOL, when
+// set, must be http/protobuf. The exporter, batcher and sampler read the
+// remaining OTEL_ settings, such as headers and timeouts, themselves.
+func FromEnv() (*sdktrace.TracerProvider, error) {
+	if disabled, _ := strconv.ParseBool(os.Getenv("OTEL_SDK_DISABLED")); disabled {
+		return nil, nil
+	}
+
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			endpoint = strings.TrimRight(base, "/") + tracesPath
+		}
+	}
+
+	switch exporter := os.Getenv("OTEL_TRACES_EXPORTER"); exporter {
+	case "none":
+		return nil, nil
+	case "":
+		if endpoint == "" {
+			return nil, nil
+		}
+	case "otlp":
+		if endpoint == "" {
+			endpoint = defaultEndpoint + tracesPath
+		}
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_TRACES_EXPORTER %q (expected otlp or none)", exporter)
+	}
+
+	protocol := firstEnv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL")
+	if protocol != "" && protocol != "http/protobuf" {
+		return nil, fmt.Errorf("unsupported OTLP protocol %q (only http/protobuf is supported)", protocol)
+	}
+
+	ctx := context.Background()
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP exporter: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the default
+	// service name
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(defaultServiceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv())
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTEL_RESOURCE_ATTRIBUTES: %w", err)
+	}
+
+	return sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res)), nil
+}
+
+// Endpoint returns where FromEnv would export spans, for logging
+func Endpoint() string {
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+	if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+		return strings.TrimRight(base, "/") + tracesPath
+	}
+	return defaultEndpoint + tracesPath
+}
+
+// firstEnv returns the first non-empty value of the variables names
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,32 @@
+// Package tracing configures OpenTelemetry tracing for HTTP requests and
+// dataset processing. Spans are recorded by the OpenTelemetry SDK and
+// exported over OTLP/HTTP, so any OpenTelemetry collector can receive
+// them. Tracing is disabled unless configured through the standard OTEL_
+// environment variables; a nil tracer provider stands for disabled.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RecordError records err on span and marks the span as failed; a nil err
+// is ignored
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// TraceIDFromContext returns the trace ID of the span in ctx, or "" when
+// there is none
+func TraceIDFromContext(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
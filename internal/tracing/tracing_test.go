@@ -0,0 +1,139 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRecordError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer("test")
+
+	ctx, span := tracer.Start(context.Background(), "failed")
+	if got := TraceIDFromContext(ctx); got != span.SpanContext().TraceID().String() {
+		t.Errorf("Expected the span's trace ID, got %q", got)
+	}
+	RecordError(span, errors.New("boom"))
+	span.End()
+
+	_, span = tracer.Start(context.Background(), "succeeded")
+	RecordError(span, nil)
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	if status := spans[0].Status(); status.Code != codes.Error || status.Description != "boom" {
+		t.Errorf("Expected an error status, got %+v", status)
+	}
+	if len(spans[0].Events()) != 1 {
+		t.Errorf("Expected the error to be recorded as an event, got %+v", spans[0].Events())
+	}
+	if status := spans[1].Status(); status.Code != codes.Unset {
+		t.Errorf("Expected a nil error to be ignored, got %+v", status)
+	}
+
+	if got := TraceIDFromContext(context.Background()); got != "" {
+		t.Errorf("Expected no trace ID without a span, got %q", got)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("OTEL_SDK_DISABLED", "")
+	t.Setenv("OTEL_TRACES_EXPORTER", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "")
+
+	if provider, err := FromEnv(); provider != nil || err != nil {
+		t.Errorf("Expected tracing to be disabled by default, got %v, %v", provider, err)
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+	provider, err := FromEnv()
+	if provider == nil || err != nil {
+		t.Fatalf("Expected a tracer provider when an endpoint is set, got %v, %v", provider, err)
+	}
+	provider.Shutdown(context.Background())
+	if got := Endpoint(); got != "http://collector:4318/v1/traces" {
+		t.Errorf("Expected the traces path to be appended, got %s", got)
+	}
+
+	t.Setenv("OTEL_SDK_DISABLED", "true")
+	if provider, err := FromEnv(); provider != nil || err != nil {
+		t.Errorf("Expected OTEL_SDK_DISABLED to disable tracing, got %v, %v", provider, err)
+	}
+
+	t.Setenv("OTEL_SDK_DISABLED", "")
+	for _, protocol := range []string{"grpc", "http/json"} {
+		t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", protocol)
+		if _, err := FromEnv(); err == nil {
+			t.Errorf("Expected an error for the %s protocol", protocol)
+		}
+	}
+}
+
+func TestFromEnvExports(t *testing.T) {
+	var request collectortrace.ExportTraceServiceRequest
+	var path string
+	var headers http.Header
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, headers = r.URL.Path, r.Header
+		body, _ := io.ReadAll(r.Body)
+		if err := proto.Unmarshal(body, &request); err != nil {
+			t.Errorf("Failed to parse OTLP request: %v", err)
+		}
+	}))
+	defer backend.Close()
+
+	t.Setenv("OTEL_SDK_DISABLED", "")
+	t.Setenv("OTEL_TRACES_EXPORTER", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/protobuf")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", backend.URL)
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=secret")
+	t.Setenv("OTEL_SERVICE_NAME", "dashboard")
+
+	provider, err := FromEnv()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_, span := provider.Tracer("test").Start(context.Background(), "GET /api/summary")
+	RecordError(span, errors.New("Internal Server Error"))
+	span.End()
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected shutdown to flush without error, got %v", err)
+	}
+
+	if path != tracesPath || headers.Get("api-key") != "secret" || headers.Get("Content-Type") != "application/x-protobuf" {
+		t.Errorf("Expected a protobuf request to %s with the configured headers, got %s %v", tracesPath, path, headers)
+	}
+	if len(request.ResourceSpans) != 1 || len(request.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("Expected one resource and scope, got %+v", &request)
+	}
+	serviceName := ""
+	for _, attr := range request.ResourceSpans[0].Resource.Attributes {
+		if attr.Key == "service.name" {
+			serviceName = attr.Value.GetStringValue()
+		}
+	}
+	if serviceName != "dashboard" {
+		t.Errorf("Expected service.name dashboard, got %q", serviceName)
+	}
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 || spans[0].Name != "GET /api/summary" || spans[0].Status.GetMessage() != "Internal Server Error" {
+		t.Errorf("Expected the failed span, got %+v", spans)
+	}
+}
@@ -3,92 +3,403 @@ package main
 import (
 	"abt-analytics-dashboard/internal/api"
 	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/metrics"
 	"abt-analytics-dashboard/internal/processor"
+	"abt-analytics-dashboard/internal/tracing"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
-	"syscall"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
+	// Embedded so that DATA_TIMEZONE resolves in images without tzdata
+	_ "time/tzdata"
 
 	"github.com/joho/godotenv"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 func main() {
-	// Load .env file
+	// Load .env file, remembering which variables were set before it so
+	// that config reloads keep giving them precedence
+	baseEnv := os.Environ()
 	if err := godotenv.Load(); err != nil {
 		log.Printf("Error loading .env file: %v, using system environment variables", err)
 	} else {
 		log.Println("Successfully loaded .env file")
 	}
 
-	// Load configuration
-	cfg := config.Load()
+	// Load configuration (command-line flags override environment variables)
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == healthCheckSubcommand {
+		args = append([]string{"-healthcheck"}, args[1:]...)
+	}
+	if len(args) > 0 && args[0] == exportSubcommand {
+		args = append([]string{"-export"}, args[1:]...)
+	}
+	cfg, err := config.LoadWithFlags(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	}
+
+	// Probe a running server, e.g. from a container HEALTHCHECK, without
+	// loading any data
+	if cfg.HealthCheck {
+		os.Exit(runHealthCheck(cfg, os.Stdout))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	if cfg.ValidateOnly && (cfg.DataFilePath == "" || cfg.UseSampleData) {
+		log.Fatal("--validate requires a dataset (--data or DATA_FILE_PATH) and cannot be combined with --sample")
+	}
+
+	if cfg.Export && (cfg.DataFilePath == "" || cfg.ExportPath == "") {
+		log.Fatal("export requires a dataset (--data or DATA_FILE_PATH) and an output file (--out)")
+	}
+
+	// Size GOMAXPROCS to the container CPU limit, so a 2-CPU pod on a
+	// large node is not throttled; an explicit GOMAXPROCS still wins
+	if os.Getenv("GOMAXPROCS") == "" {
+		runtime.GOMAXPROCS(processor.DefaultWorkers())
+	}
+
+	// Tracing is configured by the standard OTEL_ environment variables
+	tracerProvider, err := tracing.FromEnv()
+	if err != nil {
+		log.Fatalf("Invalid tracing configuration: %v", err)
+	}
+	if tracerProvider != nil {
+		log.Printf("Exporting traces to %s", tracing.Endpoint())
+	}
+
+	// Processing metrics are served at /metrics when enabled
+	var registry *metrics.Registry
+	if cfg.EnableMetrics {
+		registry = metrics.NewRegistry()
+	}
 
 	// Initialize data processor
-	dataProcessor := processor.New()
+	dataProcessor := processor.New(
+		processor.WithWorkers(cfg.Workers),
+		processor.WithBatchSize(cfg.PipelineBatchSize),
+		processor.WithMaxReadErrors(cfg.MaxReadErrors),
+		processor.WithMaxBadRows(cfg.MaxBadRows),
+		processor.WithMaxBadRowRatio(cfg.MaxBadRowRatio),
+		processor.WithMaxAggregationKeys(cfg.MaxAggregationKeys),
+		processor.WithMaxLineBytes(cfg.CSVMaxLineBytes),
+		processor.WithMetrics(registry),
+	)
+	if tracerProvider != nil {
+		dataProcessor.SetTracerProvider(tracerProvider)
+	}
+	dataProcessor.SetSampleTransactions(cfg.RetainSampleTransactions)
+	dataProcessor.SetAllowEmptyDataset(cfg.AllowEmptyDataset)
+	dataProcessor.SetKeepBlankLocations(cfg.KeepBlankLocations)
+	dataProcessor.SetExclusions(cfg.ExcludeProducts, cfg.ExcludeCountries)
+	dataProcessor.SetOtherBucketThreshold(cfg.OtherBucketThreshold)
+	dataProcessor.SetRecomputeTotals(cfg.RecomputeTotals)
+	dataProcessor.SetProductNameNormalization(cfg.ProductNameNormalization)
+	dataProcessor.SetCSVEncoding(cfg.CSVEncoding)
+	dataProcessor.SetLowStockThreshold(cfg.LowStockThreshold)
+	dataProcessor.SetDateFormats(cfg.DateFormats)
+	dataProcessor.SetDataTimezone(cfg.DataLocation())
+
+	if cfg.StaticDir != "" {
+		if err := api.ValidateStaticDir(cfg.StaticDir); err != nil {
+			log.Fatalf("Invalid frontend build: %v", err)
+		}
+		log.Printf("Serving frontend from %s", cfg.StaticDir)
+	}
 
-	// Process the dataset file if provided
-	if cfg.DataFilePath != "" {
+	if cfg.ConversionRatesFile != "" {
+		rates, err := processor.LoadConversionRates(cfg.ConversionRatesFile)
+		if err != nil {
+			log.Fatalf("Failed to load conversion rates: %v", err)
+		}
+		dataProcessor.SetConversionRates(rates)
+		log.Printf("Loaded conversion rates for %d currencies (base %s)", len(rates.Rates), rates.Base)
+	}
+
+	if cfg.CountryCodesFile != "" {
+		codes, err := processor.LoadCountryCodes(cfg.CountryCodesFile)
+		if err != nil {
+			log.Fatalf("Failed to load country codes: %v", err)
+		}
+		dataProcessor.SetCountryCodes(codes)
+		log.Printf("Loaded %d extra country codes", len(codes))
+	}
+
+	if cfg.RegionAliasesFile != "" {
+		aliases, err := processor.LoadAliases(cfg.RegionAliasesFile)
+		if err != nil {
+			log.Fatalf("Failed to load region aliases: %v", err)
+		}
+		dataProcessor.SetRegionAliases(aliases)
+		log.Printf("Loaded %d region aliases", len(aliases))
+	}
+
+	if cfg.CountryAliasesFile != "" {
+		aliases, err := processor.LoadAliases(cfg.CountryAliasesFile)
+		if err != nil {
+			log.Fatalf("Failed to load country aliases: %v", err)
+		}
+		dataProcessor.SetCountryAliases(aliases)
+		log.Printf("Loaded %d country aliases", len(aliases))
+	}
+
+	// Write the aggregates to a file instead of serving them
+	if cfg.Export {
+		err := runExport(dataProcessor, cfg.DataFilePath, cfg.ExportPath, cfg.ExportPretty)
+		shutdownTracer(tracerProvider)
+		if err != nil {
+			log.Fatalf("Export failed: %s", describeDatasetError(cfg.DataFilePath, err))
+		}
+		log.Printf("Exported aggregates of %s to %s", cfg.DataFilePath, cfg.ExportPath)
+		return
+	}
+
+	// Load what DATA_MODE selects; without it the mode is inferred from
+	// DATA_FILE_PATH and --sample, falling back to sample data in development
+	switch cfg.ResolvedDataMode() {
+	case config.DataModeDataset:
 		log.Printf("Processing dataset from: %s", cfg.DataFilePath)
 		start := time.Now()
 
-		if err := dataProcessor.ProcessDataset(cfg.DataFilePath); err != nil {
-			log.Fatalf("Failed to process dataset: %v", err)
+		err := dataProcessor.ProcessDataset(cfg.DataFilePath)
+		switch {
+		case errors.Is(err, processor.ErrEmptyDataset) && cfg.IsDevelopment() && cfg.DataMode == "" && !cfg.ValidateOnly:
+			// An empty export should not stop local development
+			log.Printf("Warning: %s; using sample data for development", describeDatasetError(cfg.DataFilePath, err))
+			dataProcessor.LoadSampleData()
+		case err != nil:
+			log.Fatalf("Failed to process dataset: %s", describeDatasetError(cfg.DataFilePath, err))
+		default:
+			duration := time.Since(start)
+			log.Printf("Dataset processed successfully in %v", duration)
+
+			if cfg.LogSummary {
+				log.Print(processor.FormatLoadSummary(dataProcessor.GetDashboardData()))
+			}
 		}
 
-		duration := time.Since(start)
-		log.Printf("Dataset processed successfully in %v", duration)
-	} else {
-		log.Println("No dataset file provided. Using sample data for development.")
+		if cfg.ValidateOnly {
+			log.Printf("Dataset is valid: %d records", dataProcessor.GetDashboardData().RecordCount)
+			shutdownTracer(tracerProvider)
+			return
+		}
+	case config.DataModeSample:
+		switch {
+		case cfg.DataMode == config.DataModeSample:
+			log.Println("DATA_MODE=sample: serving generated sample data, flagged with sample_data in every response")
+		case cfg.UseSampleData:
+			log.Println("Sample data requested. Using sample data.")
+		default:
+			log.Println("No dataset file provided. Using sample data for development; set DATA_MODE=sample to make this explicit.")
+		}
 		dataProcessor.LoadSampleData()
+	case config.DataModeEmpty:
+		log.Println("DATA_MODE=empty: serving empty aggregates")
+		dataProcessor.LoadEmptyData()
+	default:
+		log.Fatalf("No dataset configured for %s; set DATA_FILE_PATH, or DATA_MODE=sample or DATA_MODE=empty to run without one", cfg.Environment)
 	}
 
 	// Initialize API server
 	server := api.NewServer(dataProcessor, cfg)
+	server.WatchEnvFile(".env", baseEnv)
+	if tracerProvider != nil {
+		server.SetTracerProvider(tracerProvider)
+	}
+	server.SetMetrics(registry)
 
-	// Setup graceful shutdown
-	serverCtx, serverStopCtx := context.WithCancel(context.Background())
+	// SIGHUP reloads the dataset; reloads requested while one runs are coalesced
+	reloads := newReloadQueue(func() {
+		reloadDataset(dataProcessor, cfg)
+	})
 
-	// Listen for syscall signals for process to interrupt/quit
+	// Listen for syscall signals for process to reload or interrupt/quit
 	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-	go func() {
-		<-sig
+	signal.Notify(sig, append(append(reloadSignals, configSignals...), shutdownSignals...)...)
+
+	// Run the server
+	if cfg.ListenSocket != "" {
+		log.Printf("Starting server on unix socket %s", cfg.ListenSocket)
+	} else {
+		log.Printf("Starting server on port %s", cfg.Port)
+		log.Printf("Server running at http://localhost%s", cfg.Port)
+	}
+
+	timeout := cfg.ShutdownTimeout
+	if timeout == 0 {
+		timeout = config.DefaultShutdownTimeout
+	}
+	handlers := signalHandlers{
+		reload: reloads.trigger,
+		reloadConfig: func() {
+			log.Println("Received SIGUSR1, reloading configuration")
+			server.ReloadConfig()
+		},
+	}
+	err = run(server, sig, handlers, timeout)
+	shutdownTracer(tracerProvider)
+	if err != nil {
+		log.Print(err)
+		os.Exit(exitCode(err))
+	}
+	fmt.Println("Server stopped gracefully")
+}
+
+// tracerShutdownTimeout bounds how long exiting waits to export the
+// remaining spans
+const tracerShutdownTimeout = 5 * time.Second
+
+// shutdownTracer exports the spans still queued before the process exits
+func shutdownTracer(provider *sdktrace.TracerProvider) {
+	if provider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tracerShutdownTimeout)
+	defer cancel()
+	if err := provider.Shutdown(ctx); err != nil {
+		log.Printf("Warning: failed to export remaining spans: %v", err)
+	}
+}
 
-		// Trigger graceful shutdown
-		shutdownCtx, cancel := context.WithTimeout(serverCtx, 30*time.Second)
-		defer cancel()
+// Exit codes for a server that stopped with an error. Startup failures keep
+// using log.Fatal, which exits with 1.
+const (
+	exitServeFailed     = 1
+	exitShutdownTimeout = 3
+)
+
+// errShutdownTimeout is returned by run when in-flight requests did not
+// finish within the shutdown timeout
+var errShutdownTimeout = errors.New("graceful shutdown timed out")
+
+// httpServer is the part of api.Server that run drives
+type httpServer interface {
+	ListenAndServe() error
+	Shutdown(ctx context.Context) error
+}
 
-		go func() {
-			<-shutdownCtx.Done()
-			if shutdownCtx.Err() == context.DeadlineExceeded {
-				log.Fatal("graceful shutdown timed out.. forcing exit.")
+// run serves srv until a shutdown signal arrives on sig, passing other
+// signals to handlers, then shuts the server down gracefully, waiting up to
+// timeout for in-flight requests. It returns nil when every connection
+// drained, an error wrapping errShutdownTimeout when requests were still
+// running at the deadline, and the listener's error when serving failed.
+// Cleanup deferred here runs in every case, which log.Fatal would skip.
+func run(srv httpServer, sig <-chan os.Signal, handlers signalHandlers, timeout time.Duration) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	for {
+		select {
+		case err := <-serveErr:
+			return fmt.Errorf("server stopped unexpectedly: %w", err)
+		case s := <-sig:
+			handlers.shutdown = func() {
+				log.Printf("Received %v, shutting down (waiting up to %v for in-flight requests)", s, timeout)
+			}
+			if dispatchSignal(s, handlers) {
+				return shutdown(srv, serveErr, timeout)
 			}
-		}()
+		}
+	}
+}
 
-		// Trigger graceful shutdown
-		err := server.Shutdown(shutdownCtx)
-		if err != nil {
-			log.Fatal(err)
+// shutdown stops srv, giving in-flight requests until timeout to finish,
+// and logs whether the server drained cleanly
+func shutdown(srv httpServer, serveErr <-chan error, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("Shutdown did not drain: requests were still in flight after %v", timeout)
+			return fmt.Errorf("%w after %v", errShutdownTimeout, timeout)
 		}
-		serverStopCtx()
-	}()
+		return fmt.Errorf("shutdown failed: %w", err)
+	}
 
-	// Run the server
-	log.Printf("Starting server on port %s", cfg.Port)
-	log.Printf("Server running at http://localhost%s", cfg.Port)
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server stopped unexpectedly: %w", err)
+	}
+	log.Println("Shutdown drained cleanly: all in-flight requests finished")
+	return nil
+}
 
-	err := server.ListenAndServe()
-	if err != nil && err != http.ErrServerClosed {
-		log.Fatal(err)
+// exitCode maps an error returned by run to the process exit code
+func exitCode(err error) int {
+	if errors.Is(err, errShutdownTimeout) {
+		return exitShutdownTimeout
 	}
+	return exitServeFailed
+}
 
-	// Wait for server context to be stopped
-	<-serverCtx.Done()
-	fmt.Println("Server stopped gracefully")
+// reloadDataset reprocesses the configured dataset in response to SIGHUP.
+// Failures are logged and the previously loaded data keeps being served.
+func reloadDataset(dataProcessor *processor.Processor, cfg *config.Config) {
+	if cfg.DataFilePath == "" || cfg.UseSampleData {
+		log.Println("Received SIGHUP but no dataset is configured; nothing to reload")
+		return
+	}
+
+	log.Printf("Received SIGHUP, reloading dataset from: %s", cfg.DataFilePath)
+	start := time.Now()
+
+	if err := dataProcessor.Reload(cfg.DataFilePath); err != nil {
+		log.Printf("Dataset reload failed, keeping previous data: %s", describeDatasetError(cfg.DataFilePath, err))
+		return
+	}
+
+	log.Printf("Dataset reloaded successfully in %v", time.Since(start))
+	if cfg.LogSummary {
+		log.Print(processor.FormatLoadSummary(dataProcessor.GetDashboardData()))
+	}
 }
 
+// describeDatasetError turns dataset path errors into actionable messages
+func describeDatasetError(dataFilePath string, err error) string {
+	absPath, absErr := filepath.Abs(dataFilePath)
+	if absErr != nil {
+		absPath = dataFilePath
+	}
 
+	var headerErr *processor.InvalidHeaderError
+	switch {
+	case errors.Is(err, processor.ErrNotFound):
+		return fmt.Sprintf("dataset %s does not exist; check DATA_FILE_PATH or --data", absPath)
+	case errors.Is(err, processor.ErrNotAFile):
+		return fmt.Sprintf("dataset %s is not a regular file; point DATA_FILE_PATH at a CSV file, directory or glob pattern", absPath)
+	case errors.Is(err, processor.ErrPermission):
+		return fmt.Sprintf("dataset %s is not readable; check the file permissions for the user running the server", absPath)
+	case errors.Is(err, processor.ErrTooManyReadErrors):
+		return fmt.Sprintf("reading dataset %s kept failing (%v); check the storage it lives on or raise MAX_READ_ERRORS", absPath, err)
+	case errors.As(err, &headerErr):
+		return fmt.Sprintf("dataset %s is missing the required CSV columns %s", absPath, strings.Join(headerErr.Missing, ", "))
+	case errors.Is(err, processor.ErrTooManyBadRows):
+		return fmt.Sprintf("dataset %s has too many malformed rows (%v); fix the export or raise MAX_BAD_ROWS or MAX_BAD_ROW_RATIO", absPath, err)
+	case errors.Is(err, processor.ErrEmptyDataset):
+		return fmt.Sprintf("dataset %s has no data rows; check the export or set ALLOW_EMPTY_DATASET=true to serve it empty", absPath)
+	case errors.Is(err, processor.ErrAborted):
+		return fmt.Sprintf("processing dataset %s was aborted: %v", absPath, err)
+	case errors.Is(err, processor.ErrProcessingInProgress):
+		return fmt.Sprintf("dataset %s was not processed because another load was still running", absPath)
+	default:
+		return err.Error()
+	}
+}
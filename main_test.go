@@ -1,9 +1,13 @@
 package main
 
 import (
+	"abt-analytics-dashboard/internal/processor"
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -253,3 +257,26 @@ func TestBooleanOperations(t *testing.T) {
 	}
 }
 
+func TestDescribeDatasetError(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected string
+	}{
+		{fmt.Errorf("%w: /data/x.csv", processor.ErrNotFound), "does not exist"},
+		{fmt.Errorf("%w: /data", processor.ErrNotAFile), "is not a regular file"},
+		{fmt.Errorf("%w: /data/x.csv", processor.ErrPermission), "is not readable"},
+		{fmt.Errorf("%w: %w", processor.ErrTooManyReadErrors, errors.New("stale handle")), "MAX_READ_ERRORS"},
+		{fmt.Errorf("x.csv: %w", &processor.InvalidHeaderError{Missing: []string{"quantity", "total_price"}}), "columns quantity, total_price"},
+		{fmt.Errorf("%w: 20 rows skipped", processor.ErrTooManyBadRows), "MAX_BAD_ROWS"},
+		{fmt.Errorf("%w: x.csv", processor.ErrEmptyDataset), "ALLOW_EMPTY_DATASET"},
+		{fmt.Errorf("%w: %w", processor.ErrAborted, context.Canceled), "aborted"},
+		{errors.New("boom"), "boom"},
+	}
+
+	for _, tc := range testCases {
+		message := describeDatasetError("x.csv", tc.err)
+		if !strings.Contains(message, tc.expected) {
+			t.Errorf("Expected message to contain '%s', got '%s'", tc.expected, message)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+// Package client is a typed Go client for the analytics dashboard API. It
+// decodes the response envelopes into the models types and reports API
+// errors as *APIError.
+package client
+
+import (
+	"abt-analytics-dashboard/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBodyBytes bounds how much of an error response is read
+const maxErrorBodyBytes = 1 << 20
+
+// Meta is the metadata object of a response envelope. Fields that do not
+// apply to an endpoint are left zero.
+type Meta struct {
+	Description       string     `json:"description"`
+	UpdatedAt         *time.Time `json:"updated_at"`
+	ReportingCurrency string     `json:"reporting_currency"`
+	SampleData        bool       `json:"sample_data"`
+	Total             int        `json:"total"`
+	TotalItems        int        `json:"total_items"`
+	TotalPages        int        `json:"total_pages"`
+	Page              int        `json:"page"`
+	PageSize          int        `json:"page_size"`
+	HasNext           bool       `json:"has_next"`
+	HasPrev           bool       `json:"has_prev"`
+	OutOfRange        bool       `json:"out_of_range"`
+	SortBy            string     `json:"sort_by"`
+	Shape             string     `json:"shape"`
+	RankBy            string     `json:"rank_by"`
+	Order             string     `json:"order"`
+	From              string     `json:"from"`
+	To                string     `json:"to"`
+	DataStartDate     *time.Time `json:"data_start_date"`
+	DataEndDate       *time.Time `json:"data_end_date"`
+	Truncated         bool       `json:"truncated"`
+	TotalAvailable    int        `json:"total_available"`
+}
+
+// HasMore reports whether more entries exist than the response holds,
+// either on a later page or beyond a truncated ranking such as the top
+// products; TotalAvailable then counts them for a truncated ranking
+func (m Meta) HasMore() bool {
+	return m.Truncated || m.HasNext
+}
+
+// ListResponse is the envelope of list endpoints
+type ListResponse[T any] struct {
+	Count int  `json:"count"`
+	Data  []T  `json:"data"`
+	Meta  Meta `json:"meta"`
+}
+
+// Response is the envelope of endpoints that return a single object
+type Response[T any] struct {
+	Data T    `json:"data"`
+	Meta Meta `json:"meta"`
+}
+
+// FieldError describes one invalid field of a rejected request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// APIError is an error response from the API. Errors lists the invalid
+// fields of a 400 validation error, whether it came as problem details or
+// as the legacy error envelope.
+type APIError struct {
+	StatusCode int          `json:"-"`
+	Message    string       `json:"message"`
+	Errors     []FieldError `json:"errors"`
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("api error %d: %s", e.StatusCode, e.Message)
+	}
+	fields := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		fields[i] = fe.Field + ": " + fe.Message
+	}
+	return fmt.Sprintf("api error %d: %s (%s)", e.StatusCode, e.Message, strings.Join(fields, "; "))
+}
+
+// IsNotFound reports whether err is an API error with status 404
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// Client calls the API at a base URL such as "http://localhost:8080".
+// Requests are bounded by the context passed to each method.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New creates a client for the API at baseURL. A nil httpClient uses
+// http.DefaultClient.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), httpClient: httpClient}
+}
+
+// GetDashboard returns all dashboard data
+func (c *Client) GetDashboard(ctx context.Context) (*Response[*models.DashboardData], error) {
+	return get[Response[*models.DashboardData]](ctx, c, "/api/dashboard", nil)
+}
+
+// GetSummary returns the rolling 7-day and 30-day revenue summary
+func (c *Client) GetSummary(ctx context.Context) (*Response[models.Summary], error) {
+	return get[Response[models.Summary]](ctx, c, "/api/summary", nil)
+}
+
+// GetDimensions returns the distinct countries, regions, categories and
+// currencies of the current data, for filter dropdowns
+func (c *Client) GetDimensions(ctx context.Context) (*Response[models.Dimensions], error) {
+	return get[Response[models.Dimensions]](ctx, c, "/api/dimensions", nil)
+}
+
+// GetProcessingReport returns the report of the processing run that
+// produced the current data
+func (c *Client) GetProcessingReport(ctx context.Context) (*Response[models.ProcessingReport], error) {
+	return get[Response[models.ProcessingReport]](ctx, c, "/api/processing-report", nil)
+}
+
+// GetCountryRevenues returns the country revenue rows matching query. Zero
+// fields of query use the server defaults; query.Shape is ignored.
+func (c *Client) GetCountryRevenues(ctx context.Context, query models.CountryRevenueQuery) (*ListResponse[models.CountryRevenue], error) {
+	return get[ListResponse[models.CountryRevenue]](ctx, c, "/api/revenue-by-country", countryRevenueParams(query))
+}
+
+// GetCountryRevenueGroups returns the country revenue rows matching the
+// filters of query nested by country; pages count countries
+func (c *Client) GetCountryRevenueGroups(ctx context.Context, query models.CountryRevenueQuery) (*ListResponse[models.CountryRevenueGroup], error) {
+	params := countryRevenueParams(query)
+	params.Set("shape", models.ShapeNested)
+	return get[ListResponse[models.CountryRevenueGroup]](ctx, c, "/api/revenue-by-country", params)
+}
+
+// countryRevenueParams encodes the non-zero fields of query, other than
+// its shape, as query parameters
+func countryRevenueParams(query models.CountryRevenueQuery) url.Values {
+	params := url.Values{}
+	if len(query.Countries) > 0 {
+		params.Set("countries", strings.Join(query.Countries, ","))
+	}
+	if len(query.Products) > 0 {
+		params.Set("products", strings.Join(query.Products, ","))
+	}
+	if query.MinRevenue != 0 {
+		params.Set("min_revenue", strconv.FormatFloat(query.MinRevenue, 'f', -1, 64))
+	}
+	if query.MinAvgOrder != nil {
+		params.Set("min_avg_order", strconv.FormatFloat(*query.MinAvgOrder, 'f', -1, 64))
+	}
+	if query.MaxAvgOrder != nil {
+		params.Set("max_avg_order", strconv.FormatFloat(*query.MaxAvgOrder, 'f', -1, 64))
+	}
+	if query.SortBy != "" {
+		params.Set("sort_by", query.SortBy)
+	}
+	if query.Order != "" {
+		params.Set("order", query.Order)
+	}
+	if query.Page != 0 {
+		params.Set("page", strconv.Itoa(query.Page))
+	}
+	if query.PageSize != 0 {
+		params.Set("page_size", strconv.Itoa(query.PageSize))
+	}
+	return params
+}
+
+// EachCountryRevenuePage calls fn with every page of the country revenue
+// rows matching query, starting at query.Page, until the last page or the
+// first error. Returning an error from fn stops the iteration and is
+// returned as is.
+func (c *Client) EachCountryRevenuePage(ctx context.Context, query models.CountryRevenueQuery, fn func(*ListResponse[models.CountryRevenue]) error) error {
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	for {
+		page, err := c.GetCountryRevenues(ctx, query)
+		if err != nil {
+			return err
+		}
+		if page.Meta.OutOfRange {
+			return nil
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if !page.Meta.HasNext {
+			return nil
+		}
+		query.Page = page.Meta.Page + 1
+	}
+}
+
+// AllCountryRevenues returns every country revenue row matching query,
+// fetching pages of query.PageSize rows from query.Page on
+func (c *Client) AllCountryRevenues(ctx context.Context, query models.CountryRevenueQuery) ([]models.CountryRevenue, error) {
+	rows := make([]models.CountryRevenue, 0)
+	err := c.EachCountryRevenuePage(ctx, query, func(page *ListResponse[models.CountryRevenue]) error {
+		rows = append(rows, page.Data...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetTopProducts returns the top products, ranked by orders or units as
+// filter.RankBy selects, narrowed by the filter's stock conditions
+func (c *Client) GetTopProducts(ctx context.Context, filter models.TopProductsFilter) (*ListResponse[models.ProductFrequency], error) {
+	params := url.Values{}
+	if filter.MaxStock != nil {
+		params.Set("max_stock", strconv.Itoa(*filter.MaxStock))
+	}
+	if filter.OutOfStock {
+		params.Set("out_of_stock", "true")
+	}
+	if !filter.ActiveSince.IsZero() {
+		params.Set("active_since", filter.ActiveSince.Format("2006-01-02"))
+	}
+	if filter.RankBy != "" {
+		params.Set("rank_by", filter.RankBy)
+	}
+
+	return get[ListResponse[models.ProductFrequency]](ctx, c, "/api/top-products", params)
+}
+
+// GetStockPressure returns the products sold while out of stock or with
+// less stock than the quantity ordered, highest revenue first
+func (c *Client) GetStockPressure(ctx context.Context) (*ListResponse[models.StockPressure], error) {
+	return get[ListResponse[models.StockPressure]](ctx, c, "/api/stock-pressure", nil)
+}
+
+// SearchProducts returns products whose names match query, best matches
+// first. A zero limit uses the server default.
+func (c *Client) SearchProducts(ctx context.Context, query string, limit int) (*ListResponse[models.ProductFrequency], error) {
+	params := url.Values{"q": {query}}
+	if limit != 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	return get[ListResponse[models.ProductFrequency]](ctx, c, "/api/products/search", params)
+}
+
+// GetMonthlySales returns monthly sales in chronological order
+func (c *Client) GetMonthlySales(ctx context.Context) (*ListResponse[models.MonthlySales], error) {
+	return get[ListResponse[models.MonthlySales]](ctx, c, "/api/sales-by-month", nil)
+}
+
+// GetWeeklySales returns ISO week sales between the inclusive from and to
+// weeks, given as "2025-W01" or a date. Empty bounds are open.
+func (c *Client) GetWeeklySales(ctx context.Context, from, to string) (*ListResponse[models.WeeklySales], error) {
+	params := url.Values{}
+	if from != "" {
+		params.Set("from", from)
+	}
+	if to != "" {
+		params.Set("to", to)
+	}
+
+	return get[ListResponse[models.WeeklySales]](ctx, c, "/api/sales-by-week", params)
+}
+
+// GetTopRegions returns the top regions by revenue
+func (c *Client) GetTopRegions(ctx context.Context) (*ListResponse[models.RegionRevenue], error) {
+	return c.GetTopRegionsRankedBy(ctx, "")
+}
+
+// GetTopRegionsRankedBy returns the top regions ranked by rankBy,
+// models.RankByRevenue or models.RankByItems. An empty rankBy uses the
+// server default, revenue.
+func (c *Client) GetTopRegionsRankedBy(ctx context.Context, rankBy string) (*ListResponse[models.RegionRevenue], error) {
+	params := url.Values{}
+	if rankBy != "" {
+		params.Set("rank_by", rankBy)
+	}
+
+	return get[ListResponse[models.RegionRevenue]](ctx, c, "/api/top-regions", params)
+}
+
+// GetRegionProducts returns the best-selling products within region. A
+// zero limit uses the server default.
+func (c *Client) GetRegionProducts(ctx context.Context, region string, limit int) (*ListResponse[models.RegionProduct], error) {
+	params := url.Values{}
+	if limit != 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	return get[ListResponse[models.RegionProduct]](ctx, c, "/api/regions/"+url.PathEscape(region)+"/products", params)
+}
+
+// GetCategoryProducts returns the best-selling products within category,
+// which is matched case-insensitively. A zero limit uses the server default.
+func (c *Client) GetCategoryProducts(ctx context.Context, category string, limit int) (*ListResponse[models.CategoryProduct], error) {
+	params := url.Values{}
+	if limit != 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	return get[ListResponse[models.CategoryProduct]](ctx, c, "/api/categories/"+url.PathEscape(category)+"/top-products", params)
+}
+
+// GetCountrySummaries returns the per-country rollup ordered by revenue
+func (c *Client) GetCountrySummaries(ctx context.Context) (*ListResponse[models.CountrySummary], error) {
+	return get[ListResponse[models.CountrySummary]](ctx, c, "/api/countries", nil)
+}
+
+// GetCountryDetail returns one country's totals, top products and monthly
+// sales. Unknown countries return an error for which IsNotFound is true.
+func (c *Client) GetCountryDetail(ctx context.Context, country string) (*Response[models.CountryDetail], error) {
+	return get[Response[models.CountryDetail]](ctx, c, "/api/countries/"+url.PathEscape(country), nil)
+}
+
+// GetCountryTopCustomers returns the users who spent the most in a
+// country. Unknown countries return an error for which IsNotFound is true.
+func (c *Client) GetCountryTopCustomers(ctx context.Context, country string) (*ListResponse[models.CountryCustomer], error) {
+	return get[ListResponse[models.CountryCustomer]](ctx, c, "/api/countries/"+url.PathEscape(country)+"/top-customers", nil)
+}
+
+// get requests path with params and decodes the JSON envelope. Non-2xx
+// responses are returned as *APIError.
+func get[T any](ctx context.Context, c *Client, path string, params url.Values) (*T, error) {
+	target := c.baseURL + path
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, decodeAPIError(resp)
+	}
+
+	var out T
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode %s response: %w", path, err)
+	}
+	return &out, nil
+}
+
+// problemDetails is the problem details body (RFC 7807) of a 400 response
+type problemDetails struct {
+	Title         string `json:"title"`
+	InvalidParams []struct {
+		Name   string `json:"name"`
+		Reason string `json:"reason"`
+	} `json:"invalid_params"`
+}
+
+// decodeAPIError reads the error envelope or problem details of a failed
+// response, falling back to the status text when the body is neither
+func decodeAPIError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+
+	var err error
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/problem+json") {
+		err = decodeProblem(body, apiErr)
+	} else {
+		err = json.Unmarshal(body, apiErr)
+	}
+	if err != nil || apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+// decodeProblem fills apiErr from a problem details body, reporting each
+// invalid parameter as a field error
+func decodeProblem(body []byte, apiErr *APIError) error {
+	var problem problemDetails
+	if err := json.Unmarshal(body, &problem); err != nil {
+		return err
+	}
+	apiErr.Message = problem.Title
+	for _, param := range problem.InvalidParams {
+		apiErr.Errors = append(apiErr.Errors, FieldError{Field: param.Name, Message: param.Reason})
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package client
+
+import (
+	"abt-analytics-dashboard/internal/api"
+	"abt-analytics-dashboard/internal/config"
+	"abt-analytics-dashboard/internal/models"
+	"abt-analytics-dashboard/internal/processor"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestClient runs the real API over sample data and returns a client
+// for it
+func newTestClient(t *testing.T) (*Client, *processor.Processor) {
+	t.Helper()
+
+	proc := processor.New()
+	proc.LoadSampleData()
+	server := httptest.NewServer(api.NewServer(proc, &config.Config{Port: ":8080"}).Handler())
+	t.Cleanup(server.Close)
+
+	return New(server.URL+"/", server.Client()), proc
+}
+
+func TestClientGetDashboard(t *testing.T) {
+	client, proc := newTestClient(t)
+
+	response, err := client.GetDashboard(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := proc.GetDashboardData()
+	if response.Data == nil || response.Data.DataSource != expected.DataSource ||
+		len(response.Data.TopRegions) != len(expected.TopRegions) {
+		t.Errorf("Expected the served dashboard data, got %+v", response.Data)
+	}
+}
+
+func TestClientGetCountryRevenues(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	response, err := client.GetCountryRevenues(context.Background(), models.CountryRevenueQuery{
+		Countries: []string{"USA"},
+		SortBy:    processor.SortByProductName,
+		PageSize:  5,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.Count == 0 || response.Count > 5 || response.Meta.PageSize != 5 || response.Meta.SortBy != "product_name" {
+		t.Fatalf("Expected a page of at most 5 rows sorted by product, got %d rows with meta %+v", response.Count, response.Meta)
+	}
+	for _, row := range response.Data {
+		if row.Country != "USA" {
+			t.Errorf("Expected only USA rows, got %s", row.Country)
+		}
+	}
+}
+
+func TestClientGetCountryRevenueGroups(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	response, err := client.GetCountryRevenueGroups(context.Background(), models.CountryRevenueQuery{Countries: []string{"USA"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.Count != 1 || response.Data[0].Country != "USA" || len(response.Data[0].Products) == 0 || response.Meta.Shape != models.ShapeNested {
+		t.Errorf("Expected the USA products nested, got %+v", response)
+	}
+}
+
+func TestClientCountryRevenuePages(t *testing.T) {
+	client, proc := newTestClient(t)
+	ctx := context.Background()
+	total := len(proc.GetCountryRevenues())
+
+	pages := 0
+	err := client.EachCountryRevenuePage(ctx, models.CountryRevenueQuery{PageSize: 7}, func(page *ListResponse[models.CountryRevenue]) error {
+		pages++
+		if page.Meta.Page != pages || page.Meta.TotalItems != total {
+			t.Errorf("Expected page %d of %d items, got %+v", pages, total, page.Meta)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if expected := (total + 6) / 7; pages != expected {
+		t.Errorf("Expected %d pages, got %d", expected, pages)
+	}
+
+	rows, err := client.AllCountryRevenues(ctx, models.CountryRevenueQuery{PageSize: 7})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rows) != total {
+		t.Errorf("Expected all %d rows, got %d", total, len(rows))
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = client.EachCountryRevenuePage(ctx, models.CountryRevenueQuery{PageSize: 7}, func(*ListResponse[models.CountryRevenue]) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("Expected iteration to stop with the callback error after one page, got %v after %d calls", err, calls)
+	}
+
+	rows, err = client.AllCountryRevenues(ctx, models.CountryRevenueQuery{Page: 999, PageSize: 7})
+	if err != nil || len(rows) != 0 {
+		t.Errorf("Expected no rows past the last page, got %d rows and %v", len(rows), err)
+	}
+}
+
+func TestClientGetTopProducts(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	response, err := client.GetTopProducts(context.Background(), models.TopProductsFilter{OutOfStock: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.Count == 0 {
+		t.Fatal("Expected out-of-stock sample products")
+	}
+	for _, product := range response.Data {
+		if product.CurrentStock != 0 {
+			t.Errorf("Expected only out-of-stock products, got %+v", product)
+		}
+	}
+}
+
+func TestMetaHasMore(t *testing.T) {
+	for _, tc := range []struct {
+		meta     Meta
+		expected bool
+	}{
+		{Meta{}, false},
+		{Meta{Truncated: true, TotalAvailable: 45}, true},
+		{Meta{HasNext: true}, true},
+		{Meta{HasPrev: true}, false},
+	} {
+		if got := tc.meta.HasMore(); got != tc.expected {
+			t.Errorf("Expected HasMore %v for %+v, got %v", tc.expected, tc.meta, got)
+		}
+	}
+}
+
+func TestClientTopProductsHasMore(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	response, err := client.GetTopProducts(context.Background(), models.TopProductsFilter{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.Meta.HasMore() || response.Meta.TotalAvailable != 0 {
+		t.Errorf("Expected every sample product to be returned, got %d with meta %+v", response.Count, response.Meta)
+	}
+}
+
+func TestClientGetTopRegionsRankedBy(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	response, err := client.GetTopRegionsRankedBy(context.Background(), models.RankByItems)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if response.Meta.RankBy != models.RankByItems || response.Count == 0 {
+		t.Fatalf("Expected regions ranked by items, got %+v", response.Meta)
+	}
+	for i := 1; i < len(response.Data); i++ {
+		if response.Data[i].ItemsSold > response.Data[i-1].ItemsSold {
+			t.Errorf("Expected regions in descending items sold, got %+v", response.Data)
+			break
+		}
+	}
+}
+
+func TestClientListEndpoints(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	if response, err := client.GetMonthlySales(ctx); err != nil || response.Count == 0 {
+		t.Errorf("Expected monthly sales, got %v", err)
+	}
+	if response, err := client.GetWeeklySales(ctx, "", ""); err != nil || response.Count == 0 {
+		t.Errorf("Expected weekly sales, got %v", err)
+	}
+	if response, err := client.GetTopRegions(ctx); err != nil || response.Count == 0 {
+		t.Errorf("Expected top regions, got %v", err)
+	}
+	if response, err := client.GetRegionProducts(ctx, "North America", 3); err != nil || response.Count != 3 {
+		t.Errorf("Expected 3 region products, got %v", err)
+	}
+	if response, err := client.GetCountrySummaries(ctx); err != nil || response.Count == 0 {
+		t.Errorf("Expected country summaries, got %v", err)
+	}
+	if response, err := client.GetCountryDetail(ctx, "Germany"); err != nil || response.Data.Country != "Germany" {
+		t.Errorf("Expected the Germany detail, got %v", err)
+	}
+	if response, err := client.SearchProducts(ctx, "laptop", 5); err != nil || response.Count == 0 {
+		t.Errorf("Expected search results, got %v", err)
+	}
+	if response, err := client.GetSummary(ctx); err != nil || response.Meta.Description == "" {
+		t.Errorf("Expected the summary envelope, got %v", err)
+	}
+	if response, err := client.GetProcessingReport(ctx); err != nil || response.Meta.Description == "" {
+		t.Errorf("Expected the processing report envelope, got %v", err)
+	}
+	if response, err := client.GetDimensions(ctx); err != nil || len(response.Data.Countries) == 0 {
+		t.Errorf("Expected dimension values, got %v", err)
+	}
+	if response, err := client.GetStockPressure(ctx); err != nil || response.Count == 0 {
+		t.Errorf("Expected stock pressure for the out of stock products, got %v", err)
+	}
+	if response, err := client.GetCountryTopCustomers(ctx, "Germany"); err != nil || response.Count != processor.CountryTopCustomersLimit {
+		t.Errorf("Expected the top Germany customers, got %v", err)
+	}
+}
+
+func TestClientAPIErrors(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	_, err := client.GetCountryRevenues(ctx, models.CountryRevenueQuery{SortBy: "bogus", Page: -1})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected an *APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || len(apiErr.Errors) != 2 {
+		t.Errorf("Expected a 400 with two field errors, got %d %+v", apiErr.StatusCode, apiErr.Errors)
+	}
+
+	if _, err := client.GetRegionProducts(ctx, "Atlantis", 0); !IsNotFound(err) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestClientHonorsContext(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slow.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := New(slow.URL, nil).GetDashboard(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context deadline to end the request, got %v", err)
+	}
+}
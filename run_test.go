@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// testServer serves handler on a loopback listener opened up front, so the
+// test knows the address before run calls ListenAndServe
+type testServer struct {
+	server   *http.Server
+	listener net.Listener
+}
+
+func newTestServer(t *testing.T, handler http.Handler) *testServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := &testServer{server: &http.Server{Handler: handler}, listener: listener}
+	t.Cleanup(func() { server.server.Close() })
+	return server
+}
+
+func (s *testServer) ListenAndServe() error {
+	return s.server.Serve(s.listener)
+}
+
+func (s *testServer) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+func (s *testServer) url() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+// startRun calls run in the background and returns a channel with its result
+func startRun(srv httpServer, sig <-chan os.Signal, handlers signalHandlers, timeout time.Duration) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		result <- run(srv, sig, handlers, timeout)
+	}()
+	return result
+}
+
+func waitForRun(t *testing.T, result <-chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not return")
+		return nil
+	}
+}
+
+func TestRunShutsDownCleanly(t *testing.T) {
+	srv := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	sig := make(chan os.Signal, 1)
+	reloads, configReloads := 0, 0
+	handlers := signalHandlers{
+		reload:       func() { reloads++ },
+		reloadConfig: func() { configReloads++ },
+	}
+	result := startRun(srv, sig, handlers, time.Second)
+
+	resp, err := http.Get(srv.url())
+	if err != nil {
+		t.Fatalf("Expected the server to answer, got %v", err)
+	}
+	resp.Body.Close()
+
+	sig <- syscall.SIGHUP
+	sig <- syscall.SIGUSR1
+	sig <- syscall.SIGTERM
+	if err := waitForRun(t, result); err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+	if reloads != 1 || configReloads != 1 {
+		t.Errorf("Expected one dataset and one config reload, got %d and %d", reloads, configReloads)
+	}
+}
+
+func TestRunShutdownTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	// The handler hangs until the test ends, well past the shutdown timeout
+	srv := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	sig := make(chan os.Signal, 1)
+	result := startRun(srv, sig, signalHandlers{}, 50*time.Millisecond)
+
+	go func() {
+		if resp, err := http.Get(srv.url()); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	start := time.Now()
+	sig <- syscall.SIGINT
+	err := waitForRun(t, result)
+	if !errors.Is(err, errShutdownTimeout) {
+		t.Fatalf("Expected errShutdownTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected run to give up after the timeout, took %v", elapsed)
+	}
+	if code := exitCode(err); code != exitShutdownTimeout {
+		t.Errorf("Expected exit code %d, got %d", exitShutdownTimeout, code)
+	}
+}
+
+func TestRunListenFailure(t *testing.T) {
+	srv := newTestServer(t, http.NotFoundHandler())
+	srv.listener.Close()
+
+	err := waitForRun(t, startRun(srv, make(chan os.Signal), signalHandlers{}, time.Second))
+	if err == nil || errors.Is(err, errShutdownTimeout) {
+		t.Fatalf("Expected a serve error, got %v", err)
+	}
+	if code := exitCode(err); code != exitServeFailed {
+		t.Errorf("Expected exit code %d, got %d", exitServeFailed, code)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// shutdownSignals, reloadSignals and configSignals are the signals main
+// listens for
+var (
+	shutdownSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT}
+	reloadSignals   = []os.Signal{syscall.SIGHUP}
+	configSignals   = []os.Signal{syscall.SIGUSR1}
+)
+
+// signalHandlers are the actions dispatchSignal can take
+type signalHandlers struct {
+	reload       func()
+	reloadConfig func()
+	shutdown     func()
+}
+
+// dispatchSignal routes sig to its handler: SIGHUP reloads the dataset,
+// SIGUSR1 reloads the configuration and SIGINT, SIGTERM and SIGQUIT shut the
+// server down. It reports whether the
+// caller should stop listening for signals.
+func dispatchSignal(sig os.Signal, handlers signalHandlers) bool {
+	for _, candidate := range reloadSignals {
+		if sig == candidate {
+			handlers.reload()
+			return false
+		}
+	}
+	for _, candidate := range configSignals {
+		if sig == candidate {
+			handlers.reloadConfig()
+			return false
+		}
+	}
+	for _, candidate := range shutdownSignals {
+		if sig == candidate {
+			handlers.shutdown()
+			return true
+		}
+	}
+	return false
+}
+
+// reloadQueue runs reloads one at a time. Requests made while a reload is
+// running are coalesced into a single follow-up reload, so a burst of
+// SIGHUPs never queues more than one extra run.
+type reloadQueue struct {
+	requests chan struct{}
+}
+
+// newReloadQueue starts a goroutine that calls reload for queued requests
+func newReloadQueue(reload func()) *reloadQueue {
+	q := &reloadQueue{requests: make(chan struct{}, 1)}
+	go func() {
+		for range q.requests {
+			reload()
+		}
+	}()
+	return q
+}
+
+// trigger requests a reload without blocking. It is a no-op when a reload
+// is already pending.
+func (q *reloadQueue) trigger() {
+	select {
+	case q.requests <- struct{}{}:
+	default:
+	}
+}
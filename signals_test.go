@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDispatchSignal(t *testing.T) {
+	testCases := []struct {
+		sig              os.Signal
+		wantReload       int
+		wantReloadConfig int
+		wantShutdown     int
+		wantStop         bool
+	}{
+		{syscall.SIGHUP, 1, 0, 0, false},
+		{syscall.SIGUSR1, 0, 1, 0, false},
+		{syscall.SIGINT, 0, 0, 1, true},
+		{syscall.SIGTERM, 0, 0, 1, true},
+		{syscall.SIGQUIT, 0, 0, 1, true},
+		{syscall.SIGUSR2, 0, 0, 0, false},
+	}
+
+	for _, tc := range testCases {
+		reloads, configReloads, shutdowns := 0, 0, 0
+		handlers := signalHandlers{
+			reload:       func() { reloads++ },
+			reloadConfig: func() { configReloads++ },
+			shutdown:     func() { shutdowns++ },
+		}
+
+		stop := dispatchSignal(tc.sig, handlers)
+		if stop != tc.wantStop {
+			t.Errorf("%v: expected stop=%v, got %v", tc.sig, tc.wantStop, stop)
+		}
+		if reloads != tc.wantReload || configReloads != tc.wantReloadConfig || shutdowns != tc.wantShutdown {
+			t.Errorf("%v: expected %d reloads, %d config reloads and %d shutdowns, got %d, %d and %d",
+				tc.sig, tc.wantReload, tc.wantReloadConfig, tc.wantShutdown, reloads, configReloads, shutdowns)
+		}
+	}
+}
+
+func TestReloadQueueCoalescesRequests(t *testing.T) {
+	var runs atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	queue := newReloadQueue(func() {
+		defer wg.Done()
+		if runs.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+	})
+
+	queue.trigger()
+	<-started
+
+	// Requests made while the first reload runs collapse into one
+	for i := 0; i < 5; i++ {
+		queue.trigger()
+	}
+	close(release)
+	wg.Wait()
+
+	// Give any wrongly queued extra reload a chance to run
+	time.Sleep(50 * time.Millisecond)
+	if got := runs.Load(); got != 2 {
+		t.Errorf("Expected 2 reloads, got %d", got)
+	}
+}